@@ -5,6 +5,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
 
@@ -13,12 +14,20 @@ import (
 	"goki.dev/gi/v2/giv"
 	"goki.dev/girl/styles"
 	"goki.dev/goosi/events"
+	"goki.dev/ki/v2"
 	"goki.dev/mat32/v2"
 )
 
+var (
+	depth   = flag.Int("depth", 3, "depth of the tree -- 10 makes a big tree of about a million nodes")
+	virtual = flag.Bool("virtual", true, "only make TreeViews for the rows in view, reusing them as it scrolls -- much faster for a big tree")
+)
+
 func main() { gimain.Run(app) }
 
-func MakeTree(tv *giv.TreeView, iter, maxIter, maxKids int) {
+// MakeTree adds a random number of children, up to maxKids,
+// to given node, recursively, to a depth of maxIter
+func MakeTree(k ki.Ki, iter, maxIter, maxKids int) {
 	if iter > maxIter {
 		return
 	}
@@ -27,12 +36,10 @@ func MakeTree(tv *giv.TreeView, iter, maxIter, maxKids int) {
 		n = maxKids
 	}
 	iter++
-	parnm := tv.Name() + "_"
-	tv.SetNChildren(n, giv.TreeViewType, parnm+"ch")
+	parnm := k.Name() + "_"
+	k.SetNChildren(n, ki.NodeType, parnm+"ch")
 	for j := 0; j < n; j++ {
-		kt := tv.Child(j).(*giv.TreeView)
-		kt.RootView = tv.RootView
-		MakeTree(kt, iter, maxIter, maxKids)
+		MakeTree(k.Child(j), iter, maxIter, maxKids)
 	}
 }
 
@@ -41,6 +48,8 @@ func app() {
 	// gi.RenderTrace = true
 	// gi.LayoutTrace = true
 
+	flag.Parse()
+
 	gi.SetAppName("treeview")
 	gi.SetAppAbout(`This is a demo of the treeview in the <b>GoGi</b> graphical interface system, within the <b>GoKi</b> tree framework.  See <a href="https://github.com/goki">GoKi on GitHub</a>
 <p>Full Drag-and-Drop, Copy / Cut / Paste, and Keyboard Navigation is supported.</p>`)
@@ -58,25 +67,28 @@ func app() {
 		s.SetStretchMax()
 	})
 
-	tv := giv.NewTreeView(tvfr, "tv")
-	tv.RootView = tv
-
-	depth := 3 // 1 = small tree for testing
-	// depth := 10 // big tree
-	MakeTree(tv, 0, depth, 5)
+	src := ki.NewRoot[*ki.Node]("tree")
+	MakeTree(src, 0, *depth, 5)
+	nnodes := 0
+	src.WalkPre(func(k ki.Ki) bool {
+		nnodes++
+		return ki.Continue
+	})
+	fmt.Println("N nodes:", nnodes)
 
-	nleaves := tv.RootSetViewIdx()
-	fmt.Println("N leaves:", nleaves)
+	tv := giv.NewTreeView(tvfr, "tv")
+	tv.SetVirtual(*virtual)
+	tv.SyncRootNode(src)
 
 	sv := giv.NewStructView(svfr, "sv")
 	sv.Style(func(s *styles.Style) {
 		s.SetStretchMax()
 	})
-	sv.SetStruct(tv)
+	sv.SetStruct(src)
 
 	tv.OnSelect(func(e events.Event) {
-		if len(tv.SelectedNodes) > 0 {
-			sv.SetStruct(tv.SelectedNodes[0])
+		if sel := tv.SelectedSyncNodes(); len(sel) > 0 {
+			sv.SetStruct(sel[0])
 		}
 	})
 
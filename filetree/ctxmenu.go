@@ -84,6 +84,21 @@ var TreeActiveInVcsModifiedFunc = ActionUpdateFunc(func(fni any, act *gi.Button)
 	}
 })
 
+// TreeActiveInVcsConflictedFunc is an ActionUpdateFunc that activates action if node is under version control
+// and the file has merge conflicts
+var TreeActiveInVcsConflictedFunc = ActionUpdateFunc(func(fni any, act *gi.Button) {
+	ftv := AsNode(fni.(ki.Ki))
+	fn := ftv.Node()
+	if fn != nil {
+		repo, _ := fn.Repo()
+		if repo == nil || fn.IsDir() {
+			act.SetEnabledState((false))
+			return
+		}
+		act.SetEnabledState((fn.Info.Vcs == vci.Conflicted))
+	}
+})
+
 // VcsGetRemoveLabelFunc gets the appropriate label for removing from version control
 var VcsLabelFunc = LabelFunc(func(fni any, act *gi.Button) string {
 	ftv := AsNode(fni.(ki.Ki))
@@ -182,6 +197,11 @@ var NodeProps = ki.Props{
 			"updtfunc":   TreeActiveInVcsModifiedFunc,
 			"label-func": VcsLabelFunc,
 		}},
+		{"ResolveConflictsVcs", ki.Props{
+			"label":    "Resolve Conflicts",
+			"desc":     "Resolve the merge conflicts in file, taking lines from either or both of the conflicting versions",
+			"updtfunc": TreeActiveInVcsConflictedFunc,
+		}},
 		{"sep-vcs-log", ki.BlankProp{}},
 		{"DiffVcs", ki.Props{
 			"desc":       "shows the diffs between two versions of this file, given by the revision specifiers -- if empty, defaults to A = current HEAD, B = current WC file.   -1, -2 etc also work as universal ways of specifying prior revisions.",
@@ -117,6 +117,12 @@ func (t *Node) SetOpenDepth(v int) *Node {
 	return t
 }
 
+// SetVirtual sets the [Node.Virtual]
+func (t *Node) SetVirtual(v bool) *Node {
+	t.Virtual = v
+	return t
+}
+
 // SetViewIdx sets the [Node.ViewIdx]
 func (t *Node) SetViewIdx(v int) *Node {
 	t.ViewIdx = v
@@ -141,6 +147,30 @@ func (t *Node) SetSelectedNodes(v []*giv.TreeView) *Node {
 	return t
 }
 
+// SetVisNodes sets the [Node.VisNodes]
+func (t *Node) SetVisNodes(v []ki.Ki) *Node {
+	t.VisNodes = v
+	return t
+}
+
+// SetRowHeight sets the [Node.RowHeight]
+func (t *Node) SetRowHeight(v float32) *Node {
+	t.RowHeight = v
+	return t
+}
+
+// SetStartIdx sets the [Node.StartIdx]
+func (t *Node) SetStartIdx(v int) *Node {
+	t.StartIdx = v
+	return t
+}
+
+// SetVisRows sets the [Node.VisRows]
+func (t *Node) SetVisRows(v int) *Node {
+	t.VisRows = v
+	return t
+}
+
 // TreeType is the [gti.Type] for [Tree]
 var TreeType = gti.AddType(&gti.Type{
 	Name:       "goki.dev/gi/v2/filetree.Tree",
@@ -301,6 +331,12 @@ func (t *Tree) SetOpenDepth(v int) *Tree {
 	return t
 }
 
+// SetVirtual sets the [Tree.Virtual]
+func (t *Tree) SetVirtual(v bool) *Tree {
+	t.Virtual = v
+	return t
+}
+
 // SetViewIdx sets the [Tree.ViewIdx]
 func (t *Tree) SetViewIdx(v int) *Tree {
 	t.ViewIdx = v
@@ -324,3 +360,27 @@ func (t *Tree) SetSelectedNodes(v []*giv.TreeView) *Tree {
 	t.SelectedNodes = v
 	return t
 }
+
+// SetVisNodes sets the [Tree.VisNodes]
+func (t *Tree) SetVisNodes(v []ki.Ki) *Tree {
+	t.VisNodes = v
+	return t
+}
+
+// SetRowHeight sets the [Tree.RowHeight]
+func (t *Tree) SetRowHeight(v float32) *Tree {
+	t.RowHeight = v
+	return t
+}
+
+// SetStartIdx sets the [Tree.StartIdx]
+func (t *Tree) SetStartIdx(v int) *Tree {
+	t.StartIdx = v
+	return t
+}
+
+// SetVisRows sets the [Tree.VisRows]
+func (t *Tree) SetVisRows(v int) *Tree {
+	t.VisRows = v
+	return t
+}
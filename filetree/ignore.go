@@ -0,0 +1,166 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filetree
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the files holding the ignore patterns
+// for files within their directory, in the standard git format
+var IgnoreFileName = ".gitignore"
+
+// ignoreRule is one pattern line from an ignore file
+type ignoreRule struct {
+
+	// directory of the ignore file the rule is from, relative to the root,
+	// in slash form, "" for the root itself
+	base string
+
+	// pattern split into path elements, where "**" matches any number of them
+	elems []string
+
+	// if true, the pattern re-includes the files it matches (leading !)
+	negate bool
+
+	// if true, the pattern only matches directories (trailing /)
+	dirOnly bool
+
+	// if true, the pattern is matched against the full path relative to base,
+	// otherwise it matches the name at any depth
+	anchored bool
+}
+
+// Ignore holds the patterns of the .gitignore files in a directory tree,
+// for deciding which files are ignored.  Files are added as the tree is
+// walked, from the root down, so that the patterns in deeper directories
+// take precedence, as in git.  Any directory that is ignored should not be
+// walked, as git does not re-include files within an ignored directory.
+type Ignore struct {
+
+	// the patterns, in the order they were added
+	rules []ignoreRule
+}
+
+// AddFile adds the patterns from the ignore file in given directory,
+// which is relative to the root of the tree, with root the absolute path
+// of that root.  It is not an error for the file not to exist.
+func (ig *Ignore) AddFile(root, dir string) error {
+	b, err := os.ReadFile(filepath.Join(root, dir, IgnoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	ig.AddPatterns(dir, b)
+	return nil
+}
+
+// AddPatterns adds the patterns in the given ignore file contents, for the
+// ignore file in the given directory, which is relative to the root of the tree
+func (ig *Ignore) AddPatterns(dir string, data []byte) {
+	base := filepath.ToSlash(filepath.Clean(dir))
+	if base == "." {
+		base = ""
+	}
+	for _, ln := range bytes.Split(data, []byte("\n")) {
+		s := strings.TrimSuffix(string(ln), "\r")
+		if !strings.HasSuffix(s, `\ `) {
+			s = strings.TrimRight(s, " \t")
+		}
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		r := ignoreRule{base: base}
+		switch {
+		case s[0] == '!':
+			r.negate = true
+			s = s[1:]
+		case strings.HasPrefix(s, `\!`) || strings.HasPrefix(s, `\#`):
+			s = s[1:]
+		}
+		if strings.HasSuffix(s, "/") {
+			r.dirOnly = true
+			s = strings.TrimRight(s, "/")
+		}
+		if s == "" {
+			continue
+		}
+		if strings.Contains(s, "/") {
+			r.anchored = true
+			s = strings.TrimPrefix(s, "/")
+		}
+		r.elems = strings.Split(s, "/")
+		ig.rules = append(ig.rules, r)
+	}
+}
+
+// Ignored returns true if the file at the given path, relative to the root
+// of the tree, is ignored according to the patterns; isDir is whether it is
+// a directory.  Only the patterns of the ignore files in the directories
+// containing the file apply, and the last one that matches decides.
+func (ig *Ignore) Ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	ign := false
+	for i := range ig.rules {
+		r := &ig.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		if r.match(sub) {
+			ign = !r.negate
+		}
+	}
+	return ign
+}
+
+// match returns true if the rule matches the given path relative to its base
+func (r *ignoreRule) match(sub string) bool {
+	if !r.anchored {
+		name := path.Base(sub)
+		ok, _ := path.Match(r.elems[0], name)
+		return ok
+	}
+	return matchElems(r.elems, strings.Split(sub, "/"))
+}
+
+// matchElems matches pattern path elements against path elements,
+// with "**" matching zero or more elements
+func matchElems(pat, elems []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			pat = pat[1:]
+			if len(pat) == 0 {
+				return len(elems) > 0
+			}
+			for i := range elems {
+				if matchElems(pat, elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], elems[0]); !ok {
+			return false
+		}
+		pat, elems = pat[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
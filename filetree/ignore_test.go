@@ -0,0 +1,82 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filetree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	ig := &Ignore{}
+	ig.AddPatterns(".", []byte(`# a comment
+*.log
+!keep.log
+build/
+/bin
+a/b
+docs/**/*.tmp
+logs/**
+\#hash
+trailing   
+`))
+	ig.AddPatterns("sub", []byte("!*.log\nlocal.txt\n"))
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"# a comment", false, false},
+		{"a.log", false, true},
+		{"x/y/a.log", false, true},
+		{"keep.log", false, false},
+		{"x/keep.log", false, false},
+		{"build", true, true},
+		{"x/build", true, true},
+		{"build", false, false},
+		{"bin", false, true},
+		{"x/bin", false, false},
+		{"a/b", false, true},
+		{"x/a/b", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"a.tmp", false, false},
+		{"logs", true, false},
+		{"logs/x", false, true},
+		{"#hash", false, true},
+		{"trailing", false, true},
+		{"sub/a.log", false, false},
+		{"sub/local.txt", false, true},
+		{"local.txt", false, false},
+		{"x/sub/local.txt", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreAddFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", IgnoreFileName), []byte("*.o\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ig := &Ignore{}
+	if err := ig.AddFile(root, "."); err != nil {
+		t.Errorf("AddFile with no ignore file: %v", err)
+	}
+	if err := ig.AddFile(root, "sub"); err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Ignored(filepath.Join("sub", "x.o"), false) || ig.Ignored("x.o", false) {
+		t.Errorf("patterns from sub/%s are not applied to just that directory", IgnoreFileName)
+	}
+}
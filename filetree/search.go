@@ -0,0 +1,558 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filetree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"goki.dev/gi/v2/texteditor"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/ki/v2"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/vci/v2"
+)
+
+// SearchOpts are the options for a project-wide Search
+type SearchOpts struct {
+
+	// the text to find, or regular expression if Regexp is set
+	Find string
+
+	// if true, Find is a regular expression, and the replacement text can refer to its submatches as $1 etc
+	Regexp bool
+
+	// if true, the case of letters is ignored
+	IgnoreCase bool
+
+	// if non-empty, only files with these extensions (e.g., .go) are searched
+	Exts []string
+
+	// if true, files that are not tracked by the version control system are skipped
+	TrackedOnly bool
+
+	// if true, the files ignored by the .gitignore files are searched too
+	NoIgnore bool
+
+	// number of files to search at the same time -- 0 = number of CPUs
+	Workers int
+
+	// files larger than this number of bytes are skipped -- 0 = 10 MB
+	MaxSize int64
+}
+
+// SearchResults are the matches found in one file by a Search
+type SearchResults struct {
+
+	// full path to the file
+	Path string
+
+	// path of the file relative to the root of the tree
+	RelPath string
+
+	// buffer for the file if it is open, which was searched instead of the file on disk
+	Buf *texteditor.Buf
+
+	// number of matches
+	Count int
+
+	// the matches, with column positions in runes
+	Matches []textbuf.Match
+}
+
+// SearchBackupSuffix is added to the name of a file that is not open
+// to save a backup of it before replacing text in it
+var SearchBackupSuffix = "~"
+
+// Search is a project-wide search and replace of the files in a Tree.
+// The files on disk are walked, including those in directories that are
+// not open in the tree, skipping those ignored by the .gitignore files,
+// and optionally those not tracked by version control.  Files are searched
+// concurrently by a bounded pool of workers, which send their results back
+// to Run, where they are added to the output Buf as they are found, with
+// a link to each match.
+// Open buffers are searched instead of their files on disk, and
+// replacing text in them can be undone; other files are backed up
+// before they are changed.  A Run or ReplaceAll in progress is
+// stopped by Cancel.
+type Search struct {
+
+	// the tree of files to search
+	Tree *Tree
+
+	// search options
+	Opts SearchOpts
+
+	// results for each file with matches, in the order they were found
+	Results []*SearchResults
+
+	// if non-nil, the results are written to this buffer as they are found, one line per match, with a link to the match
+	Buf *texteditor.Buf
+
+	// mutex protecting Results and the output Buf
+	Mu sync.Mutex
+
+	// compiled regexp for Find
+	re *regexp.Regexp
+
+	// function that cancels the current run
+	cancel context.CancelFunc
+}
+
+// NewSearch returns a new search of the files in given tree with given options,
+// writing the results to given buffer if non-nil
+func NewSearch(ft *Tree, opts SearchOpts, buf *texteditor.Buf) *Search {
+	return &Search{Tree: ft, Opts: opts, Buf: buf}
+}
+
+// Start runs the search in a separate goroutine, logging any error
+func (sr *Search) Start() {
+	go func() {
+		if err := sr.Run(context.Background()); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error(err.Error())
+		}
+	}()
+}
+
+// Cancel stops the current Run or ReplaceAll, if there is one
+func (sr *Search) Cancel() {
+	sr.Mu.Lock()
+	defer sr.Mu.Unlock()
+	if sr.cancel != nil {
+		sr.cancel()
+	}
+}
+
+// begin starts a new run, returning its context, after compiling the regexp
+func (sr *Search) begin(ctx context.Context) (context.Context, error) {
+	if sr.Opts.Find == "" {
+		return nil, fmt.Errorf("filetree.Search: nothing to find")
+	}
+	pat := sr.Opts.Find
+	if !sr.Opts.Regexp {
+		pat = regexp.QuoteMeta(pat)
+	}
+	if sr.Opts.IgnoreCase {
+		pat = "(?i)" + pat
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, fmt.Errorf("filetree.Search: %w", err)
+	}
+	sr.Mu.Lock()
+	defer sr.Mu.Unlock()
+	sr.re = re
+	ctx, sr.cancel = context.WithCancel(ctx)
+	return ctx, nil
+}
+
+// end finishes a run
+func (sr *Search) end() {
+	sr.Mu.Lock()
+	defer sr.Mu.Unlock()
+	if sr.cancel != nil {
+		sr.cancel()
+		sr.cancel = nil
+	}
+}
+
+// Run searches the files, replacing any previous Results, and returns
+// when all of them have been searched or the search is canceled,
+// in which case the error is context.Canceled
+func (sr *Search) Run(ctx context.Context) error {
+	ctx, err := sr.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer sr.end()
+	sr.Mu.Lock()
+	sr.Results = nil
+	if sr.Buf != nil {
+		sr.Buf.NewBuf(0)
+	}
+	sr.Mu.Unlock()
+
+	bufs := sr.OpenBufs()
+	nw := sr.Opts.Workers
+	if nw <= 0 {
+		nw = runtime.NumCPU()
+	}
+	paths := make(chan string, nw)
+	results := make(chan *SearchResults, nw)
+	var wg sync.WaitGroup
+	for i := 0; i < nw; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fp := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				if res := sr.SearchFile(fp, bufs[fp]); res != nil {
+					results <- res
+				}
+			}
+		}()
+	}
+	var walkErr error
+	go func() {
+		walkErr = sr.WalkFiles(ctx, func(fp string) {
+			select {
+			case paths <- fp:
+			case <-ctx.Done():
+			}
+		})
+		close(paths)
+		wg.Wait()
+		close(results)
+	}()
+	// the results are only added here, so the workers never touch the Buf
+	for res := range results {
+		sr.addResults(res)
+	}
+	err = walkErr
+	if err == nil {
+		err = ctx.Err()
+	}
+	sr.writeSummary(err)
+	return err
+}
+
+// OpenBufs returns the buffers of the files that are open in the tree, by path
+func (sr *Search) OpenBufs() map[string]*texteditor.Buf {
+	bufs := map[string]*texteditor.Buf{}
+	sr.Tree.WalkPre(func(k ki.Ki) bool {
+		fn := AsNode(k)
+		if fn != nil && fn.Buf != nil && !fn.IsDir() {
+			bufs[string(fn.FPath)] = fn.Buf
+		}
+		return ki.Continue
+	})
+	return bufs
+}
+
+// WalkFiles calls given function with the full path of each of the files
+// to be searched in the tree, according to the options
+func (sr *Search) WalkFiles(ctx context.Context, fun func(fp string)) error {
+	root := string(sr.Tree.FPath)
+	repo, rnode := sr.Tree.Repo()
+	ig := &Ignore{}
+	return filepath.WalkDir(root, func(fp string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil // ignore unreadable files
+		}
+		rel, _ := filepath.Rel(root, fp)
+		if d.IsDir() {
+			if fp != root && (d.Name() == ".git" || (!sr.Opts.NoIgnore && ig.Ignored(rel, true))) {
+				return filepath.SkipDir
+			}
+			if !sr.Opts.NoIgnore {
+				if err := ig.AddFile(root, rel); err != nil {
+					slog.Error("filetree.Search: " + err.Error())
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(sr.Opts.Exts) > 0 && !hasExt(fp, sr.Opts.Exts) {
+			return nil
+		}
+		if !sr.Opts.NoIgnore && ig.Ignored(rel, false) {
+			return nil
+		}
+		if sr.Opts.TrackedOnly && repo != nil && len(rnode.RepoFiles) > 0 && rnode.RepoFiles.Status(repo, fp) == vci.Untracked {
+			return nil
+		}
+		fun(fp)
+		return nil
+	})
+}
+
+// hasExt returns true if the given file has one of the given extensions
+func hasExt(fp string, exts []string) bool {
+	ext := filepath.Ext(fp)
+	for _, e := range exts {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchFile searches one file, in the given buffer if it is open,
+// returning nil if there are no matches.  Files that are too large,
+// or appear to be binary, are skipped.
+func (sr *Search) SearchFile(fp string, buf *texteditor.Buf) *SearchResults {
+	var cnt int
+	var matches []textbuf.Match
+	switch {
+	case buf != nil:
+		if sr.Opts.Regexp {
+			cnt, matches = buf.SearchRegexp(sr.re)
+		} else {
+			cnt, matches = buf.Search([]byte(sr.Opts.Find), sr.Opts.IgnoreCase, false)
+		}
+	default:
+		b, err := sr.readFile(fp)
+		if err != nil || b == nil {
+			return nil
+		}
+		if sr.Opts.Regexp {
+			cnt, matches = textbuf.SearchRegexp(bytes.NewReader(b), sr.re)
+		} else {
+			cnt, matches = textbuf.Search(bytes.NewReader(b), []byte(sr.Opts.Find), sr.Opts.IgnoreCase)
+		}
+	}
+	if cnt == 0 {
+		return nil
+	}
+	rel, _ := filepath.Rel(string(sr.Tree.FPath), fp)
+	return &SearchResults{Path: fp, RelPath: rel, Buf: buf, Count: cnt, Matches: matches}
+}
+
+// readFile returns the contents of the given file, or nil if it is too large
+// or appears to be binary, because it has a zero byte near the start
+func (sr *Search) readFile(fp string) ([]byte, error) {
+	maxSize := sr.Opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxSize {
+		return nil, nil
+	}
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(b[:min(len(b), 8000)], 0) >= 0 {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// addResults adds the results for one file, and writes them to the Buf
+func (sr *Search) addResults(res *SearchResults) {
+	sr.Mu.Lock()
+	defer sr.Mu.Unlock()
+	sr.Results = append(sr.Results, res)
+	if sr.Buf == nil {
+		return
+	}
+	var txt, mu bytes.Buffer
+	for _, m := range res.Matches {
+		loc := fmt.Sprintf("%s:%d:%d", res.RelPath, m.Reg.Start.Ln+1, m.Reg.Start.Ch+1)
+		url := FileLink(res.Path, m.Reg.Start)
+		pre, mt, post := splitMark(m.Text)
+		txt.WriteString(loc + ": ")
+		txt.Write(pre)
+		txt.Write(mt)
+		txt.Write(post)
+		txt.WriteByte('\n')
+		fmt.Fprintf(&mu, `<a href="%s">%s</a>: `, url, texteditor.HTMLEscapeBytes([]byte(loc)))
+		mu.Write(texteditor.HTMLEscapeBytes(pre))
+		mu.WriteString("<mark>")
+		mu.Write(texteditor.HTMLEscapeBytes(mt))
+		mu.WriteString("</mark>")
+		mu.Write(texteditor.HTMLEscapeBytes(post))
+		mu.WriteByte('\n')
+	}
+	sr.Buf.AppendTextMarkup(txt.Bytes(), mu.Bytes(), texteditor.EditSignal)
+}
+
+// splitMark splits the text of a match into the parts before,
+// within, and after the <mark> tags added by textbuf.NewMatch
+func splitMark(txt []byte) (pre, mt, post []byte) {
+	pre, rest, ok := bytes.Cut(txt, []byte("<mark>"))
+	if !ok {
+		return txt, nil, nil
+	}
+	mt, post, _ = bytes.Cut(rest, []byte("</mark>"))
+	return
+}
+
+// writeSummary writes the number of matches to the Buf, at the end of a Run
+func (sr *Search) writeSummary(err error) {
+	if sr.Buf == nil {
+		return
+	}
+	sr.Mu.Lock()
+	defer sr.Mu.Unlock()
+	n := 0
+	for _, res := range sr.Results {
+		n += res.Count
+	}
+	msg := fmt.Sprintf("%d matches in %d files for: %s", n, len(sr.Results), sr.Opts.Find)
+	switch {
+	case errors.Is(err, context.Canceled):
+		msg += " (canceled)"
+	case err != nil:
+		msg += " (error: " + err.Error() + ")"
+	}
+	sr.Buf.AppendTextLineMarkup([]byte(msg), []byte("<b>"+string(texteditor.HTMLEscapeBytes([]byte(msg)))+"</b>"), texteditor.EditSignal)
+}
+
+// FileLink returns the URL for a link to the given position in the given file,
+// as used in the search results, of the form file:///path#L12C5, with the
+// line and column starting at 1
+func FileLink(fp string, pos lex.Pos) string {
+	return fmt.Sprintf("file://%s#L%dC%d", filepath.ToSlash(fp), pos.Ln+1, pos.Ch+1)
+}
+
+// ParseFileLink returns the file path and position from a link made
+// by FileLink, and false if it is not such a link.  This can be used by
+// a paint.TextLinkHandler to open the file at the match.
+func ParseFileLink(url string) (string, lex.Pos, bool) {
+	fp, ok := strings.CutPrefix(url, "file://")
+	if !ok {
+		return "", lex.Pos{}, false
+	}
+	fp, loc, _ := strings.Cut(fp, "#L")
+	pos := lex.Pos{}
+	if loc != "" {
+		lns, chs, _ := strings.Cut(loc, "C")
+		ln, err := strconv.Atoi(lns)
+		if err != nil {
+			return "", lex.Pos{}, false
+		}
+		pos.Ln = max(ln-1, 0)
+		if ch, err := strconv.Atoi(chs); err == nil {
+			pos.Ch = max(ch-1, 0)
+		}
+	}
+	return filepath.FromSlash(fp), pos, true
+}
+
+// ReplaceAll replaces all of the matches for the search in the files of
+// the Results from the last Run with given replacement text, which
+// can refer to submatches as $1 etc for a Regexp search.  Open buffers
+// are changed in one undoable group of edits per buffer, and are not
+// saved; other files are first backed up to a file with
+// SearchBackupSuffix added.  Returns the number of replacements made.
+// The Results are not valid after this, so Run should be called again.
+func (sr *Search) ReplaceAll(ctx context.Context, repl string) (int, error) {
+	ctx, err := sr.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sr.end()
+	sr.Mu.Lock()
+	results := sr.Results
+	sr.Mu.Unlock()
+	bufs := sr.OpenBufs()
+	total := 0
+	var errs []error
+	for _, res := range results {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		buf := res.Buf
+		if buf == nil {
+			buf = bufs[res.Path] // opened since the search
+		}
+		if buf != nil {
+			total += sr.replaceBuf(buf, repl)
+			continue
+		}
+		n, err := sr.replaceFile(res.Path, repl)
+		total += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// replaceLine returns the given line with all the matches replaced by
+// repl, and the number of them
+func (sr *Search) replaceLine(ln []byte, repl string) ([]byte, int) {
+	ms := sr.re.FindAllSubmatchIndex(ln, -1)
+	if len(ms) == 0 {
+		return ln, 0
+	}
+	var out []byte
+	last := 0
+	for _, m := range ms {
+		out = append(out, ln[last:m[0]]...)
+		if sr.Opts.Regexp {
+			out = sr.re.Expand(out, []byte(repl), ln, m)
+		} else {
+			out = append(out, repl...)
+		}
+		last = m[1]
+	}
+	return append(out, ln[last:]...), len(ms)
+}
+
+// replaceBuf replaces the matches in given open buffer, as one group of edits
+func (sr *Search) replaceBuf(tb *texteditor.Buf, repl string) int {
+	autoSave := tb.BatchUpdateStart()
+	defer tb.BatchUpdateEnd(autoSave)
+	tb.Undos.Mu.Lock()
+	upos := tb.Undos.Pos
+	tb.Undos.Mu.Unlock()
+	total := 0
+	for ln := 0; ln < tb.NumLines(); ln++ {
+		nw, n := sr.replaceLine(tb.BytesLine(ln), repl)
+		if n == 0 {
+			continue
+		}
+		tb.ReplaceText(lex.Pos{Ln: ln}, lex.Pos{Ln: ln, Ch: tb.LineLen(ln)}, lex.Pos{Ln: ln}, string(nw), texteditor.EditSignal, texteditor.ReplaceNoMatchCase)
+		ln += bytes.Count(nw, []byte("\n")) // skip any lines added by the replacement
+		total += n
+	}
+	tb.Undos.SetGroupFrom(upos)
+	return total
+}
+
+// replaceFile replaces the matches in given file on disk, after
+// saving a backup of it
+func (sr *Search) replaceFile(fp, repl string) (int, error) {
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return 0, err
+	}
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return 0, err
+	}
+	lns := bytes.Split(b, []byte("\n"))
+	total := 0
+	for i, ln := range lns {
+		nw, n := sr.replaceLine(ln, repl)
+		lns[i] = nw
+		total += n
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	if err := os.WriteFile(fp+SearchBackupSuffix, b, fi.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("filetree.Search: could not back up file: %w", err)
+	}
+	if err := os.WriteFile(fp, bytes.Join(lns, []byte("\n")), fi.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
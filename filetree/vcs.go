@@ -17,6 +17,7 @@ import (
 	"goki.dev/gi/v2/texteditor/textbuf"
 	"goki.dev/girl/styles"
 	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
 	"goki.dev/ki/v2"
 	"goki.dev/vci/v2"
 )
@@ -253,6 +254,76 @@ func (fn *Node) DiffVcs(rev_a, rev_b string) error {
 	return nil
 }
 
+// ResolveConflictsVcsSel opens a MergeView for resolving the merge
+// conflicts in each of the selected files.
+func (fn *Node) ResolveConflictsVcsSel() {
+	sels := fn.SelectedViews()
+	n := len(sels)
+	for i := n - 1; i >= 0; i-- {
+		sn := AsNode(sels[i].This())
+		sn.ResolveConflictsVcs()
+	}
+}
+
+// ResolveConflictsVcs opens a MergeView for resolving the merge conflicts
+// in this file, where the A version is ours and the B version is theirs.
+// For git, the versions are those staged by the merge (see MergeVcsStages),
+// and otherwise they are parsed from the conflict markers in the file.
+// The file buffer is reverted to the resolved result when it is saved.
+func (fn *Node) ResolveConflictsVcs() (*texteditor.MergeView, error) {
+	repo, _ := fn.Repo()
+	if repo == nil {
+		return nil, errors.New("file not in vcs repo: " + string(fn.FPath))
+	}
+	if fn.Info.Vcs != vci.Conflicted {
+		return nil, errors.New("file does not have conflicts: " + string(fn.FPath))
+	}
+	var mv *texteditor.MergeView
+	if _, isGit := repo.(*vci.GitRepo); isGit {
+		mg, err := MergeVcsStages(repo, string(fn.FPath))
+		if err != nil {
+			return nil, err
+		}
+		mv = texteditor.MergeViewDialog(fn.This().(gi.Widget), mg, string(fn.FPath))
+	} else {
+		var err error
+		mv, err = texteditor.MergeFile(fn.This().(gi.Widget), string(fn.FPath))
+		if err != nil {
+			return nil, err
+		}
+	}
+	mv.OnChange(func(e events.Event) {
+		if fn.Buf != nil {
+			fn.Buf.Revert()
+		}
+	})
+	return mv, nil
+}
+
+// MergeVcsStages returns a three-way merge of the versions of given
+// conflicted file that are staged by a git merge: the common base
+// (stage 1), ours (stage 2) as the A version, and theirs (stage 3) as
+// the B version, merged with textbuf.Merge3.  The base is empty if the
+// file was added in both (there is no stage 1).
+func MergeVcsStages(repo vci.Repo, fname string) (*textbuf.Merge, error) {
+	var lns [3][]string
+	for i, stage := range []string{":1", ":2", ":3"} {
+		fb, err := repo.FileContents(fname, stage)
+		if err != nil {
+			if i == 0 {
+				continue // no base
+			}
+			return nil, fmt.Errorf("could not get merge stage %s of %s: %w", stage[1:], fname, err)
+		}
+		lns[i] = textbuf.BytesToLineStrings(fb, false) // don't add new lines
+	}
+	mg := textbuf.Merge3(lns[0], lns[1], lns[2])
+	mg.LabelA = "ours"
+	mg.LabelB = "theirs"
+	mg.Update() // update conflict markers for labels
+	return mg, nil
+}
+
 // LogVcsSel shows the VCS log of commits for selected files, optionally with a
 // since date qualifier: If since is non-empty, it should be
 // a date-like expression that the VCS will understand, such as
@@ -0,0 +1,79 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filetree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/vci/v2"
+)
+
+func TestMergeVcsStages(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "a.txt")
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil && args[0] != "merge" {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(text string) {
+		t.Helper()
+		if err := os.WriteFile(fname, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("commit", "-q", "-a", "-m", text)
+	}
+	git("init", "-q", "-b", "main")
+	git("remote", "add", "origin", "origin") // as passed to vci.NewRepo by DetectVcsRepo
+	os.WriteFile(fname, []byte("1\n2\n3\n"), 0644)
+	git("add", "a.txt")
+	git("commit", "-q", "-m", "base")
+	git("checkout", "-q", "-b", "other")
+	commit("1\nb\n3\n")
+	git("checkout", "-q", "main")
+	commit("1\na\n3\n")
+	git("merge", "-q", "other") // fails with the conflict
+
+	repo, err := vci.NewRepo("origin", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg, err := MergeVcsStages(repo, fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		nm        string
+		got, want []string
+	}{
+		{"Base", mg.Base, []string{"1", "2", "3", ""}},
+		{"A", mg.A, []string{"1", "a", "3", ""}},
+		{"B", mg.B, []string{"1", "b", "3", ""}},
+		{"Lines", mg.Lines, []string{"1", textbuf.MergeMarkerA + " ours", "a", textbuf.MergeMarkerSep, "b", textbuf.MergeMarkerB + " theirs", "3", ""}},
+	} {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.nm, tt.got, tt.want)
+		}
+	}
+	if n, un := mg.NumConflicts(); n != 1 || un != 1 {
+		t.Errorf("conflicts = %d (%d unresolved), want 1", n, un)
+	}
+
+	// a file that is not in the merge has no stages
+	if _, err := MergeVcsStages(repo, filepath.Join(dir, "b.txt")); err == nil {
+		t.Errorf("no error for a file with no stages")
+	}
+}
@@ -1158,6 +1158,7 @@ var _ = gti.AddType(&gti.Type{
 		{"User", &gti.Field{Name: "User", Type: "goki.dev/gi/v2/gi.User", LocalType: "User", Doc: "user info -- partially filled-out automatically if empty / when prefs first created", Directives: gti.Directives{}, Tag: ""}},
 		{"FavPaths", &gti.Field{Name: "FavPaths", Type: "goki.dev/gi/v2/gi.FavPaths", LocalType: "FavPaths", Doc: "favorite paths, shown in FileViewer and also editable there", Directives: gti.Directives{}, Tag: ""}},
 		{"FileViewSort", &gti.Field{Name: "FileViewSort", Type: "string", LocalType: "string", Doc: "column to sort by in FileView, and :up or :down for direction -- updated automatically via FileView", Directives: gti.Directives{}, Tag: "view:\"-\""}},
+		{"TableViewLayouts", &gti.Field{Name: "TableViewLayouts", Type: "map[string]*goki.dev/gi/v2/gi.TableViewLayout", LocalType: "map[string]*TableViewLayout", Doc: "layouts of the columns of TableViews, by the name of the struct type of their rows -- updated automatically via TableView", Directives: gti.Directives{}, Tag: "view:\"-\""}},
 		{"ColorFilename", &gti.Field{Name: "ColorFilename", Type: "goki.dev/gi/v2/gi.FileName", LocalType: "FileName", Doc: "filename for saving / loading colors", Directives: gti.Directives{}, Tag: "view:\"-\" ext:\".json\""}},
 		{"Changed", &gti.Field{Name: "Changed", Type: "bool", LocalType: "bool", Doc: "flag that is set by StructView by virtue of changeflag tag, whenever an edit is made.  Used to drive save menus etc.", Directives: gti.Directives{}, Tag: "view:\"-\" changeflag:\"+\" json:\"-\" xml:\"-\""}},
 	}),
@@ -1286,12 +1287,19 @@ var _ = gti.AddType(&gti.Type{
 		{"TabSize", &gti.Field{Name: "TabSize", Type: "int", LocalType: "int", Doc: "size of a tab, in chars -- also determines indent level for space indent", Directives: gti.Directives{}, Tag: "xml:\"tab-size\""}},
 		{"SpaceIndent", &gti.Field{Name: "SpaceIndent", Type: "bool", LocalType: "bool", Doc: "use spaces for indentation, otherwise tabs", Directives: gti.Directives{}, Tag: "xml:\"space-indent\""}},
 		{"WordWrap", &gti.Field{Name: "WordWrap", Type: "bool", LocalType: "bool", Doc: "wrap lines at word boundaries -- otherwise long lines scroll off the end", Directives: gti.Directives{}, Tag: "xml:\"word-wrap\""}},
+		{"WrapAtColumn", &gti.Field{Name: "WrapAtColumn", Type: "bool", LocalType: "bool", Doc: "with WordWrap, wrap lines at the WrapColumn, or at the width of the\neditor if that is less, instead of just at the width of the editor", Directives: gti.Directives{}, Tag: "xml:\"wrap-at-column\""}},
+		{"WrapColumn", &gti.Field{Name: "WrapColumn", Type: "int", LocalType: "int", Doc: "column at which lines are wrapped when WrapAtColumn is on,\nand to which paragraphs are reflowed", Directives: gti.Directives{}, Tag: "xml:\"wrap-column\""}},
+		{"HangingIndent", &gti.Field{Name: "HangingIndent", Type: "bool", LocalType: "bool", Doc: "indent the continuation lines of a wrapped line to the indentation of the line", Directives: gti.Directives{}, Tag: "xml:\"hanging-indent\""}},
+		{"Rulers", &gti.Field{Name: "Rulers", Type: "[]int", LocalType: "[]int", Doc: "columns at which vertical ruler lines are drawn, e.g., 80, 100, 120", Directives: gti.Directives{}, Tag: "xml:\"rulers\""}},
 		{"LineNos", &gti.Field{Name: "LineNos", Type: "bool", LocalType: "bool", Doc: "show line numbers", Directives: gti.Directives{}, Tag: "xml:\"line-nos\""}},
 		{"Completion", &gti.Field{Name: "Completion", Type: "bool", LocalType: "bool", Doc: "use the completion system to suggest options while typing", Directives: gti.Directives{}, Tag: "xml:\"completion\""}},
 		{"SpellCorrect", &gti.Field{Name: "SpellCorrect", Type: "bool", LocalType: "bool", Doc: "suggest corrections for unknown words while typing", Directives: gti.Directives{}, Tag: "xml:\"spell-correct\""}},
 		{"AutoIndent", &gti.Field{Name: "AutoIndent", Type: "bool", LocalType: "bool", Doc: "automatically indent lines when enter, tab, }, etc pressed", Directives: gti.Directives{}, Tag: "xml:\"auto-indent\""}},
 		{"EmacsUndo", &gti.Field{Name: "EmacsUndo", Type: "bool", LocalType: "bool", Doc: "use emacs-style undo, where after a non-undo command, all the current undo actions are added to the undo stack, such that a subsequent undo is actually a redo", Directives: gti.Directives{}, Tag: "xml:\"emacs-undo\""}},
 		{"DepthColor", &gti.Field{Name: "DepthColor", Type: "bool", LocalType: "bool", Doc: "colorize the background according to nesting depth", Directives: gti.Directives{}, Tag: "xml:\"depth-color\""}},
+		{"RainbowBrackets", &gti.Field{Name: "RainbowBrackets", Type: "bool", LocalType: "bool", Doc: "color brackets according to their nesting depth, using colors from the highlighting style", Directives: gti.Directives{}, Tag: "xml:\"rainbow-brackets\""}},
+		{"IndentGuides", &gti.Field{Name: "IndentGuides", Type: "bool", LocalType: "bool", Doc: "show vertical lines at each level of indentation, highlighting the one for the block containing the cursor", Directives: gti.Directives{}, Tag: "xml:\"indent-guides\""}},
+		{"Macros", &gti.Field{Name: "Macros", Type: "map[string]goki.dev/gi/v2/gi.KeyMacro", LocalType: "map[string]KeyMacro", Doc: "named keyboard macros, saved from the last macro recorded in an editor, for replaying by name", Directives: gti.Directives{}, Tag: "xml:\"macros\""}},
 	}),
 	Embeds:  ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{}),
 	Methods: ordmap.Make([]ordmap.KeyVal[string, *gti.Method]{}),
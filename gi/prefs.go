@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"goki.dev/colors"
@@ -19,6 +20,7 @@ import (
 	"goki.dev/girl/paint"
 	"goki.dev/goosi"
 	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
 	"goki.dev/grr"
 	"goki.dev/icons"
 	"goki.dev/ki/v2"
@@ -106,6 +108,9 @@ type Preferences struct { //gti:add
 	// column to sort by in FileView, and :up or :down for direction -- updated automatically via FileView
 	FileViewSort string `view:"-"`
 
+	// layouts of the columns of TableViews, by the name of the struct type of their rows -- updated automatically via TableView
+	TableViewLayouts map[string]*TableViewLayout `view:"-"`
+
 	// filename for saving / loading colors
 	ColorFilename FileName `view:"-" ext:".json"`
 
@@ -522,6 +527,20 @@ type EditorPrefs struct { //gti:add
 	// wrap lines at word boundaries -- otherwise long lines scroll off the end
 	WordWrap bool `xml:"word-wrap"`
 
+	// with WordWrap, wrap lines at the WrapColumn, or at the width of the
+	// editor if that is less, instead of just at the width of the editor
+	WrapAtColumn bool `xml:"wrap-at-column"`
+
+	// column at which lines are wrapped when WrapAtColumn is on,
+	// and to which paragraphs are reflowed
+	WrapColumn int `xml:"wrap-column"`
+
+	// indent the continuation lines of a wrapped line to the indentation of the line
+	HangingIndent bool `xml:"hanging-indent"`
+
+	// columns at which vertical ruler lines are drawn, e.g., 80, 100, 120
+	Rulers []int `xml:"rulers"`
+
 	// show line numbers
 	LineNos bool `xml:"line-nos"`
 
@@ -539,17 +558,60 @@ type EditorPrefs struct { //gti:add
 
 	// colorize the background according to nesting depth
 	DepthColor bool `xml:"depth-color"`
+
+	// color brackets according to their nesting depth, using colors from the highlighting style
+	RainbowBrackets bool `xml:"rainbow-brackets"`
+
+	// show vertical lines at each level of indentation, highlighting the one for the block containing the cursor
+	IndentGuides bool `xml:"indent-guides"`
+
+	// named keyboard macros, saved from the last macro recorded in an editor, for replaying by name
+	Macros map[string]KeyMacro `xml:"macros"`
 }
 
 // Defaults are the defaults for EditorPrefs
 func (pf *EditorPrefs) Defaults() {
 	pf.TabSize = 4
 	pf.WordWrap = true
+	pf.WrapColumn = 80
+	pf.HangingIndent = true
 	pf.LineNos = true
 	pf.Completion = true
 	pf.SpellCorrect = true
 	pf.AutoIndent = true
 	pf.DepthColor = true
+	pf.IndentGuides = true
+}
+
+// KeyMacroKey is one key chord event in a KeyMacro
+type KeyMacroKey struct {
+
+	// the rune of the key, for printable keys
+	Rune rune
+
+	// the key code
+	Code key.Codes
+
+	// the modifier keys held down
+	Mods key.Modifiers
+}
+
+// Chord returns the key chord for the key
+func (mk KeyMacroKey) Chord() key.Chord {
+	return key.NewChord(mk.Rune, mk.Code, mk.Mods)
+}
+
+// KeyMacro is a recorded sequence of key chord events, which can be
+// replayed in an editor
+type KeyMacro []KeyMacroKey
+
+// String returns the key chords of the macro, separated by spaces
+func (km KeyMacro) String() string {
+	chs := make([]string, len(km))
+	for i, mk := range km {
+		chs[i] = string(mk.Chord())
+	}
+	return strings.Join(chs, " ")
 }
 
 //////////////////////////////////////////////////////////////////
@@ -603,6 +665,27 @@ var DefaultPaths = FavPaths{
 	{icons.Computer, "root", "/"},
 }
 
+//////////////////////////////////////////////////////////////////
+//  TableViewLayout
+
+// TableViewLayout is the layout of the columns of a giv.TableView showing
+// a given type of struct, which is saved in Prefs.TableViewLayouts
+type TableViewLayout struct {
+
+	// names of the fields in the order of their columns -- any fields
+	// that are not in it follow in the order of the struct
+	Order []string
+
+	// names of the fields whose columns are hidden
+	Hidden []string
+
+	// widths of the columns that have been resized, in dp, by field name
+	Widths map[string]float32
+
+	// fields to sort by, in the format of giv.TableView.SortFieldName
+	Sort string
+}
+
 //////////////////////////////////////////////////////////////////
 //  FilePaths
 
@@ -620,6 +620,14 @@ func (w *RenderWin) SendCustomEvent(data any) {
 	w.GoosiWin.EventMgr().Custom(data)
 }
 
+// RunOnEventLoop runs the given function in the event loop of this window,
+// under the RenderCtx ReadLock, as for any event.  This is how widgets
+// must be updated from other goroutines, e.g., ones reading the output
+// of a process.  It returns immediately, before the function is run.
+func (w *RenderWin) RunOnEventLoop(fun func()) {
+	w.SendCustomEvent(fun)
+}
+
 // SendShowEvent sends the WinShowEvent to anyone listening -- only sent once..
 func (w *RenderWin) SendShowEvent() {
 	if w.HasFlag(WinSentShow) {
@@ -715,6 +723,14 @@ func (w *RenderWin) HandleEvent(evi events.Event) {
 		w.RenderCtx().ReadUnlock()
 		return
 	}
+	if et == events.Custom {
+		if fun, ok := evi.(*events.CustomEvent).Data.(func()); ok { // from RunOnEventLoop
+			evi.SetHandled()
+			fun()
+			w.RenderCtx().ReadUnlock()
+			return
+		}
+	}
 	// fmt.Printf("got event type: %v: %v\n", et.BitIndexString(), evi)
 	w.StageMgr.HandleEvent(evi)
 	w.RenderCtx().ReadUnlock()
@@ -165,6 +165,20 @@ func (sc *Scene) RenderCtx() *RenderContext {
 	return sm.RenderCtx
 }
 
+// RunOnEventLoop runs the given function in the event loop of the
+// RenderWin of this Scene (see [RenderWin.RunOnEventLoop]), which is
+// how widgets must be updated from other goroutines.  If the Scene
+// is not yet in a window, the function is called directly.
+func (sc *Scene) RunOnEventLoop(fun func()) {
+	if sc.Stage != nil {
+		if sm := sc.MainStageMgr(); sm != nil && sm.RenderWin != nil {
+			sm.RenderWin.RunOnEventLoop(fun)
+			return
+		}
+	}
+	fun()
+}
+
 // MainStageMgr returns the MainStageMgr that typically lives in a RenderWin
 // and manages all of the MainStage elements (Windows, Dialogs etc),
 // which in turn manage their popups.  This Scene could be in a popup
@@ -12,11 +12,11 @@ import (
 	"goki.dev/gi/v2/gi"
 )
 
-var _SliceViewFlagsValues = []SliceViewFlags{9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+var _SliceViewFlagsValues = []SliceViewFlags{9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21}
 
 // SliceViewFlagsN is the highest valid value
 // for type SliceViewFlags, plus one.
-const SliceViewFlagsN SliceViewFlags = 21
+const SliceViewFlagsN SliceViewFlags = 22
 
 // An "invalid array index" compiler error signifies that the constant values have changed.
 // Re-run the enumgen command to generate them again.
@@ -29,11 +29,12 @@ func _SliceViewFlagsNoOp() {
 	_ = x[SliceViewIsArray-(13)]
 	_ = x[SliceViewShowIndex-(14)]
 	_ = x[SliceViewShowToolbar-(15)]
-	_ = x[SliceViewReadOnlyKeyNav-(16)]
-	_ = x[SliceViewSelectMode-(17)]
-	_ = x[SliceViewReadOnlyMultiSel-(18)]
-	_ = x[SliceViewInFocusGrab-(19)]
-	_ = x[SliceViewInFullRebuild-(20)]
+	_ = x[SliceViewShowImportExport-(16)]
+	_ = x[SliceViewReadOnlyKeyNav-(17)]
+	_ = x[SliceViewSelectMode-(18)]
+	_ = x[SliceViewReadOnlyMultiSel-(19)]
+	_ = x[SliceViewInFocusGrab-(20)]
+	_ = x[SliceViewInFullRebuild-(21)]
 }
 
 var _SliceViewFlagsNameToValueMap = map[string]SliceViewFlags{
@@ -51,16 +52,18 @@ var _SliceViewFlagsNameToValueMap = map[string]SliceViewFlags{
 	`showindex`:        14,
 	`ShowToolbar`:      15,
 	`showtoolbar`:      15,
-	`ReadOnlyKeyNav`:   16,
-	`readonlykeynav`:   16,
-	`SelectMode`:       17,
-	`selectmode`:       17,
-	`ReadOnlyMultiSel`: 18,
-	`readonlymultisel`: 18,
-	`InFocusGrab`:      19,
-	`infocusgrab`:      19,
-	`InFullRebuild`:    20,
-	`infullrebuild`:    20,
+	`ShowImportExport`: 16,
+	`showimportexport`: 16,
+	`ReadOnlyKeyNav`:   17,
+	`readonlykeynav`:   17,
+	`SelectMode`:       18,
+	`selectmode`:       18,
+	`ReadOnlyMultiSel`: 19,
+	`readonlymultisel`: 19,
+	`InFocusGrab`:      20,
+	`infocusgrab`:      20,
+	`InFullRebuild`:    21,
+	`infullrebuild`:    21,
 }
 
 var _SliceViewFlagsDescMap = map[SliceViewFlags]string{
@@ -71,11 +74,12 @@ var _SliceViewFlagsDescMap = map[SliceViewFlags]string{
 	13: `whether the slice is actually an array -- no modifications -- set by SetSlice`,
 	14: `whether to show index or not`,
 	15: `whether to show the toolbar or not`,
-	16: `support key navigation when ReadOnly (default true) -- no focus really plausible in ReadOnly case, so it uses a low-pri capture of up / down events`,
-	17: `editing-mode select rows mode`,
-	18: `if view is ReadOnly, default selection mode is to choose one row only -- if this is true, standard multiple selection logic with modifier keys is instead supported`,
-	19: `guard for recursive focus grabbing`,
-	20: `guard for recursive rebuild`,
+	16: `whether to show the Import and Export buttons in the toolbar -- Import is never shown if the view is ReadOnly`,
+	17: `support key navigation when ReadOnly (default true) -- no focus really plausible in ReadOnly case, so it uses a low-pri capture of up / down events`,
+	18: `editing-mode select rows mode`,
+	19: `if view is ReadOnly, default selection mode is to choose one row only -- if this is true, standard multiple selection logic with modifier keys is instead supported`,
+	20: `guard for recursive focus grabbing`,
+	21: `guard for recursive rebuild`,
 }
 
 var _SliceViewFlagsMap = map[SliceViewFlags]string{
@@ -86,11 +90,12 @@ var _SliceViewFlagsMap = map[SliceViewFlags]string{
 	13: `IsArray`,
 	14: `ShowIndex`,
 	15: `ShowToolbar`,
-	16: `ReadOnlyKeyNav`,
-	17: `SelectMode`,
-	18: `ReadOnlyMultiSel`,
-	19: `InFocusGrab`,
-	20: `InFullRebuild`,
+	16: `ShowImportExport`,
+	17: `ReadOnlyKeyNav`,
+	18: `SelectMode`,
+	19: `ReadOnlyMultiSel`,
+	20: `InFocusGrab`,
+	21: `InFullRebuild`,
 }
 
 // String returns the string representation
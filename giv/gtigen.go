@@ -1236,9 +1236,12 @@ var SliceViewBaseType = gti.AddType(&gti.Type{
 		{"RowHeight", &gti.Field{Name: "RowHeight", Type: "float32", LocalType: "float32", Doc: "height of a single row", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
 		{"LayoutHeight", &gti.Field{Name: "LayoutHeight", Type: "float32", LocalType: "float32", Doc: "the height of grid from last layout -- determines when update needed", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 		{"VisRows", &gti.Field{Name: "VisRows", Type: "int", LocalType: "int", Doc: "total number of rows visible in allocated display size", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
-		{"StartIdx", &gti.Field{Name: "StartIdx", Type: "int", LocalType: "int", Doc: "starting slice index of visible rows", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
+		{"StartIdx", &gti.Field{Name: "StartIdx", Type: "int", LocalType: "int", Doc: "starting view index of visible rows (see Idxs)", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
 		{"RenderedRows", &gti.Field{Name: "RenderedRows", Type: "int", LocalType: "int", Doc: "the number of rows rendered -- determines update", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
-		{"SliceSize", &gti.Field{Name: "SliceSize", Type: "int", LocalType: "int", Doc: "size of slice", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
+		{"SliceSize", &gti.Field{Name: "SliceSize", Type: "int", LocalType: "int", Doc: "number of elements shown in the view: the size of the slice,\nor the length of Idxs if set", Directives: gti.Directives{}, Tag: "edit:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
+		{"Idxs", &gti.Field{Name: "Idxs", Type: "[]int", LocalType: "[]int", Doc: "optional mapping from the view index of each row (StartIdx + row)\nto the index of the slice element that it shows, for a filtered\nand / or sorted view that does not change the slice itself.\nnil shows all of the elements in the order of the slice.\nAll other indexes, e.g., for selection, copy / paste and\ndrag-n-drop, are slice indexes.  Set with SetIdxs.", Directives: gti.Directives{}, Tag: "set:\"-\" copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
+		{"viewIdxs", &gti.Field{Name: "viewIdxs", Type: "[]int", LocalType: "[]int", Doc: "inverse of Idxs: the view index of each slice index, or -1 if not shown", Directives: gti.Directives{}, Tag: ""}},
+		{"idxsLen", &gti.Field{Name: "idxsLen", Type: "int", LocalType: "int", Doc: "length of the slice when Idxs were set, to rebuild them if it changes", Directives: gti.Directives{}, Tag: ""}},
 		{"CurIdx", &gti.Field{Name: "CurIdx", Type: "int", LocalType: "int", Doc: "temp idx state for e.g., dnd", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 		{"ElVal", &gti.Field{Name: "ElVal", Type: "reflect.Value", LocalType: "reflect.Value", Doc: "ElVal is a Value representation of the underlying element type\nwhich is used whenever there are no slice elements available", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 	}),
@@ -1366,7 +1369,7 @@ func (t *SliceViewBase) SetVisRows(v int) *SliceViewBase {
 }
 
 // SetStartIdx sets the [SliceViewBase.StartIdx]:
-// starting slice index of visible rows
+// starting view index of visible rows (see Idxs)
 func (t *SliceViewBase) SetStartIdx(v int) *SliceViewBase {
 	t.StartIdx = v
 	return t
@@ -1380,7 +1383,8 @@ func (t *SliceViewBase) SetRenderedRows(v int) *SliceViewBase {
 }
 
 // SetSliceSize sets the [SliceViewBase.SliceSize]:
-// size of slice
+// number of elements shown in the view: the size of the slice,
+// or the length of Idxs if set
 func (t *SliceViewBase) SetSliceSize(v int) *SliceViewBase {
 	t.SliceSize = v
 	return t
@@ -1965,14 +1969,31 @@ var TableViewType = gti.AddType(&gti.Type{
 	Name:       "goki.dev/gi/v2/giv.TableView",
 	ShortName:  "giv.TableView",
 	IDName:     "table-view",
-	Doc:        "TableView represents a slice-of-structs as a table, where the fields are\nthe columns, within an overall frame.  It is a full-featured editor with\nmultiple-selection, cut-and-paste, and drag-and-drop.\nIf ReadOnly, it functions as a mutually-exclusive item\nselector, highlighting the selected row and emitting a Selected action.",
+	Doc:        "TableView represents a slice-of-structs as a table, where the fields are\nthe columns, within an overall frame.  It is a full-featured editor with\nmultiple-selection, cut-and-paste, and drag-and-drop.\nIf ReadOnly, it functions as a mutually-exclusive item\nselector, highlighting the selected row and emitting a Selected action.\nThe rows can be sorted by multiple columns (click, and shift-click, on\nthe column headers), filtered by the values of the columns (see the\nheader context menu and TableFilter), and searched (in the toolbar),\nall of which only changes the view (see SliceViewBase.Idxs), not the slice.\nIn CellMode, cells are selected and edited as in a spreadsheet.\nThe columns can be resized and moved by dragging their headers, and\nhidden (see the header context menu), and their layout is saved in\ngi.Prefs.TableViewLayouts -- a width:\"20\" tag sets the default width of\na column (in Ch), and a tableview:\"hidden\" tag hides it by default.",
 	Directives: gti.Directives{},
 	Fields: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
 		{"StyleFunc", &gti.Field{Name: "StyleFunc", Type: "goki.dev/gi/v2/giv.TableViewStyleFunc", LocalType: "TableViewStyleFunc", Doc: "optional styling function", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 		{"SelField", &gti.Field{Name: "SelField", Type: "string", LocalType: "string", Doc: "current selection field -- initially select value in this field", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
-		{"SortIdx", &gti.Field{Name: "SortIdx", Type: "int", LocalType: "int", Doc: "current sort index", Directives: gti.Directives{}, Tag: ""}},
-		{"SortDesc", &gti.Field{Name: "SortDesc", Type: "bool", LocalType: "bool", Doc: "whether current sort order is descending", Directives: gti.Directives{}, Tag: ""}},
+		{"SortIdx", &gti.Field{Name: "SortIdx", Type: "int", LocalType: "int", Doc: "index of the visible field that the rows are sorted by first,\nor -1 if they are not sorted: the first of the SortKeys, which\nis replaced by this field when it is set (see SortSlice)", Directives: gti.Directives{}, Tag: ""}},
+		{"SortDesc", &gti.Field{Name: "SortDesc", Type: "bool", LocalType: "bool", Doc: "whether the rows are sorted in descending order of the SortIdx field", Directives: gti.Directives{}, Tag: ""}},
+		{"SortKeys", &gti.Field{Name: "SortKeys", Type: "[]goki.dev/gi/v2/giv.TableSortKey", LocalType: "[]TableSortKey", Doc: "the keys that the rows are sorted by, in order of priority,\nafter which they are in slice order", Directives: gti.Directives{}, Tag: ""}},
+		{"lastSortIdx", &gti.Field{Name: "lastSortIdx", Type: "int", LocalType: "int", Doc: "the SortIdx and SortDesc when they were last synced with the SortKeys", Directives: gti.Directives{}, Tag: ""}},
+		{"lastSortDesc", &gti.Field{Name: "lastSortDesc", Type: "bool", LocalType: "bool", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+		{"Filters", &gti.Field{Name: "Filters", Type: "map[string]string", LocalType: "map[string]string", Doc: "filters for the values of the fields, by field name,\nthat a row must match to be shown (see TableFilter)", Directives: gti.Directives{}, Tag: ""}},
+		{"Search", &gti.Field{Name: "Search", Type: "string", LocalType: "string", Doc: "quick search text: only rows with a field whose text\ncontains it, ignoring case, are shown", Directives: gti.Directives{}, Tag: ""}},
+		{"CellMode", &gti.Field{Name: "CellMode", Type: "bool", LocalType: "bool", Doc: "whether the keyboard and mouse select and navigate cells, as in a\nspreadsheet, instead of rows, with the selected cells edited as a\nrange (see TableCell and KeyInputCells)", Directives: gti.Directives{}, Tag: ""}},
+		{"curCell", &gti.Field{Name: "curCell", Type: "goki.dev/gi/v2/giv.TableCell", LocalType: "TableCell", Doc: "current cell in CellMode", Directives: gti.Directives{}, Tag: ""}},
+		{"cellAnchor", &gti.Field{Name: "cellAnchor", Type: "goki.dev/gi/v2/giv.TableCell", LocalType: "TableCell", Doc: "cell where the selection of the range of cells started in CellMode", Directives: gti.Directives{}, Tag: ""}},
+		{"cellOld", &gti.Field{Name: "cellOld", Type: "reflect.Value", LocalType: "reflect.Value", Doc: "copy of the value of the current cell, for undoing its edits", Directives: gti.Directives{}, Tag: ""}},
+		{"cellUndos", &gti.Field{Name: "cellUndos", Type: "[][]goki.dev/gi/v2/giv.TableCellEdit", LocalType: "[][]TableCellEdit", Doc: "edits of cells that can be undone", Directives: gti.Directives{}, Tag: ""}},
+		{"cellRedos", &gti.Field{Name: "cellRedos", Type: "[][]goki.dev/gi/v2/giv.TableCellEdit", LocalType: "[][]TableCellEdit", Doc: "edits of cells that have been undone, which can be redone", Directives: gti.Directives{}, Tag: ""}},
+		{"cellUndoLen", &gti.Field{Name: "cellUndoLen", Type: "int", LocalType: "int", Doc: "length of the slice when the cellUndos were recorded", Directives: gti.Directives{}, Tag: ""}},
+		{"colDrag", &gti.Field{Name: "colDrag", Type: "int", LocalType: "int", Doc: "visible field index of the column whose header is being dragged, or -1", Directives: gti.Directives{}, Tag: ""}},
+		{"colDragResize", &gti.Field{Name: "colDragResize", Type: "bool", LocalType: "bool", Doc: "whether the column being dragged is being resized, instead of moved", Directives: gti.Directives{}, Tag: ""}},
+		{"colDragWidth", &gti.Field{Name: "colDragWidth", Type: "float32", LocalType: "float32", Doc: "width in dots of the column being dragged when the drag started", Directives: gti.Directives{}, Tag: ""}},
 		{"StruType", &gti.Field{Name: "StruType", Type: "reflect.Type", LocalType: "reflect.Type", Doc: "struct type for each row", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
+		{"ColLayout", &gti.Field{Name: "ColLayout", Type: "*goki.dev/gi/v2/gi.TableViewLayout", LocalType: "*gi.TableViewLayout", Doc: "layout of the columns: their order, widths and which are hidden,\nwhich is saved in gi.Prefs.TableViewLayouts for the struct type\nwhenever the user changes it (see SaveColLayout)", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
+		{"AllFields", &gti.Field{Name: "AllFields", Type: "[]reflect.StructField", LocalType: "[]reflect.StructField", Doc: "the fields that can be shown as columns, in the order of the ColLayout", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 		{"VisFields", &gti.Field{Name: "VisFields", Type: "[]reflect.StructField", LocalType: "[]reflect.StructField", Doc: "the visible fields", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 		{"NVisFields", &gti.Field{Name: "NVisFields", Type: "int", LocalType: "int", Doc: "number of visible fields", Directives: gti.Directives{}, Tag: "copy:\"-\" view:\"-\" json:\"-\" xml:\"-\""}},
 	}),
@@ -2016,19 +2037,54 @@ func (t *TableView) SetSelField(v string) *TableView {
 }
 
 // SetSortIdx sets the [TableView.SortIdx]:
-// current sort index
+// index of the visible field that the rows are sorted by first,
+// or -1 if they are not sorted: the first of the SortKeys, which
+// is replaced by this field when it is set (see SortSlice)
 func (t *TableView) SetSortIdx(v int) *TableView {
 	t.SortIdx = v
 	return t
 }
 
 // SetSortDesc sets the [TableView.SortDesc]:
-// whether current sort order is descending
+// whether the rows are sorted in descending order of the SortIdx field
 func (t *TableView) SetSortDesc(v bool) *TableView {
 	t.SortDesc = v
 	return t
 }
 
+// SetSortKeys sets the [TableView.SortKeys]:
+// the keys that the rows are sorted by, in order of priority,
+// after which they are in slice order
+func (t *TableView) SetSortKeys(v []TableSortKey) *TableView {
+	t.SortKeys = v
+	return t
+}
+
+// SetFilters sets the [TableView.Filters]:
+// filters for the values of the fields, by field name,
+// that a row must match to be shown (see TableFilter)
+func (t *TableView) SetFilters(v map[string]string) *TableView {
+	t.Filters = v
+	return t
+}
+
+// SetSearch sets the [TableView.Search]:
+// quick search text: only rows with a field whose text
+// contains it, ignoring case, are shown
+func (t *TableView) SetSearch(v string) *TableView {
+	t.Search = v
+	return t
+}
+
+// SetCellMode sets the [TableView.CellMode]:
+// whether the keyboard and mouse select and navigate cells, as in a
+// spreadsheet, instead of rows, with the selected cells edited as a
+// range (see TableCell and KeyInputCells)
+func (t *TableView) SetCellMode(v bool) *TableView {
+	t.CellMode = v
+	return t
+}
+
 // SetStruType sets the [TableView.StruType]:
 // struct type for each row
 func (t *TableView) SetStruType(v reflect.Type) *TableView {
@@ -2036,6 +2092,22 @@ func (t *TableView) SetStruType(v reflect.Type) *TableView {
 	return t
 }
 
+// SetColLayout sets the [TableView.ColLayout]:
+// layout of the columns: their order, widths and which are hidden,
+// which is saved in gi.Prefs.TableViewLayouts for the struct type
+// whenever the user changes it (see SaveColLayout)
+func (t *TableView) SetColLayout(v *gi.TableViewLayout) *TableView {
+	t.ColLayout = v
+	return t
+}
+
+// SetAllFields sets the [TableView.AllFields]:
+// the fields that can be shown as columns, in the order of the ColLayout
+func (t *TableView) SetAllFields(v []reflect.StructField) *TableView {
+	t.AllFields = v
+	return t
+}
+
 // SetVisFields sets the [TableView.VisFields]:
 // the visible fields
 func (t *TableView) SetVisFields(v []reflect.StructField) *TableView {
@@ -2211,17 +2283,30 @@ var TreeViewType = gti.AddType(&gti.Type{
 	Name:       "goki.dev/gi/v2/giv.TreeView",
 	ShortName:  "giv.TreeView",
 	IDName:     "tree-view",
-	Doc:        "TreeView provides a graphical representation of a tree tructure\nproviding full navigation and manipulation abilities.\n\nIf the SyncNode field is non-nil, typically via\nSyncRootNode method, then the TreeView mirrors another\nKi tree structure, and tree editing functions apply to\nthe source tree first, and then to the TreeView by sync.\n\nOtherwise, data can be directly encoded in a TreeView\nderived type, to represent any kind of tree structure\nand associated data.\n\nStandard events.Event are sent to any listeners, including\nSelect, Change, and DoubleClick.  The selected nodes\nare in the root SelectedNodes list.",
+	Doc:        "TreeView provides a graphical representation of a tree tructure\nproviding full navigation and manipulation abilities.\n\nIf the SyncNode field is non-nil, typically via\nSyncRootNode method, then the TreeView mirrors another\nKi tree structure, and tree editing functions apply to\nthe source tree first, and then to the TreeView by sync.\n\nOtherwise, data can be directly encoded in a TreeView\nderived type, to represent any kind of tree structure\nand associated data.\n\nStandard events.Event are sent to any listeners, including\nSelect, Change, and DoubleClick.  The selected nodes\nare in the root SelectedNodes list.\n\nFor very large SyncNode trees, set Virtual on the root node, so that\nthere are only TreeViews for the rows of the tree that are in view\nin the enclosing scrolling layout, which are reused for other nodes\nof the tree as it scrolls, as in a SliceView (see VisNodes, StartIdx\nand VisRows).",
 	Directives: gti.Directives{},
 	Fields: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
 		{"SyncNode", &gti.Field{Name: "SyncNode", Type: "goki.dev/ki/v2.Ki", LocalType: "ki.Ki", Doc: "If non-Ki Node that this widget is viewing in the tree -- the source", Directives: gti.Directives{}, Tag: "set:\"-\" copy:\"-\" json:\"-\" xml:\"-\""}},
 		{"Icon", &gti.Field{Name: "Icon", Type: "goki.dev/icons.Icon", LocalType: "icons.Icon", Doc: "optional icon, displayed to the the left of the text label", Directives: gti.Directives{}, Tag: ""}},
 		{"Indent", &gti.Field{Name: "Indent", Type: "goki.dev/girl/units.Value", LocalType: "units.Value", Doc: "amount to indent children relative to this node", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\""}},
 		{"OpenDepth", &gti.Field{Name: "OpenDepth", Type: "int", LocalType: "int", Doc: "depth for nodes be initialized as open (default 4).\nNodes beyond this depth will be initialized as closed.", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\""}},
+		{"Virtual", &gti.Field{Name: "Virtual", Type: "bool", LocalType: "bool", Doc: "whether to only make TreeViews for the rows of the tree that are\nin view in the enclosing scrolling layout, binding them to the\nnodes of the SyncNode tree in view as it scrolls, which is much\nfaster and smaller for very large trees -- set on the root node\nonly, before calling SyncRootNode.  It has no effect without\na SyncNode.", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\""}},
 		{"ViewIdx", &gti.Field{Name: "ViewIdx", Type: "int", LocalType: "int", Doc: "linear index of this node within the entire tree.\nupdated on full rebuilds and may sometimes be off,\nbut close enough for expected uses", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
 		{"WidgetSize", &gti.Field{Name: "WidgetSize", Type: "goki.dev/mat32/v2.Vec2", LocalType: "mat32.Vec2", Doc: "size of just this node widget.\nour alloc includes all of our children, but we only draw us.", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
 		{"RootView", &gti.Field{Name: "RootView", Type: "*goki.dev/gi/v2/giv.TreeView", LocalType: "*TreeView", Doc: "cached root of the view", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
 		{"SelectedNodes", &gti.Field{Name: "SelectedNodes", Type: "[]*goki.dev/gi/v2/giv.TreeView", LocalType: "[]*TreeView", Doc: "SelectedNodes holds the currently-selected nodes, on the\nRootView node only.", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
+		{"VisNodes", &gti.Field{Name: "VisNodes", Type: "[]goki.dev/ki/v2.Ki", LocalType: "[]ki.Ki", Doc: "VisNodes are the nodes of the SyncNode tree that are shown as\nrows of the tree, because all of their parents are open, in order,\non the RootView node only, in Virtual mode.", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
+		{"RowHeight", &gti.Field{Name: "RowHeight", Type: "float32", LocalType: "float32", Doc: "height of each row of the tree, on the RootView node only,\nin Virtual mode", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
+		{"StartIdx", &gti.Field{Name: "StartIdx", Type: "int", LocalType: "int", Doc: "index in VisNodes of the first row in view, on the RootView\nnode only, in Virtual mode", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
+		{"VisRows", &gti.Field{Name: "VisRows", Type: "int", LocalType: "int", Doc: "number of rows in view, starting at StartIdx, on the RootView\nnode only, in Virtual mode", Directives: gti.Directives{}, Tag: "copy:\"-\" json:\"-\" xml:\"-\" edit:\"-\""}},
+		{"visDepths", &gti.Field{Name: "visDepths", Type: "[]int", LocalType: "[]int", Doc: "depths of the VisNodes below the SyncNode, for their indentation,\non the RootView node only, in Virtual mode", Directives: gti.Directives{}, Tag: ""}},
+		{"visStale", &gti.Field{Name: "visStale", Type: "bool", LocalType: "bool", Doc: "whether the VisNodes need to be updated for nodes that have been\nopened or closed, or changes in the SyncNode tree, on the\nRootView node only, in Virtual mode", Directives: gti.Directives{}, Tag: ""}},
+		{"closed", &gti.Field{Name: "closed", Type: "map[goki.dev/ki/v2.Ki]bool", LocalType: "map[ki.Ki]bool", Doc: "open / closed state of the nodes of the SyncNode tree that\nhave been opened or closed, on the RootView node only,\nin Virtual mode", Directives: gti.Directives{}, Tag: ""}},
+		{"selSync", &gti.Field{Name: "selSync", Type: "goki.dev/ki/v2.Slice", LocalType: "ki.Slice", Doc: "selected nodes of the SyncNode tree, in the order selected,\non the RootView node only, in Virtual mode, where the rows\nare bound to other nodes as the tree scrolls", Directives: gti.Directives{}, Tag: ""}},
+		{"selSet", &gti.Field{Name: "selSet", Type: "map[goki.dev/ki/v2.Ki]bool", LocalType: "map[ki.Ki]bool", Doc: "set of the selSync nodes", Directives: gti.Directives{}, Tag: ""}},
+		{"visIdx", &gti.Field{Name: "visIdx", Type: "int", LocalType: "int", Doc: "index in VisNodes of the node of this row, in Virtual mode", Directives: gti.Directives{}, Tag: ""}},
+		{"visDepth", &gti.Field{Name: "visDepth", Type: "int", LocalType: "int", Doc: "depth of the node of this row, for its indentation,\nin Virtual mode", Directives: gti.Directives{}, Tag: ""}},
+		{"inView", &gti.Field{Name: "inView", Type: "bool", LocalType: "bool", Doc: "whether this row is bound to a node in view, in Virtual mode", Directives: gti.Directives{}, Tag: ""}},
 		{"actStateLayer", &gti.Field{Name: "actStateLayer", Type: "float32", LocalType: "float32", Doc: "actStateLayer is the actual state layer of the tree view, which\nshould be used when rendering it and its parts (but not its children).\nthe reason that it exists is so that the children of the tree view\n(other tree views) do not inherit its stateful background color, as\nthat does not look good.", Directives: gti.Directives{}, Tag: "set:\"-\""}},
 	}),
 	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
@@ -2271,6 +2356,18 @@ func (t *TreeView) SetOpenDepth(v int) *TreeView {
 	return t
 }
 
+// SetVirtual sets the [TreeView.Virtual]:
+// whether to only make TreeViews for the rows of the tree that are
+// in view in the enclosing scrolling layout, binding them to the
+// nodes of the SyncNode tree in view as it scrolls, which is much
+// faster and smaller for very large trees -- set on the root node
+// only, before calling SyncRootNode.  It has no effect without
+// a SyncNode.
+func (t *TreeView) SetVirtual(v bool) *TreeView {
+	t.Virtual = v
+	return t
+}
+
 // SetViewIdx sets the [TreeView.ViewIdx]:
 // linear index of this node within the entire tree.
 // updated on full rebuilds and may sometimes be off,
@@ -2303,6 +2400,39 @@ func (t *TreeView) SetSelectedNodes(v []*TreeView) *TreeView {
 	return t
 }
 
+// SetVisNodes sets the [TreeView.VisNodes]:
+// VisNodes are the nodes of the SyncNode tree that are shown as
+// rows of the tree, because all of their parents are open, in order,
+// on the RootView node only, in Virtual mode.
+func (t *TreeView) SetVisNodes(v []ki.Ki) *TreeView {
+	t.VisNodes = v
+	return t
+}
+
+// SetRowHeight sets the [TreeView.RowHeight]:
+// height of each row of the tree, on the RootView node only,
+// in Virtual mode
+func (t *TreeView) SetRowHeight(v float32) *TreeView {
+	t.RowHeight = v
+	return t
+}
+
+// SetStartIdx sets the [TreeView.StartIdx]:
+// index in VisNodes of the first row in view, on the RootView
+// node only, in Virtual mode
+func (t *TreeView) SetStartIdx(v int) *TreeView {
+	t.StartIdx = v
+	return t
+}
+
+// SetVisRows sets the [TreeView.VisRows]:
+// number of rows in view, starting at StartIdx, on the RootView
+// node only, in Virtual mode
+func (t *TreeView) SetVisRows(v int) *TreeView {
+	t.VisRows = v
+	return t
+}
+
 // SetTooltip sets the [TreeView.Tooltip]
 func (t *TreeView) SetTooltip(v string) *TreeView {
 	t.Tooltip = v
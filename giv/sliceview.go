@@ -10,6 +10,7 @@ import (
 	"image"
 	"log"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -111,6 +112,9 @@ const (
 	// whether to show the toolbar or not
 	SliceViewShowToolbar
 
+	// whether to show the Import and Export buttons in the toolbar -- Import is never shown if the view is ReadOnly
+	SliceViewShowImportExport
+
 	// support key navigation when ReadOnly (default true) -- no focus really plausible in ReadOnly case, so it uses a low-pri capture of up / down events
 	SliceViewReadOnlyKeyNav
 
@@ -273,15 +277,30 @@ type SliceViewBase struct {
 	// total number of rows visible in allocated display size
 	VisRows int `edit:"-" copy:"-" json:"-" xml:"-"`
 
-	// starting slice index of visible rows
+	// starting view index of visible rows (see Idxs)
 	StartIdx int `edit:"-" copy:"-" json:"-" xml:"-"`
 
 	// the number of rows rendered -- determines update
 	RenderedRows int `copy:"-" view:"-" json:"-" xml:"-"`
 
-	// size of slice
+	// number of elements shown in the view: the size of the slice,
+	// or the length of Idxs if set
 	SliceSize int `edit:"-" copy:"-" json:"-" xml:"-"`
 
+	// optional mapping from the view index of each row (StartIdx + row)
+	// to the index of the slice element that it shows, for a filtered
+	// and / or sorted view that does not change the slice itself.
+	// nil shows all of the elements in the order of the slice.
+	// All other indexes, e.g., for selection, copy / paste and
+	// drag-n-drop, are slice indexes.  Set with SetIdxs.
+	Idxs []int `set:"-" copy:"-" view:"-" json:"-" xml:"-"`
+
+	// inverse of Idxs: the view index of each slice index, or -1 if not shown
+	viewIdxs []int
+
+	// length of the slice when Idxs were set, to rebuild them if it changes
+	idxsLen int
+
 	// temp idx state for e.g., dnd
 	CurIdx int `copy:"-" view:"-" json:"-" xml:"-"`
 
@@ -367,9 +386,9 @@ func (sv *SliceViewBase) SliceViewBaseInit() {
 			case strings.HasPrefix(w.Name(), "value-"):
 				w.Style(func(s *styles.Style) {
 					idx := grr.Log(strconv.Atoi(strings.TrimPrefix(w.Name(), "value-")))
-					si := sv.StartIdx + idx
-					if si < sv.SliceSize {
-						sv.This().(SliceViewer).StyleRow(w, si, 0)
+					vi := sv.StartIdx + idx
+					if vi < sv.SliceSize {
+						sv.This().(SliceViewer).StyleRow(w, sv.SliceIdx(vi), 0)
 					}
 				})
 			}
@@ -402,6 +421,9 @@ func (sv *SliceViewBase) SetSlice(sl any) *SliceViewBase {
 	}
 	updt := sv.UpdateStart()
 	sv.StartIdx = 0
+	sv.Idxs = nil
+	sv.viewIdxs = nil
+	sv.idxsLen = 0
 	sv.Slice = sl
 	sv.SliceNPVal = laser.NonPtrValue(reflect.ValueOf(sv.Slice))
 	isArray := laser.NonPtrType(reflect.TypeOf(sl)).Kind() == reflect.Array
@@ -586,10 +608,18 @@ func (sv *SliceViewBase) RowWidgetNs() (nWidgPerRow, idxOff int) {
 	return
 }
 
-// UpdtSliceSize updates and returns the size of the slice
-// and sets SliceSize
+// UpdtSliceSize updates and returns the number of elements shown,
+// which is the size of the slice or the length of Idxs if set,
+// and sets SliceSize.  If the length of the slice has changed
+// since the Idxs were set, they are rebuilt (see ResizeIdxs).
 func (sv *SliceViewBase) UpdtSliceSize() int {
 	sz := sv.SliceNPVal.Len()
+	if sv.Idxs != nil {
+		if sz != sv.idxsLen {
+			sv.setIdxs(ResizeIdxs(sv.Idxs, sv.idxsLen, sz))
+		}
+		sz = len(sv.Idxs)
+	}
 	sv.SliceSize = sz
 	return sz
 }
@@ -745,10 +775,10 @@ func (sv *SliceViewBase) ConfigRows(sc *gi.Scene) {
 
 	for i := 0; i < sv.VisRows; i++ {
 		i := i
-		si := i
+		si := sv.SliceIdx(i)
 		ridx := i * nWidgPerRow
 		var val reflect.Value
-		if si < sv.SliceSize {
+		if i < sv.SliceSize {
 			val = laser.OnePtrUnderlyingValue(sv.SliceNPVal.Index(si)) // deal with pointer lists
 		} else {
 			val = sv.ElVal
@@ -850,14 +880,15 @@ func (sv *SliceViewBase) UpdateWidgets() {
 		ridx := i * nWidgPerRow
 		widg := sg.Kids[ridx+idxOff].(gi.Widget)
 		vv := sv.Values[i]
-		si := sv.StartIdx + i // slice idx
+		vi := sv.StartIdx + i // view idx
+		si := sv.SliceIdx(vi) // slice idx
 		var idxlab *gi.Label
 		if sv.Is(SliceViewShowIndex) {
 			idxlab = sg.Kids[ridx].(*gi.Label)
 			idxlab.SetText(strconv.Itoa(si))
 			idxlab.SetNeedsRender()
 		}
-		if si < sv.SliceSize {
+		if vi < sv.SliceSize {
 			widg.SetState(false, states.Invisible)
 			val := laser.OnePtrUnderlyingValue(sv.SliceNPVal.Index(si)) // deal with pointer lists
 			vv.SetSliceValue(val, sv.Slice, si, sv.TmpSave, sv.ViewPath)
@@ -929,9 +960,15 @@ func (sv *SliceViewBase) SetChanged() {
 	sv.Toolbar().UpdateButtons() // nil safe
 }
 
-// SliceNewAtRow inserts a new blank element at given display row
+// SliceNewAtRow inserts a new blank element at given display row,
+// after the element shown in the row before it (see IdxsInserted)
 func (sv *SliceViewBase) SliceNewAtRow(row int) {
-	sv.This().(SliceViewer).SliceNewAt(sv.StartIdx + row)
+	vi := sv.StartIdx + row
+	idx := 0
+	if vi > 0 {
+		idx = sv.SliceIdx(min(vi, sv.SliceSize)-1) + 1
+	}
+	sv.This().(SliceViewer).SliceNewAt(idx)
 }
 
 // SliceNewAt inserts a new blank element at given index in the slice -- -1
@@ -953,7 +990,7 @@ func (sv *SliceViewBase) SliceNewAt(idx int) {
 	slptr := sltyp.Kind() == reflect.Ptr
 
 	svl := reflect.ValueOf(sv.Slice)
-	sz := sv.SliceSize
+	sz := sv.SliceNPVal.Len()
 
 	svnp := sv.SliceNPVal
 
@@ -981,11 +1018,14 @@ func (sv *SliceViewBase) SliceNewAt(idx int) {
 			nval = nval.Elem() // use concrete value
 		}
 		svnp = reflect.Append(svnp, nval)
+		ni := sz // index of the new element
 		if idx >= 0 && idx < sz {
 			reflect.Copy(svnp.Slice(idx+1, sz+1), svnp.Slice(idx, sz))
 			svnp.Index(idx).Set(nval)
+			ni = idx
 		}
 		svl.Elem().Set(svnp)
+		sv.IdxsInserted(ni, 1)
 	}
 	if idx < 0 {
 		idx = sz
@@ -1006,7 +1046,7 @@ func (sv *SliceViewBase) SliceNewAt(idx int) {
 // SliceDeleteAtRow deletes element at given display row
 // if updt is true, then update the grid after
 func (sv *SliceViewBase) SliceDeleteAtRow(row int) {
-	sv.This().(SliceViewer).SliceDeleteAt(sv.StartIdx + row)
+	sv.This().(SliceViewer).SliceDeleteAt(sv.SliceIdx(sv.StartIdx + row))
 }
 
 // SliceNewAtSel updates selected rows based on
@@ -1045,7 +1085,7 @@ func (sv *SliceViewBase) SliceDeleteAt(idx int) {
 	if sv.Is(SliceViewIsArray) {
 		return
 	}
-	if idx < 0 || idx >= sv.SliceSize {
+	if idx < 0 || idx >= sv.SliceNPVal.Len() {
 		return
 	}
 	sv.ViewMuLock()
@@ -1055,6 +1095,7 @@ func (sv *SliceViewBase) SliceDeleteAt(idx int) {
 	sv.SliceDeleteAtSel(idx)
 
 	laser.SliceDeleteAt(sv.Slice, idx)
+	sv.IdxsDeleted(idx)
 
 	sv.This().(SliceViewer).UpdtSliceSize()
 
@@ -1080,17 +1121,19 @@ func (sv *SliceViewBase) ConfigToolbar() {
 		return
 	}
 	tb := sv.Toolbar()
-	ndef := 1 // number of default actions
-	if sv.Is(SliceViewIsArray) || sv.IsReadOnly() || sv.Is(SliceViewNoAdd) {
-		ndef = 0
+	canAdd := !(sv.Is(SliceViewIsArray) || sv.IsReadOnly() || sv.Is(SliceViewNoAdd))
+	ndef := sv.NImportExport(canAdd) // number of default actions: add, import and export
+	if canAdd {
+		ndef++
 	}
 	if len(*tb.Children()) < ndef {
-		if ndef > 0 {
+		if canAdd {
 			gi.NewButton(tb, "add").SetText("Add").SetIcon(icons.Add).SetTooltip("add a new element to the slice").
 				OnClick(func(e events.Event) {
 					sv.This().(SliceViewer).SliceNewAt(-1)
 				})
 		}
+		sv.ConfigImportExport(tb, canAdd)
 	}
 	sz := len(*tb.Children())
 	if sz > ndef {
@@ -1107,6 +1150,133 @@ func (sv *SliceViewBase) Render(sc *gi.Scene) {
 	sv.Frame.Render(sc)
 }
 
+////////////////////////////////////////////////////////////
+//  View index mapping
+//  NOTE: view idx = StartIdx + row, mapped to slice idx by Idxs
+
+// SetIdxs sets the Idxs mapping from view indexes to slice indexes
+// (nil to show all of the elements in slice order), and unselects
+// any selected elements that are no longer shown
+func (sv *SliceViewBase) SetIdxs(ixs []int) {
+	sv.setIdxs(ixs)
+	sv.This().(SliceViewer).UpdtSliceSize()
+}
+
+// setIdxs sets the Idxs and their inverse, for the current length of
+// the slice, without updating the SliceSize
+func (sv *SliceViewBase) setIdxs(ixs []int) {
+	sv.Idxs = ixs
+	sv.viewIdxs = nil
+	sv.idxsLen = sv.SliceNPVal.Len()
+	if ixs != nil {
+		mx := -1
+		for _, ix := range ixs {
+			mx = max(mx, ix)
+		}
+		sv.viewIdxs = make([]int, mx+1)
+		for i := range sv.viewIdxs {
+			sv.viewIdxs[i] = -1
+		}
+		for vi, ix := range ixs {
+			sv.viewIdxs[ix] = vi
+		}
+		for idx := range sv.SelectedIdxs {
+			if sv.ViewIdx(idx) < 0 {
+				delete(sv.SelectedIdxs, idx)
+			}
+		}
+	}
+}
+
+// ResizeIdxs returns the given Idxs for a slice whose length has changed
+// from oldLen to newLen outside of the view: the indexes that are no
+// longer in the slice are removed, and any new elements at the end of
+// the slice are shown at the end.
+func ResizeIdxs(ixs []int, oldLen, newLen int) []int {
+	ixs = slices.DeleteFunc(slices.Clone(ixs), func(ix int) bool { return ix >= newLen })
+	for ix := oldLen; ix < newLen; ix++ {
+		ixs = append(ixs, ix)
+	}
+	return ixs
+}
+
+// SliceIdx returns the slice index of the element shown at given
+// view index (StartIdx + row), or -1 if out of range of Idxs
+func (sv *SliceViewBase) SliceIdx(vi int) int {
+	if sv.Idxs == nil {
+		return vi
+	}
+	if vi < 0 || vi >= len(sv.Idxs) {
+		return -1
+	}
+	return sv.Idxs[vi]
+}
+
+// ViewIdx returns the view index at which the element at given
+// slice index is shown, or -1 if it is not shown
+func (sv *SliceViewBase) ViewIdx(idx int) int {
+	if sv.Idxs == nil {
+		return idx
+	}
+	if idx < 0 || idx >= len(sv.viewIdxs) {
+		return -1
+	}
+	return sv.viewIdxs[idx]
+}
+
+// IdxsInserted updates Idxs for n elements that have been inserted into
+// the slice at given slice index, which are shown after the element
+// before them if it is shown, else before the element that they were
+// inserted before if it is shown, else at the end.
+func (sv *SliceViewBase) IdxsInserted(idx, n int) {
+	if sv.Idxs == nil || n <= 0 {
+		return
+	}
+	vi := len(sv.Idxs)
+	if pv := sv.ViewIdx(idx - 1); pv >= 0 {
+		vi = pv + 1
+	} else if nv := sv.ViewIdx(idx); nv >= 0 {
+		vi = nv
+	}
+	ixs := make([]int, 0, len(sv.Idxs)+n)
+	for i, ix := range sv.Idxs {
+		if i == vi {
+			for j := 0; j < n; j++ {
+				ixs = append(ixs, idx+j)
+			}
+		}
+		if ix >= idx {
+			ix += n
+		}
+		ixs = append(ixs, ix)
+	}
+	if vi == len(sv.Idxs) {
+		for j := 0; j < n; j++ {
+			ixs = append(ixs, idx+j)
+		}
+	}
+	sv.SetIdxs(ixs)
+}
+
+// IdxsDeleted updates Idxs for the element deleted from the slice at
+// given slice index
+func (sv *SliceViewBase) IdxsDeleted(idx int) {
+	if sv.Idxs == nil {
+		return
+	}
+	ixs := make([]int, 0, len(sv.Idxs))
+	for _, ix := range sv.Idxs {
+		switch {
+		case ix == idx:
+			continue
+		case ix > idx:
+			ix--
+		}
+		ixs = append(ixs, ix)
+	}
+	sv.SetIdxs(ixs)
+}
+
 ////////////////////////////////////////////////////////////
 //  Row access methods
 //  NOTE: row = physical GUI display row, idx = slice index
@@ -1115,7 +1285,7 @@ func (sv *SliceViewBase) Render(sc *gi.Scene) {
 // SliceVal returns value interface at given slice index
 // must be protected by mutex
 func (sv *SliceViewBase) SliceVal(idx int) any {
-	if idx < 0 || idx >= sv.SliceSize {
+	if idx < 0 || idx >= sv.SliceNPVal.Len() {
 		fmt.Printf("giv.SliceViewBase: slice index out of range: %v\n", idx)
 		return nil
 	}
@@ -1131,7 +1301,8 @@ func (sv *SliceViewBase) IsRowInBounds(row int) bool {
 
 // IsIdxVisible returns true if slice index is currently visible
 func (sv *SliceViewBase) IsIdxVisible(idx int) bool {
-	return sv.IsRowInBounds(idx - sv.StartIdx)
+	vi := sv.ViewIdx(idx)
+	return vi >= 0 && sv.IsRowInBounds(vi-sv.StartIdx)
 }
 
 // RowFirstWidget returns the first widget for given row (could be index or
@@ -1172,13 +1343,17 @@ func (sv *SliceViewBase) RowGrabFocus(row int) *gi.WidgetBase {
 // IdxGrabFocus grabs the focus for the first focusable widget
 // in given idx.  returns that element or nil if not successful.
 func (sv *SliceViewBase) IdxGrabFocus(idx int) *gi.WidgetBase {
+	vi := sv.ViewIdx(idx)
+	if vi < 0 {
+		return nil
+	}
 	sv.ScrollToIdx(idx)
-	return sv.This().(SliceViewer).RowGrabFocus(idx - sv.StartIdx)
+	return sv.This().(SliceViewer).RowGrabFocus(vi - sv.StartIdx)
 }
 
 // IdxPos returns center of window position of index label for idx (ContextMenuPos)
 func (sv *SliceViewBase) IdxPos(idx int) image.Point {
-	row := idx - sv.StartIdx
+	row := sv.ViewIdx(idx) - sv.StartIdx
 	if row < 0 {
 		row = 0
 	}
@@ -1213,7 +1388,7 @@ func (sv *SliceViewBase) IdxFromPos(posY int) (int, bool) {
 	if !ok {
 		return -1, false
 	}
-	return row + sv.StartIdx, true
+	return sv.SliceIdx(row + sv.StartIdx), true
 }
 
 // ScrollToIdxNoUpdt ensures that given slice idx is visible
@@ -1221,16 +1396,17 @@ func (sv *SliceViewBase) IdxFromPos(posY int) (int, bool) {
 // This version does not update the slicegrid.
 // Just computes the StartIdx and updates the scrollbar
 func (sv *SliceViewBase) ScrollToIdxNoUpdt(idx int) bool {
-	if sv.VisRows == 0 {
+	vi := sv.ViewIdx(idx)
+	if sv.VisRows == 0 || vi < 0 {
 		return false
 	}
-	if idx < sv.StartIdx {
-		sv.StartIdx = idx
+	if vi < sv.StartIdx {
+		sv.StartIdx = vi
 		sv.StartIdx = max(0, sv.StartIdx)
 		sv.UpdateScroll()
 		return true
-	} else if idx >= sv.StartIdx+sv.VisRows {
-		sv.StartIdx = idx - (sv.VisRows - 1)
+	} else if vi >= sv.StartIdx+sv.VisRows {
+		sv.StartIdx = vi - (sv.VisRows - 1)
 		sv.StartIdx = max(0, sv.StartIdx)
 		sv.UpdateScroll()
 		return true
@@ -1284,13 +1460,14 @@ func SliceIdxByValue(slc any, fldVal any) (int, bool) {
 //    Moving
 
 // MoveDown moves the selection down to next row, using given select mode
-// (from keyboard modifiers) -- returns newly selected row or -1 if failed
+// (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MoveDown(selMode events.SelectModes) int {
-	if sv.SelectedIdx >= sv.SliceSize-1 {
-		sv.SelectedIdx = sv.SliceSize - 1
+	vi := sv.ViewIdx(sv.SelectedIdx)
+	if vi >= sv.SliceSize-1 {
+		sv.SelectedIdx = sv.SliceIdx(sv.SliceSize - 1)
 		return -1
 	}
-	sv.SelectedIdx++
+	sv.SelectedIdx = sv.SliceIdx(vi + 1)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1310,11 +1487,12 @@ func (sv *SliceViewBase) MoveDownAction(selMode events.SelectModes) int {
 // MoveUp moves the selection up to previous idx, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MoveUp(selMode events.SelectModes) int {
-	if sv.SelectedIdx <= 0 {
-		sv.SelectedIdx = 0
+	vi := sv.ViewIdx(sv.SelectedIdx)
+	if vi <= 0 {
+		sv.SelectedIdx = sv.SliceIdx(0)
 		return -1
 	}
-	sv.SelectedIdx--
+	sv.SelectedIdx = sv.SliceIdx(vi - 1)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1333,12 +1511,13 @@ func (sv *SliceViewBase) MoveUpAction(selMode events.SelectModes) int {
 // MovePageDown moves the selection down to next page, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MovePageDown(selMode events.SelectModes) int {
-	if sv.SelectedIdx >= sv.SliceSize-1 {
-		sv.SelectedIdx = sv.SliceSize - 1
+	vi := sv.ViewIdx(sv.SelectedIdx)
+	if vi >= sv.SliceSize-1 {
+		sv.SelectedIdx = sv.SliceIdx(sv.SliceSize - 1)
 		return -1
 	}
-	sv.SelectedIdx += sv.VisRows
-	sv.SelectedIdx = min(sv.SelectedIdx, sv.SliceSize-1)
+	vi = min(vi+sv.VisRows, sv.SliceSize-1)
+	sv.SelectedIdx = sv.SliceIdx(vi)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1357,12 +1536,13 @@ func (sv *SliceViewBase) MovePageDownAction(selMode events.SelectModes) int {
 // MovePageUp moves the selection up to previous page, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MovePageUp(selMode events.SelectModes) int {
-	if sv.SelectedIdx <= 0 {
-		sv.SelectedIdx = 0
+	vi := sv.ViewIdx(sv.SelectedIdx)
+	if vi <= 0 {
+		sv.SelectedIdx = sv.SliceIdx(0)
 		return -1
 	}
-	sv.SelectedIdx -= sv.VisRows
-	sv.SelectedIdx = max(0, sv.SelectedIdx)
+	vi = max(0, vi-sv.VisRows)
+	sv.SelectedIdx = sv.SliceIdx(vi)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1410,14 +1590,14 @@ func (sv *SliceViewBase) SelectIdxWidgets(idx int, sel bool) bool {
 	if !sv.IsIdxVisible(idx) {
 		return false
 	}
-	sv.This().(SliceViewer).SelectRowWidgets(idx-sv.StartIdx, sel)
+	sv.This().(SliceViewer).SelectRowWidgets(sv.ViewIdx(idx)-sv.StartIdx, sel)
 	return true
 }
 
 // UpdateSelectRow updates the selection for the given row
 // callback from widgetsig select
 func (sv *SliceViewBase) UpdateSelectRow(row int) {
-	idx := row + sv.StartIdx
+	idx := sv.SliceIdx(row + sv.StartIdx)
 	sel := !sv.IdxIsSelected(idx)
 	sv.UpdateSelectIdx(idx, sel)
 }
@@ -1464,7 +1644,7 @@ func (sv *SliceViewBase) SelectedIdxsList(descendingSort bool) []int {
 	rws := make([]int, len(sv.SelectedIdxs))
 	i := 0
 	for r := range sv.SelectedIdxs {
-		if r >= sv.SliceSize { // double safety check at this point
+		if r >= sv.SliceNPVal.Len() { // double safety check at this point
 			delete(sv.SelectedIdxs, r)
 			rws = rws[:len(rws)-1]
 			continue
@@ -1514,7 +1694,8 @@ func (sv *SliceViewBase) SelectAllIdxs() {
 
 	sv.UnselectAllIdxs()
 	sv.SelectedIdxs = make(map[int]struct{}, sv.SliceSize)
-	for idx := 0; idx < sv.SliceSize; idx++ {
+	for vi := 0; vi < sv.SliceSize; vi++ {
+		idx := sv.SliceIdx(vi)
 		sv.SelectedIdxs[idx] = struct{}{}
 		sv.SelectIdxWidgets(idx, true)
 	}
@@ -1527,7 +1708,7 @@ func (sv *SliceViewBase) SelectIdxAction(idx int, mode events.SelectModes) {
 	if mode == events.NoSelect {
 		return
 	}
-	idx = min(idx, sv.SliceSize-1)
+	idx = min(idx, sv.SliceNPVal.Len()-1)
 	if idx < 0 {
 		sv.ResetSelectedIdxs()
 		return
@@ -1558,29 +1739,34 @@ func (sv *SliceViewBase) SelectIdxAction(idx int, mode events.SelectModes) {
 			sv.SelectIdx(idx)
 			sv.IdxGrabFocus(idx)
 			sv.Send(events.Select) //  sv.SelectedIdx)
-		} else {
+		} else { // extend in view order
 			minIdx := -1
 			maxIdx := 0
 			for r := range sv.SelectedIdxs {
+				vr := sv.ViewIdx(r)
+				if vr < 0 {
+					continue
+				}
 				if minIdx < 0 {
-					minIdx = r
+					minIdx = vr
 				} else {
-					minIdx = min(minIdx, r)
+					minIdx = min(minIdx, vr)
 				}
-				maxIdx = max(maxIdx, r)
+				maxIdx = max(maxIdx, vr)
 			}
-			cidx := idx
+			vidx := sv.ViewIdx(idx)
+			cidx := vidx
 			sv.SelectedIdx = idx
 			sv.SelectIdx(idx)
-			if idx < minIdx {
-				for cidx < minIdx {
+			if vidx < minIdx {
+				for cidx >= 0 && cidx < minIdx {
 					r := sv.MoveDown(events.SelectQuiet) // just select
-					cidx = r
+					cidx = sv.ViewIdx(r)
 				}
-			} else if idx > maxIdx {
+			} else if vidx > maxIdx {
 				for cidx > maxIdx {
 					r := sv.MoveUp(events.SelectQuiet) // just select
-					cidx = r
+					cidx = sv.ViewIdx(r)
 				}
 			}
 			sv.IdxGrabFocus(idx)
@@ -1664,6 +1850,12 @@ func (sv *SliceViewBase) CopySelToMime() mimedata.Mimes {
 		return nil
 	}
 	ixs := sv.SelectedIdxsList(false) // ascending
+	if sv.Idxs != nil {
+		// copy in the order shown
+		sort.SliceStable(ixs, func(i, j int) bool {
+			return sv.ViewIdx(ixs[i]) < sv.ViewIdx(ixs[j])
+		})
+	}
 	md := make(mimedata.Mimes, 0, nitms)
 	for _, i := range ixs {
 		sv.MimeDataIdx(&md, i)
@@ -1817,6 +2009,7 @@ func (sv *SliceViewBase) PasteAtIdx(md mimedata.Mimes, idx int) {
 	updt := sv.UpdateStart()
 	defer sv.UpdateEndLayout(updt)
 
+	ni := min(max(idx, 0), svnp.Len()) // index of the first new element
 	for _, ns := range sl {
 		sz := svnp.Len()
 		svnp = reflect.Append(svnp, reflect.ValueOf(ns).Elem())
@@ -1830,6 +2023,7 @@ func (sv *SliceViewBase) PasteAtIdx(md mimedata.Mimes, idx int) {
 	}
 
 	sv.SliceNPVal = laser.NonPtrValue(reflect.ValueOf(sv.Slice)) // need to update after changes
+	sv.IdxsInserted(ni, len(sl))
 
 	if sv.TmpSave != nil {
 		sv.TmpSave.SaveTmp()
@@ -1867,7 +2061,7 @@ func (sv *SliceViewBase) DragNDropStart() {
 	md := sv.This().(SliceViewer).CopySelToMime()
 	_ = md
 	ixs := sv.SelectedIdxsList(false) // ascending
-	widg, ok := sv.This().(SliceViewer).RowFirstWidget(ixs[0])
+	widg, ok := sv.This().(SliceViewer).RowFirstWidget(sv.ViewIdx(ixs[0]) - sv.StartIdx)
 	if ok {
 		sp := &gi.Sprite{}
 		sp.GrabRenderFrom(widg)
@@ -2157,31 +2351,31 @@ func (sv *SliceViewBase) KeyInputReadOnly(kt events.Event) {
 		}
 	}
 	kf := keyfun.Of(kt.KeyChord())
-	idx := sv.SelectedIdx
+	vi := sv.ViewIdx(sv.SelectedIdx) // navigate in view order
 	switch {
 	case kf == keyfun.MoveDown:
-		ni := idx + 1
+		ni := vi + 1
 		if ni < sv.SliceSize {
-			sv.ScrollToIdx(ni)
-			sv.UpdateSelectIdx(ni, true)
+			sv.ScrollToIdx(sv.SliceIdx(ni))
+			sv.UpdateSelectIdx(sv.SliceIdx(ni), true)
 			kt.SetHandled()
 		}
 	case kf == keyfun.MoveUp:
-		ni := idx - 1
+		ni := vi - 1
 		if ni >= 0 {
-			sv.ScrollToIdx(ni)
-			sv.UpdateSelectIdx(ni, true)
+			sv.ScrollToIdx(sv.SliceIdx(ni))
+			sv.UpdateSelectIdx(sv.SliceIdx(ni), true)
 			kt.SetHandled()
 		}
 	case kf == keyfun.PageDown:
-		ni := min(idx+sv.VisRows-1, sv.SliceSize-1)
-		sv.ScrollToIdx(ni)
-		sv.UpdateSelectIdx(ni, true)
+		ni := min(vi+sv.VisRows-1, sv.SliceSize-1)
+		sv.ScrollToIdx(sv.SliceIdx(ni))
+		sv.UpdateSelectIdx(sv.SliceIdx(ni), true)
 		kt.SetHandled()
 	case kf == keyfun.PageUp:
-		ni := max(idx-(sv.VisRows-1), 0)
-		sv.ScrollToIdx(ni)
-		sv.UpdateSelectIdx(ni, true)
+		ni := max(vi-(sv.VisRows-1), 0)
+		sv.ScrollToIdx(sv.SliceIdx(ni))
+		sv.UpdateSelectIdx(sv.SliceIdx(ni), true)
 		kt.SetHandled()
 	case kf == keyfun.Enter || kf == keyfun.Accept || kt.KeyRune() == ' ':
 		sv.Send(events.DoubleClick, kt)
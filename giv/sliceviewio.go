@@ -0,0 +1,603 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/styles"
+	"goki.dev/girl/units"
+	"goki.dev/goosi/events"
+	"goki.dev/icons"
+	"goki.dev/ki/v2"
+	"goki.dev/laser"
+)
+
+// SliceViewExportExts are the extensions of the file formats that the rows
+// of a SliceView or TableView can be exported to (see ExportFile):
+// comma or tab separated values, or JSON.  Import is from CSV or TSV.
+// The Import and Export buttons are shown in the toolbar when the
+// SliceViewShowImportExport flag is set.
+const SliceViewExportExts = ".csv,.tsv,.json"
+
+// SliceImportPreviewMax is the maximum number of rows shown in the
+// preview of the ImportDialog
+var SliceImportPreviewMax = 100
+
+// SliceViewExporter is an optional interface for a SliceViewer whose
+// elements are exported and imported as more than one column, as for
+// a TableView (see FieldsExporter) -- otherwise each element is exported
+// as a single value, without a header
+type SliceViewExporter interface {
+
+	// ExportLabels returns the labels of the columns that rows are
+	// exported with, which are the headers of CSV and TSV files
+	// and the keys of JSON objects
+	ExportLabels() []string
+
+	// ExportValues returns the values of the columns exported
+	// for given addressable, non-pointer element value
+	ExportValues(el reflect.Value) []reflect.Value
+
+	// ImportCol returns the index of the exported column with
+	// given label in the header of an imported file, or -1 if none
+	ImportCol(label string) int
+}
+
+// FileSep returns the separator of the values in a CSV or TSV file
+// with given name: a tab for a .tsv file, and a comma otherwise
+func FileSep(fname string) rune {
+	if strings.ToLower(filepath.Ext(fname)) == ".tsv" {
+		return '\t'
+	}
+	return ','
+}
+
+////////////////////////////////////////////////////////////
+//  Export
+
+// Exporter returns the SliceViewExporter for the view if it has one
+// (see SliceViewExporter), or nil if each element is a single value
+func (sv *SliceViewBase) Exporter() SliceViewExporter {
+	ex, _ := sv.This().(SliceViewExporter)
+	return ex
+}
+
+// ViewElems returns the non-pointer values of the elements of the slice
+// that are shown in the view (see Idxs), in the order they are shown
+func (sv *SliceViewBase) ViewElems() []reflect.Value {
+	n := sv.SliceNPVal.Len()
+	els := make([]reflect.Value, 0, n)
+	if sv.Idxs == nil {
+		for si := 0; si < n; si++ {
+			els = append(els, laser.NonPtrValue(sv.SliceNPVal.Index(si)))
+		}
+		return els
+	}
+	for _, si := range sv.Idxs {
+		if si < n {
+			els = append(els, laser.NonPtrValue(sv.SliceNPVal.Index(si)))
+		}
+	}
+	return els
+}
+
+// exportValues returns the values of the columns of given element
+// exported with given exporter, or just the element if it is nil
+func exportValues(ex SliceViewExporter, el reflect.Value) []reflect.Value {
+	if ex == nil {
+		return []reflect.Value{el}
+	}
+	return ex.ExportValues(el)
+}
+
+// ExportRecords returns the text of the values of given elements, with
+// the columns of given exporter (which can be nil for a single value),
+// preceded by a header with its ExportLabels
+func ExportRecords(els []reflect.Value, ex SliceViewExporter) [][]string {
+	var recs [][]string
+	if ex != nil {
+		recs = append(recs, ex.ExportLabels())
+	}
+	for _, el := range els {
+		vals := exportValues(ex, el)
+		rec := make([]string, len(vals))
+		for i, v := range vals {
+			rec[i] = ValueText(v)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// WriteCSV writes given elements (see ExportRecords) to given writer as
+// comma separated values, or using given separator instead, e.g., '\t'
+// for tab separated values
+func WriteCSV(w io.Writer, els []reflect.Value, ex SliceViewExporter, sep rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	return cw.WriteAll(ExportRecords(els, ex))
+}
+
+// jsonObject is a JSON object with its keys in a given order
+type jsonObject struct {
+	keys []string
+	vals []any
+}
+
+func (jo *jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range jo.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(jo.vals[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(kb)
+		b.WriteByte(':')
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// WriteJSON writes given elements to given writer as a JSON array, with
+// each element an object with the ExportLabels of given exporter as keys,
+// or just the element if the exporter is nil
+func WriteJSON(w io.Writer, els []reflect.Value, ex SliceViewExporter) error {
+	rows := make([]any, len(els))
+	for i, el := range els {
+		if ex == nil {
+			rows[i] = el.Interface()
+			continue
+		}
+		vals := ex.ExportValues(el)
+		jo := &jsonObject{keys: ex.ExportLabels(), vals: make([]any, len(vals))}
+		for j, v := range vals {
+			jo.vals[j] = v.Interface()
+		}
+		rows[i] = jo
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(rows)
+}
+
+// WriteCSV writes the rows shown in the view, in the order they are shown,
+// to given writer as comma separated values, or using given separator
+// instead (see WriteCSV)
+func (sv *SliceViewBase) WriteCSV(w io.Writer, sep rune) error {
+	sv.ViewMuLock()
+	defer sv.ViewMuUnlock()
+	return WriteCSV(w, sv.ViewElems(), sv.Exporter(), sep)
+}
+
+// WriteJSON writes the rows shown in the view, in the order they are
+// shown, to given writer as a JSON array (see WriteJSON)
+func (sv *SliceViewBase) WriteJSON(w io.Writer) error {
+	sv.ViewMuLock()
+	defer sv.ViewMuUnlock()
+	return WriteJSON(w, sv.ViewElems(), sv.Exporter())
+}
+
+// ExportFile writes the rows shown in the view to the file with given name,
+// in the format given by its extension: .json for JSON (see WriteJSON),
+// .tsv for tab separated values, and otherwise comma separated values
+// (see WriteCSV)
+func (sv *SliceViewBase) ExportFile(fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(filepath.Ext(fname)) == ".json" {
+		err = sv.WriteJSON(f)
+	} else {
+		err = sv.WriteCSV(f, FileSep(fname))
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ExportDialog opens a dialog for choosing a file to export the rows
+// shown in the view to (see ExportFile)
+func (sv *SliceViewBase) ExportDialog() {
+	dlg := FileViewDialog(gi.NewDialog(sv).Title("Export rows").
+		Prompt("Export the rows shown, in the order shown, to a .csv, .tsv or .json file"), "", SliceViewExportExts)
+	dlg.OnAccept(func(e events.Event) {
+		fname := dlg.Data.(string)
+		if err := sv.ExportFile(fname); err != nil {
+			gi.NewSnackbar(sv, gi.SnackbarOpts{Text: "Export failed: " + err.Error()}).Run()
+		}
+	}).Run()
+}
+
+////////////////////////////////////////////////////////////
+//  Import
+
+// SliceImport holds rows read from a CSV or TSV file to be imported into
+// a SliceView or TableView, with each value converted to the type of the
+// column it is imported into (see ReadCSV and ImportDialog)
+type SliceImport struct {
+
+	// labels of the columns in the header of the file, or nil if the
+	// elements have no SliceViewExporter, in which case there is no header
+	Header []string
+
+	// index of the exported column (see ExportLabels) that each column of
+	// the file is imported into, initially matching its header to a label
+	// or field name ignoring case, or -1 if it is not imported -- it can be
+	// changed in the ImportDialog, and then the values Converted again
+	Cols []int
+
+	// records of the file, not including the header
+	Records [][]string
+
+	// type of the elements of the slice
+	ElType reflect.Type
+
+	// new elements converted from the Records, of the element type of the slice
+	Elems []reflect.Value
+
+	// errors converting each value of the Records, which are nil for values
+	// that were converted, or are left blank
+	Errors [][]error
+
+	// total number of conversion errors
+	NErrors int
+}
+
+// ReadCSV reads rows to import into a slice of elements of given type from
+// given reader of comma separated values, or using given separator instead,
+// e.g., '\t' for tab separated values.  If there is a SliceViewExporter,
+// the first row is a header, which determines the column that each value
+// is imported into (see SliceImport.Cols), and otherwise there is just one
+// column.  The values are then converted (see SliceImport.Convert).
+func ReadCSV(r io.Reader, sep rune, eltyp reflect.Type, ex SliceViewExporter) (*SliceImport, error) {
+	if ki.IsKi(eltyp) {
+		return nil, fmt.Errorf("giv.ReadCSV: cannot import elements of Ki type %v", eltyp)
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = sep
+	cr.FieldsPerRecord = -1
+	recs, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("giv.ReadCSV: %w", err)
+	}
+	im := &SliceImport{ElType: eltyp}
+	if ex != nil && len(recs) > 0 {
+		im.Header = recs[0]
+		recs = recs[1:]
+	}
+	im.Records = recs
+	ncol := len(im.Header)
+	for _, rec := range recs {
+		ncol = max(ncol, len(rec))
+	}
+	im.Cols = make([]int, ncol)
+	for ci := range im.Cols {
+		im.Cols[ci] = -1
+		switch {
+		case ex == nil:
+			if ci == 0 {
+				im.Cols[ci] = 0
+			}
+		case ci < len(im.Header):
+			im.Cols[ci] = ex.ImportCol(strings.TrimSpace(im.Header[ci]))
+		}
+	}
+	im.Convert(ex)
+	return im, nil
+}
+
+// Convert converts the Records into new Elems, with each value imported
+// into the exported column of given exporter (nil for a single value)
+// given by Cols, using laser.SetRobust, and records any Errors
+func (im *SliceImport) Convert(ex SliceViewExporter) {
+	nptyp := laser.NonPtrType(im.ElType)
+	im.Elems = make([]reflect.Value, len(im.Records))
+	im.Errors = make([][]error, len(im.Records))
+	im.NErrors = 0
+	for ri, rec := range im.Records {
+		nv := reflect.New(nptyp)
+		vals := exportValues(ex, nv.Elem())
+		im.Errors[ri] = make([]error, len(rec))
+		for ci, txt := range rec {
+			c := im.Cols[ci]
+			if c < 0 || c >= len(vals) || strings.TrimSpace(txt) == "" || !vals[c].CanAddr() {
+				continue
+			}
+			if err := laser.SetRobust(vals[c].Addr().Interface(), txt); err != nil {
+				im.Errors[ri][ci] = fmt.Errorf("cannot convert %q to %v: %w", txt, vals[c].Type(), err)
+				im.NErrors++
+			}
+		}
+		if im.ElType.Kind() == reflect.Ptr {
+			im.Elems[ri] = nv
+		} else {
+			im.Elems[ri] = nv.Elem()
+		}
+	}
+}
+
+// ReadCSV reads rows to import into the slice from given reader of comma
+// separated values, or using given separator instead (see ReadCSV).
+// The slice is not modified: see ImportRows.
+func (sv *SliceViewBase) ReadCSV(r io.Reader, sep rune) (*SliceImport, error) {
+	return ReadCSV(r, sep, laser.SliceElType(sv.Slice), sv.Exporter())
+}
+
+// OpenCSV reads rows to import from the CSV or TSV file with given name
+// (see ReadCSV and FileSep)
+func (sv *SliceViewBase) OpenCSV(fname string) (*SliceImport, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return sv.ReadCSV(f, FileSep(fname))
+}
+
+// ImportRows appends the elements read from a file to the end of the
+// slice, where they are shown at the end of the view (see IdxsInserted)
+func (sv *SliceViewBase) ImportRows(im *SliceImport) {
+	if sv.Is(SliceViewIsArray) || sv.IsReadOnly() || len(im.Elems) == 0 {
+		return
+	}
+	sv.ViewMuLock() // no return!  must unlock before return below
+
+	updt := sv.UpdateStart()
+	defer sv.UpdateEndLayout(updt)
+
+	sz := sv.SliceNPVal.Len()
+	svnp := reflect.Append(sv.SliceNPVal, im.Elems...)
+	reflect.ValueOf(sv.Slice).Elem().Set(svnp)
+	sv.SliceNPVal = laser.NonPtrValue(reflect.ValueOf(sv.Slice)) // need to update after changes
+	sv.IdxsInserted(sz, len(im.Elems))
+	sv.This().(SliceViewer).UpdtSliceSize()
+
+	if sv.TmpSave != nil {
+		sv.TmpSave.SaveTmp()
+	}
+	sv.ViewMuUnlock()
+	sv.SetChanged()
+	sv.Update()
+}
+
+// importSummary returns the summary of the rows to import shown
+// in the ImportDialog
+func importSummary(im *SliceImport) string {
+	sum := fmt.Sprintf("Import %d rows", len(im.Records))
+	if im.NErrors > 0 {
+		sum += fmt.Sprintf(", with %d values that could not be converted (shown in red), which are left blank", im.NErrors)
+	}
+	return sum
+}
+
+// ImportDialog opens a dialog previewing the rows read from a file, with the
+// values that could not be converted shown in the error color and their
+// errors in tooltips, which imports them into the slice if accepted.
+// If the file has a header, the column that each of its columns is imported
+// into is chosen under it, and the preview is updated for the choice.
+func (sv *SliceViewBase) ImportDialog(im *SliceImport) {
+	ex := sv.Exporter()
+	dlg := gi.NewDialog(sv).Title("Import rows")
+	if im.Header != nil {
+		dlg.Prompt("Choose the column that each column of the file is imported into, and check the preview")
+	}
+	sum := gi.NewLabel(dlg.Scene, "summary").SetText(importSummary(im))
+
+	ncol := len(im.Cols)
+	grid := gi.NewFrame(dlg.Scene, "preview")
+	grid.Lay = gi.LayoutGrid
+	grid.Stripes = gi.RowStripes
+	grid.Style(func(s *styles.Style) {
+		s.Columns = max(ncol, 1)
+		s.SetMinPrefHeight(units.Em(10))
+		s.SetStretchMax()
+		s.Overflow = styles.OverflowScroll
+	})
+	var cells [][]*gi.Label
+	setTooltips := func() {
+		for ri, rlbls := range cells {
+			for ci, lbl := range rlbls {
+				lbl.Tooltip = ""
+				if ci < len(im.Errors[ri]) && im.Errors[ri][ci] != nil {
+					lbl.Tooltip = im.Errors[ri][ci].Error()
+				}
+			}
+		}
+	}
+	if im.Header != nil {
+		lbls := ex.ExportLabels()
+		items := append([]string{"(not imported)"}, lbls...)
+		for ci, hdr := range im.Header {
+			gi.NewLabel(grid, fmt.Sprintf("head-%d", ci)).SetText(html.EscapeString(hdr)).SetType(gi.LabelTitleSmall)
+		}
+		for ci := len(im.Header); ci < ncol; ci++ {
+			gi.NewLabel(grid, fmt.Sprintf("head-%d", ci))
+		}
+		for ci := range im.Header {
+			ci := ci
+			ch := gi.NewChooser(grid, fmt.Sprintf("col-%d", ci)).ItemsFromStringList(items, false, 0)
+			ch.SetTooltip("the column that this column of the file is imported into")
+			ch.SelectItem(im.Cols[ci] + 1)
+			ch.OnChange(func(e events.Event) {
+				im.Cols[ci] = ch.CurIndex - 1
+				im.Convert(ex)
+				setTooltips()
+				sum.SetText(importSummary(im))
+				sum.ApplyStyleUpdate(sum.Sc)
+				grid.ApplyStyleTree(grid.Sc)
+				grid.SetNeedsLayout()
+			})
+		}
+		for ci := len(im.Header); ci < ncol; ci++ {
+			gi.NewLabel(grid, fmt.Sprintf("col-%d", ci))
+		}
+	}
+	for ri, rec := range im.Records {
+		if ri >= SliceImportPreviewMax {
+			break
+		}
+		ri := ri
+		rlbls := make([]*gi.Label, ncol)
+		for ci := 0; ci < ncol; ci++ {
+			ci := ci
+			txt := ""
+			if ci < len(rec) {
+				txt = rec[ci]
+			}
+			lbl := gi.NewLabel(grid, fmt.Sprintf("cell-%d-%d", ri, ci)).SetText(html.EscapeString(txt))
+			lbl.Style(func(s *styles.Style) {
+				switch {
+				case ci < len(im.Errors[ri]) && im.Errors[ri][ci] != nil:
+					s.Color = colors.Scheme.Error.Base
+				case im.Cols[ci] < 0:
+					s.Color = colors.Scheme.OnSurfaceVariant
+				}
+			})
+			rlbls[ci] = lbl
+		}
+		cells = append(cells, rlbls)
+	}
+	if len(im.Records) > SliceImportPreviewMax {
+		gi.NewLabel(dlg.Scene, "more").SetText(fmt.Sprintf("... and %d more rows", len(im.Records)-SliceImportPreviewMax))
+	}
+	setTooltips()
+	dlg.Cancel().Ok("Import")
+	dlg.OnAccept(func(e events.Event) {
+		sv.ImportRows(im)
+	}).Run()
+}
+
+// ImportFileDialog opens a dialog for choosing a CSV or TSV file to import
+// rows from, followed by the ImportDialog to preview them
+func (sv *SliceViewBase) ImportFileDialog() {
+	dlg := FileViewDialog(gi.NewDialog(sv).Title("Import rows").
+		Prompt("Import rows from a .csv or .tsv file, with a header of column labels for a table"), "", ".csv,.tsv")
+	dlg.OnAccept(func(e events.Event) {
+		fname := dlg.Data.(string)
+		im, err := sv.OpenCSV(fname)
+		if err != nil {
+			gi.NewSnackbar(sv, gi.SnackbarOpts{Text: "Import failed: " + err.Error()}).Run()
+			return
+		}
+		sv.ImportDialog(im)
+	}).Run()
+}
+
+// NImportExport returns the number of buttons that ConfigImportExport
+// adds to the toolbar with given canImport
+func (sv *SliceViewBase) NImportExport(canImport bool) int {
+	switch {
+	case !sv.Is(SliceViewShowImportExport):
+		return 0
+	case canImport && !sv.IsReadOnly():
+		return 2
+	}
+	return 1
+}
+
+// ConfigImportExport adds the Import (if canImport, and the view is not
+// ReadOnly) and Export buttons to given toolbar, if the
+// SliceViewShowImportExport flag is set
+func (sv *SliceViewBase) ConfigImportExport(tb *gi.Toolbar, canImport bool) {
+	if !sv.Is(SliceViewShowImportExport) {
+		return
+	}
+	if canImport && !sv.IsReadOnly() {
+		gi.NewButton(tb, "import").SetText("Import").SetIcon(icons.Open).
+			SetTooltip("import rows from a .csv or .tsv file, added at the end").
+			OnClick(func(e events.Event) {
+				sv.ImportFileDialog()
+			})
+	}
+	gi.NewButton(tb, "export").SetText("Export").SetIcon(icons.SaveAs).
+		SetTooltip("export the rows shown, in the order shown, to a .csv, .tsv or .json file").
+		OnClick(func(e events.Event) {
+			sv.ExportDialog()
+		})
+}
+
+////////////////////////////////////////////////////////////
+//  TableView
+
+// TableFieldLabel returns the label of given field in a TableView when
+// exported, which is its label tag if it has one, and otherwise its name
+func TableFieldLabel(fld reflect.StructField) string {
+	if lbl, has := fld.Tag.Lookup("label"); has {
+		return lbl
+	}
+	return fld.Name
+}
+
+// FieldsExporter is a SliceViewExporter for struct elements, with a column
+// for each of its fields, as used for the visible fields of a TableView
+type FieldsExporter []reflect.StructField
+
+// ExportLabels returns the labels of the fields (see TableFieldLabel)
+func (fe FieldsExporter) ExportLabels() []string {
+	lbls := make([]string, len(fe))
+	for fli, fld := range fe {
+		lbls[fli] = TableFieldLabel(fld)
+	}
+	return lbls
+}
+
+// ExportValues returns the values of the fields of given struct
+func (fe FieldsExporter) ExportValues(el reflect.Value) []reflect.Value {
+	vals := make([]reflect.Value, len(fe))
+	for fli, fld := range fe {
+		vals[fli] = el.FieldByIndex(fld.Index)
+	}
+	return vals
+}
+
+// ImportCol returns the index of the field with given label or
+// name, ignoring case, or -1 if there is none
+func (fe FieldsExporter) ImportCol(label string) int {
+	for fli, fld := range fe {
+		if strings.EqualFold(TableFieldLabel(fld), label) || strings.EqualFold(fld.Name, label) {
+			return fli
+		}
+	}
+	return -1
+}
+
+// ExportLabels returns the labels of the visible fields (see FieldsExporter)
+func (tv *TableView) ExportLabels() []string {
+	return FieldsExporter(tv.VisFields).ExportLabels()
+}
+
+// ExportValues returns the values of the visible fields of given struct
+func (tv *TableView) ExportValues(el reflect.Value) []reflect.Value {
+	return FieldsExporter(tv.VisFields).ExportValues(el)
+}
+
+// ImportCol returns the index of the visible field with given label or
+// name, ignoring case, or -1 if there is none
+func (tv *TableView) ImportCol(label string) int {
+	return FieldsExporter(tv.VisFields).ImportCol(label)
+}
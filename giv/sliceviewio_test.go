@@ -0,0 +1,143 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type sliceIOTest struct {
+	Name  string
+	Count int `label:"Number"`
+	Rate  float64
+	On    bool
+}
+
+var sliceIOTests = []sliceIOTest{
+	{"a, with a comma", 1, 0.5, true},
+	{"b \"quoted\"", -2, 1e-3, false},
+	{"c\nnew line", 0, 0, true},
+}
+
+func sliceIOElems[T any](sl []T) []reflect.Value {
+	els := make([]reflect.Value, len(sl))
+	for i := range sl {
+		els[i] = reflect.ValueOf(&sl[i]).Elem()
+	}
+	return els
+}
+
+func sliceIOExporter() FieldsExporter {
+	return FieldsExporter(reflect.VisibleFields(reflect.TypeOf(sliceIOTest{})))
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	ex := sliceIOExporter()
+	for _, sep := range []rune{',', '\t'} {
+		var b bytes.Buffer
+		if err := WriteCSV(&b, sliceIOElems(sliceIOTests), ex, sep); err != nil {
+			t.Fatal(err)
+		}
+		if hdr, _, _ := strings.Cut(b.String(), "\n"); hdr != strings.Join([]string{"Name", "Number", "Rate", "On"}, string(sep)) {
+			t.Errorf("header = %q", hdr)
+		}
+		im, err := ReadCSV(&b, sep, reflect.TypeOf(sliceIOTest{}), ex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if im.NErrors != 0 || !slices.Equal(im.Cols, []int{0, 1, 2, 3}) {
+			t.Errorf("%d errors, Cols = %v", im.NErrors, im.Cols)
+		}
+		var got []sliceIOTest
+		for _, el := range im.Elems {
+			got = append(got, el.Interface().(sliceIOTest))
+		}
+		if !slices.Equal(got, sliceIOTests) {
+			t.Errorf("read back %v, want %v", got, sliceIOTests)
+		}
+	}
+
+	// pointer elements, reordered columns matched by label or name ignoring
+	// case, an unknown column, and a value that cannot be converted
+	csv := "on,RATE,count,Other,name\ntrue,2.5,7,x,p\nfalse,bad,8,y,q\n"
+	im, err := ReadCSV(strings.NewReader(csv), ',', reflect.TypeOf(&sliceIOTest{}), ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 2, 1, -1, 0}; !slices.Equal(im.Cols, want) {
+		t.Errorf("Cols = %v, want %v", im.Cols, want)
+	}
+	if im.NErrors != 1 || im.Errors[1][1] == nil {
+		t.Errorf("errors = %v", im.Errors)
+	}
+	if got := *im.Elems[1].Interface().(*sliceIOTest); got != (sliceIOTest{"q", 8, 0, false}) {
+		t.Errorf("second element = %v", got)
+	}
+	im.Cols[1] = -1 // the rate is not imported
+	im.Convert(ex)
+	if im.NErrors != 0 || im.Elems[0].Elem().Interface().(sliceIOTest).Rate != 0 {
+		t.Errorf("after not importing the rate: %d errors, %v", im.NErrors, im.Elems[0].Elem())
+	}
+
+	// single values, without a header
+	ints := []int{3, 1, 2}
+	var b bytes.Buffer
+	if err := WriteCSV(&b, sliceIOElems(ints), nil, ','); err != nil {
+		t.Fatal(err)
+	}
+	im, err = ReadCSV(&b, ',', reflect.TypeOf(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, el := range im.Elems {
+		got = append(got, el.Interface().(int))
+	}
+	if !slices.Equal(got, ints) || im.Header != nil {
+		t.Errorf("read back %v with header %v, want %v", got, im.Header, ints)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJSON(&b, sliceIOElems(sliceIOTests), sliceIOExporter()); err != nil {
+		t.Fatal(err)
+	}
+	var cb bytes.Buffer
+	if err := json.Compact(&cb, b.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cb.String(), `{"Name":"a, with a comma","Number":1,"Rate":0.5,"On":true}`) {
+		t.Errorf("keys are not the labels in order:\n%s", b.String())
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(b.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(sliceIOTests) {
+		t.Fatalf("%d rows, want %d", len(rows), len(sliceIOTests))
+	}
+	for i, row := range rows {
+		st := sliceIOTests[i]
+		if row["Name"] != st.Name || row["Number"] != float64(st.Count) || row["Rate"] != st.Rate || row["On"] != st.On {
+			t.Errorf("row %d = %v, want %v", i, row, st)
+		}
+	}
+
+	// single values
+	b.Reset()
+	if err := WriteJSON(&b, sliceIOElems([]string{"x", "y"}), nil); err != nil {
+		t.Fatal(err)
+	}
+	var strs []string
+	if err := json.Unmarshal(b.Bytes(), &strs); err != nil || !slices.Equal(strs, []string{"x", "y"}) {
+		t.Errorf("single values = %v, %v", strs, err)
+	}
+}
@@ -0,0 +1,512 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/keyfun"
+	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
+	"goki.dev/goosi/mimedata"
+	"goki.dev/laser"
+	"goki.dev/pi/v2/filecat"
+)
+
+// TableCell is the position of a cell of a TableView in CellMode: the view
+// index of its row (see SliceViewBase.Idxs) and the index of the visible
+// field of its column
+type TableCell struct {
+	Row int
+	Col int
+}
+
+// TableCellEdit records the change of the value of one cell of a TableView
+// made by an edit in CellMode, which can be undone
+type TableCellEdit struct {
+
+	// index in the slice of the element of the row
+	Idx int
+
+	// index of the visible field of the column
+	Field int
+
+	// copy of the value before the edit
+	Old reflect.Value
+
+	// copy of the value after the edit
+	New reflect.Value
+}
+
+// copyValue returns an addressable copy of given value
+func copyValue(v reflect.Value) reflect.Value {
+	cv := reflect.New(v.Type()).Elem()
+	cv.Set(v)
+	return cv
+}
+
+// CellRange returns the first and last cells (inclusive) of the rectangular
+// range of selected cells, between the anchor where the selection started
+// and the current cell
+func (tv *TableView) CellRange() (st, ed TableCell) {
+	a, c := tv.cellAnchor, tv.curCell
+	st = TableCell{min(a.Row, c.Row), min(a.Col, c.Col)}
+	ed = TableCell{max(a.Row, c.Row), max(a.Col, c.Col)}
+	return
+}
+
+// RangeCells returns the cells from st to ed (inclusive), row by row
+func RangeCells(st, ed TableCell) []TableCell {
+	var cells []TableCell
+	for r := st.Row; r <= ed.Row; r++ {
+		for c := st.Col; c <= ed.Col; c++ {
+			cells = append(cells, TableCell{r, c})
+		}
+	}
+	return cells
+}
+
+// CellIsSelected returns true if given cell is in the range of selected cells
+func (tv *TableView) CellIsSelected(c TableCell) bool {
+	st, ed := tv.CellRange()
+	return c.Row >= st.Row && c.Row <= ed.Row && c.Col >= st.Col && c.Col <= ed.Col
+}
+
+// ElemFieldValue returns the addressable value of given visible field of the
+// element at given slice index, which is invalid if either is out of range
+func (tv *TableView) ElemFieldValue(idx, fli int) reflect.Value {
+	if idx < 0 || idx >= tv.SliceNPVal.Len() || fli < 0 || fli >= tv.NVisFields {
+		return reflect.Value{}
+	}
+	el := laser.NonPtrValue(tv.SliceNPVal.Index(idx))
+	if !el.IsValid() {
+		return el
+	}
+	return el.FieldByIndex(tv.VisFields[fli].Index)
+}
+
+// CellValue returns the addressable value of the field of given cell,
+// which is invalid if it is out of range
+func (tv *TableView) CellValue(c TableCell) reflect.Value {
+	if c.Row < 0 || c.Row >= tv.SliceSize {
+		return reflect.Value{}
+	}
+	return tv.ElemFieldValue(tv.SliceIdx(c.Row), c.Col)
+}
+
+// CellWidget returns the widget showing given cell, and false if
+// its row is not visible
+func (tv *TableView) CellWidget(c TableCell) (gi.Widget, bool) {
+	row := c.Row - tv.StartIdx
+	if !tv.IsRowInBounds(row) || c.Col < 0 || c.Col >= tv.NVisFields {
+		return nil, false
+	}
+	nWidgPerRow, idxOff := tv.RowWidgetNs()
+	sg := tv.SliceGrid()
+	cidx := row*nWidgPerRow + idxOff + c.Col
+	if sg.Kids.IsValidIndex(cidx) != nil {
+		return nil, false
+	}
+	return sg.Child(cidx).(gi.Widget), true
+}
+
+// SelectCell makes given cell, limited to those in the view, the current
+// cell, and scrolls to it.  With the ExtendContinuous mode (e.g., shift
+// with the arrow keys), the range of selected cells is extended from its
+// anchor to it, and otherwise it is the only selected cell.
+func (tv *TableView) SelectCell(c TableCell, mode events.SelectModes) {
+	if tv.SliceSize == 0 || tv.NVisFields == 0 {
+		return
+	}
+	c.Row = min(max(c.Row, 0), tv.SliceSize-1)
+	c.Col = min(max(c.Col, 0), tv.NVisFields-1)
+	tv.curCell = c
+	if mode != events.ExtendContinuous {
+		tv.cellAnchor = c
+	}
+	tv.cellOld = reflect.Value{}
+	if v := tv.CellValue(c); v.IsValid() {
+		tv.cellOld = copyValue(v)
+	}
+	tv.SelectedIdx = tv.SliceIdx(c.Row)
+	tv.ScrollToIdx(tv.SelectedIdx)
+	tv.UpdateCellSelect()
+}
+
+// UpdateCellSelect updates the selected state of the visible cell widgets
+// to show the range of selected cells
+func (tv *TableView) UpdateCellSelect() {
+	updt := tv.UpdateStart()
+	defer tv.UpdateEndRender(updt)
+
+	for row := 0; row < tv.VisRows; row++ {
+		for fli := 0; fli < tv.NVisFields; fli++ {
+			c := TableCell{tv.StartIdx + row, fli}
+			if w, ok := tv.CellWidget(c); ok {
+				w.AsWidget().SetSelected(c.Row < tv.SliceSize && tv.CellIsSelected(c))
+			}
+		}
+	}
+}
+
+// KeyInputCells handles the keys for navigating and editing cells in
+// CellMode, as in a spreadsheet: the arrow, page, home and end keys move
+// the current cell, extending the range of selected cells with shift;
+// copy, cut and paste use tab separated values (see CopyCells and
+// PasteCellsText); Duplicate fills down (see FillDownCells); Delete and
+// Backspace clear the selected cells; Enter edits the current cell in its
+// widget, as does typing, which replaces its text.
+func (tv *TableView) KeyInputCells(kt events.Event) {
+	kf := keyfun.Of(kt.KeyChord())
+	selMode := events.SelectModeBits(kt.Modifiers())
+	c := tv.curCell
+	handled := true
+	switch kf {
+	case keyfun.MoveUp:
+		c.Row--
+		tv.SelectCell(c, selMode)
+	case keyfun.MoveDown:
+		c.Row++
+		tv.SelectCell(c, selMode)
+	case keyfun.MoveLeft:
+		c.Col--
+		tv.SelectCell(c, selMode)
+	case keyfun.MoveRight:
+		c.Col++
+		tv.SelectCell(c, selMode)
+	case keyfun.PageUp:
+		c.Row -= max(tv.VisRows-1, 1)
+		tv.SelectCell(c, selMode)
+	case keyfun.PageDown:
+		c.Row += max(tv.VisRows-1, 1)
+		tv.SelectCell(c, selMode)
+	case keyfun.Home:
+		c.Col = 0
+		tv.SelectCell(c, selMode)
+	case keyfun.End:
+		c.Col = tv.NVisFields - 1
+		tv.SelectCell(c, selMode)
+	case keyfun.DocHome:
+		tv.SelectCell(TableCell{0, 0}, selMode)
+	case keyfun.DocEnd:
+		tv.SelectCell(TableCell{tv.SliceSize - 1, tv.NVisFields - 1}, selMode)
+	case keyfun.SelectAll:
+		tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+		tv.SelectCell(TableCell{tv.SliceSize - 1, tv.NVisFields - 1}, events.ExtendContinuous)
+	case keyfun.CancelSelect:
+		tv.SelectCell(c, events.SelectOne)
+	case keyfun.Copy:
+		tv.CopyCells()
+	case keyfun.Cut:
+		tv.CopyCells()
+		tv.ClearCells()
+	case keyfun.Paste:
+		tv.PasteCells()
+	case keyfun.Undo:
+		tv.UndoCells()
+	case keyfun.Redo:
+		tv.RedoCells()
+	case keyfun.Duplicate:
+		tv.FillDownCells()
+	case keyfun.Delete, keyfun.Backspace:
+		tv.ClearCells()
+	case keyfun.Enter:
+		tv.EditCell("")
+	default:
+		handled = false
+		r := kt.KeyRune()
+		if unicode.IsPrint(r) && !kt.HasAnyModifier(key.Control, key.Meta) {
+			tv.EditCell(string(r))
+			handled = true
+		}
+	}
+	if handled {
+		kt.SetHandled()
+	}
+}
+
+// EditCell starts editing the current cell in its widget, which gets the
+// focus.  If given text is not empty and the widget has a text field, its
+// text is replaced by it, as when typing into a cell of a spreadsheet.
+// The edit can be undone once it is done (see CellChanged).
+func (tv *TableView) EditCell(txt string) {
+	if tv.IsReadOnly() {
+		return
+	}
+	w, ok := tv.CellWidget(tv.curCell)
+	if !ok {
+		return
+	}
+	var tf *gi.TextField
+	switch tw := w.(type) {
+	case *gi.TextField:
+		tf = tw
+	case *gi.Spinner:
+		tf = tw.TextField()
+	}
+	if tf == nil || txt == "" {
+		w.AsWidget().GrabFocus()
+		return
+	}
+	tf.GrabFocus()
+	tf.SelectAll()
+	tf.DeleteSelection()
+	tf.InsertAtCursor(txt)
+}
+
+// CellChanged is called when the value of given cell has been changed
+// through its widget, which records the change so that it can be undone
+// if it is the current cell, and returns the focus to the table
+func (tv *TableView) CellChanged(c TableCell) {
+	if c != tv.curCell || !tv.cellOld.IsValid() {
+		return
+	}
+	v := tv.CellValue(c)
+	if !v.IsValid() || v.Type() != tv.cellOld.Type() {
+		return
+	}
+	tv.PushCellEdits([]TableCellEdit{{Idx: tv.SliceIdx(c.Row), Field: c.Col, Old: tv.cellOld, New: copyValue(v)}})
+	tv.cellOld = copyValue(v)
+	tv.GrabFocus()
+}
+
+// EditCells sets the values of given cells by calling given function with
+// the value of the field of each, returning false if it could not be set,
+// and records the changes as one edit, which can be undone with UndoCells.
+// Fields with an edit:"-" tag are skipped.  Returns the number of cells
+// that could not be set.
+func (tv *TableView) EditCells(cells []TableCell, fun func(c TableCell, v reflect.Value) bool) int {
+	if tv.IsReadOnly() {
+		return 0
+	}
+	var edits []TableCellEdit
+	nerr := 0
+	tv.ViewMuLock()
+	for _, c := range cells {
+		v := tv.CellValue(c)
+		if !v.IsValid() || !v.CanSet() || tv.VisFields[c.Col].Tag.Get("edit") == "-" {
+			continue
+		}
+		old := copyValue(v)
+		if !fun(c, v) {
+			nerr++
+			continue
+		}
+		edits = append(edits, TableCellEdit{Idx: tv.SliceIdx(c.Row), Field: c.Col, Old: old, New: copyValue(v)})
+	}
+	tv.ViewMuUnlock()
+	if len(edits) > 0 {
+		tv.PushCellEdits(edits)
+		tv.CellsEdited()
+	}
+	return nerr
+}
+
+// PushCellEdits records given edits as one edit that can be undone,
+// and clears the edits that can be redone
+func (tv *TableView) PushCellEdits(edits []TableCellEdit) {
+	if tv.cellUndoLen != tv.SliceNPVal.Len() { // elements have been inserted or deleted
+		tv.cellUndos = nil
+		tv.cellUndoLen = tv.SliceNPVal.Len()
+	}
+	tv.cellUndos = append(tv.cellUndos, edits)
+	tv.cellRedos = nil
+}
+
+// UndoCells undoes the last edit of cells, returning false if there is none,
+// including when elements have been inserted or deleted since it was made
+func (tv *TableView) UndoCells() bool {
+	n := len(tv.cellUndos)
+	if n == 0 || tv.cellUndoLen != tv.SliceNPVal.Len() {
+		return false
+	}
+	edits := tv.cellUndos[n-1]
+	tv.cellUndos = tv.cellUndos[:n-1]
+	tv.cellRedos = append(tv.cellRedos, edits)
+	tv.ViewMuLock()
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		if v := tv.ElemFieldValue(e.Idx, e.Field); v.IsValid() {
+			v.Set(copyValue(e.Old))
+		}
+	}
+	tv.ViewMuUnlock()
+	tv.CellsEdited()
+	return true
+}
+
+// RedoCells redoes the last edit of cells that was undone, returning false
+// if there is none
+func (tv *TableView) RedoCells() bool {
+	n := len(tv.cellRedos)
+	if n == 0 || tv.cellUndoLen != tv.SliceNPVal.Len() {
+		return false
+	}
+	edits := tv.cellRedos[n-1]
+	tv.cellRedos = tv.cellRedos[:n-1]
+	tv.cellUndos = append(tv.cellUndos, edits)
+	tv.ViewMuLock()
+	for _, e := range edits {
+		if v := tv.ElemFieldValue(e.Idx, e.Field); v.IsValid() {
+			v.Set(copyValue(e.New))
+		}
+	}
+	tv.ViewMuUnlock()
+	tv.CellsEdited()
+	return true
+}
+
+// CellsEdited updates the view after the values of cells have been edited
+func (tv *TableView) CellsEdited() {
+	if v := tv.CellValue(tv.curCell); v.IsValid() {
+		tv.cellOld = copyValue(v)
+	}
+	if tv.TmpSave != nil {
+		tv.TmpSave.SaveTmp()
+	}
+	tv.SetChanged()
+	tv.This().(SliceViewer).UpdateWidgets()
+}
+
+// SetCellText sets given value from given text, using laser.SetRobust,
+// or to its zero value if the text is blank, returning false if the
+// text could not be converted
+func SetCellText(v reflect.Value, txt string) bool {
+	if strings.TrimSpace(txt) == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return true
+	}
+	return laser.SetRobust(v.Addr().Interface(), txt) == nil
+}
+
+// ClearCells sets the selected cells to their zero values
+func (tv *TableView) ClearCells() {
+	tv.EditCells(RangeCells(tv.CellRange()), func(c TableCell, v reflect.Value) bool {
+		v.Set(reflect.Zero(v.Type()))
+		return true
+	})
+}
+
+// FillDownCells sets the selected cells in each column to the value of the
+// first selected cell in it, or to the value of the cell above it if only
+// one row is selected, as in a spreadsheet
+func (tv *TableView) FillDownCells() {
+	st, ed := tv.CellRange()
+	src := st.Row
+	if ed.Row == st.Row {
+		src--
+	} else {
+		st.Row++
+	}
+	if src < 0 {
+		return
+	}
+	tv.EditCells(RangeCells(st, ed), func(c TableCell, v reflect.Value) bool {
+		sv := tv.CellValue(TableCell{src, c.Col})
+		if !sv.IsValid() {
+			return false
+		}
+		v.Set(copyValue(sv))
+		return true
+	})
+}
+
+// CellsTSV returns the text of the values of the selected cells as tab
+// separated values, one line per row, as used by spreadsheets
+func (tv *TableView) CellsTSV() string {
+	st, ed := tv.CellRange()
+	var b bytes.Buffer
+	cw := csv.NewWriter(&b)
+	cw.Comma = '\t'
+	tv.ViewMuLock()
+	for r := st.Row; r <= ed.Row; r++ {
+		rec := make([]string, 0, ed.Col-st.Col+1)
+		for c := st.Col; c <= ed.Col; c++ {
+			rec = append(rec, ValueText(tv.CellValue(TableCell{r, c})))
+		}
+		cw.Write(rec)
+	}
+	tv.ViewMuUnlock()
+	cw.Flush()
+	return b.String()
+}
+
+// CopyCells copies the selected cells to the clipboard as tab separated
+// values (see CellsTSV)
+func (tv *TableView) CopyCells() {
+	if tv.SliceSize == 0 || tv.NVisFields == 0 {
+		return
+	}
+	tv.EventMgr().ClipBoard().Write(mimedata.NewText(tv.CellsTSV()))
+}
+
+// PasteCells pastes tab separated values from the clipboard into the cells
+// (see PasteCellsText)
+func (tv *TableView) PasteCells() {
+	md := tv.EventMgr().ClipBoard().Read([]string{filecat.TextPlain})
+	if md == nil {
+		return
+	}
+	if nerr := tv.PasteCellsText(string(md.TypeData(filecat.TextPlain))); nerr > 0 {
+		gi.NewSnackbar(tv, gi.SnackbarOpts{Text: fmt.Sprintf("%d pasted values could not be converted", nerr)}).Run()
+	}
+}
+
+// ParseTSV parses given tab separated values, as copied from a spreadsheet,
+// into rows of values.  Values that contain tabs, newlines or quotes are in
+// quotes, with their quotes doubled.  Text with quotes that are not valid
+// as such (e.g., 5" screen) is just split at every tab and newline.
+func ParseTSV(txt string) [][]string {
+	cr := csv.NewReader(strings.NewReader(txt))
+	cr.Comma = '\t'
+	cr.FieldsPerRecord = -1
+	recs, err := cr.ReadAll()
+	if err == nil {
+		return recs
+	}
+	recs = nil
+	for _, ln := range strings.Split(strings.TrimRight(txt, "\r\n"), "\n") {
+		recs = append(recs, strings.Split(strings.TrimSuffix(ln, "\r"), "\t"))
+	}
+	return recs
+}
+
+// PasteCellsText pastes given tab separated values into the cells starting
+// at the first selected cell, which are then selected, or into all of the
+// selected cells if it is a single value, as one edit that can be undone.
+// Values beyond the last row or column are ignored.  Returns the number of
+// values that could not be converted.
+func (tv *TableView) PasteCellsText(txt string) int {
+	recs := ParseTSV(txt)
+	if len(recs) == 0 {
+		return 0
+	}
+	st, ed := tv.CellRange()
+	if len(recs) == 1 && len(recs[0]) == 1 {
+		return tv.EditCells(RangeCells(st, ed), func(c TableCell, v reflect.Value) bool {
+			return SetCellText(v, recs[0][0])
+		})
+	}
+	var cells []TableCell
+	ncol := 0
+	for r, rec := range recs {
+		ncol = max(ncol, len(rec))
+		for c := range rec {
+			cells = append(cells, TableCell{st.Row + r, st.Col + c})
+		}
+	}
+	nerr := tv.EditCells(cells, func(c TableCell, v reflect.Value) bool {
+		return SetCellText(v, recs[c.Row-st.Row][c.Col-st.Col])
+	})
+	tv.SelectCell(st, events.SelectOne)
+	tv.SelectCell(TableCell{st.Row + len(recs) - 1, st.Col + ncol - 1}, events.ExtendContinuous)
+	return nerr
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"image"
+	"reflect"
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/states"
+	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
+)
+
+type tableCellsStruct struct {
+	Name string
+	N    int
+	F    float64
+	ID   int `edit:"-"`
+}
+
+// tableCellsView returns a TableView in CellMode of given slice, in an
+// 800x600 Scene that has been configured and laid out
+func tableCellsView(sl *[]tableCellsStruct) *TableView {
+	sc := gi.NewScene("table-cells")
+	gi.NewMainStage(gi.WindowStage, sc, nil)
+	sc.Geom.Size = image.Point{800, 600}
+	tv := NewTableView(sc, "tv")
+	tv.CellMode = true
+	tv.SetSlice(sl)
+	sc.ConfigScene()
+	sc.ApplyStyleScene()
+	sc.LayoutScene()
+	return tv
+}
+
+func tableCellsData() []tableCellsStruct {
+	return []tableCellsStruct{
+		{"a", 1, 1.5, 10},
+		{"b", 2, 2.5, 11},
+		{"c", 3, 3.5, 12},
+		{"d", 4, 4.5, 13},
+	}
+}
+
+// cellKey sends a key chord event with given code and modifiers to
+// the KeyInputCells of given view
+func cellKey(tv *TableView, code key.Codes, mods key.Modifiers) {
+	tv.KeyInputCells(events.NewKey(events.KeyChord, 0, code, mods))
+}
+
+func TestSelectCells(t *testing.T) {
+	data := tableCellsData()
+	tv := tableCellsView(&data)
+	if tv.NVisFields != 4 || tv.SliceSize != 4 {
+		t.Fatalf("NVisFields, SliceSize = %d, %d, want 4, 4", tv.NVisFields, tv.SliceSize)
+	}
+	tv.SelectCell(TableCell{1, 1}, events.SelectOne)
+	tv.SelectCell(TableCell{3, 0}, events.ExtendContinuous)
+	st, ed := tv.CellRange()
+	if st != (TableCell{1, 0}) || ed != (TableCell{3, 1}) {
+		t.Errorf("CellRange = %v, %v, want {1 0}, {3 1}", st, ed)
+	}
+	want := []TableCell{{1, 0}, {1, 1}, {2, 0}, {2, 1}, {3, 0}, {3, 1}}
+	if got := RangeCells(st, ed); !slices.Equal(got, want) {
+		t.Errorf("RangeCells = %v, want %v", got, want)
+	}
+	for _, tt := range []struct {
+		c   TableCell
+		sel bool
+	}{
+		{TableCell{2, 1}, true}, {TableCell{1, 0}, true}, {TableCell{2, 2}, false}, {TableCell{0, 0}, false},
+	} {
+		if tv.CellIsSelected(tt.c) != tt.sel {
+			t.Errorf("CellIsSelected(%v) = %v, want %v", tt.c, !tt.sel, tt.sel)
+		}
+		if w, ok := tv.CellWidget(tt.c); !ok || w.AsWidget().StateIs(states.Selected) != tt.sel {
+			t.Errorf("widget of cell %v selected = %v, want %v", tt.c, ok && !tt.sel, tt.sel)
+		}
+	}
+
+	// the current cell is limited to the cells in the view
+	tv.SelectCell(TableCell{10, -3}, events.SelectOne)
+	if st, ed := tv.CellRange(); st != (TableCell{3, 0}) || ed != st {
+		t.Errorf("CellRange of clamped cell = %v, %v, want {3 0}", st, ed)
+	}
+	if tv.SelectedIdx != 3 {
+		t.Errorf("SelectedIdx = %d, want 3", tv.SelectedIdx)
+	}
+
+	// navigating with keys, extending with shift
+	tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	shift := key.Modifiers(1 << key.Shift)
+	for _, tt := range []struct {
+		code   key.Codes
+		mods   key.Modifiers
+		st, ed TableCell
+	}{
+		{key.CodeDownArrow, 0, TableCell{1, 0}, TableCell{1, 0}},
+		{key.CodeRightArrow, 0, TableCell{1, 1}, TableCell{1, 1}},
+		{key.CodeDownArrow, shift, TableCell{1, 1}, TableCell{2, 1}},
+		{key.CodeRightArrow, shift, TableCell{1, 1}, TableCell{2, 2}},
+		{key.CodeUpArrow, shift, TableCell{1, 1}, TableCell{1, 2}},
+		{key.CodeLeftArrow, 0, TableCell{1, 1}, TableCell{1, 1}},
+		{key.CodeUpArrow, 0, TableCell{0, 1}, TableCell{0, 1}},
+		{key.CodeUpArrow, 0, TableCell{0, 1}, TableCell{0, 1}}, // stays in the first row
+	} {
+		cellKey(tv, tt.code, tt.mods)
+		if st, ed := tv.CellRange(); st != tt.st || ed != tt.ed {
+			t.Errorf("after key %v (%v): CellRange = %v, %v, want %v, %v", tt.code, tt.mods, st, ed, tt.st, tt.ed)
+		}
+	}
+}
+
+func TestParseTSV(t *testing.T) {
+	for _, tt := range []struct {
+		txt  string
+		want [][]string
+	}{
+		{"a\tb\nc\td\n", [][]string{{"a", "b"}, {"c", "d"}}},
+		{"a\tb\r\nc\td", [][]string{{"a", "b"}, {"c", "d"}}},
+		{"a\nb\tc\n", [][]string{{"a"}, {"b", "c"}}},
+		{"x\t\t\n", [][]string{{"x", "", ""}}},
+		// quoted values, as spreadsheets copy them
+		{"\"x\ty\"\t\"line1\nline2\"\t\"say \"\"hi\"\"\"\n", [][]string{{"x\ty", "line1\nline2", "say \"hi\""}}},
+		// quotes that are not valid are kept, splitting at every tab and newline
+		{"5\" screen\t2\n\"open\tz\n", [][]string{{"5\" screen", "2"}, {"\"open", "z"}}},
+		{"\"a\"b\tc\r\nd", [][]string{{"\"a\"b", "c"}, {"d"}}},
+	} {
+		if got := ParseTSV(tt.txt); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTSV(%q) = %q, want %q", tt.txt, got, tt.want)
+		}
+	}
+}
+
+func TestCellsTSV(t *testing.T) {
+	data := tableCellsData()
+	data[0].Name = "tab\there"
+	data[1].Name = "two\nlines"
+	data[2].Name = `"quoted"`
+	tv := tableCellsView(&data)
+	tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	tv.SelectCell(TableCell{2, 2}, events.ExtendContinuous)
+	txt := tv.CellsTSV()
+	want := [][]string{{"tab\there", "1", "1.5"}, {"two\nlines", "2", "2.5"}, {`"quoted"`, "3", "3.5"}}
+	if got := ParseTSV(txt); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseTSV(CellsTSV()) = %q, want %q\nfrom: %q", got, want, txt)
+	}
+
+	// pasting the text into another table gives the same values
+	pdata := make([]tableCellsStruct, 3)
+	ptv := tableCellsView(&pdata)
+	ptv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	if nerr := ptv.PasteCellsText(txt); nerr != 0 {
+		t.Errorf("%d errors pasting %q", nerr, txt)
+	}
+	for i := range pdata {
+		d := data[i]
+		d.ID = 0 // not edited
+		if pdata[i] != d {
+			t.Errorf("pasted row %d = %v, want %v", i, pdata[i], d)
+		}
+	}
+}
+
+func TestPasteCellsText(t *testing.T) {
+	data := tableCellsData()
+	tv := tableCellsView(&data)
+	tv.SelectCell(TableCell{1, 0}, events.SelectOne)
+	// the ID column is not edited, and the last row and column are ignored
+	nerr := tv.PasteCellsText("p\t7\nq\t\tz\t99\tx\nr\t9\t1\t99\ns\t10\n")
+	if nerr != 1 {
+		t.Errorf("%d values could not be converted, want 1", nerr)
+	}
+	want := []tableCellsStruct{{"a", 1, 1.5, 10}, {"p", 7, 2.5, 11}, {"q", 0, 3.5, 12}, {"r", 9, 1, 13}}
+	if !slices.Equal(data, want) {
+		t.Errorf("after paste: %v, want %v", data, want)
+	}
+	if st, ed := tv.CellRange(); st != (TableCell{1, 0}) || ed != (TableCell{3, 3}) {
+		t.Errorf("pasted cells selected = %v, %v, want {1 0}, {3 3}", st, ed)
+	}
+
+	// a single value goes into all of the selected cells
+	tv.SelectCell(TableCell{0, 1}, events.SelectOne)
+	tv.SelectCell(TableCell{2, 2}, events.ExtendContinuous)
+	tv.PasteCellsText("5\n")
+	want = []tableCellsStruct{{"a", 5, 5, 10}, {"p", 5, 5, 11}, {"q", 5, 5, 12}, {"r", 9, 1, 13}}
+	if !slices.Equal(data, want) {
+		t.Errorf("after pasting one value: %v, want %v", data, want)
+	}
+
+	// the whole paste is undone at once
+	tv.UndoCells()
+	want = []tableCellsStruct{{"a", 1, 1.5, 10}, {"p", 7, 2.5, 11}, {"q", 0, 3.5, 12}, {"r", 9, 1, 13}}
+	if !slices.Equal(data, want) {
+		t.Errorf("after undo: %v, want %v", data, want)
+	}
+}
+
+func TestFillDownCells(t *testing.T) {
+	data := tableCellsData()
+	tv := tableCellsView(&data)
+	tv.SelectCell(TableCell{0, 1}, events.SelectOne)
+	tv.SelectCell(TableCell{2, 3}, events.ExtendContinuous)
+	tv.FillDownCells()
+	want := []tableCellsStruct{{"a", 1, 1.5, 10}, {"b", 1, 1.5, 11}, {"c", 1, 1.5, 12}, {"d", 4, 4.5, 13}}
+	if !slices.Equal(data, want) {
+		t.Errorf("after filling down: %v, want %v", data, want)
+	}
+
+	// with one row selected, it is filled from the row above
+	tv.SelectCell(TableCell{3, 0}, events.SelectOne)
+	tv.SelectCell(TableCell{3, 1}, events.ExtendContinuous)
+	tv.FillDownCells()
+	want[3].Name, want[3].N = "c", 1
+	if !slices.Equal(data, want) {
+		t.Errorf("after filling one row: %v, want %v", data, want)
+	}
+	tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	tv.FillDownCells()
+	if !slices.Equal(data, want) || len(tv.cellUndos) != 2 {
+		t.Errorf("filling the first row changed it: %v, with %d undos", data, len(tv.cellUndos))
+	}
+}
+
+func TestUndoCells(t *testing.T) {
+	data := tableCellsData()
+	orig := slices.Clone(data)
+	tv := tableCellsView(&data)
+	if tv.UndoCells() || tv.RedoCells() {
+		t.Errorf("undo or redo with no edits")
+	}
+	tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	tv.SelectCell(TableCell{1, 1}, events.ExtendContinuous)
+	tv.ClearCells()
+	cleared := []tableCellsStruct{{"", 0, 1.5, 10}, {"", 0, 2.5, 11}, orig[2], orig[3]}
+	if !slices.Equal(data, cleared) {
+		t.Fatalf("after clearing: %v, want %v", data, cleared)
+	}
+
+	// an edit of the current cell through its widget is undone by itself
+	tv.SelectCell(TableCell{2, 2}, events.SelectOne)
+	data[2].F = 9
+	tv.CellChanged(TableCell{2, 2})
+	if !tv.UndoCells() || data[2].F != 3.5 {
+		t.Errorf("undo of cell edit: %v", data[2])
+	}
+	if !tv.UndoCells() || !slices.Equal(data, orig) {
+		t.Errorf("after undoing the clear: %v, want %v", data, orig)
+	}
+	if tv.UndoCells() {
+		t.Errorf("undo with nothing left to undo")
+	}
+	if !tv.RedoCells() || !slices.Equal(data, cleared) {
+		t.Errorf("after redo: %v, want %v", data, cleared)
+	}
+
+	// a new edit clears the redos
+	tv.SelectCell(TableCell{3, 0}, events.SelectOne)
+	tv.PasteCellsText("x")
+	if tv.RedoCells() {
+		t.Errorf("redo after a new edit")
+	}
+
+	// the undos are dropped once the length of the slice changes
+	tv.SliceDeleteAt(0)
+	if len(data) != 3 {
+		t.Fatalf("slice length after deleting = %d", len(data))
+	}
+	if tv.UndoCells() {
+		t.Errorf("undo after the slice length changed: %v", data)
+	}
+	if data[2].Name != "x" {
+		t.Errorf("undo changed the slice after the length changed: %v", data)
+	}
+	tv.SelectCell(TableCell{0, 0}, events.SelectOne)
+	tv.PasteCellsText("y")
+	if !tv.UndoCells() || data[0].Name != "" || tv.UndoCells() {
+		t.Errorf("undos after the slice length changed: %v", data)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/units"
+	"goki.dev/goosi/events"
+	"goki.dev/grr"
+	"goki.dev/icons"
+	"goki.dev/ki/v2"
+)
+
+// TableViewResizeMargin is the width in dots of the region at the right
+// edge of a column header where dragging resizes the column, instead of
+// moving it
+var TableViewResizeMargin = 8
+
+// TableViewMinColWidth is the minimum width in dp that a column can be
+// resized to
+var TableViewMinColWidth = float32(16)
+
+// TableViewLayoutSaveDelay is how long after a column layout is saved in
+// gi.Prefs.TableViewLayouts that the preferences are saved, so that a
+// series of changes, e.g., sorting by several columns, is saved at once
+var TableViewLayoutSaveDelay = 2 * time.Second
+
+var (
+	// tableViewSaveTimer is the timer for saving the preferences
+	// after TableViewLayoutSaveDelay, which is nil if none is pending
+	tableViewSaveTimer *time.Timer
+
+	// tableViewSaveMu protects tableViewSaveTimer
+	tableViewSaveMu sync.Mutex
+)
+
+// ColLayoutName returns the name that the ColLayout is saved under in
+// gi.Prefs.TableViewLayouts, which is the name of the struct type
+func (tv *TableView) ColLayoutName() string {
+	if tv.StruType == nil {
+		return ""
+	}
+	return tv.StruType.String()
+}
+
+// CopyColLayout returns a copy of given column layout that does not
+// share any of its slices or maps, or an empty one if it is nil
+func CopyColLayout(cl *gi.TableViewLayout) *gi.TableViewLayout {
+	ncl := &gi.TableViewLayout{}
+	if cl == nil {
+		return ncl
+	}
+	*ncl = *cl
+	ncl.Order = slices.Clone(cl.Order)
+	ncl.Hidden = slices.Clone(cl.Hidden)
+	ncl.Widths = make(map[string]float32, len(cl.Widths))
+	for k, v := range cl.Widths {
+		ncl.Widths[k] = v
+	}
+	return ncl
+}
+
+// PrefsColLayout returns a copy of the column layout saved in
+// gi.Prefs.TableViewLayouts for the struct type, or an empty one if none
+func (tv *TableView) PrefsColLayout() *gi.TableViewLayout {
+	return CopyColLayout(gi.Prefs.TableViewLayouts[tv.ColLayoutName()])
+}
+
+// SaveColLayout saves a copy of the ColLayout, with the current sorting,
+// in gi.Prefs.TableViewLayouts, and saves the preferences after
+// TableViewLayoutSaveDelay (see SavePrefsDelayed)
+func (tv *TableView) SaveColLayout() {
+	nm := tv.ColLayoutName()
+	if nm == "" || tv.ColLayout == nil {
+		return
+	}
+	tv.ColLayout.Sort = tv.SortFieldName()
+	if gi.Prefs.TableViewLayouts == nil {
+		gi.Prefs.TableViewLayouts = make(map[string]*gi.TableViewLayout)
+	}
+	gi.Prefs.TableViewLayouts[nm] = CopyColLayout(tv.ColLayout)
+	tv.SavePrefsDelayed()
+}
+
+// SavePrefsDelayed saves the preferences after TableViewLayoutSaveDelay,
+// on the event loop of the scene, unless a save is already pending
+func (tv *TableView) SavePrefsDelayed() {
+	sc := tv.Sc
+	tableViewSaveMu.Lock()
+	defer tableViewSaveMu.Unlock()
+	if tableViewSaveTimer != nil {
+		return
+	}
+	tableViewSaveTimer = time.AfterFunc(TableViewLayoutSaveDelay, func() {
+		tableViewSaveMu.Lock()
+		tableViewSaveTimer = nil
+		tableViewSaveMu.Unlock()
+		save := func() {
+			grr.Log0(gi.Prefs.Save())
+		}
+		if sc == nil {
+			save()
+			return
+		}
+		sc.RunOnEventLoop(save)
+	})
+}
+
+// IsColHidden returns true if the column of given field is hidden by the
+// ColLayout, or by a tableview:"hidden" tag if it is not in its Order
+func (tv *TableView) IsColHidden(fld reflect.StructField) bool {
+	cl := tv.ColLayout
+	if cl == nil || !slices.Contains(cl.Order, fld.Name) {
+		return fld.Tag.Get("tableview") == "hidden"
+	}
+	return slices.Contains(cl.Hidden, fld.Name)
+}
+
+// ApplyColLayout sorts the AllFields in the order of the ColLayout, and
+// sets the VisFields to those that are not hidden
+func (tv *TableView) ApplyColLayout() {
+	if tv.ColLayout == nil {
+		tv.ColLayout = &gi.TableViewLayout{}
+	}
+	cl := tv.ColLayout
+	if len(cl.Order) > 0 {
+		pos := func(fld reflect.StructField) int {
+			if i := slices.Index(cl.Order, fld.Name); i >= 0 {
+				return i
+			}
+			return len(cl.Order)
+		}
+		slices.SortStableFunc(tv.AllFields, func(a, b reflect.StructField) int {
+			return pos(a) - pos(b)
+		})
+	}
+	tv.VisFields = make([]reflect.StructField, 0, len(tv.AllFields))
+	for _, fld := range tv.AllFields {
+		if !tv.IsColHidden(fld) {
+			tv.VisFields = append(tv.VisFields, fld)
+		}
+	}
+	tv.NVisFields = len(tv.VisFields)
+}
+
+// ColWidth returns the width in dp that the column of given visible field
+// has been resized to, or 0 if it has not been, in which case its width
+// is determined by styling, including any width tag (in Ch units)
+func (tv *TableView) ColWidth(fli int) float32 {
+	if tv.ColLayout == nil || fli < 0 || fli >= tv.NVisFields {
+		return 0
+	}
+	return tv.ColLayout.Widths[tv.VisFields[fli].Name]
+}
+
+// fixColOrder makes the Order of the ColLayout include all of the fields,
+// in their current order, so that it determines whether they are hidden
+func (tv *TableView) fixColOrder() {
+	cl := tv.ColLayout
+	cl.Order = make([]string, len(tv.AllFields))
+	for i, fld := range tv.AllFields {
+		if tv.IsColHidden(fld) && !slices.Contains(cl.Hidden, fld.Name) {
+			cl.Hidden = append(cl.Hidden, fld.Name)
+		}
+		cl.Order[i] = fld.Name
+	}
+}
+
+// UpdateColumns rebuilds the header and rows after a change of the ColLayout
+func (tv *TableView) UpdateColumns() {
+	if !tv.IsConfiged() {
+		return
+	}
+	updt := tv.UpdateStart()
+	tv.ApplyColLayout()
+	tv.SliceHeader().DeleteChildren(ki.DestroyKids)
+	tv.SliceGrid().DeleteChildren(ki.DestroyKids)
+	tv.Values = nil
+	tv.VisRows = 0
+	tv.SetFlag(false, SliceViewConfiged)
+	tv.UpdateEndLayout(updt)
+	tv.Update()
+}
+
+// SetColHiddenAction hides or shows the column of the field with given
+// name, except that the last visible column can not be hidden, and saves
+// the ColLayout
+func (tv *TableView) SetColHiddenAction(name string, hide bool) {
+	if hide && tv.NVisFields <= 1 {
+		return
+	}
+	tv.fixColOrder()
+	cl := tv.ColLayout
+	cl.Hidden = slices.DeleteFunc(cl.Hidden, func(nm string) bool { return nm == name })
+	if hide {
+		cl.Hidden = append(cl.Hidden, name)
+	}
+	tv.UpdateColumns()
+	tv.SaveColLayout()
+}
+
+// MoveColAction moves the column of the visible field at index from to
+// the position of the visible field at index to, and saves the ColLayout
+func (tv *TableView) MoveColAction(from, to int) {
+	if from == to || from < 0 || to < 0 || from >= tv.NVisFields || to >= tv.NVisFields {
+		return
+	}
+	tv.fixColOrder()
+	cl := tv.ColLayout
+	fnm, tnm := tv.VisFields[from].Name, tv.VisFields[to].Name
+	cl.Order = slices.DeleteFunc(cl.Order, func(nm string) bool { return nm == fnm })
+	ti := slices.Index(cl.Order, tnm)
+	if from < to {
+		ti++
+	}
+	cl.Order = slices.Insert(cl.Order, ti, fnm)
+	tv.UpdateColumns()
+	tv.SaveColLayout()
+}
+
+// SetColWidth sets the width in dp of the column of given visible field,
+// without saving the ColLayout
+func (tv *TableView) SetColWidth(fli int, wd float32) {
+	if fli < 0 || fli >= tv.NVisFields {
+		return
+	}
+	cl := tv.ColLayout
+	if cl.Widths == nil {
+		cl.Widths = make(map[string]float32)
+	}
+	cl.Widths[tv.VisFields[fli].Name] = max(wd, TableViewMinColWidth)
+	tv.ApplyStyleTree(tv.Sc)
+	tv.SetNeedsLayout()
+}
+
+// ResetColLayoutAction resets the ColLayout to the default, in which the
+// columns are in the order of the struct, the widths are from styling, and
+// only the fields with a tableview:"hidden" tag are hidden, and saves it
+func (tv *TableView) ResetColLayoutAction() {
+	tv.ColLayout = &gi.TableViewLayout{}
+	tv.UpdateColumns()
+	tv.SaveColLayout()
+}
+
+// HeaderSlideStart starts dragging the header of given visible field,
+// which resizes the column if it is near its right edge, and otherwise
+// moves it to where it is dropped
+func (tv *TableView) HeaderSlideStart(fli int, e events.Event) {
+	sgh := tv.SliceHeader()
+	if tv.headerIdx(fli) >= sgh.NumChildren() {
+		return
+	}
+	hdr, ok := sgh.Child(tv.headerIdx(fli)).(*gi.Button)
+	if !ok {
+		return
+	}
+	wd := hdr.ScBBox.Dx()
+	tv.colDrag = fli
+	tv.colDragResize = hdr.PointToRelPos(e.StartPos()).X >= wd-TableViewResizeMargin
+	tv.colDragWidth = float32(wd)
+}
+
+// HeaderSlideMove resizes the column being dragged, if resizing
+func (tv *TableView) HeaderSlideMove(e events.Event) {
+	if tv.colDrag < 0 || !tv.colDragResize {
+		return
+	}
+	dpd := tv.Styles.UnContext.Dots(units.UnitDp) // dots per dp
+	if dpd <= 0 {
+		dpd = 1
+	}
+	tv.SetColWidth(tv.colDrag, (tv.colDragWidth+float32(e.StartDelta().X))/dpd)
+}
+
+// HeaderSlideStop finishes dragging a column header, saving the width of
+// the column if resizing, or moving it to the column under the mouse
+func (tv *TableView) HeaderSlideStop(e events.Event) {
+	fli := tv.colDrag
+	tv.colDrag = -1
+	if fli < 0 {
+		return
+	}
+	if tv.colDragResize {
+		tv.SaveColLayout()
+		return
+	}
+	sgh := tv.SliceHeader()
+	x := e.Pos().X
+	for to := 0; to < tv.NVisFields && tv.headerIdx(to) < sgh.NumChildren(); to++ {
+		hdr, ok := sgh.Child(tv.headerIdx(to)).(*gi.Button)
+		if ok && x >= hdr.ScBBox.Min.X && x < hdr.ScBBox.Max.X {
+			tv.MoveColAction(fli, to)
+			return
+		}
+	}
+}
+
+// headerIdx returns the index in the header of the header of given visible field
+func (tv *TableView) headerIdx(fli int) int {
+	_, idxOff := tv.RowWidgetNs()
+	return idxOff + fli
+}
+
+// ColumnsMenu adds buttons to given menu for showing or hiding each of the
+// fields, and for resetting the layout of the columns
+func (tv *TableView) ColumnsMenu(m *gi.Scene) {
+	for _, fld := range tv.AllFields {
+		fld := fld
+		hidden := tv.IsColHidden(fld)
+		ic := icons.Visibility
+		if hidden {
+			ic = icons.VisibilityOff
+		}
+		gi.NewButton(m, "show-"+fld.Name).SetText(TableFieldLabel(fld)).SetIcon(ic).
+			OnClick(func(e events.Event) {
+				tv.SetColHiddenAction(fld.Name, !hidden)
+			})
+	}
+	gi.NewSeparator(m, "sep-reset")
+	gi.NewButton(m, "reset-cols").SetText("Reset columns").SetIcon(icons.DeviceReset).
+		OnClick(func(e events.Event) {
+			tv.ResetColLayoutAction()
+		})
+}
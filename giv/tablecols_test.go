@@ -0,0 +1,139 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+)
+
+type tableColsStruct struct {
+	A int
+	B int `tableview:"hidden"`
+	C string
+	D float32
+}
+
+// tableColsView returns a TableView with the fields of tableColsStruct
+// and given column layout applied
+func tableColsView(cl *gi.TableViewLayout) *TableView {
+	tv := &TableView{}
+	typ := reflect.TypeOf(tableColsStruct{})
+	tv.StruType = typ
+	for i := 0; i < typ.NumField(); i++ {
+		tv.AllFields = append(tv.AllFields, typ.Field(i))
+	}
+	tv.ColLayout = cl
+	tv.ApplyColLayout()
+	return tv
+}
+
+func fieldNames(flds []reflect.StructField) []string {
+	nms := make([]string, len(flds))
+	for i, fld := range flds {
+		nms[i] = fld.Name
+	}
+	return nms
+}
+
+func TestApplyColLayout(t *testing.T) {
+	tv := tableColsView(nil)
+	if got, want := fieldNames(tv.VisFields), []string{"A", "C", "D"}; !slices.Equal(got, want) {
+		t.Errorf("default VisFields = %v, want %v", got, want)
+	}
+
+	// fields that are not in the Order go after the others, in their
+	// own order, and are hidden only by their tag
+	tv = tableColsView(&gi.TableViewLayout{Order: []string{"C", "A"}, Hidden: []string{"A"}})
+	if got, want := fieldNames(tv.AllFields), []string{"C", "A", "B", "D"}; !slices.Equal(got, want) {
+		t.Errorf("AllFields = %v, want %v", got, want)
+	}
+	if got, want := fieldNames(tv.VisFields), []string{"C", "D"}; !slices.Equal(got, want) || tv.NVisFields != 2 {
+		t.Errorf("VisFields = %v (%d), want %v", got, tv.NVisFields, want)
+	}
+
+	// once all of the fields are in the Order, the Hidden list decides
+	tv.fixColOrder()
+	cl := tv.ColLayout
+	if want := []string{"C", "A", "B", "D"}; !slices.Equal(cl.Order, want) {
+		t.Errorf("fixed Order = %v, want %v", cl.Order, want)
+	}
+	if want := []string{"A", "B"}; !slices.Equal(cl.Hidden, want) {
+		t.Errorf("fixed Hidden = %v, want %v", cl.Hidden, want)
+	}
+	cl.Hidden = []string{"C"}
+	tv.ApplyColLayout()
+	if got, want := fieldNames(tv.VisFields), []string{"A", "B", "D"}; !slices.Equal(got, want) {
+		t.Errorf("VisFields after showing B = %v, want %v", got, want)
+	}
+}
+
+func TestColLayoutEncode(t *testing.T) {
+	cl := &gi.TableViewLayout{
+		Order:  []string{"C", "A", "B", "D"},
+		Hidden: []string{"B"},
+		Widths: map[string]float32{"C": 120},
+		Sort:   "C:down,A:up",
+	}
+	b, err := json.Marshal(cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dcl := &gi.TableViewLayout{}
+	if err := json.Unmarshal(b, dcl); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dcl, cl) {
+		t.Errorf("decoded layout = %+v, want %+v", dcl, cl)
+	}
+
+	// a copy does not share anything with the original
+	ccl := CopyColLayout(cl)
+	if !reflect.DeepEqual(ccl, cl) {
+		t.Errorf("copied layout = %+v, want %+v", ccl, cl)
+	}
+	ccl.Order[0] = "X"
+	ccl.Hidden[0] = "X"
+	ccl.Widths["C"] = 10
+	if cl.Order[0] != "C" || cl.Hidden[0] != "B" || cl.Widths["C"] != 120 {
+		t.Errorf("changing the copy changed the original: %+v", cl)
+	}
+	if ncl := CopyColLayout(nil); ncl == nil || len(ncl.Order) != 0 {
+		t.Errorf("copy of nil layout = %+v", ncl)
+	}
+}
+
+func TestSortFieldName(t *testing.T) {
+	tv := tableColsView(&gi.TableViewLayout{Order: []string{"C", "A"}})
+	tv.SetSortFieldName("C:down,A:up")
+	want := []TableSortKey{{Field: "C", Desc: true}, {Field: "A"}}
+	if !slices.Equal(tv.SortKeys, want) {
+		t.Errorf("SortKeys = %v, want %v", tv.SortKeys, want)
+	}
+	if nm := tv.SortFieldName(); nm != "C:down,A:up" {
+		t.Errorf("SortFieldName = %q", nm)
+	}
+
+	// the SortIdx and SortDesc follow the first sort key
+	tv.syncSortIdx()
+	if tv.SortIdx != 0 || !tv.SortDesc {
+		t.Errorf("SortIdx = %d, SortDesc = %v, want 0, true", tv.SortIdx, tv.SortDesc)
+	}
+	tv.SortIdx = 1
+	tv.SortDesc = false
+	tv.syncSortIdx()
+	if nm := tv.SortFieldName(); nm != "A:up" {
+		t.Errorf("SortFieldName after setting SortIdx = %q", nm)
+	}
+
+	tv.SetSortFieldName("")
+	if nm := tv.SortFieldName(); nm != "A:up" {
+		t.Errorf("empty name changed the sorting to %q", nm)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"goki.dev/laser"
+)
+
+// TableSortKey is one of the keys that the rows of a TableView are sorted by
+type TableSortKey struct {
+
+	// name of the field to sort by
+	Field string
+
+	// whether to sort in descending order
+	Desc bool
+}
+
+// TableFilter is a filter for the values of one field (column) of
+// a TableView, parsed from a filter string by NewTableFilter.
+type TableFilter struct {
+
+	// the filter string that this was parsed from
+	Filter string
+
+	// regular expression that the text of the value must match, for /regexp/
+	Regexp *regexp.Regexp
+
+	// compiled expression that must be true, for =expr
+	Expr *vm.Program
+
+	// comparison operator for a numeric comparison, which is ".." for a range
+	Op string
+
+	// number that a numeric value is compared with, or the start of a range
+	Num float64
+
+	// end of a range, for which Num and End are NaN if not given
+	End float64
+
+	// lowercase text that the text of the value must contain, otherwise
+	Text string
+}
+
+// NewTableFilter returns a new filter for values of given type parsed
+// from given filter string, which is one of:
+//   - /regexp/ to match the text of the value with a regular expression.
+//   - =expr for an expression that must be true, in the expr language used
+//     for viewif tags, where x is the value, e.g., =x > 10 && x % 2 == 0
+//   - for numeric values, a range lo..hi (inclusive), where either end can
+//     be omitted, or a comparison with a number using < <= > >= == != ,
+//     or just a number that the value must be equal to.
+//   - otherwise, text that the text of the value must contain, ignoring case.
+func NewTableFilter(flt string, typ reflect.Type) (*TableFilter, error) {
+	tf := &TableFilter{Filter: flt}
+	flt = strings.TrimSpace(flt)
+	switch {
+	case len(flt) >= 2 && strings.HasPrefix(flt, "/") && strings.HasSuffix(flt, "/"):
+		re, err := regexp.Compile(flt[1 : len(flt)-1])
+		if err != nil {
+			return nil, fmt.Errorf("giv.NewTableFilter: invalid regular expression %q: %w", flt, err)
+		}
+		tf.Regexp = re
+		return tf, nil
+	case strings.HasPrefix(flt, "=") && !strings.HasPrefix(flt, "=="):
+		prog, err := expr.Compile(flt[1:], expr.AllowUndefinedVariables(), expr.AsBool()) // x is any type
+		if err != nil {
+			return nil, fmt.Errorf("giv.NewTableFilter: invalid expression %q: %w", flt, err)
+		}
+		tf.Expr = prog
+		return tf, nil
+	}
+	if IsNumberKind(typ.Kind()) && tf.parseNumber(flt) {
+		return tf, nil
+	}
+	tf.Text = strings.ToLower(flt)
+	return tf, nil
+}
+
+// parseNumber parses a numeric range or comparison, returning false,
+// without changing the filter, if it is not one
+func (tf *TableFilter) parseNumber(flt string) bool {
+	num := func(s string) (float64, bool) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return math.NaN(), true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	if lo, hi, ok := strings.Cut(flt, ".."); ok {
+		st, sok := num(lo)
+		ed, eok := num(hi)
+		if !sok || !eok {
+			return false
+		}
+		tf.Op, tf.Num, tf.End = "..", st, ed
+		return true
+	}
+	op := "=="
+	for _, o := range []string{"<=", ">=", "==", "!=", "<", ">"} { // two chars first
+		if strings.HasPrefix(flt, o) {
+			op = o
+			flt = flt[len(o):]
+			break
+		}
+	}
+	f, ok := num(flt)
+	if !ok || math.IsNaN(f) {
+		return false
+	}
+	tf.Op, tf.Num = op, f
+	return true
+}
+
+// Match returns true if given value matches the filter.
+// An error running an expression is logged, and does not match.
+func (tf *TableFilter) Match(v reflect.Value) bool {
+	switch {
+	case tf.Regexp != nil:
+		return tf.Regexp.MatchString(ValueText(v))
+	case tf.Expr != nil:
+		x := any(nil)
+		if v.IsValid() {
+			x = v.Interface()
+			switch { // basic types for named types, for the operators
+			case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+				x = v.Int()
+			case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+				x = v.Uint()
+			case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+				x = v.Float()
+			case v.Kind() == reflect.String:
+				x = v.String()
+			}
+		}
+		res, err := expr.Run(tf.Expr, map[string]any{"x": x})
+		if err != nil {
+			slog.Error(fmt.Sprintf("giv.TableFilter: error running expression %q: %v", tf.Filter, err))
+			return false
+		}
+		b, _ := res.(bool)
+		return b
+	case tf.Op != "":
+		if !v.IsValid() || !IsNumberKind(v.Kind()) {
+			return false
+		}
+		f := ValueFloat(v)
+		switch tf.Op {
+		case "..":
+			return !(f < tf.Num) && !(f > tf.End) // false for omitted NaN ends
+		case "<=":
+			return f <= tf.Num
+		case ">=":
+			return f >= tf.Num
+		case "!=":
+			return f != tf.Num
+		case "<":
+			return f < tf.Num
+		case ">":
+			return f > tf.Num
+		}
+		return f == tf.Num
+	}
+	return strings.Contains(strings.ToLower(ValueText(v)), tf.Text)
+}
+
+// IsNumberKind returns true if given kind is an integer, unsigned
+// integer or floating point number
+func IsNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// ValueFloat returns the value of given number value as a float64
+func ValueFloat(v reflect.Value) float64 {
+	switch {
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		return float64(v.Int())
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		return float64(v.Uint())
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return v.Float()
+	}
+	return 0
+}
+
+// ValueText returns the text of given value, as shown in views
+func ValueText(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	return laser.ToString(v.Interface())
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// CompareValues returns -1, 0 or 1 if value a is less than, equal to,
+// or greater than value b, comparing numbers and bools by value (integers
+// exactly, as integers), times (including types based on time.Time) by
+// time, and anything else by its text, ignoring case unless that is the
+// only difference.
+func CompareValues(a, b reflect.Value) int {
+	switch {
+	case !a.IsValid() || !b.IsValid():
+		return compareBools(a.IsValid(), b.IsValid())
+	case a.Type() != b.Type(): // by text
+	case a.Kind() >= reflect.Int && a.Kind() <= reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case a.Kind() >= reflect.Uint && a.Kind() <= reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case IsNumberKind(a.Kind()):
+		fa, fb := a.Float(), b.Float()
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case a.Kind() == reflect.Bool:
+		return compareBools(a.Bool(), b.Bool())
+	case a.Type().ConvertibleTo(timeType):
+		return a.Convert(timeType).Interface().(time.Time).Compare(b.Convert(timeType).Interface().(time.Time))
+	}
+	ta, tb := ValueText(a), ValueText(b)
+	if c := strings.Compare(strings.ToLower(ta), strings.ToLower(tb)); c != 0 {
+		return c
+	}
+	return strings.Compare(ta, tb)
+}
+
+// compareBools compares bools with false before true
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
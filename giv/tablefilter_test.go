@@ -0,0 +1,160 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type tableFilterTime time.Time
+
+func TestNewTableFilter(t *testing.T) {
+	intType := reflect.TypeOf(0)
+	strType := reflect.TypeOf("")
+	for _, tt := range []struct {
+		flt  string
+		typ  reflect.Type
+		err  bool
+		op   string
+		text string
+	}{
+		{flt: "/^a.c$/", typ: strType},
+		{flt: "/(/", typ: strType, err: true},
+		{flt: "=x > 2", typ: intType},
+		{flt: "=x >", typ: intType, err: true},
+		{flt: "1..5", typ: intType, op: ".."},
+		{flt: " >= 3", typ: intType, op: ">="},
+		{flt: "7", typ: intType, op: "=="},
+		{flt: "==7", typ: intType, op: "=="},
+		{flt: "abc", typ: intType, text: "abc"}, // not a number, so text
+		{flt: "1..5", typ: strType, text: "1..5"},
+		{flt: "AbC", typ: strType, text: "abc"},
+	} {
+		tf, err := NewTableFilter(tt.flt, tt.typ)
+		if (err != nil) != tt.err {
+			t.Errorf("NewTableFilter(%q) error = %v, want error %v", tt.flt, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tf.Op != tt.op || tf.Text != tt.text {
+			t.Errorf("NewTableFilter(%q) Op = %q, Text = %q, want %q, %q", tt.flt, tf.Op, tf.Text, tt.op, tt.text)
+		}
+	}
+}
+
+func TestTableFilterMatch(t *testing.T) {
+	for _, tt := range []struct {
+		flt  string
+		vals []any
+		want []bool
+	}{
+		{"/^a.c$/", []any{"abc", "xabc", "aXc"}, []bool{true, false, true}},
+		{"=x > 2 && x % 2 == 0", []any{2, 3, 4, int8(6)}, []bool{false, false, true, true}},
+		{"=x > 2.5", []any{2.0, float32(3)}, []bool{false, true}},
+		{"=x contains \"b\"", []any{"abc", "xyz"}, []bool{true, false}},
+		{"2..4", []any{1, 2, 4, 4.5, uint(3)}, []bool{false, true, true, false, true}},
+		{"..4", []any{-10, 4, 5}, []bool{true, true, false}},
+		{"2..", []any{1, 2, 100}, []bool{false, true, true}},
+		{"<3", []any{2, 3}, []bool{true, false}},
+		{"<=3", []any{3, 4}, []bool{true, false}},
+		{">3", []any{3, 4}, []bool{false, true}},
+		{"!=3", []any{3, 4}, []bool{false, true}},
+		{"3", []any{3, 3.0, 4}, []bool{true, true, false}},
+		{"Ab", []any{"cAB", "ba", "xaby"}, []bool{true, false, true}},
+	} {
+		for i, v := range tt.vals {
+			rv := reflect.ValueOf(v)
+			tf, err := NewTableFilter(tt.flt, rv.Type())
+			if err != nil {
+				t.Fatalf("NewTableFilter(%q): %v", tt.flt, err)
+			}
+			if got := tf.Match(rv); got != tt.want[i] {
+				t.Errorf("filter %q Match(%v) = %v, want %v", tt.flt, v, got, tt.want[i])
+			}
+		}
+	}
+
+	// integers are passed to expressions as integers, without losing
+	// precision above 2^53
+	big := int64(1)<<53 + 1
+	for _, tt := range []struct {
+		flt  string
+		v    any
+		want bool
+	}{
+		{"=x == 9007199254740993", big, true},
+		{"=x == 9007199254740992", big, false},
+		{"=x > 9007199254740992", uint64(big), true},
+		{"=x % 2 == 1", big, true},
+	} {
+		rv := reflect.ValueOf(tt.v)
+		tf, err := NewTableFilter(tt.flt, rv.Type())
+		if err != nil {
+			t.Fatalf("NewTableFilter(%q): %v", tt.flt, err)
+		}
+		if got := tf.Match(rv); got != tt.want {
+			t.Errorf("filter %q Match(%v) = %v, want %v", tt.flt, tt.v, got, tt.want)
+		}
+	}
+
+	// a numeric filter does not match a value that is not a number
+	tf := &TableFilter{Op: "==", Num: 3}
+	if tf.Match(reflect.ValueOf("3")) || tf.Match(reflect.Value{}) {
+		t.Errorf("numeric filter matches a non-number")
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		a, b any
+		want int
+	}{
+		{1, 2, -1},
+		{2.5, 2.5, 0},
+		{uint8(9), uint8(3), 1},
+		{false, true, -1},
+		{true, true, 0},
+		{"apple", "Banana", -1}, // ignoring case
+		{"b", "B", 1},           // unless that is the only difference
+		{"b", "b", 0},
+		{now, now.Add(time.Second), -1},
+		{tableFilterTime(now.Add(time.Second)), tableFilterTime(now), 1},
+		{10, "9", -1},                           // different types are compared by text
+		{int64(1) << 53, int64(1)<<53 + 1, -1},  // not equal as float64s
+		{uint64(1)<<63 + 1, uint64(1) << 63, 1}, // same
+		{int64(math.MinInt64), int64(math.MinInt64 + 1), -1},
+	} {
+		if got := CompareValues(reflect.ValueOf(tt.a), reflect.ValueOf(tt.b)); got != tt.want {
+			t.Errorf("CompareValues(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareValues(reflect.ValueOf(tt.b), reflect.ValueOf(tt.a)); got != -tt.want {
+			t.Errorf("CompareValues(%v, %v) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+	// invalid (nil) values are first
+	if c := CompareValues(reflect.Value{}, reflect.ValueOf(0)); c != -1 {
+		t.Errorf("CompareValues(invalid, 0) = %d, want -1", c)
+	}
+}
+
+func TestResizeIdxs(t *testing.T) {
+	ixs := []int{4, 0, 2}
+	if got, want := ResizeIdxs(ixs, 5, 7), []int{4, 0, 2, 5, 6}; !slices.Equal(got, want) {
+		t.Errorf("ResizeIdxs grown = %v, want %v", got, want)
+	}
+	if got, want := ResizeIdxs(ixs, 5, 3), []int{0, 2}; !slices.Equal(got, want) {
+		t.Errorf("ResizeIdxs shrunk = %v, want %v", got, want)
+	}
+	if want := []int{4, 0, 2}; !slices.Equal(ixs, want) {
+		t.Errorf("ResizeIdxs changed its argument: %v", ixs)
+	}
+}
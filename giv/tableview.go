@@ -9,6 +9,7 @@ import (
 	"image"
 	"log/slog"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,6 +20,7 @@ import (
 	"goki.dev/girl/styles"
 	"goki.dev/girl/units"
 	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
 	"goki.dev/grr"
 	"goki.dev/icons"
 	"goki.dev/ki/v2"
@@ -26,13 +28,22 @@ import (
 )
 
 // todo:
-// * search option, both as a search field and as simple type-to-search
+// * simple type-to-search
 
 // TableView represents a slice-of-structs as a table, where the fields are
 // the columns, within an overall frame.  It is a full-featured editor with
 // multiple-selection, cut-and-paste, and drag-and-drop.
 // If ReadOnly, it functions as a mutually-exclusive item
 // selector, highlighting the selected row and emitting a Selected action.
+// The rows can be sorted by multiple columns (click, and shift-click, on
+// the column headers), filtered by the values of the columns (see the
+// header context menu and TableFilter), and searched (in the toolbar),
+// all of which only changes the view (see SliceViewBase.Idxs), not the slice.
+// In CellMode, cells are selected and edited as in a spreadsheet.
+// The columns can be resized and moved by dragging their headers, and
+// hidden (see the header context menu), and their layout is saved in
+// gi.Prefs.TableViewLayouts -- a width:"20" tag sets the default width of
+// a column (in Ch), and a tableview:"hidden" tag hides it by default.
 type TableView struct {
 	SliceViewBase
 
@@ -42,15 +53,73 @@ type TableView struct {
 	// current selection field -- initially select value in this field
 	SelField string `copy:"-" view:"-" json:"-" xml:"-"`
 
-	// current sort index
+	// index of the visible field that the rows are sorted by first,
+	// or -1 if they are not sorted: the first of the SortKeys, which
+	// is replaced by this field when it is set (see SortSlice)
 	SortIdx int
 
-	// whether current sort order is descending
+	// whether the rows are sorted in descending order of the SortIdx field
 	SortDesc bool
 
+	// the keys that the rows are sorted by, in order of priority,
+	// after which they are in slice order
+	SortKeys []TableSortKey
+
+	// the SortIdx and SortDesc when they were last synced with the SortKeys
+	lastSortIdx  int
+	lastSortDesc bool
+
+	// filters for the values of the fields, by field name,
+	// that a row must match to be shown (see TableFilter)
+	Filters map[string]string
+
+	// quick search text: only rows with a field whose text
+	// contains it, ignoring case, are shown
+	Search string
+
+	// whether the keyboard and mouse select and navigate cells, as in a
+	// spreadsheet, instead of rows, with the selected cells edited as a
+	// range (see TableCell and KeyInputCells)
+	CellMode bool
+
+	// current cell in CellMode
+	curCell TableCell
+
+	// cell where the selection of the range of cells started in CellMode
+	cellAnchor TableCell
+
+	// copy of the value of the current cell, for undoing its edits
+	cellOld reflect.Value
+
+	// edits of cells that can be undone
+	cellUndos [][]TableCellEdit
+
+	// edits of cells that have been undone, which can be redone
+	cellRedos [][]TableCellEdit
+
+	// length of the slice when the cellUndos were recorded
+	cellUndoLen int
+
+	// visible field index of the column whose header is being dragged, or -1
+	colDrag int
+
+	// whether the column being dragged is being resized, instead of moved
+	colDragResize bool
+
+	// width in dots of the column being dragged when the drag started
+	colDragWidth float32
+
 	// struct type for each row
 	StruType reflect.Type `copy:"-" view:"-" json:"-" xml:"-"`
 
+	// layout of the columns: their order, widths and which are hidden,
+	// which is saved in gi.Prefs.TableViewLayouts for the struct type
+	// whenever the user changes it (see SaveColLayout)
+	ColLayout *gi.TableViewLayout `copy:"-" view:"-" json:"-" xml:"-"`
+
+	// the fields that can be shown as columns, in the order of the ColLayout
+	AllFields []reflect.StructField `copy:"-" view:"-" json:"-" xml:"-"`
+
 	// the visible fields
 	VisFields []reflect.StructField `copy:"-" view:"-" json:"-" xml:"-"`
 
@@ -76,8 +145,14 @@ func (tv *TableView) TableViewInit() {
 	tv.SetFlag(true, SliceViewShowIndex)
 	tv.SetFlag(true, SliceViewShowToolbar)
 	tv.SetFlag(true, SliceViewReadOnlyKeyNav)
+	tv.colDrag = -1
 
 	tv.HandleSliceViewEvents()
+	tv.OnKeyChord(func(e events.Event) { // before the row keys
+		if tv.CellMode {
+			tv.KeyInputCells(e)
+		}
+	})
 
 	tv.Lay = gi.LayoutVert
 	tv.Style(func(s *styles.Style) {
@@ -167,9 +242,9 @@ func (tv *TableView) TableViewInit() {
 					fstr = fstr[:dp]    // field idx is -X.
 					idx := grr.Log(strconv.Atoi(istr))
 					fli := grr.Log(strconv.Atoi(fstr))
-					si := tv.StartIdx + idx
-					if si < tv.SliceSize {
-						tv.This().(SliceViewer).StyleRow(w, si, fli)
+					vi := tv.StartIdx + idx
+					if vi < tv.SliceSize {
+						tv.This().(SliceViewer).StyleRow(w, tv.SliceIdx(vi), fli)
 					}
 				})
 			}
@@ -199,6 +274,18 @@ func (tv *TableView) SetSlice(sl any) *TableView {
 	tv.StartIdx = 0
 	tv.SortIdx = -1
 	tv.SortDesc = false
+	tv.SortKeys = nil
+	tv.lastSortIdx = -1
+	tv.lastSortDesc = false
+	tv.Filters = nil
+	tv.Search = ""
+	tv.Idxs = nil
+	tv.viewIdxs = nil
+	tv.curCell = TableCell{}
+	tv.cellAnchor = TableCell{}
+	tv.cellOld = reflect.Value{}
+	tv.cellUndos = nil
+	tv.cellRedos = nil
 	slpTyp := reflect.TypeOf(sl)
 	if slpTyp.Kind() != reflect.Ptr {
 		slog.Error("TableView requires that you pass a pointer to a slice of struct elements, but type is not a Ptr", "type", slpTyp)
@@ -216,6 +303,8 @@ func (tv *TableView) SetSlice(sl any) *TableView {
 		return tv
 	}
 	tv.ElVal = laser.OnePtrValue(laser.SliceElValue(sl))
+	tv.ColLayout = tv.PrefsColLayout()
+	tv.SetSortFieldName(tv.ColLayout.Sort)
 	updt := tv.UpdateStart()
 	tv.ResetSelectedIdxs()
 	tv.SetFlag(false, SliceViewSelectMode)
@@ -231,11 +320,11 @@ func (tv *TableView) StructType() reflect.Type {
 	return tv.StruType
 }
 
-// CacheVisFields computes the number of visible fields in nVisFields and
-// caches those to skip in fieldSkip
+// CacheVisFields computes the fields that can be shown as columns in
+// AllFields, and the visible ones in VisFields (see ApplyColLayout)
 func (tv *TableView) CacheVisFields() {
 	styp := tv.StructType()
-	tv.VisFields = make([]reflect.StructField, 0)
+	tv.AllFields = make([]reflect.StructField, 0)
 	laser.FlatFieldsTypeFunc(styp, func(typ reflect.Type, fld reflect.StructField) bool {
 		if !fld.IsExported() {
 			return true
@@ -259,17 +348,17 @@ func (tv *TableView) CacheVisFields() {
 			if typ != styp {
 				rfld, has := styp.FieldByName(fld.Name)
 				if has {
-					tv.VisFields = append(tv.VisFields, rfld)
+					tv.AllFields = append(tv.AllFields, rfld)
 				} else {
 					fmt.Printf("TableView: Field name: %v is ambiguous from base struct type: %v, cannot be used in view!\n", fld.Name, styp.String())
 				}
 			} else {
-				tv.VisFields = append(tv.VisFields, fld)
+				tv.AllFields = append(tv.AllFields, fld)
 			}
 		}
 		return true
 	})
-	tv.NVisFields = len(tv.VisFields)
+	tv.ApplyColLayout()
 }
 
 // IsConfiged returns true if the widget is fully configured
@@ -374,6 +463,7 @@ func (tv *TableView) ConfigOneRow(sc *gi.Scene) {
 		widg := ki.NewOfType(vtyp).(gi.Widget)
 		sg.SetChild(widg, cidx, valnm)
 		vv.ConfigWidget(widg, sc)
+		tv.StyleColWidth(widg, fli)
 	}
 
 	if !tv.IsReadOnly() {
@@ -398,9 +488,17 @@ func (tv *TableView) ConfigOneRow(sc *gi.Scene) {
 			cidx++
 		}
 	}
-	if tv.SortIdx >= 0 {
-		tv.SortSlice()
-	}
+	tv.SortSlice()
+}
+
+// StyleColWidth adds a styler to given value widget for the column of given
+// visible field, giving it the width that the column has been resized to
+func (tv *TableView) StyleColWidth(w gi.Widget, fli int) {
+	w.Style(func(s *styles.Style) {
+		if wd := tv.ColWidth(fli); wd > 0 {
+			s.SetFixedWidth(units.Dp(wd))
+		}
+	})
 }
 
 func (tv *TableView) ConfigHeaderStyleWidth(w *gi.WidgetBase, sg *gi.Frame, spc float32, idx int) {
@@ -450,27 +548,33 @@ func (tv *TableView) ConfigHeader(sc *gi.Scene) {
 	}
 	for fli := 0; fli < nfld; fli++ {
 		fli := fli
-		field := tv.VisFields[fli]
 		hdr := sgh.Child(idxOff + fli).(*gi.Button)
 		hdr.SetType(gi.ButtonAction)
-		hdr.SetText(field.Name)
-		if fli == tv.SortIdx {
-			if tv.SortDesc {
-				hdr.SetIcon(icons.KeyboardArrowDown)
+		hdr.OnClick(func(e events.Event) {
+			if e.HasAnyModifier(key.Shift) {
+				tv.AddSortKeyAction(fli)
 			} else {
-				hdr.SetIcon(icons.KeyboardArrowUp)
+				tv.SortSliceAction(fli)
 			}
+		})
+		hdr.CustomContextMenu = func(m *gi.Scene) {
+			tv.HeaderCtxtMenu(m, fli)
 		}
-		hdr.Tooltip = field.Name + " (click to sort by)"
-		dsc := field.Tag.Get("desc")
-		if dsc != "" {
-			hdr.Tooltip += ": " + dsc
-		}
-		hdr.OnClick(func(e events.Event) {
-			tv.SortSliceAction(fli)
+		hdr.Style(func(s *styles.Style) {
+			s.SetAbilities(true, abilities.Slideable) // to resize and move
+		})
+		hdr.On(events.SlideStart, func(e events.Event) {
+			tv.HeaderSlideStart(fli, e)
+		})
+		hdr.On(events.SlideMove, func(e events.Event) {
+			tv.HeaderSlideMove(e)
+		})
+		hdr.On(events.SlideStop, func(e events.Event) {
+			tv.HeaderSlideStop(e)
 		})
 		tv.ConfigHeaderStyleWidth(hdr.AsWidget(), sg, spc, fli+idxOff)
 	}
+	tv.UpdateHeader()
 	if !tv.IsReadOnly() {
 		cidx := tv.NVisFields + idxOff
 		if !tv.Is(SliceViewNoAdd) {
@@ -580,10 +684,10 @@ func (tv *TableView) ConfigRows(sc *gi.Scene) {
 
 	for i := 0; i < tv.VisRows; i++ {
 		i := i
-		si := i
+		si := tv.SliceIdx(i)
 		ridx := i * nWidgPerRow
 		var val reflect.Value
-		if si < tv.SliceSize {
+		if i < tv.SliceSize {
 			val = laser.OnePtrUnderlyingValue(tv.SliceNPVal.Index(si)) // deal with pointer lists
 		} else {
 			val = tv.ElVal
@@ -602,6 +706,11 @@ func (tv *TableView) ConfigRows(sc *gi.Scene) {
 			sg.SetChild(idxlab, ridx, labnm)
 			idxlab.OnSelect(func(e events.Event) {
 				e.SetHandled()
+				if tv.CellMode { // select all cells of the row
+					tv.SelectCell(TableCell{tv.StartIdx + i, 0}, events.SelectOne)
+					tv.SelectCell(TableCell{tv.StartIdx + i, tv.NVisFields - 1}, events.ExtendContinuous)
+					return
+				}
 				tv.UpdateSelectRow(i)
 			})
 			idxlab.SetText(sitxt)
@@ -634,9 +743,18 @@ func (tv *TableView) ConfigRows(sc *gi.Scene) {
 			widg := ki.NewOfType(vtyp).(gi.Widget)
 			sg.SetChild(widg, cidx, valnm)
 			vv.ConfigWidget(widg, sc)
+			tv.StyleColWidth(widg, fli)
 			wb := widg.AsWidget()
 			wb.OnSelect(func(e events.Event) {
 				e.SetHandled()
+				if tv.CellMode {
+					selMode := events.SelectOne
+					if em := tv.EventMgr(); em != nil {
+						selMode = em.LastSelMode
+					}
+					tv.SelectCell(TableCell{tv.StartIdx + i, fli}, selMode)
+					return
+				}
 				tv.UpdateSelectRow(i)
 			})
 
@@ -646,6 +764,9 @@ func (tv *TableView) ConfigRows(sc *gi.Scene) {
 				vvb := vv.AsValueBase()
 				vvb.OnChange(func(e events.Event) {
 					tv.SetChanged()
+					if tv.CellMode {
+						tv.CellChanged(TableCell{tv.StartIdx + i, fli})
+					}
 				})
 			}
 		}
@@ -704,7 +825,8 @@ func (tv *TableView) UpdateWidgets() {
 	for i := 0; i < tv.VisRows; i++ {
 		i := i
 		ridx := i * nWidgPerRow
-		si := tv.StartIdx + i // slice idx
+		vi := tv.StartIdx + i // view idx
+		si := tv.SliceIdx(vi) // slice idx
 
 		var idxlab *gi.Label
 		if tv.Is(SliceViewShowIndex) {
@@ -728,7 +850,7 @@ func (tv *TableView) UpdateWidgets() {
 			widg := sg.Kids[cidx].(gi.Widget)
 
 			var val reflect.Value
-			if si < tv.SliceSize {
+			if vi < tv.SliceSize {
 				val = laser.OnePtrUnderlyingValue(tv.SliceNPVal.Index(si)) // deal with pointer lists
 				if laser.ValueIsZero(val) {
 					val = tv.ElVal
@@ -744,9 +866,12 @@ func (tv *TableView) UpdateWidgets() {
 			vv.SetReadOnly(tv.IsReadOnly())
 			vv.UpdateWidget()
 
-			if si < tv.SliceSize {
+			if vi < tv.SliceSize {
 				widg.SetState(false, states.Invisible)
 				issel := tv.IdxIsSelected(si)
+				if tv.CellMode {
+					issel = tv.CellIsSelected(TableCell{vi, fli})
+				}
 				if tv.IsReadOnly() {
 					widg.AsWidget().SetState(true, states.ReadOnly)
 				}
@@ -764,7 +889,7 @@ func (tv *TableView) UpdateWidgets() {
 		if !tv.IsReadOnly() {
 			cidx := ridx + tv.NVisFields + idxOff
 			invis := true
-			if si < tv.SliceSize {
+			if vi < tv.SliceSize {
 				invis = false
 			}
 			if !tv.Is(SliceViewNoAdd) {
@@ -803,10 +928,12 @@ func (tv *TableView) SliceNewAt(idx int) {
 	defer tv.UpdateEndLayout(updt)
 
 	tv.SliceNewAtSel(idx)
-	laser.SliceNewAt(tv.Slice, idx)
-	if idx < 0 {
-		idx = tv.SliceSize
+	ni := idx // index of the new element
+	if sz := tv.SliceNPVal.Len(); ni < 0 || ni > sz {
+		ni = sz
 	}
+	laser.SliceNewAt(tv.Slice, idx)
+	tv.IdxsInserted(ni, 1)
 
 	tv.This().(SliceViewer).UpdtSliceSize()
 	if tv.TmpSave != nil {
@@ -819,7 +946,7 @@ func (tv *TableView) SliceNewAt(idx int) {
 
 // SliceDeleteAt deletes element at given index from slice
 func (tv *TableView) SliceDeleteAt(idx int) {
-	if idx < 0 || idx >= tv.SliceSize {
+	if idx < 0 || idx >= tv.SliceNPVal.Len() {
 		return
 	}
 	tv.ViewMuLock()
@@ -829,6 +956,7 @@ func (tv *TableView) SliceDeleteAt(idx int) {
 	tv.SliceDeleteAtSel(idx)
 
 	laser.SliceDeleteAt(tv.Slice, idx)
+	tv.IdxsDeleted(idx)
 
 	tv.This().(SliceViewer).UpdtSliceSize()
 
@@ -840,49 +968,349 @@ func (tv *TableView) SliceDeleteAt(idx int) {
 	tv.Update()
 }
 
-// SortSlice sorts the slice according to current settings
+// UpdtSliceSize updates and returns the number of rows shown,
+// and sets SliceSize, first filtering and sorting the rows again
+// if the length of the slice has changed since they were
+func (tv *TableView) UpdtSliceSize() int {
+	if tv.Idxs != nil && tv.idxsLen != tv.SliceNPVal.Len() {
+		tv.SortSlice()
+	}
+	return tv.SliceViewBase.UpdtSliceSize()
+}
+
+// VisFieldIdx returns the index in VisFields of the field with given name,
+// or -1 if it is not visible
+func (tv *TableView) VisFieldIdx(name string) int {
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		if tv.VisFields[fli].Name == name {
+			return fli
+		}
+	}
+	return -1
+}
+
+// syncSortIdx replaces the first of the SortKeys with the SortIdx field
+// and the SortDesc direction if either has been set since they were last
+// synced, and then sets them from the first of the SortKeys
+func (tv *TableView) syncSortIdx() {
+	if tv.NVisFields == 0 {
+		return
+	}
+	if tv.SortIdx != tv.lastSortIdx || tv.SortDesc != tv.lastSortDesc {
+		switch {
+		case tv.SortIdx < 0 || tv.SortIdx >= tv.NVisFields:
+			tv.SortKeys = nil
+		case len(tv.SortKeys) > 0 && tv.SortKeys[0].Field == tv.VisFields[tv.SortIdx].Name:
+			tv.SortKeys[0].Desc = tv.SortDesc
+		default:
+			tv.SortKeys = []TableSortKey{{Field: tv.VisFields[tv.SortIdx].Name, Desc: tv.SortDesc}}
+		}
+	}
+	tv.SortIdx = -1
+	tv.SortDesc = false
+	if len(tv.SortKeys) > 0 {
+		tv.SortIdx = tv.VisFieldIdx(tv.SortKeys[0].Field)
+		tv.SortDesc = tv.SortKeys[0].Desc
+	}
+	tv.lastSortIdx = tv.SortIdx
+	tv.lastSortDesc = tv.SortDesc
+}
+
+// SortSlice filters and sorts the rows shown according to the current
+// Filters, Search and SortKeys (the first of which is set by SortIdx
+// and SortDesc), by setting the Idxs of the view, without changing
+// the slice itself.  Rows that are edited stay where they are until
+// this is called again.
 func (tv *TableView) SortSlice() {
-	if tv.SortIdx < 0 || tv.SortIdx >= len(tv.VisFields) {
+	if tv.IsNil() {
+		return
+	}
+	tv.syncSortIdx()
+	type fieldFilter struct {
+		idx []int
+		flt *TableFilter
+	}
+	var flts []fieldFilter
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		field := tv.VisFields[fli]
+		fs := tv.Filters[field.Name]
+		if strings.TrimSpace(fs) == "" {
+			continue
+		}
+		flt, err := NewTableFilter(fs, field.Type)
+		if err != nil {
+			slog.Error(err.Error())
+			continue
+		}
+		flts = append(flts, fieldFilter{field.Index, flt})
+	}
+	type sortKey struct {
+		idx  []int
+		desc bool
+	}
+	var keys []sortKey
+	for _, sk := range tv.SortKeys {
+		if fli := tv.VisFieldIdx(sk.Field); fli >= 0 {
+			keys = append(keys, sortKey{tv.VisFields[fli].Index, sk.Desc})
+		}
+	}
+	srch := strings.ToLower(tv.Search)
+	if len(flts) == 0 && len(keys) == 0 && srch == "" {
+		tv.SetIdxs(nil)
 		return
 	}
-	rawIdx := tv.VisFields[tv.SortIdx].Index
-	laser.StructSliceSort(tv.Slice, rawIdx, !tv.SortDesc)
+
+	match := func(row reflect.Value) bool {
+		if !row.IsValid() { // nil elements are always shown
+			return true
+		}
+		for _, ff := range flts {
+			if !ff.flt.Match(row.FieldByIndex(ff.idx)) {
+				return false
+			}
+		}
+		if srch == "" {
+			return true
+		}
+		for _, field := range tv.VisFields {
+			if strings.Contains(strings.ToLower(ValueText(row.FieldByIndex(field.Index))), srch) {
+				return true
+			}
+		}
+		return false
+	}
+
+	tv.ViewMuLock()
+	sz := tv.SliceNPVal.Len()
+	rows := make([]reflect.Value, sz) // struct values, invalid for nil
+	ixs := make([]int, 0, sz)
+	for i := 0; i < sz; i++ {
+		val := laser.OnePtrUnderlyingValue(tv.SliceNPVal.Index(i))
+		if !laser.ValueIsZero(val) {
+			rows[i] = val.Elem()
+		}
+		if match(rows[i]) {
+			ixs = append(ixs, i)
+		}
+	}
+	if len(keys) > 0 {
+		slices.SortFunc(ixs, func(a, b int) int {
+			ra, rb := rows[a], rows[b]
+			for _, k := range keys {
+				var c int
+				if ra.IsValid() && rb.IsValid() {
+					c = CompareValues(ra.FieldByIndex(k.idx), rb.FieldByIndex(k.idx))
+				} else {
+					c = CompareValues(ra, rb)
+				}
+				if k.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return a - b // stable
+		})
+	}
+	tv.ViewMuUnlock()
+	tv.SetIdxs(ixs)
 }
 
-// SortSliceAction sorts the slice for given field index -- toggles ascending
-// vs. descending if already sorting on this dimension
+// SortSliceAction sorts the rows by given field index only, toggling
+// ascending vs. descending if already sorting by only this field
 func (tv *TableView) SortSliceAction(fldIdx int) {
+	if fldIdx < 0 || fldIdx >= tv.NVisFields {
+		return
+	}
 	updt := tv.UpdateStart()
 	defer tv.UpdateEndLayout(updt)
 
-	sgh := tv.SliceHeader()
-	_, idxOff := tv.RowWidgetNs()
+	tv.syncSortIdx()
+	nm := tv.VisFields[fldIdx].Name
+	desc := false
+	if len(tv.SortKeys) == 1 && tv.SortKeys[0].Field == nm {
+		desc = !tv.SortKeys[0].Desc
+	}
+	tv.SortKeys = []TableSortKey{{Field: nm, Desc: desc}}
+	tv.UpdateHeader()
+	tv.SortSlice()
+	tv.Update()
+	tv.SaveColLayout()
+}
 
-	ascending := true
+// AddSortKeyAction adds given field index as the last key to sort the rows
+// by, after the current ones, or toggles ascending vs. descending if it is
+// already one of them
+func (tv *TableView) AddSortKeyAction(fldIdx int) {
+	if fldIdx < 0 || fldIdx >= tv.NVisFields {
+		return
+	}
+	updt := tv.UpdateStart()
+	defer tv.UpdateEndLayout(updt)
+
+	tv.syncSortIdx()
+	nm := tv.VisFields[fldIdx].Name
+	if ski := slices.IndexFunc(tv.SortKeys, func(sk TableSortKey) bool { return sk.Field == nm }); ski >= 0 {
+		tv.SortKeys[ski].Desc = !tv.SortKeys[ski].Desc
+	} else {
+		tv.SortKeys = append(tv.SortKeys, TableSortKey{Field: nm})
+	}
+	tv.UpdateHeader()
+	tv.SortSlice()
+	tv.Update()
+	tv.SaveColLayout()
+}
 
+// FilterAction sets the filter for the values of given field index
+// (see TableFilter), or removes it if empty, and updates the rows shown
+func (tv *TableView) FilterAction(fldIdx int, flt string) {
+	if fldIdx < 0 || fldIdx >= tv.NVisFields {
+		return
+	}
+	field := tv.VisFields[fldIdx]
+	flt = strings.TrimSpace(flt)
+	if flt != "" {
+		if _, err := NewTableFilter(flt, field.Type); err != nil {
+			slog.Error(err.Error())
+			return
+		}
+	}
+	updt := tv.UpdateStart()
+	defer tv.UpdateEndLayout(updt)
+
+	if flt == "" {
+		delete(tv.Filters, field.Name)
+	} else {
+		if tv.Filters == nil {
+			tv.Filters = map[string]string{}
+		}
+		tv.Filters[field.Name] = flt
+	}
+	tv.UpdateHeader()
+	tv.SortSlice()
+	tv.Update()
+}
+
+// FilterPrompt prompts for the filter for the values of given field index
+func (tv *TableView) FilterPrompt(fldIdx int) {
+	if fldIdx < 0 || fldIdx >= tv.NVisFields {
+		return
+	}
+	nm := tv.VisFields[fldIdx].Name
+	dlg := gi.NewDialog(tv).Title("Filter "+nm).
+		Prompt("Show only rows with a "+nm+" that contains the given text, matches a /regexp/, is in a range lo..hi or a comparison such as >= 10 for a number, or for which an =expression of its value x is true:").
+		StringPrompt(tv.Filters[nm], "Filter..").Cancel().Ok()
+	dlg.OnAccept(func(e events.Event) {
+		tv.FilterAction(fldIdx, dlg.Data.(string))
+	}).Run()
+}
+
+// SearchAction sets the quick Search text and updates the rows shown
+func (tv *TableView) SearchAction(srch string) {
+	updt := tv.UpdateStart()
+	defer tv.UpdateEndLayout(updt)
+
+	tv.Search = srch
+	tv.SortSlice()
+	tv.Update()
+}
+
+// ClearFiltersAction removes all of the Filters and the Search,
+// and the sorting, so that all of the rows are shown in slice order
+func (tv *TableView) ClearFiltersAction() {
+	updt := tv.UpdateStart()
+	defer tv.UpdateEndLayout(updt)
+
+	tv.syncSortIdx()
+	tv.SortKeys = nil
+	tv.Filters = nil
+	tv.Search = ""
+	if tb := tv.Toolbar(); tb != nil {
+		if sf, ok := tb.ChildByName("search", 1).(*gi.TextField); ok {
+			sf.SetText("")
+		}
+	}
+	tv.UpdateHeader()
+	tv.SortSlice()
+	tv.Update()
+}
+
+// UpdateHeader updates the column headers to show the SortKeys, with an
+// up or down arrow for the direction and their priority if more than one,
+// and the Filters
+func (tv *TableView) UpdateHeader() {
+	tv.syncSortIdx()
+	sgh := tv.SliceHeader()
+	_, idxOff := tv.RowWidgetNs()
+	if sgh.NumChildren() < idxOff+tv.NVisFields {
+		return
+	}
 	for fli := 0; fli < tv.NVisFields; fli++ {
+		field := tv.VisFields[fli]
 		hdr := sgh.Child(idxOff + fli).(*gi.Button)
-		hdr.SetType(gi.ButtonAction)
-		if fli == fldIdx {
-			if tv.SortIdx == fli {
-				tv.SortDesc = !tv.SortDesc
-				ascending = !tv.SortDesc
+		txt := field.Name
+		hdr.SetIcon("none")
+		ski := slices.IndexFunc(tv.SortKeys, func(sk TableSortKey) bool { return sk.Field == field.Name })
+		if ski >= 0 {
+			if tv.SortKeys[ski].Desc {
+				hdr.SetIcon(icons.KeyboardArrowDown)
 			} else {
-				tv.SortDesc = false
-			}
-			if ascending {
 				hdr.SetIcon(icons.KeyboardArrowUp)
-			} else {
-				hdr.SetIcon(icons.KeyboardArrowDown)
 			}
-		} else {
-			hdr.SetIcon("none")
+			if len(tv.SortKeys) > 1 {
+				txt += " " + strconv.Itoa(ski+1)
+			}
+		}
+		flt := tv.Filters[field.Name]
+		if flt != "" {
+			txt += ": " + flt
+		}
+		hdr.SetText(txt)
+		hdr.Tooltip = field.Name + " (click to sort by, shift-click to also sort by, context menu to filter)"
+		dsc := field.Tag.Get("desc")
+		if dsc != "" {
+			hdr.Tooltip += ": " + dsc
+		}
+		if flt != "" {
+			hdr.Tooltip += "\nfilter: " + flt
 		}
 	}
+}
 
-	tv.SortIdx = fldIdx
-	tv.SortSlice()
-	tv.Update()
+// HeaderCtxtMenu makes the context menu for the header of given field index
+func (tv *TableView) HeaderCtxtMenu(m *gi.Scene, fldIdx int) {
+	gi.NewButton(m, "sort").SetText("Sort by").SetIcon(icons.Sort).
+		OnClick(func(e events.Event) {
+			tv.SortSliceAction(fldIdx)
+		})
+	gi.NewButton(m, "add-sort").SetText("Also sort by").
+		OnClick(func(e events.Event) {
+			tv.AddSortKeyAction(fldIdx)
+		})
+	gi.NewSeparator(m, "sep-filter")
+	gi.NewButton(m, "filter").SetText("Filter...").SetIcon(icons.Search).
+		OnClick(func(e events.Event) {
+			tv.FilterPrompt(fldIdx)
+		})
+	if tv.Filters[tv.VisFields[fldIdx].Name] != "" {
+		gi.NewButton(m, "clear-filter").SetText("Clear filter").SetIcon(icons.Close).
+			OnClick(func(e events.Event) {
+				tv.FilterAction(fldIdx, "")
+			})
+	}
+	gi.NewButton(m, "clear-all").SetText("Show all rows").SetIcon(icons.ClearAll).
+		OnClick(func(e events.Event) {
+			tv.ClearFiltersAction()
+		})
+	gi.NewSeparator(m, "sep-cols")
+	gi.NewButton(m, "hide").SetText("Hide column").SetIcon(icons.VisibilityOff).
+		SetState(tv.NVisFields <= 1, states.Disabled).
+		OnClick(func(e events.Event) {
+			tv.SetColHiddenAction(tv.VisFields[fldIdx].Name, true)
+		})
+	gi.NewButton(m, "columns").SetText("Columns").SetIcon(icons.Visibility).
+		SetMenu(tv.ColumnsMenu)
 }
 
 // ConfigToolbar configures the toolbar actions
@@ -898,17 +1326,24 @@ func (tv *TableView) ConfigToolbar() {
 		return
 	}
 	tb := tv.Toolbar()
-	ndef := 1 // number of default actions
-	if tv.Is(SliceViewIsArray) || tv.IsReadOnly() || tv.Is(SliceViewNoAdd) {
-		ndef = 0
+	canAdd := !(tv.Is(SliceViewIsArray) || tv.IsReadOnly() || tv.Is(SliceViewNoAdd))
+	ndef := 1 + tv.NImportExport(canAdd) // number of default actions: add, import, export and search
+	if canAdd {
+		ndef++
 	}
 	if len(*tb.Children()) < ndef {
-		if ndef > 0 {
+		if canAdd {
 			gi.NewButton(tb, "add").SetText("Add").SetIcon(icons.Add).SetTooltip("add a new element to the table").
 				OnClick(func(e events.Event) {
 					tv.SliceNewAt(-1)
 				})
 		}
+		tv.ConfigImportExport(tb, canAdd)
+		sf := gi.NewTextField(tb, "search").SetPlaceholder("Search..").SetText(tv.Search)
+		sf.SetTooltip("show only the rows with a field that contains this text, ignoring case")
+		sf.OnChange(func(e events.Event) {
+			tv.SearchAction(sf.Text())
+		})
 	}
 	sz := len(*tb.Children())
 	if sz > ndef {
@@ -920,40 +1355,30 @@ func (tv *TableView) ConfigToolbar() {
 	tv.ToolbarSlice = tv.Slice
 }
 
-// SortFieldName returns the name of the field being sorted, along with :up or
-// :down depending on descending
+// SortFieldName returns the names of the fields being sorted by, each along
+// with :up or :down depending on descending, separated by commas
 func (tv *TableView) SortFieldName() string {
-	if tv.SortIdx >= 0 && tv.SortIdx < tv.NVisFields {
-		nm := tv.VisFields[tv.SortIdx].Name
-		if tv.SortDesc {
-			nm += ":down"
-		} else {
-			nm += ":up"
+	nms := make([]string, len(tv.SortKeys))
+	for i, sk := range tv.SortKeys {
+		nms[i] = sk.Field + ":up"
+		if sk.Desc {
+			nms[i] = sk.Field + ":down"
 		}
-		return nm
 	}
-	return ""
+	return strings.Join(nms, ",")
 }
 
-// SetSortField sets sorting to happen on given field and direction -- see
-// SortFieldName for details
+// SetSortFieldName sets sorting to happen on given fields and directions
+// -- see SortFieldName for details
 func (tv *TableView) SetSortFieldName(nm string) {
 	if nm == "" {
 		return
 	}
-	spnm := strings.Split(nm, ":")
-	for fli := 0; fli < tv.NVisFields; fli++ {
-		fld := tv.VisFields[fli]
-		if fld.Name == spnm[0] {
-			tv.SortIdx = fli
-		}
-	}
-	if len(spnm) == 2 {
-		if spnm[1] == "down" {
-			tv.SortDesc = true
-		} else {
-			tv.SortDesc = false
-		}
+	tv.syncSortIdx()
+	tv.SortKeys = nil
+	for _, fnm := range strings.Split(nm, ",") {
+		spnm := strings.Split(fnm, ":")
+		tv.SortKeys = append(tv.SortKeys, TableSortKey{Field: spnm[0], Desc: len(spnm) == 2 && spnm[1] == "down"})
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"slices"
 
 	"goki.dev/gi/v2/gi"
 	"goki.dev/girl/states"
@@ -67,7 +68,10 @@ func (tv *TreeView) ReSync() {
 // SyncToSrc updates the view tree to match the sync tree, using
 // ConfigChildren to maximally preserve existing tree elements.
 // init means we are doing initial build, and depth tracks depth
-// (only during init).
+// (only during init).  It makes a TreeView for every node of the sync
+// tree, including those under closed nodes, except in Virtual mode,
+// where the RootView just binds its rows to the nodes in view, at the
+// next layout (see syncVirtual).
 func (tv *TreeView) SyncToSrc(tvIdx *int, init bool, depth int) {
 	// pr := prof.Start("TreeView.SyncToSrc")
 	// defer pr.End()
@@ -83,6 +87,10 @@ func (tv *TreeView) SyncToSrc(tvIdx *int, init bool, depth int) {
 		// 	tv.SetClosed(true)
 		// }
 	}
+	if tv.IsVirtual() {
+		tv.syncVirtual()
+		return
+	}
 	vcprop := "view-closed"
 	skids := *sk.Children()
 	tnl := make(ki.Config, 0, len(skids))
@@ -151,6 +159,9 @@ func (tv *TreeView) UpdateReadOnly() bool {
 // SelectedSyncNodes returns a slice of the currently-selected
 // sync source nodes in the entire tree view
 func (tv *TreeView) SelectedSyncNodes() ki.Slice {
+	if tv.IsVirtual() {
+		return slices.Clone(tv.RootView.selSync)
+	}
 	var sn ki.Slice
 	sl := tv.SelectedViews()
 	for _, v := range sl {
@@ -160,8 +171,13 @@ func (tv *TreeView) SelectedSyncNodes() ki.Slice {
 }
 
 // FindSyncNode finds TreeView node for given source node,
-// or nil if not found
+// or nil if not found.  In Virtual mode, it is the row for the
+// node, which is scrolled into view, if the node is shown.
 func (tv *TreeView) FindSyncNode(kn ki.Ki) *TreeView {
+	if tv.IsVirtual() {
+		rn := tv.RootView
+		return rn.RowAt(rn.SyncNodeIdx(kn))
+	}
 	var ttv *TreeView
 	tv.WalkPre(func(k ki.Ki) bool {
 		tvki := AsTreeView(k)
@@ -215,8 +231,15 @@ func (tv *TreeView) AddTreeNodes(rel, myidx int, typ *gti.Type, n int) {
 	}
 }
 
+// AddSyncNodes adds n new nodes of given type to the SyncNode
+// of this node, at myidx, and selects the last one.
 func (tv *TreeView) AddSyncNodes(rel, myidx int, typ *gti.Type, n int) {
-	par := tv.SyncNode
+	tv.addSyncNodes(tv.SyncNode, rel, myidx, typ, n)
+}
+
+// addSyncNodes adds n new nodes of given type to given parent
+// in the sync tree, at myidx, and selects the last one.
+func (tv *TreeView) addSyncNodes(par ki.Ki, rel, myidx int, typ *gti.Type, n int) {
 	updt := par.UpdateStart()
 	var ski ki.Ki
 	for i := 0; i < n; i++ {
@@ -230,8 +253,7 @@ func (tv *TreeView) AddSyncNodes(rel, myidx int, typ *gti.Type, n int) {
 	tv.SendChangeEventReSync(nil)
 	par.UpdateEnd(updt)
 	if ski != nil {
-		if tvk := tv.ChildByName("tv_"+ski.Name(), 0); tvk != nil {
-			stv := AsTreeView(tvk)
+		if stv := tv.RootView.FindSyncNode(ski); stv != nil {
 			stv.SelectAction(events.SelectOne)
 		}
 	}
@@ -247,6 +269,9 @@ func (tv *TreeView) InsertAt(rel int, actNm string) {
 		return
 	}
 	myidx, ok := tv.IndexInParent()
+	if tv.SyncNode != nil { // the rows of a Virtual tree are not in the tree
+		myidx, ok = tv.SyncNode.IndexInParent()
+	}
 	if !ok {
 		return
 	}
@@ -259,11 +284,10 @@ func (tv *TreeView) InsertAt(rel int, actNm string) {
 	dlg.OnAccept(func(e events.Event) {
 		n := 1 // todo
 		typ := dlg.Data.(*gti.Type)
-		par := AsTreeView(tv.Par)
 		if tv.SyncNode != nil {
-			par.AddSyncNodes(rel, myidx, typ, n)
+			tv.addSyncNodes(tv.SyncNode.Parent(), rel, myidx, typ, n)
 		} else {
-			par.AddTreeNodes(rel, myidx, typ, n)
+			AsTreeView(tv.Par).AddTreeNodes(rel, myidx, typ, n)
 		}
 	}).Run()
 }
@@ -346,10 +370,9 @@ func (tv *TreeView) Duplicate() {
 
 func (tv *TreeView) DuplicateSync() {
 	sk := tv.SyncNode
-	tvpar := AsTreeView(tv.Par)
-	par := tvpar.SyncNode
+	par := sk.Parent()
 	if par == nil {
-		log.Printf("TreeView %v nil SyncNode in: %v\n", tv, tvpar.Path())
+		log.Printf("TreeView %v nil parent of SyncNode: %v\n", tv, sk.Path())
 		return
 	}
 	myidx, ok := sk.IndexInParent()
@@ -363,9 +386,8 @@ func (tv *TreeView) DuplicateSync() {
 	par.SetChildAdded()
 	par.InsertChild(nwkid, myidx+1)
 	par.UpdateEnd(updt)
-	tvpar.SendChangeEventReSync(nil)
-	if tvk := tvpar.ChildByName("tv_"+nm, 0); tvk != nil {
-		stv := AsTreeView(tvk)
+	tv.SendChangeEventReSync(nil)
+	if stv := tv.RootView.FindSyncNode(nwkid); stv != nil {
 		stv.SelectAction(events.SelectOne)
 	}
 }
@@ -396,8 +418,13 @@ func (tv *TreeView) GoGiEditNode() {
 // and an application/json of the sync node.
 // satisfies Clipper.MimeData interface
 func (tv *TreeView) MimeDataSync(md *mimedata.Mimes) {
+	tv.syncMimeData(md, tv.SyncNode)
+}
+
+// syncMimeData adds the mimedata of MimeDataSync for given
+// node of the sync tree
+func (tv *TreeView) syncMimeData(md *mimedata.Mimes, src ki.Ki) {
 	sroot := tv.RootView.SyncNode
-	src := tv.SyncNode
 	*md = append(*md, mimedata.NewTextData(src.PathFrom(sroot)))
 	var buf bytes.Buffer
 	err := ki.WriteNewJSON(src, &buf)
@@ -448,7 +475,6 @@ func (tv *TreeView) PasteAssignSync(md mimedata.Mimes) {
 func (tv *TreeView) PasteAtSync(md mimedata.Mimes, mod events.DropMods, rel int, actNm string) {
 	sk := tv.SyncNode
 	sl, pl := tv.NodesFromMimeData(md)
-	tvpar := AsTreeView(tv.Par)
 	par := sk.Parent()
 	myidx, ok := sk.IndexInParent()
 	if !ok {
@@ -477,10 +503,9 @@ func (tv *TreeView) PasteAtSync(md mimedata.Mimes, mod events.DropMods, rel int,
 		}
 	}
 	par.UpdateEnd(updt)
-	tvpar.SendChangeEventReSync(nil)
+	tv.SendChangeEventReSync(nil)
 	if selKi != nil {
-		if tvk := tvpar.ChildByName("tv_"+selKi.Name(), myidx); tvk != nil {
-			stv := AsTreeView(tvk)
+		if stv := tv.RootView.FindSyncNode(selKi); stv != nil {
 			stv.SelectAction(events.SelectOne)
 		}
 	}
@@ -80,6 +80,12 @@ func AsTreeView(k ki.Ki) *TreeView {
 // Standard events.Event are sent to any listeners, including
 // Select, Change, and DoubleClick.  The selected nodes
 // are in the root SelectedNodes list.
+//
+// For very large SyncNode trees, set Virtual on the root node, so that
+// there are only TreeViews for the rows of the tree that are in view
+// in the enclosing scrolling layout, which are reused for other nodes
+// of the tree as it scrolls, as in a SliceView (see VisNodes, StartIdx
+// and VisRows).
 type TreeView struct {
 	gi.WidgetBase
 
@@ -96,6 +102,14 @@ type TreeView struct {
 	// Nodes beyond this depth will be initialized as closed.
 	OpenDepth int `copy:"-" json:"-" xml:"-"`
 
+	// whether to only make TreeViews for the rows of the tree that are
+	// in view in the enclosing scrolling layout, binding them to the
+	// nodes of the SyncNode tree in view as it scrolls, which is much
+	// faster and smaller for very large trees -- set on the root node
+	// only, before calling SyncRootNode.  It has no effect without
+	// a SyncNode.
+	Virtual bool `copy:"-" json:"-" xml:"-"`
+
 	/////////////////////////////////////////
 	// All fields below are computed
 
@@ -115,6 +129,55 @@ type TreeView struct {
 	// RootView node only.
 	SelectedNodes []*TreeView `copy:"-" json:"-" xml:"-" edit:"-"`
 
+	// VisNodes are the nodes of the SyncNode tree that are shown as
+	// rows of the tree, because all of their parents are open, in order,
+	// on the RootView node only, in Virtual mode.
+	VisNodes []ki.Ki `copy:"-" json:"-" xml:"-" edit:"-"`
+
+	// height of each row of the tree, on the RootView node only,
+	// in Virtual mode
+	RowHeight float32 `copy:"-" json:"-" xml:"-" edit:"-"`
+
+	// index in VisNodes of the first row in view, on the RootView
+	// node only, in Virtual mode
+	StartIdx int `copy:"-" json:"-" xml:"-" edit:"-"`
+
+	// number of rows in view, starting at StartIdx, on the RootView
+	// node only, in Virtual mode
+	VisRows int `copy:"-" json:"-" xml:"-" edit:"-"`
+
+	// depths of the VisNodes below the SyncNode, for their indentation,
+	// on the RootView node only, in Virtual mode
+	visDepths []int
+
+	// whether the VisNodes need to be updated for nodes that have been
+	// opened or closed, or changes in the SyncNode tree, on the
+	// RootView node only, in Virtual mode
+	visStale bool
+
+	// open / closed state of the nodes of the SyncNode tree that
+	// have been opened or closed, on the RootView node only,
+	// in Virtual mode
+	closed map[ki.Ki]bool
+
+	// selected nodes of the SyncNode tree, in the order selected,
+	// on the RootView node only, in Virtual mode, where the rows
+	// are bound to other nodes as the tree scrolls
+	selSync ki.Slice
+
+	// set of the selSync nodes
+	selSet map[ki.Ki]bool
+
+	// index in VisNodes of the node of this row, in Virtual mode
+	visIdx int
+
+	// depth of the node of this row, for its indentation,
+	// in Virtual mode
+	visDepth int
+
+	// whether this row is bound to a node in view, in Virtual mode
+	inView bool
+
 	// actStateLayer is the actual state layer of the tree view, which
 	// should be used when rendering it and its parts (but not its children).
 	// the reason that it exists is so that the children of the tree view
@@ -232,7 +295,7 @@ func (tv *TreeView) TreeViewStyles() {
 				e.SetHandled()
 			})
 			parts.OnDoubleClick(func(e events.Event) {
-				if tv.HasChildren() {
+				if tv.hasKids() {
 					tv.ToggleClose()
 				}
 			})
@@ -380,7 +443,7 @@ func (tv *TreeView) ConfigParts(sc *gi.Scene) {
 	}
 	config.Add(gi.LabelType, "label")
 	mods, updt := parts.ConfigChildren(config)
-	if tv.HasChildren() {
+	if tv.hasKids() {
 		if wb, ok := tv.BranchPart(); ok {
 			tv.SetBranchState()
 			wb.Config(sc)
@@ -401,11 +464,14 @@ func (tv *TreeView) ConfigParts(sc *gi.Scene) {
 }
 
 func (tv *TreeView) ConfigWidget(sc *gi.Scene) {
+	if tv.outOfView() {
+		return
+	}
 	tv.ConfigParts(sc)
 }
 
 func (tv *TreeView) StyleTreeView(sc *gi.Scene) {
-	if !tv.HasChildren() {
+	if !tv.hasKids() {
 		tv.SetClosed(true)
 	}
 	tv.Indent.ToDots(&tv.Styles.UnContext)
@@ -416,6 +482,9 @@ func (tv *TreeView) StyleTreeView(sc *gi.Scene) {
 }
 
 func (tv *TreeView) ApplyStyle(sc *gi.Scene) {
+	if tv.outOfView() {
+		return
+	}
 	tv.StyMu.Lock() // todo: needed??  maybe not.
 	defer tv.StyMu.Unlock()
 
@@ -426,6 +495,10 @@ func (tv *TreeView) ApplyStyle(sc *gi.Scene) {
 // of its children but has to maintain its own bbox for its own widget.
 
 func (tv *TreeView) GetSize(sc *gi.Scene, iter int) {
+	if tv.IsVirtual() {
+		tv.GetSizeVirtual(sc, iter)
+		return
+	}
 	tv.InitLayout(sc)
 	tv.GetSizeParts(sc, iter) // get our size from parts
 	tv.WidgetSize = tv.LayState.Alloc.Size
@@ -456,7 +529,7 @@ func (tv *TreeView) SetBranchState() {
 		return
 	}
 	switch {
-	case !tv.HasChildren():
+	case !tv.hasKids():
 		br.SetState(true, states.Disabled)
 	case tv.IsClosed():
 		br.SetState(false, states.Disabled)
@@ -481,6 +554,9 @@ func (tv *TreeView) ChildrenBBoxes(sc *gi.Scene) image.Rectangle {
 }
 
 func (tv *TreeView) DoLayout(sc *gi.Scene, parBBox image.Rectangle, iter int) bool {
+	if tv.IsVirtual() {
+		return tv.DoLayoutVirtual(sc, parBBox, iter)
+	}
 	psize := tv.AddParentPos() // have to add our pos first before computing below:
 
 	rn := tv.RootView
@@ -543,6 +619,20 @@ func (tv *TreeView) RenderNode(sc *gi.Scene) {
 }
 
 func (tv *TreeView) Render(sc *gi.Scene) {
+	if tv.IsVirtual() {
+		tv.RenderVirtual(sc)
+		return
+	}
+	tv.RenderRow(sc)
+	// we always have to render our kids b/c
+	// we could be out of scope but they could be in!
+	if !tv.IsClosed() {
+		tv.RenderChildren(sc)
+	}
+}
+
+// RenderRow renders just the row of this node, not its children
+func (tv *TreeView) RenderRow(sc *gi.Scene) {
 	if tv.PushBounds(sc) {
 		tv.RenderNode(sc)
 		if tv.Parts != nil {
@@ -555,11 +645,6 @@ func (tv *TreeView) Render(sc *gi.Scene) {
 		}
 		tv.PopBounds(sc)
 	}
-	// we always have to render our kids b/c
-	// we could be out of scope but they could be in!
-	if !tv.IsClosed() {
-		tv.RenderChildren(sc)
-	}
 }
 
 //////////////////////////////////////////////////////////////////////////////
@@ -583,23 +668,40 @@ func (tv *TreeView) SelectModeToggle() {
 
 // SelectedViews returns a slice of the currently-selected
 // TreeViews within the entire tree, using a list maintained
-// by the root node
+// by the root node.  In Virtual mode, these are just the
+// selected rows in view: see SelectedSyncNodes for all of them.
 func (tv *TreeView) SelectedViews() []*TreeView {
 	if tv.RootView == nil {
 		return nil
 	}
+	if tv.IsVirtual() {
+		return tv.RootView.selectedRows()
+	}
 	return tv.RootView.SelectedNodes
 }
 
 // SetSelectedViews updates the selected views to given list
 func (tv *TreeView) SetSelectedViews(sl []*TreeView) {
-	if tv.RootView != nil {
-		tv.RootView.SelectedNodes = sl
+	if tv.RootView == nil {
+		return
 	}
+	if tv.IsVirtual() {
+		rn := tv.RootView
+		rn.selSync, rn.selSet = nil, nil
+		for _, v := range sl {
+			rn.selectSync(v.SyncNode, true)
+		}
+		rn.updateRowStates()
+		return
+	}
+	tv.RootView.SelectedNodes = sl
 }
 
 // HasSelection returns true if there are currently selected items
 func (tv *TreeView) HasSelection() bool {
+	if tv.IsVirtual() {
+		return len(tv.RootView.selSync) > 0
+	}
 	return len(tv.SelectedViews()) > 0
 }
 
@@ -609,9 +711,13 @@ func (tv *TreeView) Select() {
 	if !tv.StateIs(states.Selected) {
 		tv.SetSelected(true)
 		tv.ApplyStyle(tv.Sc)
-		sl := tv.SelectedViews()
-		sl = append(sl, tv)
-		tv.SetSelectedViews(sl)
+		if tv.IsVirtual() {
+			tv.RootView.selectSync(tv.SyncNode, true)
+		} else {
+			sl := tv.SelectedViews()
+			sl = append(sl, tv)
+			tv.SetSelectedViews(sl)
+		}
 		tv.SetNeedsRender()
 	}
 }
@@ -622,6 +728,11 @@ func (tv *TreeView) Unselect() {
 	if tv.StateIs(states.Selected) {
 		tv.SetSelected(false)
 		tv.ApplyStyle(tv.Sc)
+		if tv.IsVirtual() {
+			tv.RootView.selectSync(tv.SyncNode, false)
+			tv.SetNeedsRender()
+			return
+		}
 		sl := tv.SelectedViews()
 		sz := len(sl)
 		for i := 0; i < sz; i++ {
@@ -641,6 +752,11 @@ func (tv *TreeView) UnselectAll() {
 		return
 	}
 	updt := tv.UpdateStart()
+	if tv.IsVirtual() {
+		tv.SetSelectedViews(nil)
+		tv.UpdateEndRender(updt)
+		return
+	}
 	sl := tv.SelectedViews()
 	tv.SetSelectedViews(nil) // clear in advance
 	for _, v := range sl {
@@ -658,6 +774,11 @@ func (tv *TreeView) SelectAll() {
 	}
 	updt := tv.UpdateStart()
 	tv.UnselectAll()
+	if tv.IsVirtual() {
+		tv.RootView.selectAllVirtual()
+		tv.UpdateEndRender(updt)
+		return
+	}
 	nn := tv.RootView
 	nn.Select()
 	for nn != nil {
@@ -692,6 +813,10 @@ func (tv *TreeView) SelectUpdate(mode events.SelectModes) bool {
 			sel = true
 		}
 	case events.ExtendContinuous:
+		if tv.IsVirtual() {
+			sel = tv.extendSelectVirtual()
+			break
+		}
 		sl := tv.SelectedViews()
 		if len(sl) == 0 {
 			tv.Select()
@@ -791,6 +916,9 @@ func (tv *TreeView) UnselectAction() {
 // using given select mode (from keyboard modifiers).
 // Returns newly selected node.
 func (tv *TreeView) MoveDown(selMode events.SelectModes) *TreeView {
+	if tv.IsVirtual() {
+		return tv.moveVirtual(selMode, 1)
+	}
 	if tv.Par == nil {
 		return nil
 	}
@@ -824,6 +952,9 @@ func (tv *TreeView) MoveDownAction(selMode events.SelectModes) *TreeView {
 // MoveDownSibling moves down only to siblings, not down into children,
 // using given select mode (from keyboard modifiers)
 func (tv *TreeView) MoveDownSibling(selMode events.SelectModes) *TreeView {
+	if tv.IsVirtual() {
+		return tv.moveDownSiblingVirtual(selMode)
+	}
 	if tv.Par == nil {
 		return nil
 	}
@@ -847,6 +978,9 @@ func (tv *TreeView) MoveDownSibling(selMode events.SelectModes) *TreeView {
 // using given select mode (from keyboard modifiers).
 // Returns newly selected node
 func (tv *TreeView) MoveUp(selMode events.SelectModes) *TreeView {
+	if tv.IsVirtual() {
+		return tv.moveVirtual(selMode, -1)
+	}
 	if tv.Par == nil || tv == tv.RootView {
 		return nil
 	}
@@ -951,6 +1085,9 @@ func (tv *TreeView) MovePageDownAction(selMode events.SelectModes) *TreeView {
 // MoveToLastChild moves to the last child under me, using given select mode
 // (from keyboard modifiers)
 func (tv *TreeView) MoveToLastChild(selMode events.SelectModes) *TreeView {
+	if tv.IsVirtual() {
+		return tv.moveToLastChildVirtual(selMode)
+	}
 	if tv.Par == nil || tv == tv.RootView {
 		return nil
 	}
@@ -989,6 +1126,11 @@ func (tv *TreeView) MoveEndAction(selMode events.SelectModes) *TreeView {
 	} else if selMode == events.ExtendContinuous || selMode == events.ExtendOne {
 		mvMode = events.SelectQuiet
 	}
+	if tv.IsVirtual() {
+		fnn := tv.moveEndVirtual(selMode, mvMode)
+		tv.UpdateEnd(updt)
+		return fnn
+	}
 	fnn := tv.MoveDown(mvMode)
 	if fnn != nil && fnn != tv {
 		for {
@@ -1031,13 +1173,17 @@ func (tv *TreeView) Close() {
 		return
 	}
 	updt := tv.UpdateStart()
-	if tv.HasChildren() {
+	if tv.hasKids() {
 		tv.SetNeedsLayout()
 	}
 	tv.SetClosed(true)
 	tv.SetBranchState()
 	tv.This().(TreeViewer).OnClose()
-	tv.SetKidsVisibility(true) // parent closed
+	if tv.IsVirtual() {
+		tv.RootView.setSyncClosed(tv.SyncNode, true)
+	} else {
+		tv.SetKidsVisibility(true) // parent closed
+	}
 	tv.UpdateEndRender(updt)
 }
 
@@ -1054,12 +1200,16 @@ func (tv *TreeView) Open() {
 		return
 	}
 	updt := tv.UpdateStart()
-	if tv.HasChildren() {
+	if tv.hasKids() {
 		tv.SetNeedsLayout()
 		tv.SetClosed(false)
 		tv.SetBranchState()
 		tv.This().(TreeViewer).OnOpen()
-		tv.SetKidsVisibility(false)
+		if tv.IsVirtual() {
+			tv.RootView.setSyncClosed(tv.SyncNode, false)
+		} else {
+			tv.SetKidsVisibility(false)
+		}
 	}
 	tv.UpdateEndRender(updt)
 }
@@ -1075,6 +1225,10 @@ func (tv *TreeView) ToggleClose() {
 
 // OpenAll opens the given node and all of its sub-nodes
 func (tv *TreeView) OpenAll() {
+	if tv.IsVirtual() {
+		tv.setSyncClosedAll(false)
+		return
+	}
 	updt := tv.UpdateStart()
 	tv.WalkPre(func(k ki.Ki) bool {
 		tvki := AsTreeView(k)
@@ -1090,6 +1244,10 @@ func (tv *TreeView) OpenAll() {
 
 // CloseAll closes the given node and all of its sub-nodes.
 func (tv *TreeView) CloseAll() {
+	if tv.IsVirtual() {
+		tv.setSyncClosedAll(true)
+		return
+	}
 	updt := tv.UpdateStart()
 	tv.WalkPre(func(k ki.Ki) bool {
 		tvki := AsTreeView(k)
@@ -1107,6 +1265,10 @@ func (tv *TreeView) CloseAll() {
 // OpenParents opens all the parents of this node,
 // so that it will be visible.
 func (tv *TreeView) OpenParents() {
+	if tv.IsVirtual() {
+		tv.openParentsVirtual()
+		return
+	}
 	updt := tv.UpdateStart()
 	tv.WalkUpParent(func(k ki.Ki) bool {
 		tvki := AsTreeView(k)
@@ -1285,6 +1447,10 @@ func (tv *TreeView) NodesFromMimeData(md mimedata.Mimes) (ki.Slice, []string) {
 // Copy copies to clip.Board, optionally resetting the selection.
 // satisfies gi.Clipper interface and can be overridden by subtypes
 func (tv *TreeView) Copy(reset bool) {
+	if tv.IsVirtual() {
+		tv.copyVirtual(reset)
+		return
+	}
 	sels := tv.SelectedViews()
 	nitms := max(1, len(sels))
 	md := make(mimedata.Mimes, 0, 2*nitms)
@@ -0,0 +1,718 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"image"
+	"slices"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/states"
+	"goki.dev/goosi/events"
+	"goki.dev/goosi/mimedata"
+	"goki.dev/ki/v2"
+	"goki.dev/laser"
+	"goki.dev/mat32/v2"
+)
+
+// note: in Virtual mode, there is not a TreeView for every node of the
+// SyncNode tree.  Instead, as in SliceViewBase, the RootView has a pool
+// of row TreeViews as its children, which are bound to the nodes in view,
+// VisNodes[StartIdx:StartIdx+VisRows], as the tree scrolls, and the
+// RootView itself is the row for the root of the SyncNode tree.
+// Row i of the pool shows the nodes at VisNodes indexes that are i modulo
+// the number of rows, of which there is always one more than VisRows, so
+// that the rows above and below any row are different TreeViews.
+// The open / closed and selected states of the nodes are kept on the
+// RootView, by sync node, and set on the rows when they are bound.
+// Moving the selection to a node that is not in view binds a row to it,
+// so that selection, navigation, clipboard and editing of the sync tree
+// work on the rows as they do otherwise.
+
+// IsVirtual returns true if the tree is in Virtual mode, which is set
+// on the RootView, and only applies to a tree with a SyncNode
+func (tv *TreeView) IsVirtual() bool {
+	rn := tv.RootView
+	return rn != nil && rn.Virtual && rn.SyncNode != nil
+}
+
+// outOfView returns true if this node is a row of a Virtual tree that is
+// not bound to a node in view, so that it is not configured, styled or
+// laid out.  The RootView is always configured and styled, for its
+// Indent and RowHeight.
+func (tv *TreeView) outOfView() bool {
+	return tv.IsVirtual() && tv != tv.RootView && !tv.inView
+}
+
+// hasKids returns true if this node has children in the tree,
+// which for the rows of a Virtual tree are those of their SyncNode
+func (tv *TreeView) hasKids() bool {
+	if tv.IsVirtual() {
+		return tv.SyncNode != nil && tv.SyncNode.HasChildren()
+	}
+	return tv.HasChildren()
+}
+
+// isSyncClosed returns true if given node of the SyncNode tree is closed,
+// for this RootView in Virtual mode: nodes without children are closed,
+// and others are open, unless they have been closed, or have a true
+// "view-closed" property.
+func (tv *TreeView) isSyncClosed(sk ki.Ki) bool {
+	if !sk.HasChildren() {
+		return true
+	}
+	if cl, ok := tv.closed[sk]; ok {
+		return cl
+	}
+	if vcp, ok := sk.PropInherit("view-closed", ki.NoInherit); ok {
+		if vc, err := laser.ToBool(vcp); vc && err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setSyncClosed sets whether given node of the SyncNode tree is closed,
+// for this RootView in Virtual mode, which updates the VisNodes
+// at the next layout
+func (tv *TreeView) setSyncClosed(sk ki.Ki, closed bool) {
+	if tv.closed == nil {
+		tv.closed = map[ki.Ki]bool{}
+	}
+	tv.closed[sk] = closed
+	tv.visStale = true
+}
+
+// setSyncClosedAll opens or closes the SyncNode of this row
+// and all of the nodes under it, in Virtual mode
+func (tv *TreeView) setSyncClosedAll(closed bool) {
+	updt := tv.UpdateStart()
+	rn := tv.RootView
+	tv.SyncNode.WalkPre(func(k ki.Ki) bool {
+		if k.HasChildren() {
+			rn.setSyncClosed(k, closed)
+		}
+		return ki.Continue
+	})
+	rn.updateRowStates()
+	tv.SendChangeEvent(nil)
+	tv.UpdateEndLayout(updt)
+}
+
+// openParentsVirtual opens all of the parents of the SyncNode
+// of this row, in Virtual mode
+func (tv *TreeView) openParentsVirtual() {
+	updt := tv.UpdateStart()
+	rn := tv.RootView
+	if tv != rn && tv.SyncNode != nil {
+		for p := tv.SyncNode.Parent(); p != nil; p = p.Parent() {
+			rn.setSyncClosed(p, false)
+			if p == rn.SyncNode {
+				break
+			}
+		}
+	}
+	rn.updateRowStates()
+	tv.SendChangeEvent(nil)
+	tv.UpdateEndLayout(updt)
+}
+
+// UpdateVisNodes updates the VisNodes of this RootView node to the nodes
+// of the SyncNode tree that are shown as rows of the tree, because all of
+// their parents are open, in order.  It is called automatically during
+// layout in Virtual mode, after nodes are opened or closed, or ReSync.
+func (tv *TreeView) UpdateVisNodes() {
+	tv.visStale = false
+	tv.VisNodes = tv.VisNodes[:0]
+	tv.visDepths = tv.visDepths[:0]
+	if tv.SyncNode == nil {
+		return
+	}
+	var add func(sk ki.Ki, depth int)
+	add = func(sk ki.Ki, depth int) {
+		tv.VisNodes = append(tv.VisNodes, sk)
+		tv.visDepths = append(tv.visDepths, depth)
+		if tv.isSyncClosed(sk) {
+			return
+		}
+		for _, k := range *sk.Children() {
+			if k != nil && k.This() != nil {
+				add(k, depth+1)
+			}
+		}
+	}
+	add(tv.SyncNode, 0)
+}
+
+// checkVisNodes updates the VisNodes of this RootView if they are stale
+func (tv *TreeView) checkVisNodes() {
+	if tv.visStale || tv.VisNodes == nil {
+		tv.UpdateVisNodes()
+	}
+}
+
+// SyncNodeIdx returns the index in VisNodes of given node of the SyncNode
+// tree, for this RootView in Virtual mode, or -1 if it is not shown
+func (tv *TreeView) SyncNodeIdx(sk ki.Ki) int {
+	tv.checkVisNodes()
+	return slices.Index(tv.VisNodes, sk)
+}
+
+// visIdxOf returns the index in VisNodes of the node shown by given row,
+// for this RootView in Virtual mode, or -1 if it is not shown
+func (tv *TreeView) visIdxOf(r *TreeView) int {
+	if r == tv {
+		return 0
+	}
+	tv.checkVisNodes()
+	if i := r.visIdx; i >= 0 && i < len(tv.VisNodes) && tv.VisNodes[i] == r.SyncNode {
+		return i
+	}
+	return tv.SyncNodeIdx(r.SyncNode)
+}
+
+// configRows makes sure that this RootView has at least n rows, as its
+// children, of the same type as it, in Virtual mode, returning true if
+// any were made.  Rows are not deleted, so that a row that has the focus
+// or is in use remains valid.
+func (tv *TreeView) configRows(n int) bool {
+	if len(tv.Kids) >= n {
+		return false
+	}
+	_, updt := tv.SetNChildren(n, tv.This().KiType(), "row_")
+	for _, k := range tv.Kids {
+		AsTreeView(k).RootView = tv
+	}
+	tv.UpdateEnd(updt)
+	return true
+}
+
+// UpdateRows binds the rows of this RootView in Virtual mode to the nodes
+// in view, VisNodes[StartIdx:StartIdx+VisRows], making more rows if
+// needed, and configuring, styling and sizing the rows that are bound
+// to a different node, or whose node has changed state, for the given
+// Scene, if non-nil.  Other rows are unbound.  Returns true if new rows
+// were made.
+func (tv *TreeView) UpdateRows(sc *gi.Scene) bool {
+	tv.checkVisNodes()
+	mods := tv.configRows(tv.VisRows + 1)
+	n := len(tv.Kids)
+	st := max(tv.StartIdx, 1) // 0 is the RootView
+	ed := min(tv.StartIdx+tv.VisRows, len(tv.VisNodes))
+	for i, k := range tv.Kids {
+		r := AsTreeView(k)
+		if r.inView && (r.visIdx < st || r.visIdx >= ed || r.visIdx%n != i) {
+			r.unbindRow()
+		}
+	}
+	for idx := st; idx < ed; idx++ {
+		tv.bindRow(sc, AsTreeView(tv.Kids[idx%n]), idx)
+	}
+	tv.inView = tv.StartIdx == 0 && ed > 0
+	return mods
+}
+
+// bindRow binds given row to the node at given index in the VisNodes
+// of this RootView, in Virtual mode
+func (tv *TreeView) bindRow(sc *gi.Scene, r *TreeView, idx int) {
+	sk := tv.VisNodes[idx]
+	closed := tv.isSyncClosed(sk)
+	sel := tv.selSet[sk]
+	if r.inView && r.SyncNode == sk && r.visIdx == idx && r.IsClosed() == closed && r.StateIs(states.Selected) == sel {
+		return
+	}
+	r.RootView = tv
+	r.SyncNode = sk
+	r.ViewIdx = idx
+	r.visIdx = idx
+	r.visDepth = tv.visDepths[idx]
+	r.inView = true
+	r.SetClosed(closed)
+	r.SetSelected(sel)
+	if sc != nil {
+		r.ConfigTree(sc)
+		r.ApplyStyleTree(sc)
+		r.GetSizeTree(sc, 0)
+	}
+}
+
+// unbindRow unbinds this row from its node, when it is no longer
+// in view, in Virtual mode
+func (tv *TreeView) unbindRow() {
+	tv.inView = false
+	tv.SyncNode = nil
+	ClearBBoxes(&tv.WidgetBase)
+	if tv.Parts != nil {
+		ClearBBoxes(&tv.Parts.WidgetBase)
+	}
+}
+
+// boundRows returns the RootView and the rows that are bound
+// to the nodes in view, in Virtual mode
+func (tv *TreeView) boundRows() []*TreeView {
+	rows := []*TreeView{tv}
+	for _, k := range tv.Kids {
+		if r := AsTreeView(k); r.inView {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// RowAt returns the TreeView showing the node at given index in the
+// VisNodes of this RootView, in Virtual mode, first moving the rows in
+// view so that it is the first or last of them if it is not in view,
+// or nil if the index is out of range.  Call ScrollToMe on it to scroll
+// the enclosing layout to it.
+func (tv *TreeView) RowAt(idx int) *TreeView {
+	tv.checkVisNodes()
+	if idx < 0 || idx >= len(tv.VisNodes) {
+		return nil
+	}
+	if idx == 0 {
+		return tv
+	}
+	if idx < tv.StartIdx || idx >= tv.StartIdx+tv.VisRows {
+		tv.VisRows = max(tv.VisRows, 1)
+		if idx < tv.StartIdx {
+			tv.StartIdx = idx
+		} else {
+			tv.StartIdx = idx - tv.VisRows + 1
+		}
+		tv.SetNeedsLayout()
+	}
+	tv.UpdateRows(tv.Sc)
+	return AsTreeView(tv.Kids[idx%len(tv.Kids)])
+}
+
+// selectSync adds given node of the SyncNode tree to the selection
+// of this RootView, or removes it, in Virtual mode
+func (tv *TreeView) selectSync(sk ki.Ki, sel bool) {
+	if sk == nil || tv.selSet[sk] == sel {
+		return
+	}
+	if !sel {
+		delete(tv.selSet, sk)
+		tv.selSync = slices.DeleteFunc(tv.selSync, func(k ki.Ki) bool { return k == sk })
+		return
+	}
+	if tv.selSet == nil {
+		tv.selSet = map[ki.Ki]bool{}
+	}
+	tv.selSet[sk] = true
+	tv.selSync = append(tv.selSync, sk)
+}
+
+// selectedRows returns the selected rows in view, in Virtual mode
+func (tv *TreeView) selectedRows() []*TreeView {
+	var sl []*TreeView
+	for _, r := range tv.boundRows() {
+		if r.StateIs(states.Selected) {
+			sl = append(sl, r)
+		}
+	}
+	return sl
+}
+
+// updateRowStates updates the selected and closed states of the RootView
+// and the rows in view for those of their nodes, in Virtual mode
+func (tv *TreeView) updateRowStates() {
+	for _, r := range tv.boundRows() {
+		sel := tv.selSet[r.SyncNode]
+		closed := tv.isSyncClosed(r.SyncNode)
+		if r.StateIs(states.Selected) == sel && r.IsClosed() == closed {
+			continue
+		}
+		r.SetSelected(sel)
+		r.SetClosed(closed)
+		r.SetBranchState()
+		r.ApplyStyle(tv.Sc)
+		r.SetNeedsRender()
+	}
+}
+
+// selectAllVirtual selects all of the VisNodes of this RootView,
+// in Virtual mode
+func (tv *TreeView) selectAllVirtual() {
+	tv.checkVisNodes()
+	for _, sk := range tv.VisNodes {
+		tv.selectSync(sk, true)
+	}
+	tv.updateRowStates()
+}
+
+// extendSelectVirtual extends the selection to the node of this row,
+// and all of the nodes between it and the selected nodes, in Virtual
+// mode, returning true if it selected this row, when nothing else was.
+func (tv *TreeView) extendSelectVirtual() bool {
+	rn := tv.RootView
+	if len(rn.selSync) == 0 {
+		tv.Select()
+		tv.GrabFocus()
+		return true
+	}
+	idx := rn.visIdxOf(tv)
+	if idx < 0 {
+		return false
+	}
+	minIdx, maxIdx := -1, -1
+	for i, sk := range rn.VisNodes {
+		if rn.selSet[sk] {
+			if minIdx < 0 {
+				minIdx = i
+			}
+			maxIdx = i
+		}
+	}
+	st, ed := idx, idx
+	switch {
+	case minIdx < 0:
+	case idx < minIdx:
+		ed = minIdx
+	case idx > maxIdx:
+		st = maxIdx
+	}
+	for i := st; i <= ed; i++ {
+		rn.selectSync(rn.VisNodes[i], true)
+	}
+	rn.updateRowStates()
+	return false
+}
+
+// moveVirtual moves the selection by given number of rows from this row,
+// in Virtual mode, returning the row moved to, or nil if there is none
+func (tv *TreeView) moveVirtual(selMode events.SelectModes, delta int) *TreeView {
+	rn := tv.RootView
+	idx := rn.visIdxOf(tv)
+	if idx < 0 {
+		return nil
+	}
+	nn := rn.RowAt(idx + delta)
+	if nn != nil {
+		nn.SelectUpdate(selMode)
+	}
+	return nn
+}
+
+// moveDownSiblingVirtual moves the selection to the next row after this
+// row and all of the rows under it, in Virtual mode
+func (tv *TreeView) moveDownSiblingVirtual(selMode events.SelectModes) *TreeView {
+	rn := tv.RootView
+	idx := rn.visIdxOf(tv)
+	if idx <= 0 {
+		return nil
+	}
+	for i := idx + 1; i < len(rn.VisNodes); i++ {
+		if rn.visDepths[i] <= rn.visDepths[idx] {
+			nn := rn.RowAt(i)
+			nn.SelectUpdate(selMode)
+			return nn
+		}
+	}
+	return nil
+}
+
+// moveToLastChildVirtual moves the selection to the last row under this
+// row, or this row if it is closed, in Virtual mode
+func (tv *TreeView) moveToLastChildVirtual(selMode events.SelectModes) *TreeView {
+	rn := tv.RootView
+	idx := rn.visIdxOf(tv)
+	if idx <= 0 {
+		return nil
+	}
+	last := idx
+	for last+1 < len(rn.VisNodes) && rn.visDepths[last+1] > rn.visDepths[idx] {
+		last++
+	}
+	nn := rn.RowAt(last)
+	nn.SelectUpdate(selMode)
+	return nn
+}
+
+// moveEndVirtual moves the selection to the last row of the tree, in
+// Virtual mode, as MoveEndAction does, selecting all of the rows to it
+// directly when extending the selection, instead of moving down to each
+// of them in turn.
+func (tv *TreeView) moveEndVirtual(selMode, mvMode events.SelectModes) *TreeView {
+	rn := tv.RootView
+	idx := rn.visIdxOf(tv)
+	last := len(rn.VisNodes) - 1
+	if idx < 0 || idx >= last {
+		return nil
+	}
+	if mvMode == events.SelectQuiet {
+		for i := idx + 1; i <= last; i++ {
+			rn.selectSync(rn.VisNodes[i], true)
+		}
+		rn.updateRowStates()
+	}
+	fnn := rn.RowAt(last)
+	if selMode == events.SelectOne {
+		fnn.SelectUpdate(selMode)
+	}
+	fnn.GrabFocus()
+	fnn.ScrollToMe()
+	tv.SendSelectEvent(nil)
+	return fnn
+}
+
+// copyVirtual copies the node of this row, and all of the other selected
+// nodes of the SyncNode tree, including those not in view, to the
+// clipboard, in Virtual mode, optionally resetting the selection.
+func (tv *TreeView) copyVirtual(reset bool) {
+	rn := tv.RootView
+	md := make(mimedata.Mimes, 0, 2*max(1, len(rn.selSync)))
+	tv.This().(gi.Clipper).MimeData(&md) // source is always first..
+	for _, sn := range rn.selSync {
+		if sn != tv.SyncNode {
+			rn.syncMimeData(&md, sn)
+		}
+	}
+	tv.EventMgr().ClipBoard().Write(md)
+	if reset {
+		tv.UnselectAll()
+	}
+}
+
+// syncVirtual updates this RootView in Virtual mode for changes in its
+// SyncNode tree, for which the VisNodes are updated and all of the rows
+// are bound again at the next layout, forgetting any nodes that were
+// selected, opened or closed, that are no longer in the tree.
+func (tv *TreeView) syncVirtual() {
+	sk := tv.SyncNode
+	inTree := func(k ki.Ki) bool {
+		for p := k; p != nil; p = p.Parent() {
+			if p == sk {
+				return true
+			}
+		}
+		return false
+	}
+	sel := tv.selSync[:0]
+	for _, k := range tv.selSync {
+		if inTree(k) {
+			sel = append(sel, k)
+		} else {
+			delete(tv.selSet, k)
+		}
+	}
+	clear(tv.selSync[len(sel):])
+	tv.selSync = sel
+	for k := range tv.closed {
+		if !inTree(k) {
+			delete(tv.closed, k)
+		}
+	}
+	tv.SetClosed(tv.isSyncClosed(sk))
+	tv.SetSelected(tv.selSet[sk])
+	for _, k := range tv.Kids {
+		AsTreeView(k).visIdx = -1 // bind again for any changes in the nodes
+	}
+	tv.visStale = true
+	tv.SetNeedsLayout()
+}
+
+// GetSizeVirtual gets the size in Virtual mode, where only the rows in
+// view are sized, and the RootView is as tall as all of the VisNodes,
+// which all have the same RowHeight.
+func (tv *TreeView) GetSizeVirtual(sc *gi.Scene, iter int) {
+	if tv.outOfView() {
+		return
+	}
+	tv.InitLayout(sc)
+	tv.GetSizeParts(sc, iter) // get our size from parts
+	tv.WidgetSize = tv.LayState.Alloc.Size
+	if tv != tv.RootView {
+		return
+	}
+	tv.checkVisNodes()
+	h := mat32.Ceil(tv.WidgetSize.Y)
+	w := tv.WidgetSize.X
+	for _, k := range tv.Kids { // rows in view have already been sized
+		if r := AsTreeView(k); r.inView {
+			h = max(h, mat32.Ceil(r.WidgetSize.Y))
+			w = max(w, float32(r.visDepth)*tv.Indent.Dots+r.WidgetSize.X)
+		}
+	}
+	tv.RowHeight = h
+	tv.LayState.Alloc.Size = mat32.Vec2{w, h * float32(len(tv.VisNodes))}
+	tv.WidgetSize.X = w // stretch
+}
+
+// UpdateViewRows updates the StartIdx and VisRows of this RootView node
+// for the rows that are in view in the enclosing scrolling layout,
+// or within the given parent bounding box if there is none.
+// Layout happens before scrolling, so positions are unscrolled here.
+func (tv *TreeView) UpdateViewRows(sc *gi.Scene, parBBox image.Rectangle) {
+	vis := parBBox
+	off := float32(0)
+	if ly := tv.ParentScrollLayout(); ly != nil {
+		vis = ly.ChildrenBBoxes(sc)
+		if ly.HasScroll[mat32.Y] {
+			off = ly.Scrolls[mat32.Y].Value
+		}
+	}
+	top := float32(vis.Min.Y) + off - tv.LayState.Alloc.Pos.Y
+	bot := float32(vis.Max.Y) + off - tv.LayState.Alloc.Pos.Y
+	tv.StartIdx, tv.VisRows = ViewRows(top, bot, tv.RowHeight, len(tv.VisNodes))
+}
+
+// ViewRows returns the index of the first of n rows of given height
+// that is in view between top and bot, which are relative to the top of
+// the first row, and the number of rows in view, including any partly
+// in view.  The start is always within [0, n], and start+rows <= n.
+func ViewRows(top, bot, rowHeight float32, n int) (start, rows int) {
+	rh := max(rowHeight, 1)
+	start = min(max(int(mat32.Floor(top/rh)), 0), n)
+	rows = min(max(int(mat32.Ceil(bot/rh)), start), n) - start
+	return
+}
+
+// DoLayoutVirtual does the layout in Virtual mode, where the RootView
+// binds its rows to the nodes in view, and lays out its own row and
+// theirs.  It returns true for another layout pass when it has made
+// new rows, to get their sizes.
+func (tv *TreeView) DoLayoutVirtual(sc *gi.Scene, parBBox image.Rectangle, iter int) bool {
+	if tv != tv.RootView {
+		return false // laid out by the RootView
+	}
+	psize := tv.AddParentPos()
+	wi := tv.This().(gi.Widget)
+	tv.WidgetSize.X = tv.LayState.Alloc.Size.X
+	tv.LayState.Alloc.PosOrig = tv.LayState.Alloc.Pos
+	gi.SetUnitContext(&tv.Styles, sc, tv.NodeSize(), psize) // update units with final layout
+	tv.BBox = wi.BBoxes()                                   // all of the rows
+	wi.ComputeBBoxes(sc, parBBox, image.Point{})
+	cbb := tv.ScBBox
+
+	tv.UpdateViewRows(sc, parBBox)
+	mods := tv.UpdateRows(sc)
+	tv.layoutVirtual(sc, tv, parBBox, iter)
+	for _, k := range tv.Kids {
+		if r := AsTreeView(k); r.inView {
+			r.layoutVirtual(sc, tv, cbb, iter)
+		}
+	}
+	if gi.LayoutTrace {
+		fmt.Printf("Layout: %v virtual rows: %v start: %v of: %v\n", tv.Path(), tv.VisRows, tv.StartIdx, len(tv.VisNodes))
+	}
+	return mods && iter == 0
+}
+
+// ClearBBoxes clears the bounding boxes of given widget, which is not laid
+// out, so that it does not get any events
+func ClearBBoxes(wb *gi.WidgetBase) {
+	wb.BBoxMu.Lock()
+	wb.BBox = image.Rectangle{}
+	wb.ObjBBox = image.Rectangle{}
+	wb.ScBBox = image.Rectangle{}
+	wb.BBoxMu.Unlock()
+}
+
+// layoutVirtual lays out this row in Virtual mode, at the position of its
+// node under given RootView, and its parts if it is in view.  The RootView
+// has a bounding box spanning all of the rows.
+func (tv *TreeView) layoutVirtual(sc *gi.Scene, rn *TreeView, parBBox image.Rectangle, iter int) {
+	rh := rn.RowHeight
+	pos := rn.LayState.Alloc.PosOrig
+	pos.X += float32(tv.visDepth) * rn.Indent.Dots
+	pos.Y += float32(tv.visIdx) * rh
+	w := rn.WidgetSize.X - (pos.X - rn.LayState.Alloc.PosOrig.X)
+	nrows := 1
+	if tv == rn {
+		nrows = len(rn.VisNodes)
+	}
+	tv.LayState.Alloc.Pos = pos
+	tv.LayState.Alloc.PosOrig = pos
+	tv.LayState.Alloc.Size = mat32.Vec2{w, rh * float32(nrows)}
+	if tv.inView {
+		gi.SetUnitContext(&tv.Styles, sc, tv.NodeSize(), rn.LayState.Alloc.Size)
+	}
+	tv.BBox = tv.BBoxFromAlloc()
+	tv.ComputeBBoxesBase(sc, parBBox, image.Point{})
+	tv.WidgetSize = mat32.Vec2{w, rh}
+	tv.LayState.Alloc.Size = tv.WidgetSize
+	if tv.Parts == nil {
+		return
+	}
+	if !tv.inView {
+		ClearBBoxes(&tv.Parts.WidgetBase)
+		return
+	}
+	tv.SetBranchState()
+	tv.This().(TreeViewer).UpdateBranchIcons()
+	tv.DoLayoutParts(sc, parBBox, iter)
+}
+
+// LayoutScroll moves the rows of the tree for scrolling, which in Virtual
+// mode is done by the RootView, for its own row and the rows in view
+func (tv *TreeView) LayoutScroll(sc *gi.Scene, delta image.Point, parBBox image.Rectangle) {
+	if !tv.IsVirtual() {
+		tv.WidgetBase.LayoutScroll(sc, delta, parBBox)
+		return
+	}
+	if tv != tv.RootView {
+		return
+	}
+	tv.LayoutScrollBase(sc, delta, parBBox)
+	cbb := tv.ScBBox
+	if tv.inView && tv.Parts != nil {
+		tv.Parts.LayoutScroll(sc, delta, cbb)
+	}
+	for _, k := range tv.Kids {
+		r := AsTreeView(k)
+		if !r.inView {
+			continue
+		}
+		r.LayState.Alloc.Pos = r.LayState.Alloc.PosOrig.Add(mat32.NewVec2FmPoint(delta))
+		r.ComputeBBoxesBase(sc, cbb, delta)
+		if r.Parts != nil {
+			r.Parts.LayoutScroll(sc, delta, cbb)
+		}
+	}
+}
+
+// RenderVirtual renders in Virtual mode, where the RootView renders
+// its own row and the rows in view, and rows only render themselves
+// if they are in view (e.g., when they alone need to be rendered)
+func (tv *TreeView) RenderVirtual(sc *gi.Scene) {
+	if tv.inView {
+		tv.RenderRow(sc)
+	}
+	if tv != tv.RootView {
+		return
+	}
+	for _, k := range tv.Kids {
+		if r := AsTreeView(k); r.inView {
+			r.RenderRow(sc)
+		}
+	}
+}
+
+// ScrollToMe tells the parent layout (that has scroll bars) to scroll to
+// keep this node in view, which in Virtual mode uses the position of the
+// row of its node, as it may not have been laid out there yet.
+// Returns true if scrolled.
+func (tv *TreeView) ScrollToMe() bool {
+	if !tv.IsVirtual() {
+		return tv.WidgetBase.ScrollToMe()
+	}
+	rn := tv.RootView
+	ly := rn.ParentScrollLayout()
+	if ly == nil {
+		return false
+	}
+	idx := rn.visIdxOf(tv)
+	if idx < 0 {
+		return false
+	}
+	rh := max(rn.RowHeight, 1)
+	bb := rn.ObjBBox
+	bb.Min.X += int(float32(rn.visDepths[idx]) * rn.Indent.Dots)
+	bb.Min.Y += int(float32(idx) * rh)
+	bb.Max.Y = bb.Min.Y + int(mat32.Ceil(rh))
+	return ly.ScrollToBox(bb)
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/states"
+	"goki.dev/girl/styles"
+	"goki.dev/goosi/events"
+	"goki.dev/ki/v2"
+)
+
+// syncTree returns the root of a tree of ki.Nodes with given
+// number of children per node, to given depth
+func syncTree(depth, nkids int) *ki.Node {
+	root := ki.NewRoot[*ki.Node]("root")
+	addSyncKids(root, depth, nkids)
+	return root
+}
+
+func addSyncKids(n ki.Ki, depth, nkids int) {
+	if depth <= 0 {
+		return
+	}
+	for i := 0; i < nkids; i++ {
+		addSyncKids(ki.NewNode(n, fmt.Sprintf("%s_%d", n.Name(), i)), depth-1, nkids)
+	}
+}
+
+// treeScene returns an 800x600 Scene with a scrolling Frame holding
+// a TreeView, in Virtual mode or not, to be synced to a tree
+func treeScene(virtual bool) (*gi.Scene, *TreeView) {
+	sc := gi.NewScene("tree-test")
+	gi.NewMainStage(gi.WindowStage, sc, nil)
+	sc.Geom.Size = image.Point{800, 600}
+	fr := gi.NewFrame(sc, "fr")
+	fr.Style(func(s *styles.Style) {
+		s.SetStretchMax()
+	})
+	tv := NewTreeView(fr, "tv")
+	tv.SetVirtual(virtual)
+	return sc, tv
+}
+
+func layoutTreeScene(sc *gi.Scene) {
+	sc.ConfigScene()
+	sc.ApplyStyleScene()
+	sc.LayoutScene()
+}
+
+// countTreeViews returns the number of TreeViews in the Scene
+func countTreeViews(sc *gi.Scene) int {
+	n := 0
+	sc.WalkPre(func(k ki.Ki) bool {
+		if AsTreeView(k) != nil {
+			n++
+		}
+		return ki.Continue
+	})
+	return n
+}
+
+func visNodeNames(tv *TreeView) []string {
+	nms := make([]string, len(tv.VisNodes))
+	for i, n := range tv.VisNodes {
+		nms[i] = n.Name()
+	}
+	return nms
+}
+
+func syncNodeNames(sl ki.Slice) []string {
+	nms := make([]string, len(sl))
+	for i, n := range sl {
+		nms[i] = n.Name()
+	}
+	return nms
+}
+
+// checkRows checks that the rows in view of given Virtual RootView
+// show the VisNodes in view, at the positions of their rows
+func checkRows(t *testing.T, tv *TreeView) {
+	t.Helper()
+	nin := 0
+	for _, k := range tv.Kids {
+		r := AsTreeView(k)
+		if !r.inView {
+			continue
+		}
+		nin++
+		if r.visIdx < tv.StartIdx || r.visIdx >= tv.StartIdx+tv.VisRows || r.SyncNode != tv.VisNodes[r.visIdx] {
+			t.Errorf("row %s shows %v at %d, not in view from %d", r.Name(), r.SyncNode, r.visIdx, tv.StartIdx)
+			continue
+		}
+		if lbl, ok := r.LabelPart(); !ok || lbl.Text != r.SyncNode.Name() {
+			t.Errorf("row %s at %d is not labeled %s", r.Name(), r.visIdx, r.SyncNode.Name())
+		}
+		if y := tv.LayState.Alloc.PosOrig.Y + float32(r.visIdx)*tv.RowHeight; r.LayState.Alloc.PosOrig.Y != y {
+			t.Errorf("row %s at %d is at y = %g, want %g", r.Name(), r.visIdx, r.LayState.Alloc.PosOrig.Y, y)
+		}
+	}
+	want := tv.VisRows
+	if tv.StartIdx == 0 && want > 0 {
+		want-- // shown by the RootView
+	}
+	if nin != want {
+		t.Errorf("%d rows in view, want %d", nin, want)
+	}
+}
+
+func TestVirtualRows(t *testing.T) {
+	sc, tv := treeScene(true)
+	tv.SyncRootNode(syncTree(6, 5))
+	layoutTreeScene(sc)
+	if len(tv.VisNodes) != 19531 {
+		t.Fatalf("len(VisNodes) = %d, want 19531", len(tv.VisNodes))
+	}
+	if tv.VisRows <= 1 || tv.VisRows > 100 || tv.StartIdx != 0 {
+		t.Fatalf("StartIdx, VisRows = %d, %d", tv.StartIdx, tv.VisRows)
+	}
+	if n := countTreeViews(sc); n > tv.VisRows+2 {
+		t.Errorf("%d TreeViews for %d rows in view", n, tv.VisRows)
+	}
+	if !tv.inView || tv.RowHeight <= 0 {
+		t.Errorf("RootView row not in view, or RowHeight = %g", tv.RowHeight)
+	}
+	checkRows(t, tv)
+}
+
+func TestUpdateVisNodes(t *testing.T) {
+	sc, tv := treeScene(true)
+	src := syncTree(2, 2)
+	src.Child(1).SetProp("view-closed", true)
+	tv.SyncRootNode(src)
+	layoutTreeScene(sc)
+	want := []string{"root", "root_0", "root_0_0", "root_0_1", "root_1"}
+	if got := visNodeNames(tv); !slices.Equal(got, want) {
+		t.Fatalf("VisNodes = %v, want %v", got, want)
+	}
+	if !slices.Equal(tv.visDepths, []int{0, 1, 2, 2, 1}) {
+		t.Errorf("visDepths = %v", tv.visDepths)
+	}
+
+	r0, r1 := tv.RowAt(1), tv.RowAt(4)
+	if !r1.IsClosed() || r0.IsClosed() {
+		t.Errorf("closed = %v, %v, want false, true", r0.IsClosed(), r1.IsClosed())
+	}
+	r0.Close()
+	r1.Open()
+	want = []string{"root", "root_0", "root_1", "root_1_0", "root_1_1"}
+	if tv.SyncNodeIdx(src.Child(1)) != 2 {
+		t.Errorf("root_1 at %d, want 2", tv.SyncNodeIdx(src.Child(1)))
+	}
+	if got := visNodeNames(tv); !slices.Equal(got, want) {
+		t.Fatalf("VisNodes after closing = %v, want %v", got, want)
+	}
+	layoutTreeScene(sc)
+	checkRows(t, tv)
+}
+
+func TestVirtualSelect(t *testing.T) {
+	sc, tv := treeScene(true)
+	src := syncTree(3, 5)
+	tv.SyncRootNode(src)
+	layoutTreeScene(sc)
+	nvis := len(tv.VisNodes)
+	if tv.VisRows >= nvis {
+		t.Fatalf("all %d rows are in view", nvis)
+	}
+	selected := func() []string {
+		return syncNodeNames(tv.SelectedSyncNodes())
+	}
+
+	tv.SelectAction(events.SelectOne)
+	nn := tv.MoveDownAction(events.SelectOne)
+	if nn == nil || nn.SyncNode != tv.VisNodes[1] || !nn.StateIs(states.Selected) || tv.StateIs(states.Selected) {
+		t.Fatalf("MoveDownAction moved to %v", nn)
+	}
+	if got := selected(); !slices.Equal(got, []string{"root_0"}) {
+		t.Errorf("selected %v after moving down", got)
+	}
+
+	end := nn.MoveEndAction(events.SelectOne)
+	last := tv.VisNodes[nvis-1]
+	if end == nil || end.SyncNode != last || end == nn {
+		t.Fatalf("MoveEndAction moved to %v, want row for %v", end, last)
+	}
+	if got := selected(); !slices.Equal(got, []string{last.Name()}) {
+		t.Errorf("selected %v after moving to the end", got)
+	}
+	layoutTreeScene(sc) // scrolled to the end
+	if tv.StartIdx+tv.VisRows != nvis || !end.inView || end.SyncNode != last {
+		t.Errorf("rows %d to %d in view after moving to the end of %d", tv.StartIdx, tv.StartIdx+tv.VisRows, nvis)
+	}
+	checkRows(t, tv)
+	if n := countTreeViews(sc); n > tv.VisRows+2 {
+		t.Errorf("%d TreeViews for %d rows in view", n, tv.VisRows)
+	}
+	up := end.MoveUpAction(events.SelectOne)
+	if up == nil || up.SyncNode != tv.VisNodes[nvis-2] || up == end {
+		t.Errorf("MoveUpAction moved to %v", up)
+	}
+
+	// selection of nodes out of view
+	tv.RowAt(1).SelectAction(events.SelectOne)
+	tv.RowAt(4).SelectAction(events.ExtendContinuous)
+	if got := selected(); !slices.Equal(got, visNodeNames(tv)[1:5]) {
+		t.Errorf("selected %v after extending the selection", got)
+	}
+	layoutTreeScene(sc)
+	if !tv.HasSelection() || len(tv.SelectedViews()) != 0 {
+		t.Errorf("selected rows in view: %v", tv.SelectedViews())
+	}
+	tv.SelectAll()
+	if got := tv.SelectedSyncNodes(); len(got) != nvis {
+		t.Errorf("%d selected after SelectAll, want %d", len(got), nvis)
+	}
+	tv.UnselectAll()
+	if tv.HasSelection() || len(tv.SelectedViews()) != 0 {
+		t.Errorf("selected after UnselectAll: %v", selected())
+	}
+}
+
+func TestVirtualReSync(t *testing.T) {
+	sc, tv := treeScene(true)
+	src := syncTree(2, 3)
+	tv.SyncRootNode(src)
+	layoutTreeScene(sc)
+	r := tv.FindSyncNode(src.Child(0).Child(0))
+	r.SelectAction(events.SelectOne)
+	r.Close() // forgotten when deleted
+	src.Child(0).Child(0).Delete(true)
+	ki.NewNode(src.Child(2), "root_2_3")
+	tv.ReSync()
+	if tv.HasSelection() || len(tv.closed) != 0 {
+		t.Errorf("deleted node still selected or closed: %v, %v", tv.SelectedSyncNodes(), tv.closed)
+	}
+	layoutTreeScene(sc)
+	want := []string{"root", "root_0", "root_0_1", "root_0_2", "root_1", "root_1_0", "root_1_1", "root_1_2",
+		"root_2", "root_2_0", "root_2_1", "root_2_2", "root_2_3"}
+	if got := visNodeNames(tv); !slices.Equal(got, want) {
+		t.Fatalf("VisNodes after ReSync = %v, want %v", got, want)
+	}
+	checkRows(t, tv)
+}
+
+func TestViewRows(t *testing.T) {
+	for _, tt := range []struct {
+		top, bot, rh float32
+		n            int
+		start, rows  int
+	}{
+		{0, 100, 20, 10, 0, 5},
+		{10, 100, 20, 10, 0, 5},  // first row partly in view
+		{10, 101, 20, 10, 0, 6},  // last row partly in view
+		{40, 60, 20, 10, 2, 1},   // exactly one row
+		{-50, 30, 20, 10, 0, 2},  // above the first row
+		{150, 400, 20, 10, 7, 3}, // below the last row
+		{300, 400, 20, 10, 10, 0},
+		{0, 100, 20, 0, 0, 0},
+		{0, 5, 0, 10, 0, 5}, // zero row height counts as 1
+		{50, 40, 20, 10, 2, 0},
+	} {
+		start, rows := ViewRows(tt.top, tt.bot, tt.rh, tt.n)
+		if start != tt.start || rows != tt.rows {
+			t.Errorf("ViewRows(%g, %g, %g, %d) = %d, %d, want %d, %d",
+				tt.top, tt.bot, tt.rh, tt.n, start, rows, tt.start, tt.rows)
+		}
+	}
+}
+
+// BenchmarkTreeView measures syncing a TreeView to a large tree (19,531
+// nodes) and its first Config, ApplyStyle and Layout, and then restyling
+// and relaying it out, with Virtual off and on, reporting the number of
+// TreeViews made.  Run with:
+//
+//	go test -run=XXX -bench=TreeView -benchmem ./giv
+func BenchmarkTreeView(b *testing.B) {
+	src := syncTree(6, 5)
+	for _, virtual := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Virtual=%v/Config", virtual), func(b *testing.B) {
+			ntv := 0
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				sc, tv := treeScene(virtual)
+				b.StartTimer()
+				tv.SyncRootNode(src)
+				layoutTreeScene(sc)
+				ntv = countTreeViews(sc)
+			}
+			b.ReportMetric(float64(ntv), "treeviews")
+		})
+		sc, tv := treeScene(virtual)
+		tv.SyncRootNode(src)
+		layoutTreeScene(sc)
+		b.Run(fmt.Sprintf("Virtual=%v/Style", virtual), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sc.ApplyStyleScene()
+			}
+		})
+		b.Run(fmt.Sprintf("Virtual=%v/Layout", virtual), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sc.LayoutScene()
+			}
+		})
+	}
+}
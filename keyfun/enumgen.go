@@ -10,11 +10,11 @@ import (
 	"goki.dev/enums"
 )
 
-var _FunsValues = []Funs{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 65}
+var _FunsValues = []Funs{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73, 74, 75, 76, 77, 78, 79}
 
 // FunsN is the highest valid value
 // for type Funs, plus one.
-const FunsN Funs = 66
+const FunsN Funs = 80
 
 // An "invalid array index" compiler error signifies that the constant values have changed.
 // Re-run the enumgen command to generate them again.
@@ -86,141 +86,183 @@ func _FunsNoOp() {
 	_ = x[SaveAlt-(63)]
 	_ = x[CloseAlt1-(64)]
 	_ = x[CloseAlt2-(65)]
+	_ = x[AddCursorAbove-(66)]
+	_ = x[AddCursorBelow-(67)]
+	_ = x[AddCursorNextMatch-(68)]
+	_ = x[SplitSelectionLines-(69)]
+	_ = x[Fold-(70)]
+	_ = x[Unfold-(71)]
+	_ = x[UnfoldAll-(72)]
+	_ = x[MacroStart-(73)]
+	_ = x[MacroStop-(74)]
+	_ = x[MacroReplay-(75)]
+	_ = x[MacroReplayCount-(76)]
+	_ = x[NextDiagnostic-(77)]
+	_ = x[PrevDiagnostic-(78)]
+	_ = x[ReflowPara-(79)]
 }
 
 var _FunsNameToValueMap = map[string]Funs{
-	`Nil`:           0,
-	`nil`:           0,
-	`MoveUp`:        1,
-	`moveup`:        1,
-	`MoveDown`:      2,
-	`movedown`:      2,
-	`MoveRight`:     3,
-	`moveright`:     3,
-	`MoveLeft`:      4,
-	`moveleft`:      4,
-	`PageUp`:        5,
-	`pageup`:        5,
-	`PageDown`:      6,
-	`pagedown`:      6,
-	`Home`:          7,
-	`home`:          7,
-	`End`:           8,
-	`end`:           8,
-	`DocHome`:       9,
-	`dochome`:       9,
-	`DocEnd`:        10,
-	`docend`:        10,
-	`WordRight`:     11,
-	`wordright`:     11,
-	`WordLeft`:      12,
-	`wordleft`:      12,
-	`FocusNext`:     13,
-	`focusnext`:     13,
-	`FocusPrev`:     14,
-	`focusprev`:     14,
-	`Enter`:         15,
-	`enter`:         15,
-	`Accept`:        16,
-	`accept`:        16,
-	`CancelSelect`:  17,
-	`cancelselect`:  17,
-	`SelectMode`:    18,
-	`selectmode`:    18,
-	`SelectAll`:     19,
-	`selectall`:     19,
-	`Abort`:         20,
-	`abort`:         20,
-	`Copy`:          21,
-	`copy`:          21,
-	`Cut`:           22,
-	`cut`:           22,
-	`Paste`:         23,
-	`paste`:         23,
-	`PasteHist`:     24,
-	`pastehist`:     24,
-	`Backspace`:     25,
-	`backspace`:     25,
-	`BackspaceWord`: 26,
-	`backspaceword`: 26,
-	`Delete`:        27,
-	`delete`:        27,
-	`DeleteWord`:    28,
-	`deleteword`:    28,
-	`Kill`:          29,
-	`kill`:          29,
-	`Duplicate`:     30,
-	`duplicate`:     30,
-	`Transpose`:     31,
-	`transpose`:     31,
-	`TransposeWord`: 32,
-	`transposeword`: 32,
-	`Undo`:          33,
-	`undo`:          33,
-	`Redo`:          34,
-	`redo`:          34,
-	`Insert`:        35,
-	`insert`:        35,
-	`InsertAfter`:   36,
-	`insertafter`:   36,
-	`ZoomOut`:       37,
-	`zoomout`:       37,
-	`ZoomIn`:        38,
-	`zoomin`:        38,
-	`Prefs`:         39,
-	`prefs`:         39,
-	`Refresh`:       40,
-	`refresh`:       40,
-	`Recenter`:      41,
-	`recenter`:      41,
-	`Complete`:      42,
-	`complete`:      42,
-	`Lookup`:        43,
-	`lookup`:        43,
-	`Search`:        44,
-	`search`:        44,
-	`Find`:          45,
-	`find`:          45,
-	`Replace`:       46,
-	`replace`:       46,
-	`Jump`:          47,
-	`jump`:          47,
-	`HistPrev`:      48,
-	`histprev`:      48,
-	`HistNext`:      49,
-	`histnext`:      49,
-	`Menu`:          50,
-	`menu`:          50,
-	`WinFocusNext`:  51,
-	`winfocusnext`:  51,
-	`WinClose`:      52,
-	`winclose`:      52,
-	`WinSnapshot`:   53,
-	`winsnapshot`:   53,
-	`GoGiEditor`:    54,
-	`gogieditor`:    54,
-	`New`:           55,
-	`new`:           55,
-	`NewAlt1`:       56,
-	`newalt1`:       56,
-	`NewAlt2`:       57,
-	`newalt2`:       57,
-	`Open`:          58,
-	`open`:          58,
-	`OpenAlt1`:      59,
-	`openalt1`:      59,
-	`OpenAlt2`:      60,
-	`openalt2`:      60,
-	`Save`:          61,
-	`save`:          61,
-	`SaveAs`:        62,
-	`saveas`:        62,
-	`SaveAlt`:       63,
-	`savealt`:       63,
-	`CloseAlt1`:     64,
-	`closealt1`:     64,
-	`CloseAlt2`:     65,
-	`closealt2`:     65,
+	`Nil`:                 0,
+	`nil`:                 0,
+	`MoveUp`:              1,
+	`moveup`:              1,
+	`MoveDown`:            2,
+	`movedown`:            2,
+	`MoveRight`:           3,
+	`moveright`:           3,
+	`MoveLeft`:            4,
+	`moveleft`:            4,
+	`PageUp`:              5,
+	`pageup`:              5,
+	`PageDown`:            6,
+	`pagedown`:            6,
+	`Home`:                7,
+	`home`:                7,
+	`End`:                 8,
+	`end`:                 8,
+	`DocHome`:             9,
+	`dochome`:             9,
+	`DocEnd`:              10,
+	`docend`:              10,
+	`WordRight`:           11,
+	`wordright`:           11,
+	`WordLeft`:            12,
+	`wordleft`:            12,
+	`FocusNext`:           13,
+	`focusnext`:           13,
+	`FocusPrev`:           14,
+	`focusprev`:           14,
+	`Enter`:               15,
+	`enter`:               15,
+	`Accept`:              16,
+	`accept`:              16,
+	`CancelSelect`:        17,
+	`cancelselect`:        17,
+	`SelectMode`:          18,
+	`selectmode`:          18,
+	`SelectAll`:           19,
+	`selectall`:           19,
+	`Abort`:               20,
+	`abort`:               20,
+	`Copy`:                21,
+	`copy`:                21,
+	`Cut`:                 22,
+	`cut`:                 22,
+	`Paste`:               23,
+	`paste`:               23,
+	`PasteHist`:           24,
+	`pastehist`:           24,
+	`Backspace`:           25,
+	`backspace`:           25,
+	`BackspaceWord`:       26,
+	`backspaceword`:       26,
+	`Delete`:              27,
+	`delete`:              27,
+	`DeleteWord`:          28,
+	`deleteword`:          28,
+	`Kill`:                29,
+	`kill`:                29,
+	`Duplicate`:           30,
+	`duplicate`:           30,
+	`Transpose`:           31,
+	`transpose`:           31,
+	`TransposeWord`:       32,
+	`transposeword`:       32,
+	`Undo`:                33,
+	`undo`:                33,
+	`Redo`:                34,
+	`redo`:                34,
+	`Insert`:              35,
+	`insert`:              35,
+	`InsertAfter`:         36,
+	`insertafter`:         36,
+	`ZoomOut`:             37,
+	`zoomout`:             37,
+	`ZoomIn`:              38,
+	`zoomin`:              38,
+	`Prefs`:               39,
+	`prefs`:               39,
+	`Refresh`:             40,
+	`refresh`:             40,
+	`Recenter`:            41,
+	`recenter`:            41,
+	`Complete`:            42,
+	`complete`:            42,
+	`Lookup`:              43,
+	`lookup`:              43,
+	`Search`:              44,
+	`search`:              44,
+	`Find`:                45,
+	`find`:                45,
+	`Replace`:             46,
+	`replace`:             46,
+	`Jump`:                47,
+	`jump`:                47,
+	`HistPrev`:            48,
+	`histprev`:            48,
+	`HistNext`:            49,
+	`histnext`:            49,
+	`Menu`:                50,
+	`menu`:                50,
+	`WinFocusNext`:        51,
+	`winfocusnext`:        51,
+	`WinClose`:            52,
+	`winclose`:            52,
+	`WinSnapshot`:         53,
+	`winsnapshot`:         53,
+	`GoGiEditor`:          54,
+	`gogieditor`:          54,
+	`New`:                 55,
+	`new`:                 55,
+	`NewAlt1`:             56,
+	`newalt1`:             56,
+	`NewAlt2`:             57,
+	`newalt2`:             57,
+	`Open`:                58,
+	`open`:                58,
+	`OpenAlt1`:            59,
+	`openalt1`:            59,
+	`OpenAlt2`:            60,
+	`openalt2`:            60,
+	`Save`:                61,
+	`save`:                61,
+	`SaveAs`:              62,
+	`saveas`:              62,
+	`SaveAlt`:             63,
+	`savealt`:             63,
+	`CloseAlt1`:           64,
+	`closealt1`:           64,
+	`CloseAlt2`:           65,
+	`closealt2`:           65,
+	`AddCursorAbove`:      66,
+	`addcursorabove`:      66,
+	`AddCursorBelow`:      67,
+	`addcursorbelow`:      67,
+	`AddCursorNextMatch`:  68,
+	`addcursornextmatch`:  68,
+	`SplitSelectionLines`: 69,
+	`splitselectionlines`: 69,
+	`Fold`:                70,
+	`fold`:                70,
+	`Unfold`:              71,
+	`unfold`:              71,
+	`UnfoldAll`:           72,
+	`unfoldall`:           72,
+	`MacroStart`:          73,
+	`macrostart`:          73,
+	`MacroStop`:           74,
+	`macrostop`:           74,
+	`MacroReplay`:         75,
+	`macroreplay`:         75,
+	`MacroReplayCount`:    76,
+	`macroreplaycount`:    76,
+	`NextDiagnostic`:      77,
+	`nextdiagnostic`:      77,
+	`PrevDiagnostic`:      78,
+	`prevdiagnostic`:      78,
+	`ReflowPara`:          79,
+	`reflowpara`:          79,
 }
 
 var _FunsDescMap = map[Funs]string{
@@ -290,6 +332,20 @@ var _FunsDescMap = map[Funs]string{
 	63: ``,
 	64: ``,
 	65: ``,
+	66: ``,
+	67: ``,
+	68: ``,
+	69: ``,
+	70: ``,
+	71: ``,
+	72: ``,
+	73: ``,
+	74: ``,
+	75: ``,
+	76: ``,
+	77: ``,
+	78: ``,
+	79: ``,
 }
 
 var _FunsMap = map[Funs]string{
@@ -359,6 +415,20 @@ var _FunsMap = map[Funs]string{
 	63: `SaveAlt`,
 	64: `CloseAlt1`,
 	65: `CloseAlt2`,
+	66: `AddCursorAbove`,
+	67: `AddCursorBelow`,
+	68: `AddCursorNextMatch`,
+	69: `SplitSelectionLines`,
+	70: `Fold`,
+	71: `Unfold`,
+	72: `UnfoldAll`,
+	73: `MacroStart`,
+	74: `MacroStop`,
+	75: `MacroReplay`,
+	76: `MacroReplayCount`,
+	77: `NextDiagnostic`,
+	78: `PrevDiagnostic`,
+	79: `ReflowPara`,
 }
 
 // String returns the string representation
@@ -96,9 +96,23 @@ const (
 	OpenAlt2 // alternative version (e.g., alt)
 	Save
 	SaveAs
-	SaveAlt   // another alt (e.g., alt)
-	CloseAlt1 // alternative version (e.g., shift)
-	CloseAlt2 // alternative version (e.g., alt)
+	SaveAlt             // another alt (e.g., alt)
+	CloseAlt1           // alternative version (e.g., shift)
+	CloseAlt2           // alternative version (e.g., alt)
+	AddCursorAbove      // multi-cursor: add a cursor on the line above
+	AddCursorBelow      // multi-cursor: add a cursor on the line below
+	AddCursorNextMatch  // multi-cursor: select the next occurrence of the selected word
+	SplitSelectionLines // multi-cursor: one cursor at the end of each selected line
+	Fold                // fold the code region at the cursor
+	Unfold              // unfold the folded region at the cursor
+	UnfoldAll           // unfold all folded regions
+	MacroStart          // start recording a keyboard macro
+	MacroStop           // stop recording the keyboard macro
+	MacroReplay         // replay the last keyboard macro, on each selected line if there is a selection
+	MacroReplayCount    // replay the last keyboard macro a number of times, prompting for the count
+	NextDiagnostic      // move to the next diagnostic (error, warning etc) in the text
+	PrevDiagnostic      // move to the previous diagnostic in the text
+	ReflowPara          // hard wrap the paragraph at the cursor, or the selected ones, to the wrap column
 )
 
 // Map is a map between a key sequence (chord) and a specific KeyFun
@@ -517,6 +531,21 @@ var StdMaps = Maps{
 		"Alt+Meta+S":              SaveAlt,
 		"Shift+Meta+W":            CloseAlt1,
 		"Alt+Meta+W":              CloseAlt2,
+		"Alt+Meta+UpArrow":        AddCursorAbove,
+		"Alt+Meta+DownArrow":      AddCursorBelow,
+		"Meta+D":                  AddCursorNextMatch,
+		"Shift+Meta+L":            SplitSelectionLines,
+		"Alt+Meta+[":              Fold,
+		"Alt+Meta+]":              Unfold,
+		"Alt+Meta+0":              UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
+		"Alt+œ":                   ReflowPara,
 	}},
 	{"MacEmacs", "Mac with emacs-style navigation -- emacs wins in conflicts", Map{
 		"UpArrow":                 MoveUp,
@@ -651,6 +680,21 @@ var StdMaps = Maps{
 		"Alt+Meta+S":              SaveAlt,
 		"Shift+Meta+W":            CloseAlt1,
 		"Alt+Meta+W":              CloseAlt2,
+		"Alt+Meta+UpArrow":        AddCursorAbove,
+		"Alt+Meta+DownArrow":      AddCursorBelow,
+		"Meta+D":                  AddCursorNextMatch,
+		"Shift+Meta+L":            SplitSelectionLines,
+		"Alt+Meta+[":              Fold,
+		"Alt+Meta+]":              Unfold,
+		"Alt+Meta+0":              UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
+		"Alt+œ":                   ReflowPara,
 	}},
 	{"LinuxEmacs", "Linux with emacs-style navigation -- emacs wins in conflicts", Map{
 		"UpArrow":                 MoveUp,
@@ -770,6 +814,20 @@ var StdMaps = Maps{
 		"Control+Alt+S":           SaveAlt,
 		"Shift+Alt+W":             CloseAlt1,
 		"Control+Alt+W":           CloseAlt2,
+		"Control+Alt+UpArrow":     AddCursorAbove,
+		"Control+Alt+DownArrow":   AddCursorBelow,
+		"Shift+Control+D":         AddCursorNextMatch,
+		"Shift+Control+L":         SplitSelectionLines,
+		"Shift+Control+[":         Fold,
+		"Shift+Control+]":         Unfold,
+		"Control+Alt+0":           UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
 	}},
 	{"LinuxStd", "Standard Linux KeyMap", Map{
 		"UpArrow":                 MoveUp,
@@ -858,6 +916,20 @@ var StdMaps = Maps{
 		"Control+Alt+S":           SaveAlt,
 		"Shift+Control+W":         CloseAlt1,
 		"Control+Alt+W":           CloseAlt2,
+		"Control+Alt+UpArrow":     AddCursorAbove,
+		"Control+Alt+DownArrow":   AddCursorBelow,
+		"Shift+Control+D":         AddCursorNextMatch,
+		"Shift+Control+L":         SplitSelectionLines,
+		"Shift+Control+[":         Fold,
+		"Shift+Control+]":         Unfold,
+		"Control+Alt+0":           UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
 	}},
 	{"WindowsStd", "Standard Windows KeyMap", Map{
 		"UpArrow":                 MoveUp,
@@ -946,6 +1018,20 @@ var StdMaps = Maps{
 		"Control+Alt+S":           SaveAlt,
 		"Shift+Control+W":         CloseAlt1,
 		"Control+Alt+W":           CloseAlt2,
+		"Control+Alt+UpArrow":     AddCursorAbove,
+		"Control+Alt+DownArrow":   AddCursorBelow,
+		"Shift+Control+D":         AddCursorNextMatch,
+		"Shift+Control+L":         SplitSelectionLines,
+		"Shift+Control+[":         Fold,
+		"Shift+Control+]":         Unfold,
+		"Control+Alt+0":           UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
 	}},
 	{"ChromeStd", "Standard chrome-browser and linux-under-chrome bindings", Map{
 		"UpArrow":                 MoveUp,
@@ -1034,5 +1120,19 @@ var StdMaps = Maps{
 		"Control+Alt+S":           SaveAlt,
 		"Shift+Control+W":         CloseAlt1,
 		"Control+Alt+W":           CloseAlt2,
+		"Control+Alt+UpArrow":     AddCursorAbove,
+		"Control+Alt+DownArrow":   AddCursorBelow,
+		"Shift+Control+D":         AddCursorNextMatch,
+		"Shift+Control+L":         SplitSelectionLines,
+		"Shift+Control+[":         Fold,
+		"Shift+Control+]":         Unfold,
+		"Control+Alt+0":           UnfoldAll,
+		"F3":                      MacroStart,
+		"F4":                      MacroStop,
+		"Shift+F4":                MacroReplay,
+		"Control+F4":              MacroReplayCount,
+		"F8":                      NextDiagnostic,
+		"Shift+F8":                PrevDiagnostic,
+		"Alt+Q":                   ReflowPara,
 	}},
 }
@@ -0,0 +1,315 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ANSIColors are the 16 standard terminal colors used for the ANSI SGR
+// color codes 30-37 / 40-47 (0-7) and the bright versions 90-97 / 100-107 (8-15),
+// and for the first 16 entries of the 256-color palette.
+var ANSIColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ANSIStyle is the graphic rendition state set by ANSI SGR codes
+type ANSIStyle struct {
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+	Strike    bool
+	Fg        string
+	Bg        string
+}
+
+// IsPlain returns true if no styling is active
+func (as *ANSIStyle) IsPlain() bool {
+	return *as == ANSIStyle{}
+}
+
+// Span returns the opening span tag for the current style
+func (as *ANSIStyle) Span() string {
+	var sb strings.Builder
+	sb.WriteString(`<span style="`)
+	if as.Fg != "" {
+		sb.WriteString("color:" + as.Fg + ";")
+	}
+	if as.Bg != "" {
+		sb.WriteString("background-color:" + as.Bg + ";")
+	}
+	if as.Bold {
+		sb.WriteString("font-weight:bold;")
+	}
+	if as.Faint {
+		sb.WriteString("opacity:0.6;")
+	}
+	if as.Italic {
+		sb.WriteString("font-style:italic;")
+	}
+	switch {
+	case as.Underline && as.Strike:
+		sb.WriteString("text-decoration:underline line-through;")
+	case as.Underline:
+		sb.WriteString("text-decoration:underline;")
+	case as.Strike:
+		sb.WriteString("text-decoration:line-through;")
+	}
+	sb.WriteString(`">`)
+	return sb.String()
+}
+
+// ANSI256Color returns the hex color for given index in the
+// standard xterm 256-color palette.
+func ANSI256Color(idx int) string {
+	switch {
+	case idx < 0 || idx > 255:
+		return ""
+	case idx < 16:
+		return ANSIColors[idx]
+	case idx < 232:
+		idx -= 16
+		lv := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", lv(idx/36), lv((idx/6)%6), lv(idx%6))
+	default:
+		g := 8 + (idx-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", g, g, g)
+	}
+}
+
+// SetSGR updates the style according to the given list of SGR parameters
+// (the numbers between ESC[ and m).  An empty list is a reset.
+func (as *ANSIStyle) SetSGR(params []int) {
+	if len(params) == 0 {
+		*as = ANSIStyle{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch {
+		case p == 0:
+			*as = ANSIStyle{}
+		case p == 1:
+			as.Bold = true
+		case p == 2:
+			as.Faint = true
+		case p == 3:
+			as.Italic = true
+		case p == 4:
+			as.Underline = true
+		case p == 9:
+			as.Strike = true
+		case p == 21 || p == 22:
+			as.Bold = false
+			as.Faint = false
+		case p == 23:
+			as.Italic = false
+		case p == 24:
+			as.Underline = false
+		case p == 29:
+			as.Strike = false
+		case p >= 30 && p <= 37:
+			as.Fg = ANSIColors[p-30]
+		case p == 39:
+			as.Fg = ""
+		case p >= 40 && p <= 47:
+			as.Bg = ANSIColors[p-40]
+		case p == 49:
+			as.Bg = ""
+		case p >= 90 && p <= 97:
+			as.Fg = ANSIColors[p-90+8]
+		case p >= 100 && p <= 107:
+			as.Bg = ANSIColors[p-100+8]
+		case p == 38 || p == 48:
+			clr, n := ansiExtColor(params[i+1:])
+			i += n
+			if clr == "" {
+				continue
+			}
+			if p == 38 {
+				as.Fg = clr
+			} else {
+				as.Bg = clr
+			}
+		}
+	}
+}
+
+// ansiExtColor parses the extended color parameters that follow a 38 or 48
+// code: either 5;n (256-color palette) or 2;r;g;b (24-bit color).
+// Returns the color and the number of parameters consumed.
+func ansiExtColor(params []int) (string, int) {
+	if len(params) == 0 {
+		return "", 0
+	}
+	switch params[0] {
+	case 5:
+		if len(params) < 2 {
+			return "", len(params)
+		}
+		return ANSI256Color(params[1]), 2
+	case 2:
+		if len(params) < 4 {
+			return "", len(params)
+		}
+		cv := func(v int) int { return min(max(v, 0), 255) }
+		return fmt.Sprintf("#%02x%02x%02x", cv(params[1]), cv(params[2]), cv(params[3])), 4
+	}
+	return "", 1
+}
+
+// ansiEscLen returns the length of the escape sequence starting at b[0],
+// which must be an ESC character, along with the SGR parameters if it is
+// an SGR (ESC[...m) sequence (isSGR is false for all other sequences,
+// which are just skipped).
+func ansiEscLen(b []byte) (n int, isSGR bool, params []int) {
+	sz := len(b)
+	if sz < 2 {
+		return sz, false, nil
+	}
+	switch b[1] {
+	case '[': // CSI: params 0x30-0x3F, intermediates 0x20-0x2F, final 0x40-0x7E
+		i := 2
+		for i < sz && b[i] >= 0x30 && b[i] <= 0x3F {
+			i++
+		}
+		pend := i
+		for i < sz && b[i] >= 0x20 && b[i] <= 0x2F {
+			i++
+		}
+		if i >= sz {
+			return sz, false, nil
+		}
+		if b[i] != 'm' || pend != i {
+			return i + 1, false, nil
+		}
+		ps := string(b[2:pend])
+		if ps != "" {
+			for _, f := range strings.FieldsFunc(ps, func(r rune) bool { return r == ';' || r == ':' }) {
+				v, err := strconv.Atoi(f)
+				if err != nil {
+					v = 0
+				}
+				params = append(params, v)
+			}
+		}
+		return i + 1, true, params
+	case ']': // OSC: terminated by BEL or ESC \
+		for i := 2; i < sz; i++ {
+			if b[i] == 0x07 {
+				return i + 1, false, nil
+			}
+			if b[i] == 0x1b && i+1 < sz && b[i+1] == '\\' {
+				return i + 2, false, nil
+			}
+		}
+		return sz, false, nil
+	}
+	return 2, false, nil
+}
+
+// StripANSI returns the given bytes with all ANSI terminal escape
+// sequences removed.  If there are no escape sequences, the input
+// slice is returned as-is.
+func StripANSI(b []byte) []byte {
+	if bytes.IndexByte(b, 0x1b) < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		if b[i] != 0x1b {
+			out = append(out, b[i])
+			i++
+			continue
+		}
+		n, _, _ := ansiEscLen(b[i:])
+		i += n
+	}
+	return out
+}
+
+// ANSISpan is a span of text that is styled by ANSI SGR escape sequences,
+// with byte offsets into the text with the escape sequences removed
+type ANSISpan struct {
+
+	// starting byte offset of the span
+	St int
+
+	// ending byte offset of the span (exclusive)
+	Ed int
+
+	// style of the span, which is never plain
+	Style ANSIStyle
+}
+
+// ParseANSI returns the given line with all ANSI terminal escape
+// sequences removed, as [StripANSI] does, along with the spans of that
+// text that are styled by SGR sequences for colors (standard, bright,
+// 256-color and 24-bit), bold, italic, underline and strikethrough,
+// in order.  Styling does not carry across lines: each line starts
+// with no styling.
+func ParseANSI(line []byte) (text []byte, spans []ANSISpan) {
+	if bytes.IndexByte(line, 0x1b) < 0 {
+		return line, nil
+	}
+	text = make([]byte, 0, len(line))
+	var as ANSIStyle
+	st := 0
+	endSpan := func() {
+		if !as.IsPlain() && len(text) > st {
+			spans = append(spans, ANSISpan{St: st, Ed: len(text), Style: as})
+		}
+	}
+	for i := 0; i < len(line); {
+		if line[i] != 0x1b {
+			text = append(text, line[i])
+			i++
+			continue
+		}
+		n, isSGR, params := ansiEscLen(line[i:])
+		i += n
+		if !isSGR {
+			continue
+		}
+		nas := as
+		nas.SetSGR(params)
+		if nas != as {
+			endSpan()
+			as = nas
+			st = len(text)
+		}
+	}
+	endSpan()
+	return
+}
+
+// MarkupANSISpans returns the html markup for the given text, with the
+// given spans from [ParseANSI] as html span tags: the text is escaped,
+// and otherwise only tags are added, as for an [OutBufMarkupFunc].
+func MarkupANSISpans(text []byte, spans []ANSISpan) []byte {
+	if len(spans) == 0 {
+		return HTMLEscapeBytes(text)
+	}
+	mu := make([]byte, 0, len(text)+40*len(spans))
+	cp := 0
+	for _, sp := range spans {
+		mu = append(mu, HTMLEscapeBytes(text[cp:sp.St])...)
+		mu = append(mu, sp.Style.Span()...)
+		mu = append(mu, HTMLEscapeBytes(text[sp.St:sp.Ed])...)
+		mu = append(mu, "</span>"...)
+		cp = sp.Ed
+	}
+	return append(mu, HTMLEscapeBytes(text[cp:])...)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseANSI(t *testing.T) {
+	red := ANSIStyle{Fg: ANSIColors[1]}
+	tests := []struct {
+		line  string
+		text  string
+		spans []ANSISpan
+	}{
+		{"plain text", "plain text", nil},
+		{"a \x1b[31mred\x1b[0m b", "a red b", []ANSISpan{{St: 2, Ed: 5, Style: red}}},
+		{"\x1b[1;4mbu\x1b[24mb\x1b[m", "bub", []ANSISpan{
+			{St: 0, Ed: 2, Style: ANSIStyle{Bold: true, Underline: true}},
+			{St: 2, Ed: 3, Style: ANSIStyle{Bold: true}}}},
+		{"\x1b[31mre\x1b[31md", "red", []ANSISpan{{St: 0, Ed: 3, Style: red}}},
+		{"\x1b[38;5;196mx\x1b[48;2;1;2;3my", "xy", []ANSISpan{
+			{St: 0, Ed: 1, Style: ANSIStyle{Fg: "#ff0000"}},
+			{St: 1, Ed: 2, Style: ANSIStyle{Fg: "#ff0000", Bg: "#010203"}}}},
+		{"\x1b[2K\x1b]0;title\x07\x1b[31m\x1b[0mdone", "done", nil},
+		{"\x1b[92mgo: ok\x1b[", "go: ok", []ANSISpan{{St: 0, Ed: 6, Style: ANSIStyle{Fg: ANSIColors[10]}}}},
+	}
+	for _, tt := range tests {
+		text, spans := ParseANSI([]byte(tt.line))
+		if string(text) != tt.text {
+			t.Errorf("ParseANSI(%q) text = %q, want %q", tt.line, text, tt.text)
+		}
+		if !reflect.DeepEqual(spans, tt.spans) {
+			t.Errorf("ParseANSI(%q) spans = %v, want %v", tt.line, spans, tt.spans)
+		}
+		if st := StripANSI([]byte(tt.line)); string(st) != tt.text {
+			t.Errorf("StripANSI(%q) = %q, want %q", tt.line, st, tt.text)
+		}
+	}
+}
+
+func TestMarkupANSISpans(t *testing.T) {
+	text, spans := ParseANSI([]byte("if a<b \x1b[1;31mFAIL\x1b[0m & \x1b[3mdone"))
+	want := `if a&lt;b <span style="color:#cd0000;font-weight:bold;">FAIL</span> &amp; <span style="font-style:italic;">done</span>`
+	if mu := MarkupANSISpans(text, spans); string(mu) != want {
+		t.Errorf("MarkupANSISpans:\n got: %s\nwant: %s", mu, want)
+	}
+	if mu := MarkupANSISpans([]byte("a<b"), nil); string(mu) != "a&lt;b" {
+		t.Errorf("MarkupANSISpans with no spans = %s", mu)
+	}
+}
+
+func TestANSI256Color(t *testing.T) {
+	tests := map[int]string{0: "#000000", 9: "#ff0000", 16: "#000000", 21: "#0000ff", 231: "#ffffff", 232: "#080808", 255: "#eeeeee", 256: ""}
+	for idx, want := range tests {
+		if got := ANSI256Color(idx); got != want {
+			t.Errorf("ANSI256Color(%d) = %q, want %q", idx, got, want)
+		}
+	}
+}
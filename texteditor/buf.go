@@ -14,12 +14,16 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"goki.dev/enums"
 	"goki.dev/gi/v2/gi"
 	"goki.dev/gi/v2/texteditor/histyle"
+	"goki.dev/gi/v2/texteditor/lsp"
 	"goki.dev/gi/v2/texteditor/textbuf"
 	"goki.dev/girl/styles"
 	"goki.dev/glop/dirs"
@@ -79,6 +83,17 @@ type Buf struct {
 	// if true, auto-save file after changes (in a separate routine)
 	Autosave bool
 
+	// if true, the undo history is saved to a sidecar file (see UndoFilename)
+	// whenever the file is saved, and restored when the file is opened again,
+	// as long as the file has not been changed on disk in the meantime
+	PersistUndo bool
+
+	// if true, each edit is appended to a journal sidecar file (see
+	// JournalFilename) as soon as it is made, so that the edits since the
+	// file was last saved can be recovered after a crash -- see
+	// JournalRecoverPrompt
+	JournalEdits bool
+
 	// options for how text editing / viewing works
 	Opts textbuf.Opts
 
@@ -103,21 +118,22 @@ type Buf struct {
 	// icons for each LineIcons being used
 	Icons map[icons.Icon]*gi.Icon `json:"-" xml:"-"`
 
-	// the live lines of text being edited, with latest modifications -- encoded as runes per line, which is necessary for one-to-one rune / glyph rendering correspondence -- all TextPos positions etc are in *rune* indexes, not byte indexes!
-	Lines [][]rune `json:"-" xml:"-"`
+	// the live text being edited, with latest modifications -- this is the only store of the text, where all edits are made at a cost of O(log n) in the size of the text, and the lines are materialized from it as they are needed (see Line) -- encoded as runes per line, which is necessary for one-to-one rune / glyph rendering correspondence -- all TextPos positions etc are in *rune* indexes, not byte indexes!
+	Rope *textbuf.Rope `json:"-" xml:"-"`
 
-	// the live lines of text being edited, with latest modifications -- encoded in bytes per line translated from Lines, and used for input to markup -- essential to use Lines and not LineBytes when dealing with TextPos positions, which are in runes
-	LineBytes [][]byte   `json:"-" xml:"-"`
-	Tags      []lex.Line `json:"extra custom tagged regions for each line"`
-	HiTags    []lex.Line `json:"syntax highlighting tags -- auto-generated"`
+	// extra custom tagged regions for each line -- the per-line data are all kept in LineSeqs, where lines are inserted and deleted at a cost of O(log n)
+	Tags textbuf.LineSeq[lex.Line] `json:"-" xml:"-"`
+
+	// syntax highlighting tags for each line -- auto-generated
+	HiTags textbuf.LineSeq[lex.Line] `json:"-" xml:"-"`
 
 	// marked-up version of the edit text lines, after being run through the syntax highlighting process etc -- this is what is actually rendered
-	Markup [][]byte `json:"-" xml:"-"`
+	Markup textbuf.LineSeq[[]byte] `json:"-" xml:"-"`
 
 	// edits that have been made since last full markup
 	MarkupEdits []*textbuf.Edit `json:"-" xml:"-"`
 
-	// offsets for start of each line in Txt byte slice -- this is NOT updated with edits -- call SetByteOffs to set it when needed -- used for re-generating the Txt in LinesToBytes, and set on initial open in BytesToLines
+	// offsets for start of each line in Txt byte slice -- this is NOT updated with edits -- call SetByteOffs to set it when needed
 	ByteOffs []int `json:"-" xml:"-"`
 
 	// total bytes in document -- see ByteOffs for when it is updated
@@ -150,6 +166,56 @@ type Buf struct {
 	// functions and data for spelling correction
 	Spell *gi.Spell `json:"-" xml:"-"`
 
+	// Language Server Protocol client in use for this file, if any -- see SetLSP
+	LSP *lsp.Client `json:"-" xml:"-"`
+
+	// the latest diagnostics for this file from the LSP server
+	LSPDiags []lsp.Diagnostic `json:"-" xml:"-"`
+
+	// diagnostics for the text from all sources, e.g., the LSP server,
+	// go vet and the spell checker -- see SetDiagnostics
+	Diags textbuf.Diagnostics `json:"-" xml:"-"`
+
+	// lines that have line icons for the diagnostics, for removing them,
+	// and the cached Diagnostics adjusted for the edits, protected by diagsMu
+	diagIconLns []int
+	diagsCache  []textbuf.Diagnostic
+	diagsMu     sync.Mutex
+
+	// whether diagsCache needs to be recomputed, set by edits and
+	// by changes to the diagnostics
+	diagsStale atomic.Bool
+
+	// the journal of edits since the file was last saved, when JournalEdits is on
+	Journal textbuf.Journal `json:"-" xml:"-"`
+
+	// bracket nesting depth and indentation of each line, for rainbow
+	// brackets and indent guides -- protected by MarkupMu (see NestingCheck)
+	Nesting textbuf.Nesting `json:"-" xml:"-"`
+
+	// cached regions of lines that can be folded, and the tab size they were
+	// computed with, protected by foldsMu -- see FoldRegions
+	foldRegs  []textbuf.Fold
+	foldTabSz int
+	foldsMu   sync.Mutex
+
+	// whether foldRegs needs to be recomputed, set by edits and by a new
+	// parse of the text (in MarkupAllLines)
+	foldsStale atomic.Bool
+
+	// timer for sending edits to the LSP server
+	LSPDelayTimer *time.Timer `json:"-" xml:"-"`
+
+	// mutex for updating LSP delay timer
+	LSPDelayMu sync.Mutex `json:"-" xml:"-"`
+
+	// true if the change listener for the LSP server has been added
+	lspOnChange bool
+
+	// incremented by each ConfigLSP and CloseLSP, so that a server that
+	// finishes starting for a prior file is not used
+	lspGen atomic.Int64
+
 	// current text editor -- e.g., the one that initiated Complete or Correct process -- update cursor position in this view -- is reset to nil after usage always
 	CurView *Editor `json:"-" xml:"-"`
 
@@ -268,35 +334,27 @@ func (tb *Buf) SetChanged() {
 
 // SetText sets the text to given bytes
 func (tb *Buf) SetText(txt []byte) {
+	oend := tb.EndPos()
 	tb.Txt = txt
 	tb.BytesToLines()
+	tb.journalSetText(oend)
 	tb.InitialMarkup()
 	tb.SignalViews(BufNew, nil)
 	tb.ReMarkup()
 }
 
-// SetTextLines sets the text to given lines of bytes
-// if cpy is true, make a copy of bytes -- otherwise use
+// SetTextLines sets the text to given lines of bytes, which are
+// always copied into the Rope, so cpy no longer has any effect
 func (tb *Buf) SetTextLines(lns [][]byte, cpy bool) {
 	tb.LinesMu.Lock()
 	tb.NLines = len(lns)
 	tb.LinesMu.Unlock()
 	tb.NewBuf(tb.NLines)
 	tb.LinesMu.Lock()
-	bo := 0
-	for ln, txt := range lns {
-		tb.ByteOffs[ln] = bo
-		tb.Lines[ln] = bytes.Runes(txt)
-		if cpy {
-			tb.LineBytes[ln] = make([]byte, len(txt))
-			copy(tb.LineBytes[ln], txt)
-		} else {
-			tb.LineBytes[ln] = txt
-		}
-		tb.Markup[ln] = HTMLEscapeRunes(tb.Lines[ln])
-		bo += len(txt) + 1 // lf
-	}
-	tb.TotalBytes = bo
+	tb.Rope.SetText(bytes.Join(lns, []byte("\n"))) // always a copy
+	tb.InitMarkup()
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
 	tb.LinesMu.Unlock()
 	tb.LinesToBytes()
 	tb.InitialMarkup()
@@ -339,34 +397,36 @@ func (tb *Buf) IsValidLine(ln int) bool {
 	return true
 }
 
-// Line is the concurrent-safe accessor to specific Line of Lines runes
+// Line is the concurrent-safe accessor to the runes of specific line,
+// materialized from the Rope
 func (tb *Buf) Line(ln int) []rune {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
 	if ln >= tb.NLines || ln < 0 {
 		return nil
 	}
-	return tb.Lines[ln]
+	return tb.Rope.Line(ln)
 }
 
-// LineLen is the concurrent-safe accessor to length of specific Line of Lines runes
+// LineLen is the concurrent-safe accessor to length of specific line in runes
 func (tb *Buf) LineLen(ln int) int {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
 	if ln >= tb.NLines || ln < 0 {
 		return 0
 	}
-	return len(tb.Lines[ln])
+	return tb.Rope.LineLen(ln)
 }
 
-// BytesLine is the concurrent-safe accessor to specific Line of LineBytes
+// BytesLine is the concurrent-safe accessor to the bytes of specific line,
+// materialized from the Rope
 func (tb *Buf) BytesLine(ln int) []byte {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
 	if ln >= tb.NLines || ln < 0 {
 		return nil
 	}
-	return tb.LineBytes[ln]
+	return []byte(string(tb.Rope.Line(ln)))
 }
 
 // SetHiStyle sets the highlighting style -- needs to be protected by mutex
@@ -397,24 +457,17 @@ func (tb *Buf) NewBuf(nlines int) {
 	tb.LinesMu.Lock()
 	tb.MarkupMu.Lock()
 	tb.Undos.Reset()
-	tb.Lines = make([][]rune, nlines)
-	tb.LineBytes = make([][]byte, nlines)
-	tb.Tags = make([]lex.Line, nlines)
-	tb.HiTags = make([]lex.Line, nlines)
-	tb.Markup = make([][]byte, nlines)
-
-	if cap(tb.ByteOffs) >= nlines {
-		tb.ByteOffs = tb.ByteOffs[:nlines]
-	} else {
-		tb.ByteOffs = make([]int, nlines)
-	}
-
-	if nlines == 1 { // this is used for a new blank doc
-		tb.ByteOffs[0] = 0 // by definition
-		tb.Lines[0] = []rune("")
-		tb.LineBytes[0] = []byte("")
-		tb.Markup[0] = []byte("")
-	}
+	if tb.Rope == nil {
+		tb.Rope = &textbuf.Rope{}
+	}
+	tb.Rope.SetText(bytes.Repeat([]byte("\n"), nlines-1))
+	tb.Tags.Reset(nlines)
+	tb.HiTags.Reset(nlines)
+	tb.Markup.Reset(nlines)
+	tb.Nesting.Reset()
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
+	tb.ByteOffs = tb.ByteOffs[:0] // see SetByteOffs
 
 	tb.NLines = nlines
 
@@ -497,6 +550,10 @@ func (tb *Buf) Open(filename gi.FileName) error {
 	tb.InitialMarkup()
 	tb.SignalViews(BufNew, nil)
 	tb.ReMarkup()
+	tb.ConfigLSP()
+	if len(tb.Views) > 0 {
+		tb.JournalRecoverPrompt(tb.Views[0])
+	}
 	return nil
 }
 
@@ -509,9 +566,16 @@ func (tb *Buf) OpenFile(filename gi.FileName) error {
 	}
 	tb.Txt, err = ioutil.ReadAll(fp)
 	fp.Close()
+	tb.Journal.Delete() // any journal is for the prior text
 	tb.Filename = filename
 	tb.Stat()
 	tb.BytesToLines()
+	if tb.PersistUndo {
+		tb.UndoOpen()
+	}
+	if !tb.JournalCheck() { // otherwise keep it for JournalRecoverPrompt
+		tb.JournalStart()
+	}
 	return nil
 }
 
@@ -551,6 +615,7 @@ func (tb *Buf) Revert() bool {
 	}
 	tb.ClearChanged()
 	tb.AutoSaveDelete()
+	tb.JournalStart()
 	tb.SignalViews(BufNew, nil)
 	tb.ReMarkup()
 	return true
@@ -601,6 +666,11 @@ func (tb *Buf) SaveFile(filename gi.FileName) error {
 	} else {
 		tb.Filename = filename
 		tb.Stat()
+		if tb.PersistUndo {
+			tb.UndoSave()
+		}
+		tb.JournalStart()
+		tb.LSPSaved()
 	}
 	return err
 }
@@ -675,6 +745,9 @@ func (tb *Buf) Close(afterFun func(canceled bool)) bool {
 		}
 		return false // awaiting decisions..
 	}
+	tb.CloseLSP()
+	tb.ClearDiagnostics()
+	tb.JournalDelete()
 	tb.SignalViews(BufClosed, nil)
 	tb.NewBuf(1)
 	tb.Filename = ""
@@ -757,7 +830,7 @@ func (tb *Buf) EndPos() lex.Pos {
 	if tb.NLines == 0 {
 		return lex.PosZero
 	}
-	ed := lex.Pos{tb.NLines - 1, len(tb.Lines[tb.NLines-1])}
+	ed := lex.Pos{tb.NLines - 1, tb.Rope.LineLen(tb.NLines - 1)}
 	return ed
 }
 
@@ -812,9 +885,11 @@ func (tb *Buf) AppendTextMarkup(text []byte, markup []byte, signal bool) *textbu
 		log.Printf("Buf AppendTextMarkup: markup text less than appended text: is: %v, should be: %v\n", len(msplt), sz)
 		el = min(st+len(msplt)-1, el)
 	}
+	tb.MarkupMu.Lock()
 	for ln := st; ln <= el; ln++ {
-		tb.Markup[ln] = msplt[ln-st]
+		tb.Markup.Set(ln, msplt[ln-st])
 	}
+	tb.MarkupMu.Unlock()
 	if signal {
 		tb.SignalViews(BufInsert, tbe)
 	}
@@ -843,7 +918,9 @@ func (tb *Buf) AppendTextLineMarkup(text []byte, markup []byte, signal bool) *te
 		efft = tcpy
 	}
 	tbe := tb.InsertText(ed, efft, false)
-	tb.Markup[tbe.Reg.Start.Ln] = markup
+	tb.MarkupMu.Lock()
+	tb.Markup.Set(tbe.Reg.Start.Ln, markup)
+	tb.MarkupMu.Unlock()
 	if signal {
 		tb.SignalViews(BufInsert, tbe)
 	}
@@ -878,6 +955,20 @@ func (tb *Buf) SceneFromView() *gi.Scene {
 	return nil
 }
 
+// RunOnViews runs the given function, which updates the buffer and its
+// views, in the event loop of the window of the views, which is how
+// this must be done from other goroutines (see [gi.Scene.RunOnEventLoop]).
+// If no view is in a window, the function is called directly.
+func (tb *Buf) RunOnViews(fun func()) {
+	for _, ed := range tb.Views {
+		if ed != nil && ed.Sc != nil {
+			ed.Sc.RunOnEventLoop(fun)
+			return
+		}
+	}
+	fun()
+}
+
 // AutoscrollViews ensures that views are always viewing the end of the buffer
 func (tb *Buf) AutoScrollViews() {
 	for _, ed := range tb.Views {
@@ -907,15 +998,17 @@ func (tb *Buf) BatchUpdateEnd(autoSave bool) {
 
 // SetByteOffs sets the byte offsets for each line into the raw text
 func (tb *Buf) SetByteOffs() {
-	bo := 0
-	for ln, txt := range tb.LineBytes {
-		tb.ByteOffs[ln] = bo
-		bo += len(txt) + 1 // lf
+	txt := tb.LinesToBytesCopy()
+	tb.ByteOffs = append(tb.ByteOffs[:0], 0)
+	for i, b := range txt[:len(txt)-1] {
+		if b == '\n' {
+			tb.ByteOffs = append(tb.ByteOffs, i+1)
+		}
 	}
-	tb.TotalBytes = bo
+	tb.TotalBytes = len(txt)
 }
 
-// LinesToBytes converts current Lines back to the Txt slice of bytes.
+// LinesToBytes converts the current text in the Rope back to the Txt slice of bytes.
 func (tb *Buf) LinesToBytes() {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
@@ -927,60 +1020,90 @@ func (tb *Buf) LinesToBytes() {
 		return
 	}
 
-	txt := bytes.Join(tb.LineBytes, []byte("\n"))
+	txt := tb.Rope.Bytes()
 	txt = append(txt, '\n')
 	tb.Txt = txt
 }
 
-// LinesToBytesCopy converts current Lines into a separate text byte copy --
-// e.g., for autosave or other "offline" uses of the text -- doesn't affect
-// byte offsets etc
+// LinesToBytesCopy converts the current text in the Rope into a separate
+// text byte copy -- e.g., for autosave or other "offline" uses of the text --
+// doesn't affect byte offsets etc
 func (tb *Buf) LinesToBytesCopy() []byte {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
 
-	txt := bytes.Join(tb.LineBytes, []byte("\n"))
+	txt := tb.Rope.Bytes()
 	txt = append(txt, '\n')
 	return txt
 }
 
-// BytesToLines converts current Txt bytes into lines, and initializes markup
-// with raw text
+// BytesToLines sets the text in the Rope from the current Txt bytes,
+// and initializes markup with raw text
 func (tb *Buf) BytesToLines() {
 	if len(tb.Txt) == 0 {
 		tb.NewBuf(1)
 		return
 	}
 	tb.LinesMu.Lock()
-	lns := bytes.Split(tb.Txt, []byte("\n"))
-	tb.NLines = len(lns)
-	if len(lns[tb.NLines-1]) == 0 { // lines have lf at end typically
+	tb.NLines = bytes.Count(tb.Txt, []byte("\n")) + 1
+	if tb.Txt[len(tb.Txt)-1] == '\n' { // lines have lf at end typically
 		tb.NLines--
-		lns = lns[:tb.NLines]
 	}
 	tb.LinesMu.Unlock()
 	tb.NewBuf(tb.NLines)
 	tb.LinesMu.Lock()
-	bo := 0
-	for ln, txt := range lns {
-		tb.ByteOffs[ln] = bo
-		tb.Lines[ln] = bytes.Runes(txt)
-		tb.LineBytes[ln] = make([]byte, len(txt))
-		copy(tb.LineBytes[ln], txt)
-		tb.Markup[ln] = HTMLEscapeRunes(tb.Lines[ln])
-		bo += len(txt) + 1 // lf
-	}
-	tb.TotalBytes = bo
+	tb.Rope.SetText(bytes.TrimSuffix(tb.Txt, []byte("\n")))
+	tb.InitMarkup()
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
 	tb.LinesMu.Unlock()
 }
 
+// InitMarkup sets the Markup of all of the lines to their HTML-escaped
+// text, until they are marked up with syntax highlighting.
+// Must be called under LinesMu.Lock.
+func (tb *Buf) InitMarkup() {
+	lns := tb.Rope.Lines(0, tb.NLines)
+	mus := make([][]byte, len(lns))
+	for ln, lr := range lns {
+		mus[ln] = HTMLEscapeRunes(lr)
+	}
+	tb.MarkupMu.Lock()
+	tb.Markup.SetAll(mus)
+	tb.MarkupMu.Unlock()
+}
+
+// linesWindow returns the lines within given numbers of lines before and
+// after given line, materialized from the Rope, along with their HiTags,
+// for functions that take the lines of the whole text, and the index of
+// the first line, which must be subtracted from the line numbers passed
+// to them, and added to those returned.  Must be called under LinesMu
+// and MarkupMu.
+func (tb *Buf) linesWindow(ln, before, after int) ([][]rune, []lex.Line, int) {
+	st := max(ln-before, 0)
+	ed := min(ln+after+1, tb.NLines)
+	lns := tb.Rope.Lines(st, ed)
+	tags := tb.HiTags.Slice(st, ed)
+	if len(tags) < len(lns) { // may be out of sync
+		tags = append(tags, make([]lex.Line, len(lns)-len(tags))...)
+	}
+	return lns, tags, st
+}
+
+// nestingLine returns the text and HiTags of given line, as the
+// textbuf.LineFunc for Nesting.Update.  Must be called under LinesMu
+// and MarkupMu.
+func (tb *Buf) nestingLine(ln int) ([]rune, lex.Line) {
+	return tb.Rope.Line(ln), tb.HiTags.At(ln)
+}
+
 // Strings returns the current text as []string array.
 // If addNewLn is true, each string line has a \n appended at end.
 func (tb *Buf) Strings(addNewLn bool) []string {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
 	str := make([]string, tb.NLines)
-	for i, l := range tb.Lines {
+	for i, l := range tb.Rope.Lines(0, tb.NLines) {
 		str[i] = string(l)
 		if addNewLn {
 			str[i] += "\n"
@@ -998,9 +1121,10 @@ func (tb *Buf) Search(find []byte, ignoreCase, lexItems bool) (int, []textbuf.Ma
 	if lexItems {
 		tb.MarkupMu.RLock()
 		defer tb.MarkupMu.RUnlock()
-		return textbuf.SearchLexItems(tb.Lines, tb.HiTags, find, ignoreCase)
+		lns, tags, _ := tb.linesWindow(0, 0, tb.NLines)
+		return textbuf.SearchLexItems(lns, tags, find, ignoreCase)
 	} else {
-		return textbuf.SearchRuneLines(tb.Lines, find, ignoreCase)
+		return textbuf.SearchRuneLines(tb.Rope.Lines(0, tb.NLines), find, ignoreCase)
 	}
 }
 
@@ -1010,7 +1134,8 @@ func (tb *Buf) Search(find []byte, ignoreCase, lexItems bool) (int, []textbuf.Ma
 func (tb *Buf) SearchRegexp(re *regexp.Regexp) (int, []textbuf.Match) {
 	tb.LinesMu.RLock()
 	defer tb.LinesMu.RUnlock()
-	return textbuf.SearchByteLinesRegexp(tb.LineBytes, re)
+	txt := tb.Rope.Bytes()
+	return textbuf.SearchByteLinesRegexp(bytes.Split(txt, []byte("\n")), re)
 }
 
 // BraceMatch finds the brace, bracket, or parens that is the partner
@@ -1020,7 +1145,16 @@ func (tb *Buf) BraceMatch(r rune, st lex.Pos) (en lex.Pos, found bool) {
 	defer tb.LinesMu.RUnlock()
 	tb.MarkupMu.RLock()
 	defer tb.MarkupMu.RUnlock()
-	return lex.BraceMatch(tb.Lines, tb.HiTags, r, st, BufMaxScopeLines)
+	if st.Ln < 0 || st.Ln >= tb.NLines {
+		return lex.Pos{Ln: -1}, false
+	}
+	lns, tags, wst := tb.linesWindow(st.Ln, BufMaxScopeLines, BufMaxScopeLines)
+	st.Ln -= wst
+	en, found = lex.BraceMatch(lns, tags, r, st, BufMaxScopeLines)
+	if found {
+		en.Ln += wst
+	}
+	return en, found
 }
 
 /////////////////////////////////////////////////////////////////////////////
@@ -1037,13 +1171,12 @@ func (tb *Buf) ValidPos(pos lex.Pos) lex.Pos {
 	if pos.Ln < 0 {
 		pos.Ln = 0
 	}
-	if pos.Ln >= len(tb.Lines) {
-		pos.Ln = len(tb.Lines) - 1
-		pos.Ch = len(tb.Lines[pos.Ln])
+	if pos.Ln >= tb.NLines {
+		pos.Ln = tb.NLines - 1
+		pos.Ch = tb.Rope.LineLen(pos.Ln)
 		return pos
 	}
-	pos.Ln = min(pos.Ln, len(tb.Lines)-1)
-	llen := len(tb.Lines[pos.Ln])
+	llen := tb.Rope.LineLen(pos.Ln)
 	pos.Ch = min(pos.Ch, llen)
 	if pos.Ch < 0 {
 		pos.Ch = 0
@@ -1101,32 +1234,17 @@ func (tb *Buf) DeleteText(st, ed lex.Pos, signal bool) *textbuf.Edit {
 // Sets the timestamp on resulting textbuf.Edit to now.  Must be called under
 // LinesMu.Lock.
 func (tb *Buf) DeleteTextImpl(st, ed lex.Pos) *textbuf.Edit {
-	tbe := tb.RegionImpl(st, ed)
+	tbe := tb.Rope.DeleteText(st, ed)
 	if tbe == nil {
 		return nil
 	}
-	tbe.Delete = true
-	if ed.Ln == st.Ln {
-		tb.Lines[st.Ln] = append(tb.Lines[st.Ln][:st.Ch], tb.Lines[st.Ln][ed.Ch:]...)
+	if tbe.Reg.End.Ln == tbe.Reg.Start.Ln {
 		tb.LinesEdited(tbe)
 	} else {
-		// first get chars on start and end
-		stln := st.Ln + 1
-		cpln := st.Ln
-		tb.Lines[st.Ln] = tb.Lines[st.Ln][:st.Ch]
-		eoedl := len(tb.Lines[ed.Ln][ed.Ch:])
-		var eoed []rune
-		if eoedl > 0 { // save it
-			eoed = make([]rune, eoedl)
-			copy(eoed, tb.Lines[ed.Ln][ed.Ch:])
-		}
-		tb.Lines = append(tb.Lines[:stln], tb.Lines[ed.Ln+1:]...)
-		if eoed != nil {
-			tb.Lines[cpln] = append(tb.Lines[cpln], eoed...)
-		}
-		tb.NLines = len(tb.Lines)
+		tb.NLines = tb.Rope.NumLines()
 		tb.LinesDeleted(tbe)
 	}
+	tb.JournalEdit(tbe)
 	return tbe
 }
 
@@ -1170,16 +1288,13 @@ func (tb *Buf) DeleteTextRectImpl(st, ed lex.Pos) *textbuf.Edit {
 	}
 	tbe.Delete = true
 	for ln := st.Ln; ln <= ed.Ln; ln++ {
-		ls := tb.Lines[ln]
-		if len(ls) > st.Ch {
-			if ed.Ch < len(ls)-1 {
-				tb.Lines[ln] = append(ls[:st.Ch], ls[ed.Ch:]...)
-			} else {
-				tb.Lines[ln] = ls[:st.Ch]
-			}
+		ll := tb.Rope.LineLen(ln)
+		if ll > st.Ch {
+			tb.Rope.DeleteText(lex.Pos{Ln: ln, Ch: st.Ch}, lex.Pos{Ln: ln, Ch: min(ed.Ch, ll)})
 		}
 	}
 	tb.LinesEdited(tbe)
+	tb.JournalEdit(tbe)
 	return tbe
 }
 
@@ -1194,7 +1309,7 @@ func (tb *Buf) InsertText(st lex.Pos, text []byte, signal bool) *textbuf.Edit {
 	st = tb.ValidPos(st)
 	tb.FileModCheck() // will just revert changes if shouldn't have changed
 	tb.SetChanged()
-	if len(tb.Lines) == 0 {
+	if tb.NumLines() == 0 {
 		tb.NewBuf(1)
 	}
 	tb.LinesMu.Lock()
@@ -1213,50 +1328,17 @@ func (tb *Buf) InsertText(st lex.Pos, text []byte, signal bool) *textbuf.Edit {
 // InsertTextImpl does the raw insert of new text at given starting position, returning
 // a new Edit with timestamp of Now.  LinesMu must be locked surrounding this call.
 func (tb *Buf) InsertTextImpl(st lex.Pos, text []byte) *textbuf.Edit {
-	lns := bytes.Split(text, []byte("\n"))
-	sz := len(lns)
-	rs := bytes.Runes(lns[0])
-	rsz := len(rs)
-	ed := st
-	var tbe *textbuf.Edit
-	if sz == 1 {
-		nt := append(tb.Lines[st.Ln], rs...) // first append to end to extend capacity
-		copy(nt[st.Ch+rsz:], nt[st.Ch:])     // move stuff to end
-		copy(nt[st.Ch:], rs)                 // copy into position
-		tb.Lines[st.Ln] = nt
-		ed.Ch += rsz
-		tbe = tb.RegionImpl(st, ed)
+	tbe := tb.Rope.InsertText(st, text)
+	if tbe == nil {
+		return nil
+	}
+	if tbe.Reg.End.Ln == tbe.Reg.Start.Ln {
 		tb.LinesEdited(tbe)
 	} else {
-		if tb.Lines[st.Ln] == nil {
-			tb.Lines[st.Ln] = []rune("")
-		}
-		eostl := len(tb.Lines[st.Ln][st.Ch:]) // end of starting line
-		var eost []rune
-		if eostl > 0 { // save it
-			eost = make([]rune, eostl)
-			copy(eost, tb.Lines[st.Ln][st.Ch:])
-		}
-		tb.Lines[st.Ln] = append(tb.Lines[st.Ln][:st.Ch], rs...)
-		nsz := sz - 1
-		tmp := make([][]rune, nsz)
-		for i := 1; i < sz; i++ {
-			tmp[i-1] = bytes.Runes(lns[i])
-		}
-		stln := st.Ln + 1
-		nt := append(tb.Lines, tmp...) // first append to end to extend capacity
-		copy(nt[stln+nsz:], nt[stln:]) // move stuff to end
-		copy(nt[stln:], tmp)           // copy into position
-		tb.Lines = nt
-		tb.NLines = len(tb.Lines)
-		ed.Ln += nsz
-		ed.Ch = len(tb.Lines[ed.Ln])
-		if eost != nil {
-			tb.Lines[ed.Ln] = append(tb.Lines[ed.Ln], eost...)
-		}
-		tbe = tb.RegionImpl(st, ed)
+		tb.NLines = tb.Rope.NumLines()
 		tb.LinesInserted(tbe)
 	}
+	tb.JournalEdit(tbe)
 	return tbe
 }
 
@@ -1303,36 +1385,30 @@ func (tb *Buf) InsertTextRectImpl(tbe *textbuf.Edit) *textbuf.Edit {
 		return nil
 	}
 	// make sure there are enough lines -- add as needed
-	cln := len(tb.Lines)
+	cln := tb.NLines
 	if cln == 0 {
 		tb.NewBuf(nlns)
 	} else if cln <= ed.Ln {
 		nln := (1 + ed.Ln) - cln
-		tmp := make([][]rune, nln)
-		tb.Lines = append(tb.Lines, tmp...) // first append to end to extend capacity
-		tb.NLines = len(tb.Lines)
+		tb.Rope.InsertText(tb.Rope.EndPos(), bytes.Repeat([]byte("\n"), nln))
+		tb.NLines = tb.Rope.NumLines()
 		ie := &textbuf.Edit{}
 		ie.Reg.Start.Ln = cln - 1
 		ie.Reg.End.Ln = ed.Ln
 		tb.LinesInserted(ie)
 	}
-	nch := (ed.Ch - st.Ch)
 	for i := 0; i < nlns; i++ {
 		ln := st.Ln + i
-		lr := tb.Lines[ln]
-		ir := tbe.Text[i]
-		if len(lr) < st.Ch {
-			lr = append(lr, runes.Repeat([]rune(" "), st.Ch-len(lr))...)
+		if ll := tb.Rope.LineLen(ln); ll < st.Ch {
+			tb.Rope.InsertText(lex.Pos{Ln: ln, Ch: ll}, bytes.Repeat([]byte(" "), st.Ch-ll))
 		}
-		nt := append(lr, ir...)          // first append to end to extend capacity
-		copy(nt[st.Ch+nch:], nt[st.Ch:]) // move stuff to end
-		copy(nt[st.Ch:], ir)             // copy into position
-		tb.Lines[ln] = nt
+		tb.Rope.InsertText(lex.Pos{Ln: ln, Ch: st.Ch}, []byte(string(tbe.Text[i])))
 	}
 	re := tbe.Clone()
 	re.Delete = false
 	re.Reg.TimeNow()
 	tb.LinesEdited(re)
+	tb.JournalEdit(re)
 	return re
 }
 
@@ -1354,51 +1430,7 @@ func (tb *Buf) RegionImpl(st, ed lex.Pos) *textbuf.Edit {
 	if st == ed || ed.IsLess(st) {
 		return nil
 	}
-	if !st.IsLess(ed) {
-		log.Printf("giv.Buf.Region: starting position must be less than ending!: st: %v, ed: %v\n", st, ed)
-		return nil
-	}
-	tbe := &textbuf.Edit{Reg: textbuf.NewRegionPos(st, ed)}
-	if ed.Ln == st.Ln {
-		sz := ed.Ch - st.Ch
-		if sz <= 0 {
-			return nil
-		}
-		tbe.Text = make([][]rune, 1)
-		tbe.Text[0] = make([]rune, sz)
-		copy(tbe.Text[0][:sz], tb.Lines[st.Ln][st.Ch:ed.Ch])
-	} else {
-		// first get chars on start and end
-		if ed.Ln >= len(tb.Lines) {
-			ed.Ln = len(tb.Lines) - 1
-			ed.Ch = len(tb.Lines[ed.Ln])
-		}
-		nlns := (ed.Ln - st.Ln) + 1
-		tbe.Text = make([][]rune, nlns)
-		stln := st.Ln
-		if st.Ch > 0 {
-			ec := len(tb.Lines[st.Ln])
-			sz := ec - st.Ch
-			if sz > 0 {
-				tbe.Text[0] = make([]rune, sz)
-				copy(tbe.Text[0][0:sz], tb.Lines[st.Ln][st.Ch:])
-			}
-			stln++
-		}
-		edln := ed.Ln
-		if ed.Ch < len(tb.Lines[ed.Ln]) {
-			tbe.Text[ed.Ln-st.Ln] = make([]rune, ed.Ch)
-			copy(tbe.Text[ed.Ln-st.Ln], tb.Lines[ed.Ln][:ed.Ch])
-			edln--
-		}
-		for ln := stln; ln <= edln; ln++ {
-			ti := ln - st.Ln
-			sz := len(tb.Lines[ln])
-			tbe.Text[ti] = make([]rune, sz)
-			copy(tbe.Text[ti], tb.Lines[ln])
-		}
-	}
-	return tbe
+	return tb.Rope.Region(st, ed)
 }
 
 // RegionRect returns a textbuf.Edit representation of text between start and end positions
@@ -1435,7 +1467,7 @@ func (tb *Buf) RegionRectImpl(st, ed lex.Pos) *textbuf.Edit {
 	tbe.Text = make([][]rune, nlns)
 	for i := 0; i < nlns; i++ {
 		ln := st.Ln + i
-		lr := tb.Lines[ln]
+		lr := tb.Rope.Line(ln)
 		ll := len(lr)
 		var txt []rune
 		if ll > st.Ch {
@@ -1495,17 +1527,16 @@ func (tb *Buf) SavePosHistory(pos lex.Pos) bool {
 func (tb *Buf) LinesEdited(tbe *textbuf.Edit) {
 	tb.MarkupMu.Lock()
 	st, ed := tbe.Reg.Start.Ln, tbe.Reg.End.Ln
-	for ln := st; ln <= ed; ln++ {
-		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
-		tb.Markup[ln] = HTMLEscapeRunes(tb.Lines[ln])
-	}
 	tb.MarkupLines(st, ed)
+	tb.Nesting.Update(tb.NLines, tb.nestingLine, st, ed, tb.Opts.TabSize)
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
 	tb.MarkupMu.Unlock()
 	tb.StartDelayedReMarkup()
 }
 
 // LinesInserted inserts new lines in Markup corresponding to lines
-// inserted in Lines text.  Locks and unlocks the Markup mutex, and
+// inserted in the text.  Locks and unlocks the Markup mutex, and
 // must be called under lines mutex
 func (tb *Buf) LinesInserted(tbe *textbuf.Edit) {
 	stln := tbe.Reg.Start.Ln + 1
@@ -1513,86 +1544,52 @@ func (tb *Buf) LinesInserted(tbe *textbuf.Edit) {
 
 	tb.MarkupMu.Lock()
 	tb.MarkupEdits = append(tb.MarkupEdits, tbe)
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
 
-	// LineBytes
-	tmplb := make([][]byte, nsz)
-	nlb := append(tb.LineBytes, tmplb...)
-	copy(nlb[stln+nsz:], nlb[stln:])
-	copy(nlb[stln:], tmplb)
-	tb.LineBytes = nlb
-
-	// Markup
-	tmpmu := make([][]byte, nsz)
-	nmu := append(tb.Markup, tmpmu...) // first append to end to extend capacity
-	copy(nmu[stln+nsz:], nmu[stln:])   // move stuff to end
-	copy(nmu[stln:], tmpmu)            // copy into position
-	tb.Markup = nmu
-
-	// Tags
-	tmptg := make([]lex.Line, nsz)
-	ntg := append(tb.Tags, tmptg...)
-	copy(ntg[stln+nsz:], ntg[stln:])
-	copy(ntg[stln:], tmptg)
-	tb.Tags = ntg
-
-	// HiTags
-	tmpht := make([]lex.Line, nsz)
-	nht := append(tb.HiTags, tmpht...)
-	copy(nht[stln+nsz:], nht[stln:])
-	copy(nht[stln:], tmpht)
-	tb.HiTags = nht
-
-	// ByteOffs -- maintain mem updt
-	tmpof := make([]int, nsz)
-	nof := append(tb.ByteOffs, tmpof...)
-	copy(nof[stln+nsz:], nof[stln:])
-	copy(nof[stln:], tmpof)
-	tb.ByteOffs = nof
+	tb.Markup.Insert(stln, make([][]byte, nsz)...)
+	tb.Tags.Insert(stln, make([]lex.Line, nsz)...)
+	tb.HiTags.Insert(stln, make([]lex.Line, nsz)...)
 
 	if tb.Hi.UsingPi() {
 		pfs := tb.PiState.Done()
 		pfs.Src.LinesInserted(stln, nsz)
 	}
+	tb.Nesting.LinesInserted(stln, nsz)
 
 	st, ed := tbe.Reg.Start.Ln, tbe.Reg.End.Ln
-	bo := tb.ByteOffs[st]
-	for ln := st; ln <= ed; ln++ {
-		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
-		tb.Markup[ln] = HTMLEscapeRunes(tb.Lines[ln])
-		tb.ByteOffs[ln] = bo
-		bo += len(tb.LineBytes[ln]) + 1
-	}
 	tb.MarkupLines(st, ed)
+	tb.Nesting.Update(tb.NLines, tb.nestingLine, st, ed, tb.Opts.TabSize)
 	tb.MarkupMu.Unlock()
 	tb.StartDelayedReMarkup()
 }
 
 // LinesDeleted deletes lines in Markup corresponding to lines
-// deleted in Lines text.  Locks and unlocks the Markup mutex, and
+// deleted in the text.  Locks and unlocks the Markup mutex, and
 // must be called under lines mutex.
 func (tb *Buf) LinesDeleted(tbe *textbuf.Edit) {
 	tb.MarkupMu.Lock()
 
 	tb.MarkupEdits = append(tb.MarkupEdits, tbe)
+	tb.foldsStale.Store(true)
+	tb.diagsStale.Store(true)
 
 	stln := tbe.Reg.Start.Ln
 	edln := tbe.Reg.End.Ln
 
-	tb.LineBytes = append(tb.LineBytes[:stln], tb.LineBytes[edln:]...)
-	tb.Markup = append(tb.Markup[:stln], tb.Markup[edln:]...)
-	tb.Tags = append(tb.Tags[:stln], tb.Tags[edln:]...)
-	tb.HiTags = append(tb.HiTags[:stln], tb.HiTags[edln:]...)
-	tb.ByteOffs = append(tb.ByteOffs[:stln], tb.ByteOffs[edln:]...)
+	tb.Markup.Delete(stln, edln)
+	tb.Tags.Delete(stln, edln)
+	tb.HiTags.Delete(stln, edln)
 
 	if tb.Hi.UsingPi() {
 		pfs := tb.PiState.Done()
 		pfs.Src.LinesDeleted(stln, edln)
 	}
+	tb.Nesting.LinesDeleted(stln, edln)
 
 	st := tbe.Reg.Start.Ln
-	tb.LineBytes[st] = []byte(string(tb.Lines[st]))
-	tb.Markup[st] = HTMLEscapeRunes(tb.Lines[st])
 	tb.MarkupLines(st, st)
+	tb.Nesting.Update(tb.NLines, tb.nestingLine, st, st, tb.Opts.TabSize)
 	tb.MarkupMu.Unlock()
 	tb.StartDelayedReMarkup()
 }
@@ -1605,9 +1602,7 @@ func (tb *Buf) MarkupLine(ln int) {
 	tb.LinesMu.Lock()
 	tb.MarkupMu.Lock()
 
-	if ln >= 0 && ln < len(tb.Markup) {
-		tb.LineBytes[ln] = []byte(string(tb.Lines[ln]))
-		tb.Markup[ln] = HTMLEscapeRunes(tb.Lines[ln])
+	if ln >= 0 && ln < tb.Markup.Len() {
 		tb.MarkupLines(ln, ln)
 	}
 	tb.MarkupMu.Unlock()
@@ -1683,7 +1678,7 @@ func (tb *Buf) ReMarkup() {
 // AdjustedTags updates tag positions for edits
 // must be called under MarkupMu lock
 func (tb *Buf) AdjustedTags(ln int) lex.Line {
-	return tb.AdjustedTagsImpl(tb.Tags[ln], ln)
+	return tb.AdjustedTagsImpl(tb.Tags.At(ln), ln)
 }
 
 // AdjustedTagsImpl updates tag positions for edits, for given list of tags
@@ -1726,9 +1721,11 @@ func (tb *Buf) MarkupAllLines(maxLines int) {
 	var txt []byte
 	if maxLines > 0 {
 		tb.LinesMu.RLock()
-		mln := min(maxLines, len(tb.LineBytes))
-		txt = bytes.Join(tb.LineBytes[:mln], []byte("\n"))
-		txt = append(txt, '\n')
+		mln := min(maxLines, tb.NLines)
+		txt = []byte(string(tb.Rope.Runes(0, tb.Rope.Offset(lex.Pos{Ln: mln}))))
+		if mln == tb.NLines {
+			txt = append(txt, '\n')
+		}
 		tb.LinesMu.RUnlock()
 	} else {
 		txt = tb.LinesToBytesCopy()
@@ -1743,7 +1740,7 @@ func (tb *Buf) MarkupAllLines(maxLines int) {
 	tb.LinesMu.Lock()
 	tb.MarkupMu.Lock()
 
-	maxln := min(len(tb.Markup), tb.NLines)
+	maxln := min(tb.Markup.Len(), tb.NLines)
 	if maxLines > 0 {
 		maxln = min(maxln, maxLines)
 	}
@@ -1767,8 +1764,9 @@ func (tb *Buf) MarkupAllLines(maxLines int) {
 		// 	fmt.Printf("error: markup out of sync: %v != %v len(Lexs)\n", maxln, len(pfs.Src.Lexs)-1)
 		// }
 		for ln := 0; ln < maxln; ln++ {
-			tb.HiTags[ln] = pfs.LexLine(ln) // does clone, combines comments too
+			tb.HiTags.Set(ln, pfs.LexLine(ln)) // does clone, combines comments too
 		}
+		tb.foldsStale.Store(true) // new parse tree for the fold regions
 	} else {
 		// first update mtags with any changes since it was generated
 		for _, tbe := range tb.MarkupEdits {
@@ -1792,12 +1790,18 @@ func (tb *Buf) MarkupAllLines(maxLines int) {
 		// }
 		maxln = min(maxln, len(mtags))
 		for ln := 0; ln < maxln; ln++ {
-			tb.HiTags[ln] = mtags[ln] // chroma tags are freshly allocated
+			tb.HiTags.Set(ln, mtags[ln]) // chroma tags are freshly allocated
 		}
 	}
-	for ln := 0; ln < maxln; ln++ {
-		tb.Tags[ln] = tb.AdjustedTags(ln)
-		tb.Markup[ln] = tb.Hi.MarkupLine(tb.Lines[ln], tb.HiTags[ln], tb.Tags[ln])
+	for ln, lr := range tb.Rope.Lines(0, maxln) {
+		tags := tb.AdjustedTags(ln)
+		tb.Tags.Set(ln, tags)
+		tb.Markup.Set(ln, tb.Hi.MarkupLine(lr, tb.HiTags.At(ln), tags))
+	}
+	if tb.Nesting.IsValid(tb.NLines) { // new tags can put brackets in or out of strings
+		tb.Nesting.Update(tb.NLines, tb.nestingLine, 0, maxln-1, tb.Opts.TabSize)
+	} else if maxLines <= 0 {
+		tb.Nesting.Init(tb.Rope.Lines(0, tb.NLines), tb.HiTags.Slice(0, tb.NLines), tb.Opts.TabSize)
 	}
 	tb.MarkupMu.Unlock()
 	tb.LinesMu.Unlock()
@@ -1809,39 +1813,47 @@ func (tb *Buf) MarkupAllLines(maxLines int) {
 // running new tagging -- for special case where tagging is under external
 // control
 func (tb *Buf) MarkupFromTags() {
+	tb.LinesMu.RLock()
 	tb.MarkupMu.Lock()
 	// getting the lock means we are in control of the flag
 	tb.SetFlag(true, BufMarkingUp)
 
-	maxln := min(len(tb.HiTags), tb.NLines)
-	for ln := 0; ln < maxln; ln++ {
-		tb.Markup[ln] = tb.Hi.MarkupLine(tb.Lines[ln], tb.HiTags[ln], nil)
+	maxln := min(tb.HiTags.Len(), tb.NLines)
+	for ln, lr := range tb.Rope.Lines(0, maxln) {
+		tb.Markup.Set(ln, tb.Hi.MarkupLine(lr, tb.HiTags.At(ln), nil))
 	}
 	tb.MarkupMu.Unlock()
+	tb.LinesMu.RUnlock()
 	tb.SetFlag(false, BufMarkingUp)
 	tb.SignalViews(BufMarkUpdt, nil)
 }
 
 // MarkupLines generates markup of given range of lines. end is *inclusive*
-// line.  returns true if all lines were marked up successfully.  This does
-// NOT lock the MarkupMu mutex (done at outer loop)
+// line.  returns true if all lines were marked up successfully -- without
+// highlighting, the lines are just HTML escaped, and it returns false.
+// This does NOT lock the LinesMu or MarkupMu mutexes (done at outer loop)
 func (tb *Buf) MarkupLines(st, ed int) bool {
-	if !tb.Hi.HasHi() || tb.NLines == 0 {
+	if tb.NLines == 0 {
 		return false
 	}
 	if ed >= tb.NLines {
 		ed = tb.NLines - 1
 	}
 
-	allgood := true
+	hasHi := tb.Hi.HasHi()
+	allgood := hasHi
 	for ln := st; ln <= ed; ln++ {
-		ltxt := tb.Lines[ln]
+		ltxt := tb.Rope.Line(ln)
+		if !hasHi {
+			tb.Markup.Set(ln, HTMLEscapeRunes(ltxt))
+			continue
+		}
 		mt, err := tb.Hi.MarkupTagsLine(ln, ltxt)
 		if err == nil {
-			tb.HiTags[ln] = mt
-			tb.Markup[ln] = tb.Hi.MarkupLine(ltxt, mt, tb.AdjustedTags(ln))
+			tb.HiTags.Set(ln, mt)
+			tb.Markup.Set(ln, tb.Hi.MarkupLine(ltxt, mt, tb.AdjustedTags(ln)))
 		} else {
-			tb.Markup[ln] = HTMLEscapeRunes(ltxt)
+			tb.Markup.Set(ln, HTMLEscapeRunes(ltxt))
 			allgood = false
 		}
 	}
@@ -1850,8 +1862,10 @@ func (tb *Buf) MarkupLines(st, ed int) bool {
 	return allgood
 }
 
-// MarkupLinesLock does MarkupLines and gets the mutex lock first
+// MarkupLinesLock does MarkupLines and gets the mutex locks first
 func (tb *Buf) MarkupLinesLock(st, ed int) bool {
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
 	tb.MarkupMu.Lock()
 	defer tb.MarkupMu.Unlock()
 	return tb.MarkupLines(st, ed)
@@ -1880,43 +1894,13 @@ func (tb *Buf) Undo() *textbuf.Edit {
 	stgp := tbe.Group
 	last := tbe
 	for {
-		if tbe.Rect {
-			if tbe.Delete {
-				utbe := tb.InsertTextRectImpl(tbe)
-				utbe.Group = stgp + tbe.Group
-				if tb.Opts.EmacsUndo {
-					tb.Undos.SaveUndo(utbe)
-				}
-				tb.LinesMu.Unlock()
-				tb.SignalMods()
-			} else {
-				utbe := tb.DeleteTextRectImpl(tbe.Reg.Start, tbe.Reg.End)
-				utbe.Group = stgp + tbe.Group
-				if tb.Opts.EmacsUndo {
-					tb.Undos.SaveUndo(utbe)
-				}
-				tb.LinesMu.Unlock()
-				tb.SignalMods()
-			}
-		} else {
-			if tbe.Delete {
-				utbe := tb.InsertTextImpl(tbe.Reg.Start, tbe.ToBytes())
-				utbe.Group = stgp + tbe.Group
-				if tb.Opts.EmacsUndo {
-					tb.Undos.SaveUndo(utbe)
-				}
-				tb.LinesMu.Unlock()
-				tb.SignalViews(BufInsert, utbe)
-			} else {
-				utbe := tb.DeleteTextImpl(tbe.Reg.Start, tbe.Reg.End)
-				utbe.Group = stgp + tbe.Group
-				if tb.Opts.EmacsUndo {
-					tb.Undos.SaveUndo(utbe)
-				}
-				tb.LinesMu.Unlock()
-				tb.SignalViews(BufDelete, utbe)
-			}
+		utbe, sig := tb.UndoEditImpl(tbe)
+		utbe.Group = stgp + tbe.Group
+		if tb.Opts.EmacsUndo {
+			tb.Undos.SaveUndo(utbe)
 		}
+		tb.LinesMu.Unlock()
+		tb.SignalViews(sig, utbe)
 		tb.LinesMu.Lock()
 		tbe = tb.Undos.UndoPopIfGroup(stgp)
 		if tbe == nil {
@@ -1932,6 +1916,42 @@ func (tb *Buf) Undo() *textbuf.Edit {
 	return last
 }
 
+// UndoEditImpl applies the inverse of given edit from the undo stack,
+// returning the resulting edit and the signal to send to views for it.
+// Must be called under LinesMu.Lock.
+func (tb *Buf) UndoEditImpl(tbe *textbuf.Edit) (*textbuf.Edit, BufSignals) {
+	if tbe.Rect {
+		if tbe.Delete {
+			return tb.InsertTextRectImpl(tbe), BufMods
+		}
+		return tb.DeleteTextRectImpl(tbe.Reg.Start, tbe.Reg.End), BufMods
+	}
+	if tbe.Delete {
+		return tb.InsertTextImpl(tbe.Reg.Start, tbe.ToBytes()), BufInsert
+	}
+	return tb.DeleteTextImpl(tbe.Reg.Start, tbe.Reg.End), BufDelete
+}
+
+// RedoEditImpl re-applies given edit from the undo stack,
+// returning the signal to send to views for it.
+// Must be called under LinesMu.Lock.
+func (tb *Buf) RedoEditImpl(tbe *textbuf.Edit) BufSignals {
+	if tbe.Rect {
+		if tbe.Delete {
+			tb.DeleteTextRectImpl(tbe.Reg.Start, tbe.Reg.End)
+		} else {
+			tb.InsertTextRectImpl(tbe)
+		}
+		return BufMods
+	}
+	if tbe.Delete {
+		tb.DeleteTextImpl(tbe.Reg.Start, tbe.Reg.End)
+		return BufDelete
+	}
+	tb.InsertTextImpl(tbe.Reg.Start, tbe.ToBytes())
+	return BufInsert
+}
+
 // EmacsUndoSave is called by View at end of latest set of undo commands.
 // If EmacsUndo mode is active, saves the current UndoStack to the regular Undo stack
 // at the end, and moves undo to the very end -- undo is a constant stream.
@@ -1956,27 +1976,9 @@ func (tb *Buf) Redo() *textbuf.Edit {
 	stgp := tbe.Group
 	last := tbe
 	for {
-		if tbe.Rect {
-			if tbe.Delete {
-				tb.DeleteTextRectImpl(tbe.Reg.Start, tbe.Reg.End)
-				tb.LinesMu.Unlock()
-				tb.SignalMods()
-			} else {
-				tb.InsertTextRectImpl(tbe)
-				tb.LinesMu.Unlock()
-				tb.SignalMods()
-			}
-		} else {
-			if tbe.Delete {
-				tb.DeleteTextImpl(tbe.Reg.Start, tbe.Reg.End)
-				tb.LinesMu.Unlock()
-				tb.SignalViews(BufDelete, tbe)
-			} else {
-				tb.InsertTextImpl(tbe.Reg.Start, tbe.ToBytes())
-				tb.LinesMu.Unlock()
-				tb.SignalViews(BufInsert, tbe)
-			}
-		}
+		sig := tb.RedoEditImpl(tbe)
+		tb.LinesMu.Unlock()
+		tb.SignalViews(sig, tbe)
 		tb.LinesMu.Lock()
 		tbe = tb.Undos.RedoNextIfGroup(stgp)
 		if tbe == nil {
@@ -1988,6 +1990,118 @@ func (tb *Buf) Redo() *textbuf.Edit {
 	return last
 }
 
+// UndoStates returns all of the distinct states of the text recorded
+// in the undo tree, across all branches, in order of creation, starting with
+// the original text.  This is the timeline for "time travel" through the
+// editing history, e.g., with a slider -- see UndoToNode.
+func (tb *Buf) UndoStates() []*textbuf.UndoNode {
+	return tb.Undos.States()
+}
+
+// UndoBranches returns the alternative edits that can be redone
+// from the current state -- see UndoSelectBranch.
+func (tb *Buf) UndoBranches() []*textbuf.UndoNode {
+	return tb.Undos.Branches()
+}
+
+// UndoSelectBranch selects the branch (index into UndoBranches) that
+// will be followed by Redo.  Returns false if index is out of range.
+func (tb *Buf) UndoSelectBranch(idx int) bool {
+	return tb.Undos.SelectBranch(idx)
+}
+
+// UndoToNode moves the text to the state just after given edit node
+// in the undo tree (or the original text for the Root), by undoing back
+// to the closest state shared with the current one, and then redoing
+// down the branch to the node.  It returns false if the text is already
+// in that state, or undo is off.
+func (tb *Buf) UndoToNode(nd *textbuf.UndoNode) bool {
+	if nd == nil || tb.Undos.Off || nd == tb.Undos.CurNode() {
+		return false
+	}
+	autoSave := tb.BatchUpdateStart()
+	defer tb.BatchUpdateEnd(autoSave)
+	tb.LinesMu.Lock()
+	cp := tb.Undos.CommonPos(nd)
+	for tb.Undos.Pos > cp {
+		tbe := tb.Undos.UndoPop()
+		utbe, sig := tb.UndoEditImpl(tbe)
+		tb.LinesMu.Unlock()
+		tb.SignalViews(sig, utbe)
+		tb.LinesMu.Lock()
+	}
+	tpos := tb.Undos.SetPathTo(nd)
+	for tb.Undos.Pos < tpos {
+		tbe := tb.Undos.RedoNext()
+		sig := tb.RedoEditImpl(tbe)
+		tb.LinesMu.Unlock()
+		tb.SignalViews(sig, tbe)
+		tb.LinesMu.Lock()
+	}
+	tb.LinesMu.Unlock()
+	if tb.Undos.Pos == 0 {
+		tb.ClearChanged()
+		tb.AutoSaveDelete()
+	} else {
+		tb.SetChanged()
+	}
+	return true
+}
+
+// UndoToTime moves the text to the state it was in at the given time,
+// using the most recent state in the undo tree at or before that time
+// -- see UndoToNode.
+func (tb *Buf) UndoToTime(t time.Time) bool {
+	return tb.UndoToNode(tb.Undos.StateAtTime(t))
+}
+
+// UndoFilename returns the name of the sidecar file where the
+// undo history is saved when PersistUndo is on
+func (tb *Buf) UndoFilename() string {
+	path, fn := filepath.Split(string(tb.Filename))
+	if fn == "" {
+		fn = "new_file"
+	}
+	return filepath.Join(path, "."+fn+".undo")
+}
+
+// UndoSave saves the undo history to the UndoFilename sidecar file,
+// along with a hash of the current Txt, which must be in sync with
+// the file as saved.
+func (tb *Buf) UndoSave() error {
+	if tb.Filename == "" {
+		return fmt.Errorf("giv.Buf: filename is empty for UndoSave")
+	}
+	ufn := tb.UndoFilename()
+	err := tb.Undos.SaveFile(ufn, textbuf.UndoHash(tb.Txt))
+	if err != nil {
+		log.Printf("giv.Buf: Could not save undo file: %v, error: %v\n", ufn, err)
+	}
+	return err
+}
+
+// UndoOpen restores the undo history from the UndoFilename sidecar file,
+// if it exists and was saved for the same text as the current Txt,
+// as just loaded from the file.  Otherwise the undo history is left empty,
+// and a stale sidecar file is removed.
+func (tb *Buf) UndoOpen() error {
+	ufn := tb.UndoFilename()
+	if _, err := os.Stat(ufn); os.IsNotExist(err) {
+		return nil
+	}
+	err := tb.Undos.OpenFile(ufn, textbuf.UndoHash(tb.Txt))
+	if err != nil {
+		log.Printf("giv.Buf: Not restoring undo history from: %v, error: %v\n", ufn, err)
+		os.Remove(ufn)
+	}
+	return err
+}
+
+// UndoDelete deletes any existing undo history sidecar file
+func (tb *Buf) UndoDelete() {
+	os.Remove(tb.UndoFilename())
+}
+
 // AdjustPos adjusts given text position, which was recorded at given time
 // for any edits that have taken place since that time (using the Undo stack).
 // del determines what to do with positions within a deleted region -- either move
@@ -2015,12 +2129,14 @@ func (tb *Buf) AddTag(ln, st, ed int, tag token.Tokens) {
 	tb.MarkupMu.Lock()
 	tr := lex.NewLex(token.KeyToken{Tok: tag}, st, ed)
 	tr.Time.Now()
-	if len(tb.Tags[ln]) == 0 {
-		tb.Tags[ln] = append(tb.Tags[ln], tr)
+	tags := tb.Tags.At(ln)
+	if len(tags) == 0 {
+		tags = append(tags, tr)
 	} else {
-		tb.Tags[ln] = tb.AdjustedTags(ln) // must re-adjust before adding new ones!
-		tb.Tags[ln].AddSort(tr)
+		tags = tb.AdjustedTags(ln) // must re-adjust before adding new ones!
+		tags.AddSort(tr)
 	}
+	tb.Tags.Set(ln, tags)
 	tb.MarkupMu.Unlock()
 	tb.MarkupLinesLock(ln, ln)
 }
@@ -2037,8 +2153,9 @@ func (tb *Buf) TagAt(pos lex.Pos) (reg lex.Lex, ok bool) {
 	if !tb.IsValidLine(pos.Ln) {
 		return
 	}
-	tb.Tags[pos.Ln] = tb.AdjustedTags(pos.Ln) // re-adjust for current info
-	for _, t := range tb.Tags[pos.Ln] {
+	tags := tb.AdjustedTags(pos.Ln) // re-adjust for current info
+	tb.Tags.Set(pos.Ln, tags)
+	for _, t := range tags {
 		if t.St >= pos.Ch && t.Ed < pos.Ch {
 			return t, true
 		}
@@ -2053,18 +2170,19 @@ func (tb *Buf) RemoveTag(pos lex.Pos, tag token.Tokens) (reg lex.Lex, ok bool) {
 		return
 	}
 	tb.MarkupMu.Lock()
-	tb.Tags[pos.Ln] = tb.AdjustedTags(pos.Ln) // re-adjust for current info
-	for i, t := range tb.Tags[pos.Ln] {
+	tags := tb.AdjustedTags(pos.Ln) // re-adjust for current info
+	for i, t := range tags {
 		if t.ContainsPos(pos.Ch) {
 			if tag > 0 && t.Tok.Tok != tag {
 				continue
 			}
-			tb.Tags[pos.Ln].DeleteIdx(i)
+			tags.DeleteIdx(i)
 			reg = t
 			ok = true
 			break
 		}
 	}
+	tb.Tags.Set(pos.Ln, tags)
 	tb.MarkupMu.Unlock()
 	if ok {
 		tb.MarkupLinesLock(pos.Ln, pos.Ln)
@@ -2080,7 +2198,8 @@ func (tb *Buf) HiTagAtPos(pos lex.Pos) (*lex.Lex, int) {
 	if !tb.IsValidLine(pos.Ln) {
 		return nil, -1
 	}
-	return tb.HiTags[pos.Ln].AtPos(pos.Ch)
+	tags := tb.HiTags.At(pos.Ln)
+	return tags.AtPos(pos.Ch)
 }
 
 // LexString returns the string associated with given Lex (Tag) at given line
@@ -2090,7 +2209,7 @@ func (tb *Buf) LexString(ln int, lx *lex.Lex) string {
 	if !tb.IsValidLine(ln) {
 		return ""
 	}
-	rns := tb.Lines[ln][lx.St:lx.Ed]
+	rns := tb.Rope.Line(ln)[lx.St:lx.Ed]
 	return string(rns)
 }
 
@@ -2104,8 +2223,10 @@ func (tb *Buf) LexObjPathString(ln int, lx *lex.Lex) string {
 	if !tb.IsValidLine(ln) {
 		return ""
 	}
-	stlx := lex.ObjPathAt(tb.HiTags[ln], lx)
-	rns := tb.Lines[ln][stlx.St:lx.Ed]
+	tb.MarkupMu.RLock()
+	stlx := lex.ObjPathAt(tb.HiTags.At(ln), lx)
+	tb.MarkupMu.RUnlock()
+	rns := tb.Rope.Line(ln)[stlx.St:lx.Ed]
 	return string(rns)
 }
 
@@ -2228,7 +2349,7 @@ func (tb *Buf) IndentLine(ln, ind int) *textbuf.Edit {
 	}
 
 	tb.LinesMu.RLock()
-	curind, _ := lex.LineIndent(tb.Lines[ln], tabSz)
+	curind, _ := lex.LineIndent(tb.Rope.Line(ln), tabSz)
 	tb.LinesMu.RUnlock()
 	if ind > curind {
 		return tb.InsertText(lex.Pos{Ln: ln}, indent.Bytes(ichr, ind-curind, tabSz), EditSignal)
@@ -2251,11 +2372,12 @@ func (tb *Buf) AutoIndent(ln int) (tbe *textbuf.Edit, indLev, chPos int) {
 	tb.LinesMu.RLock()
 	tb.MarkupMu.RLock()
 	lp, _ := pi.LangSupport.Props(tb.PiState.Sup)
+	lns, tags, wst := tb.linesWindow(ln, BufMaxScopeLines, 0)
 	var pInd, delInd int
 	if lp != nil && lp.Lang != nil {
-		pInd, delInd, _, _ = lp.Lang.IndentLine(&tb.PiState, tb.Lines, tb.HiTags, ln, tabSz)
+		pInd, delInd, _, _ = lp.Lang.IndentLine(&tb.PiState, lns, tags, ln-wst, tabSz)
 	} else {
-		pInd, delInd, _, _ = lex.BracketIndentLine(tb.Lines, tb.HiTags, ln, tabSz)
+		pInd, delInd, _, _ = lex.BracketIndentLine(lns, tags, ln-wst, tabSz)
 	}
 	tb.MarkupMu.RUnlock()
 	tb.LinesMu.RUnlock()
@@ -2309,7 +2431,7 @@ func (tb *Buf) InComment(pos lex.Pos) bool {
 func (tb *Buf) LineCommented(ln int) bool {
 	tb.MarkupMu.RLock()
 	defer tb.MarkupMu.RUnlock()
-	tags := tb.HiTags[ln]
+	tags := tb.HiTags.At(ln)
 	if len(tags) == 0 {
 		return false
 	}
@@ -2328,7 +2450,7 @@ func (tb *Buf) CommentRegion(st, ed int) {
 
 	ch := 0
 	tb.LinesMu.RLock()
-	ind, _ := lex.LineIndent(tb.Lines[st], tabSz)
+	ind, _ := lex.LineIndent(tb.Rope.Line(st), tabSz)
 	tb.LinesMu.RUnlock()
 
 	if ind > 0 {
@@ -2363,7 +2485,7 @@ func (tb *Buf) CommentRegion(st, ed int) {
 		if doCom {
 			tb.InsertText(lex.Pos{Ln: ln, Ch: ch}, []byte(comst), EditSignal)
 			if comed != "" {
-				lln := len(tb.Lines[ln])
+				lln := tb.LineLen(ln)
 				tb.InsertText(lex.Pos{Ln: ln, Ch: lln}, []byte(comed), EditSignal)
 			}
 		} else {
@@ -2389,7 +2511,7 @@ func (tb *Buf) JoinParaLines(stLn, edLn int) {
 
 	curEd := edLn                      // current end of region being joined == last blank line
 	for ln := edLn; ln >= stLn; ln-- { // reverse order
-		lb := tb.LineBytes[ln]
+		lb := tb.BytesLine(ln)
 		lbt := bytes.TrimSpace(lb)
 		if len(lbt) == 0 || ln == stLn {
 			if ln < curEd-1 {
@@ -2401,10 +2523,15 @@ func (tb *Buf) JoinParaLines(stLn, edLn int) {
 				if curEd == edLn {
 					ep.Ln = curEd
 				}
-				eln := tb.Lines[ep.Ln]
-				ep.Ch = len(eln)
-				tlb := bytes.Join(tb.LineBytes[stp.Ln:ep.Ln+1], []byte(" "))
-				tb.ReplaceText(stp, ep, stp, string(tlb), EditSignal, ReplaceNoMatchCase)
+				tb.LinesMu.RLock()
+				lns := tb.Rope.Lines(stp.Ln, ep.Ln+1)
+				tb.LinesMu.RUnlock()
+				ep.Ch = len(lns[len(lns)-1])
+				strs := make([]string, len(lns))
+				for i, l := range lns {
+					strs[i] = string(l)
+				}
+				tb.ReplaceText(stp, ep, stp, strings.Join(strs, " "), EditSignal, ReplaceNoMatchCase)
 			}
 			curEd = ln
 		}
@@ -2413,11 +2540,56 @@ func (tb *Buf) JoinParaLines(stLn, edLn int) {
 	tb.SignalMods()
 }
 
+// ReflowParaLines hard wraps the paragraphs within the given line regions
+// -- edLn is *inclusive* -- into lines that are at most col columns wide,
+// keeping the indentation and any line comment prefix of the first line
+// of each paragraph (see textbuf.ReflowPara).  Paragraphs are separated by
+// lines that are blank apart from that prefix.  The changes are grouped
+// into one undo step.
+func (tb *Buf) ReflowParaLines(stLn, edLn, col int) {
+	if col <= 0 {
+		return
+	}
+	autoSave := tb.BatchUpdateStart()
+	defer tb.BatchUpdateEnd(autoSave)
+	tb.Undos.Mu.Lock()
+	upos := tb.Undos.Pos
+	tb.Undos.Mu.Unlock()
+
+	comLn := tb.Opts.CommentLn
+	pend := -1                           // last line of current paragraph
+	for ln := edLn; ln >= stLn-1; ln-- { // reverse order
+		if ln >= stLn && !textbuf.IsParaBreak(tb.Line(ln), comLn) {
+			if pend < 0 {
+				pend = ln
+			}
+			continue
+		}
+		if pend < 0 {
+			continue
+		}
+		pst := ln + 1
+		tb.LinesMu.RLock()
+		lns := tb.Rope.Lines(pst, pend+1)
+		tb.LinesMu.RUnlock()
+		nl := textbuf.ReflowPara(lns, col, tb.Opts.TabSize, comLn)
+		if !slices.EqualFunc(lns, nl, slices.Equal[[]rune]) {
+			st := lex.Pos{Ln: pst}
+			ep := lex.Pos{Ln: pend, Ch: len(lns[len(lns)-1])}
+			txt := textbuf.RuneLinesToBytes(nl, false)
+			tb.ReplaceText(st, ep, st, string(txt), EditSignal, ReplaceNoMatchCase)
+		}
+		pend = -1
+	}
+	tb.Undos.SetGroupFrom(upos)
+	tb.SignalMods()
+}
+
 // TabsToSpaces replaces tabs with spaces in given line.
 func (tb *Buf) TabsToSpaces(ln int) {
 	tabSz := tb.Opts.TabSize
 
-	lr := tb.Lines[ln]
+	lr := tb.Line(ln)
 	st := lex.Pos{Ln: ln}
 	ed := lex.Pos{Ln: ln}
 	i := 0
@@ -2433,7 +2605,7 @@ func (tb *Buf) TabsToSpaces(ln int) {
 			ed.Ch = i + 1
 			tb.ReplaceText(st, ed, st, indent.Spaces(1, nspc), EditNoSignal, ReplaceNoMatchCase)
 			i += nspc
-			lr = tb.Lines[ln]
+			lr = tb.Line(ln)
 		} else {
 			i++
 		}
@@ -2457,7 +2629,7 @@ func (tb *Buf) TabsToSpacesRegion(st, ed int) {
 func (tb *Buf) SpacesToTabs(ln int) {
 	tabSz := tb.Opts.TabSize
 
-	lr := tb.Lines[ln]
+	lr := tb.Line(ln)
 	st := lex.Pos{Ln: ln}
 	ed := lex.Pos{Ln: ln}
 	i := 0
@@ -2474,7 +2646,7 @@ func (tb *Buf) SpacesToTabs(ln int) {
 				ed.Ch = i + 1
 				tb.ReplaceText(st, ed, st, "\t", EditNoSignal, ReplaceNoMatchCase)
 				i -= tabSz - 1
-				lr = tb.Lines[ln]
+				lr = tb.Line(ln)
 				nspc = 0
 			} else {
 				i++
@@ -2540,37 +2712,56 @@ func (tb *Buf) DeleteCompleter() {
 	tb.Complete = nil
 }
 
-// CompleteText edits the text using the string chosen from the completion menu
+// CompleteText edits the text using the string chosen from the completion menu.
+// If the view has multiple cursors, the completion is made at each cursor.
 func (tb *Buf) CompleteText(s string) {
 	if s == "" {
 		return
 	}
+	c := tb.Complete.GetCompletion(s)
+	ed := tb.CurView
+	tb.CurView = nil
+	if ed == nil || !ed.HasMultiCursors() {
+		ep := tb.CompleteTextAt(lex.Pos{tb.Complete.SrcLn, tb.Complete.SrcCh}, c)
+		if ed != nil {
+			ed.SetCursorShow(ep)
+		}
+		return
+	}
+	ed.MultiCursorDo(func() {
+		ed.SetCursorShow(tb.CompleteTextAt(ed.CursorPos, c))
+	})
+}
+
+// CompleteTextAt edits the text to insert given completion at given
+// position, replacing the completion Seed if it is just before the
+// position, and returns the position where the cursor should go.
+func (tb *Buf) CompleteTextAt(pos lex.Pos, c complete.Completion) lex.Pos {
 	// give the completer a chance to edit the completion before insert,
 	// also it return a number of runes past the cursor to delete
-	st := lex.Pos{tb.Complete.SrcLn, 0}
-	en := lex.Pos{tb.Complete.SrcLn, tb.LineLen(tb.Complete.SrcLn)}
+	st := lex.Pos{pos.Ln, 0}
+	en := lex.Pos{pos.Ln, tb.LineLen(pos.Ln)}
 	var tbes string
 	tbe := tb.Region(st, en)
 	if tbe != nil {
 		tbes = string(tbe.ToBytes())
 	}
-	c := tb.Complete.GetCompletion(s)
-	pos := lex.Pos{tb.Complete.SrcLn, tb.Complete.SrcCh}
-	ed := tb.Complete.EditFunc(tb.Complete.Context, tbes, tb.Complete.SrcCh, c, tb.Complete.Seed)
+	seed := tb.Complete.Seed
+	ed := tb.Complete.EditFunc(tb.Complete.Context, tbes, pos.Ch, c, seed)
 	if ed.ForwardDelete > 0 {
-		delEn := lex.Pos{tb.Complete.SrcLn, tb.Complete.SrcCh + ed.ForwardDelete}
+		delEn := lex.Pos{pos.Ln, pos.Ch + ed.ForwardDelete}
 		tb.DeleteText(pos, delEn, EditNoSignal)
 	}
 	// now the normal completion insertion
 	st = pos
-	st.Ch -= len(tb.Complete.Seed)
-	tb.ReplaceText(st, pos, st, ed.NewText, EditSignal, ReplaceNoMatchCase)
-	if tb.CurView != nil {
-		ep := st
-		ep.Ch += len(ed.NewText) + ed.CursorAdjust
-		tb.CurView.SetCursorShow(ep)
-		tb.CurView = nil
+	st.Ch -= len([]rune(seed))
+	if lr := tb.Line(pos.Ln); st.Ch < 0 || pos.Ch > len(lr) || !strings.EqualFold(string(lr[st.Ch:pos.Ch]), seed) {
+		st = pos // seed not here, e.g., at another cursor: just insert
 	}
+	tb.ReplaceText(st, pos, st, ed.NewText, EditSignal, ReplaceNoMatchCase)
+	ep := st
+	ep.Ch += len(ed.NewText) + ed.CursorAdjust
+	return ep
 }
 
 // CompleteExtend inserts the extended seed at the current cursor position
@@ -2669,7 +2860,7 @@ func (tb *Buf) SpellCheckLineErrs(ln int) lex.Line {
 	defer tb.LinesMu.RUnlock()
 	tb.MarkupMu.RLock()
 	defer tb.MarkupMu.RUnlock()
-	return spell.CheckLexLine(tb.Lines[ln], tb.HiTags[ln])
+	return spell.CheckLexLine(tb.Rope.Line(ln), tb.HiTags.At(ln))
 }
 
 // SpellCheckLineTag runs spell check on given line, and sets Tags for any
@@ -2685,7 +2876,7 @@ func (tb *Buf) SpellCheckLineTag(ln int) {
 	for _, t := range ser {
 		ntgs.AddSort(t)
 	}
-	tb.Tags[ln] = ntgs
+	tb.Tags.Set(ln, ntgs)
 	tb.MarkupMu.Unlock()
 	tb.MarkupLinesLock(ln, ln)
 	tb.StartDelayedReMarkup()
@@ -2749,6 +2940,53 @@ func (tb *Buf) PatchFromBuf(ob *Buf, diffs textbuf.Diffs, signal bool) bool {
 	return mods
 }
 
+// ApplyPatch applies given patch for one file (e.g., from
+// textbuf.ParseUnified) to this buffer, allowing up to maxFuzz lines of
+// context to be ignored in placing each hunk (2 is typical), with all of
+// the changes saved as one undoable group of edits.  Returns the results
+// for each hunk, including where it was applied, and textbuf.Rejects
+// returns the hunks that could not be applied.
+func (tb *Buf) ApplyPatch(fp *textbuf.FilePatch, maxFuzz int) []textbuf.HunkResult {
+	res := fp.Place(tb.Strings(false), maxFuzz)
+	autoSave := tb.BatchUpdateStart()
+	defer tb.BatchUpdateEnd(autoSave)
+	tb.Undos.Mu.Lock()
+	upos := tb.Undos.Pos
+	tb.Undos.Mu.Unlock()
+
+	for i := len(res) - 1; i >= 0; i-- { // go in reverse so changes are valid!
+		hr := res[i]
+		if !hr.Applied {
+			continue
+		}
+		nlines := tb.NumLines()
+		txt := strings.Join(hr.New, "\n")
+		st, ed := lex.Pos{Ln: hr.OldSt}, lex.Pos{Ln: hr.OldEd}
+		switch {
+		case hr.OldEd < nlines:
+			if len(hr.New) > 0 {
+				txt += "\n"
+			}
+		case hr.OldSt > 0: // through the end: delete from the end of the line before
+			st = lex.Pos{Ln: hr.OldSt - 1, Ch: tb.LineLen(hr.OldSt - 1)}
+			ed = tb.EndPos()
+			if len(hr.New) > 0 {
+				txt = "\n" + txt
+			}
+		default:
+			ed = tb.EndPos()
+		}
+		if st != ed {
+			tb.DeleteText(st, ed, EditSignal)
+		}
+		if txt != "" {
+			tb.InsertText(st, []byte(txt), EditSignal)
+		}
+	}
+	tb.Undos.SetGroupFrom(upos)
+	return res
+}
+
 ////////////////////////////////////////////////////////////////////////////
 //   BufList, Bufs
 
@@ -0,0 +1,188 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+)
+
+func TestBufUndoSidecar(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "file.txt")
+	tb := NewBuf()
+	tb.Filename = gi.FileName(fn)
+	tb.SetText([]byte("one\n"))
+	tb.InsertText(lex.Pos{Ln: 0, Ch: 3}, []byte(" two"), false)
+	tb.LinesToBytes()
+	if err := tb.UndoSave(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopened with the same text: the history is restored
+	nb := NewBuf()
+	nb.Filename = tb.Filename
+	nb.SetText(slices.Clone(tb.Txt))
+	if err := nb.UndoOpen(); err != nil {
+		t.Fatal(err)
+	}
+	if nb.Undos.Pos != 1 || len(nb.Undos.Stack) != 1 {
+		t.Fatalf("restored undo Pos, len(Stack) = %d, %d, want 1, 1", nb.Undos.Pos, len(nb.Undos.Stack))
+	}
+	nb.Undo()
+	if txt := string(nb.Text()); txt != "one\n" {
+		t.Errorf("text after undo = %q, want %q", txt, "one\n")
+	}
+
+	// reopened after the file was changed by something else: the
+	// history is not restored, and the stale sidecar is removed
+	mb := NewBuf()
+	mb.Filename = tb.Filename
+	mb.SetText([]byte("one two three\n"))
+	if err := mb.UndoOpen(); !errors.Is(err, textbuf.ErrUndoHashMismatch) {
+		t.Errorf("UndoOpen of changed file: error = %v, want ErrUndoHashMismatch", err)
+	}
+	if len(mb.Undos.Stack) != 0 {
+		t.Errorf("UndoOpen of changed file restored %d edits", len(mb.Undos.Stack))
+	}
+	if _, err := os.Stat(tb.UndoFilename()); !os.IsNotExist(err) {
+		t.Errorf("stale undo file was not removed: %v", err)
+	}
+}
+
+func TestBufUndoToNode(t *testing.T) {
+	tb := NewBuf()
+	tb.SetText([]byte("x\n"))
+	ins := func(s string) {
+		tb.Undos.NewGroup()
+		tb.InsertText(lex.Pos{Ln: 0, Ch: tb.LineLen(0)}, []byte(s), false)
+	}
+	ins("a")
+	ins("b")
+	tb.Undo()
+	ins("c") // new branch
+	text := func() string {
+		return string(tb.Text())
+	}
+	sts := tb.UndoStates()
+	if len(sts) != 4 {
+		t.Fatalf("%d UndoStates, want 4", len(sts))
+	}
+	if tb.UndoToNode(sts[3]) {
+		t.Errorf("UndoToNode of the current state returned true")
+	}
+	for _, tt := range []struct {
+		st   int
+		want string
+	}{{2, "xab\n"}, {0, "x\n"}, {1, "xa\n"}, {3, "xac\n"}} {
+		if !tb.UndoToNode(sts[tt.st]) {
+			t.Errorf("UndoToNode(%d) returned false", tt.st)
+		}
+		if txt := text(); txt != tt.want {
+			t.Errorf("text after UndoToNode(%d) = %q, want %q", tt.st, txt, tt.want)
+		}
+	}
+	tb.UndoToNode(sts[0])
+	tb.UndoToTime(time.Now())
+	if txt := text(); txt != "xac\n" {
+		t.Errorf("text after UndoToTime(now) = %q, want %q", txt, "xac\n")
+	}
+	if brs := tb.UndoBranches(); len(brs) != 0 {
+		t.Errorf("%d UndoBranches at the tip, want 0", len(brs))
+	}
+}
+
+func TestBufRope(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tb := NewBuf()
+	tb.SetText([]byte("one\ntwo\n\nfour é\n"))
+	for i := 0; i < 500; i++ {
+		pos := lex.Pos{Ln: rnd.Intn(tb.NumLines())}
+		pos.Ch = rnd.Intn(tb.LineLen(pos.Ln) + 1)
+		switch rnd.Intn(4) {
+		case 0:
+			tb.InsertText(pos, []byte(fmt.Sprintf("ins%d\nαβ", i)), EditNoSignal)
+		case 1:
+			ed := tb.ValidPos(lex.Pos{Ln: pos.Ln + rnd.Intn(3), Ch: rnd.Intn(6)})
+			if pos.IsLess(ed) {
+				tb.DeleteText(pos, ed, EditNoSignal)
+			}
+		case 2:
+			if tbe := tb.RegionRect(lex.Pos{Ln: pos.Ln}, lex.Pos{Ln: pos.Ln, Ch: 2}); tbe != nil {
+				tb.InsertTextRect(tbe, EditNoSignal)
+			}
+		default:
+			tb.InsertText(pos, []byte(fmt.Sprintf("x%d", i)), EditNoSignal)
+		}
+		lns := strings.Split(string(tb.Rope.Bytes()), "\n")
+		if len(lns) != tb.NLines || tb.Markup.Len() != tb.NLines || tb.Tags.Len() != tb.NLines || tb.HiTags.Len() != tb.NLines {
+			t.Fatalf("step %d: %d rope lines, NLines %d, %d Markup, %d Tags, %d HiTags", i, len(lns), tb.NLines, tb.Markup.Len(), tb.Tags.Len(), tb.HiTags.Len())
+		}
+		for ln, l := range lns {
+			mu := string(HTMLEscapeRunes([]rune(l)))
+			if string(tb.Line(ln)) != l || string(tb.BytesLine(ln)) != l || string(tb.Markup.At(ln)) != mu {
+				t.Fatalf("step %d: line %d: Line %q, BytesLine %q, Markup %q, want %q", i, ln, string(tb.Line(ln)), tb.BytesLine(ln), tb.Markup.At(ln), l)
+			}
+		}
+	}
+	txt := slices.Clone(tb.Text())
+	for tb.Undos.Pos > 0 {
+		tb.Undo()
+	}
+	if got := string(tb.Text()); got != "one\ntwo\n\nfour é\n" {
+		t.Errorf("text after undoing all edits = %q", got)
+	}
+	for tb.Redo() != nil {
+	}
+	if got := tb.Text(); !bytes.Equal(got, txt) {
+		t.Errorf("text after redoing all edits = %q, want %q", got, txt)
+	}
+}
+
+// benchBuf returns a Buf with a large text of given number of lines
+func benchBuf(nlines int) *Buf {
+	var b bytes.Buffer
+	for i := 0; i < nlines; i++ {
+		fmt.Fprintf(&b, "%08d: the quick brown fox jumps over the lazy dog, again and again\n", i)
+	}
+	tb := NewBuf()
+	tb.SetText(b.Bytes())
+	tb.Undos.Off = true
+	return tb
+}
+
+func BenchmarkBufInsertDelete(b *testing.B) {
+	tb := benchBuf(500000)
+	rnd := rand.New(rand.NewSource(1))
+	ins := []byte("inserted line one\ninserted line two\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ln := rnd.Intn(tb.NumLines() - 2)
+		tbe := tb.InsertText(lex.Pos{Ln: ln}, ins, EditNoSignal)
+		tb.DeleteText(tbe.Reg.Start, tbe.Reg.End, EditNoSignal)
+	}
+}
+
+func BenchmarkBufInsertChar(b *testing.B) {
+	tb := benchBuf(500000)
+	rnd := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pos := lex.Pos{Ln: rnd.Intn(tb.NumLines()), Ch: 4}
+		tb.InsertText(pos, []byte("x"), EditNoSignal)
+		tb.DeleteText(pos, lex.Pos{Ln: pos.Ln, Ch: 5}, EditNoSignal)
+	}
+}
@@ -0,0 +1,484 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image/color"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/goosi/events"
+	"goki.dev/icons"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/spell"
+)
+
+// DiagnosticColors are the colors of the squiggly underlines
+// for the diagnostics of each severity
+var DiagnosticColors = [textbuf.SeveritiesN]color.RGBA{colors.Red, colors.Orange, colors.Dodgerblue, colors.Gray}
+
+// DiagnosticIcons are the line icons for the diagnostics of each severity,
+// shown on the line where the diagnostic starts, for the most severe one there
+var DiagnosticIcons = [textbuf.SeveritiesN]icons.Icon{icons.Error, icons.Warning, icons.Info, icons.None}
+
+var (
+	// diagBufs are the buffers that currently have diagnostics,
+	// which are listed in the DiagnosticsViews
+	diagBufs []*Buf
+
+	// diagViews are the DiagnosticsViews to update when diagnostics change
+	diagViews []*DiagnosticsView
+
+	// diagMu protects diagBufs and diagViews
+	diagMu sync.Mutex
+)
+
+// DiagnosticsBufs returns the buffers that currently have diagnostics,
+// in the order in which they first got them
+func DiagnosticsBufs() []*Buf {
+	diagMu.Lock()
+	defer diagMu.Unlock()
+	return slices.Clone(diagBufs)
+}
+
+///////////////////////////////////////////////////////////////////////////
+//  Buf
+
+// SetDiagnostics sets the diagnostics from the given source, e.g., "go vet",
+// replacing any previous ones from it, and leaving those of other sources:
+// nil removes them.  The regions of the diagnostics must be for the current
+// text, and are adjusted for any later edits, via the Undo stack, so they
+// should be made with textbuf.NewRegion to have the current time stamp.
+// It can be called from any goroutine.
+func (tb *Buf) SetDiagnostics(source string, diags []textbuf.Diagnostic) {
+	tb.Diags.Set(source, diags)
+	tb.DiagnosticsUpdated()
+}
+
+// ClearDiagnostics removes the diagnostics from all sources
+func (tb *Buf) ClearDiagnostics() {
+	tb.Diags.Reset()
+	tb.DiagnosticsUpdated()
+}
+
+// DiagnosticsUpdated updates the line icons, the views, and the
+// DiagnosticsViews after the diagnostics have changed.  It can be called
+// from any goroutine: the updates of the views are done in their event loop.
+func (tb *Buf) DiagnosticsUpdated() {
+	tb.diagsStale.Store(true)
+	has := tb.Diags.Len() > 0
+	diagMu.Lock()
+	idx := slices.Index(diagBufs, tb)
+	switch {
+	case has && idx < 0:
+		diagBufs = append(diagBufs, tb)
+	case !has && idx >= 0:
+		diagBufs = slices.Delete(diagBufs, idx, idx+1)
+	}
+	dvs := slices.Clone(diagViews)
+	diagMu.Unlock()
+
+	tb.RunOnViews(tb.updateDiagIcons)
+	for _, dv := range dvs {
+		if dv.Sc != nil {
+			dv.Sc.RunOnEventLoop(dv.UpdateItems)
+		} else {
+			dv.UpdateItems()
+		}
+	}
+}
+
+// updateDiagIcons sets the line icons for the most severe diagnostic
+// starting on each line, and signals the views to render them
+func (tb *Buf) updateDiagIcons() {
+	diags := tb.Diagnostics()
+	tb.diagsMu.Lock()
+	for _, ln := range tb.diagIconLns {
+		tb.DeleteLineIcon(ln)
+	}
+	tb.diagIconLns = tb.diagIconLns[:0]
+	worst := map[int]textbuf.Severities{}
+	for _, d := range diags {
+		ln := d.Reg.Start.Ln
+		if sev, ok := worst[ln]; !ok || d.Severity < sev {
+			worst[ln] = d.Severity
+		}
+	}
+	for ln, sev := range worst {
+		if int(sev) >= len(DiagnosticIcons) || DiagnosticIcons[sev] == icons.None {
+			continue
+		}
+		tb.SetLineIcon(ln, DiagnosticIcons[sev])
+		tb.diagIconLns = append(tb.diagIconLns, ln)
+	}
+	tb.diagsMu.Unlock()
+	tb.SignalViews(BufMarkUpdt, nil)
+}
+
+// Diagnostics returns the diagnostics from all sources sorted by position,
+// with their regions adjusted for the edits since they were set, omitting
+// those whose text has been deleted.  The list is only recomputed after
+// the text has been edited or the diagnostics have changed, and otherwise
+// a copy of the previous list is returned.
+func (tb *Buf) Diagnostics() []textbuf.Diagnostic {
+	tb.diagsMu.Lock()
+	defer tb.diagsMu.Unlock()
+	if tb.diagsStale.Swap(false) {
+		tb.diagsCache = tb.Diags.All(tb.AdjustReg)
+	}
+	return slices.Clone(tb.diagsCache)
+}
+
+// DiagnosticsAt returns the diagnostics whose region contains the given
+// position, or starts at it if the region is empty
+func (tb *Buf) DiagnosticsAt(pos lex.Pos) []textbuf.Diagnostic {
+	var ds []textbuf.Diagnostic
+	for _, d := range tb.Diagnostics() {
+		if pos.IsLess(d.Reg.Start) {
+			break
+		}
+		if pos.IsLess(d.Reg.End) || pos == d.Reg.Start {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// NextDiagnostic returns the first diagnostic starting after the given
+// position, wrapping around to the first one, and false if there are none
+func (tb *Buf) NextDiagnostic(pos lex.Pos) (textbuf.Diagnostic, bool) {
+	diags := tb.Diagnostics()
+	if len(diags) == 0 {
+		return textbuf.Diagnostic{}, false
+	}
+	for _, d := range diags {
+		if pos.IsLess(d.Reg.Start) {
+			return d, true
+		}
+	}
+	return diags[0], true
+}
+
+// PrevDiagnostic returns the last diagnostic starting before the given
+// position, wrapping around to the last one, and false if there are none
+func (tb *Buf) PrevDiagnostic(pos lex.Pos) (textbuf.Diagnostic, bool) {
+	diags := tb.Diagnostics()
+	if len(diags) == 0 {
+		return textbuf.Diagnostic{}, false
+	}
+	for i := len(diags) - 1; i >= 0; i-- {
+		if diags[i].Reg.Start.IsLess(pos) {
+			return diags[i], true
+		}
+	}
+	return diags[len(diags)-1], true
+}
+
+// ApplyQuickFix applies the edits of the given fix of a diagnostic,
+// as one undo group, with their regions adjusted for the edits since
+// the diagnostic was set
+func (tb *Buf) ApplyQuickFix(fix textbuf.QuickFix) {
+	if len(fix.Edits) == 0 {
+		return
+	}
+	edits := slices.Clone(fix.Edits)
+	for i := range edits {
+		edits[i].Reg = tb.AdjustReg(edits[i].Reg)
+	}
+	// from the end, so the earlier regions stay valid
+	slices.SortFunc(edits, func(a, b textbuf.FixEdit) int {
+		switch {
+		case a.Reg.Start.IsLess(b.Reg.Start):
+			return 1
+		case b.Reg.Start.IsLess(a.Reg.Start):
+			return -1
+		}
+		return 0
+	})
+	autoSave := tb.BatchUpdateStart()
+	tb.Undos.Mu.Lock()
+	upos := tb.Undos.Pos
+	tb.Undos.Mu.Unlock()
+	for _, fe := range edits {
+		if fe.Reg == textbuf.RegionNil {
+			continue
+		}
+		tb.ReplaceText(fe.Reg.Start, fe.Reg.End, fe.Reg.Start, fe.Text, EditSignal, ReplaceNoMatchCase)
+	}
+	tb.Undos.SetGroupFrom(upos)
+	tb.BatchUpdateEnd(autoSave)
+}
+
+// SetToolDiagnostics sets the diagnostics parsed from the output of a tool,
+// all of which must be for the file of this buffer, as those of the given
+// source, converting their 1-based lines and byte columns to positions in
+// the text.  Each diagnostic covers the word at its column, or the whole
+// line if there is no column.
+func (tb *Buf) SetToolDiagnostics(source string, tds []textbuf.ToolDiag) {
+	var diags []textbuf.Diagnostic
+	for _, td := range tds {
+		ln := td.Line - 1
+		if !tb.IsValidLine(ln) {
+			continue
+		}
+		bl := tb.BytesLine(ln)
+		rl := tb.Line(ln)
+		var st, ed int
+		if td.Col > 0 {
+			st = utf8.RuneCount(bl[:min(td.Col-1, len(bl))])
+			ed = st
+			for ed < len(rl) && (unicode.IsLetter(rl[ed]) || unicode.IsDigit(rl[ed]) || rl[ed] == '_') {
+				ed++
+			}
+			if ed == st && ed < len(rl) {
+				ed++
+			}
+		} else {
+			for st < len(rl) && unicode.IsSpace(rl[st]) {
+				st++
+			}
+			ed = len(rl)
+		}
+		diags = append(diags, textbuf.Diagnostic{Reg: textbuf.NewRegion(ln, st, ln, ed), Severity: td.Severity, Message: td.Message})
+	}
+	tb.SetDiagnostics(source, diags)
+}
+
+// SetToolOutput parses the given output of the tool with given name, e.g.,
+// "go vet", using textbuf.ParseToolOutput, and sets the diagnostics for
+// each of the given buffers from it, with the tool name as the source --
+// those with none have any previous ones from the tool removed.  The
+// file names in the output are relative to the given directory that the
+// tool was run in, unless they are absolute.
+func SetToolOutput(tool, dir string, out []byte, bufs []*Buf) {
+	byFile := map[string][]textbuf.ToolDiag{}
+	for _, td := range textbuf.ParseToolOutput(tool, out) {
+		fn := td.File
+		if !filepath.IsAbs(fn) {
+			fn = filepath.Join(dir, fn)
+		}
+		fn, _ = filepath.Abs(fn)
+		byFile[fn] = append(byFile[fn], td)
+	}
+	for _, tb := range bufs {
+		if tb.Filename == "" {
+			continue
+		}
+		fn, _ := filepath.Abs(string(tb.Filename))
+		tb.SetToolDiagnostics(tool, byFile[fn])
+	}
+}
+
+// SetSpellDiagnostics runs the spell checker over the whole text and sets
+// a diagnostic of hint severity for each misspelled word, with its suggested
+// corrections as quick fixes, as those of the "spell" source
+func (tb *Buf) SetSpellDiagnostics() {
+	var diags []textbuf.Diagnostic
+	nln := tb.NumLines()
+	for ln := 0; ln < nln; ln++ {
+		for _, lx := range tb.SpellCheckLineErrs(ln) {
+			reg := textbuf.NewRegion(ln, lx.St, ln, lx.Ed)
+			word := string(tb.Line(ln)[lx.St:lx.Ed])
+			d := textbuf.Diagnostic{Reg: reg, Severity: textbuf.SeverityHint, Message: "unknown word: " + word}
+			sugs, _ := spell.CheckWord(word)
+			for _, s := range sugs {
+				d.Fixes = append(d.Fixes, textbuf.QuickFix{Title: s, Edits: []textbuf.FixEdit{{Reg: reg, Text: s}}})
+			}
+			diags = append(diags, d)
+		}
+	}
+	tb.SetDiagnostics("spell", diags)
+}
+
+///////////////////////////////////////////////////////////////////////////
+//  Editor
+
+// NextDiagnostic moves the cursor to the next diagnostic after it,
+// wrapping around, and shows its message -- returns false if there are none
+func (ed *Editor) NextDiagnostic() bool {
+	if ed.Buf == nil {
+		return false
+	}
+	d, ok := ed.Buf.NextDiagnostic(ed.CursorPos)
+	if ok {
+		ed.GoToDiagnostic(d)
+	}
+	return ok
+}
+
+// PrevDiagnostic moves the cursor to the previous diagnostic before it,
+// wrapping around, and shows its message -- returns false if there are none
+func (ed *Editor) PrevDiagnostic() bool {
+	if ed.Buf == nil {
+		return false
+	}
+	d, ok := ed.Buf.PrevDiagnostic(ed.CursorPos)
+	if ok {
+		ed.GoToDiagnostic(d)
+	}
+	return ok
+}
+
+// GoToDiagnostic moves the cursor to the start of the given diagnostic,
+// highlighting its region and showing its message in a tooltip
+func (ed *Editor) GoToDiagnostic(d textbuf.Diagnostic) {
+	ed.SavePosHistory(ed.CursorPos)
+	ed.HighlightRegion(d.Reg)
+	ed.SetCursorShow(d.Reg.Start)
+	pos := ed.CharStartPos(d.Reg.Start).ToPoint() // physical location
+	gi.NewTooltipText(ed, DiagnosticsText([]textbuf.Diagnostic{d}), pos).Run()
+}
+
+// DiagnosticsText returns the text for showing the given diagnostics,
+// one per paragraph, with their severity and source
+func DiagnosticsText(diags []textbuf.Diagnostic) string {
+	var sb strings.Builder
+	for i, d := range diags {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(d.Severity.String() + ": " + d.Message)
+		if d.Source != "" {
+			sb.WriteString(" (" + d.Source + ")")
+		}
+	}
+	return sb.String()
+}
+
+// HandleHover shows the messages of the diagnostics under the mouse,
+// followed by the hover information from the language server if there
+// is one, in a tooltip, on a long hover.
+func (ed *Editor) HandleHover() {
+	ed.On(events.LongHoverStart, func(e events.Event) {
+		if ed.Buf == nil {
+			return
+		}
+		pt := ed.PointToRelPos(e.LocalPos())
+		pos := ed.PixelToCursor(pt)
+		txt := DiagnosticsText(ed.Buf.DiagnosticsAt(pos))
+		if lt := ed.LSPHoverText(pos); lt != "" {
+			if txt != "" {
+				txt += "\n\n"
+			}
+			txt += lt
+		}
+		if txt == "" {
+			return
+		}
+		e.SetHandled()
+		gi.NewTooltipText(ed, txt, e.Pos()).Run()
+	})
+}
+
+// DiagnosticsContextMenu adds the quick fixes of the diagnostics at the
+// cursor, if any, to the given context menu
+func (ed *Editor) DiagnosticsContextMenu(m *gi.Scene) {
+	if ed.Buf == nil || ed.IsReadOnly() {
+		return
+	}
+	first := true
+	for _, d := range ed.Buf.DiagnosticsAt(ed.CursorPos) {
+		for _, fix := range d.Fixes {
+			if first {
+				gi.NewSeparator(m)
+				first = false
+			}
+			fix := fix
+			gi.NewButton(m).SetText(fix.Title).SetIcon(icons.Edit).
+				OnClick(func(e events.Event) {
+					ed.Buf.ApplyQuickFix(fix)
+				})
+		}
+	}
+}
+
+// RenderDiagnostics renders a squiggly underline in the color of its
+// severity under the text of each of the diagnostics in the given range
+// of lines -- always called within context of outer RenderAllLines
+func (ed *Editor) RenderDiagnostics(stln, edln int) {
+	if ed.Buf == nil || ed.Buf.Diags.Len() == 0 {
+		return
+	}
+	rs := &ed.Sc.RenderState
+	pc := &rs.Paint
+	pc.StrokeStyle.Width.Dots = mat32.Max(1, mat32.Round(ed.LineHeight/16))
+	for _, d := range ed.Buf.Diagnostics() {
+		st, end := d.Reg.Start, d.Reg.End
+		if st.Ln > edln || end.Ln < stln || int(d.Severity) >= len(DiagnosticColors) {
+			continue
+		}
+		pc.StrokeStyle.SetColor(DiagnosticColors[d.Severity])
+		for ln := max(st.Ln, stln); ln <= min(end.Ln, edln); ln++ {
+			if ln >= ed.NLines || ed.IsLineHidden(ln) {
+				continue
+			}
+			sc, ec := 0, ed.Buf.LineLen(ln)
+			if ln == st.Ln {
+				sc = st.Ch
+			}
+			if ln == end.Ln {
+				ec = end.Ch
+			}
+			if ec <= sc && ln != st.Ln {
+				continue
+			}
+			ed.renderSquiggleLine(ln, sc, ec)
+		}
+		pc.Stroke(rs)
+	}
+}
+
+// renderSquiggleLine adds the squiggle for given range of characters in
+// given line to the path, with a separate one on each of its wrapped
+// lines, and a short one for an empty range
+func (ed *Editor) renderSquiggleLine(ln, sc, ec int) {
+	st := ed.CharStartPos(lex.Pos{Ln: ln, Ch: sc})
+	if ec <= sc {
+		ed.renderSquiggle(st.X, st.X+0.5*ed.LineHeight, st.Y+ed.LineHeight)
+		return
+	}
+	for ch := sc + 1; ch <= ec; ch++ {
+		var p mat32.Vec2
+		if ch < ec {
+			p = ed.CharStartPos(lex.Pos{Ln: ln, Ch: ch})
+		}
+		if ch == ec || p.Y != st.Y { // end, or wrapped onto the next line
+			ep := ed.CharEndPos(lex.Pos{Ln: ln, Ch: ch - 1})
+			ed.renderSquiggle(st.X, ep.X, st.Y+ed.LineHeight)
+			st = p
+		}
+	}
+}
+
+// renderSquiggle adds a zig-zag line from x0 to x1 just above given
+// bottom of a line to the path
+func (ed *Editor) renderSquiggle(x0, x1, y float32) {
+	rs := &ed.Sc.RenderState
+	pc := &rs.Paint
+	h := mat32.Max(1.5, ed.LineHeight/10)
+	y -= 1
+	pc.MoveTo(rs, x0, y)
+	up := true
+	for x := x0 + h; ; x += h {
+		x = mat32.Min(x, x1)
+		if up {
+			pc.LineTo(rs, x, y-h)
+		} else {
+			pc.LineTo(rs, x, y)
+		}
+		up = !up
+		if x >= x1 {
+			break
+		}
+	}
+}
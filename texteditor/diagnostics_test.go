@@ -0,0 +1,77 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"testing"
+
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+)
+
+func TestDiagnosticsCache(t *testing.T) {
+	tb := NewBuf()
+	tb.SetText([]byte("abc def\nghi"))
+	// hints have no line icons
+	tb.SetDiagnostics("test", []textbuf.Diagnostic{
+		{Reg: textbuf.NewRegion(1, 0, 1, 3), Severity: textbuf.SeverityHint, Message: "ghi"},
+		{Reg: textbuf.NewRegion(0, 4, 0, 7), Severity: textbuf.SeverityHint, Message: "def"},
+	})
+	regs := func() []textbuf.Region {
+		var rs []textbuf.Region
+		for _, d := range tb.Diagnostics() {
+			rs = append(rs, d.Reg)
+		}
+		return rs
+	}
+	same := func(got []textbuf.Region, want ...textbuf.Region) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i].Start != want[i].Start || got[i].End != want[i].End {
+				return false
+			}
+		}
+		return true
+	}
+
+	if rs := regs(); !same(rs, textbuf.NewRegion(0, 4, 0, 7), textbuf.NewRegion(1, 0, 1, 3)) {
+		t.Fatalf("Diagnostics regions = %v", rs)
+	}
+	if ds := tb.Diagnostics(); ds[0].Source != "test" {
+		t.Errorf("Diagnostics source = %q, want test", ds[0].Source)
+	}
+	if tb.diagsStale.Load() {
+		t.Errorf("diagnostics are stale without any edits")
+	}
+	ds := tb.Diagnostics()
+	ds[0].Message = "changed" // the cache is not affected by changes to the result
+	if ds := tb.Diagnostics(); ds[0].Message != "def" {
+		t.Errorf("cached Diagnostics = %v", ds)
+	}
+
+	tb.InsertText(lex.Pos{}, []byte("x\n"), EditSignal)
+	if !tb.diagsStale.Load() {
+		t.Errorf("diagnostics are not stale after an edit")
+	}
+	if rs := regs(); !same(rs, textbuf.NewRegion(1, 4, 1, 7), textbuf.NewRegion(2, 0, 2, 3)) {
+		t.Errorf("Diagnostics regions after insert = %v", rs)
+	}
+
+	// the diagnostic whose text is deleted is dropped
+	tb.DeleteText(lex.Pos{Ln: 1, Ch: 3}, lex.Pos{Ln: 1, Ch: 7}, EditSignal)
+	if rs := regs(); !same(rs, textbuf.NewRegion(2, 0, 2, 3)) {
+		t.Errorf("Diagnostics regions after delete = %v", rs)
+	}
+	if n := len(tb.DiagnosticsAt(lex.Pos{Ln: 2, Ch: 1})); n != 1 {
+		t.Errorf("DiagnosticsAt 2:1 = %d diagnostics, want 1", n)
+	}
+
+	tb.ClearDiagnostics()
+	if ds := tb.Diagnostics(); len(ds) != 0 {
+		t.Errorf("Diagnostics after ClearDiagnostics = %v", ds)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/keyfun"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/states"
+	"goki.dev/girl/styles"
+	"goki.dev/girl/units"
+	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
+	"goki.dev/icons"
+	"goki.dev/ki/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// DiagnosticItem is one diagnostic listed in a DiagnosticsView,
+// with the buffer it is for
+type DiagnosticItem struct {
+	textbuf.Diagnostic
+
+	// the buffer the diagnostic is for
+	Buf *Buf
+}
+
+// DiagnosticsView is a panel listing the diagnostics of all of the buffers
+// that have any (see DiagnosticsBufs), one per line, as file:line:col:
+// severity: message (source), which is updated whenever they change.
+// Clicking on a line, or pressing Enter on it, goes to the diagnostic in
+// the first editor viewing its buffer, or calls the OpenFunc if there is none.
+type DiagnosticsView struct {
+	gi.Frame
+
+	// only diagnostics that are at least as severe as this are listed
+	Severity textbuf.Severities
+
+	// function called to go to a diagnostic in a buffer that no editor is
+	// viewing, e.g., to open the buffer in a new tab of an app
+	OpenFunc func(tb *Buf, reg textbuf.Region) `json:"-" xml:"-"`
+
+	// the diagnostics listed, one per line of the ListBuf
+	Items []DiagnosticItem `set:"-" json:"-" xml:"-"`
+
+	// the buffer for the list
+	ListBuf *Buf `set:"-" json:"-" xml:"-"`
+}
+
+func (dv *DiagnosticsView) OnInit() {
+	dv.Severity = textbuf.SeverityHint
+	dv.Lay = gi.LayoutVert
+	dv.Style(func(s *styles.Style) {
+		s.SetStretchMax()
+	})
+	dv.OnWidgetAdded(func(w gi.Widget) {
+		switch w.PathFrom(dv) {
+		case "list":
+			w.Style(func(s *styles.Style) {
+				s.SetStretchMax()
+				s.SetMinPrefWidth(units.Ch(80))
+				s.SetMinPrefHeight(units.Em(10))
+				s.Font.Family = string(gi.Prefs.MonoFont)
+			})
+		}
+	})
+}
+
+func (dv *DiagnosticsView) ConfigWidget(sc *gi.Scene) {
+	dv.ConfigDiagnosticsView()
+}
+
+func (dv *DiagnosticsView) Destroy() {
+	diagMu.Lock()
+	if idx := slices.Index(diagViews, dv); idx >= 0 {
+		diagViews = slices.Delete(diagViews, idx, idx+1)
+	}
+	diagMu.Unlock()
+	dv.Frame.Destroy()
+}
+
+// ConfigDiagnosticsView configures the toolbar and list editor of the view
+func (dv *DiagnosticsView) ConfigDiagnosticsView() {
+	config := ki.Config{}
+	config.Add(gi.ToolbarType, "toolbar")
+	config.Add(EditorType, "list")
+	mods, updt := dv.ConfigChildren(config)
+	if !mods {
+		updt = dv.UpdateStart()
+	} else {
+		if dv.ListBuf == nil {
+			dv.ListBuf = NewBuf()
+		}
+		dv.ListBuf.SetReadOnly(true)
+		le := dv.ListEditor()
+		le.SetState(true, states.ReadOnly)
+		le.SetBuf(dv.ListBuf)
+		le.On(events.MouseDown, func(e events.Event) {
+			if e.MouseButton() != events.Left {
+				return
+			}
+			e.SetHandled()
+			pos := le.PixelToCursor(le.PointToRelPos(e.LocalPos()))
+			le.SetCursorShow(pos)
+			dv.GoTo(pos.Ln)
+		})
+		le.OnKeyChord(func(e events.Event) {
+			switch keyfun.Of(e.KeyChord()) {
+			case keyfun.Enter, keyfun.Accept:
+				e.SetHandled()
+				dv.GoTo(le.CursorPos.Ln)
+			}
+		})
+		dv.ConfigToolbar()
+		diagMu.Lock()
+		if !slices.Contains(diagViews, dv) {
+			diagViews = append(diagViews, dv)
+		}
+		diagMu.Unlock()
+	}
+	dv.UpdateEnd(updt)
+	dv.UpdateItems()
+}
+
+// Toolbar returns the toolbar widget
+func (dv *DiagnosticsView) Toolbar() *gi.Toolbar {
+	return dv.ChildByName("toolbar", 0).(*gi.Toolbar)
+}
+
+// ListEditor returns the Editor showing the list of diagnostics
+func (dv *DiagnosticsView) ListEditor() *Editor {
+	return dv.ChildByName("list", 1).(*Editor)
+}
+
+// ConfigToolbar configures the toolbar actions
+func (dv *DiagnosticsView) ConfigToolbar() {
+	tb := dv.Toolbar()
+	gi.NewButton(tb, "prev").SetText("Prev").SetIcon(icons.KeyboardArrowUp).
+		SetTooltip("go to the previous diagnostic in the list").
+		OnClick(func(e events.Event) {
+			dv.GoTo(dv.ListEditor().CursorPos.Ln - 1)
+		})
+	gi.NewButton(tb, "next").SetText("Next").SetIcon(icons.KeyboardArrowDown).
+		SetTooltip("go to the next diagnostic in the list").
+		OnClick(func(e events.Event) {
+			dv.GoTo(dv.ListEditor().CursorPos.Ln + 1)
+		})
+	gi.NewSeparator(tb)
+	sv := gi.NewChooser(tb, "severity").SetTooltip("list only the diagnostics that are at least this severe")
+	sv.ItemsFromEnum(textbuf.SeverityHint, false, 0)
+	sv.SetCurVal(dv.Severity)
+	sv.OnChange(func(e events.Event) {
+		if sev, ok := sv.CurVal.(textbuf.Severities); ok {
+			dv.Severity = sev
+			dv.UpdateItems()
+		}
+	})
+	gi.NewButton(tb, "refresh").SetText("Refresh").SetIcon(icons.Refresh).
+		SetTooltip("update the list from the current diagnostics").
+		OnClick(func(e events.Event) {
+			dv.UpdateItems()
+		})
+	gi.NewLabel(tb, "status")
+}
+
+// UpdateItems updates the list from the current diagnostics of all of the
+// buffers that have any -- it is called in the event loop of the window
+// of the view whenever they change
+func (dv *DiagnosticsView) UpdateItems() {
+	if dv.ListBuf == nil {
+		return
+	}
+	var items []DiagnosticItem
+	var sb strings.Builder
+	nerr, nwarn := 0, 0
+	for _, tb := range DiagnosticsBufs() {
+		fn := dirs.DirAndFile(string(tb.Filename))
+		if tb.Filename == "" {
+			fn = "(unnamed)"
+		}
+		for _, d := range tb.Diagnostics() {
+			if d.Severity > dv.Severity {
+				continue
+			}
+			switch d.Severity {
+			case textbuf.SeverityError:
+				nerr++
+			case textbuf.SeverityWarning:
+				nwarn++
+			}
+			items = append(items, DiagnosticItem{Diagnostic: d, Buf: tb})
+			msg, _, _ := strings.Cut(d.Message, "\n")
+			fmt.Fprintf(&sb, "%s:%d:%d: %s: %s", fn, d.Reg.Start.Ln+1, d.Reg.Start.Ch+1, d.Severity, msg)
+			if d.Source != "" {
+				sb.WriteString(" (" + d.Source + ")")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	dv.Items = items
+	dv.ListBuf.SetText([]byte(sb.String()))
+	lbl := dv.Toolbar().ChildByName("status", 0).(*gi.Label)
+	lbl.SetText(fmt.Sprintf("%d errors, %d warnings, %d total", nerr, nwarn, len(items)))
+	lbl.Update()
+}
+
+// GoTo goes to the diagnostic of the given index in the list, in the first
+// editor viewing its buffer, or by calling the OpenFunc if there is none
+func (dv *DiagnosticsView) GoTo(idx int) {
+	if idx < 0 || idx >= len(dv.Items) {
+		return
+	}
+	it := &dv.Items[idx]
+	le := dv.ListEditor()
+	le.HighlightRegion(textbuf.NewRegion(idx, 0, idx, dv.ListBuf.LineLen(idx)))
+	le.SetCursorShow(lex.Pos{Ln: idx})
+	if len(it.Buf.Views) == 0 {
+		if dv.OpenFunc != nil {
+			dv.OpenFunc(it.Buf, it.Reg)
+		}
+		return
+	}
+	ed := it.Buf.Views[0]
+	ed.GrabFocus()
+	ed.GoToDiagnostic(it.Diagnostic)
+}
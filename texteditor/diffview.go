@@ -4,166 +4,313 @@
 
 package texteditor
 
-/*
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/styles"
+	"goki.dev/girl/units"
+	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
+	"goki.dev/icons"
+	"goki.dev/ki/v2"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+	"goki.dev/vci/v2"
+)
+
 // DiffFiles shows the diffs between this file as the A file, and other file as B file,
 // in a DiffViewDialog
-
-	func DiffFiles(afile, bfile string) (*DiffView, error) {
-		ab, err := os.ReadFile(afile)
-		if err != nil {
-			slog.Error(err.Error())
-			return nil, err
-		}
-		bb, err := os.ReadFile(bfile)
-		if err != nil {
-			slog.Error(err.Error())
-			return nil, err
-		}
-		astr := strings.Split(strings.Replace(string(ab), "\r\n", "\n", -1), "\n") // windows safe
-		bstr := strings.Split(strings.Replace(string(bb), "\r\n", "\n", -1), "\n")
-		dlg := DiffViewDialog(nil, DlgOpts{Title: "Diff File View:"}, astr, bstr, afile, bfile, "", "")
-		return dlg, nil
+func DiffFiles(ctx gi.Widget, afile, bfile string) (*DiffView, error) {
+	ab, err := os.ReadFile(afile)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
 	}
+	bb, err := os.ReadFile(bfile)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	astr := strings.Split(strings.Replace(string(ab), "\r\n", "\n", -1), "\n") // windows safe
+	bstr := strings.Split(strings.Replace(string(bb), "\r\n", "\n", -1), "\n")
+	dlg := gi.NewDialog(ctx).Title("Diff File View")
+	dv := DiffViewDialog(dlg, astr, bstr, afile, bfile, "", "")
+	dlg.Run()
+	return dv, nil
+}
 
 // DiffViewDialogFromRevs opens a dialog for displaying diff between file
 // at two different revisions from given repository
 // if empty, defaults to: A = current HEAD, B = current WC file.
 // -1, -2 etc also work as universal ways of specifying prior revisions.
-
-	func DiffViewDialogFromRevs(avp *gi.Scene, repo vci.Repo, file string, fbuf *Buf, rev_a, rev_b string) (*DiffView, error) {
-		var astr, bstr []string
-		if rev_b == "" { // default to current file
-			if fbuf != nil {
-				bstr = fbuf.Strings(false)
-			} else {
-				fb, err := textbuf.FileBytes(file)
-				if err != nil {
-					return nil, err
-				}
-				bstr = textbuf.BytesToLineStrings(fb, false) // don't add new lines
-			}
+func DiffViewDialogFromRevs(ctx gi.Widget, repo vci.Repo, file string, fbuf *Buf, rev_a, rev_b string) (*DiffView, error) {
+	var astr, bstr []string
+	if rev_b == "" { // default to current file
+		if fbuf != nil {
+			bstr = fbuf.Strings(false)
 		} else {
-			fb, err := repo.FileContents(file, rev_b)
+			fb, err := textbuf.FileBytes(file)
 			if err != nil {
 				return nil, err
 			}
 			bstr = textbuf.BytesToLineStrings(fb, false) // don't add new lines
 		}
-		fb, err := repo.FileContents(file, rev_a)
+	} else {
+		fb, err := repo.FileContents(file, rev_b)
 		if err != nil {
 			return nil, err
 		}
-		astr = textbuf.BytesToLineStrings(fb, false) // don't add new lines
-		if rev_a == "" {
-			rev_a = "HEAD"
-		}
-		return DiffViewDialog(nil, DlgOpts{Title: "DiffVcs: " + dirs.DirAndFile(file)}, astr, bstr, file, file, rev_a, rev_b), nil
+		bstr = textbuf.BytesToLineStrings(fb, false) // don't add new lines
 	}
-
-// DiffViewDialog opens a dialog for displaying diff between two files as line-strings
-
-	func DiffViewDialog(ctx gi.Widget, opts DlgOpts, astr, bstr []string, afile, bfile, arev, brev string) *DiffView {
-		dlg := gi.NewStdDialog(ctx, opts.ToGiOpts(), nil)
-
-		frame := dlg.Stage.Scene
-		prIdx := dlg.PromptWidgetIdx()
-
-		dv := frame.InsertNewChild(DiffViewType, prIdx+1, "diff-view").(*DiffView)
-		dv.SetStretchMax()
-		dv.FileA = afile
-		dv.FileB = bfile
-		dv.RevA = arev
-		dv.RevB = brev
-		dv.DiffStrings(astr, bstr)
-		dlg.Run()
-		return dv
+	fb, err := repo.FileContents(file, rev_a)
+	if err != nil {
+		return nil, err
 	}
+	astr = textbuf.BytesToLineStrings(fb, false) // don't add new lines
+	if rev_a == "" {
+		rev_a = "HEAD"
+	}
+	dlg := gi.NewDialog(ctx).Title("DiffVcs: " + dirs.DirAndFile(file))
+	dv := DiffViewDialog(dlg, astr, bstr, file, file, rev_a, rev_b)
+	dlg.Run()
+	return dv, nil
+}
+
+// DiffViewDialog adds to the given dialog a DiffView displaying the diff
+// between two files as line-strings, returning the DiffView -- call Run
+// on the dialog to show it, after adding any buttons.
+func DiffViewDialog(dlg *gi.Dialog, astr, bstr []string, afile, bfile, arev, brev string) *DiffView {
+	dv := NewDiffView(dlg.Scene, "diff-view")
+	dv.FileA = afile
+	dv.FileB = bfile
+	dv.RevA = arev
+	dv.RevB = brev
+	dv.DiffStrings(astr, bstr)
+	dlg.FullWindow(true)
+	return dv
+}
 
 ///////////////////////////////////////////////////////////////////
 // DiffView
 
-// DiffView presents two side-by-side TextView windows showing the differences
-// between two files (represented as lines of strings).
+// DiffView presents two side-by-side [DiffTextView]s showing the differences
+// between two files (represented as lines of strings), with the lines of
+// each aligned, and a toolbar to go to each of the diff regions, apply
+// the changes in a region from one file to the other, and save the result.
+type DiffView struct {
+	gi.Frame
 
-	type DiffView struct {
-		gi.Frame
+	// first file name being compared
+	FileA string
 
-		// first file name being compared
-		FileA string `desc:"first file name being compared"`
+	// second file name being compared
+	FileB string
 
-		// second file name being compared
-		FileB string `desc:"second file name being compared"`
+	// revision for first file, if relevant
+	RevA string
 
-		// revision for first file, if relevant
-		RevA string `desc:"revision for first file, if relevant"`
+	// revision for second file, if relevant
+	RevB string
 
-		// revision for second file, if relevant
-		RevB string `desc:"revision for second file, if relevant"`
+	// textbuf for A
+	BufA *Buf `json:"-" xml:"-"`
 
-		// the diff records
-		Diffs textbuf.Diffs `json:"-" xml:"-" desc:"the diff records"`
+	// textbuf for B
+	BufB *Buf `json:"-" xml:"-"`
 
-		// textbuf for A
-		BufA *Buf `json:"-" xml:"-" desc:"textbuf for A"`
+	// the diff records
+	Diffs textbuf.Diffs `set:"-" json:"-" xml:"-"`
 
-		// textbuf for B
-		BufB *Buf `json:"-" xml:"-" desc:"textbuf for B"`
+	// aligned diffs records diff for aligned lines
+	AlignD textbuf.Diffs `set:"-" json:"-" xml:"-"`
 
-		// aligned diffs records diff for aligned lines
-		AlignD textbuf.Diffs `json:"-" xml:"-" desc:"aligned diffs records diff for aligned lines"`
+	// edit diffs records aligned diffs with edits applied
+	EditA textbuf.Diffs `set:"-" json:"-" xml:"-"`
 
-		// edit diffs records aligned diffs with edits applied
-		EditA textbuf.Diffs `json:"-" xml:"-" desc:"edit diffs records aligned diffs with edits applied"`
+	// edit diffs records aligned diffs with edits applied
+	EditB textbuf.Diffs `set:"-" json:"-" xml:"-"`
 
-		// edit diffs records aligned diffs with edits applied
-		EditB textbuf.Diffs `json:"-" xml:"-" desc:"edit diffs records aligned diffs with edits applied"`
+	// undo diffs records aligned diffs with edits applied
+	UndoA textbuf.Diffs `set:"-" json:"-" xml:"-"`
 
-		// undo diffs records aligned diffs with edits applied
-		UndoA textbuf.Diffs `json:"-" xml:"-" desc:"undo diffs records aligned diffs with edits applied"`
-
-		// undo diffs records aligned diffs with edits applied
-		UndoB textbuf.Diffs `json:"-" xml:"-" desc:"undo diffs records aligned diffs with edits applied"`
-	}
-
-func (dv *DiffView) OnInit() {
-	dv.DiffViewStyles()
+	// undo diffs records aligned diffs with edits applied
+	UndoB textbuf.Diffs `set:"-" json:"-" xml:"-"`
 }
 
-func (dv *DiffView) DiffViewStyles() {
+func (dv *DiffView) OnInit() {
+	dv.Lay = gi.LayoutVert
 	dv.Style(func(s *styles.Style) {
 		s.SetStretchMax()
 	})
 	dv.OnWidgetAdded(func(w gi.Widget) {
 		switch w.PathFrom(dv) {
-		case "text-a-lay", "text-b-lay":
+		case "text-ab":
 			w.Style(func(s *styles.Style) {
 				s.SetStretchMax()
-				s.SetMinPrefWidth(units.Ch(80))
-				s.SetMinPrefHeight(units.Em(40))
-			})
-		case "text-a", "text-b":
-			w.Style(func(s *styles.Style) {
-				s.Font.Family = string(gi.Prefs.MonoFont)
 			})
-		case "diff-lay":
-			df := w.(*gi.Layout)
-			df.Lay = gi.LayoutHoriz
+		case "text-ab/text-a", "text-ab/text-b":
 			w.Style(func(s *styles.Style) {
 				s.SetStretchMax()
+				s.SetMinPrefWidth(units.Ch(80))
+				s.SetMinPrefHeight(units.Em(40))
+				s.Font.Family = string(gi.Prefs.MonoFont)
 			})
 		}
 	})
 }
 
-// NextDiff moves to next diff region
-func (dv *DiffView) NextDiff(ab int) bool {
-	tva, tvb := dv.TextViews()
-	tv := tva
-	if ab == 1 {
-		tv = tvb
+// ConfigDiffView configures the toolbar and editors of the view
+func (dv *DiffView) ConfigDiffView() {
+	config := ki.Config{}
+	config.Add(gi.ToolbarType, "toolbar")
+	config.Add(gi.SplitsType, "text-ab")
+	mods, updt := dv.ConfigChildren(config)
+	if !mods {
+		updt = dv.UpdateStart()
+		dv.SetTextNames()
+	} else {
+		dv.ConfigTexts()
+		dv.ConfigToolbar()
+	}
+	dv.UpdateEnd(updt)
+}
+
+// ConfigTexts configures the Bufs and the DiffTextViews for the A and B files
+func (dv *DiffView) ConfigTexts() {
+	if dv.BufA == nil {
+		dv.BufA = NewBuf()
+		dv.BufB = NewBuf()
 	}
+	dv.BufA.Filename = gi.FileName(dv.FileA)
+	dv.BufA.Opts.LineNos = true
+	dv.BufA.Stat() // update markup
+	dv.BufB.Filename = gi.FileName(dv.FileB)
+	dv.BufB.Opts.LineNos = true
+	dv.BufB.Stat() // update markup
+	sp := dv.Splits()
+	sp.Dim = mat32.X
+	config := ki.Config{}
+	config.Add(DiffTextViewType, "text-a")
+	config.Add(DiffTextViewType, "text-b")
+	sp.ConfigChildren(config)
+	av, bv := dv.TextViews()
+	av.SetBuf(dv.BufA)
+	bv.SetBuf(dv.BufB)
+}
+
+// Toolbar returns the toolbar widget
+func (dv *DiffView) Toolbar() *gi.Toolbar {
+	return dv.ChildByName("toolbar", 0).(*gi.Toolbar)
+}
+
+// Splits returns the Splits containing the A and B views
+func (dv *DiffView) Splits() *gi.Splits {
+	return dv.ChildByName("text-ab", 1).(*gi.Splits)
+}
+
+// TextViews returns the DiffTextViews showing the A and B files
+func (dv *DiffView) TextViews() (*DiffTextView, *DiffTextView) {
+	sp := dv.Splits()
+	av := sp.Child(0).(*DiffTextView)
+	bv := sp.Child(1).(*DiffTextView)
+	return av, bv
+}
+
+// ConfigToolbar configures the toolbar actions
+func (dv *DiffView) ConfigToolbar() {
+	tb := dv.Toolbar()
+	gi.NewLabel(tb, "label-a")
+	gi.NewButton(tb, "prev").SetText("Prev").SetIcon(icons.KeyboardArrowUp).
+		SetTooltip("move up to previous diff region").
+		OnClick(func(e events.Event) {
+			dv.PrevDiff()
+		})
+	gi.NewButton(tb, "next").SetText("Next").SetIcon(icons.KeyboardArrowDown).
+		SetTooltip("move down to next diff region").
+		OnClick(func(e events.Event) {
+			dv.NextDiff()
+		})
+	gi.NewButton(tb, "apply-a").SetText("A <- B").SetIcon(icons.ContentCopy).
+		SetTooltip("for current diff region, apply change from corresponding version in B, and move to next diff").
+		OnClick(func(e events.Event) {
+			dv.ApplyDiff(0, -1)
+			dv.NextDiff()
+		})
+	gi.NewButton(tb, "undo-a").SetText("Undo").SetIcon(icons.Undo).
+		SetTooltip("undo last diff apply action (A <- B)").
+		OnClick(func(e events.Event) {
+			dv.UndoDiff(0)
+		})
+	gi.NewButton(tb, "save-a").SetText("Save").SetIcon(icons.Save).
+		SetTooltip("save edited version of file A -- this converts it back to its original form (removing side-by-side alignment) and ends the diff editing function").
+		OnClick(func(e events.Event) {
+			dv.SaveFileA(gi.FileName(dv.FileA))
+		})
+	gi.NewLabel(tb, "status")
+	gi.NewStretch(tb, "str")
+	gi.NewLabel(tb, "label-b")
+	gi.NewButton(tb, "apply-b").SetText("A -> B").SetIcon(icons.ContentCopy).
+		SetTooltip("for current diff region, apply change from corresponding version in A, and move to next diff").
+		OnClick(func(e events.Event) {
+			dv.ApplyDiff(1, -1)
+			dv.NextDiff()
+		})
+	gi.NewButton(tb, "undo-b").SetText("Undo").SetIcon(icons.Undo).
+		SetTooltip("undo last diff apply action (A -> B)").
+		OnClick(func(e events.Event) {
+			dv.UndoDiff(1)
+		})
+	gi.NewButton(tb, "save-b").SetText("Save").SetIcon(icons.Save).
+		SetTooltip("save edited version of file B -- this converts it back to its original form (removing side-by-side alignment) and ends the diff editing function").
+		OnClick(func(e events.Event) {
+			dv.SaveFileB(gi.FileName(dv.FileB))
+		})
+	dv.SetTextNames()
+}
+
+// SetTextNames sets the labels for the A and B files in the toolbar
+func (dv *DiffView) SetTextNames() {
+	tb := dv.Toolbar()
+	txta := "A: " + dirs.DirAndFile(dv.FileA)
+	if dv.RevA != "" {
+		txta += ": " + dv.RevA
+	}
+	tb.ChildByName("label-a", 0).(*gi.Label).SetText(txta)
+	txtb := "B: " + dirs.DirAndFile(dv.FileB)
+	if dv.RevB != "" {
+		txtb += ": " + dv.RevB
+	}
+	tb.ChildByName("label-b", 9).(*gi.Label).SetText(txtb)
+}
+
+// UpdateStatus updates the status label in the toolbar with
+// the number of diff regions remaining to be applied.
+func (dv *DiffView) UpdateStatus() {
+	n := 0
+	for _, df := range dv.AlignD {
+		if df.Tag != 'e' {
+			n++
+		}
+	}
+	lbl := dv.Toolbar().ChildByName("status", 6).(*gi.Label)
+	lbl.SetText(fmt.Sprintf("%d differences, %d applied", n, len(dv.UndoA)+len(dv.UndoB)))
+	lbl.Update()
+}
+
+// NextDiff moves to next diff region
+func (dv *DiffView) NextDiff() bool {
+	av, bv := dv.TextViews()
 	nd := len(dv.AlignD)
-	curLn := tv.CursorPos.Ln
+	curLn := av.CursorPos.Ln
 	di, df := dv.AlignD.DiffForLine(curLn)
 	if di < 0 {
 		return false
@@ -178,21 +325,14 @@ func (dv *DiffView) NextDiff(ab int) bool {
 			break
 		}
 	}
-	tva.SetCursorShow(lex.Pos{Ln: df.I1})
-	tva.ScrollCursorToVertCenter()
-	tvb.SetCursorShow(lex.Pos{Ln: df.I1})
-	tvb.ScrollCursorToVertCenter()
+	dv.ShowDiff(av.AsEditor(), bv.AsEditor(), df.I1)
 	return true
 }
 
 // PrevDiff moves to previous diff region
-func (dv *DiffView) PrevDiff(ab int) bool {
-	tva, tvb := dv.TextViews()
-	tv := tva
-	if ab == 1 {
-		tv = tvb
-	}
-	curLn := tv.CursorPos.Ln
+func (dv *DiffView) PrevDiff() bool {
+	av, bv := dv.TextViews()
+	curLn := av.CursorPos.Ln
 	di, df := dv.AlignD.DiffForLine(curLn)
 	if di < 0 {
 		return false
@@ -207,13 +347,19 @@ func (dv *DiffView) PrevDiff(ab int) bool {
 			break
 		}
 	}
-	tva.SetCursorShow(lex.Pos{Ln: df.I1})
-	tva.ScrollCursorToVertCenter()
-	tvb.SetCursorShow(lex.Pos{Ln: df.I1})
-	tvb.ScrollCursorToVertCenter()
+	dv.ShowDiff(av.AsEditor(), bv.AsEditor(), df.I1)
 	return true
 }
 
+// ShowDiff moves the cursor in both editors to given aligned line,
+// centering it in the view
+func (dv *DiffView) ShowDiff(ae, be *Editor, ln int) {
+	ae.SetCursorShow(lex.Pos{Ln: ln})
+	ae.ScrollCursorToVertCenter()
+	be.SetCursorShow(lex.Pos{Ln: ln})
+	be.ScrollCursorToVertCenter()
+}
+
 // ResetDiffs resets all active diff state -- after saving
 func (dv *DiffView) ResetDiffs() {
 	dv.BufA.LineColors = nil
@@ -235,25 +381,16 @@ func (dv *DiffView) RemoveAlignsA() {
 			if df.J2 > df.I2 {
 				spos := lex.Pos{Ln: df.I2, Ch: 0}
 				epos := lex.Pos{Ln: df.J2, Ch: 0}
-				dv.BufA.DeleteText(spos, epos, true)
+				dv.BufA.DeleteText(spos, epos, EditSignal)
 			}
 		case 'i':
 			spos := lex.Pos{Ln: df.J1, Ch: 0}
 			epos := lex.Pos{Ln: df.J2, Ch: 0}
-			dv.BufA.DeleteText(spos, epos, true)
+			dv.BufA.DeleteText(spos, epos, EditSignal)
 		}
 	}
 }
 
-// SaveFileA saves the current state of file A to given filename
-func (dv *DiffView) SaveFileA(fname gi.FileName) {
-	dv.RemoveAlignsA()
-	dv.RemoveAlignsB()
-	dv.ResetDiffs()
-	dv.BufA.SaveAs(fname)
-	dv.UpdateToolbar()
-}
-
 // RemoveAlignsB removes extra blank text lines added to align with A
 func (dv *DiffView) RemoveAlignsB() {
 	nd := len(dv.EditB)
@@ -264,31 +401,38 @@ func (dv *DiffView) RemoveAlignsB() {
 			if df.I2 > df.J2 {
 				spos := lex.Pos{Ln: df.J2, Ch: 0}
 				epos := lex.Pos{Ln: df.I2, Ch: 0}
-				dv.BufB.DeleteText(spos, epos, true)
+				dv.BufB.DeleteText(spos, epos, EditSignal)
 			}
 		case 'd':
 			spos := lex.Pos{Ln: df.I1, Ch: 0}
 			epos := lex.Pos{Ln: df.I2, Ch: 0}
-			dv.BufB.DeleteText(spos, epos, true)
+			dv.BufB.DeleteText(spos, epos, EditSignal)
 		}
 	}
 }
 
+// SaveFileA saves the current state of file A to given filename
+func (dv *DiffView) SaveFileA(fname gi.FileName) {
+	dv.RemoveAlignsA()
+	dv.RemoveAlignsB()
+	dv.ResetDiffs()
+	dv.BufA.SaveAs(fname)
+	dv.UpdateStatus()
+}
+
 // SaveFileB saves the current state of file B to given filename
 func (dv *DiffView) SaveFileB(fname gi.FileName) {
 	dv.RemoveAlignsA()
 	dv.RemoveAlignsB()
 	dv.ResetDiffs()
 	dv.BufB.SaveAs(fname)
-	dv.UpdateToolbar()
+	dv.UpdateStatus()
 }
 
 // DiffStrings computes differences between two lines-of-strings and displays in
 // DiffView.
 func (dv *DiffView) DiffStrings(astr, bstr []string) {
-	if !dv.IsConfiged() {
-		dv.Config(dv.Sc)
-	}
+	dv.ConfigDiffView()
 	av, bv := dv.TextViews()
 	aupdt := av.UpdateStart()
 	bupdt := bv.UpdateStart()
@@ -297,11 +441,13 @@ func (dv *DiffView) DiffStrings(astr, bstr []string) {
 	del := colors.Red
 	ins := colors.Green
 	chg := colors.Blue
-	dv.Diffs = textbuf.DiffLines(astr, bstr)
+	dv.Diffs = textbuf.MyersDiffLines(astr, bstr, nil)
 	nd := len(dv.Diffs)
 	dv.AlignD = make(textbuf.Diffs, nd)
 	dv.EditA = make(textbuf.Diffs, nd)
 	dv.EditB = make(textbuf.Diffs, nd)
+	dv.UndoA = nil
+	dv.UndoB = nil
 	var ab, bb [][]byte
 	absln := 0
 	bspc := []byte(" ")
@@ -403,10 +549,25 @@ func (dv *DiffView) DiffStrings(astr, bstr []string) {
 	dv.BufB.ReMarkup()
 	av.UpdateEnd(aupdt)
 	bv.UpdateEnd(bupdt)
+	dv.UpdateStatus()
 }
 
+const (
+	// WordDiffMinLen is the length, in runes, above which a pair of
+	// replaced lines is not tagged with its word differences by
+	// TagWordDiffs if the lines are mostly different (see WordDiffDensity),
+	// as the tags would then just be noise.
+	WordDiffMinLen = 80
+
+	// WordDiffDensity is the number of runes per differing region
+	// below which lines longer than WordDiffMinLen are mostly different.
+	WordDiffDensity = 8
+)
+
 // TagWordDiffs goes through replace diffs and tags differences at the
-// word level between the two regions.
+// word level between the two regions, using textbuf.DiffLineEdits:
+// replaced words are tagged as errors in both, and deleted or inserted
+// words as deleted in the one that has them.
 func (dv *DiffView) TagWordDiffs() {
 	for _, df := range dv.AlignD {
 		if df.Tag != 'r' {
@@ -418,35 +579,29 @@ func (dv *DiffView) TagWordDiffs() {
 		stln := df.I1
 		for i := 0; i < mx; i++ {
 			ln := stln + i
-			ra := dv.BufA.Lines[ln]
-			rb := dv.BufB.Lines[ln]
-			lna := lex.RuneFields(ra)
-			lnb := lex.RuneFields(rb)
-			fla := lna.RuneStrings(ra)
-			flb := lnb.RuneStrings(rb)
-			nab := max(len(fla), len(flb))
-			ldif := textbuf.DiffLines(fla, flb)
-			ndif := len(ldif)
-			if nab > 25 && ndif > nab/2 { // more than half of big diff -- skip
+			ra := dv.BufA.Line(ln)
+			rb := dv.BufB.Line(ln)
+			lds := textbuf.DiffLineEdits(ra, rb, textbuf.DiffLevelWord, false)
+			nreg := 0
+			for _, ld := range lds {
+				nreg++
+				if ld.Tag == 'r' {
+					nreg++
+				}
+			}
+			nab := max(len(ra), len(rb))
+			if nab > WordDiffMinLen && nreg > nab/WordDiffDensity { // mostly different -- skip
 				continue
 			}
-			for _, ld := range ldif {
+			for _, ld := range lds {
 				switch ld.Tag {
 				case 'r':
-					sla := lna[ld.I1]
-					ela := lna[ld.I2-1]
-					dv.BufA.AddTag(ln, sla.St, ela.Ed, token.TextStyleError)
-					slb := lnb[ld.J1]
-					elb := lnb[ld.J2-1]
-					dv.BufB.AddTag(ln, slb.St, elb.Ed, token.TextStyleError)
+					dv.BufA.AddTag(ln, ld.A.Start.Ch, ld.A.End.Ch, token.TextStyleError)
+					dv.BufB.AddTag(ln, ld.B.Start.Ch, ld.B.End.Ch, token.TextStyleError)
 				case 'd':
-					sla := lna[ld.I1]
-					ela := lna[ld.I2-1]
-					dv.BufA.AddTag(ln, sla.St, ela.Ed, token.TextStyleDeleted)
+					dv.BufA.AddTag(ln, ld.A.Start.Ch, ld.A.End.Ch, token.TextStyleDeleted)
 				case 'i':
-					slb := lnb[ld.J1]
-					elb := lnb[ld.J2-1]
-					dv.BufB.AddTag(ln, slb.St, elb.Ed, token.TextStyleDeleted)
+					dv.BufB.AddTag(ln, ld.B.Start.Ch, ld.B.End.Ch, token.TextStyleDeleted)
 				}
 			}
 		}
@@ -454,12 +609,12 @@ func (dv *DiffView) TagWordDiffs() {
 }
 
 // ApplyDiff applies change from the other buffer to the buffer for given file
-// name, from diff that includes given line.
+// name, from diff that includes given line (-1 for the line at the cursor).
 func (dv *DiffView) ApplyDiff(ab int, line int) bool {
-	tva, tvb := dv.TextViews()
-	tv := tva
+	av, bv := dv.TextViews()
+	tv := av
 	if ab == 1 {
-		tv = tvb
+		tv = bv
 	}
 	if line < 0 {
 		line = tv.CursorPos.Ln
@@ -470,12 +625,11 @@ func (dv *DiffView) ApplyDiff(ab int, line int) bool {
 	}
 	if ab == 0 {
 		dv.BufA.Undos.Off = false
-		// srcLen := len(dv.BufB.Lines[df.J2])
 		spos := lex.Pos{Ln: df.I1, Ch: 0}
 		epos := lex.Pos{Ln: df.I2, Ch: 0}
 		src := dv.BufB.Region(spos, epos)
-		dv.BufA.DeleteText(spos, epos, true)
-		dv.BufA.InsertText(spos, src.ToBytes(), true) // we always just copy, is blank for delete..
+		dv.BufA.DeleteText(spos, epos, EditSignal)
+		dv.BufA.InsertText(spos, src.ToBytes(), EditSignal) // we always just copy, is blank for delete..
 		ei, _ := dv.EditA.DiffForLine(df.J1)
 		if ei >= 0 {
 			dv.UndoA = append(dv.UndoA, dv.EditA[ei])
@@ -490,8 +644,8 @@ func (dv *DiffView) ApplyDiff(ab int, line int) bool {
 		spos := lex.Pos{Ln: df.J1, Ch: 0}
 		epos := lex.Pos{Ln: df.J2, Ch: 0}
 		src := dv.BufA.Region(spos, epos)
-		dv.BufB.DeleteText(spos, epos, true)
-		dv.BufB.InsertText(spos, src.ToBytes(), true)
+		dv.BufB.DeleteText(spos, epos, EditSignal)
+		dv.BufB.InsertText(spos, src.ToBytes(), EditSignal)
 		ei, _ := dv.EditB.DiffForLine(df.I1)
 		if ei >= 0 {
 			dv.UndoB = append(dv.UndoB, dv.EditB[ei])
@@ -502,17 +656,17 @@ func (dv *DiffView) ApplyDiff(ab int, line int) bool {
 			}
 		}
 	}
-	dv.UpdateToolbar()
+	dv.UpdateStatus()
 	return true
 }
 
 // UndoDiff undoes last applied change, if any -- just does Undo in buffer and
 // updates the list of edits applied.
 func (dv *DiffView) UndoDiff(ab int) {
-	tva, tvb := dv.TextViews()
-	tv := tva
+	av, bv := dv.TextViews()
+	tv := av
 	if ab == 1 {
-		tv = tvb
+		tv = bv
 	}
 	if ab == 0 {
 		dv.BufA.Undos.Off = false
@@ -533,13 +687,7 @@ func (dv *DiffView) UndoDiff(ab int) {
 			if oi >= 0 {
 				df.Tag = od.Tag // restore
 			}
-			if ei < 0 {
-				dv.EditA = append(textbuf.Diffs{df}, dv.EditA...)
-			} else {
-				tmp := append(dv.EditA[:ei+1], df)
-				tmp = append(tmp, dv.EditA[ei+1:]...)
-				dv.EditA = tmp
-			}
+			dv.EditA = slices.Insert(dv.EditA, ei+1, df)
 		}
 	} else {
 		dv.BufB.Undos.Off = false
@@ -560,265 +708,68 @@ func (dv *DiffView) UndoDiff(ab int) {
 			if oi >= 0 {
 				df.Tag = od.Tag // restore
 			}
-			if ei < 0 {
-				dv.EditB = append(textbuf.Diffs{df}, dv.EditB...)
-			} else {
-				tmp := append(dv.EditB[:ei+1], df)
-				tmp = append(tmp, dv.EditB[ei+1:]...)
-				dv.EditB = tmp
-			}
+			dv.EditB = slices.Insert(dv.EditB, ei+1, df)
 		}
 	}
 	tv.Undo()
-	dv.UpdateToolbar()
-}
-
-func (dv *DiffView) ConfigWidget(vp *gi.Scene) {
-	dv.Lay = gi.LayoutVert
-	config := ki.Config{}
-	config.Add(gi.ToolbarType, "toolbar")
-	config.Add(gi.LayoutType, "diff-lay")
-	mods, updt := dv.ConfigChildren(config)
-	if !mods {
-		updt = dv.UpdateStart()
-		dv.SetTextNames()
-	} else {
-		dv.ConfigToolbar()
-		dv.ConfigTexts()
-	}
-	dv.UpdateEnd(updt)
-}
-
-func (dv *DiffView) FileModifiedUpdateA(bt *gi.Button) {
-	bt.SetEnabledStateUpdt(dv.BufA.IsChanged())
-}
-
-func (dv *DiffView) FileModifiedUpdateB(bt *gi.Button) {
-	bt.SetEnabledStateUpdt(dv.BufB.IsChanged())
-}
-
-func (dv *DiffView) HasDiffsUpdate(bt *gi.Button) {
-	bt.SetEnabledStateUpdt(len(dv.AlignD) > 1) // always has at least 1
-}
-
-func (dv *DiffView) ConfigToolbar() {
-	tb := dv.Toolbar()
-	txta := "A: " + dirs.DirAndFile(dv.FileA)
-	if dv.RevA != "" {
-		txta += ": " + dv.RevA
-	}
-	gi.NewLabel(tb, "label-a").SetText(txta)
-	tb.AddButton(gi.ActOpts{Label: "Next", Icon: icons.KeyboardArrowDown, Tooltip: "move down to next diff region", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.NextDiff(0)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Prev", Icon: icons.KeyboardArrowUp, Tooltip: "move up to previous diff region", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.PrevDiff(0)
-	})
-	tb.AddButton(gi.ActOpts{Label: "A <- B", Icon: icons.ContentCopy, Tooltip: "for current diff region, apply change from corresponding version in B, and move to next diff", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.ApplyDiff(0, -1)
-		dv.NextDiff(0)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Undo", Icon: icons.Undo, Tooltip: "undo last diff apply action (A <- B)", UpdateFunc: dv.FileModifiedUpdateA}, func(act *gi.Button) {
-		dv.UndoDiff(0)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Save", Icon: icons.Save, Tooltip: "save edited version of file -- prompts for filename -- this will convert file back to its original form (removing side-by-side alignment) and end the diff editing function", UpdateFunc: dv.FileModifiedUpdateA}, func(act *gi.Button) {
-		CallMethod(dv, "SaveFileA", dv.Sc)
-	})
-	gi.NewStretch(tb, "str")
-
-	txtb := "B: " + dirs.DirAndFile(dv.FileB)
-	if dv.RevB != "" {
-		txtb += ": " + dv.RevB
-	}
-	gi.NewLabel(tb, "label-b", txtb)
-	tb.AddButton(gi.ActOpts{Label: "Next", Icon: icons.KeyboardArrowDown, Tooltip: "move down to next diff region", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.NextDiff(1)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Prev", Icon: icons.KeyboardArrowUp, Tooltip: "move up to previous diff region", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.PrevDiff(1)
-	})
-	tb.AddButton(gi.ActOpts{Label: "A -> B", Icon: icons.ContentCopy, Tooltip: "for current diff region, apply change from corresponding version in A, and move to next diff", UpdateFunc: dv.HasDiffsUpdate}, func(act *gi.Button) {
-		dv.ApplyDiff(1, -1)
-		dv.NextDiff(1)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Undo", Icon: icons.Undo, Tooltip: "undo last diff apply action (A -> B)", UpdateFunc: dv.FileModifiedUpdateB}, func(act *gi.Button) {
-		dv.UndoDiff(1)
-	})
-	tb.AddButton(gi.ActOpts{Label: "Save", Icon: icons.Save, Tooltip: "save edited version of file -- prompts for filename -- this will convert file back to its original form (removing side-by-side alignment) and end the diff editing function", UpdateFunc: dv.FileModifiedUpdateB}, func(act *gi.Button) {
-		CallMethod(dv, "SaveFileB", dv.Sc)
-	})
-}
-
-func (dv *DiffView) SetTextNames() {
-	tb := dv.Toolbar()
-	la := tb.ChildByName("label-a", 0).(*gi.Label)
-	txta := "A: " + dirs.DirAndFile(dv.FileA)
-	if dv.RevA != "" {
-		txta += ": " + dv.RevA
-	}
-	la.SetText(txta)
-	lb := tb.ChildByName("label-b", 4).(*gi.Label)
-	txtb := "B: " + dirs.DirAndFile(dv.FileB)
-	if dv.RevB != "" {
-		txtb += ": " + dv.RevB
-	}
-	lb.SetText(txtb)
-}
-
-func (dv *DiffView) UpdateToolbar() {
-	tb := dv.Toolbar()
-	tb.UpdateButtons()
-}
-
-func (dv *DiffView) Toolbar() *gi.Toolbar {
-	tb := dv.ChildByName("toolbar", 1).(*gi.Toolbar)
-	return tb
-}
-
-func (dv *DiffView) DiffLay() *gi.Layout {
-	lay := dv.ChildByName("diff-lay", 1).(*gi.Layout)
-	return lay
-}
-
-func (dv *DiffView) TextViewLays() (*gi.Layout, *gi.Layout) {
-	lay := dv.DiffLay()
-	a := lay.Child(0).(*gi.Layout)
-	b := lay.Child(1).(*gi.Layout)
-	return a, b
-}
-
-func (dv *DiffView) TextViews() (*DiffTextView, *DiffTextView) {
-	a, b := dv.TextViewLays()
-	av := a.Child(0).(*DiffTextView)
-	bv := b.Child(0).(*DiffTextView)
-	return av, bv
-}
-
-func (dv *DiffView) ConfigTexts() {
-	lay := dv.DiffLay()
-	if dv.BufA == nil {
-		dv.BufA = &Buf{}
-		dv.BufA.InitName(dv.BufA, "diff-buf-a")
-		dv.BufB = &Buf{}
-		dv.BufB.InitName(dv.BufB, "diff-buf-b")
-	}
-	dv.BufA.Filename = gi.FileName(dv.FileA)
-	dv.BufA.Opts.LineNos = true
-	dv.BufA.Stat() // update markup
-	dv.BufB.Filename = gi.FileName(dv.FileB)
-	dv.BufB.Opts.LineNos = true
-	dv.BufB.Stat() // update markup
-	config := ki.Config{}
-	config.Add(gi.LayoutType, "text-a-lay")
-	config.Add(gi.LayoutType, "text-b-lay")
-	mods, updt := lay.ConfigChildren(config)
-	al, bl := dv.TextViewLays()
-	if !mods {
-		updt = lay.UpdateStart()
-	} else {
-		av := NewDiffTextView(al, "text-a")
-		bv := NewDiffTextView(bl, "text-b")
-		// av.SetReadOnly()
-		// bv.SetReadOnly()
-		av.SetBuf(dv.BufA)
-		bv.SetBuf(dv.BufB)
-
-		// sync scrolling
-		al.On(events.Scroll, func(e events.Event) {
-			// bl.ScrollToPos(al.Pos(), data.(float32))
-		})
-		bl.On(events.Scroll, func(e events.Event) {
-			// al.ScrollToPos(mat32.Dims(sig), data.(float32))
-		})
-	}
-	lay.UpdateEnd(updt)
-}
-
-func (dv *DiffView) IsConfiged() bool {
-	if dv.NumChildren() > 0 && dv.BufA != nil {
-		return true
-	}
-	return false
-}
-
-// DiffViewProps are style properties for DiffView
-var DiffViewProps = ki.Props{
-	"CallMethods": ki.PropSlice{
-		{"SaveFileA", ki.Props{
-			"Args": ki.PropSlice{
-				{"File Name", ki.Props{
-					"default-field": "FileA",
-				}},
-			},
-		}},
-		{"SaveFileB", ki.Props{
-			"Args": ki.PropSlice{
-				{"File Name", ki.Props{
-					"default-field": "FileB",
-				}},
-			},
-		}},
-	},
+	dv.UpdateStatus()
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 //   DiffTextView
 
-// DiffTextView supports double-click based application of edits from one
-// buffer to the other.
+// DiffTextView is an [Editor] in a [DiffView], which applies the diff
+// region at a line from the other file when its line number is
+// double-clicked.  In a [MergeView], it instead takes the lines from
+// its version for the conflict at that line.
 type DiffTextView struct {
-	View
+	Editor
 }
 
-func (tv *DiffTextView) OnInit() {
-	tv.TextViewEvents()
+func (dt *DiffTextView) OnInit() {
+	dt.Editor.OnInit()
+	dt.HandleDiffDoubleClick()
 }
 
-func (tv *DiffTextView) DiffView() *DiffView {
-	dvi := tv.ParentByType(DiffViewType, ki.NoEmbeds)
+// DiffView returns the DiffView containing this view, or nil if none
+func (dt *DiffTextView) DiffView() *DiffView {
+	dvi := dt.ParentByType(DiffViewType, ki.NoEmbeds)
 	if dvi == nil {
 		return nil
 	}
 	return dvi.(*DiffView)
 }
 
-// MouseEvent handles the events.Event to process double-click
-func (tv *DiffTextView) HandleDoubleClick(me events.Event) {
-	if me.MouseButton() != events.Left {
-		tv.TextView.MouseEvent(me)
-		return
+// MergeView returns the MergeView containing this view, or nil if none
+func (dt *DiffTextView) MergeView() *MergeView {
+	mvi := dt.ParentByType(MergeViewType, ki.NoEmbeds)
+	if mvi == nil {
+		return nil
 	}
-	pt := tv.PointToRelPos(me.LocalPos())
-	if pt.X >= 0 && pt.X < int(tv.LineNoOff) {
-		newPos := tv.PixelToCursor(pt)
-		ln := newPos.Ln
-		dv := tv.DiffView()
-		if dv != nil && tv.Buf != nil {
-			if tv.Nm == "text-a" {
+	return mvi.(*MergeView)
+}
+
+// HandleDiffDoubleClick applies the diff at the line whose line number
+// is double-clicked, from the other file to this one, or in a MergeView,
+// takes this version for the conflict at that line.
+func (dt *DiffTextView) HandleDiffDoubleClick() {
+	dt.OnDoubleClick(func(e events.Event) {
+		pt := dt.PointToRelPos(e.LocalPos())
+		if dt.Buf == nil || !dt.HasLineNos() || float32(pt.X) >= dt.FoldMarkerOff() { // not on the line number
+			return
+		}
+		ln := dt.PixelToCursor(pt).Ln
+		if dv := dt.DiffView(); dv != nil {
+			if dt.Nm == "text-a" {
 				dv.ApplyDiff(0, ln)
 			} else {
 				dv.ApplyDiff(1, ln)
 			}
+		} else if mv := dt.MergeView(); mv != nil && dt.Nm != "text-base" {
+			mv.TakeAtLine(dt.Nm == "text-a", ln)
+		} else {
+			return
 		}
-		me.SetHandled()
-		return
-	}
-	tv.View.MouseEvent(me)
-}
-
-// TextViewEvents sets connections between mouse and key events and actions
-func (tv *DiffTextView) TextViewEvents() {
-	// tv.HoverTooltipEvent()
-	tv.MouseMoveEvent()
-	tv.MouseDragEvent()
-	tv.On(events.DoubleClick, func(e events.Event) {
-		tv.HandleDoubleClick(e)
-	})
-	tv.MouseFocusEvent()
-	tv.On(events.KeyChord, func(e events.Event) {
-		kt := e.(*events.Key)
-		tv.KeyInput(kt)
+		e.SetHandled()
 	})
 }
-
-*/
@@ -98,9 +98,18 @@ type Editor struct { //goki:embedder
 	// current selection region
 	SelectReg textbuf.Region `set:"-" edit:"-" json:"-" xml:"-"`
 
+	// additional cursors and their selections, for multi-cursor editing -- the primary cursor is CursorPos and SelectReg
+	Cursors []MultiCursor `set:"-" edit:"-" json:"-" xml:"-"`
+
 	// previous selection region, that was actually rendered -- needed to update render
 	PrevSelectReg textbuf.Region `set:"-" edit:"-" json:"-" xml:"-"`
 
+	// regions of lines that can be folded, updated from the Buf during layout
+	FoldRegs []textbuf.Fold `set:"-" edit:"-" json:"-" xml:"-"`
+
+	// regions of lines that are currently folded (hidden), in order of starting line -- use SetFolded to change
+	Folded []textbuf.Fold `set:"-" edit:"-" json:"-" xml:"-"`
+
 	// highlighted regions, e.g., for search results
 	Highlights []textbuf.Region `set:"-" edit:"-" json:"-" xml:"-"`
 
@@ -119,6 +128,15 @@ type Editor struct { //goki:embedder
 	// query replace data
 	QReplace QReplace `set:"-" edit:"-" json:"-" xml:"-"`
 
+	// keyboard macro recording data
+	Macro Macro `set:"-" edit:"-" json:"-" xml:"-"`
+
+	// minimap overview column, shown if Minimap.On -- use SetMinimap to change
+	Minimap Minimap `set:"-" edit:"-" json:"-" xml:"-"`
+
+	// optional handler that gets key events before the default KeyInput processing, e.g., for vim-style modal editing (see the texteditor/vim package) -- nil for the standard key functions
+	KeyHandler KeyHandler `edit:"-" json:"-" xml:"-"`
+
 	// font height, cached during styling
 	FontHeight float32 `set:"-" edit:"-" json:"-" xml:"-"`
 
@@ -151,6 +169,9 @@ type Editor struct { //goki:embedder
 	lastRecenter   int
 	lastAutoInsert rune
 	lastFilename   gi.FileName
+
+	// for each line, whether it is hidden in a Folded region -- see SetFolded
+	hiddenLns []bool
 }
 
 func (ed *Editor) FlagType() enums.BitFlag {
@@ -175,6 +196,7 @@ func (ed *Editor) OnInit() {
 func (ed *Editor) ViewStyles() {
 	ed.Style(func(s *styles.Style) {
 		s.SetAbilities(true, abilities.Activatable, abilities.Focusable, abilities.Hoverable, abilities.Slideable)
+		s.SetAbilities(true, abilities.LongHoverable) // for diagnostics and LSP hover info
 		ed.CursorWidth.Dp(1)
 		ed.LineNumberColor.SetSolid(colors.Scheme.SurfaceContainer)
 		ed.SelectColor.SetSolid(colors.Scheme.Select.Container)
@@ -264,11 +286,13 @@ func (ed *Editor) Clear() {
 // ResetState resets all the random state variables, when opening a new buffer etc
 func (ed *Editor) ResetState() {
 	ed.SelectReset()
+	ed.Cursors = nil
 	ed.Highlights = nil
 	ed.ISearch.On = false
 	ed.QReplace.On = false
 	if ed.Buf == nil || ed.lastFilename != ed.Buf.Filename { // don't reset if reopening..
 		ed.CursorPos = lex.Pos{}
+		ed.SetFolded(nil)
 	}
 	if ed.Buf != nil {
 		ed.Buf.SetReadOnly(ed.IsReadOnly())
@@ -340,6 +364,11 @@ func (ed *Editor) LinesDeleted(tbe *textbuf.Edit) {
 // BufSignal receives a signal from the Buf when underlying text
 // is changed.
 func (ed *Editor) BufSignal(sig BufSignals, tbe *textbuf.Edit) {
+	ed.MinimapBufSignal(sig, tbe)
+	if sig == BufInsert || sig == BufDelete {
+		ed.AdjustCursors(tbe)
+		ed.AdjustFolds(tbe)
+	}
 	switch sig {
 	case BufDone:
 	case BufNew:
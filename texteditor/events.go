@@ -26,18 +26,41 @@ func (ed *Editor) HandleTextViewEvents() {
 	ed.HandleLayoutEvents()
 	ed.HandleTextViewKeyChord()
 	ed.HandleTextViewMouse()
+	ed.HandleMinimapEvents()
+	ed.HandleHover()
 	ed.HandleWidgetContextMenu()
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 //    KeyInput handling
 
+// KeyHandler is an alternative key input handler for an Editor, set in
+// its KeyHandler field, which gets each key chord event before the
+// default KeyInput, e.g., for modal editing.  Any event that it does not
+// mark as handled (SetHandled) goes on to the default KeyInput.
+type KeyHandler interface {
+
+	// HandleKey handles given key chord event for given editor
+	HandleKey(ed *Editor, e events.Event)
+}
+
 func (ed *Editor) HandleTextViewKeyChord() {
 	ed.OnKeyChord(func(e events.Event) {
-		ed.KeyInput(e)
+		ed.KeyChordInput(e)
 	})
 }
 
+// KeyChordInput handles given key chord event, recording it if a keyboard
+// macro is being recorded, and sending it to the KeyHandler if set,
+// and then to KeyInput.  Replayed macros are sent here too.
+func (ed *Editor) KeyChordInput(e events.Event) {
+	ed.MacroRecord(e)
+	if ed.KeyHandler != nil {
+		ed.KeyHandler.HandleKey(ed, e)
+	}
+	ed.KeyInput(e)
+}
+
 // ShiftSelect sets the selection start if the shift key is down but wasn't on the last key move.
 // If the shift key has been released the select region is set to textbuf.RegionNil
 func (ed *Editor) ShiftSelect(kt events.Event) {
@@ -233,6 +256,42 @@ func (ed *Editor) KeyInput(kt events.Event) {
 		cancelAll()
 		kt.SetHandled()
 		ed.Lookup()
+	case keyfun.Fold:
+		cancelAll()
+		kt.SetHandled()
+		ed.Fold()
+	case keyfun.Unfold:
+		cancelAll()
+		kt.SetHandled()
+		ed.Unfold()
+	case keyfun.UnfoldAll:
+		cancelAll()
+		kt.SetHandled()
+		ed.UnfoldAll()
+	case keyfun.MacroStart:
+		cancelAll()
+		kt.SetHandled()
+		ed.MacroStart()
+	case keyfun.MacroStop:
+		cancelAll()
+		kt.SetHandled()
+		ed.MacroStop()
+	case keyfun.MacroReplay:
+		cancelAll()
+		kt.SetHandled()
+		ed.MacroReplayKey()
+	case keyfun.MacroReplayCount:
+		cancelAll()
+		kt.SetHandled()
+		ed.MacroReplayCountPrompt()
+	case keyfun.NextDiagnostic:
+		cancelAll()
+		kt.SetHandled()
+		ed.NextDiagnostic()
+	case keyfun.PrevDiagnostic:
+		cancelAll()
+		kt.SetHandled()
+		ed.PrevDiagnostic()
 	}
 	if ed.IsReadOnly() {
 		switch {
@@ -275,27 +334,27 @@ func (ed *Editor) KeyInput(kt events.Event) {
 			ed.ISearchBackspace()
 		} else {
 			kt.SetHandled()
-			ed.CursorBackspace(1)
+			ed.MultiCursorDo(func() { ed.CursorBackspace(1) })
 			ed.ISpellKeyInput(kt)
 			ed.OfferComplete()
 		}
 	case keyfun.Kill:
 		cancelAll()
 		kt.SetHandled()
-		ed.CursorKill()
+		ed.MultiCursorDo(ed.CursorKill)
 	case keyfun.Delete:
 		cancelAll()
 		kt.SetHandled()
-		ed.CursorDelete(1)
+		ed.MultiCursorDo(func() { ed.CursorDelete(1) })
 		ed.ISpellKeyInput(kt)
 	case keyfun.BackspaceWord:
 		cancelAll()
 		kt.SetHandled()
-		ed.CursorBackspaceWord(1)
+		ed.MultiCursorDo(func() { ed.CursorBackspaceWord(1) })
 	case keyfun.DeleteWord:
 		cancelAll()
 		kt.SetHandled()
-		ed.CursorDeleteWord(1)
+		ed.MultiCursorDo(func() { ed.CursorDeleteWord(1) })
 	case keyfun.Cut:
 		cancelAll()
 		kt.SetHandled()
@@ -303,7 +362,7 @@ func (ed *Editor) KeyInput(kt events.Event) {
 	case keyfun.Paste:
 		cancelAll()
 		kt.SetHandled()
-		ed.Paste()
+		ed.MultiCursorDo(ed.Paste)
 	case keyfun.Transpose:
 		cancelAll()
 		kt.SetHandled()
@@ -325,6 +384,26 @@ func (ed *Editor) KeyInput(kt events.Event) {
 		cancelAll()
 		kt.SetHandled()
 		ed.Redo()
+	case keyfun.ReflowPara:
+		cancelAll()
+		kt.SetHandled()
+		ed.ReflowPara()
+	case keyfun.AddCursorAbove:
+		cancelAll()
+		kt.SetHandled()
+		ed.AddCursorAbove()
+	case keyfun.AddCursorBelow:
+		cancelAll()
+		kt.SetHandled()
+		ed.AddCursorBelow()
+	case keyfun.AddCursorNextMatch:
+		cancelAll()
+		kt.SetHandled()
+		ed.AddCursorNextMatch()
+	case keyfun.SplitSelectionLines:
+		cancelAll()
+		kt.SetHandled()
+		ed.SplitSelectionLines()
 	case keyfun.Complete:
 		ed.ISearchCancel()
 		kt.SetHandled()
@@ -339,25 +418,7 @@ func (ed *Editor) KeyInput(kt events.Event) {
 		cancelAll()
 		if !kt.HasAnyModifier(key.Control, key.Meta) {
 			kt.SetHandled()
-			if ed.Buf.Opts.AutoIndent {
-				lp, _ := pi.LangSupport.Props(ed.Buf.PiState.Sup)
-				if lp != nil && lp.Lang != nil && lp.HasFlag(pi.ReAutoIndent) {
-					// only re-indent current line for supported types
-					tbe, _, _ := ed.Buf.AutoIndent(ed.CursorPos.Ln) // reindent current line
-					if tbe != nil {
-						// go back to end of line!
-						npos := lex.Pos{Ln: ed.CursorPos.Ln, Ch: ed.Buf.LineLen(ed.CursorPos.Ln)}
-						ed.SetCursor(npos)
-					}
-				}
-				ed.InsertAtCursor([]byte("\n"))
-				tbe, _, cpos := ed.Buf.AutoIndent(ed.CursorPos.Ln)
-				if tbe != nil {
-					ed.SetCursorShow(lex.Pos{Ln: tbe.Reg.End.Ln, Ch: cpos})
-				}
-			} else {
-				ed.InsertAtCursor([]byte("\n"))
-			}
+			ed.MultiCursorDo(ed.InsertNewline)
 			ed.ISpellKeyInput(kt)
 		}
 		// todo: KeFunFocusPrev -- unindent
@@ -367,13 +428,15 @@ func (ed *Editor) KeyInput(kt events.Event) {
 			kt.SetHandled()
 			updt := ed.UpdateStart()
 			lasttab := ed.Is(EditorLastWasTabAI)
-			if !lasttab && ed.CursorPos.Ch == 0 && ed.Buf.Opts.AutoIndent {
+			if !lasttab && ed.CursorPos.Ch == 0 && ed.Buf.Opts.AutoIndent && !ed.HasMultiCursors() {
 				_, _, cpos := ed.Buf.AutoIndent(ed.CursorPos.Ln)
 				ed.CursorPos.Ch = cpos
 				ed.RenderCursor(true)
 				gotTabAI = true
 			} else {
-				ed.InsertAtCursor(indent.Bytes(ed.Buf.Opts.IndentChar(), 1, ed.Styles.Text.TabSize))
+				ed.MultiCursorDo(func() {
+					ed.InsertAtCursor(indent.Bytes(ed.Buf.Opts.IndentChar(), 1, ed.Styles.Text.TabSize))
+				})
 			}
 			ed.UpdateEndRender(updt)
 			ed.ISpellKeyInput(kt)
@@ -407,6 +470,30 @@ func (ed *Editor) KeyInput(kt events.Event) {
 	ed.SetFlag(gotTabAI, EditorLastWasTabAI)
 }
 
+// InsertNewline inserts a new line at the cursor, auto-indenting
+// the current and new lines if the Buf AutoIndent option is on.
+func (ed *Editor) InsertNewline() {
+	if !ed.Buf.Opts.AutoIndent {
+		ed.InsertAtCursor([]byte("\n"))
+		return
+	}
+	lp, _ := pi.LangSupport.Props(ed.Buf.PiState.Sup)
+	if lp != nil && lp.Lang != nil && lp.HasFlag(pi.ReAutoIndent) {
+		// only re-indent current line for supported types
+		tbe, _, _ := ed.Buf.AutoIndent(ed.CursorPos.Ln) // reindent current line
+		if tbe != nil {
+			// go back to end of line!
+			npos := lex.Pos{Ln: ed.CursorPos.Ln, Ch: ed.Buf.LineLen(ed.CursorPos.Ln)}
+			ed.SetCursor(npos)
+		}
+	}
+	ed.InsertAtCursor([]byte("\n"))
+	tbe, _, cpos := ed.Buf.AutoIndent(ed.CursorPos.Ln)
+	if tbe != nil {
+		ed.SetCursorShow(lex.Pos{Ln: tbe.Reg.End.Ln, Ch: cpos})
+	}
+}
+
 // KeyInputInsertBra handle input of opening bracket-like entity (paren, brace, bracket)
 func (ed *Editor) KeyInputInsertBra(kt events.Event) {
 	pos := ed.CursorPos
@@ -465,39 +552,41 @@ func (ed *Editor) KeyInputInsertRune(kt events.Event) {
 		ed.CancelComplete()
 		ed.QReplaceKeyInput(kt)
 	} else {
-		if kt.KeyRune() == '{' || kt.KeyRune() == '(' || kt.KeyRune() == '[' {
-			ed.KeyInputInsertBra(kt)
-		} else if kt.KeyRune() == '}' && ed.Buf.Opts.AutoIndent && ed.CursorPos.Ch == ed.Buf.LineLen(ed.CursorPos.Ln) {
-			ed.CancelComplete()
-			ed.lastAutoInsert = 0
-			ed.InsertAtCursor([]byte(string(kt.KeyRune())))
-			tbe, _, cpos := ed.Buf.AutoIndent(ed.CursorPos.Ln)
-			if tbe != nil {
-				ed.SetCursorShow(lex.Pos{Ln: tbe.Reg.End.Ln, Ch: cpos})
-			}
-		} else if ed.lastAutoInsert == kt.KeyRune() { // if we type what we just inserted, just move past
-			ed.CursorPos.Ch++
-			ed.SetCursorShow(ed.CursorPos)
-			ed.lastAutoInsert = 0
-		} else {
-			ed.lastAutoInsert = 0
-			ed.InsertAtCursor([]byte(string(kt.KeyRune())))
-			if kt.KeyRune() == ' ' {
+		ed.MultiCursorDo(func() {
+			if kt.KeyRune() == '{' || kt.KeyRune() == '(' || kt.KeyRune() == '[' {
+				ed.KeyInputInsertBra(kt)
+			} else if kt.KeyRune() == '}' && ed.Buf.Opts.AutoIndent && ed.CursorPos.Ch == ed.Buf.LineLen(ed.CursorPos.Ln) {
 				ed.CancelComplete()
+				ed.lastAutoInsert = 0
+				ed.InsertAtCursor([]byte(string(kt.KeyRune())))
+				tbe, _, cpos := ed.Buf.AutoIndent(ed.CursorPos.Ln)
+				if tbe != nil {
+					ed.SetCursorShow(lex.Pos{Ln: tbe.Reg.End.Ln, Ch: cpos})
+				}
+			} else if ed.lastAutoInsert == kt.KeyRune() { // if we type what we just inserted, just move past
+				ed.CursorPos.Ch++
+				ed.SetCursorShow(ed.CursorPos)
+				ed.lastAutoInsert = 0
 			} else {
-				ed.OfferComplete()
+				ed.lastAutoInsert = 0
+				ed.InsertAtCursor([]byte(string(kt.KeyRune())))
+				if kt.KeyRune() == ' ' {
+					ed.CancelComplete()
+				} else {
+					ed.OfferComplete()
+				}
 			}
-		}
-		if kt.KeyRune() == '}' || kt.KeyRune() == ')' || kt.KeyRune() == ']' {
-			cp := ed.CursorPos
-			np := cp
-			np.Ch--
-			tp, found := ed.Buf.BraceMatch(kt.KeyRune(), np)
-			if found {
-				ed.Scopelights = append(ed.Scopelights, textbuf.NewRegionPos(tp, lex.Pos{tp.Ln, tp.Ch + 1}))
-				ed.Scopelights = append(ed.Scopelights, textbuf.NewRegionPos(np, lex.Pos{cp.Ln, cp.Ch}))
+			if kt.KeyRune() == '}' || kt.KeyRune() == ')' || kt.KeyRune() == ']' {
+				cp := ed.CursorPos
+				np := cp
+				np.Ch--
+				tp, found := ed.Buf.BraceMatch(kt.KeyRune(), np)
+				if found {
+					ed.Scopelights = append(ed.Scopelights, textbuf.NewRegionPos(tp, lex.Pos{tp.Ln, tp.Ch + 1}))
+					ed.Scopelights = append(ed.Scopelights, textbuf.NewRegionPos(np, lex.Pos{cp.Ln, cp.Ch}))
+				}
 			}
-		}
+		})
 	}
 }
 
@@ -560,9 +649,28 @@ func (ed *Editor) OpenLinkAt(pos lex.Pos) (*paint.TextLink, bool) {
 	return tl, ok
 }
 
+// HandleFoldClick toggles the folding of the line whose fold marker is
+// clicked with the left button (see FoldMarkerAt), which also works in
+// read-only views, returning true if it did
+func (ed *Editor) HandleFoldClick(e events.Event) bool {
+	if e.MouseButton() != events.Left {
+		return false
+	}
+	ln, ok := ed.FoldMarkerAt(ed.PointToRelPos(e.LocalPos()))
+	if !ok {
+		return false
+	}
+	ed.ToggleFold(ln)
+	e.SetHandled()
+	return true
+}
+
 // HandleTextViewMouse handles mouse events.Event
 func (ed *Editor) HandleTextViewMouse() {
 	ed.On(events.MouseDown, func(e events.Event) { // note: usual is Click..
+		if ed.HandleFoldClick(e) {
+			return
+		}
 		if ed.IsReadOnly() {
 			return
 		}
@@ -575,7 +683,10 @@ func (ed *Editor) HandleTextViewMouse() {
 		case events.Left:
 			ed.SetState(true, states.Focused)
 			if _, got := ed.OpenLinkAt(newPos); got {
+			} else if e.HasAnyModifier(key.Alt) {
+				ed.AddCursor(newPos)
 			} else {
+				ed.ClearCursors()
 				ed.SetCursorFromMouse(pt, newPos, e.SelectMode())
 				ed.SavePosHistory(ed.CursorPos)
 			}
@@ -591,6 +702,9 @@ func (ed *Editor) HandleTextViewMouse() {
 		}
 	})
 	ed.OnDoubleClick(func(e events.Event) {
+		if ed.HandleFoldClick(e) { // a quick second click on a fold marker
+			return
+		}
 		if ed.IsReadOnly() {
 			return
 		}
@@ -0,0 +1,400 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/texteditor/histyle"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+)
+
+// ExportOpts are options for exporting the syntax highlighted text of a Buf
+type ExportOpts struct {
+
+	// include line numbers at the start of each line
+	LineNos bool
+
+	// include the line colors from Buf.LineColors, as the background of the
+	// line numbers, or of a one-space gutter if there are no line numbers
+	LineColors bool
+
+	// title of the HTML document -- the file name is used if empty
+	Title string
+}
+
+// exportLine is one line of text to export, with its tags
+type exportLine struct {
+
+	// line number in the Buf
+	ln int
+
+	// text of the line, clipped to the exported region
+	txt []rune
+
+	// syntax highlighting and custom tags, clipped to the text
+	hitags, tags lex.Line
+}
+
+// exportRun is a run of text in one style
+type exportRun struct {
+	txt []rune
+	se  histyle.StyleEntry
+}
+
+// exportLines returns the lines of text in given region to export,
+// or all of the lines if the region is nil.
+func (tb *Buf) exportLines(reg textbuf.Region) []exportLine {
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	tb.MarkupMu.RLock()
+	defer tb.MarkupMu.RUnlock()
+	if tb.NLines == 0 {
+		return nil
+	}
+	if reg.IsNil() {
+		reg = textbuf.NewRegion(0, 0, tb.NLines-1, tb.Rope.LineLen(tb.NLines-1))
+	}
+	stln := max(reg.Start.Ln, 0)
+	edln := min(reg.End.Ln, tb.NLines-1)
+	lines := make([]exportLine, 0, edln-stln+1)
+	for ln, lr := range tb.Rope.Lines(stln, edln+1) {
+		ln += stln
+		st, ed := 0, len(lr)
+		if ln == reg.Start.Ln {
+			st = min(reg.Start.Ch, ed)
+		}
+		if ln == reg.End.Ln {
+			ed = max(min(reg.End.Ch, ed), st)
+		}
+		el := exportLine{ln: ln, txt: lr[st:ed]}
+		if ln < tb.HiTags.Len() {
+			el.hitags = clipTags(tb.HiTags.At(ln), st, ed)
+		}
+		if ln < tb.Tags.Len() {
+			el.tags = clipTags(tb.AdjustedTags(ln), st, ed)
+		}
+		lines = append(lines, el)
+	}
+	return lines
+}
+
+// clipTags returns the tags in given line that are within the
+// range of chars [st, ed), relative to st.
+func clipTags(tags lex.Line, st, ed int) lex.Line {
+	var ct lex.Line
+	for _, t := range tags {
+		t.St = max(t.St, st) - st
+		t.Ed = min(t.Ed, ed) - st
+		if t.Ed > t.St {
+			ct = append(ct, t)
+		}
+	}
+	return ct
+}
+
+// styleRuns returns the runs of text in given line that have the same
+// style in given highlighting style, with inner tags inheriting from
+// the outer ones they are within.
+func styleRuns(hs *histyle.Style, el *exportLine) []exportRun {
+	sz := len(el.txt)
+	if sz == 0 {
+		return nil
+	}
+	ses := make([]histyle.StyleEntry, sz)
+	for _, t := range lex.MergeLines(el.hitags, el.tags) { // outer tags before inner
+		se := hs.Tag(t.Tok.Tok)
+		for i := max(t.St, 0); i < min(t.Ed, sz); i++ {
+			ses[i] = se.Inherit(ses[i])
+		}
+	}
+	var runs []exportRun
+	st := 0
+	for i := 1; i <= sz; i++ {
+		if i == sz || ses[i] != ses[st] {
+			runs = append(runs, exportRun{txt: el.txt[st:i], se: ses[st]})
+			st = i
+		}
+	}
+	return runs
+}
+
+// exportStyle returns the highlighting style to use for export,
+// and the line number format for given lines and options
+func (tb *Buf) exportStyle(lines []exportLine, opts *ExportOpts) (*histyle.Style, string) {
+	hs := tb.Hi.HiStyle
+	if hs == nil {
+		hs = histyle.AvailStyle(tb.Hi.Style)
+	}
+	if hs == nil {
+		hs = &histyle.Style{}
+	}
+	lnfmt := ""
+	if opts.LineNos && len(lines) > 0 {
+		digs := len(fmt.Sprintf("%d", lines[len(lines)-1].ln+1))
+		lnfmt = fmt.Sprintf("%%%dd ", digs)
+	} else if opts.LineColors {
+		lnfmt = " "
+	}
+	return hs, lnfmt
+}
+
+// lineNo returns the line number text for given line, using given format
+func lineNo(lnfmt string, ln int) string {
+	if strings.Contains(lnfmt, "%") {
+		return fmt.Sprintf(lnfmt, ln+1)
+	}
+	return lnfmt
+}
+
+// lineColor returns the line color for given line, if there is one and
+// line colors are being exported
+func (tb *Buf) lineColor(ln int, opts *ExportOpts) (color.RGBA, bool) {
+	if !opts.LineColors {
+		return color.RGBA{}, false
+	}
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	clr, has := tb.LineColors[ln]
+	return clr, has
+}
+
+// ExportHTML writes the text in given region (the whole buffer if the
+// region is nil) as a self-contained HTML document, with the syntax
+// highlighting from the current HiStyle in an embedded CSS style sheet.
+func (tb *Buf) ExportHTML(w io.Writer, reg textbuf.Region, opts *ExportOpts) error {
+	if opts == nil {
+		opts = &ExportOpts{}
+	}
+	lines := tb.exportLines(reg)
+	hs, lnfmt := tb.exportStyle(lines, opts)
+	title := opts.Title
+	if title == "" {
+		title = filepath.Base(string(tb.Filename))
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(bw, "<title>%s</title>\n<style>\n", HTMLEscapeRunes([]rune(title)))
+	bg := hs.TagRaw(token.Background)
+	pre := []string{"font-family: monospace", fmt.Sprintf("tab-size: %d", max(tb.Opts.TabSize, 1))}
+	if !colors.IsNil(bg.Background) {
+		pre = append(pre, "background-color: "+colors.AsString(bg.Background), "padding: 0.5em")
+	}
+	if txt := hs.Tag(token.Text); !colors.IsNil(txt.Color) {
+		pre = append(pre, "color: "+colors.AsString(txt.Color))
+	}
+	fmt.Fprintf(bw, "pre.code { %s }\n", strings.Join(pre, "; "))
+	lnsty := "user-select: none; opacity: 0.6"
+	if cm := hs.Tag(token.Comment); !colors.IsNil(cm.Color) {
+		lnsty += "; color: " + colors.AsString(cm.Color)
+	}
+	fmt.Fprintf(bw, "pre.code .line-no { %s }\n", lnsty)
+	css := hs.ToCSS()
+	toks := make([]token.Tokens, 0, len(css))
+	for tok := range css {
+		toks = append(toks, tok)
+	}
+	slices.Sort(toks)
+	for _, tok := range toks {
+		fmt.Fprintf(bw, "pre.code .%s { %s }\n", tok.StyleName(), css[tok])
+	}
+	bw.WriteString("</style>\n</head>\n<body>\n<pre class=\"code\">")
+	for _, el := range lines {
+		if lnfmt != "" {
+			lns := lineNo(lnfmt, el.ln)
+			if clr, has := tb.lineColor(el.ln, opts); has {
+				fmt.Fprintf(bw, `<span class="line-no" style="background-color: %s">%s</span>`, colors.AsString(clr), lns)
+			} else {
+				fmt.Fprintf(bw, `<span class="line-no">%s</span>`, lns)
+			}
+		}
+		bw.Write(tb.Hi.MarkupLine(el.txt, el.hitags, el.tags))
+		bw.WriteString("\n")
+	}
+	bw.WriteString("</pre>\n</body>\n</html>\n")
+	return bw.Flush()
+}
+
+// ExportRTF writes the text in given region (the whole buffer if the
+// region is nil) as an RTF document, for pasting into word processors,
+// with the syntax highlighting from the current HiStyle, including its
+// background color.
+func (tb *Buf) ExportRTF(w io.Writer, reg textbuf.Region, opts *ExportOpts) error {
+	if opts == nil {
+		opts = &ExportOpts{}
+	}
+	lines := tb.exportLines(reg)
+	hs, lnfmt := tb.exportStyle(lines, opts)
+	bg := hs.TagRaw(token.Background).Background
+	lnclr := hs.Tag(token.Comment).Color
+
+	var clrs []color.RGBA // color table
+	clrIdx := func(c color.RGBA) int {
+		if colors.IsNil(c) {
+			return 0
+		}
+		if i := slices.Index(clrs, c); i >= 0 {
+			return i + 1
+		}
+		clrs = append(clrs, c)
+		return len(clrs)
+	}
+	var body strings.Builder
+	run := func(txt []rune, se histyle.StyleEntry) {
+		if colors.IsNil(se.Background) {
+			se.Background = bg
+		}
+		cb := clrIdx(se.Background)
+		fmt.Fprintf(&body, `{\cf%d\cb%d\chcbpat%d`, clrIdx(se.Color), cb, cb)
+		if se.Bold == histyle.Yes {
+			body.WriteString(`\b`)
+		}
+		if se.Italic == histyle.Yes {
+			body.WriteString(`\i`)
+		}
+		if se.Underline == histyle.Yes {
+			body.WriteString(`\ul`)
+		}
+		body.WriteString(" ")
+		rtfEscape(&body, txt)
+		body.WriteString("}")
+	}
+	for _, el := range lines {
+		if lnfmt != "" {
+			se := histyle.StyleEntry{Color: lnclr}
+			if clr, has := tb.lineColor(el.ln, opts); has {
+				se.Background = clr
+			}
+			run([]rune(lineNo(lnfmt, el.ln)), se)
+		}
+		for _, r := range styleRuns(hs, &el) {
+			run(r.txt, r.se)
+		}
+		body.WriteString("\\par\n")
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\\rtf1\\ansi\\deff0{\\fonttbl{\\f0\\fmodern Courier New;}}\n{\\colortbl;")
+	for _, c := range clrs {
+		fmt.Fprintf(bw, "\\red%d\\green%d\\blue%d;", c.R, c.G, c.B)
+	}
+	bw.WriteString("}\n\\f0\\fs20\n")
+	bw.WriteString(body.String())
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// rtfEscape writes given text to given builder, escaping the RTF special
+// characters, and writing non-ASCII characters as \u unicode escapes
+func rtfEscape(sb *strings.Builder, txt []rune) {
+	for _, r := range txt {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\t':
+			sb.WriteString(`\tab `)
+		case r < 0x80:
+			sb.WriteRune(r)
+		case r < 0x10000:
+			fmt.Fprintf(sb, `\u%d?`, int16(r))
+		default: // surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(sb, `\u%d?\u%d?`, int16(0xD800+(r>>10)), int16(0xDC00+(r&0x3FF)))
+		}
+	}
+}
+
+// ExportANSI writes the text in given region (the whole buffer if the
+// region is nil) with 24-bit ANSI terminal escape codes for the syntax
+// highlighting from the current HiStyle.  The background color of the
+// style is not used, so the text is shown on the terminal background.
+func (tb *Buf) ExportANSI(w io.Writer, reg textbuf.Region, opts *ExportOpts) error {
+	if opts == nil {
+		opts = &ExportOpts{}
+	}
+	lines := tb.exportLines(reg)
+	hs, lnfmt := tb.exportStyle(lines, opts)
+	lnclr := hs.Tag(token.Comment).Color
+	bw := bufio.NewWriter(w)
+	for _, el := range lines {
+		if lnfmt != "" {
+			se := histyle.StyleEntry{Color: lnclr}
+			if clr, has := tb.lineColor(el.ln, opts); has {
+				se.Background = clr
+			}
+			bw.WriteString(ansiCodes(se))
+			bw.WriteString(lineNo(lnfmt, el.ln))
+			bw.WriteString("\x1b[0m")
+		}
+		for _, r := range styleRuns(hs, &el) {
+			if r.se.IsZero() {
+				bw.WriteString(string(r.txt))
+				continue
+			}
+			bw.WriteString(ansiCodes(r.se))
+			bw.WriteString(string(r.txt))
+			bw.WriteString("\x1b[0m")
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// ansiCodes returns the ANSI escape codes that set the given style
+func ansiCodes(se histyle.StyleEntry) string {
+	var cds []string
+	if se.Bold == histyle.Yes {
+		cds = append(cds, "1")
+	}
+	if se.Italic == histyle.Yes {
+		cds = append(cds, "3")
+	}
+	if se.Underline == histyle.Yes {
+		cds = append(cds, "4")
+	}
+	if c := se.Color; !colors.IsNil(c) {
+		cds = append(cds, fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B))
+	}
+	if c := se.Background; !colors.IsNil(c) {
+		cds = append(cds, fmt.Sprintf("48;2;%d;%d;%d", c.R, c.G, c.B))
+	}
+	if len(cds) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(cds, ";") + "m"
+}
+
+// ExportFile exports the text in given region (the whole buffer if the
+// region is nil) to given file, as HTML if it has a .html or .htm
+// extension, RTF for .rtf, and ANSI terminal text otherwise.
+func (tb *Buf) ExportFile(filename string, reg textbuf.Region, opts *ExportOpts) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		err = tb.ExportHTML(f, reg, opts)
+	case ".rtf":
+		err = tb.ExportRTF(f, reg, opts)
+	default:
+		err = tb.ExportANSI(f, reg, opts)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"goki.dev/gi/v2/texteditor/histyle"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+)
+
+// exportTestBuf returns a Buf with a keyword tag on the first line and a
+// comment tag on the second, and a known highlighting style
+func exportTestBuf() *Buf {
+	tb := NewBuf()
+	tb.SetText([]byte("if a<b {\n\tü {x}\n}\n"))
+	tb.Hi.HiStyle = &histyle.Style{
+		token.Keyword:    &histyle.StyleEntry{Color: color.RGBA{255, 0, 0, 255}, Bold: histyle.Yes},
+		token.Comment:    &histyle.StyleEntry{Color: color.RGBA{0, 128, 0, 255}, Italic: histyle.Yes},
+		token.Background: &histyle.StyleEntry{Background: color.RGBA{255, 255, 255, 255}},
+	}
+	tb.HiTags.Set(0, lex.Line{lex.NewLex(token.KeyToken{Tok: token.Keyword}, 0, 2)})
+	tb.AddTag(1, 3, 6, token.Comment)
+	return tb
+}
+
+func TestExportHTML(t *testing.T) {
+	tb := exportTestBuf()
+	var sb strings.Builder
+	if err := tb.ExportHTML(&sb, textbuf.RegionNil, &ExportOpts{LineNos: true, Title: "a<b"}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	kw, cm := token.Keyword.StyleName(), token.Comment.StyleName()
+	for _, want := range []string{
+		"<title>a&lt;b</title>",
+		"background-color: ",
+		"pre.code ." + kw + " { ",
+		"pre.code ." + cm + " { ",
+		`<pre class="code"><span class="line-no">1 </span><span class="` + kw + `">if</span> a&lt;b {` + "\n",
+		`<span class="line-no">2 </span>` + "\tü " + `<span class="` + cm + `">{x}</span>` + "\n",
+		`<span class="line-no">3 </span>}` + "\n</pre>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ExportHTML output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	sb.Reset()
+	if err := tb.ExportHTML(&sb, textbuf.NewRegion(0, 3, 1, 2), nil); err != nil {
+		t.Fatal(err)
+	}
+	if out := sb.String(); !strings.Contains(out, "<pre class=\"code\">a&lt;b {\n\tü\n</pre>") {
+		t.Errorf("ExportHTML of region, tags should be clipped away:\n%s", out)
+	}
+}
+
+func TestExportRTF(t *testing.T) {
+	tb := exportTestBuf()
+	var sb strings.Builder
+	if err := tb.ExportRTF(&sb, textbuf.RegionNil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\\rtf1\\ansi\\deff0{\\fonttbl{\\f0\\fmodern Courier New;}}\n" +
+		"{\\colortbl;\\red255\\green255\\blue255;\\red255\\green0\\blue0;\\red0\\green128\\blue0;}\n" +
+		"\\f0\\fs20\n" +
+		`{\cf2\cb1\chcbpat1\b if}{\cf0\cb1\chcbpat1  a<b \{}\par` + "\n" +
+		`{\cf0\cb1\chcbpat1 \tab \u252? }{\cf3\cb1\chcbpat1\i \{x\}}\par` + "\n" +
+		`{\cf0\cb1\chcbpat1 \}}\par` + "\n}\n"
+	if out := sb.String(); out != want {
+		t.Errorf("ExportRTF:\n got: %q\nwant: %q", out, want)
+	}
+
+	sb.Reset()
+	rtfEscape(&sb, []rune("😀"))
+	if out := sb.String(); out != `\u-10179?\u-8704?` {
+		t.Errorf("rtfEscape of a surrogate pair = %q", out)
+	}
+}
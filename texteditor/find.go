@@ -5,6 +5,9 @@
 package texteditor
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"unicode"
 
 	"goki.dev/gi/v2/gi"
@@ -12,6 +15,7 @@ import (
 	"goki.dev/girl/states"
 	"goki.dev/girl/units"
 	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
 	"goki.dev/pi/v2/lex"
 )
 
@@ -28,9 +32,35 @@ func (ed *Editor) FindMatches(find string, useCase, lexItems bool) ([]textbuf.Ma
 		return nil, false
 	}
 	_, matches := ed.Buf.Search([]byte(find), !useCase, lexItems)
+	return matches, ed.HighlightMatches(matches)
+}
+
+// FindMatchesRegexp finds the matches with given regular expression,
+// and updates highlights for all.  returns false if none found
+func (ed *Editor) FindMatchesRegexp(re *regexp.Regexp) ([]textbuf.Match, bool) {
+	if re == nil {
+		ed.Highlights = nil
+		return nil, false
+	}
+	_, matches := ed.Buf.SearchRegexp(re)
+	return matches, ed.HighlightMatches(matches)
+}
+
+// FindRegexp compiles the given regular expression for finding matches,
+// ignoring case unless useCase is true
+func FindRegexp(find string, useCase bool) (*regexp.Regexp, error) {
+	if !useCase {
+		find = "(?i)" + find
+	}
+	return regexp.Compile(find)
+}
+
+// HighlightMatches sets the highlights to the regions of the given
+// matches, up to ViewMaxFindHighlights -- returns false if there are none
+func (ed *Editor) HighlightMatches(matches []textbuf.Match) bool {
 	if len(matches) == 0 {
 		ed.Highlights = nil
-		return matches, false
+		return false
 	}
 	hi := make([]textbuf.Region, len(matches))
 	for i, m := range matches {
@@ -40,7 +70,7 @@ func (ed *Editor) FindMatches(find string, useCase, lexItems bool) ([]textbuf.Ma
 		}
 	}
 	ed.Highlights = hi
-	return matches, true
+	return true
 }
 
 // MatchFromPos finds the match at or after the given text position -- returns 0, false if none
@@ -66,6 +96,9 @@ type ISearch struct {
 	// pay attention to case in isearch -- triggered by typing an upper-case letter
 	UseCase bool `json:"-" xml:"-"`
 
+	// if true, the search string is a regular expression -- toggled by Alt+R while searching
+	Regexp bool `json:"-" xml:"-"`
+
 	// current search matches
 	Matches []textbuf.Match `json:"-" xml:"-"`
 
@@ -85,13 +118,40 @@ var ViewMaxFindHighlights = 1000
 // PrevISearchString is the previous ISearch string
 var PrevISearchString string
 
-// ISearchMatches finds ISearch matches -- returns true if there are any
+// PrevISearchRegexp is whether the previous ISearch string was a regular expression
+var PrevISearchRegexp bool
+
+// ISearchMatches finds ISearch matches -- returns true if there are any.
+// In Regexp mode, a search string that is not (yet) a valid regular
+// expression has no matches.
 func (ed *Editor) ISearchMatches() bool {
 	got := false
+	if ed.ISearch.Regexp {
+		re, err := FindRegexp(ed.ISearch.Find, ed.ISearch.UseCase)
+		if err != nil || ed.ISearch.Find == "" {
+			re = nil
+		}
+		ed.ISearch.Matches, got = ed.FindMatchesRegexp(re)
+		return got
+	}
 	ed.ISearch.Matches, got = ed.FindMatches(ed.ISearch.Find, ed.ISearch.UseCase, false)
 	return got
 }
 
+// ISearchToggleRegexp toggles whether the interactive search string is
+// a regular expression, and updates the matches from the starting position
+func (ed *Editor) ISearchToggleRegexp() {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	ed.ISearch.Regexp = !ed.ISearch.Regexp
+	if ed.ISearch.Find == "" {
+		ed.ISearchSig()
+		return
+	}
+	ed.ISearchMatches()
+	ed.ISearchNextMatch(ed.ISearch.StartPos)
+}
+
 // ISearchNextMatch finds next match after given cursor position, and highlights
 // it, etc
 func (ed *Editor) ISearchNextMatch(cpos lex.Pos) bool {
@@ -145,6 +205,7 @@ func (ed *Editor) ISearchStart() {
 		} else { // restore prev
 			if PrevISearchString != "" {
 				ed.ISearch.Find = PrevISearchString
+				ed.ISearch.Regexp = PrevISearchRegexp
 				ed.ISearch.UseCase = lex.HasUpperCase(ed.ISearch.Find)
 				ed.ISearchMatches()
 				ed.ISearchNextMatch(ed.CursorPos)
@@ -168,6 +229,10 @@ func (ed *Editor) ISearchStart() {
 // when keys are typed while in search mode
 func (ed *Editor) ISearchKeyInput(kt events.Event) {
 	r := kt.KeyRune()
+	if kt.KeyCode() == key.CodeR && kt.HasAnyModifier(key.Alt) {
+		ed.ISearchToggleRegexp()
+		return
+	}
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
 	// if ed.ISearch.Find == PrevISearchString { // undo starting point
@@ -226,10 +291,12 @@ func (ed *Editor) ISearchCancel() {
 	defer ed.UpdateEndRender(updt)
 	if ed.ISearch.Find != "" {
 		PrevISearchString = ed.ISearch.Find
+		PrevISearchRegexp = ed.ISearch.Regexp
 	}
 	ed.ISearch.PrevPos = ed.ISearch.Pos
 	ed.ISearch.Find = ""
 	ed.ISearch.UseCase = false
+	ed.ISearch.Regexp = false
 	ed.ISearch.On = false
 	ed.ISearch.Pos = -1
 	ed.ISearch.Matches = nil
@@ -242,8 +309,25 @@ func (ed *Editor) ISearchCancel() {
 ///////////////////////////////////////////////////////////////////////////////
 //    Query-Replace
 
+// QReplaceOpts are the options for query-replace
+type QReplaceOpts struct {
+
+	// search only as entire lexically-tagged item boundaries -- key for replacing short local variables like i
+	LexItems bool `json:"-" xml:"-"`
+
+	// the find string is a regular expression, and the replacement can refer to its submatches as $1, ${name} etc
+	Regexp bool `json:"-" xml:"-"`
+
+	// change the case of each replacement to that of the text it replaces, e.g., snake_case or CamelCase
+	PreserveCase bool `json:"-" xml:"-"`
+
+	// only replace within the selected region at the start
+	InSelection bool `json:"-" xml:"-"`
+}
+
 // QReplace holds all the query-replace data
 type QReplace struct {
+	QReplaceOpts
 
 	// if true, in interactive search mode
 	On bool `json:"-" xml:"-"`
@@ -257,8 +341,11 @@ type QReplace struct {
 	// pay attention to case in isearch -- triggered by typing an upper-case letter
 	UseCase bool `json:"-" xml:"-"`
 
-	// search only as entire lexically-tagged item boundaries -- key for replacing short local variables like i
-	LexItems bool `json:"-" xml:"-"`
+	// compiled regular expression for Find, in Regexp mode
+	Re *regexp.Regexp `json:"-" xml:"-"`
+
+	// region to replace within, for InSelection
+	Region textbuf.Region `json:"-" xml:"-"`
 
 	// current search matches
 	Matches []textbuf.Match `json:"-" xml:"-"`
@@ -279,14 +366,19 @@ var PrevQReplaceFinds []string
 // PrevQReplaceRepls are the previous QReplace strings
 var PrevQReplaceRepls []string
 
+// QReplaceMaxPreview is the maximum number of replacements listed in the
+// preview in the query-replace dialog
+var QReplaceMaxPreview = 10
+
 // QReplaceSig sends the signal that QReplace is updated
 func (ed *Editor) QReplaceSig() {
 	// ed.ViewSig.Emit(ed.This(), int64(ViewQReplace), ed.CursorPos)
 }
 
 // QReplaceDialog adds to the given dialog a display prompting the user for
-// query-replace items, with choosers with history
-func QReplaceDialog(dlg *gi.Dialog, find string, lexitems bool) *gi.Dialog {
+// query-replace items, with choosers with history, and a label
+// for a preview of the replacements
+func QReplaceDialog(dlg *gi.Dialog, find string, opts QReplaceOpts) *gi.Dialog {
 	tff := gi.NewChooser(dlg.Scene, "find")
 	tff.Editable = true
 	tff.SetStretchMaxWidth()
@@ -306,14 +398,30 @@ func QReplaceDialog(dlg *gi.Dialog, find string, lexitems bool) *gi.Dialog {
 
 	lb := gi.NewSwitch(dlg.Scene, "lexb")
 	lb.SetText("Lexical Items")
-	lb.SetState(lexitems, states.Checked)
+	lb.SetState(opts.LexItems, states.Checked)
 	lb.Tooltip = "search matches entire lexically tagged items -- good for finding local variable names like 'i' and not matching everything"
 
+	rb := gi.NewSwitch(dlg.Scene, "regexp")
+	rb.SetText("Regexp")
+	rb.SetState(opts.Regexp, states.Checked)
+	rb.Tooltip = "find is a regular expression, and the replacement can refer to its submatches as $1, ${name} etc"
+
+	cb := gi.NewSwitch(dlg.Scene, "case")
+	cb.SetText("Preserve Case")
+	cb.SetState(opts.PreserveCase, states.Checked)
+	cb.Tooltip = "change the case of each replacement to that of the text it replaces, e.g., snake_case, CamelCase, or UPPER"
+
+	sb := gi.NewSwitch(dlg.Scene, "sel")
+	sb.SetText("In Selection")
+	sb.SetState(opts.InSelection, states.Checked)
+	sb.Tooltip = "only replace within the region that was selected"
+
+	gi.NewLabel(dlg.Scene, "preview")
 	return dlg
 }
 
 // QReplaceDialogValues gets the string values
-func QReplaceDialogValues(dlg *gi.Dialog) (find, repl string, lexItems bool) {
+func QReplaceDialogValues(dlg *gi.Dialog) (find, repl string, opts QReplaceOpts) {
 	sc := dlg.Stage.Scene
 	tff := sc.ChildByName("find", 1).(*gi.Chooser)
 	if tf, found := tff.TextField(); found {
@@ -323,36 +431,119 @@ func QReplaceDialogValues(dlg *gi.Dialog) (find, repl string, lexItems bool) {
 	if tf, found := tfr.TextField(); found {
 		repl = tf.Text()
 	}
-	lb := sc.ChildByName("lexb", 3).(*gi.Switch)
-	lexItems = lb.StateIs(states.Checked)
+	opts.LexItems = sc.ChildByName("lexb", 3).(*gi.Switch).StateIs(states.Checked)
+	opts.Regexp = sc.ChildByName("regexp", 4).(*gi.Switch).StateIs(states.Checked)
+	opts.PreserveCase = sc.ChildByName("case", 5).(*gi.Switch).StateIs(states.Checked)
+	opts.InSelection = sc.ChildByName("sel", 6).(*gi.Switch).StateIs(states.Checked)
 	return
 }
 
 // QReplacePrompt is an emacs-style query-replace mode -- this starts the process, prompting
-// user for items to search etc
+// user for items to search etc.  A selection within one line is the default find
+// string, while a larger one is the default region to replace within.
+// The matches and their replacements are previewed as the values are changed.
 func (ed *Editor) QReplacePrompt() {
 	find := ""
+	opts := ed.QReplace.QReplaceOpts
+	opts.InSelection = false
+	ed.QReplace.Region = textbuf.RegionNil
 	if ed.HasSelection() {
-		find = string(ed.Selection().ToBytes())
+		if ed.SelectReg.Start.Ln == ed.SelectReg.End.Ln {
+			find = string(ed.Selection().ToBytes())
+		} else {
+			opts.InSelection = true
+			ed.QReplace.Region = ed.SelectReg
+		}
 	}
 	dlg := QReplaceDialog(gi.NewDialog(ed).Title("Query-Replace").
 		Prompt("Enter strings for find and replace, then select Ok -- with dialog dismissed press <b>y</b> to replace current match, <b>n</b> to skip, <b>Enter</b> or <b>q</b> to quit, <b>!</b> to replace-all remaining"),
-		find, ed.QReplace.LexItems)
+		find, opts)
+	sc := dlg.Stage.Scene
+	plb := sc.ChildByName("preview", 7).(*gi.Label)
+	preview := func(e events.Event) {
+		find, repl, opts := QReplaceDialogValues(dlg)
+		updt := plb.UpdateStart()
+		plb.SetText(ed.QReplacePreview(find, repl, opts))
+		plb.UpdateEndLayout(updt)
+	}
+	for _, nm := range []string{"find", "repl"} {
+		if tf, found := sc.ChildByName(nm, 1).(*gi.Chooser).TextField(); found {
+			tf.OnChange(preview)
+		}
+	}
+	for _, nm := range []string{"lexb", "regexp", "case", "sel"} {
+		sc.ChildByName(nm, 3).(*gi.Switch).OnChange(preview)
+	}
 	dlg.OnAccept(func(e events.Event) {
-		find, repl, lexItems := QReplaceDialogValues(dlg)
-		ed.QReplaceStart(find, repl, lexItems)
+		find, repl, opts := QReplaceDialogValues(dlg)
+		ed.QReplaceStart(find, repl, opts)
 	}).Run()
 }
 
-// QReplaceStart starts query-replace using given find, replace strings
-func (ed *Editor) QReplaceStart(find, repl string, lexItems bool) {
-	ed.QReplace.On = true
+// QReplaceSet sets the query-replace find and replace strings and options,
+// compiling the find string if it is a regular expression
+func (ed *Editor) QReplaceSet(find, repl string, opts QReplaceOpts) error {
+	ed.QReplace.QReplaceOpts = opts
 	ed.QReplace.Find = find
 	ed.QReplace.Replace = repl
-	ed.QReplace.LexItems = lexItems
-	ed.QReplace.StartPos = ed.CursorPos
 	ed.QReplace.UseCase = lex.HasUpperCase(find)
 	ed.QReplace.Matches = nil
+	ed.QReplace.Re = nil
+	if !opts.Regexp || find == "" {
+		return nil
+	}
+	re, err := FindRegexp(find, ed.QReplace.UseCase)
+	if err != nil {
+		return fmt.Errorf("texteditor.QReplace: invalid regexp: %w", err)
+	}
+	ed.QReplace.Re = re
+	return nil
+}
+
+// QReplacePreview highlights all of the matches for the given query-replace
+// values, and returns a preview of the replacements, listing the first
+// QReplaceMaxPreview of them
+func (ed *Editor) QReplacePreview(find, repl string, opts QReplaceOpts) string {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	if err := ed.QReplaceSet(find, repl, opts); err != nil {
+		ed.Highlights = nil
+		return err.Error()
+	}
+	if !ed.QReplaceMatches() {
+		return "no matches"
+	}
+	nm := len(ed.QReplace.Matches)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d matches:", nm)
+	for mi := 0; mi < min(nm, QReplaceMaxPreview); mi++ {
+		reg, rep, ok := ed.QReplaceText(mi)
+		if !ok {
+			continue
+		}
+		cur := ""
+		if tbe := ed.Buf.Region(reg.Start, reg.End); tbe != nil {
+			cur = string(tbe.ToBytes())
+		}
+		fmt.Fprintf(&b, "<br>%d: <mark>%s</mark> -> %s", reg.Start.Ln+1, HTMLEscapeBytes([]byte(cur)), HTMLEscapeBytes([]byte(rep)))
+	}
+	if nm > QReplaceMaxPreview {
+		b.WriteString("<br>...")
+	}
+	return b.String()
+}
+
+// QReplaceStart starts query-replace using given find, replace strings
+// and options
+func (ed *Editor) QReplaceStart(find, repl string, opts QReplaceOpts) {
+	if err := ed.QReplaceSet(find, repl, opts); err != nil {
+		ed.QReplace.On = false
+		ed.Highlights = nil
+		gi.NewDialog(ed).Title("Query-Replace").Prompt(err.Error()).Ok().Run()
+		return
+	}
+	ed.QReplace.On = true
+	ed.QReplace.StartPos = ed.CursorPos
 	ed.QReplace.Pos = -1
 
 	gi.StringsInsertFirstUnique(&PrevQReplaceFinds, find, gi.Prefs.Params.SavedPathsMax)
@@ -364,11 +555,25 @@ func (ed *Editor) QReplaceStart(find, repl string, lexItems bool) {
 	ed.QReplaceSig()
 }
 
-// QReplaceMatches finds QReplace matches -- returns true if there are any
+// QReplaceMatches finds QReplace matches, within the Region for InSelection
+// -- returns true if there are any
 func (ed *Editor) QReplaceMatches() bool {
-	got := false
-	ed.QReplace.Matches, got = ed.FindMatches(ed.QReplace.Find, ed.QReplace.UseCase, ed.QReplace.LexItems)
-	return got
+	qr := &ed.QReplace
+	if qr.Regexp {
+		qr.Matches, _ = ed.FindMatchesRegexp(qr.Re)
+	} else {
+		qr.Matches, _ = ed.FindMatches(qr.Find, qr.UseCase, qr.LexItems)
+	}
+	if qr.InSelection {
+		ms := qr.Matches[:0]
+		for _, m := range qr.Matches {
+			if !m.Reg.Start.IsLess(qr.Region.Start) && !qr.Region.End.IsLess(m.Reg.End) {
+				ms = append(ms, m)
+			}
+		}
+		qr.Matches = ms
+	}
+	return ed.HighlightMatches(qr.Matches)
 }
 
 // QReplaceNextMatch finds next match using, QReplace.Pos and highlights it, etc
@@ -401,35 +606,71 @@ func (ed *Editor) QReplaceSelectMatch(midx int) {
 	ed.QReplaceSig()
 }
 
+// QReplaceText returns the current region of the match at given match index,
+// and the text to replace it with: the Replace string with any submatches
+// expanded in Regexp mode, and changed to the case of the match for
+// PreserveCase.  Returns false if the match is no longer there.
+func (ed *Editor) QReplaceText(midx int) (textbuf.Region, string, bool) {
+	m := ed.QReplace.Matches[midx]
+	reg := ed.Buf.AdjustReg(m.Reg)
+	if reg == textbuf.RegionNil { // deleted
+		return reg, "", false
+	}
+	rep := ed.QReplace.Replace
+	if ed.QReplace.Regexp {
+		var ok bool
+		rep, ok = textbuf.ExpandRegexp(ed.QReplace.Re, ed.Buf.BytesLine(reg.Start.Ln), reg.Start.Ch, rep)
+		if !ok {
+			return reg, "", false
+		}
+	}
+	if ed.QReplace.PreserveCase {
+		if tbe := ed.Buf.Region(reg.Start, reg.End); tbe != nil {
+			rep = textbuf.ReCaseAs(rep, string(tbe.ToBytes()))
+		}
+	}
+	return reg, rep, true
+}
+
 // QReplaceReplace replaces at given match index (e.g., ed.QReplace.Pos)
 func (ed *Editor) QReplaceReplace(midx int) {
 	nm := len(ed.QReplace.Matches)
 	if midx >= nm {
 		return
 	}
-	m := ed.QReplace.Matches[midx]
-	rep := ed.QReplace.Replace
-	reg := ed.Buf.AdjustReg(m.Reg)
+	reg, rep, ok := ed.QReplaceText(midx)
+	if !ok {
+		return
+	}
 	pos := reg.Start
 	// last arg is matchCase, only if not using case to match and rep is also lower case
-	matchCase := !ed.QReplace.UseCase && !lex.HasUpperCase(rep)
+	matchCase := !ed.QReplace.Regexp && !ed.QReplace.PreserveCase && !ed.QReplace.UseCase && !lex.HasUpperCase(rep)
 	ed.Buf.ReplaceText(reg.Start, reg.End, pos, rep, EditSignal, matchCase)
-	ed.Highlights[midx] = textbuf.RegionNil
+	if midx < len(ed.Highlights) {
+		ed.Highlights[midx] = textbuf.RegionNil
+	}
 	ed.SetCursor(pos)
 	ed.SavePosHistory(ed.CursorPos)
 	ed.ScrollCursorToCenterIfHidden()
 	ed.QReplaceSig()
 }
 
-// QReplaceReplaceAll replaces all remaining from index
+// QReplaceReplaceAll replaces all remaining from index, as one group of
+// edits that is undone together
 func (ed *Editor) QReplaceReplaceAll(midx int) {
 	nm := len(ed.QReplace.Matches)
 	if midx >= nm {
 		return
 	}
+	autoSave := ed.Buf.BatchUpdateStart()
+	ed.Buf.Undos.Mu.Lock()
+	upos := ed.Buf.Undos.Pos
+	ed.Buf.Undos.Mu.Unlock()
 	for mi := midx; mi < nm; mi++ {
 		ed.QReplaceReplace(mi)
 	}
+	ed.Buf.Undos.SetGroupFrom(upos)
+	ed.Buf.BatchUpdateEnd(autoSave)
 }
 
 // QReplaceKeyInput is an emacs-style interactive search mode -- this is called
@@ -483,6 +724,8 @@ func (ed *Editor) EscPressed() {
 	case ed.QReplace.On:
 		ed.QReplaceCancel()
 		ed.SetCursorShow(ed.ISearch.StartPos)
+	case ed.HasMultiCursors():
+		ed.ClearCursors()
 	case ed.HasSelection():
 		ed.SelectReset()
 	default:
@@ -0,0 +1,283 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image"
+	"slices"
+	"strings"
+
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/parse"
+)
+
+// FoldRegions returns the regions of lines that can be folded, computed
+// from the parse tree (AST) of the pi parser if it is in use, and otherwise
+// from the structure of the brackets in the text, or the indentation
+// if there are no multi-line brackets.  tabSz is the tab size for indentation.
+// The regions are only recomputed after the text has been edited or parsed
+// again, and otherwise a copy of the previous regions is returned.
+func (tb *Buf) FoldRegions(tabSz int) []textbuf.Fold {
+	tb.foldsMu.Lock()
+	defer tb.foldsMu.Unlock()
+	if tb.foldsStale.Swap(false) || tabSz != tb.foldTabSz {
+		tb.foldRegs = tb.foldRegionsImpl(tabSz)
+		tb.foldTabSz = tabSz
+	}
+	return slices.Clone(tb.foldRegs)
+}
+
+// foldRegionsImpl computes the regions of lines that can be folded,
+// for FoldRegions
+func (tb *Buf) foldRegionsImpl(tabSz int) []textbuf.Fold {
+	if tb.Hi.UsingPi() {
+		var fds []textbuf.Fold
+		tb.MarkupMu.RLock()
+		pfs := tb.PiState.Done()
+		astFolds(&pfs.Ast, &fds)
+		tb.MarkupMu.RUnlock()
+		if len(fds) > 0 {
+			tb.LinesMu.RLock()
+			for i, fd := range fds { // keep lines with just a closing bracket visible
+				if fd.EdLn < tb.NLines {
+					if cl := strings.TrimSpace(string(tb.Rope.Line(fd.EdLn))); len(cl) == 1 && strings.ContainsAny(cl, "})]") {
+						fds[i].EdLn--
+					}
+				}
+			}
+			tb.LinesMu.RUnlock()
+			return textbuf.SortFolds(fds)
+		}
+	}
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	lns := tb.Rope.Lines(0, tb.NLines)
+	if fds := textbuf.FoldsBrace(lns); len(fds) > 0 {
+		return fds
+	}
+	return textbuf.FoldsIndent(lns, tabSz)
+}
+
+// astFolds adds a fold for each node in the given AST that spans
+// multiple lines
+func astFolds(ast *parse.Ast, fds *[]textbuf.Fold) {
+	if ast.SrcReg.Ed.Ln > ast.SrcReg.St.Ln {
+		*fds = append(*fds, textbuf.Fold{StLn: ast.SrcReg.St.Ln, EdLn: ast.SrcReg.Ed.Ln})
+	}
+	for _, k := range ast.Kids {
+		if ak, ok := k.(*parse.Ast); ok {
+			astFolds(ak, fds)
+		}
+	}
+}
+
+// UpdateFoldRegions updates the FoldRegs regions that can be folded, from the Buf
+func (ed *Editor) UpdateFoldRegions() {
+	if ed.Buf == nil {
+		ed.FoldRegs = nil
+		return
+	}
+	ed.FoldRegs = ed.Buf.FoldRegions(ed.Styles.Text.TabSize)
+}
+
+// IsLineHidden returns true if given line is hidden in a folded region
+func (ed *Editor) IsLineHidden(ln int) bool {
+	return ln >= 0 && ln < len(ed.hiddenLns) && ed.hiddenLns[ln]
+}
+
+// SetFolded sets the regions of lines that are folded (hidden),
+// and updates the record of the lines that they hide.
+func (ed *Editor) SetFolded(fds []textbuf.Fold) {
+	ed.Folded = textbuf.SortFolds(fds)
+	ed.hiddenLns = ed.hiddenLns[:0]
+	for _, fd := range ed.Folded {
+		if n := fd.EdLn + 1; n > len(ed.hiddenLns) {
+			ed.hiddenLns = append(ed.hiddenLns, make([]bool, n-len(ed.hiddenLns))...)
+		}
+		for ln := fd.StLn + 1; ln <= fd.EdLn; ln++ {
+			ed.hiddenLns[ln] = true
+		}
+	}
+}
+
+// IsFoldHeader returns true if given line is the header of a folded
+// region, and it is not itself hidden.
+func (ed *Editor) IsFoldHeader(ln int) bool {
+	return slices.ContainsFunc(ed.Folded, func(fd textbuf.Fold) bool { return fd.StLn == ln }) && !ed.IsLineHidden(ln)
+}
+
+// IsFoldable returns true if given line is the header of a region
+// that can be folded
+func (ed *Editor) IsFoldable(ln int) bool {
+	return slices.ContainsFunc(ed.FoldRegs, func(fd textbuf.Fold) bool { return fd.StLn == ln })
+}
+
+// VisibleLine returns the nearest line that is not hidden in a folded
+// region, starting at given line and moving past the end of any
+// folded region for dir > 0, or to its header for dir <= 0
+// (or if there are no lines after it).
+func (ed *Editor) VisibleLine(ln, dir int) int {
+	if !ed.IsLineHidden(ln) {
+		return ln
+	}
+	if dir > 0 {
+		nl := ln
+		for ed.IsLineHidden(nl) {
+			nl++
+		}
+		if nl < ed.NLines {
+			return nl
+		}
+	}
+	for ed.IsLineHidden(ln) {
+		ln--
+	}
+	return ln
+}
+
+// FoldLine folds the innermost region that can be folded and that contains
+// given line (as its header or in its body), if not already folded.
+// The cursor is moved to the header if it would be hidden.
+func (ed *Editor) FoldLine(ln int) {
+	if len(ed.FoldRegs) == 0 {
+		ed.UpdateFoldRegions()
+	}
+	idx := -1
+	for i, fd := range ed.FoldRegs {
+		if fd.StLn > ln {
+			break
+		}
+		if (fd.StLn == ln || fd.Contains(ln)) && !slices.Contains(ed.Folded, fd) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndLayout(updt)
+	fd := ed.FoldRegs[idx]
+	ed.SetFolded(append(ed.Folded, fd))
+	if ed.IsLineHidden(ed.CursorPos.Ln) {
+		ed.SetCursorShow(lex.Pos{Ln: ed.VisibleLine(ed.CursorPos.Ln, -1)})
+	}
+}
+
+// UnfoldLine unfolds all of the folded regions that hide given line,
+// or that have it as their header.  Returns true if any were unfolded.
+func (ed *Editor) UnfoldLine(ln int) bool {
+	n := len(ed.Folded)
+	ed.SetFolded(slices.DeleteFunc(ed.Folded, func(fd textbuf.Fold) bool {
+		return fd.StLn == ln || fd.Contains(ln)
+	}))
+	if len(ed.Folded) == n {
+		return false
+	}
+	ed.SetNeedsLayout()
+	return true
+}
+
+// ToggleFold unfolds given line if it is a folded header, and
+// otherwise folds the region at it.
+func (ed *Editor) ToggleFold(ln int) {
+	if ed.IsFoldHeader(ln) {
+		ed.UnfoldLine(ln)
+		return
+	}
+	ed.FoldLine(ln)
+}
+
+// Fold folds the region at the cursor
+func (ed *Editor) Fold() {
+	ed.FoldLine(ed.CursorPos.Ln)
+}
+
+// Unfold unfolds the folded region(s) at the cursor
+func (ed *Editor) Unfold() {
+	ed.UnfoldLine(ed.CursorPos.Ln)
+}
+
+// UnfoldAll unfolds all of the folded regions
+func (ed *Editor) UnfoldAll() {
+	if len(ed.Folded) == 0 {
+		return
+	}
+	ed.SetFolded(nil)
+	ed.SetNeedsLayout()
+}
+
+// AdjustFolds updates the folded and foldable regions for given edit
+// to the text.  Folds whose header line was deleted are removed.
+func (ed *Editor) AdjustFolds(tbe *textbuf.Edit) {
+	if tbe == nil || tbe.Reg.Start.Ln == tbe.Reg.End.Ln {
+		return
+	}
+	adj := func(fds []textbuf.Fold) []textbuf.Fold {
+		for i := range fds {
+			fd := &fds[i]
+			st := tbe.AdjustPos(lex.Pos{Ln: fd.StLn}, textbuf.AdjustPosDelErr)
+			if st == lex.PosErr {
+				fd.EdLn = -1 // removed by SortFolds
+				continue
+			}
+			fd.StLn = st.Ln
+			fd.EdLn = tbe.AdjustPos(lex.Pos{Ln: fd.EdLn}, textbuf.AdjustPosDelStart).Ln
+		}
+		return textbuf.SortFolds(fds)
+	}
+	ed.SetFolded(adj(ed.Folded))
+	ed.FoldRegs = adj(ed.FoldRegs)
+}
+
+// FoldMarkerOff returns the horizontal offset, relative to the editor, of
+// the column of the line number gutter where the fold markers are, after
+// the line numbers, which extends to LineNoOff
+func (ed *Editor) FoldMarkerOff() float32 {
+	sty := &ed.Styles
+	return sty.BoxSpace().Pos().X + float32(ed.LineNoDigs+1)*sty.Font.Face.Metrics.Ch
+}
+
+// FoldMarkerAt returns the line whose fold marker is at given point,
+// relative to the editor (see PointToRelPos), and false if the point is
+// not in the fold marker column, or the line cannot be folded or unfolded.
+// Clicks on the line numbers themselves do not fold, and are left to
+// other handlers (e.g., applying a diff in a DiffTextView).
+func (ed *Editor) FoldMarkerAt(pt image.Point) (int, bool) {
+	if !ed.HasLineNos() || ed.NLines == 0 {
+		return -1, false
+	}
+	if x := float32(pt.X); x < ed.FoldMarkerOff() || x >= ed.LineNoOff {
+		return -1, false
+	}
+	ln := ed.PixelToCursor(pt).Ln
+	if !ed.IsFoldHeader(ln) && !ed.IsFoldable(ln) {
+		return -1, false
+	}
+	return ln, true
+}
+
+// RenderFoldMarker renders the fold marker for given line in the line
+// number gutter, in the column at FoldMarkerOff, if the line is a folded
+// header or can be folded -- called within context of RenderLineNo,
+// with the position of the line number.
+func (ed *Editor) RenderFoldMarker(ln int, pos mat32.Vec2) {
+	mark := ""
+	switch {
+	case ed.IsFoldHeader(ln):
+		mark = "▸"
+	case ed.IsFoldable(ln):
+		mark = "▾"
+	default:
+		return
+	}
+	sty := &ed.Styles
+	fst := sty.FontRender()
+	fst.BackgroundColor.SetSolid(nil)
+	ed.LineNoRender.SetString(mark, fst, &sty.UnContext, &sty.Text, true, 0, 0)
+	pos.X = float32(ed.ScBBox.Min.X) + ed.FoldMarkerOff()
+	ed.LineNoRender.Render(&ed.Sc.RenderState, pos)
+}
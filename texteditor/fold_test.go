@@ -0,0 +1,117 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/states"
+	"goki.dev/goosi/events"
+	"goki.dev/pi/v2/lex"
+)
+
+func TestFoldRegionsCache(t *testing.T) {
+	tb := NewBuf()
+	tb.SetText([]byte("a {\n\tb\n}\nc\n"))
+	fds := tb.FoldRegions(4)
+	if want := []textbuf.Fold{{StLn: 0, EdLn: 1}}; !slices.Equal(fds, want) {
+		t.Fatalf("FoldRegions = %v, want %v", fds, want)
+	}
+	fds[0].EdLn = 5 // the cache is not affected by changes to the result
+	if fds := tb.FoldRegions(4); fds[0].EdLn != 1 {
+		t.Errorf("cached FoldRegions = %v", fds)
+	}
+	if tb.foldsStale.Load() {
+		t.Errorf("folds are stale without any edits")
+	}
+	tb.InsertText(lex.Pos{Ln: 3, Ch: 1}, []byte(" {\n\td\n}"), EditNoSignal)
+	if !tb.foldsStale.Load() {
+		t.Errorf("folds are not stale after an edit")
+	}
+	want := []textbuf.Fold{{StLn: 0, EdLn: 1}, {StLn: 3, EdLn: 4}}
+	if fds := tb.FoldRegions(4); !slices.Equal(fds, want) {
+		t.Errorf("FoldRegions after edit = %v, want %v", fds, want)
+	}
+}
+
+func TestFoldedHidden(t *testing.T) {
+	ed := &Editor{}
+	ed.NLines = 12
+	ed.SetFolded([]textbuf.Fold{{StLn: 6, EdLn: 11}, {StLn: 1, EdLn: 3}, {StLn: 2, EdLn: 3}})
+	var hidden []int
+	for ln := -1; ln <= ed.NLines; ln++ {
+		if ed.IsLineHidden(ln) {
+			hidden = append(hidden, ln)
+		}
+	}
+	if want := []int{2, 3, 7, 8, 9, 10, 11}; !slices.Equal(hidden, want) {
+		t.Errorf("hidden lines = %v, want %v", hidden, want)
+	}
+	for _, tt := range []struct{ ln, dir, want int }{
+		{0, 1, 0}, {3, 1, 4}, {3, -1, 1}, {2, 1, 4}, {8, 1, 6}, {8, -1, 6},
+	} {
+		if got := ed.VisibleLine(tt.ln, tt.dir); got != tt.want {
+			t.Errorf("VisibleLine(%d, %d) = %d, want %d", tt.ln, tt.dir, got, tt.want)
+		}
+	}
+
+	// an edit that deletes lines 2-4 removes the first two folds,
+	// and moves the last one up
+	tbe := &textbuf.Edit{Reg: textbuf.NewRegion(1, 1, 4, 0), Delete: true}
+	ed.AdjustFolds(tbe)
+	if want := []textbuf.Fold{{StLn: 3, EdLn: 8}}; !slices.Equal(ed.Folded, want) {
+		t.Errorf("Folded after edit = %v, want %v", ed.Folded, want)
+	}
+	if ed.IsLineHidden(2) || !ed.IsLineHidden(4) || ed.IsLineHidden(9) {
+		t.Errorf("hidden lines after edit are out of date: %v", ed.hiddenLns)
+	}
+	ed.SetFolded(nil)
+	if ed.IsLineHidden(4) {
+		t.Errorf("line 4 is hidden after unfolding")
+	}
+}
+
+func TestFoldMarkerClick(t *testing.T) {
+	sc := gi.NewScene("fold-click")
+	gi.NewMainStage(gi.WindowStage, sc, nil)
+	sc.Geom.Size = image.Point{800, 600}
+	tb := NewBuf()
+	tb.Opts.LineNos = true
+	tb.SetText([]byte("a {\n\tb\n}\nc\n"))
+	ed := NewEditor(sc, "ed")
+	ed.SetBuf(tb)
+	ed.SetState(true, states.ReadOnly) // folding works in read-only views
+	sc.ConfigScene()
+	sc.ApplyStyleScene()
+	sc.LayoutScene()
+	sc.LayoutScene() // the lines are laid out once the editor has its size
+	ed.UpdateFoldRegions()
+	if !ed.HasLineNos() || ed.FoldMarkerOff() >= ed.LineNoOff {
+		t.Fatalf("fold marker column at %g, line numbers end at %g", ed.FoldMarkerOff(), ed.LineNoOff)
+	}
+	click := func(x float32, ln int) bool {
+		y := ed.CharStartPos(lex.Pos{Ln: ln}).Y + ed.LineHeight/2
+		e := events.NewMouse(events.MouseDown, events.Left, image.Point{ed.ScBBox.Min.X + int(x), int(y)}, 0)
+		e.SetLocalOff(image.Point{})
+		return ed.HandleFoldClick(e)
+	}
+	mx := ed.FoldMarkerOff() + 1
+	if click(1, 0) || click(ed.LineNoOff+1, 0) || click(mx, 3) {
+		t.Errorf("click on a line number, the text or a line that cannot be folded toggled folding")
+	}
+	if len(ed.Folded) != 0 {
+		t.Fatalf("folded = %v", ed.Folded)
+	}
+	if !click(mx, 0) || !ed.IsFoldHeader(0) || !ed.IsLineHidden(1) {
+		t.Errorf("click on the fold marker did not fold: %v", ed.Folded)
+	}
+	if !click(mx, 0) || len(ed.Folded) != 0 {
+		t.Errorf("second click on the fold marker did not unfold: %v", ed.Folded)
+	}
+}
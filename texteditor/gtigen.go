@@ -5,6 +5,7 @@ package texteditor
 import (
 	"goki.dev/colors"
 	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
 	"goki.dev/girl/units"
 	"goki.dev/gti"
 	"goki.dev/ki/v2"
@@ -12,6 +13,336 @@ import (
 	"goki.dev/ordmap"
 )
 
+// DiagnosticsViewType is the [gti.Type] for [DiagnosticsView]
+var DiagnosticsViewType = gti.AddType(&gti.Type{
+	Name:       "goki.dev/gi/v2/texteditor.DiagnosticsView",
+	ShortName:  "texteditor.DiagnosticsView",
+	IDName:     "diagnostics-view",
+	Doc:        "DiagnosticsView is a panel listing the diagnostics of all of the buffers\nthat have any (see DiagnosticsBufs), one per line, as file:line:col:\nseverity: message (source), which is updated whenever they change.\nClicking on a line, or pressing Enter on it, goes to the diagnostic in\nthe first editor viewing its buffer, or calls the OpenFunc if there is none.",
+	Directives: gti.Directives{},
+	Fields: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Severity", &gti.Field{Name: "Severity", Type: "goki.dev/gi/v2/texteditor/textbuf.Severities", LocalType: "textbuf.Severities", Doc: "only diagnostics that are at least as severe as this are listed", Directives: gti.Directives{}, Tag: ""}},
+		{"OpenFunc", &gti.Field{Name: "OpenFunc", Type: "func(tb *goki.dev/gi/v2/texteditor.Buf, reg goki.dev/gi/v2/texteditor/textbuf.Region)", LocalType: "func(tb *Buf, reg textbuf.Region)", Doc: "function called to go to a diagnostic in a buffer that no editor is\nviewing, e.g., to open the buffer in a new tab of an app", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"Items", &gti.Field{Name: "Items", Type: "[]goki.dev/gi/v2/texteditor.DiagnosticItem", LocalType: "[]DiagnosticItem", Doc: "the diagnostics listed, one per line of the ListBuf", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"ListBuf", &gti.Field{Name: "ListBuf", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "the buffer for the list", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+	}),
+	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Frame", &gti.Field{Name: "Frame", Type: "goki.dev/gi/v2/gi.Frame", LocalType: "gi.Frame", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+	}),
+	Methods:  ordmap.Make([]ordmap.KeyVal[string, *gti.Method]{}),
+	Instance: &DiagnosticsView{},
+})
+
+// NewDiagnosticsView adds a new [DiagnosticsView] with the given name
+// to the given parent. If the name is unspecified, it defaults
+// to the ID (kebab-case) name of the type, plus the
+// [ki.Ki.NumLifetimeChildren] of the given parent.
+func NewDiagnosticsView(par ki.Ki, name ...string) *DiagnosticsView {
+	return par.NewChild(DiagnosticsViewType, name...).(*DiagnosticsView)
+}
+
+// KiType returns the [*gti.Type] of [DiagnosticsView]
+func (t *DiagnosticsView) KiType() *gti.Type {
+	return DiagnosticsViewType
+}
+
+// New returns a new [*DiagnosticsView] value
+func (t *DiagnosticsView) New() ki.Ki {
+	return &DiagnosticsView{}
+}
+
+// SetSeverity sets the [DiagnosticsView.Severity]:
+// only diagnostics that are at least as severe as this are listed
+func (t *DiagnosticsView) SetSeverity(v textbuf.Severities) *DiagnosticsView {
+	t.Severity = v
+	return t
+}
+
+// SetOpenFunc sets the [DiagnosticsView.OpenFunc]:
+// function called to go to a diagnostic in a buffer that no editor is
+// viewing, e.g., to open the buffer in a new tab of an app
+func (t *DiagnosticsView) SetOpenFunc(v func(tb *Buf, reg textbuf.Region)) *DiagnosticsView {
+	t.OpenFunc = v
+	return t
+}
+
+// SetTooltip sets the [DiagnosticsView.Tooltip]
+func (t *DiagnosticsView) SetTooltip(v string) *DiagnosticsView {
+	t.Tooltip = v
+	return t
+}
+
+// SetClass sets the [DiagnosticsView.Class]
+func (t *DiagnosticsView) SetClass(v string) *DiagnosticsView {
+	t.Class = v
+	return t
+}
+
+// SetCustomContextMenu sets the [DiagnosticsView.CustomContextMenu]
+func (t *DiagnosticsView) SetCustomContextMenu(v func(m *gi.Scene)) *DiagnosticsView {
+	t.CustomContextMenu = v
+	return t
+}
+
+// SetLayout sets the [DiagnosticsView.Lay]
+func (t *DiagnosticsView) SetLayout(v gi.Layouts) *DiagnosticsView {
+	t.Lay = v
+	return t
+}
+
+// SetSpacing sets the [DiagnosticsView.Spacing]
+func (t *DiagnosticsView) SetSpacing(v units.Value) *DiagnosticsView {
+	t.Spacing = v
+	return t
+}
+
+// SetStackTop sets the [DiagnosticsView.StackTop]
+func (t *DiagnosticsView) SetStackTop(v int) *DiagnosticsView {
+	t.StackTop = v
+	return t
+}
+
+// SetStripes sets the [DiagnosticsView.Stripes]
+func (t *DiagnosticsView) SetStripes(v gi.Stripes) *DiagnosticsView {
+	t.Stripes = v
+	return t
+}
+
+// DiffViewType is the [gti.Type] for [DiffView]
+var DiffViewType = gti.AddType(&gti.Type{
+	Name:       "goki.dev/gi/v2/texteditor.DiffView",
+	ShortName:  "texteditor.DiffView",
+	IDName:     "diff-view",
+	Doc:        "DiffView presents two side-by-side [DiffTextView]s showing the differences\nbetween two files (represented as lines of strings), with the lines of\neach aligned, and a toolbar to go to each of the diff regions, apply\nthe changes in a region from one file to the other, and save the result.",
+	Directives: gti.Directives{},
+	Fields: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"FileA", &gti.Field{Name: "FileA", Type: "string", LocalType: "string", Doc: "first file name being compared", Directives: gti.Directives{}, Tag: ""}},
+		{"FileB", &gti.Field{Name: "FileB", Type: "string", LocalType: "string", Doc: "second file name being compared", Directives: gti.Directives{}, Tag: ""}},
+		{"RevA", &gti.Field{Name: "RevA", Type: "string", LocalType: "string", Doc: "revision for first file, if relevant", Directives: gti.Directives{}, Tag: ""}},
+		{"RevB", &gti.Field{Name: "RevB", Type: "string", LocalType: "string", Doc: "revision for second file, if relevant", Directives: gti.Directives{}, Tag: ""}},
+		{"BufA", &gti.Field{Name: "BufA", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for A", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"BufB", &gti.Field{Name: "BufB", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for B", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"Diffs", &gti.Field{Name: "Diffs", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "the diff records", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"AlignD", &gti.Field{Name: "AlignD", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "aligned diffs records diff for aligned lines", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"EditA", &gti.Field{Name: "EditA", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "edit diffs records aligned diffs with edits applied", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"EditB", &gti.Field{Name: "EditB", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "edit diffs records aligned diffs with edits applied", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"UndoA", &gti.Field{Name: "UndoA", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "undo diffs records aligned diffs with edits applied", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"UndoB", &gti.Field{Name: "UndoB", Type: "goki.dev/gi/v2/texteditor/textbuf.Diffs", LocalType: "textbuf.Diffs", Doc: "undo diffs records aligned diffs with edits applied", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+	}),
+	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Frame", &gti.Field{Name: "Frame", Type: "goki.dev/gi/v2/gi.Frame", LocalType: "gi.Frame", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+	}),
+	Methods:  ordmap.Make([]ordmap.KeyVal[string, *gti.Method]{}),
+	Instance: &DiffView{},
+})
+
+// NewDiffView adds a new [DiffView] with the given name
+// to the given parent. If the name is unspecified, it defaults
+// to the ID (kebab-case) name of the type, plus the
+// [ki.Ki.NumLifetimeChildren] of the given parent.
+func NewDiffView(par ki.Ki, name ...string) *DiffView {
+	return par.NewChild(DiffViewType, name...).(*DiffView)
+}
+
+// KiType returns the [*gti.Type] of [DiffView]
+func (t *DiffView) KiType() *gti.Type {
+	return DiffViewType
+}
+
+// New returns a new [*DiffView] value
+func (t *DiffView) New() ki.Ki {
+	return &DiffView{}
+}
+
+// SetFileA sets the [DiffView.FileA]:
+// first file name being compared
+func (t *DiffView) SetFileA(v string) *DiffView {
+	t.FileA = v
+	return t
+}
+
+// SetFileB sets the [DiffView.FileB]:
+// second file name being compared
+func (t *DiffView) SetFileB(v string) *DiffView {
+	t.FileB = v
+	return t
+}
+
+// SetRevA sets the [DiffView.RevA]:
+// revision for first file, if relevant
+func (t *DiffView) SetRevA(v string) *DiffView {
+	t.RevA = v
+	return t
+}
+
+// SetRevB sets the [DiffView.RevB]:
+// revision for second file, if relevant
+func (t *DiffView) SetRevB(v string) *DiffView {
+	t.RevB = v
+	return t
+}
+
+// SetBufA sets the [DiffView.BufA]:
+// textbuf for A
+func (t *DiffView) SetBufA(v *Buf) *DiffView {
+	t.BufA = v
+	return t
+}
+
+// SetBufB sets the [DiffView.BufB]:
+// textbuf for B
+func (t *DiffView) SetBufB(v *Buf) *DiffView {
+	t.BufB = v
+	return t
+}
+
+// SetTooltip sets the [DiffView.Tooltip]
+func (t *DiffView) SetTooltip(v string) *DiffView {
+	t.Tooltip = v
+	return t
+}
+
+// SetClass sets the [DiffView.Class]
+func (t *DiffView) SetClass(v string) *DiffView {
+	t.Class = v
+	return t
+}
+
+// SetCustomContextMenu sets the [DiffView.CustomContextMenu]
+func (t *DiffView) SetCustomContextMenu(v func(m *gi.Scene)) *DiffView {
+	t.CustomContextMenu = v
+	return t
+}
+
+// SetLayout sets the [DiffView.Lay]
+func (t *DiffView) SetLayout(v gi.Layouts) *DiffView {
+	t.Lay = v
+	return t
+}
+
+// SetSpacing sets the [DiffView.Spacing]
+func (t *DiffView) SetSpacing(v units.Value) *DiffView {
+	t.Spacing = v
+	return t
+}
+
+// SetStackTop sets the [DiffView.StackTop]
+func (t *DiffView) SetStackTop(v int) *DiffView {
+	t.StackTop = v
+	return t
+}
+
+// SetStripes sets the [DiffView.Stripes]
+func (t *DiffView) SetStripes(v gi.Stripes) *DiffView {
+	t.Stripes = v
+	return t
+}
+
+// DiffTextViewType is the [gti.Type] for [DiffTextView]
+var DiffTextViewType = gti.AddType(&gti.Type{
+	Name:       "goki.dev/gi/v2/texteditor.DiffTextView",
+	ShortName:  "texteditor.DiffTextView",
+	IDName:     "diff-text-view",
+	Doc:        "DiffTextView is an [Editor] in a [DiffView], which applies the diff\nregion at a line from the other file when its line number is\ndouble-clicked.  In a [MergeView], it instead takes the lines from\nits version for the conflict at that line.",
+	Directives: gti.Directives{},
+	Fields:     ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{}),
+	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Editor", &gti.Field{Name: "Editor", Type: "goki.dev/gi/v2/texteditor.Editor", LocalType: "Editor", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+	}),
+	Methods:  ordmap.Make([]ordmap.KeyVal[string, *gti.Method]{}),
+	Instance: &DiffTextView{},
+})
+
+// NewDiffTextView adds a new [DiffTextView] with the given name
+// to the given parent. If the name is unspecified, it defaults
+// to the ID (kebab-case) name of the type, plus the
+// [ki.Ki.NumLifetimeChildren] of the given parent.
+func NewDiffTextView(par ki.Ki, name ...string) *DiffTextView {
+	return par.NewChild(DiffTextViewType, name...).(*DiffTextView)
+}
+
+// KiType returns the [*gti.Type] of [DiffTextView]
+func (t *DiffTextView) KiType() *gti.Type {
+	return DiffTextViewType
+}
+
+// New returns a new [*DiffTextView] value
+func (t *DiffTextView) New() ki.Ki {
+	return &DiffTextView{}
+}
+
+// SetPlaceholder sets the [DiffTextView.Placeholder]
+func (t *DiffTextView) SetPlaceholder(v string) *DiffTextView {
+	t.Placeholder = v
+	return t
+}
+
+// SetCursorWidth sets the [DiffTextView.CursorWidth]
+func (t *DiffTextView) SetCursorWidth(v units.Value) *DiffTextView {
+	t.CursorWidth = v
+	return t
+}
+
+// SetLineNumberColor sets the [DiffTextView.LineNumberColor]
+func (t *DiffTextView) SetLineNumberColor(v colors.Full) *DiffTextView {
+	t.LineNumberColor = v
+	return t
+}
+
+// SetSelectColor sets the [DiffTextView.SelectColor]
+func (t *DiffTextView) SetSelectColor(v colors.Full) *DiffTextView {
+	t.SelectColor = v
+	return t
+}
+
+// SetHighlightColor sets the [DiffTextView.HighlightColor]
+func (t *DiffTextView) SetHighlightColor(v colors.Full) *DiffTextView {
+	t.HighlightColor = v
+	return t
+}
+
+// SetCursorColor sets the [DiffTextView.CursorColor]
+func (t *DiffTextView) SetCursorColor(v colors.Full) *DiffTextView {
+	t.CursorColor = v
+	return t
+}
+
+// SetKeyHandler sets the [DiffTextView.KeyHandler]
+func (t *DiffTextView) SetKeyHandler(v KeyHandler) *DiffTextView {
+	t.KeyHandler = v
+	return t
+}
+
+// SetTooltip sets the [DiffTextView.Tooltip]
+func (t *DiffTextView) SetTooltip(v string) *DiffTextView {
+	t.Tooltip = v
+	return t
+}
+
+// SetClass sets the [DiffTextView.Class]
+func (t *DiffTextView) SetClass(v string) *DiffTextView {
+	t.Class = v
+	return t
+}
+
+// SetCustomContextMenu sets the [DiffTextView.CustomContextMenu]
+func (t *DiffTextView) SetCustomContextMenu(v func(m *gi.Scene)) *DiffTextView {
+	t.CustomContextMenu = v
+	return t
+}
+
+// SetSpacing sets the [DiffTextView.Spacing]
+func (t *DiffTextView) SetSpacing(v units.Value) *DiffTextView {
+	t.Spacing = v
+	return t
+}
+
+// SetStackTop sets the [DiffTextView.StackTop]
+func (t *DiffTextView) SetStackTop(v int) *DiffTextView {
+	t.StackTop = v
+	return t
+}
+
 // EditorType is the [gti.Type] for [Editor]
 var EditorType = gti.AddType(&gti.Type{
 	Name:      "goki.dev/gi/v2/texteditor.Editor",
@@ -42,13 +373,19 @@ var EditorType = gti.AddType(&gti.Type{
 		{"PosHistIdx", &gti.Field{Name: "PosHistIdx", Type: "int", LocalType: "int", Doc: "current index within PosHistory", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"SelectStart", &gti.Field{Name: "SelectStart", Type: "goki.dev/pi/v2/lex.Pos", LocalType: "lex.Pos", Doc: "starting point for selection -- will either be the start or end of selected region depending on subsequent selection.", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"SelectReg", &gti.Field{Name: "SelectReg", Type: "goki.dev/gi/v2/texteditor/textbuf.Region", LocalType: "textbuf.Region", Doc: "current selection region", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"Cursors", &gti.Field{Name: "Cursors", Type: "[]goki.dev/gi/v2/texteditor.MultiCursor", LocalType: "[]MultiCursor", Doc: "additional cursors and their selections, for multi-cursor editing -- the primary cursor is CursorPos and SelectReg", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"PrevSelectReg", &gti.Field{Name: "PrevSelectReg", Type: "goki.dev/gi/v2/texteditor/textbuf.Region", LocalType: "textbuf.Region", Doc: "previous selection region, that was actually rendered -- needed to update render", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"FoldRegs", &gti.Field{Name: "FoldRegs", Type: "[]goki.dev/gi/v2/texteditor/textbuf.Fold", LocalType: "[]textbuf.Fold", Doc: "regions of lines that can be folded, updated from the Buf during layout", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"Folded", &gti.Field{Name: "Folded", Type: "[]goki.dev/gi/v2/texteditor/textbuf.Fold", LocalType: "[]textbuf.Fold", Doc: "regions of lines that are currently folded (hidden), in order of starting line -- use SetFolded to change", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"Highlights", &gti.Field{Name: "Highlights", Type: "[]goki.dev/gi/v2/texteditor/textbuf.Region", LocalType: "[]textbuf.Region", Doc: "highlighted regions, e.g., for search results", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"Scopelights", &gti.Field{Name: "Scopelights", Type: "[]goki.dev/gi/v2/texteditor/textbuf.Region", LocalType: "[]textbuf.Region", Doc: "highlighted regions, specific to scope markers", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"SelectMode", &gti.Field{Name: "SelectMode", Type: "bool", LocalType: "bool", Doc: "if true, select text as cursor moves", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"ForceComplete", &gti.Field{Name: "ForceComplete", Type: "bool", LocalType: "bool", Doc: "if true, complete regardless of any disqualifying reasons", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"ISearch", &gti.Field{Name: "ISearch", Type: "goki.dev/gi/v2/texteditor.ISearch", LocalType: "ISearch", Doc: "interactive search data", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"QReplace", &gti.Field{Name: "QReplace", Type: "goki.dev/gi/v2/texteditor.QReplace", LocalType: "QReplace", Doc: "query replace data", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"Macro", &gti.Field{Name: "Macro", Type: "goki.dev/gi/v2/texteditor.Macro", LocalType: "Macro", Doc: "keyboard macro recording data", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"Minimap", &gti.Field{Name: "Minimap", Type: "goki.dev/gi/v2/texteditor.Minimap", LocalType: "Minimap", Doc: "minimap overview column, shown if Minimap.On -- use SetMinimap to change", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
+		{"KeyHandler", &gti.Field{Name: "KeyHandler", Type: "goki.dev/gi/v2/texteditor.KeyHandler", LocalType: "KeyHandler", Doc: "optional handler that gets key events before the default KeyInput processing, e.g., for vim-style modal editing (see the texteditor/vim package) -- nil for the standard key functions", Directives: gti.Directives{}, Tag: "readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"FontHeight", &gti.Field{Name: "FontHeight", Type: "float32", LocalType: "float32", Doc: "font height, cached during styling", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"LineHeight", &gti.Field{Name: "LineHeight", Type: "float32", LocalType: "float32", Doc: "line height, cached during styling", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
 		{"NLinesChars", &gti.Field{Name: "NLinesChars", Type: "image.Point", LocalType: "image.Point", Doc: "height in lines and width in chars of the visible area", Directives: gti.Directives{}, Tag: "set:\"-\" readonly:\"-\" json:\"-\" xml:\"-\""}},
@@ -61,6 +398,7 @@ var EditorType = gti.AddType(&gti.Type{
 		{"lastRecenter", &gti.Field{Name: "lastRecenter", Type: "int", LocalType: "int", Doc: "", Directives: gti.Directives{}, Tag: ""}},
 		{"lastAutoInsert", &gti.Field{Name: "lastAutoInsert", Type: "rune", LocalType: "rune", Doc: "", Directives: gti.Directives{}, Tag: ""}},
 		{"lastFilename", &gti.Field{Name: "lastFilename", Type: "goki.dev/gi/v2/gi.FileName", LocalType: "gi.FileName", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+		{"hiddenLns", &gti.Field{Name: "hiddenLns", Type: "[]bool", LocalType: "[]bool", Doc: "for each line, whether it is hidden in a Folded region -- see SetFolded", Directives: gti.Directives{}, Tag: ""}},
 	}),
 	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
 		{"Layout", &gti.Field{Name: "Layout", Type: "goki.dev/gi/v2/gi.Layout", LocalType: "gi.Layout", Doc: "", Directives: gti.Directives{}, Tag: ""}},
@@ -151,6 +489,13 @@ func (t *Editor) SetCursorColor(v colors.Full) *Editor {
 	return t
 }
 
+// SetKeyHandler sets the [Editor.KeyHandler]:
+// optional handler that gets key events before the default KeyInput processing, e.g., for vim-style modal editing (see the texteditor/vim package) -- nil for the standard key functions
+func (t *Editor) SetKeyHandler(v KeyHandler) *Editor {
+	t.KeyHandler = v
+	return t
+}
+
 // SetlastRecenter sets the [Editor.lastRecenter]
 func (t *Editor) SetlastRecenter(v int) *Editor {
 	t.lastRecenter = v
@@ -169,6 +514,13 @@ func (t *Editor) SetlastFilename(v gi.FileName) *Editor {
 	return t
 }
 
+// SethiddenLns sets the [Editor.hiddenLns]:
+// for each line, whether it is hidden in a Folded region -- see SetFolded
+func (t *Editor) SethiddenLns(v []bool) *Editor {
+	t.hiddenLns = v
+	return t
+}
+
 // SetTooltip sets the [Editor.Tooltip]
 func (t *Editor) SetTooltip(v string) *Editor {
 	t.Tooltip = v
@@ -199,6 +551,131 @@ func (t *Editor) SetStackTop(v int) *Editor {
 	return t
 }
 
+// MergeViewType is the [gti.Type] for [MergeView]
+var MergeViewType = gti.AddType(&gti.Type{
+	Name:       "goki.dev/gi/v2/texteditor.MergeView",
+	ShortName:  "texteditor.MergeView",
+	IDName:     "merge-view",
+	Doc:        "MergeView presents a three-way merge for resolving conflicts between\ntwo versions of a file (A and B) that were both derived from a common\nbase version: the A (left, ours), Base and B (right, theirs) versions\nare shown side-by-side in [DiffTextView]s above the merged result,\nwith the lines changed in each hunk colored, and a toolbar to go to\neach conflict hunk and take the lines from A, B or both, and to save\nthe result.  Double-clicking on a line number in the A or B version\ntakes the lines from that version for the conflict at that line.\nTaking a version for a hunk regenerates the merged text, so any direct\nedits to it should be made after all the conflicts have been resolved.",
+	Directives: gti.Directives{},
+	Fields: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Filename", &gti.Field{Name: "Filename", Type: "goki.dev/gi/v2/gi.FileName", LocalType: "gi.FileName", Doc: "file name for the merged result, which is where it is saved", Directives: gti.Directives{}, Tag: ""}},
+		{"Merge", &gti.Field{Name: "Merge", Type: "*goki.dev/gi/v2/texteditor/textbuf.Merge", LocalType: "*textbuf.Merge", Doc: "the merge being resolved -- use SetMerge to set", Directives: gti.Directives{}, Tag: "set:\"-\" json:\"-\" xml:\"-\""}},
+		{"CurHunk", &gti.Field{Name: "CurHunk", Type: "int", LocalType: "int", Doc: "index of the current hunk in the Merge", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"BufA", &gti.Field{Name: "BufA", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for the A version", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"BufBase", &gti.Field{Name: "BufBase", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for the Base version", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"BufB", &gti.Field{Name: "BufB", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for the B version", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+		{"BufOut", &gti.Field{Name: "BufOut", Type: "*goki.dev/gi/v2/texteditor.Buf", LocalType: "*Buf", Doc: "textbuf for the merged result", Directives: gti.Directives{}, Tag: "json:\"-\" xml:\"-\""}},
+	}),
+	Embeds: ordmap.Make([]ordmap.KeyVal[string, *gti.Field]{
+		{"Frame", &gti.Field{Name: "Frame", Type: "goki.dev/gi/v2/gi.Frame", LocalType: "gi.Frame", Doc: "", Directives: gti.Directives{}, Tag: ""}},
+	}),
+	Methods:  ordmap.Make([]ordmap.KeyVal[string, *gti.Method]{}),
+	Instance: &MergeView{},
+})
+
+// NewMergeView adds a new [MergeView] with the given name
+// to the given parent. If the name is unspecified, it defaults
+// to the ID (kebab-case) name of the type, plus the
+// [ki.Ki.NumLifetimeChildren] of the given parent.
+func NewMergeView(par ki.Ki, name ...string) *MergeView {
+	return par.NewChild(MergeViewType, name...).(*MergeView)
+}
+
+// KiType returns the [*gti.Type] of [MergeView]
+func (t *MergeView) KiType() *gti.Type {
+	return MergeViewType
+}
+
+// New returns a new [*MergeView] value
+func (t *MergeView) New() ki.Ki {
+	return &MergeView{}
+}
+
+// SetFilename sets the [MergeView.Filename]:
+// file name for the merged result, which is where it is saved
+func (t *MergeView) SetFilename(v gi.FileName) *MergeView {
+	t.Filename = v
+	return t
+}
+
+// SetCurHunk sets the [MergeView.CurHunk]:
+// index of the current hunk in the Merge
+func (t *MergeView) SetCurHunk(v int) *MergeView {
+	t.CurHunk = v
+	return t
+}
+
+// SetBufA sets the [MergeView.BufA]:
+// textbuf for the A version
+func (t *MergeView) SetBufA(v *Buf) *MergeView {
+	t.BufA = v
+	return t
+}
+
+// SetBufBase sets the [MergeView.BufBase]:
+// textbuf for the Base version
+func (t *MergeView) SetBufBase(v *Buf) *MergeView {
+	t.BufBase = v
+	return t
+}
+
+// SetBufB sets the [MergeView.BufB]:
+// textbuf for the B version
+func (t *MergeView) SetBufB(v *Buf) *MergeView {
+	t.BufB = v
+	return t
+}
+
+// SetBufOut sets the [MergeView.BufOut]:
+// textbuf for the merged result
+func (t *MergeView) SetBufOut(v *Buf) *MergeView {
+	t.BufOut = v
+	return t
+}
+
+// SetTooltip sets the [MergeView.Tooltip]
+func (t *MergeView) SetTooltip(v string) *MergeView {
+	t.Tooltip = v
+	return t
+}
+
+// SetClass sets the [MergeView.Class]
+func (t *MergeView) SetClass(v string) *MergeView {
+	t.Class = v
+	return t
+}
+
+// SetCustomContextMenu sets the [MergeView.CustomContextMenu]
+func (t *MergeView) SetCustomContextMenu(v func(m *gi.Scene)) *MergeView {
+	t.CustomContextMenu = v
+	return t
+}
+
+// SetLayout sets the [MergeView.Lay]
+func (t *MergeView) SetLayout(v gi.Layouts) *MergeView {
+	t.Lay = v
+	return t
+}
+
+// SetSpacing sets the [MergeView.Spacing]
+func (t *MergeView) SetSpacing(v units.Value) *MergeView {
+	t.Spacing = v
+	return t
+}
+
+// SetStackTop sets the [MergeView.StackTop]
+func (t *MergeView) SetStackTop(v int) *MergeView {
+	t.StackTop = v
+	return t
+}
+
+// SetStripes sets the [MergeView.Stripes]
+func (t *MergeView) SetStripes(v gi.Stripes) *MergeView {
+	t.Stripes = v
+	return t
+}
+
 // TwinEditorsType is the [gti.Type] for [TwinEditors]
 var TwinEditorsType = gti.AddType(&gti.Type{
 	Name:       "goki.dev/gi/v2/texteditor.TwinEditors",
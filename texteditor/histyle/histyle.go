@@ -15,6 +15,7 @@ import (
 	"image/color"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 
 	"goki.dev/colors"
@@ -269,6 +270,34 @@ func (hs Style) Tag(tag token.Tokens) StyleEntry {
 	return se
 }
 
+// BracketTags are the tags whose colors in a Style are used, in order, for
+// the colors of brackets at successive levels of nesting -- see BracketColors
+var BracketTags = []token.Tokens{token.Keyword, token.NameFunction, token.LitStr, token.NameBuiltin, token.LitNum, token.NameClass}
+
+// DefaultBracketColors are the colors of brackets at successive levels of
+// nesting for a Style that does not have enough distinct colors of its own
+var DefaultBracketColors = []color.RGBA{colors.Gold, colors.Orchid, colors.Lightskyblue}
+
+// BracketColors returns the colors of brackets at successive levels of
+// nesting (rainbow brackets), which are the distinct colors of the
+// BracketTags in this style, other than its Text color, or the
+// DefaultBracketColors if it has fewer than 3 of them.
+func (hs Style) BracketColors() []color.RGBA {
+	txt := hs.Tag(token.Text).Color
+	var clrs []color.RGBA
+	for _, tag := range BracketTags {
+		clr := hs.Tag(tag).Color
+		if colors.IsNil(clr) || clr == txt || slices.Contains(clrs, clr) {
+			continue
+		}
+		clrs = append(clrs, clr)
+	}
+	if len(clrs) < 3 {
+		return DefaultBracketColors
+	}
+	return clrs
+}
+
 // ToCSS generates a CSS style sheet for this style, by token.Tokens tag
 func (hs Style) ToCSS() map[token.Tokens]string {
 	css := map[token.Tokens]string{}
@@ -0,0 +1,153 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
+	"goki.dev/pi/v2/lex"
+)
+
+// JournalFilename returns the name of the sidecar file where the
+// edits since the last save are journaled when JournalEdits is on
+func (tb *Buf) JournalFilename() string {
+	path, fn := filepath.Split(string(tb.Filename))
+	if fn == "" {
+		fn = "new_file"
+	}
+	return filepath.Join(path, "."+fn+".journal")
+}
+
+// JournalStart starts a new journal of edits for the current text, which
+// must be in sync with the file as saved, deleting any existing journal.
+// Does nothing if JournalEdits is off or there is no Filename.
+func (tb *Buf) JournalStart() error {
+	tb.JournalDelete()
+	if !tb.JournalEdits || tb.Filename == "" {
+		return nil
+	}
+	jfn := tb.JournalFilename()
+	err := tb.Journal.Start(jfn, textbuf.UndoHash(tb.Txt))
+	if err != nil {
+		log.Printf("giv.Buf: Could not start journal file: %v, error: %v\n", jfn, err)
+	}
+	return err
+}
+
+// JournalEdit appends given edit to the journal, if it is on.
+// It is called for every change to the text, by the Impl edit methods.
+func (tb *Buf) JournalEdit(tbe *textbuf.Edit) {
+	err := tb.Journal.Append(tbe)
+	if err != nil {
+		log.Printf("giv.Buf: Could not write to journal file: %v, error: %v\n", tb.Journal.Filename, err)
+		tb.Journal.Close()
+	}
+}
+
+// journalSetText records the replacement of all of the text by SetText in
+// the journal, as a deletion of the prior text, which ended at given
+// position, followed by an insertion of the new text.
+func (tb *Buf) journalSetText(oend lex.Pos) {
+	if !tb.Journal.IsOn() {
+		return
+	}
+	tb.JournalEdit(&textbuf.Edit{Reg: textbuf.NewRegionPos(lex.PosZero, oend), Delete: true})
+	tb.JournalEdit(tb.Region(lex.PosZero, tb.EndPos()))
+}
+
+// JournalDelete stops journaling edits and deletes any journal file,
+// including one left over from a prior session
+func (tb *Buf) JournalDelete() {
+	tb.Journal.Delete()
+	os.Remove(tb.JournalFilename())
+}
+
+// JournalCheck returns true if there is a journal file left over from a
+// prior session that ended without saving or closing the buffer, e.g.,
+// from a crash -- see JournalRecover and JournalRecoverPrompt.
+// The journal is not started by OpenFile when there is one, so that it
+// is not lost before it is dealt with.
+func (tb *Buf) JournalCheck() bool {
+	if tb.Filename == "" || tb.Journal.IsOn() {
+		return false
+	}
+	if _, err := os.Stat(tb.JournalFilename()); os.IsNotExist(err) {
+		return false
+	}
+	return true
+}
+
+// JournalRecover returns the text that results from replaying the edits
+// in the journal file left over from a prior session onto the file as
+// saved on disk, which is the text that was being edited when that
+// session ended.  textbuf.ErrJournalHashMismatch is returned if the file
+// has been changed on disk since the journal was started.
+func (tb *Buf) JournalRecover() ([]byte, error) {
+	saved, err := os.ReadFile(string(tb.Filename))
+	if err != nil {
+		return nil, err
+	}
+	return textbuf.RecoverJournal(tb.JournalFilename(), saved)
+}
+
+// JournalRecoverPrompt checks for a journal file left over from a prior
+// session, and if the text recovered from it differs from the file as
+// saved, shows a DiffView of the saved vs. recovered text in a dialog
+// in the context of given widget, where the user can Recover the edits
+// into the buffer, or Discard them.  Either way, the journal then
+// starts over for the current text.  The buffer must have just been
+// opened from the file, and not yet edited.  Open calls this when the
+// buffer already has a view -- otherwise it is up to the app to call it
+// after opening a file, e.g., when it is first shown.
+func (tb *Buf) JournalRecoverPrompt(ctx gi.Widget) {
+	if !tb.JournalCheck() {
+		return
+	}
+	recovered, err := tb.JournalRecover()
+	if err != nil {
+		log.Printf("giv.Buf: Not recovering edits from journal file: %v, error: %v\n", tb.JournalFilename(), err)
+		tb.JournalStart()
+		return
+	}
+	if bytes.Equal(recovered, tb.Txt) {
+		tb.JournalStart()
+		return
+	}
+	fn := string(tb.Filename)
+	dlg := gi.NewDialog(ctx).Title("Recover Unsaved Edits: " + dirs.DirAndFile(fn)).
+		Prompt(fmt.Sprintf("Edits to this file were not saved when it was last open -- do you want to recover them?  The file as saved is shown on the left (A), and with the edits recovered on the right (B).  If you <code>Discard</code> them, they will be lost.  File: %v", fn))
+	DiffViewDialog(dlg, textbuf.BytesToLineStrings(tb.Txt, false), textbuf.BytesToLineStrings(recovered, false), fn, fn, "saved", "recovered")
+	dlg.Cancel("Discard").Ok("Recover")
+	dlg.OnAccept(func(e events.Event) {
+		tb.JournalApply(recovered)
+	}).OnCancel(func(e events.Event) {
+		tb.JournalStart()
+	}).Run()
+}
+
+// JournalApply starts a new journal, and then changes the text to given
+// recovered text (see JournalRecover), as one undoable group of edits,
+// so that the changes are themselves journaled until the file is saved.
+func (tb *Buf) JournalApply(recovered []byte) {
+	tb.JournalStart()
+	ob := NewBuf()
+	ob.Txt = recovered
+	ob.BytesToLines()
+	autoSave := tb.BatchUpdateStart()
+	defer tb.BatchUpdateEnd(autoSave)
+	tb.Undos.Mu.Lock()
+	upos := tb.Undos.Pos
+	tb.Undos.Mu.Unlock()
+	tb.PatchFromBuf(ob, tb.DiffBufs(ob), true)
+	tb.Undos.SetGroupFrom(upos)
+}
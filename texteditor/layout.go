@@ -49,7 +49,7 @@ func (ed *Editor) UpdateFromAlloc() {
 		ed.NLinesChars.X = int(mat32.Floor(float32(asz.X) / sty.Font.Face.Metrics.Ch))
 	}
 	ed.LineLayoutSize = asz.Sub(ed.ExtraSize).Sub(spc.Size())
-	ed.LineLayoutSize.X -= ed.LineNoOff
+	ed.LineLayoutSize.X -= ed.LineNoOff + ed.MinimapOffset()
 	// SidesTODO: this is sketchy
 	// ed.LineLayoutSize.X -= spc.Size().X / 2 // extra space for word wrap
 }
@@ -114,6 +114,7 @@ func (ed *Editor) LayoutAllLines() {
 	}
 
 	sz := ed.LineLayoutSize
+	wsz := ed.WrapSize()
 	// fmt.Println("LineLayoutSize:", sz)
 
 	sty := &ed.Styles
@@ -122,15 +123,18 @@ func (ed *Editor) LayoutAllLines() {
 	off := float32(0)
 	mxwd := sz.X // always start with our render size
 
+	ed.UpdateFoldRegions()
 	ed.Buf.MarkupMu.RLock()
 	ed.HasLinks = false
 	for ln := 0; ln < nln; ln++ {
-		ed.Renders[ln].SetHTMLPre(ed.Buf.Markup[ln], fst, &sty.Text, &sty.UnContext, ed.CSS)
-		ed.Renders[ln].LayoutStdLR(&sty.Text, sty.FontRender(), &sty.UnContext, sz)
+		ed.LayoutLineRender(ln, fst, wsz)
 		if !ed.HasLinks && len(ed.Renders[ln].Links) > 0 {
 			ed.HasLinks = true
 		}
 		ed.Offs[ln] = off
+		if ed.IsLineHidden(ln) { // folded: takes no space
+			continue
+		}
 		lsz := mat32.Max(ed.Renders[ln].Size.Y, ed.LineHeight)
 		off += lsz
 		mxwd = mat32.Max(mxwd, ed.Renders[ln].Size.X)
@@ -162,8 +166,7 @@ func (ed *Editor) LayoutLine(ln int) bool {
 
 	ed.Buf.MarkupMu.RLock()
 	curspans := len(ed.Renders[ln].Spans)
-	ed.Renders[ln].SetHTMLPre(ed.Buf.Markup[ln], fst, &sty.Text, &sty.UnContext, ed.CSS)
-	ed.Renders[ln].LayoutStdLR(&sty.Text, sty.FontRender(), &sty.UnContext, ed.LineLayoutSize)
+	ed.LayoutLineRender(ln, fst, ed.WrapSize())
 	if !ed.HasLinks && len(ed.Renders[ln].Links) > 0 {
 		ed.HasLinks = true
 	}
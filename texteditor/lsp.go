@@ -0,0 +1,361 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/lsp"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
+	"goki.dev/pi/v2/complete"
+	"goki.dev/pi/v2/filecat"
+	"goki.dev/pi/v2/lex"
+)
+
+var (
+	// LSPServers are the commands (with args) for starting the Language
+	// Server Protocol server to use for each supported language, e.g.,
+	// {"gopls"} for filecat.Go.  When a Buf is opened for a file in one of
+	// these languages, the server is used for completion, lookup (go to
+	// definition), hover and diagnostics, instead of the pi parser.
+	// There are none by default.
+	LSPServers = map[filecat.Supported][]string{}
+
+	// LSPRootMarkers are the names of files or directories that mark the
+	// root directory of a project for a language server -- the nearest
+	// directory containing one of them, up from the file, is used.
+	LSPRootMarkers = []string{"go.mod", "go.work", "package.json", "Cargo.toml", "pyproject.toml", ".git"}
+
+	// LSPChangeDelay is the delay after the last edit to a Buf before
+	// its new text is sent to the language server
+	LSPChangeDelay = 250 * time.Millisecond
+
+	// lspClients are the running language server clients, by command and root dir
+	lspClients = map[string]*lsp.Client{}
+
+	// lspClientsMu protects lspClients
+	lspClientsMu sync.Mutex
+)
+
+// LSPClient returns the running language server client for given server
+// command (with args) in given root dir, starting it if it is not
+// already running, so that all Bufs in a project share the same server.
+func LSPClient(rootDir string, command []string) (*lsp.Client, error) {
+	key := strings.Join(command, " ") + "\n" + rootDir
+	lspClientsMu.Lock()
+	defer lspClientsMu.Unlock()
+	if cl, ok := lspClients[key]; ok {
+		select {
+		case <-cl.Conn.Done(): // server has ended: restart
+		default:
+			return cl, nil
+		}
+	}
+	cl, err := lsp.Start(rootDir, command[0], command[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	lspClients[key] = cl
+	return cl, nil
+}
+
+// ShutdownLSPClients shuts down all of the running language servers --
+// should be called when the app quits.
+func ShutdownLSPClients() {
+	lspClientsMu.Lock()
+	defer lspClientsMu.Unlock()
+	for key, cl := range lspClients {
+		cl.Shutdown()
+		delete(lspClients, key)
+	}
+}
+
+// LSPRootDir returns the project root directory for given file, which is
+// the nearest directory up from it that contains one of the LSPRootMarkers,
+// or the directory of the file if none do.
+func LSPRootDir(fname string) string {
+	fdir, _ := filepath.Abs(filepath.Dir(fname))
+	for dir := fdir; ; {
+		for _, mk := range LSPRootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, mk)); err == nil {
+				return dir
+			}
+		}
+		pdir := filepath.Dir(dir)
+		if pdir == dir {
+			return fdir
+		}
+		dir = pdir
+	}
+}
+
+// ConfigLSP starts using the language server in LSPServers for the language
+// of the file, if there is one, and stops using any previous one --
+// called when the file is opened.  Starting the server can take a while,
+// so it is done in a separate goroutine, and the server is used from
+// when it is ready, in the event loop of the views (see RunOnViews).
+func (tb *Buf) ConfigLSP() {
+	tb.CloseLSP()
+	cmd := LSPServers[tb.Info.Sup]
+	if len(cmd) == 0 || tb.Filename == "" {
+		return
+	}
+	gen := tb.lspGen.Add(1)
+	fname := tb.Filename
+	go func() {
+		cl, err := LSPClient(LSPRootDir(string(fname)), cmd)
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+		tb.RunOnViews(func() {
+			if tb.lspGen.Load() != gen || tb.Filename != fname { // file changed since
+				return
+			}
+			tb.SetLSP(cl)
+		})
+	}()
+}
+
+// SetLSP sets the language server client to use for this buffer, opening
+// the file in the server and using it for completion, lookup, hover and
+// diagnostics.  Edits are sent to the server after the LSPChangeDelay.
+func (tb *Buf) SetLSP(cl *lsp.Client) error {
+	tb.CloseLSP()
+	tb.LSP = cl
+	lang := strings.ToLower(tb.Info.Sup.String())
+	err := cl.DidOpen(string(tb.Filename), lang, string(tb.Txt), tb.SetLSPDiagnostics)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+	tb.SetCompleter(tb, CompleteLSP, CompleteEditLSP, LookupLSP)
+	if !tb.lspOnChange {
+		tb.lspOnChange = true
+		tb.OnChange(func(e events.Event) {
+			tb.LSPChanged()
+		})
+	}
+	return err
+}
+
+// CloseLSP stops using the language server client for this buffer, if any,
+// closing the file in the server and removing its diagnostics.  A server
+// that is still being started by ConfigLSP is not used when it is ready.
+func (tb *Buf) CloseLSP() {
+	tb.lspGen.Add(1)
+	if tb.LSP == nil {
+		return
+	}
+	tb.LSPDelayMu.Lock()
+	if tb.LSPDelayTimer != nil {
+		tb.LSPDelayTimer.Stop()
+		tb.LSPDelayTimer = nil
+	}
+	tb.LSPDelayMu.Unlock()
+	tb.LSP.DidClose(string(tb.Filename))
+	tb.LSP = nil
+	tb.SetLSPDiagnostics(nil)
+	tb.DeleteCompleter()
+	tb.ConfigSupported() // back to the pi completer
+}
+
+// LSPChanged schedules the current text to be sent to the language
+// server after the LSPChangeDelay, if it is not already scheduled.
+func (tb *Buf) LSPChanged() {
+	if tb.LSP == nil {
+		return
+	}
+	tb.LSPDelayMu.Lock()
+	defer tb.LSPDelayMu.Unlock()
+	if tb.LSPDelayTimer != nil {
+		tb.LSPDelayTimer.Reset(LSPChangeDelay)
+		return
+	}
+	tb.LSPDelayTimer = time.AfterFunc(LSPChangeDelay, tb.LSPSync)
+}
+
+// LSPSync sends the current text to the language server now, if there
+// are edits that have not yet been sent -- called before each request.
+func (tb *Buf) LSPSync() {
+	tb.LSPDelayMu.Lock()
+	if tb.LSP == nil || tb.LSPDelayTimer == nil {
+		tb.LSPDelayMu.Unlock()
+		return
+	}
+	tb.LSPDelayTimer.Stop()
+	tb.LSPDelayTimer = nil
+	tb.LSPDelayMu.Unlock()
+	tb.LSP.DidChange(string(tb.Filename), strings.Join(tb.Strings(false), "\n"))
+}
+
+// LSPSaved tells the language server that the file has been saved
+func (tb *Buf) LSPSaved() {
+	if tb.LSP == nil {
+		return
+	}
+	tb.LSPSync()
+	tb.LSP.DidSave(string(tb.Filename))
+}
+
+// LSPPos returns the language server position for given position in the text
+func (tb *Buf) LSPPos(pos lex.Pos) lsp.Position {
+	return lsp.Position{Line: pos.Ln, Character: lsp.UTF16Col(tb.Line(pos.Ln), pos.Ch)}
+}
+
+// PosFromLSP returns the position in the text for given language server position
+func (tb *Buf) PosFromLSP(pos lsp.Position) lex.Pos {
+	return lex.Pos{Ln: pos.Line, Ch: lsp.RuneCol(tb.Line(pos.Line), pos.Character)}
+}
+
+// SetLSPDiagnostics sets the diagnostics from the language server,
+// replacing any previous ones, as the diagnostics of the "lsp" source.
+// It is called when the server publishes diagnostics.
+func (tb *Buf) SetLSPDiagnostics(diags []lsp.Diagnostic) {
+	tb.LSPDiags = diags
+	ds := make([]textbuf.Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if d.Range.Start.Line >= tb.NumLines() {
+			continue
+		}
+		sev := textbuf.SeverityError // the client decides if not given
+		if d.Severity > lsp.SeverityError {
+			sev = textbuf.Severities(d.Severity - lsp.SeverityError)
+		}
+		code := strings.Trim(string(d.Code), `"`)
+		if d.Source != "" {
+			code = strings.TrimSuffix(d.Source+" "+code, " ")
+		}
+		reg := textbuf.NewRegionPos(tb.PosFromLSP(d.Range.Start), tb.PosFromLSP(d.Range.End))
+		ds = append(ds, textbuf.Diagnostic{Reg: reg, Severity: sev, Message: d.Message, Code: code})
+	}
+	tb.SetDiagnostics("lsp", ds)
+}
+
+// lspSeed returns the identifier just before the end of given text,
+// which is the seed for language server completion
+func lspSeed(text string) string {
+	rs := []rune(text)
+	i := len(rs)
+	for i > 0 && (unicode.IsLetter(rs[i-1]) || unicode.IsDigit(rs[i-1]) || rs[i-1] == '_') {
+		i--
+	}
+	return string(rs[i:])
+}
+
+// CompleteLSP uses the language server of the Buf to get the completions at
+// the given position -- the string is a line of text up to the point
+// where the user has typed.  The data must be the *Buf.
+func CompleteLSP(data any, text string, posLn, posCh int) (md complete.Matches) {
+	tb, ok := data.(*Buf)
+	if !ok || tb.LSP == nil {
+		return md
+	}
+	tb.LSPSync()
+	items, err := tb.LSP.Completion(string(tb.Filename), tb.LSPPos(lex.Pos{Ln: posLn, Ch: posCh}))
+	if err != nil {
+		slog.Error(err.Error())
+		return md
+	}
+	md.Seed = lspSeed(text)
+	for _, it := range items {
+		md.Matches = append(md.Matches, complete.Completion{Text: it.Text(), Label: it.Label, Desc: it.Detail})
+	}
+	return md
+}
+
+// CompleteEditLSP uses the selected completion to edit the text
+func CompleteEditLSP(data any, text string, cursorPos int, comp complete.Completion, seed string) (ed complete.Edit) {
+	return gi.CompleteEditText(text, cursorPos, comp.Text, seed)
+}
+
+// LookupLSP uses the language server of the Buf to go to the definition of
+// the symbol at the given position, in the view that made the lookup (the
+// CurView of the Buf) if it is in the same file, and otherwise in a dialog
+// with an editor on the file.  The data must be the *Buf.
+func LookupLSP(data any, text string, posLn, posCh int) (ld complete.Lookup) {
+	tb, ok := data.(*Buf)
+	if !ok || tb.LSP == nil {
+		return ld
+	}
+	ed := tb.CurView
+	tb.CurView = nil
+	tb.LSPSync()
+	locs, err := tb.LSP.Definition(string(tb.Filename), tb.LSPPos(lex.Pos{Ln: posLn, Ch: posCh}))
+	if err != nil {
+		slog.Error(err.Error())
+		return ld
+	}
+	if len(locs) == 0 {
+		return ld
+	}
+	loc := locs[0]
+	ld.Filename = lsp.URIFile(loc.URI)
+	ld.StLine = loc.Range.Start.Line
+	ld.EdLine = loc.Range.End.Line
+	if ed == nil {
+		return ld
+	}
+	if same, _ := sameFile(ld.Filename, string(tb.Filename)); same {
+		ed.SavePosHistory(ed.CursorPos)
+		ed.SetCursorShow(tb.PosFromLSP(loc.Range.Start))
+		return ld
+	}
+	DefinitionDialog(ed, ld.Filename, loc.Range.Start)
+	return ld
+}
+
+// sameFile returns true if the two file names refer to the same file
+func sameFile(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+// DefinitionDialog opens a dialog with an editor on given file, with the
+// cursor at given language server position, for showing a definition.
+func DefinitionDialog(ctx gi.Widget, fname string, pos lsp.Position) *Editor {
+	tb := NewBuf()
+	tb.Opts.LineNos = true
+	if err := tb.OpenFile(gi.FileName(fname)); err != nil { // not Open: no language server
+		slog.Error(err.Error())
+		return nil
+	}
+	tb.InitialMarkup()
+	dlg := gi.NewDialog(ctx).Title("Definition: " + dirs.DirAndFile(fname))
+	ed := NewEditor(dlg.Scene, "editor")
+	ed.SetBuf(tb)
+	ed.SetCursorShow(tb.PosFromLSP(pos))
+	dlg.Run()
+	return ed
+}
+
+// LSPHoverText returns the hover information from the language server
+// for the text at given position, or "" if there is none or no server
+func (ed *Editor) LSPHoverText(pos lex.Pos) string {
+	if ed.Buf == nil || ed.Buf.LSP == nil || pos.Ch >= ed.Buf.LineLen(pos.Ln) {
+		return ""
+	}
+	ed.Buf.LSPSync()
+	txt, err := ed.Buf.LSP.Hover(string(ed.Buf.Filename), ed.Buf.LSPPos(pos))
+	if err != nil {
+		return ""
+	}
+	return txt
+}
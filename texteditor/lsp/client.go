@@ -0,0 +1,312 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lsp provides a client for the Language Server Protocol,
+// which talks JSON-RPC to a language server process over its stdin
+// and stdout, for completion, hover, definition and diagnostics.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the default Timeout for requests made by a Client
+var DefaultTimeout = 5 * time.Second
+
+// Client is a Language Server Protocol client connected to a server,
+// which keeps track of the documents that are open in the server, and
+// calls the registered functions when diagnostics are published for them.
+type Client struct {
+
+	// the JSON-RPC connection to the server
+	Conn *Conn
+
+	// root directory of the workspace
+	RootDir string
+
+	// capabilities of the server, from Initialize
+	Capabilities ServerCapabilities
+
+	// timeout for requests to the server
+	Timeout time.Duration
+
+	// the server process, if started by Start
+	Cmd *exec.Cmd
+
+	// protects the maps
+	mu sync.Mutex
+
+	// versions of the open documents, by URI
+	versions map[string]int
+
+	// functions to call with published diagnostics, by URI
+	diagFuncs map[string]func(diags []Diagnostic)
+}
+
+// stdio combines the stdin and stdout pipes of a server process
+type stdio struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (s *stdio) Close() error {
+	err := s.WriteCloser.Close()
+	if rerr := s.ReadCloser.Close(); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Start starts the given language server command in given root
+// directory, talking to it over its stdin and stdout, and initializes it.
+// The stderr of the server goes to our stderr.
+func Start(rootDir string, command string, args ...string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = rootDir
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("lsp.Start: could not start server %q: %w", command, err)
+	}
+	cl := NewClient(&stdio{ReadCloser: out, WriteCloser: in})
+	cl.Cmd = cmd
+	if err := cl.Initialize(rootDir); err != nil {
+		cl.Conn.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return cl, nil
+}
+
+// NewClient returns a new Client talking to a server on given stream,
+// which must then be initialized with Initialize.
+func NewClient(rw io.ReadWriteCloser) *Client {
+	cl := &Client{Timeout: DefaultTimeout, versions: make(map[string]int), diagFuncs: make(map[string]func([]Diagnostic))}
+	cl.Conn = NewConn(rw, cl.handle)
+	return cl
+}
+
+// handle handles requests and notifications from the server
+func (cl *Client) handle(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var pd PublishDiagnosticsParams
+		if err := json.Unmarshal(params, &pd); err != nil {
+			return nil, err
+		}
+		cl.mu.Lock()
+		fun := cl.diagFuncs[pd.URI]
+		cl.mu.Unlock()
+		if fun != nil {
+			fun(pd.Diagnostics)
+		}
+		return nil, nil
+	case "window/logMessage", "window/showMessage":
+		var lm struct {
+			Type    int    `json:"type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(params, &lm) == nil && lm.Type == 1 { // errors only
+			log.Printf("lsp: server error: %s\n", lm.Message)
+		}
+		return nil, nil
+	case "workspace/configuration":
+		var cp struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &cp)
+		return make([]any, len(cp.Items)), nil
+	case "window/workDoneProgress/create", "client/registerCapability", "client/unregisterCapability":
+		return nil, nil
+	}
+	return nil, &Error{Code: ErrMethodNotFound, Message: "method not supported: " + method}
+}
+
+// context returns a context with the Timeout for a request
+func (cl *Client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), cl.Timeout)
+}
+
+// call makes a request to the server, with the Timeout
+func (cl *Client) call(method string, params, result any) error {
+	ctx, cancel := cl.context()
+	defer cancel()
+	return cl.Conn.Call(ctx, method, params, result)
+}
+
+// Initialize sends the initialize request for given workspace root
+// directory, and the initialized notification after it.
+func (cl *Client) Initialize(rootDir string) error {
+	cl.RootDir = rootDir
+	uri := FileURI(rootDir)
+	ip := &InitializeParams{
+		ProcessID:        os.Getpid(),
+		RootURI:          uri,
+		WorkspaceFolders: []WorkspaceFolder{{URI: uri, Name: filepath.Base(rootDir)}},
+		Capabilities: map[string]any{
+			"textDocument": map[string]any{
+				"synchronization":    map[string]any{"didSave": true},
+				"completion":         map[string]any{"completionItem": map[string]any{"snippetSupport": false}},
+				"hover":              map[string]any{"contentFormat": []string{"plaintext", "markdown"}},
+				"definition":         map[string]any{"linkSupport": true},
+				"publishDiagnostics": map[string]any{},
+			},
+		},
+	}
+	var res InitializeResult
+	if err := cl.call("initialize", ip, &res); err != nil {
+		return fmt.Errorf("lsp.Initialize: %w", err)
+	}
+	cl.Capabilities = res.Capabilities
+	return cl.Conn.Notify("initialized", struct{}{})
+}
+
+// DidOpen tells the server that given file has been opened with given
+// text, in given language (e.g., "go"), and sets the function to call
+// with the diagnostics for it, which can be nil.
+func (cl *Client) DidOpen(fname, langID, text string, diagFun func(diags []Diagnostic)) error {
+	uri := FileURI(fname)
+	cl.mu.Lock()
+	cl.versions[uri] = 1
+	if diagFun != nil {
+		cl.diagFuncs[uri] = diagFun
+	}
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didOpen", &DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: langID, Version: 1, Text: text}})
+}
+
+// DidChange tells the server the new full text of given open file
+func (cl *Client) DidChange(fname, text string) error {
+	uri := FileURI(fname)
+	cl.mu.Lock()
+	cl.versions[uri]++
+	vers := cl.versions[uri]
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didChange", &DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: vers},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}}})
+}
+
+// DidSave tells the server that given open file has been saved
+func (cl *Client) DidSave(fname string) error {
+	return cl.Conn.Notify("textDocument/didSave", &DidSaveTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: FileURI(fname)}})
+}
+
+// DidClose tells the server that given file has been closed
+func (cl *Client) DidClose(fname string) error {
+	uri := FileURI(fname)
+	cl.mu.Lock()
+	delete(cl.versions, uri)
+	delete(cl.diagFuncs, uri)
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didClose", &DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri}})
+}
+
+// IsOpen returns true if given file is open in the server
+func (cl *Client) IsOpen(fname string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	_, has := cl.versions[FileURI(fname)]
+	return has
+}
+
+// posParams returns the params for a request at given position in given file
+func posParams(fname string, pos Position) *TextDocumentPositionParams {
+	return &TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: FileURI(fname)}, Position: pos}
+}
+
+// Completion returns the completions at given position in given file
+func (cl *Client) Completion(fname string, pos Position) ([]CompletionItem, error) {
+	var res json.RawMessage
+	if err := cl.call("textDocument/completion", posParams(fname, pos), &res); err != nil {
+		return nil, err
+	}
+	var items []CompletionItem
+	if json.Unmarshal(res, &items) == nil {
+		return items, nil
+	}
+	var cls CompletionList
+	if err := json.Unmarshal(res, &cls); err != nil {
+		return nil, fmt.Errorf("lsp.Completion: invalid result: %w", err)
+	}
+	return cls.Items, nil
+}
+
+// Hover returns the hover text at given position in given file,
+// which is empty if there is nothing there.
+func (cl *Client) Hover(fname string, pos Position) (string, error) {
+	var hv *Hover
+	if err := cl.call("textDocument/hover", posParams(fname, pos), &hv); err != nil {
+		return "", err
+	}
+	if hv == nil {
+		return "", nil
+	}
+	return hv.Text(), nil
+}
+
+// Definition returns the location(s) of the definition of the symbol
+// at given position in given file.
+func (cl *Client) Definition(fname string, pos Position) ([]Location, error) {
+	var res json.RawMessage
+	if err := cl.call("textDocument/definition", posParams(fname, pos), &res); err != nil {
+		return nil, err
+	}
+	var loc Location
+	if json.Unmarshal(res, &loc) == nil && loc.URI != "" {
+		return []Location{loc}, nil
+	}
+	var lns []LocationLink
+	if json.Unmarshal(res, &lns) == nil && len(lns) > 0 && lns[0].TargetURI != "" {
+		locs := make([]Location, len(lns))
+		for i, ln := range lns {
+			locs[i] = Location{URI: ln.TargetURI, Range: ln.TargetSelectionRange}
+		}
+		return locs, nil
+	}
+	var locs []Location
+	json.Unmarshal(res, &locs)
+	return locs, nil
+}
+
+// Shutdown asks the server to shut down and exit, and closes the
+// connection, waiting for the server process (if any) to end.
+func (cl *Client) Shutdown() error {
+	err := cl.call("shutdown", nil, nil)
+	if err == nil {
+		err = cl.Conn.Notify("exit", nil)
+	}
+	if cl.Cmd == nil {
+		cl.Conn.Close()
+		return err
+	}
+	select { // the server closes its stdout when it exits
+	case <-cl.Conn.Done():
+	case <-time.After(cl.Timeout):
+		cl.Cmd.Process.Kill()
+	}
+	cl.Conn.Close()
+	cl.Cmd.Wait()
+	return err
+}
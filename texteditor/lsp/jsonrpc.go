@@ -0,0 +1,281 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// Error is a JSON-RPC error returned in response to a request
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("lsp: error %d: %s", e.Code, e.Message)
+}
+
+// Standard JSON-RPC and LSP error codes
+const (
+	ErrParse          = -32700
+	ErrInvalidRequest = -32600
+	ErrMethodNotFound = -32601
+	ErrInvalidParams  = -32602
+	ErrInternal       = -32603
+	ErrRequestFailed  = -32803
+)
+
+// Handler handles a notification or request received from the other end
+// of a Conn.  For requests, the result (or error) is sent back as the
+// response -- return an *Error to control the error code.
+// Handlers are called on the reading goroutine, in order.
+type Handler func(method string, params json.RawMessage) (result any, err error)
+
+// message is any JSON-RPC 2.0 message: a request has a Method and ID,
+// a notification a Method and no ID, and a response an ID and no Method.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection using the base protocol of the
+// Language Server Protocol, where each message is preceded by a
+// Content-Length header.  It is symmetric: either end can make
+// requests and send notifications to the other.
+type Conn struct {
+
+	// the underlying stream, e.g., the stdin / stdout of a server process
+	rw io.ReadWriteCloser
+
+	// handles incoming requests and notifications -- can be nil
+	handler Handler
+
+	// protects writing of messages
+	writeMu sync.Mutex
+
+	// protects the fields below
+	mu sync.Mutex
+
+	// id of the next request
+	nextID int64
+
+	// channels waiting for the response to each pending request, by id
+	pending map[int64]chan *message
+
+	// error that ended the connection
+	err error
+
+	// closed when the connection ends
+	done chan struct{}
+}
+
+// NewConn returns a new connection on given stream, and starts reading
+// messages from it, calling the handler for incoming requests and
+// notifications.
+func NewConn(rw io.ReadWriteCloser, handler Handler) *Conn {
+	cn := &Conn{rw: rw, handler: handler, pending: make(map[int64]chan *message), done: make(chan struct{})}
+	go cn.readLoop()
+	return cn
+}
+
+// Call sends a request with given method and params, and waits for the
+// response, which is decoded into result unless it is nil.
+func (cn *Conn) Call(ctx context.Context, method string, params, result any) error {
+	cn.mu.Lock()
+	if cn.err != nil {
+		cn.mu.Unlock()
+		return cn.err
+	}
+	id := cn.nextID
+	cn.nextID++
+	rch := make(chan *message, 1)
+	cn.pending[id] = rch
+	cn.mu.Unlock()
+	defer func() {
+		cn.mu.Lock()
+		delete(cn.pending, id)
+		cn.mu.Unlock()
+	}()
+
+	pm, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	idb := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := cn.write(&message{ID: idb, Method: method, Params: pm}); err != nil {
+		return err
+	}
+	select {
+	case rm := <-rch:
+		if rm.Error != nil {
+			return rm.Error
+		}
+		if result == nil || len(rm.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(rm.Result, result)
+	case <-ctx.Done():
+		cn.Notify("$/cancelRequest", map[string]any{"id": id})
+		return ctx.Err()
+	case <-cn.done:
+		return cn.Err()
+	}
+}
+
+// Notify sends a notification with given method and params,
+// which has no response.
+func (cn *Conn) Notify(method string, params any) error {
+	pm, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	return cn.write(&message{Method: method, Params: pm})
+}
+
+// Close closes the connection and the underlying stream
+func (cn *Conn) Close() error {
+	err := cn.rw.Close()
+	<-cn.done
+	return err
+}
+
+// Done returns a channel that is closed when the connection ends
+func (cn *Conn) Done() <-chan struct{} {
+	return cn.done
+}
+
+// Err returns the error that ended the connection, which is
+// io.EOF if the other end closed it.
+func (cn *Conn) Err() error {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	return cn.err
+}
+
+// marshalParams returns the JSON encoding of given params,
+// which are omitted if nil
+func marshalParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
+
+// write writes given message with its header
+func (cn *Conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	cn.writeMu.Lock()
+	defer cn.writeMu.Unlock()
+	if _, err := fmt.Fprintf(cn.rw, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = cn.rw.Write(b)
+	return err
+}
+
+// readLoop reads and dispatches messages until the stream ends
+func (cn *Conn) readLoop() {
+	tr := textproto.NewReader(bufio.NewReader(cn.rw))
+	var err error
+	for {
+		var msg *message
+		msg, err = readMessage(tr)
+		if err != nil {
+			break
+		}
+		if msg.Method == "" {
+			cn.dispatchResponse(msg)
+			continue
+		}
+		cn.handle(msg)
+	}
+	cn.mu.Lock()
+	cn.err = err
+	cn.mu.Unlock()
+	close(cn.done)
+}
+
+// readMessage reads the next message from given reader
+func readMessage(tr *textproto.Reader) (*message, error) {
+	hdr, err := tr.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(hdr.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("lsp: invalid Content-Length header: %q", hdr.Get("Content-Length"))
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(tr.R, b); err != nil {
+		return nil, err
+	}
+	msg := &message{}
+	if err := json.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("lsp: invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+// dispatchResponse sends given response to the pending request it is for
+func (cn *Conn) dispatchResponse(msg *message) {
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		return
+	}
+	cn.mu.Lock()
+	rch, ok := cn.pending[id]
+	cn.mu.Unlock()
+	if ok {
+		rch <- msg
+	}
+}
+
+// handle calls the handler for given request or notification,
+// and sends the response for a request
+func (cn *Conn) handle(msg *message) {
+	var res any
+	var err error
+	if cn.handler != nil {
+		res, err = cn.handler(msg.Method, msg.Params)
+	} else {
+		err = &Error{Code: ErrMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if len(msg.ID) == 0 { // notification
+		return
+	}
+	rsp := &message{ID: msg.ID}
+	if err != nil {
+		re, ok := err.(*Error)
+		if !ok {
+			re = &Error{Code: ErrInternal, Message: err.Error()}
+		}
+		rsp.Error = re
+	} else {
+		rb, merr := json.Marshal(res)
+		if merr != nil {
+			rsp.Error = &Error{Code: ErrInternal, Message: merr.Error()}
+		} else {
+			rsp.Result = rb
+		}
+	}
+	cn.write(rsp)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// stubServerEnv is set in the environment of the test binary when it is
+// started as a stub language server by the tests
+const stubServerEnv = "LSP_STUB_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) != "" {
+		runStubServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runStubServer runs a minimal language server on stdin and stdout,
+// which offers fixed completions, hover text and definitions, and
+// publishes a diagnostic for each line containing "bad".
+func runStubServer() {
+	exit := make(chan struct{})
+	pub := make(chan *PublishDiagnosticsParams, 10)
+	diags := func(uri, text string) {
+		var ds []Diagnostic
+		for i, ln := range strings.Split(text, "\n") {
+			if st := strings.Index(ln, "bad"); st >= 0 {
+				ds = append(ds, Diagnostic{Range: Range{Start: Position{i, st}, End: Position{i, st + 3}}, Severity: SeverityError, Message: "bad word"})
+			}
+		}
+		pub <- &PublishDiagnosticsParams{URI: uri, Diagnostics: ds}
+	}
+	cn := NewConn(&stdio{ReadCloser: os.Stdin, WriteCloser: os.Stdout}, func(method string, params json.RawMessage) (any, error) {
+		switch method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{"completionProvider": map[string]any{}, "hoverProvider": true, "definitionProvider": true}}, nil
+		case "textDocument/didOpen":
+			var p DidOpenTextDocumentParams
+			json.Unmarshal(params, &p)
+			diags(p.TextDocument.URI, p.TextDocument.Text)
+		case "textDocument/didChange":
+			var p DidChangeTextDocumentParams
+			json.Unmarshal(params, &p)
+			diags(p.TextDocument.URI, p.ContentChanges[0].Text)
+		case "textDocument/completion":
+			return &CompletionList{Items: []CompletionItem{{Label: "Println", Kind: CompletionFunction}, {Label: "Printf", Kind: CompletionFunction, InsertText: "Printf("}}}, nil
+		case "textDocument/hover":
+			var p TextDocumentPositionParams
+			json.Unmarshal(params, &p)
+			if p.Position.Line > 0 {
+				return nil, nil
+			}
+			return map[string]any{"contents": MarkupContent{Kind: "plaintext", Value: "func Println(a ...any)"}}, nil
+		case "textDocument/definition":
+			var p TextDocumentPositionParams
+			json.Unmarshal(params, &p)
+			return []LocationLink{{TargetURI: p.TextDocument.URI, TargetSelectionRange: Range{Start: Position{2, 5}, End: Position{2, 9}}}}, nil
+		case "shutdown":
+		case "exit":
+			close(exit)
+		default:
+			return nil, &Error{Code: ErrMethodNotFound, Message: method}
+		}
+		return nil, nil
+	})
+	for {
+		select {
+		case pd := <-pub:
+			cn.Notify("textDocument/publishDiagnostics", pd)
+		case <-exit:
+			return
+		case <-cn.Done():
+			return
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Setenv(stubServerEnv, "1")
+	dir := t.TempDir()
+	cl, err := Start(dir, os.Args[0], "-test.run=^$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Has(cl.Capabilities.HoverProvider) || !Has(cl.Capabilities.CompletionProvider) || Has(cl.Capabilities.TextDocumentSync) {
+		t.Errorf("capabilities: %+v", cl.Capabilities)
+	}
+
+	fname := dir + "/test.go"
+	dch := make(chan []Diagnostic, 2)
+	if err := cl.DidOpen(fname, "go", "package bad\n", func(ds []Diagnostic) { dch <- ds }); err != nil {
+		t.Fatal(err)
+	}
+	ds := <-dch
+	if len(ds) != 1 || ds[0].Range.Start != (Position{0, 8}) || ds[0].Message != "bad word" {
+		t.Errorf("diagnostics on open: %+v", ds)
+	}
+	cl.DidChange(fname, "package good\n")
+	if ds := <-dch; len(ds) != 0 {
+		t.Errorf("diagnostics on change: %+v", ds)
+	}
+
+	items, err := cl.Completion(fname, Position{1, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Text() != "Println" || items[1].Text() != "Printf(" {
+		t.Errorf("completion: %+v", items)
+	}
+	hv, err := cl.Hover(fname, Position{0, 1})
+	if err != nil || hv != "func Println(a ...any)" {
+		t.Errorf("hover: %q %v", hv, err)
+	}
+	if hv, err := cl.Hover(fname, Position{1, 1}); err != nil || hv != "" {
+		t.Errorf("hover on nothing: %q %v", hv, err)
+	}
+	locs, err := cl.Definition(fname, Position{0, 1})
+	if err != nil || len(locs) != 1 || URIFile(locs[0].URI) != fname || locs[0].Range.Start != (Position{2, 5}) {
+		t.Errorf("definition: %+v %v", locs, err)
+	}
+	if err := cl.Conn.Call(context.Background(), "unknown", nil, nil); err == nil || err.(*Error).Code != ErrMethodNotFound {
+		t.Errorf("unknown method: %v", err)
+	}
+
+	cl.DidClose(fname)
+	if cl.IsOpen(fname) {
+		t.Errorf("still open after DidClose")
+	}
+	if err := cl.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if cl.Cmd.ProcessState == nil || !cl.Cmd.ProcessState.Success() {
+		t.Errorf("server did not exit cleanly: %v", cl.Cmd.ProcessState)
+	}
+}
+
+func TestUTF16Col(t *testing.T) {
+	ln := []rune("a😀b")
+	if c := UTF16Col(ln, 2); c != 3 {
+		t.Errorf("UTF16Col: %d", c)
+	}
+	if c := RuneCol(ln, 3); c != 2 {
+		t.Errorf("RuneCol: %d", c)
+	}
+	if c := RuneCol(ln, 10); c != 3 {
+		t.Errorf("RuneCol past end: %d", c)
+	}
+}
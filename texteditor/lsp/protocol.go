@@ -0,0 +1,309 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// This file has the subset of the Language Server Protocol types
+// that are used by the Client.  See
+// https://microsoft.github.io/language-server-protocol/specification
+
+// Position is a position in a text document, as a zero-based line
+// and a zero-based character offset in UTF-16 code units in that line.
+// Use UTF16Col and RuneCol to convert to and from rune indexes.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a text document, with the End exclusive
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in the document at given URI
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// LocationLink is an alternative form of Location returned for definitions
+type LocationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// TextDocumentIdentifier identifies a text document by its URI
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a version of a text document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentItem is a text document sent to the server when it is opened
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentPositionParams are the params for requests at a position
+// in a document, e.g., completion, hover and definition
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// TextDocumentContentChangeEvent is a change to a document -- the Client
+// always sends the full text, without a Range
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// DidOpenTextDocumentParams are the params for textDocument/didOpen
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams are the params for textDocument/didChange
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidSaveTextDocumentParams are the params for textDocument/didSave
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DidCloseTextDocumentParams are the params for textDocument/didClose
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// WorkspaceFolder is a root folder of the workspace
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// InitializeParams are the params for the initialize request
+type InitializeParams struct {
+	ProcessID        int               `json:"processId"`
+	RootURI          string            `json:"rootUri"`
+	WorkspaceFolders []WorkspaceFolder `json:"workspaceFolders,omitempty"`
+	Capabilities     map[string]any    `json:"capabilities"`
+}
+
+// ServerCapabilities are the capabilities of the server, from the
+// initialize result -- providers can be a bool or an options object,
+// so they are kept raw, and tested with Has.
+type ServerCapabilities struct {
+	TextDocumentSync   json.RawMessage `json:"textDocumentSync,omitempty"`
+	CompletionProvider json.RawMessage `json:"completionProvider,omitempty"`
+	HoverProvider      json.RawMessage `json:"hoverProvider,omitempty"`
+	DefinitionProvider json.RawMessage `json:"definitionProvider,omitempty"`
+}
+
+// Has returns true if given capability (provider) field is present
+// and not false or null
+func Has(capability json.RawMessage) bool {
+	s := string(capability)
+	return s != "" && s != "false" && s != "null"
+}
+
+// InitializeResult is the result of the initialize request
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+}
+
+// CompletionItemKind is the kind of a completion item
+type CompletionItemKind int
+
+// The kinds of completion items that are distinguished by the Client
+const (
+	CompletionMethod      CompletionItemKind = 2
+	CompletionFunction    CompletionItemKind = 3
+	CompletionConstructor CompletionItemKind = 4
+	CompletionField       CompletionItemKind = 5
+	CompletionVariable    CompletionItemKind = 6
+	CompletionClass       CompletionItemKind = 7
+	CompletionInterface   CompletionItemKind = 8
+	CompletionModule      CompletionItemKind = 9
+	CompletionProperty    CompletionItemKind = 10
+	CompletionKeyword     CompletionItemKind = 14
+	CompletionConstant    CompletionItemKind = 21
+	CompletionStruct      CompletionItemKind = 22
+	CompletionTypeParam   CompletionItemKind = 25
+)
+
+// TextEdit is an edit replacing the text in Range with NewText
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// CompletionItem is one of the completions returned by the server
+type CompletionItem struct {
+	Label      string             `json:"label"`
+	Kind       CompletionItemKind `json:"kind,omitempty"`
+	Detail     string             `json:"detail,omitempty"`
+	InsertText string             `json:"insertText,omitempty"`
+	FilterText string             `json:"filterText,omitempty"`
+	TextEdit   *TextEdit          `json:"textEdit,omitempty"`
+}
+
+// Text returns the text to insert for the completion
+func (ci *CompletionItem) Text() string {
+	switch {
+	case ci.TextEdit != nil:
+		return ci.TextEdit.NewText
+	case ci.InsertText != "":
+		return ci.InsertText
+	}
+	return ci.Label
+}
+
+// CompletionList is the result of a completion request
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// MarkupContent is text in plaintext or markdown format
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the result of a hover request
+type Hover struct {
+	Contents json.RawMessage `json:"contents"`
+	Range    *Range          `json:"range,omitempty"`
+}
+
+// Text returns the text of the Contents, which can be MarkupContent,
+// a MarkedString (a string or {language, value}), or a list of those.
+func (hv *Hover) Text() string {
+	var mc MarkupContent
+	if json.Unmarshal(hv.Contents, &mc) == nil && mc.Value != "" {
+		return mc.Value
+	}
+	var s string
+	if json.Unmarshal(hv.Contents, &s) == nil {
+		return s
+	}
+	var ms []json.RawMessage
+	if json.Unmarshal(hv.Contents, &ms) != nil {
+		return ""
+	}
+	var strs []string
+	for _, m := range ms {
+		if json.Unmarshal(m, &s) == nil {
+			strs = append(strs, s)
+		} else if json.Unmarshal(m, &mc) == nil {
+			strs = append(strs, mc.Value)
+		}
+	}
+	return strings.Join(strs, "\n\n")
+}
+
+// DiagnosticSeverity is the severity of a diagnostic
+type DiagnosticSeverity int
+
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+// Diagnostic is an error, warning, etc for a range of a document
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Code     json.RawMessage    `json:"code,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of the
+// textDocument/publishDiagnostics notification
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// FileURI returns the file:// URI for given file path
+func FileURI(fname string) string {
+	if abs, err := filepath.Abs(fname); err == nil {
+		fname = abs
+	}
+	p := filepath.ToSlash(fname)
+	if !strings.HasPrefix(p, "/") { // windows drive
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// URIFile returns the file path for given file:// URI
+func URIFile(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/")
+	}
+	return filepath.FromSlash(p)
+}
+
+// UTF16Col returns the column in UTF-16 code units for given
+// rune index in given line
+func UTF16Col(line []rune, ch int) int {
+	ch = min(ch, len(line))
+	col := 0
+	for _, r := range line[:ch] {
+		col += runeLen16(r)
+	}
+	return col
+}
+
+// RuneCol returns the rune index for given column in UTF-16 code
+// units in given line
+func RuneCol(line []rune, col int) int {
+	n := 0
+	for i, r := range line {
+		if n >= col {
+			return i
+		}
+		n += runeLen16(r)
+	}
+	return len(line)
+}
+
+// runeLen16 returns the number of UTF-16 code units for given rune
+func runeLen16(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
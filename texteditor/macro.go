@@ -0,0 +1,240 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"fmt"
+	"log/slog"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/keyfun"
+	"goki.dev/goosi/events"
+	"goki.dev/laser"
+	"goki.dev/pi/v2/lex"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+//    Keyboard Macros
+
+// Macro holds the keyboard macro recording data
+type Macro struct {
+
+	// if true, key chords are being recorded
+	On bool `json:"-" xml:"-"`
+
+	// the keys recorded so far
+	Keys gi.KeyMacro `json:"-" xml:"-"`
+
+	// true while replaying a macro, so the replayed keys are not recorded again
+	Replaying bool `json:"-" xml:"-"`
+}
+
+// LastMacro is the last keyboard macro recorded in any editor, which is
+// replayed by the MacroReplay key function, and can be saved by name
+// in the preferences with MacroSave
+var LastMacro gi.KeyMacro
+
+// MacroMaxReplay is the maximum number of times a macro is replayed in one
+// go, to stop a macro that never finishes, e.g., by recursively replaying itself
+var MacroMaxReplay = 10000
+
+// MacroStart starts recording a keyboard macro of the key chords typed in
+// this editor, until MacroStop
+func (ed *Editor) MacroStart() {
+	if ed.Macro.Replaying {
+		return
+	}
+	ed.Macro.On = true
+	ed.Macro.Keys = nil
+}
+
+// MacroStop stops recording the keyboard macro, which becomes the LastMacro
+// if any keys were recorded
+func (ed *Editor) MacroStop() {
+	if !ed.Macro.On {
+		return
+	}
+	ed.Macro.On = false
+	if len(ed.Macro.Keys) > 0 {
+		LastMacro = ed.Macro.Keys
+	}
+	ed.Macro.Keys = nil
+}
+
+// MacroRecord records the given key chord event if recording a macro,
+// except for the macro key functions themselves
+func (ed *Editor) MacroRecord(e events.Event) {
+	if !ed.Macro.On || ed.Macro.Replaying {
+		return
+	}
+	switch keyfun.Of(e.KeyChord()) {
+	case keyfun.MacroStart, keyfun.MacroStop, keyfun.MacroReplay:
+		return
+	}
+	ed.Macro.Keys = append(ed.Macro.Keys, gi.KeyMacroKey{Rune: e.KeyRune(), Code: e.KeyCode(), Mods: e.Modifiers()})
+}
+
+// MacroReplay replays the given keyboard macro n times, sending each key
+// chord through the same KeyHandler and KeyInput processing as typed keys,
+// with all of the edits in one undo group.
+func (ed *Editor) MacroReplay(mac gi.KeyMacro, n int) {
+	if len(mac) == 0 || ed.Buf == nil || ed.Macro.Replaying {
+		return
+	}
+	ed.macroDo(func() {
+		ed.macroReplay(mac, n)
+	})
+}
+
+// macroReplay sends the keys of given macro to the editor n times,
+// up to MacroMaxReplay
+func (ed *Editor) macroReplay(mac gi.KeyMacro, n int) {
+	for i := 0; i < min(n, MacroMaxReplay); i++ {
+		ed.macroPlay(mac)
+	}
+}
+
+// MacroReplayLines replays the given keyboard macro once on each line
+// of the current selection, starting with the cursor at the start of
+// the line, with all of the edits in one undo group.  Lines that are
+// inserted or deleted by the macro are accounted for, so it can
+// be used for bulk edits that a regexp replace can not express.
+func (ed *Editor) MacroReplayLines(mac gi.KeyMacro) {
+	if len(mac) == 0 || ed.Buf == nil || ed.Macro.Replaying || !ed.HasSelection() {
+		return
+	}
+	sel := ed.SelectReg
+	st, last := sel.Start.Ln, sel.End.Ln
+	if sel.End.Ch == 0 && last > st {
+		last--
+	}
+	ed.SelectReset()
+	ed.ClearCursors()
+	ed.macroDo(func() {
+		ed.macroReplayLines(mac, st, last)
+	})
+}
+
+// macroReplayLines sends the keys of given macro to the editor once for
+// each of the lines from st to last inclusive, starting with the cursor
+// at the start of the line
+func (ed *Editor) macroReplayLines(mac gi.KeyMacro, st, last int) {
+	nl := ed.Buf.NumLines()
+	for ln := st; ln <= last && ln < nl; ln++ {
+		ed.CursorPos = lex.Pos{Ln: ln}
+		ed.macroPlay(mac)
+		// lines added or removed by the macro shift the ones after it
+		dn := ed.Buf.NumLines() - nl
+		ln += dn
+		last += dn
+		nl = ed.Buf.NumLines()
+	}
+}
+
+// macroDo runs given function to replay a macro, with the edits in one
+// undo group
+func (ed *Editor) macroDo(fun func()) {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	ed.macroGroup(fun)
+	ed.SetCursorShow(ed.CursorPos)
+}
+
+// macroGroup runs given function with Macro.Replaying set, so the keys
+// are not recorded, and with the edits in one undo group
+func (ed *Editor) macroGroup(fun func()) {
+	autoSave := ed.Buf.BatchUpdateStart()
+	ed.Buf.Undos.Mu.Lock()
+	upos := ed.Buf.Undos.Pos
+	ed.Buf.Undos.Mu.Unlock()
+	ed.Macro.Replaying = true
+	fun()
+	ed.Macro.Replaying = false
+	ed.Buf.Undos.SetGroupFrom(upos)
+	ed.Buf.BatchUpdateEnd(autoSave)
+}
+
+// macroPlay sends the keys of given macro to the editor once
+func (ed *Editor) macroPlay(mac gi.KeyMacro) {
+	for _, mk := range mac {
+		ed.KeyChordInput(events.NewKey(events.KeyChord, mk.Rune, mk.Code, mk.Mods))
+	}
+}
+
+// MacroReplayKey replays the LastMacro, for the MacroReplay key function:
+// on each selected line if more than one line is selected, else once.
+func (ed *Editor) MacroReplayKey() {
+	if len(LastMacro) == 0 {
+		return
+	}
+	if ed.HasSelection() && ed.SelectReg.Start.Ln != ed.SelectReg.End.Ln {
+		ed.MacroReplayLines(LastMacro)
+		return
+	}
+	ed.MacroReplay(LastMacro, 1)
+}
+
+// MacroReplayCountPrompt prompts for the number of times to replay the
+// LastMacro, for the MacroReplayCount key function
+func (ed *Editor) MacroReplayCountPrompt() {
+	if len(LastMacro) == 0 {
+		return
+	}
+	dlg := gi.NewDialog(ed).Title("Replay Keyboard Macro").Prompt("Number of times to replay the last keyboard macro: "+LastMacro.String()).StringPrompt("", "Count..")
+	dlg.OnAccept(func(e events.Event) {
+		n, err := laser.ToInt(dlg.Data.(string))
+		if err == nil && n > 0 {
+			ed.MacroReplay(LastMacro, int(n))
+		}
+	}).Run()
+}
+
+// MacroSave saves the LastMacro in the preferences, with given name,
+// and saves the preferences
+func (ed *Editor) MacroSave(name string) error {
+	if name == "" || len(LastMacro) == 0 {
+		return fmt.Errorf("texteditor.MacroSave: no macro has been recorded, or no name given")
+	}
+	pf := &gi.Prefs.Editor
+	if pf.Macros == nil {
+		pf.Macros = make(map[string]gi.KeyMacro)
+	}
+	pf.Macros[name] = LastMacro
+	return gi.Prefs.Save()
+}
+
+// MacroSavePrompt prompts for a name to save the LastMacro under in the preferences
+func (ed *Editor) MacroSavePrompt() {
+	if len(LastMacro) == 0 {
+		return
+	}
+	dlg := gi.NewDialog(ed).Title("Save Keyboard Macro").Prompt("Name to save the last keyboard macro as: "+LastMacro.String()).StringPrompt("", "Macro name..")
+	dlg.OnAccept(func(e events.Event) {
+		if err := ed.MacroSave(dlg.Data.(string)); err != nil {
+			slog.Error(err.Error())
+		}
+	}).Run()
+}
+
+// MacroNamed returns the macro saved in the preferences with given name
+func MacroNamed(name string) (gi.KeyMacro, bool) {
+	mac, ok := gi.Prefs.Editor.Macros[name]
+	return mac, ok
+}
+
+// MacroReplayNamed replays the macro saved in the preferences with given
+// name n times, or on each selected line if lines is true
+func (ed *Editor) MacroReplayNamed(name string, n int, lines bool) error {
+	mac, ok := MacroNamed(name)
+	if !ok {
+		return fmt.Errorf("texteditor.MacroReplayNamed: no macro named: %q", name)
+	}
+	if lines {
+		ed.MacroReplayLines(mac)
+	} else {
+		ed.MacroReplay(mac, n)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/keyfun"
+	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
+)
+
+// macroTestHandler is a KeyHandler that inserts the rune of each key at
+// the cursor, and a new line for Enter, so macros can be tested without
+// a window
+type macroTestHandler struct{}
+
+func (mh macroTestHandler) HandleKey(ed *Editor, e events.Event) {
+	e.SetHandled()
+	txt := string(e.KeyRune())
+	if e.KeyCode() == key.CodeReturnEnter {
+		txt = "\n"
+	}
+	tbe := ed.Buf.InsertText(ed.CursorPos, []byte(txt), EditSignal)
+	ed.CursorPos = tbe.Reg.End
+}
+
+// macroTestKeyMap sets the active key map to one with the macro key
+// functions, restoring the previous one and the LastMacro at the end of the test
+func macroTestKeyMap(t *testing.T) {
+	am, lm := keyfun.ActiveMap, LastMacro
+	t.Cleanup(func() {
+		keyfun.ActiveMap, LastMacro = am, lm
+	})
+	keyfun.ActiveMap = &keyfun.Map{
+		key.NewChord(0, key.CodeF3, 0): keyfun.MacroStart,
+		key.NewChord(0, key.CodeF4, 0): keyfun.MacroStop,
+	}
+}
+
+func TestMacroRecord(t *testing.T) {
+	macroTestKeyMap(t)
+	ka := events.NewKey(events.KeyChord, 'a', key.CodeA, 0)
+	kf3 := events.NewKey(events.KeyChord, 0, key.CodeF3, 0)
+	kent := events.NewKey(events.KeyChord, 0, key.CodeReturnEnter, 0)
+
+	ed := &Editor{}
+	ed.MacroRecord(ka)
+	if len(ed.Macro.Keys) != 0 {
+		t.Errorf("keys recorded before MacroStart: %v", ed.Macro.Keys)
+	}
+	ed.MacroStart()
+	for _, k := range []*events.Key{ka, kf3, kent} {
+		ed.MacroRecord(k)
+	}
+	ed.Macro.Replaying = true
+	ed.MacroRecord(ka)
+	ed.Macro.Replaying = false
+	ed.MacroStop()
+	want := gi.KeyMacro{{Rune: 'a', Code: key.CodeA}, {Code: key.CodeReturnEnter}}
+	if !slices.Equal(LastMacro, want) {
+		t.Errorf("LastMacro = %v, want %v", LastMacro, want)
+	}
+	if ed.Macro.On || ed.Macro.Keys != nil {
+		t.Errorf("still recording after MacroStop")
+	}
+
+	// a macro with no keys does not replace the LastMacro
+	ed.MacroStart()
+	ed.MacroStop()
+	if !slices.Equal(LastMacro, want) {
+		t.Errorf("LastMacro after empty recording = %v, want %v", LastMacro, want)
+	}
+}
+
+// macroTestEditor returns an Editor viewing a new Buf with given text,
+// using the macroTestHandler
+func macroTestEditor(txt string) *Editor {
+	tb := NewBuf()
+	tb.SetText([]byte(txt))
+	ed := &Editor{}
+	ed.Buf = tb
+	ed.KeyHandler = macroTestHandler{}
+	return ed
+}
+
+func TestMacroReplay(t *testing.T) {
+	macroTestKeyMap(t)
+	mac := gi.KeyMacro{{Rune: 'x', Code: key.CodeX}, {Rune: 'y', Code: key.CodeY}}
+	ed := macroTestEditor("ab\n")
+	ed.CursorPos.Ch = 1
+	ed.macroGroup(func() {
+		ed.macroReplay(mac, 2)
+	})
+	if txt := string(ed.Buf.Text()); txt != "axyxyb\n" {
+		t.Errorf("text after replaying twice = %q", txt)
+	}
+	if ed.Macro.Replaying {
+		t.Errorf("still replaying after macroGroup")
+	}
+	ed.Buf.Undo()
+	if txt := string(ed.Buf.Text()); txt != "ab\n" {
+		t.Errorf("text after undoing the replay = %q", txt)
+	}
+
+	defer func(mx int) { MacroMaxReplay = mx }(MacroMaxReplay)
+	MacroMaxReplay = 3
+	ed = macroTestEditor("\n")
+	ed.macroGroup(func() {
+		ed.macroReplay(mac, 100)
+	})
+	if txt := string(ed.Buf.Text()); txt != "xyxyxy\n" {
+		t.Errorf("text after replaying more than MacroMaxReplay = %q", txt)
+	}
+}
+
+func TestMacroReplayLines(t *testing.T) {
+	macroTestKeyMap(t)
+	// the macro adds a line before each line, which shifts the lines after it
+	mac := gi.KeyMacro{{Rune: '-', Code: key.CodeHyphenMinus}, {Code: key.CodeReturnEnter}}
+	ed := macroTestEditor("a\nb\nc\nd\n")
+	ed.macroGroup(func() {
+		ed.macroReplayLines(mac, 0, 2)
+	})
+	if txt := string(ed.Buf.Text()); txt != "-\na\n-\nb\n-\nc\nd\n" {
+		t.Errorf("text after replaying on lines = %q", txt)
+	}
+	ed.Buf.Undo()
+	if txt := string(ed.Buf.Text()); txt != "a\nb\nc\nd\n" {
+		t.Errorf("text after undoing the replay = %q", txt)
+	}
+}
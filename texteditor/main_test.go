@@ -0,0 +1,47 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"os"
+	"testing"
+
+	"goki.dev/girl/paint"
+	"goki.dev/goosi"
+)
+
+// testApp is the goosi.App for tests, which run without a driver,
+// providing only the prefs directories that are needed to make a Buf
+// (for the custom highlighting styles), and what is needed to configure,
+// style and lay out a Scene that is not shown
+type testApp struct {
+	goosi.App
+
+	prefsDir string
+}
+
+func (app *testApp) AppPrefsDir() string {
+	return app.prefsDir
+}
+
+func (app *testApp) GoGiPrefsDir() string {
+	return app.prefsDir
+}
+
+func (app *testApp) Platform() goosi.Platforms {
+	return goosi.LinuxX11
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "texteditor-test")
+	if err != nil {
+		panic(err)
+	}
+	goosi.TheApp = &testApp{prefsDir: dir}
+	paint.FontLibrary.InitFontPaths(dir) // just the Go fonts
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,380 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"fmt"
+	"strings"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/states"
+	"goki.dev/girl/styles"
+	"goki.dev/girl/units"
+	"goki.dev/glop/dirs"
+	"goki.dev/goosi/events"
+	"goki.dev/icons"
+	"goki.dev/ki/v2"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// MergeView presents a three-way merge for resolving conflicts between
+// two versions of a file (A and B) that were both derived from a common
+// base version: the A (left, ours), Base and B (right, theirs) versions
+// are shown side-by-side in [DiffTextView]s above the merged result,
+// with the lines changed in each hunk colored, and a toolbar to go to
+// each conflict hunk and take the lines from A, B or both, and to save
+// the result.  Double-clicking on a line number in the A or B version
+// takes the lines from that version for the conflict at that line.
+// Taking a version for a hunk regenerates the merged text, so any direct
+// edits to it should be made after all the conflicts have been resolved.
+type MergeView struct {
+	gi.Frame
+
+	// file name for the merged result, which is where it is saved
+	Filename gi.FileName
+
+	// the merge being resolved -- use SetMerge to set
+	Merge *textbuf.Merge `set:"-" json:"-" xml:"-"`
+
+	// index of the current hunk in the Merge
+	CurHunk int `json:"-" xml:"-"`
+
+	// textbuf for the A version
+	BufA *Buf `json:"-" xml:"-"`
+
+	// textbuf for the Base version
+	BufBase *Buf `json:"-" xml:"-"`
+
+	// textbuf for the B version
+	BufB *Buf `json:"-" xml:"-"`
+
+	// textbuf for the merged result
+	BufOut *Buf `json:"-" xml:"-"`
+}
+
+func (mv *MergeView) OnInit() {
+	mv.Lay = gi.LayoutVert
+	mv.Style(func(s *styles.Style) {
+		s.SetStretchMax()
+	})
+	mv.OnWidgetAdded(func(w gi.Widget) {
+		switch w.PathFrom(mv) {
+		case "text-abs":
+			w.Style(func(s *styles.Style) {
+				s.SetStretchMax()
+			})
+		case "text-abs/text-a", "text-abs/text-base", "text-abs/text-b":
+			w.Style(func(s *styles.Style) {
+				s.SetStretchMax()
+				s.SetMinPrefWidth(units.Ch(60))
+				s.SetMinPrefHeight(units.Em(20))
+				s.Font.Family = string(gi.Prefs.MonoFont)
+			})
+		case "text-out":
+			w.Style(func(s *styles.Style) {
+				s.SetStretchMax()
+				s.SetMinPrefWidth(units.Ch(80))
+				s.SetMinPrefHeight(units.Em(20))
+				s.Font.Family = string(gi.Prefs.MonoFont)
+			})
+		}
+	})
+}
+
+// MergeFile opens a MergeView dialog for resolving the conflicts in
+// given file, which contains conflict markers as written by git and diff3.
+func MergeFile(ctx gi.Widget, fname string) (*MergeView, error) {
+	fb, err := textbuf.FileBytes(fname)
+	if err != nil {
+		return nil, err
+	}
+	mg := textbuf.ParseConflicts(strings.Split(string(fb), "\n"))
+	return MergeViewDialog(ctx, mg, fname), nil
+}
+
+// MergeFiles opens a MergeView dialog for a three-way merge of the
+// changes from the common base file to each of the afile and bfile
+// versions of it, using textbuf.Merge3, which saves the result in
+// given output file.
+func MergeFiles(ctx gi.Widget, basefile, afile, bfile, outfile string) (*MergeView, error) {
+	var lns [3][]string
+	for i, fn := range []string{basefile, afile, bfile} {
+		fb, err := textbuf.FileBytes(fn)
+		if err != nil {
+			return nil, err
+		}
+		lns[i] = textbuf.BytesToLineStrings(fb, false) // don't add new lines
+	}
+	mg := textbuf.Merge3(lns[0], lns[1], lns[2])
+	mg.LabelA = dirs.DirAndFile(afile)
+	mg.LabelB = dirs.DirAndFile(bfile)
+	mg.Update() // update conflict markers for labels
+	return MergeViewDialog(ctx, mg, outfile), nil
+}
+
+// MergeViewDialog opens a dialog for resolving the conflicts in
+// given Merge using a MergeView, which saves the result in given file.
+func MergeViewDialog(ctx gi.Widget, mg *textbuf.Merge, fname string) *MergeView {
+	title := "Resolve Conflicts: " + dirs.DirAndFile(fname)
+	dlg := gi.NewDialog(ctx).Title(title)
+	mv := NewMergeView(dlg.Scene, "merge-view")
+	mv.SetMerge(mg, fname)
+	dlg.FullWindow(true)
+	dlg.Run()
+	return mv
+}
+
+// SetMerge sets the Merge to resolve and the file name for saving
+// the result, and configures the view for it.
+func (mv *MergeView) SetMerge(mg *textbuf.Merge, fname string) {
+	mv.Merge = mg
+	mv.Filename = gi.FileName(fname)
+	mv.CurHunk = mg.NextConflict(-1)
+	mv.ConfigMergeView()
+	mv.BufA.SetText([]byte(strings.Join(mg.A, "\n")))
+	mv.BufBase.SetText([]byte(strings.Join(mg.Base, "\n")))
+	mv.BufB.SetText([]byte(strings.Join(mg.B, "\n")))
+	mv.ColorHunks()
+	mv.UpdateTexts()
+}
+
+// ConfigMergeView configures the toolbar and editors of the view
+func (mv *MergeView) ConfigMergeView() {
+	config := ki.Config{}
+	config.Add(gi.ToolbarType, "toolbar")
+	config.Add(gi.SplitsType, "text-abs")
+	config.Add(EditorType, "text-out")
+	mods, updt := mv.ConfigChildren(config)
+	if !mods {
+		updt = mv.UpdateStart()
+	} else {
+		mv.ConfigTexts()
+		mv.ConfigToolbar()
+	}
+	mv.UpdateEnd(updt)
+}
+
+// ConfigTexts configures the Bufs and the views for the A, Base and B
+// versions, which are read-only, and the merged result.
+func (mv *MergeView) ConfigTexts() {
+	if mv.BufA == nil {
+		mv.BufA = NewBuf()
+		mv.BufBase = NewBuf()
+		mv.BufB = NewBuf()
+	}
+	if mv.BufOut == nil {
+		mv.BufOut = NewBuf()
+	}
+	sp := mv.Splits()
+	sp.Dim = mat32.X
+	config := ki.Config{}
+	config.Add(DiffTextViewType, "text-a")
+	config.Add(DiffTextViewType, "text-base")
+	config.Add(DiffTextViewType, "text-b")
+	sp.ConfigChildren(config)
+	for i, tb := range []*Buf{mv.BufA, mv.BufBase, mv.BufB} {
+		tb.Opts.LineNos = true
+		tb.SetReadOnly(true)
+		dt := sp.Child(i).(*DiffTextView)
+		dt.SetBuf(tb)
+		dt.SetState(true, states.ReadOnly)
+	}
+	mv.BufOut.Opts.LineNos = true
+	mv.BufOut.Filename = mv.Filename
+	mv.BufOut.Stat() // update markup
+	mv.OutEditor().SetBuf(mv.BufOut)
+}
+
+// Toolbar returns the toolbar widget
+func (mv *MergeView) Toolbar() *gi.Toolbar {
+	return mv.ChildByName("toolbar", 0).(*gi.Toolbar)
+}
+
+// Splits returns the Splits containing the A, Base and B views
+func (mv *MergeView) Splits() *gi.Splits {
+	return mv.ChildByName("text-abs", 1).(*gi.Splits)
+}
+
+// TextViews returns the DiffTextViews showing the A, Base and B versions
+func (mv *MergeView) TextViews() (a, base, b *DiffTextView) {
+	sp := mv.Splits()
+	return sp.Child(0).(*DiffTextView), sp.Child(1).(*DiffTextView), sp.Child(2).(*DiffTextView)
+}
+
+// OutEditor returns the Editor showing the merged result
+func (mv *MergeView) OutEditor() *Editor {
+	return mv.ChildByName("text-out", 2).(*Editor)
+}
+
+// ConfigToolbar configures the toolbar actions
+func (mv *MergeView) ConfigToolbar() {
+	tb := mv.Toolbar()
+	gi.NewButton(tb, "prev").SetText("Prev").SetIcon(icons.KeyboardArrowUp).
+		SetTooltip("go to the previous conflict").
+		OnClick(func(e events.Event) {
+			mv.PrevConflict()
+		})
+	gi.NewButton(tb, "next").SetText("Next").SetIcon(icons.KeyboardArrowDown).
+		SetTooltip("go to the next conflict").
+		OnClick(func(e events.Event) {
+			mv.NextConflict()
+		})
+	gi.NewSeparator(tb)
+	gi.NewButton(tb, "take-a").SetText("Take A").SetIcon(icons.ArrowBack).
+		SetTooltip("use the lines from the A (left) version for the current conflict").
+		OnClick(func(e events.Event) {
+			mv.Take(textbuf.MergeTakeA)
+		})
+	gi.NewButton(tb, "take-b").SetText("Take B").SetIcon(icons.ArrowForward).
+		SetTooltip("use the lines from the B (right) version for the current conflict").
+		OnClick(func(e events.Event) {
+			mv.Take(textbuf.MergeTakeB)
+		})
+	gi.NewButton(tb, "take-both").SetText("Take Both").SetIcon(icons.Add).
+		SetTooltip("use the lines from the A version followed by those from the B version for the current conflict").
+		OnClick(func(e events.Event) {
+			mv.Take(textbuf.MergeTakeBoth)
+		})
+	gi.NewSeparator(tb)
+	gi.NewButton(tb, "save").SetText("Save").SetIcon(icons.Save).
+		SetTooltip("save the merged result to the file").
+		OnClick(func(e events.Event) {
+			mv.SaveFile()
+		})
+	gi.NewLabel(tb, "status")
+}
+
+// ColorHunks sets the line colors of the lines in each hunk in the
+// A, Base and B versions: red for conflicts, and blue for changes
+// that are merged automatically.
+func (mv *MergeView) ColorHunks() {
+	bufs := []*Buf{mv.BufA, mv.BufBase, mv.BufB}
+	for _, tb := range bufs {
+		tb.LineColors = nil
+	}
+	for _, h := range mv.Merge.Hunks {
+		clr := colors.Blue
+		if h.Conflict {
+			clr = colors.Red
+		}
+		rngs := [][2]int{{h.ASt, h.AEd}, {h.BaseSt, h.BaseEd}, {h.BSt, h.BEd}}
+		for i, tb := range bufs {
+			for ln := rngs[i][0]; ln < rngs[i][1]; ln++ {
+				tb.SetLineColor(ln, clr)
+			}
+		}
+	}
+}
+
+// UpdateTexts updates the merged text from the Merge,
+// and highlights the current hunk.
+func (mv *MergeView) UpdateTexts() {
+	if mv.Merge == nil {
+		return
+	}
+	updt := mv.UpdateStart()
+	defer mv.UpdateEndRender(updt)
+	mv.BufOut.SetText([]byte(strings.Join(mv.Merge.Lines, "\n")))
+	mv.BufOut.SetChanged()
+	mv.UpdateStatus()
+	mv.ShowHunk()
+}
+
+// UpdateStatus updates the status label in the toolbar with
+// the number of unresolved conflicts.
+func (mv *MergeView) UpdateStatus() {
+	n, un := mv.Merge.NumConflicts()
+	lbl := mv.Toolbar().ChildByName("status", 0).(*gi.Label)
+	lbl.SetText(fmt.Sprintf("%d of %d conflicts unresolved", un, n))
+	lbl.Update()
+}
+
+// ShowHunk highlights the lines of the current hunk in each of
+// the editors, and scrolls them to show it.
+func (mv *MergeView) ShowHunk() {
+	av, bsv, bv := mv.TextViews()
+	eds := []*Editor{av.AsEditor(), bsv.AsEditor(), bv.AsEditor(), mv.OutEditor()}
+	if mv.CurHunk < 0 || mv.CurHunk >= len(mv.Merge.Hunks) {
+		for _, ed := range eds {
+			ed.ClearHighlights()
+		}
+		return
+	}
+	h := mv.Merge.Hunks[mv.CurHunk]
+	rngs := [][2]int{{h.ASt, h.AEd}, {h.BaseSt, h.BaseEd}, {h.BSt, h.BEd}, {h.OutSt, h.OutEd}}
+	for i, ed := range eds {
+		st, end := rngs[i][0], rngs[i][1]
+		ed.Highlights = []textbuf.Region{textbuf.NewRegion(st, 0, end, 0)}
+		ed.SetCursorShow(lex.Pos{Ln: st})
+		ed.SetNeedsRender()
+	}
+}
+
+// NextConflict goes to the next conflict hunk
+func (mv *MergeView) NextConflict() {
+	mv.CurHunk = mv.Merge.NextConflict(mv.CurHunk)
+	mv.ShowHunk()
+}
+
+// PrevConflict goes to the previous conflict hunk
+func (mv *MergeView) PrevConflict() {
+	mv.CurHunk = mv.Merge.PrevConflict(mv.CurHunk)
+	mv.ShowHunk()
+}
+
+// Take resolves the current hunk by taking the lines from given
+// version(s), and goes to the next unresolved conflict, if any.
+func (mv *MergeView) Take(take textbuf.MergeTake) {
+	if mv.Merge == nil || mv.CurHunk < 0 {
+		return
+	}
+	mv.Merge.Resolve(mv.CurHunk, take)
+	if _, un := mv.Merge.NumConflicts(); un > 0 {
+		for {
+			mv.CurHunk = mv.Merge.NextConflict(mv.CurHunk)
+			if mv.Merge.Hunks[mv.CurHunk].Take == textbuf.MergeUnresolved {
+				break
+			}
+		}
+	}
+	mv.UpdateTexts()
+}
+
+// TakeAtLine resolves the conflict hunk that contains given line of
+// the A (if a is true) or B version by taking the lines from that
+// version, returning false if there is no conflict at that line.
+func (mv *MergeView) TakeAtLine(a bool, ln int) bool {
+	if mv.Merge == nil {
+		return false
+	}
+	for i, h := range mv.Merge.Hunks {
+		st, ed, take := h.BSt, h.BEd, textbuf.MergeTakeB
+		if a {
+			st, ed, take = h.ASt, h.AEd, textbuf.MergeTakeA
+		}
+		if h.Conflict && ln >= st && (ln < ed || ln == st) {
+			mv.CurHunk = i
+			mv.Take(take)
+			return true
+		}
+	}
+	return false
+}
+
+// SaveFile saves the merged result to the Filename,
+// and sends a Change event if successful.
+func (mv *MergeView) SaveFile() error {
+	if _, un := mv.Merge.NumConflicts(); un > 0 {
+		gi.NewSnackbar(mv, gi.SnackbarOpts{Text: fmt.Sprintf("Saved with %d unresolved conflicts still marked", un)}).Run()
+	}
+	err := mv.BufOut.SaveFile(mv.Filename)
+	if err == nil {
+		mv.SendChange()
+	}
+	return err
+}
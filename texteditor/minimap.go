@@ -0,0 +1,423 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"unicode"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/texteditor/histyle"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/goosi/events"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+)
+
+// MinimapWidth is the width of the minimap column, in dots, which is
+// also the maximum number of characters of each line that are shown
+var MinimapWidth = 100
+
+// MinimapLineHeight is the height of each line in the minimap, in dots
+var MinimapLineHeight = 2
+
+// Minimap holds the state for the optional overview column at the right
+// side of the Editor, which shows a scaled-down rendering of the whole
+// buffer in its highlighting colors, marking the visible viewport, search
+// highlights, line colors and icons.  Clicking or dragging in it
+// scrolls the editor.  The rendering of the text is cached in Img,
+// which is updated incrementally from the Buf signals for edits:
+// the signals, which can come from other goroutines, only record the
+// Edits under the Mu lock, and the Img is only updated when rendering.
+type Minimap struct {
+
+	// if true, the minimap is shown
+	On bool
+
+	// mutex protecting the update state, Stale and Edits, and the Img
+	Mu sync.Mutex
+
+	// cached rendering of the lines, with MinimapLineHeight rows of pixels
+	// per line, and one pixel per character
+	Img *image.RGBA
+
+	// number of lines currently in Img
+	NLines int
+
+	// if true, all of the lines need to be rendered again into Img, e.g.,
+	// after new markup
+	Stale bool
+
+	// edits since the last update, which are applied to Img when rendering
+	Edits []*textbuf.Edit
+
+	// lines that need to be rendered again into Img, after edits
+	Dirty map[int]bool
+
+	// bounding box where the minimap was last rendered, in scene coordinates
+	BBox image.Rectangle
+
+	// vertical offset into Img of the top of the BBox, for buffers
+	// that are too long to show all at once
+	Off int
+
+	// true while dragging in the minimap
+	Dragging bool
+}
+
+// SetMinimap sets whether the minimap is shown
+func (ed *Editor) SetMinimap(on bool) *Editor {
+	mm := &ed.Minimap
+	mm.Mu.Lock()
+	if mm.On == on {
+		mm.Mu.Unlock()
+		return ed
+	}
+	mm.On = on
+	mm.Stale = true
+	if !on {
+		mm.Img = nil
+	}
+	mm.Mu.Unlock()
+	ed.SetNeedsLayout()
+	return ed
+}
+
+// MinimapOffset returns the width taken up by the minimap, if it is on
+func (ed *Editor) MinimapOffset() float32 {
+	if !ed.Minimap.On {
+		return 0
+	}
+	return float32(MinimapWidth)
+}
+
+// MinimapBufSignal records the given signal from the Buf for updating the
+// cached minimap rendering: all of the lines are rendered again after
+// new text or markup, and only the edited lines for inserts and deletes.
+// The Buf signals can come from other goroutines, so this only records
+// what needs to be done under the Mu lock, for MinimapUpdate.
+func (ed *Editor) MinimapBufSignal(sig BufSignals, tbe *textbuf.Edit) {
+	mm := &ed.Minimap
+	mm.Mu.Lock()
+	if !mm.On {
+		mm.Mu.Unlock()
+		return
+	}
+	switch sig {
+	case BufNew, BufMarkUpdt, BufClosed:
+		mm.Stale = true
+	case BufMods, BufInsert, BufDelete:
+		switch {
+		case mm.Stale:
+		case tbe == nil:
+			mm.Stale = true
+		default:
+			mm.Edits = append(mm.Edits, tbe)
+		}
+	}
+	mm.Mu.Unlock()
+	ed.SetNeedsRender()
+}
+
+// minimapEdit updates the cached image for given edit, moving the lines
+// after it for inserted and deleted lines, and marking the edited lines
+// as Dirty.  Must be called under the Mu lock.
+func (ed *Editor) minimapEdit(tbe *textbuf.Edit) {
+	mm := &ed.Minimap
+	st, nl := tbe.Reg.Start.Ln, tbe.Reg.End.Ln-tbe.Reg.Start.Ln
+	if tbe.Rect { // rectangular edits do not change the number of lines
+		nl = 0
+	} else if tbe.Delete {
+		nl = -nl
+	}
+	if nl != 0 {
+		ed.minimapShift(st+1, nl)
+	}
+	if mm.Dirty == nil {
+		mm.Dirty = make(map[int]bool)
+	}
+	edln := st + max(nl, 0)
+	if tbe.Rect {
+		edln = tbe.Reg.End.Ln
+	}
+	for ln := st; ln <= edln; ln++ {
+		mm.Dirty[ln] = true
+	}
+}
+
+// minimapShift moves the lines in the cached image starting at given line
+// down by given number of lines, or up if negative, for inserted or
+// deleted lines.  Must be called under the Mu lock.
+func (ed *Editor) minimapShift(st, nl int) {
+	mm := &ed.Minimap
+	h := MinimapLineHeight
+	nlines := max(mm.NLines+nl, 0)
+	ed.minimapAlloc(nlines)
+	img := mm.Img
+	rowsz := img.Stride * h
+	if nl > 0 {
+		if st < mm.NLines {
+			copy(img.Pix[(st+nl)*rowsz:], img.Pix[st*rowsz:mm.NLines*rowsz])
+		}
+	} else if st-nl <= mm.NLines {
+		copy(img.Pix[st*rowsz:], img.Pix[(st-nl)*rowsz:mm.NLines*rowsz])
+		clear(img.Pix[nlines*rowsz : mm.NLines*rowsz])
+	}
+	// moving the dirty lines too
+	if len(mm.Dirty) > 0 {
+		dirty := make(map[int]bool, len(mm.Dirty))
+		for ln := range mm.Dirty {
+			if ln >= st {
+				ln += nl
+			}
+			if ln >= 0 {
+				dirty[ln] = true
+			}
+		}
+		mm.Dirty = dirty
+	}
+	mm.NLines = nlines
+}
+
+// minimapAlloc ensures that the cached image has room for given number of lines
+func (ed *Editor) minimapAlloc(nlines int) {
+	mm := &ed.Minimap
+	ht := nlines * MinimapLineHeight
+	if mm.Img != nil && mm.Img.Bounds().Dy() >= ht {
+		return
+	}
+	img := image.NewRGBA(image.Rect(0, 0, MinimapWidth, max(ht+ht/2, 100)))
+	if mm.Img != nil {
+		copy(img.Pix, mm.Img.Pix)
+	}
+	mm.Img = img
+}
+
+// MinimapUpdate renders the lines that have changed into the cached image,
+// or all of them if it is Stale
+func (ed *Editor) MinimapUpdate() {
+	ed.Minimap.Mu.Lock()
+	defer ed.Minimap.Mu.Unlock()
+	ed.minimapUpdate()
+}
+
+// minimapUpdate does MinimapUpdate under the Mu lock
+func (ed *Editor) minimapUpdate() {
+	mm := &ed.Minimap
+	tb := ed.Buf
+	if !mm.Stale && mm.Img != nil {
+		for _, tbe := range mm.Edits {
+			ed.minimapEdit(tbe)
+		}
+	}
+	mm.Edits = nil
+	nlines := tb.NumLines()
+	if mm.Img == nil || mm.NLines != nlines {
+		mm.Stale = true
+	}
+	if !mm.Stale && len(mm.Dirty) == 0 {
+		return
+	}
+	hs := tb.Hi.HiStyle
+	if hs == nil {
+		hs = &histyle.Style{}
+	}
+	clrs := map[token.Tokens]color.RGBA{}
+	tb.MarkupMu.RLock()
+	defer tb.MarkupMu.RUnlock()
+	if mm.Stale {
+		mm.Img = nil
+		ed.minimapAlloc(nlines)
+		mm.NLines = nlines
+		for ln := 0; ln < nlines; ln++ {
+			ed.minimapRenderLine(ln, hs, clrs)
+		}
+	} else {
+		for ln := range mm.Dirty {
+			if ln < nlines {
+				ed.minimapRenderLine(ln, hs, clrs)
+			}
+		}
+	}
+	mm.Stale = false
+	mm.Dirty = nil
+}
+
+// minimapRenderLine renders given line into the cached image, using the
+// colors of the given highlighting style for the HiTags of the line,
+// caching them in clrs.  Must be called under MarkupMu.RLock.
+func (ed *Editor) minimapRenderLine(ln int, hs *histyle.Style, clrs map[token.Tokens]color.RGBA) {
+	img := ed.Minimap.Img
+	h := MinimapLineHeight
+	y := ln * h
+	draw.Draw(img, image.Rect(0, y, MinimapWidth, y+h), image.Transparent, image.Point{}, draw.Src)
+	txt := ed.Buf.Line(ln)
+	if len(txt) == 0 {
+		return
+	}
+	def := ed.Styles.Color
+	lclrs := make([]color.RGBA, len(txt))
+	for i := range lclrs {
+		lclrs[i] = def
+	}
+	if ln < ed.Buf.HiTags.Len() {
+		for _, t := range ed.Buf.HiTags.At(ln) { // outer tags are before inner ones
+			clr, ok := clrs[t.Tok.Tok]
+			if !ok {
+				clr = hs.Tag(t.Tok.Tok).Color
+				if colors.IsNil(clr) {
+					clr = def
+				}
+				clrs[t.Tok.Tok] = clr
+			}
+			for i := max(t.St, 0); i < min(t.Ed, len(txt)); i++ {
+				lclrs[i] = clr
+			}
+		}
+	}
+	tabsz := max(ed.Styles.Text.TabSize, 1)
+	x := 0
+	for i, r := range txt {
+		if x >= MinimapWidth {
+			break
+		}
+		switch {
+		case r == '\t':
+			x += tabsz - x%tabsz
+			continue
+		case unicode.IsSpace(r):
+			x++
+			continue
+		}
+		for yy := y; yy < y+max(h-1, 1); yy++ { // leave a gap between lines
+			img.SetRGBA(x, yy, lclrs[i])
+		}
+		x++
+	}
+}
+
+// MinimapBBox returns the bounding box of the minimap column,
+// at the right side of the visible region, within any scrollbar
+func (ed *Editor) MinimapBBox() image.Rectangle {
+	bb := ed.ScBBox
+	x1 := bb.Max.X
+	if ed.HasScroll[mat32.Y] {
+		x1 -= int(ed.Styles.ScrollBarWidth.Dots)
+	}
+	return image.Rect(max(x1-MinimapWidth, bb.Min.X), bb.Min.Y, x1, bb.Max.Y)
+}
+
+// RenderMinimap renders the minimap, if it is on -- called within the
+// context of the overall Render
+func (ed *Editor) RenderMinimap() {
+	mm := &ed.Minimap
+	mm.Mu.Lock()
+	defer mm.Mu.Unlock()
+	if !mm.On || ed.Buf == nil || ed.NLines == 0 {
+		return
+	}
+	ed.minimapUpdate()
+	bb := ed.MinimapBBox()
+	mm.BBox = bb
+	h := MinimapLineHeight
+	total := mm.NLines * h
+	stln := ed.FirstVisibleLine(0)
+	edln := ed.LastVisibleLine(stln)
+	mm.Off = 0
+	if total > bb.Dy() { // keep the viewport in view, moving in proportion to it
+		nvis := edln - stln + 1
+		frac := float32(stln) / float32(max(ed.NLines-nvis, 1))
+		mm.Off = int(mat32.Min(frac, 1) * float32(total-bb.Dy()))
+	}
+	lineBox := func(st, ed int) image.Rectangle {
+		return image.Rect(bb.Min.X, bb.Min.Y+st*h-mm.Off, bb.Max.X, bb.Min.Y+(ed+1)*h-mm.Off).Intersect(bb)
+	}
+
+	rs := &ed.Sc.RenderState
+	rs.Lock()
+	defer rs.Unlock()
+	fill := func(r image.Rectangle, clr color.Color) {
+		draw.Draw(rs.Image, r.Intersect(rs.Bounds), &image.Uniform{clr}, image.Point{}, draw.Over)
+	}
+	draw.Draw(rs.Image, bb.Intersect(rs.Bounds), &image.Uniform{ed.LineNumberColor.Solid}, image.Point{}, draw.Src)
+	for ln, clr := range ed.Buf.LineColors {
+		fill(lineBox(ln, ln), colors.SetAF32(clr, 0.5))
+	}
+	hclr := colors.SetAF32(ed.HighlightColor.Solid, 0.6)
+	for _, reg := range ed.Highlights {
+		reg := ed.Buf.AdjustReg(reg)
+		if reg.IsNil() {
+			continue
+		}
+		fill(lineBox(reg.Start.Ln, reg.End.Ln), hclr)
+	}
+	draw.Draw(rs.Image, bb.Intersect(rs.Bounds), mm.Img, image.Point{0, mm.Off}, draw.Over)
+	for ln := range ed.Buf.LineIcons { // icons are marked at the left edge
+		r := lineBox(ln, ln)
+		r.Max.X = r.Min.X + 3
+		r.Min.Y = r.Max.Y - max(h, 3)
+		fill(r, ed.CursorColor.Solid)
+	}
+	fill(lineBox(stln, edln), colors.SetAF32(ed.SelectColor.Solid, 0.4))
+}
+
+// MinimapLineAt returns the line at given point in scene coordinates,
+// and false if the point is not within the minimap.  If clamp is true,
+// points outside of it vertically return the nearest line.
+func (ed *Editor) MinimapLineAt(pt image.Point, clamp bool) (int, bool) {
+	mm := &ed.Minimap
+	if !mm.On || ed.NLines == 0 {
+		return 0, false
+	}
+	bb := mm.BBox
+	if !clamp && !pt.In(bb) {
+		return 0, false
+	}
+	ln := (pt.Y - bb.Min.Y + mm.Off) / MinimapLineHeight
+	return max(min(ln, ed.NLines-1), 0), true
+}
+
+// MinimapScrollTo scrolls the editor to center the given line
+func (ed *Editor) MinimapScrollTo(ln int) {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	pos := ed.CharStartPos(lex.Pos{Ln: ln})
+	ed.ScrollToVertCenter(int(pos.Y + ed.LineHeight/2))
+}
+
+// HandleMinimapEvents handles clicking and dragging in the minimap,
+// before the other mouse events, to scroll the editor
+func (ed *Editor) HandleMinimapEvents() {
+	ed.On(events.MouseDown, func(e events.Event) {
+		ln, ok := ed.MinimapLineAt(e.LocalPos(), false)
+		if !ok {
+			return
+		}
+		e.SetHandled()
+		ed.Minimap.Dragging = true
+		ed.MinimapScrollTo(ln)
+	})
+	ed.On(events.SlideMove, func(e events.Event) {
+		if !ed.Minimap.Dragging {
+			return
+		}
+		e.SetHandled()
+		if ln, ok := ed.MinimapLineAt(e.LocalPos(), true); ok {
+			ed.MinimapScrollTo(ln)
+		}
+	})
+	stop := func(e events.Event) {
+		if ed.Minimap.Dragging {
+			ed.Minimap.Dragging = false
+			e.SetHandled()
+		}
+	}
+	ed.On(events.SlideStop, stop)
+	ed.On(events.MouseUp, stop)
+}
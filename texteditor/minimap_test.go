@@ -0,0 +1,80 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// minimapWidths returns the number of pixels drawn in the cached
+// minimap image for each line
+func minimapWidths(ed *Editor) []int {
+	mm := &ed.Minimap
+	ws := make([]int, mm.NLines)
+	for ln := range ws {
+		for x := 0; x < MinimapWidth; x++ {
+			if mm.Img.RGBAAt(x, ln*MinimapLineHeight).A > 0 {
+				ws[ln]++
+			}
+		}
+	}
+	return ws
+}
+
+func TestMinimapEdits(t *testing.T) {
+	tb := NewBuf()
+	tb.SetText([]byte("a\nbb\nccc"))
+	ed := &Editor{}
+	ed.Buf = tb
+	tb.AddView(ed)
+	ed.Styles.Color = color.RGBA{255, 255, 255, 255}
+	ed.SetMinimap(true)
+	ed.MinimapUpdate()
+	if ws, want := minimapWidths(ed), []int{1, 2, 3}; !slices.Equal(ws, want) {
+		t.Fatalf("minimap widths = %v, want %v", ws, want)
+	}
+	// a marker on the last line, which is not rendered again for the
+	// edits before it, so it is moved along with the line
+	red := color.RGBA{255, 0, 0, 255}
+	ed.Minimap.Img.SetRGBA(50, 2*MinimapLineHeight, red)
+	marker := func(ln int) bool {
+		return ed.Minimap.Img.RGBAAt(50, ln*MinimapLineHeight) == red
+	}
+
+	tb.InsertText(lex.Pos{Ln: 1}, []byte("x\ny"), EditSignal)
+	if mm := &ed.Minimap; len(mm.Edits) != 1 || mm.Stale || mm.NLines != 3 {
+		t.Errorf("the insert signal should only be recorded: %d edits, stale: %v, %d lines", len(mm.Edits), mm.Stale, mm.NLines)
+	}
+	ed.MinimapUpdate()
+	if ws, want := minimapWidths(ed), []int{1, 1, 3, 4}; !slices.Equal(ws, want) {
+		t.Errorf("minimap widths after insert = %v, want %v", ws, want)
+	}
+	if !marker(3) {
+		t.Errorf("the line after the insert was not moved down")
+	}
+
+	tb.DeleteText(lex.Pos{Ln: 1}, lex.Pos{Ln: 2, Ch: 1}, EditSignal)
+	ed.MinimapUpdate()
+	if ws, want := minimapWidths(ed), []int{1, 2, 4}; !slices.Equal(ws, want) {
+		t.Errorf("minimap widths after delete = %v, want %v", ws, want)
+	}
+	if !marker(2) {
+		t.Errorf("the line after the delete was not moved up")
+	}
+	if len(ed.Minimap.Edits) != 0 || len(ed.Minimap.Dirty) != 0 {
+		t.Errorf("edits are left after update: %v, dirty lines: %v", ed.Minimap.Edits, ed.Minimap.Dirty)
+	}
+
+	// new markup renders all of the lines again
+	ed.MinimapBufSignal(BufMarkUpdt, nil)
+	ed.MinimapUpdate()
+	if ws, want := minimapWidths(ed), []int{1, 2, 3}; !slices.Equal(ws, want) || marker(2) {
+		t.Errorf("minimap widths after new markup = %v, want %v", ws, want)
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"bytes"
+	"slices"
+
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// MultiCursor is an additional cursor in the Editor, with its own
+// position and selection region, for editing at multiple places at once.
+// The primary cursor is always the Editor CursorPos and SelectReg.
+type MultiCursor struct {
+
+	// cursor position
+	Pos lex.Pos
+
+	// selection region -- textbuf.RegionNil if nothing selected
+	Select textbuf.Region
+}
+
+// HasSelection returns whether there is a selected region for this cursor
+func (mc *MultiCursor) HasSelection() bool {
+	return mc.Select.Start.IsLess(mc.Select.End)
+}
+
+// Overlaps returns whether this cursor is at the same position as the
+// other one, or both have selections that overlap
+func (mc *MultiCursor) Overlaps(oc MultiCursor) bool {
+	if mc.Pos == oc.Pos {
+		return true
+	}
+	if !mc.HasSelection() || !oc.HasSelection() {
+		return false
+	}
+	return mc.Select.Start.IsLess(oc.Select.End) && oc.Select.Start.IsLess(mc.Select.End)
+}
+
+// Merge merges the other cursor, which overlaps this one, into this one,
+// so that the selection covers both of their selections, with the cursor
+// position remaining at the same end of the selection.
+func (mc *MultiCursor) Merge(oc MultiCursor) {
+	if !oc.HasSelection() {
+		return
+	}
+	if !mc.HasSelection() {
+		mc.Select = oc.Select
+		mc.Pos = oc.Pos
+		return
+	}
+	atEnd := mc.Pos == mc.Select.End
+	if oc.Select.Start.IsLess(mc.Select.Start) {
+		mc.Select.Start = oc.Select.Start
+	}
+	if mc.Select.End.IsLess(oc.Select.End) {
+		mc.Select.End = oc.Select.End
+	}
+	if atEnd {
+		mc.Pos = mc.Select.End
+	} else {
+		mc.Pos = mc.Select.Start
+	}
+}
+
+// HasMultiCursors returns true if there are any additional cursors
+// beyond the primary one.
+func (ed *Editor) HasMultiCursors() bool {
+	return len(ed.Cursors) > 0
+}
+
+// ClearCursors removes all of the additional cursors, leaving only
+// the primary one.
+func (ed *Editor) ClearCursors() {
+	if !ed.HasMultiCursors() {
+		return
+	}
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	ed.Cursors = nil
+}
+
+// AllCursors returns the primary cursor followed by all of the
+// additional cursors.
+func (ed *Editor) AllCursors() []MultiCursor {
+	cs := make([]MultiCursor, 0, len(ed.Cursors)+1)
+	cs = append(cs, MultiCursor{Pos: ed.CursorPos, Select: ed.SelectReg})
+	return append(cs, ed.Cursors...)
+}
+
+// SetAllCursors sets the primary and additional cursors from given list,
+// where the first one becomes the primary cursor, and any that
+// overlap an earlier one (see [MultiCursor.Overlaps]) are merged into it.
+func (ed *Editor) SetAllCursors(cs []MultiCursor) {
+	if len(cs) == 0 {
+		return
+	}
+	var mcs []MultiCursor
+	for _, mc := range cs {
+		if idx := slices.IndexFunc(mcs, mc.Overlaps); idx >= 0 {
+			mcs[idx].Merge(mc)
+			continue
+		}
+		mcs = append(mcs, mc)
+	}
+	ed.CursorPos = mcs[0].Pos
+	ed.SelectReg = mcs[0].Select
+	ed.Cursors = mcs[1:]
+	if len(ed.Cursors) == 0 {
+		ed.Cursors = nil
+	}
+}
+
+// AddCursor makes given position the primary cursor, keeping the current
+// primary cursor (and its selection) as an additional cursor.
+// If there is already a cursor at that position, it is removed instead.
+func (ed *Editor) AddCursor(pos lex.Pos) {
+	pos = ed.Buf.ValidPos(pos)
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	if idx := slices.IndexFunc(ed.Cursors, func(mc MultiCursor) bool { return mc.Pos == pos }); idx >= 0 {
+		ed.Cursors = slices.Delete(ed.Cursors, idx, idx+1)
+		return
+	}
+	if pos == ed.CursorPos {
+		return
+	}
+	ed.Cursors = append(ed.Cursors, MultiCursor{Pos: ed.CursorPos, Select: ed.SelectReg})
+	ed.SelectMode = false
+	ed.SelectReg = textbuf.RegionNil
+	ed.SetCursorShow(pos)
+}
+
+// AddCursorAbove adds a cursor on the line above the top-most cursor,
+// at the column of the primary cursor (or the end of the line if shorter).
+func (ed *Editor) AddCursorAbove() {
+	ln := ed.CursorPos.Ln
+	for _, mc := range ed.Cursors {
+		ln = min(ln, mc.Pos.Ln)
+	}
+	ed.addCursorAtLine(ln - 1)
+}
+
+// AddCursorBelow adds a cursor on the line below the bottom-most cursor,
+// at the column of the primary cursor (or the end of the line if shorter).
+func (ed *Editor) AddCursorBelow() {
+	ln := ed.CursorPos.Ln
+	for _, mc := range ed.Cursors {
+		ln = max(ln, mc.Pos.Ln)
+	}
+	ed.addCursorAtLine(ln + 1)
+}
+
+// addCursorAtLine adds a cursor at given line, at the column of the
+// primary cursor, keeping the primary cursor where it is.
+func (ed *Editor) addCursorAtLine(ln int) {
+	if ln < 0 || ln >= ed.Buf.NumLines() {
+		return
+	}
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	pos := lex.Pos{Ln: ln, Ch: min(max(ed.CursorCol, ed.CursorPos.Ch), ed.Buf.LineLen(ln))}
+	ed.Cursors = append(ed.Cursors, MultiCursor{Pos: pos, Select: textbuf.RegionNil})
+	ed.ScrollInView(ed.CursorBBox(pos))
+}
+
+// AddCursorNextMatch adds a cursor selecting the next occurrence of the
+// currently selected text, after the most recently added selection
+// (wrapping around at the end).  If nothing is selected, the word at
+// the cursor is selected first, without adding any cursor.
+func (ed *Editor) AddCursorNextMatch() {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	if !ed.HasSelection() {
+		if ed.SelectWord() {
+			ed.SetCursorShow(ed.SelectReg.End)
+		}
+		return
+	}
+	sel := ed.Selection()
+	if sel == nil {
+		return
+	}
+	_, matches := ed.Buf.Search(sel.ToBytes(), false, false)
+	if len(matches) == 0 {
+		return
+	}
+	last := ed.SelectReg
+	if ed.HasMultiCursors() {
+		last = ed.Cursors[len(ed.Cursors)-1].Select
+	}
+	cs := ed.AllCursors()
+	taken := func(reg textbuf.Region) bool {
+		return slices.ContainsFunc(cs, func(mc MultiCursor) bool { return mc.Select.Start == reg.Start })
+	}
+	midx := -1
+	for i, m := range matches {
+		if !m.Reg.Start.IsLess(last.End) && !taken(m.Reg) {
+			midx = i
+			break
+		}
+	}
+	if midx < 0 { // wrap around
+		for i, m := range matches {
+			if !taken(m.Reg) {
+				midx = i
+				break
+			}
+		}
+	}
+	if midx < 0 {
+		return
+	}
+	reg := matches[midx].Reg
+	reg.Time = ed.SelectReg.Time
+	ed.Cursors = append(ed.Cursors, MultiCursor{Pos: reg.End, Select: reg})
+	ed.ScrollInView(ed.CursorBBox(reg.End))
+}
+
+// SplitSelectionLines splits each multi-line selection into one selection
+// per line, each with its own cursor at the end of the line, so that
+// the same edit can then be made on every line.
+func (ed *Editor) SplitSelectionLines() {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	var ncs []MultiCursor
+	for _, mc := range ed.AllCursors() {
+		if !mc.HasSelection() || mc.Select.Start.Ln == mc.Select.End.Ln {
+			ncs = append(ncs, mc)
+			continue
+		}
+		st, end := mc.Select.Start, mc.Select.End
+		if end.Ch == 0 { // don't include the line after the selection
+			end.Ln--
+			end.Ch = ed.Buf.LineLen(end.Ln)
+		}
+		for ln := st.Ln; ln <= end.Ln; ln++ {
+			reg := textbuf.Region{Start: lex.Pos{Ln: ln}, End: lex.Pos{Ln: ln, Ch: ed.Buf.LineLen(ln)}, Time: mc.Select.Time}
+			if ln == st.Ln {
+				reg.Start = st
+			}
+			if ln == end.Ln {
+				reg.End = end
+			}
+			ncs = append(ncs, MultiCursor{Pos: reg.End, Select: reg})
+		}
+	}
+	ed.SelectMode = false
+	ed.SetAllCursors(ncs)
+	ed.SetCursorShow(ed.CursorPos)
+}
+
+// MultiCursorDo calls given function once for each cursor, with the
+// CursorPos and SelectReg set to that cursor, so that any editing action
+// written for a single cursor is applied at every cursor.  The primary
+// cursor is done last, so that its state (e.g., for completion) is the
+// one that remains.  All of the cursors are updated for the edits made
+// at each cursor (in BufSignal), and all of the edits are saved as one
+// undo group.  If there are no additional cursors, the function is just
+// called directly.
+func (ed *Editor) MultiCursorDo(fun func()) {
+	if !ed.HasMultiCursors() || ed.Buf == nil {
+		fun()
+		return
+	}
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	ed.multiCursorDo(fun)
+	ed.SetCursorShow(ed.CursorPos)
+}
+
+// multiCursorDo does the editing for MultiCursorDo, calling given
+// function at each cursor, without any rendering
+func (ed *Editor) multiCursorDo(fun func()) {
+	autoSave := ed.Buf.BatchUpdateStart()
+	ed.Buf.Undos.Mu.Lock()
+	upos := ed.Buf.Undos.Pos
+	ed.Buf.Undos.Mu.Unlock()
+
+	lastAuto := ed.lastAutoInsert
+	cs := ed.AllCursors()
+	for i := len(cs) - 1; i >= 0; i-- {
+		// all the other cursors are kept in Cursors while running the
+		// function, so they are adjusted for the edits in BufSignal
+		ed.Cursors = slices.Delete(slices.Clone(cs), i, i+1)
+		ed.CursorPos = cs[i].Pos
+		ed.SelectReg = cs[i].Select
+		ed.SelectStart = ed.SelectReg.Start
+		ed.lastAutoInsert = lastAuto
+		fun()
+		cs = slices.Insert(ed.Cursors, i, MultiCursor{Pos: ed.CursorPos, Select: ed.SelectReg})
+	}
+	ed.SetAllCursors(cs)
+	ed.Buf.Undos.SetGroupFrom(upos)
+	ed.Buf.BatchUpdateEnd(autoSave)
+}
+
+// multiCursorClip calls given function, which returns the text that it
+// cuts or copies at the cursor, at each cursor as in multiCursorDo.
+// It returns the text for the primary cursor, and all of the texts
+// joined one per line, in the order of the cursors in the text, for
+// the clipboard (nil if there are none).
+func (ed *Editor) multiCursorClip(fun func() *textbuf.Edit) (*textbuf.Edit, []byte) {
+	type clip struct {
+		rank int
+		txt  []byte
+	}
+	var clips []clip
+	var prim *textbuf.Edit
+	ed.multiCursorDo(func() {
+		// the other cursors are all adjusted for the edits made so far,
+		// so the number of them before this one is its place in the text
+		st := ed.CursorPos
+		if ed.HasSelection() {
+			st = ed.SelectReg.Start
+		}
+		rank := 0
+		for _, mc := range ed.Cursors {
+			mst := mc.Pos
+			if mc.HasSelection() {
+				mst = mc.Select.Start
+			}
+			if mst.IsLess(st) {
+				rank++
+			}
+		}
+		prim = fun() // the primary cursor is done last
+		if prim != nil {
+			clips = append(clips, clip{rank, prim.ToBytes()})
+		}
+	})
+	if len(clips) == 0 {
+		return prim, nil
+	}
+	slices.SortFunc(clips, func(a, b clip) int { return a.rank - b.rank })
+	txts := make([][]byte, len(clips))
+	for i, c := range clips {
+		txts[i] = c.txt
+	}
+	return prim, bytes.Join(txts, []byte("\n"))
+}
+
+// AdjustCursors updates the additional cursors and their selections
+// for given edit, made by any view of the buffer.
+func (ed *Editor) AdjustCursors(tbe *textbuf.Edit) {
+	if tbe == nil {
+		return
+	}
+	for i := range ed.Cursors {
+		mc := &ed.Cursors[i]
+		mc.Pos = tbe.AdjustPos(mc.Pos, textbuf.AdjustPosDelStart)
+		if mc.HasSelection() {
+			mc.Select.Start = tbe.AdjustPos(mc.Select.Start, textbuf.AdjustPosDelEnd)
+			mc.Select.End = tbe.AdjustPos(mc.Select.End, textbuf.AdjustPosDelStart)
+			if !mc.HasSelection() {
+				mc.Select = textbuf.RegionNil
+			}
+		}
+	}
+}
+
+// RenderCursors renders the additional cursors and their selections
+// -- always called within context of outer RenderLines or RenderAllLines
+func (ed *Editor) RenderCursors() {
+	if !ed.HasMultiCursors() {
+		return
+	}
+	rs := &ed.Sc.RenderState
+	pc := &rs.Paint
+	for _, mc := range ed.Cursors {
+		if mc.HasSelection() {
+			ed.RenderRegionBox(mc.Select, &ed.SelectColor)
+		}
+		cpos := ed.CharStartPos(mc.Pos)
+		sz := mat32.Vec2{X: mat32.Max(ed.CursorWidth.Dots, 1), Y: ed.FontHeight}
+		pc.FillBox(rs, cpos, sz, &ed.CursorColor)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"slices"
+	"testing"
+
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+)
+
+func TestSetAllCursorsMerge(t *testing.T) {
+	sel := func(stLn, stCh, edLn, edCh int) MultiCursor {
+		reg := textbuf.NewRegion(stLn, stCh, edLn, edCh)
+		return MultiCursor{Pos: reg.End, Select: reg}
+	}
+	ed := &Editor{}
+	ed.SetAllCursors([]MultiCursor{
+		sel(0, 2, 0, 6),
+		{Pos: lex.Pos{Ln: 1, Ch: 1}, Select: textbuf.RegionNil},
+		sel(0, 4, 0, 9), // overlaps the primary
+		{Pos: lex.Pos{Ln: 1, Ch: 1}, Select: textbuf.RegionNil}, // same position
+		sel(2, 0, 2, 3),
+		sel(2, 3, 2, 5), // only touches the one before
+		sel(1, 8, 2, 1), // overlaps the one on line 2
+	})
+	if want := textbuf.NewRegion(0, 2, 0, 9); ed.SelectReg.Start != want.Start || ed.SelectReg.End != want.End {
+		t.Errorf("merged primary selection = %v, want %v", ed.SelectReg, want)
+	}
+	if want := (lex.Pos{Ln: 0, Ch: 9}); ed.CursorPos != want {
+		t.Errorf("merged primary cursor = %v, want %v", ed.CursorPos, want)
+	}
+	want := []lex.Pos{{Ln: 1, Ch: 1}, {Ln: 2, Ch: 3}, {Ln: 2, Ch: 5}}
+	var got []lex.Pos
+	for _, mc := range ed.Cursors {
+		got = append(got, mc.Pos)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("cursors = %v, want %v", got, want)
+	}
+	if st := ed.Cursors[1].Select.Start; st != (lex.Pos{Ln: 1, Ch: 8}) {
+		t.Errorf("merged selection start = %v, want 1:8", st)
+	}
+
+	// a cursor that is at the start of its selection stays there
+	ed.SetAllCursors([]MultiCursor{
+		{Pos: lex.Pos{Ln: 0, Ch: 2}, Select: textbuf.NewRegion(0, 2, 0, 6)},
+		sel(0, 0, 0, 3),
+	})
+	if ed.CursorPos != (lex.Pos{Ln: 0, Ch: 0}) || ed.SelectReg.End != (lex.Pos{Ln: 0, Ch: 6}) || ed.HasMultiCursors() {
+		t.Errorf("merged cursor = %v, selection %v, %d cursors", ed.CursorPos, ed.SelectReg, len(ed.Cursors))
+	}
+}
+
+// multiCursorEditor returns an Editor viewing a new Buf with given text,
+// with cursors at given positions (the first being the primary cursor)
+func multiCursorEditor(txt string, pos ...lex.Pos) *Editor {
+	tb := NewBuf()
+	tb.SetText([]byte(txt))
+	ed := &Editor{}
+	ed.Buf = tb
+	tb.AddView(ed)
+	cs := make([]MultiCursor, len(pos))
+	for i, p := range pos {
+		cs[i] = MultiCursor{Pos: p, Select: textbuf.RegionNil}
+	}
+	ed.SetAllCursors(cs)
+	return ed
+}
+
+func TestMultiCursorInsertDelete(t *testing.T) {
+	ed := multiCursorEditor("aaa\nbbb\nccc\n", lex.Pos{Ln: 0, Ch: 1}, lex.Pos{Ln: 1, Ch: 1}, lex.Pos{Ln: 2, Ch: 1})
+	tb := ed.Buf
+	allPos := func() []lex.Pos {
+		var ps []lex.Pos
+		for _, mc := range ed.AllCursors() {
+			ps = append(ps, mc.Pos)
+		}
+		return ps
+	}
+
+	ed.multiCursorDo(func() {
+		tbe := tb.InsertText(ed.CursorPos, []byte("X\n"), EditSignal)
+		ed.CursorPos = tbe.Reg.End
+	})
+	if txt := string(tb.Text()); txt != "aX\naa\nbX\nbb\ncX\ncc\n" {
+		t.Errorf("text after insert = %q", txt)
+	}
+	if got, want := allPos(), []lex.Pos{{Ln: 1, Ch: 0}, {Ln: 3, Ch: 0}, {Ln: 5, Ch: 0}}; !slices.Equal(got, want) {
+		t.Errorf("cursors after insert = %v, want %v", got, want)
+	}
+
+	ed.multiCursorDo(func() {
+		tbe := tb.DeleteText(lex.Pos{Ln: ed.CursorPos.Ln - 1, Ch: 1}, ed.CursorPos, EditSignal)
+		ed.CursorPos = tbe.Reg.Start
+	})
+	if txt := string(tb.Text()); txt != "aaa\nbbb\nccc\n" {
+		t.Errorf("text after delete = %q", txt)
+	}
+	if got, want := allPos(), []lex.Pos{{Ln: 0, Ch: 1}, {Ln: 1, Ch: 1}, {Ln: 2, Ch: 1}}; !slices.Equal(got, want) {
+		t.Errorf("cursors after delete = %v, want %v", got, want)
+	}
+
+	// the edits at all of the cursors are undone together
+	tb.Undo()
+	if txt := string(tb.Text()); txt != "aX\naa\nbX\nbb\ncX\ncc\n" {
+		t.Errorf("text after one undo = %q", txt)
+	}
+	tb.Undo()
+	if txt := string(tb.Text()); txt != "aaa\nbbb\nccc\n" {
+		t.Errorf("text after two undos = %q", txt)
+	}
+
+	// cursors that end up at the same place are merged
+	ed = multiCursorEditor("abc\n", lex.Pos{Ln: 0, Ch: 1}, lex.Pos{Ln: 0, Ch: 2})
+	ed.multiCursorDo(func() {
+		tbe := ed.Buf.DeleteText(ed.CursorPos, ed.Buf.EndPos(), EditSignal)
+		if tbe != nil {
+			ed.CursorPos = tbe.Reg.Start
+		}
+	})
+	if ed.HasMultiCursors() || ed.CursorPos != (lex.Pos{Ln: 0, Ch: 1}) {
+		t.Errorf("cursor after deleting to end = %v, with %d more", ed.CursorPos, len(ed.Cursors))
+	}
+}
+
+func TestMultiCursorClip(t *testing.T) {
+	sel := func(stLn, stCh, edLn, edCh int) MultiCursor {
+		reg := textbuf.NewRegion(stLn, stCh, edLn, edCh)
+		return MultiCursor{Pos: reg.End, Select: reg}
+	}
+	txt := "one two\nthree four\n"
+	ed := multiCursorEditor(txt)
+	tb := ed.Buf
+	// the primary cursor is last in the text, and the others are out of order
+	cs := []MultiCursor{sel(1, 6, 1, 10), sel(1, 0, 1, 5), {Pos: lex.Pos{Ln: 0, Ch: 4}, Select: textbuf.RegionNil}, sel(0, 0, 0, 3)}
+	ed.SetAllCursors(cs)
+
+	tbe, cb := ed.multiCursorClip(ed.Selection)
+	if string(cb) != "one\nthree\nfour" || tbe == nil || string(tbe.ToBytes()) != "four" {
+		t.Errorf("copied %q, with %v for the primary cursor", cb, tbe)
+	}
+	if string(tb.Text()) != txt || len(ed.Cursors) != 3 {
+		t.Errorf("copy changed the text to %q, or cursors to %v", tb.Text(), ed.Cursors)
+	}
+
+	tbe, cb = ed.multiCursorClip(ed.cutSelection)
+	if string(cb) != "one\nthree\nfour" || tbe == nil || string(tbe.ToBytes()) != "four" {
+		t.Errorf("cut %q, with %v for the primary cursor", cb, tbe)
+	}
+	if got := string(tb.Text()); got != " two\n \n" {
+		t.Errorf("text after cut = %q", got)
+	}
+	var ps []lex.Pos
+	for _, mc := range ed.AllCursors() {
+		if mc.HasSelection() {
+			t.Errorf("cursor at %v still has a selection after cut", mc.Pos)
+		}
+		ps = append(ps, mc.Pos)
+	}
+	if want := []lex.Pos{{Ln: 1, Ch: 1}, {Ln: 1, Ch: 0}, {Ln: 0, Ch: 1}, {Ln: 0, Ch: 0}}; !slices.Equal(ps, want) {
+		t.Errorf("cursors after cut = %v, want %v", ps, want)
+	}
+
+	// the cuts at all of the cursors are undone together
+	tb.Undo()
+	if got := string(tb.Text()); got != txt {
+		t.Errorf("text after undo = %q", got)
+	}
+
+	// nothing to cut
+	ed = multiCursorEditor(txt, lex.Pos{Ln: 0, Ch: 1}, lex.Pos{Ln: 1, Ch: 1})
+	if tbe, cb := ed.multiCursorClip(ed.cutSelection); tbe != nil || cb != nil {
+		t.Errorf("cut without selections = %v, %q", tbe, cb)
+	}
+}
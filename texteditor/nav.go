@@ -6,6 +6,7 @@ package texteditor
 
 import (
 	"image"
+	"math"
 
 	"goki.dev/gi/v2/gi"
 	"goki.dev/gi/v2/texteditor/textbuf"
@@ -44,7 +45,7 @@ func (ed *Editor) WrappedLines(ln int) int {
 // within that span of the given character position within line in position,
 // and false if out of range (last valid position returned in that case -- still usable).
 func (ed *Editor) WrappedLineNo(pos lex.Pos) (si, ri int, ok bool) {
-	if pos.Ln >= len(ed.Renders) {
+	if pos.Ln >= len(ed.Renders) || ed.IsLineHidden(pos.Ln) {
 		return 0, 0, false
 	}
 	return ed.Renders[pos.Ln].RuneSpanPos(pos.Ch)
@@ -61,6 +62,9 @@ func (ed *Editor) SetCursor(pos lex.Pos) {
 
 	ed.ClearScopelights()
 	ed.CursorPos = ed.Buf.ValidPos(pos)
+	if ed.IsLineHidden(ed.CursorPos.Ln) { // don't leave cursor in a folded region
+		ed.UnfoldLine(ed.CursorPos.Ln)
+	}
 	ed.Buf.MarkupLine(ed.CursorPos.Ln)
 	ed.CursorMovedSig()
 	txt := ed.Buf.Line(ed.CursorPos.Ln)
@@ -86,18 +90,10 @@ func (ed *Editor) SetCursorShow(pos lex.Pos) {
 }
 
 // SetCursorCol sets the current target cursor column (CursorCol) to that
-// of the given position
+// of the given position, which is its column on its visual (wrapped) line
+// -- see VisualLinePos
 func (ed *Editor) SetCursorCol(pos lex.Pos) {
-	if wln := ed.WrappedLines(pos.Ln); wln > 1 {
-		si, ri, ok := ed.WrappedLineNo(pos)
-		if ok && si > 0 {
-			ed.CursorCol = ri
-		} else {
-			ed.CursorCol = pos.Ch
-		}
-	} else {
-		ed.CursorCol = pos.Ch
-	}
+	_, ed.CursorCol = ed.VisualLinePos(pos)
 }
 
 // SavePosHistory saves the cursor position in history stack of cursor positions
@@ -253,7 +249,8 @@ func (ed *Editor) CursorForwardWord(steps int) {
 	ed.CursorSelect(org)
 }
 
-// CursorDown moves the cursor down line(s)
+// CursorDown moves the cursor down line(s), which are visual lines when
+// a line is wrapped, keeping the cursor in the target CursorCol
 func (ed *Editor) CursorDown(steps int) {
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
@@ -261,39 +258,11 @@ func (ed *Editor) CursorDown(steps int) {
 	org := ed.CursorPos
 	pos := ed.CursorPos
 	for i := 0; i < steps; i++ {
-		gotwrap := false
-		if wln := ed.WrappedLines(pos.Ln); wln > 1 {
-			si, ri, _ := ed.WrappedLineNo(pos)
-			if si < wln-1 {
-				si++
-				mxlen := min(len(ed.Renders[pos.Ln].Spans[si].Text), ed.CursorCol)
-				if ed.CursorCol < mxlen {
-					ri = ed.CursorCol
-				} else {
-					ri = mxlen
-				}
-				nwc, _ := ed.Renders[pos.Ln].SpanPosToRuneIdx(si, ri)
-				if si == wln-1 && ri == mxlen {
-					nwc++
-				}
-				pos.Ch = nwc
-				gotwrap = true
-
-			}
-		}
-		if !gotwrap {
-			pos.Ln++
-			if pos.Ln >= ed.NLines {
-				pos.Ln = ed.NLines - 1
-				break
-			}
-			mxlen := min(ed.Buf.LineLen(pos.Ln), ed.CursorCol)
-			if ed.CursorCol < mxlen {
-				pos.Ch = ed.CursorCol
-			} else {
-				pos.Ch = mxlen
-			}
+		np, ok := ed.VisualLineDown(pos, ed.CursorCol)
+		if !ok {
+			break
 		}
+		pos = np
 	}
 	ed.SetCursorShow(pos)
 	ed.CursorSelect(org)
@@ -397,7 +366,8 @@ func (ed *Editor) CursorBackwardWord(steps int) {
 	ed.CursorSelect(org)
 }
 
-// CursorUp moves the cursor up line(s)
+// CursorUp moves the cursor up line(s), which are visual lines when
+// a line is wrapped, keeping the cursor in the target CursorCol
 func (ed *Editor) CursorUp(steps int) {
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
@@ -405,37 +375,11 @@ func (ed *Editor) CursorUp(steps int) {
 	org := ed.CursorPos
 	pos := ed.CursorPos
 	for i := 0; i < steps; i++ {
-		gotwrap := false
-		if wln := ed.WrappedLines(pos.Ln); wln > 1 {
-			si, ri, _ := ed.WrappedLineNo(pos)
-			if si > 0 {
-				ri = ed.CursorCol
-				// fmt.Printf("up cursorcol: %v\n", ed.CursorCol)
-				nwc, _ := ed.Renders[pos.Ln].SpanPosToRuneIdx(si-1, ri)
-				pos.Ch = nwc
-				gotwrap = true
-			}
-		}
-		if !gotwrap {
-			pos.Ln--
-			if pos.Ln < 0 {
-				pos.Ln = 0
-				break
-			}
-			if wln := ed.WrappedLines(pos.Ln); wln > 1 { // just entered end of wrapped line
-				si := wln - 1
-				ri := ed.CursorCol
-				nwc, _ := ed.Renders[pos.Ln].SpanPosToRuneIdx(si, ri)
-				pos.Ch = nwc
-			} else {
-				mxlen := min(ed.Buf.LineLen(pos.Ln), ed.CursorCol)
-				if ed.CursorCol < mxlen {
-					pos.Ch = ed.CursorCol
-				} else {
-					pos.Ch = mxlen
-				}
-			}
+		np, ok := ed.VisualLineUp(pos, ed.CursorCol)
+		if !ok {
+			break
 		}
+		pos = np
 	}
 	ed.SetCursorShow(pos)
 	ed.CursorSelect(org)
@@ -479,31 +423,17 @@ func (ed *Editor) CursorRecenter() {
 	ed.lastRecenter = cur
 }
 
-// CursorStartLine moves the cursor to the start of the line, updating selection
+// CursorStartLine moves the cursor to the start of the line, which is the
+// start of the visual line when it is wrapped, updating selection
 // if select mode is active
 func (ed *Editor) CursorStartLine() {
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
 	ed.ValidateCursor()
 	org := ed.CursorPos
-	pos := ed.CursorPos
-
-	gotwrap := false
-	if wln := ed.WrappedLines(pos.Ln); wln > 1 {
-		si, ri, _ := ed.WrappedLineNo(pos)
-		if si > 0 {
-			ri = 0
-			nwc, _ := ed.Renders[pos.Ln].SpanPosToRuneIdx(si, ri)
-			pos.Ch = nwc
-			ed.CursorPos = pos
-			ed.CursorCol = ri
-			gotwrap = true
-		}
-	}
-	if !gotwrap {
-		ed.CursorPos.Ch = 0
-		ed.CursorCol = ed.CursorPos.Ch
-	}
+	si, _ := ed.VisualLinePos(ed.CursorPos)
+	ed.CursorPos = ed.VisualLineToPos(ed.CursorPos.Ln, si, 0)
+	ed.SetCursorCol(ed.CursorPos)
 	// fmt.Printf("sol cursorcol: %v\n", ed.CursorCol)
 	ed.SetCursor(ed.CursorPos)
 	ed.ScrollCursorToLeft()
@@ -527,32 +457,17 @@ func (ed *Editor) CursorStartDoc() {
 	ed.CursorSelect(org)
 }
 
-// CursorEndLine moves the cursor to the end of the text
+// CursorEndLine moves the cursor to the end of the line, which is the
+// end of the visual line when it is wrapped, updating selection
+// if select mode is active
 func (ed *Editor) CursorEndLine() {
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
 	ed.ValidateCursor()
 	org := ed.CursorPos
-	pos := ed.CursorPos
-
-	gotwrap := false
-	if wln := ed.WrappedLines(pos.Ln); wln > 1 {
-		si, ri, _ := ed.WrappedLineNo(pos)
-		ri = len(ed.Renders[pos.Ln].Spans[si].Text) - 1
-		nwc, _ := ed.Renders[pos.Ln].SpanPosToRuneIdx(si, ri)
-		if si == len(ed.Renders[pos.Ln].Spans)-1 { // last span
-			ri++
-			nwc++
-		}
-		ed.CursorCol = ri
-		pos.Ch = nwc
-		ed.CursorPos = pos
-		gotwrap = true
-	}
-	if !gotwrap {
-		ed.CursorPos.Ch = ed.Buf.LineLen(ed.CursorPos.Ln)
-		ed.CursorCol = ed.CursorPos.Ch
-	}
+	si, _ := ed.VisualLinePos(ed.CursorPos)
+	ed.CursorPos = ed.VisualLineToPos(ed.CursorPos.Ln, si, math.MaxInt) // clamped to the end
+	ed.SetCursorCol(ed.CursorPos)
 	ed.SetCursor(ed.CursorPos)
 	ed.ScrollCursorToRight()
 	ed.RenderCursor(true)
@@ -689,15 +604,16 @@ func (ed *Editor) CursorTranspose() {
 	ppos := pos
 	ppos.Ch--
 	ed.Buf.LinesMu.Lock()
-	lln := len(ed.Buf.Lines[pos.Ln])
+	lr := ed.Buf.Rope.Line(pos.Ln)
+	lln := len(lr)
 	end := false
 	if pos.Ch >= lln {
 		end = true
 		pos.Ch = lln - 1
 		ppos.Ch = lln - 2
 	}
-	chr := ed.Buf.Lines[pos.Ln][pos.Ch]
-	pchr := ed.Buf.Lines[pos.Ln][ppos.Ch]
+	chr := lr[pos.Ch]
+	pchr := lr[ppos.Ch]
 	ed.Buf.LinesMu.Unlock()
 	repl := string([]rune{chr, pchr})
 	pos.Ch++
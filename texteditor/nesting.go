@@ -0,0 +1,159 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image/color"
+	"slices"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/texteditor/histyle"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/paint"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// NestingCheck computes the bracket nesting and indentation of the lines
+// (see textbuf.Nesting) if they are not already valid, e.g., after the
+// text has been set -- they are then kept up to date as lines are edited.
+func (tb *Buf) NestingCheck() {
+	tb.LinesMu.RLock()
+	tb.MarkupMu.Lock()
+	if !tb.Nesting.IsValid(tb.NLines) {
+		tb.Nesting.Init(tb.Rope.Lines(0, tb.NLines), tb.HiTags.Slice(0, tb.NLines), tb.Opts.TabSize)
+	}
+	tb.MarkupMu.Unlock()
+	tb.LinesMu.RUnlock()
+}
+
+// BracketColors returns the colors of brackets at successive levels of
+// nesting, from the highlighting style (see histyle.Style.BracketColors)
+func (tb *Buf) BracketColors() []color.RGBA {
+	hs := tb.Hi.HiStyle
+	if hs == nil {
+		hs = histyle.AvailStyle(tb.Hi.Style)
+	}
+	if hs == nil {
+		return histyle.DefaultBracketColors
+	}
+	return hs.BracketColors()
+}
+
+// BracketColorRenders returns copies of the Renders of the given range of
+// lines (indexed from stln) with the brackets colored according to their
+// nesting depth, when RainbowBrackets is on, and nil for the lines without
+// brackets.  The Renders themselves are not changed, so the colors do not
+// stay in them when the option is turned off or the nesting changes.
+// Always called within context of outer RenderAllLines.
+func (ed *Editor) BracketColorRenders(stln, edln int) []*paint.Text {
+	if ed.Buf == nil || !ed.Buf.Opts.RainbowBrackets {
+		return nil
+	}
+	tb := ed.Buf
+	clrs := tb.BracketColors()
+	tb.NestingCheck()
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	tb.MarkupMu.RLock()
+	defer tb.MarkupMu.RUnlock()
+	if !tb.Nesting.IsValid(tb.NLines) || edln < stln {
+		return nil
+	}
+	txs := make([]*paint.Text, edln-stln+1)
+	for ln := stln; ln <= edln; ln++ {
+		if ln >= len(ed.Renders) || ln >= tb.NLines || ed.IsLineHidden(ln) {
+			continue
+		}
+		txs[ln-stln] = bracketColorText(&ed.Renders[ln], tb.Rope.Line(ln), tb.HiTags.At(ln), tb.Nesting.Depths.At(ln), clrs)
+	}
+	return txs
+}
+
+// bracketColorText returns a copy of given render of given line, with the
+// brackets colored by their depth starting from given depth (see
+// textbuf.LineBrackets), or nil if the line has no brackets
+func bracketColorText(tx *paint.Text, ln []rune, tags lex.Line, depth int, clrs []color.RGBA) *paint.Text {
+	var ctx *paint.Text
+	textbuf.LineBrackets(ln, tags, depth, func(ch, depth int) {
+		si, ri, ok := tx.RuneSpanPos(ch)
+		if !ok {
+			return
+		}
+		if ctx == nil {
+			ctx = copyTextColors(tx)
+		}
+		ctx.Spans[si].Render[ri].Color = clrs[depth%len(clrs)]
+	})
+	return ctx
+}
+
+// copyTextColors returns a copy of given render with its own rune render
+// info, in which all of the colors are explicit, so each rune can be set
+func copyTextColors(tx *paint.Text) *paint.Text {
+	ctx := *tx
+	ctx.Spans = slices.Clone(tx.Spans)
+	for si := range ctx.Spans {
+		sr := &ctx.Spans[si]
+		sr.Render = slices.Clone(sr.Render)
+		var cur color.Color
+		for ri := range sr.Render {
+			if sr.Render[ri].Color == nil {
+				sr.Render[ri].Color = cur
+			} else {
+				cur = sr.Render[ri].Color
+			}
+		}
+	}
+	return &ctx
+}
+
+// RenderIndentGuides renders a vertical line at each level of indentation
+// of the given range of lines, highlighting the one for the block that
+// contains the cursor (see textbuf.Nesting.ActiveGuide), when IndentGuides
+// is on -- always called within context of outer RenderAllLines
+func (ed *Editor) RenderIndentGuides(stln, edln int) {
+	if ed.Buf == nil || !ed.Buf.Opts.IndentGuides {
+		return
+	}
+	tb := ed.Buf
+	tabSz := max(tb.Opts.TabSize, 1)
+	tb.NestingCheck()
+	tb.MarkupMu.RLock()
+	defer tb.MarkupMu.RUnlock()
+	ns := &tb.Nesting
+	if !ns.IsValid(ed.NLines) {
+		return
+	}
+	acol, ast, aed := ns.ActiveGuide(ed.CursorPos.Ln, tabSz)
+	rs := &ed.Sc.RenderState
+	pc := &rs.Paint
+	pos := ed.RenderStartPos()
+	ch := ed.Styles.Font.Face.Metrics.Ch
+	pc.StrokeStyle.Width.Dots = 1
+	for _, active := range []bool{false, true} {
+		if active {
+			pc.StrokeStyle.SetColor(colors.Scheme.Outline)
+		} else {
+			pc.StrokeStyle.SetColor(colors.Scheme.OutlineVariant)
+		}
+		for ln := stln; ln <= edln; ln++ {
+			if ed.IsLineHidden(ln) {
+				continue
+			}
+			lst := pos.Y + ed.Offs[ln]
+			led := lst + mat32.Max(ed.Renders[ln].Size.Y, ed.LineHeight)
+			ind := ns.GuideIndent(ln)
+			for col := 0; col < ind; col += tabSz {
+				if (col == acol && ln >= ast && ln <= aed) != active {
+					continue
+				}
+				x := mat32.Floor(pos.X+ed.LineNoOff+float32(col)*ch) + 0.5
+				pc.DrawLine(rs, x, lst, x, led)
+			}
+		}
+		pc.Stroke(rs)
+	}
+}
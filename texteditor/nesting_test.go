@@ -0,0 +1,44 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"image/color"
+	"testing"
+
+	"goki.dev/girl/paint"
+)
+
+func TestBracketColorText(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	clrs := []color.RGBA{{R: 1, A: 255}, {G: 2, A: 255}}
+	ln := []rune("f(a[0])")
+	tx := &paint.Text{Spans: []paint.Span{
+		{Text: ln[:5], Render: make([]paint.Rune, 5)},
+		{Text: ln[5:], Render: make([]paint.Rune, 2)},
+	}}
+	tx.Spans[0].Render[0].Color = red
+	ctx := bracketColorText(tx, ln, nil, 1, clrs)
+	if ctx == nil {
+		t.Fatal("no render for a line with brackets")
+	}
+	want := []color.Color{red, clrs[1], red, clrs[0], red, clrs[0], clrs[1]}
+	for ch := range ln {
+		si, ri, _ := ctx.RuneSpanPos(ch)
+		if c := ctx.Spans[si].Render[ri].Color; c != want[ch] {
+			t.Errorf("color of %q = %v, want %v", ln[ch], c, want[ch])
+		}
+	}
+	for si := range tx.Spans { // the original render is not changed
+		for ri, r := range tx.Spans[si].Render {
+			if r.Color != nil && !(si == 0 && ri == 0) {
+				t.Errorf("original render color changed at %d, %d: %v", si, ri, r.Color)
+			}
+		}
+	}
+	if ctx := bracketColorText(tx, []rune("f a 0 "), nil, 0, clrs); ctx != nil {
+		t.Errorf("render for a line without brackets")
+	}
+}
@@ -15,7 +15,9 @@ import (
 
 // OutBufMarkupFunc is a function that returns a marked-up version of a given line of
 // output text by adding html tags.  It is essential that it ONLY adds tags,
-// and otherwise has the exact same visible bytes as the input
+// and otherwise has the exact same visible bytes as the input.
+// The line is html escaped, and has any ANSI escape sequences removed,
+// as in the raw text of the Buf.
 type OutBufMarkupFunc func(line []byte) []byte
 
 // OutBuf is a Buf that records the output from an io.Reader using
@@ -33,7 +35,7 @@ type OutBuf struct {
 	// default 200: how many milliseconds to wait while batching output
 	BatchMSec int
 
-	// optional markup function that adds html tags to given line of output -- essential that it ONLY adds tags, and otherwise has the exact same visible bytes as the input
+	// optional markup function that adds html tags to given line of output -- essential that it ONLY adds tags, and otherwise has the exact same visible bytes as the input.  If nil, the ANSI SGR styling of the output is marked up (see MarkupANSISpans).
 	MarkupFun OutBufMarkupFunc
 
 	// current buffered output raw lines -- not yet sent to Buf
@@ -48,7 +50,7 @@ type OutBuf struct {
 	// time when last output was sent to buffer
 	LastOut time.Time
 
-	// time.AfterFunc that is started after new input is received and not immediately output -- ensures that it will get output if no further burst happens
+	// timer that is started after new input is received and not immediately output -- ensures that it will get output if no further burst happens
 	AfterTimer *time.Timer
 }
 
@@ -64,60 +66,91 @@ func (ob *OutBuf) Init(out io.Reader, buf *Buf, batchMSec int, markup OutBufMark
 	}
 }
 
-// MonOut monitors the output and updates the Buf
+// MonOut monitors the output and updates the Buf, until the output is closed.
+// The output is sent to the Buf in batches, from this goroutine,
+// through the event loop of its views (see [Buf.RunOnViews]).
 func (ob *OutBuf) MonOut() {
-	outscan := bufio.NewScanner(ob.Out) // line at a time
+	lns := make(chan []byte, 100)
+	go func() {
+		outscan := bufio.NewScanner(ob.Out) // line at a time
+		for outscan.Scan() {
+			lns <- slices.Clone(outscan.Bytes()) // outscan bytes are temp
+		}
+		close(lns)
+	}()
+	ob.Mu.Lock()
 	ob.CurOutLns = make([][]byte, 0, 100)
 	ob.CurOutMus = make([][]byte, 0, 100)
-	for outscan.Scan() {
-		b := outscan.Bytes()
-		bc := slices.Clone(b) // outscan bytes are temp
-		bec := HTMLEscapeBytes(bc)
-
-		ob.Mu.Lock()
-		if ob.AfterTimer != nil {
-			ob.AfterTimer.Stop()
-			ob.AfterTimer = nil
-		}
-		ob.CurOutLns = append(ob.CurOutLns, bc)
-		mup := bec
-		if ob.MarkupFun != nil {
-			mup = ob.MarkupFun(bec)
-		}
-		ob.CurOutMus = append(ob.CurOutMus, mup)
-		now := time.Now()
-		lag := int(now.Sub(ob.LastOut) / time.Millisecond)
-		if lag > ob.BatchMSec {
-			ob.LastOut = now
-			ob.OutToBuf()
-		} else {
-			ob.AfterTimer = time.AfterFunc(time.Duration(ob.BatchMSec*2)*time.Millisecond, func() {
-				ob.Mu.Lock()
-				ob.LastOut = time.Now()
+	batch := time.Duration(ob.BatchMSec) * time.Millisecond
+	ob.AfterTimer = time.NewTimer(2 * batch)
+	ob.AfterTimer.Stop()
+	timer := ob.AfterTimer
+	ob.Mu.Unlock()
+	pending := false // timer is running
+	for {
+		select {
+		case ln, ok := <-lns:
+			ob.Mu.Lock()
+			if !ok {
+				timer.Stop()
 				ob.OutToBuf()
-				ob.AfterTimer = nil
 				ob.Mu.Unlock()
-			})
+				return
+			}
+			ob.AddLine(ln)
+			now := time.Now()
+			if now.Sub(ob.LastOut) > batch {
+				ob.LastOut = now
+				ob.OutToBuf()
+			} else if !pending {
+				timer.Reset(2 * batch)
+				pending = true
+			}
+			ob.Mu.Unlock()
+		case <-timer.C:
+			pending = false
+			ob.Mu.Lock()
+			ob.LastOut = time.Now()
+			ob.OutToBuf()
+			ob.Mu.Unlock()
 		}
-		ob.Mu.Unlock()
 	}
-	ob.OutToBuf()
 }
 
-// OutToBuf sends the current output to Buf
+// AddLine adds given raw line of output, which can contain ANSI escape
+// sequences, to the current output, with its markup.
+// MUST be called under mutex protection
+func (ob *OutBuf) AddLine(ln []byte) {
+	bc, spans := ParseANSI(ln)
+	var mup []byte
+	if ob.MarkupFun != nil {
+		mup = ob.MarkupFun(HTMLEscapeBytes(bc))
+	} else {
+		mup = MarkupANSISpans(bc, spans)
+	}
+	ob.CurOutLns = append(ob.CurOutLns, bc)
+	ob.CurOutMus = append(ob.CurOutMus, mup)
+}
+
+// OutToBuf sends the current output to Buf, with undo turned off,
+// and scrolls the views to the end of the output, in the event loop
+// of the views (see [Buf.RunOnViews]).
 // MUST be called under mutex protection
 func (ob *OutBuf) OutToBuf() {
 	lfb := []byte("\n")
-	if len(ob.CurOutLns) == 0 {
+	if len(ob.CurOutLns) == 0 || ob.Buf == nil {
 		return
 	}
 	tlns := bytes.Join(ob.CurOutLns, lfb)
 	mlns := bytes.Join(ob.CurOutMus, lfb)
 	tlns = append(tlns, lfb...)
 	mlns = append(mlns, lfb...)
-	// ob.Buf.Undos.Off = true
-	// ob.Buf.AppendTextMarkup(tlns, mlns, EditSignal)
-	// ob.Buf.AutoScrollViews()
+	tb := ob.Buf
+	tb.RunOnViews(func() {
+		tb.Undos.Off = true
+		tb.AppendTextMarkup(tlns, mlns, EditSignal)
+		tb.AutoScrollViews()
+	})
 	ob.CurOutLns = make([][]byte, 0, 100)
 	ob.CurOutMus = make([][]byte, 0, 100)
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"goki.dev/goosi/events"
+)
+
+func TestOutBuf(t *testing.T) {
+	tb := NewBuf()
+	nsig := 0
+	tb.OnChange(func(e events.Event) {
+		nsig++
+	})
+	pr, pw := io.Pipe()
+	ob := &OutBuf{}
+	ob.Init(pr, tb, 20, nil)
+	done := make(chan struct{})
+	go func() {
+		ob.MonOut()
+		close(done)
+	}()
+	n := 50
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(pw, "line \x1b[31m%d\x1b[0m <x>\n", i)
+		if i == n/2 {
+			time.Sleep(100 * time.Millisecond) // timer sends the first batch
+		}
+	}
+	pw.Close()
+	<-done
+	if tb.NLines != n+1 {
+		t.Fatalf("NLines = %d, want %d", tb.NLines, n+1)
+	}
+	for i := 0; i < n; i++ {
+		if got, want := string(tb.Line(i)), fmt.Sprintf("line %d <x>", i); got != want {
+			t.Errorf("line %d = %q, want %q", i, got, want)
+		}
+		want := fmt.Sprintf(`line <span style="color:#cd0000;">%d</span> &lt;x&gt;`, i)
+		if got := string(tb.Markup.At(i)); got != want {
+			t.Errorf("markup %d = %q, want %q", i, got, want)
+		}
+	}
+	if len(tb.Undos.Stack) != 0 {
+		t.Errorf("undo is not off: %d undos", len(tb.Undos.Stack))
+	}
+	if nsig < 2 || nsig >= n {
+		t.Errorf("output was sent in %d batches, want more than 1 and fewer than %d", nsig, n)
+	}
+}
+
+func TestOutBufMarkupFun(t *testing.T) {
+	tb := NewBuf()
+	pr, pw := io.Pipe()
+	ob := &OutBuf{}
+	ob.Init(pr, tb, 0, func(line []byte) []byte {
+		return append(append([]byte("<b>"), line...), "</b>"...)
+	})
+	done := make(chan struct{})
+	go func() {
+		ob.MonOut()
+		close(done)
+	}()
+	fmt.Fprintf(pw, "\x1b[1ma&b\x1b[0m\n")
+	pw.Close()
+	<-done
+	if got := string(tb.Line(0)); got != "a&b" {
+		t.Errorf("line = %q, want %q", got, "a&b")
+	}
+	if got := string(tb.Markup.At(0)); got != "<b>a&amp;b</b>" {
+		t.Errorf("markup = %q, want %q", got, "<b>a&amp;b</b>")
+	}
+}
@@ -26,6 +26,7 @@ import (
 func (ed *Editor) Render(sc *gi.Scene) {
 	if ed.PushBounds(sc) {
 		ed.RenderAllLinesInBounds()
+		ed.RenderMinimap()
 		if ed.ScrollToCursorOnRender {
 			ed.ScrollToCursorOnRender = false
 			ed.CursorPos = ed.ScrollToCursorPos
@@ -150,10 +151,10 @@ func (ed *Editor) RenderDepthBg(stln, edln int) {
 		if int(mat32.Floor(lst)) > ed.ScBBox.Max.Y {
 			continue
 		}
-		if ln >= len(ed.Buf.HiTags) { // may be out of sync
+		if ln >= ed.Buf.HiTags.Len() { // may be out of sync
 			continue
 		}
-		ht := ed.Buf.HiTags[ln]
+		ht := ed.Buf.HiTags.At(ln)
 		lsted := 0
 		for ti := range ht {
 			lx := &ht[ti]
@@ -181,6 +182,7 @@ func (ed *Editor) RenderDepthBg(stln, edln int) {
 // RenderSelect renders the selection region as a selected background color
 // -- always called within context of outer RenderLines or RenderAllLines
 func (ed *Editor) RenderSelect() {
+	ed.RenderCursors()
 	if !ed.HasSelection() {
 		return
 	}
@@ -327,11 +329,16 @@ func (ed *Editor) RenderAllLinesInBounds() {
 	if ed.HasLineNos() {
 		ed.RenderLineNosBoxAll()
 		for ln := stln; ln <= edln; ln++ {
+			if ed.IsLineHidden(ln) {
+				continue
+			}
 			ed.RenderLineNo(ln, false, false) // don't re-render std fill boxes, no separate vp upload
 		}
 	}
 
 	ed.RenderDepthBg(stln, edln)
+	ed.RenderRulers()
+	ed.RenderIndentGuides(stln, edln)
 	ed.RenderHighlights(stln, edln)
 	ed.RenderScopelights(stln, edln)
 	ed.RenderSelect()
@@ -342,13 +349,22 @@ func (ed *Editor) RenderAllLinesInBounds() {
 		rs.PushBounds(tbb)
 		rs.Lock()
 	}
+	brs := ed.BracketColorRenders(stln, edln)
 	for ln := stln; ln <= edln; ln++ {
+		if ed.IsLineHidden(ln) {
+			continue
+		}
 		lst := pos.Y + ed.Offs[ln]
 		lp := pos
 		lp.Y = lst
 		lp.X += ed.LineNoOff
-		ed.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
+		tx := &ed.Renders[ln]
+		if brs != nil && brs[ln-stln] != nil {
+			tx = brs[ln-stln]
+		}
+		tx.Render(rs, lp) // not top pos -- already has baseline offset
 	}
+	ed.RenderDiagnostics(stln, edln)
 	rs.Unlock()
 	if ed.HasLineNos() {
 		rs.PopBounds()
@@ -445,6 +461,7 @@ func (ed *Editor) RenderLineNo(ln int, defFill bool, vpUpload bool) {
 	pos.X = float32(ed.ScBBox.Min.X) + spc.Pos().X
 
 	ed.LineNoRender.Render(rs, pos)
+	ed.RenderFoldMarker(ln, pos)
 	// todo: need an SvgRender interface that just takes an svg file or object
 	// and renders it to a given bitmap, and then just keep that around.
 	// if icnm, ok := ed.Buf.LineIcons[ln]; ok {
@@ -502,6 +519,9 @@ func (ed *Editor) RenderLines(st, end int) bool {
 	visSt := -1
 	visEd := -1
 	for ln := st; ln <= end; ln++ {
+		if ed.IsLineHidden(ln) {
+			continue
+		}
 		lst := ed.CharStartPos(lex.Pos{Ln: ln}).Y // note: charstart pos includes descent
 		led := lst + mat32.Max(ed.Renders[ln].Size.Y, ed.LineHeight)
 		if int(mat32.Ceil(led)) < ed.ScBBox.Min.Y {
@@ -535,6 +555,9 @@ func (ed *Editor) RenderLines(st, end int) bool {
 
 		if ed.HasLineNos() {
 			for ln := visSt; ln <= visEd; ln++ {
+				if ed.IsLineHidden(ln) {
+					continue
+				}
 				ed.RenderLineNo(ln, true, false)
 			}
 			tbb := ed.ScBBox
@@ -544,6 +567,9 @@ func (ed *Editor) RenderLines(st, end int) bool {
 			rs.Lock()
 		}
 		for ln := visSt; ln <= visEd; ln++ {
+			if ed.IsLineHidden(ln) {
+				continue
+			}
 			lst := pos.Y + ed.Offs[ln]
 			lp := pos
 			lp.Y = lst
@@ -578,6 +604,9 @@ func (ed *Editor) FirstVisibleLine(stln int) int {
 			stln = 0
 		}
 		for ln := stln; ln < ed.NLines; ln++ {
+			if ed.IsLineHidden(ln) {
+				continue
+			}
 			cpos := ed.CharStartPos(lex.Pos{Ln: ln})
 			if int(mat32.Floor(cpos.Y)) >= ed.ScBBox.Min.Y { // top definitely on screen
 				stln = ln
@@ -587,6 +616,9 @@ func (ed *Editor) FirstVisibleLine(stln int) int {
 	}
 	lastln := stln
 	for ln := stln - 1; ln >= 0; ln-- {
+		if ed.IsLineHidden(ln) {
+			continue
+		}
 		cpos := ed.CharStartPos(lex.Pos{Ln: ln})
 		if int(mat32.Ceil(cpos.Y)) < ed.ScBBox.Min.Y { // top just offscreen
 			break
@@ -601,6 +633,9 @@ func (ed *Editor) FirstVisibleLine(stln int) int {
 func (ed *Editor) LastVisibleLine(stln int) int {
 	lastln := stln
 	for ln := stln + 1; ln < ed.NLines; ln++ {
+		if ed.IsLineHidden(ln) {
+			continue
+		}
 		pos := lex.Pos{Ln: ln}
 		cpos := ed.CharStartPos(pos)
 		if int(mat32.Floor(cpos.Y)) > ed.ScBBox.Max.Y { // just offscreen
@@ -630,6 +665,9 @@ func (ed *Editor) PixelToCursor(pt image.Point) lex.Pos {
 	} else {
 		got := false
 		for ln := stln; ln < ed.NLines; ln++ {
+			if ed.IsLineHidden(ln) {
+				continue
+			}
 			ls := ed.CharStartPos(lex.Pos{Ln: ln}).Y - yoff
 			es := ls
 			es += mat32.Max(ed.Renders[ln].Size.Y, ed.LineHeight)
@@ -643,6 +681,7 @@ func (ed *Editor) PixelToCursor(pt image.Point) lex.Pos {
 			cln = ed.NLines - 1
 		}
 	}
+	cln = ed.VisibleLine(cln, -1)
 	// fmt.Printf("cln: %v  pt: %v\n", cln, pt)
 	lnsz := ed.Buf.LineLen(cln)
 	if lnsz == 0 {
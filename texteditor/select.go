@@ -356,25 +356,45 @@ func (ed *Editor) PasteHist() {
 	*/
 }
 
-// Cut cuts any selected text and adds it to the clipboard, also returns cut text
+// Cut cuts any selected text and adds it to the clipboard, also returns cut text.
+// If there are multiple cursors, the selected text at each of them is cut,
+// and the clipboard gets all of the cut texts, one per line in the order
+// they were in the text -- the cut text at the primary cursor is returned.
 func (ed *Editor) Cut() *textbuf.Edit {
-	if !ed.HasSelection() {
+	if !ed.HasSelection() && !ed.HasMultiCursors() {
 		return nil
 	}
 	updt := ed.UpdateStart()
 	defer ed.UpdateEndRender(updt)
-	org := ed.SelectReg.Start
-	cut := ed.DeleteSelection()
-	if cut != nil {
-		cb := cut.ToBytes()
+	var cut *textbuf.Edit
+	var cb []byte
+	if ed.HasMultiCursors() && ed.Buf != nil {
+		cut, cb = ed.multiCursorClip(ed.cutSelection)
+	} else if cut = ed.cutSelection(); cut != nil {
+		cb = cut.ToBytes()
+	}
+	if cb != nil {
 		ed.EventMgr().ClipBoard().Write(mimedata.NewTextBytes(cb))
 		ViewClipHistAdd(cb)
 	}
-	ed.SetCursorShow(org)
+	ed.SetCursorShow(ed.CursorPos)
 	ed.SavePosHistory(ed.CursorPos)
 	return cut
 }
 
+// cutSelection deletes any selected text, without adding to clipboard,
+// leaving the cursor at the start of where it was -- returns text deleted
+// as textbuf.Edit (nil if none)
+func (ed *Editor) cutSelection() *textbuf.Edit {
+	if !ed.HasSelection() {
+		return nil
+	}
+	org := ed.SelectReg.Start
+	cut := ed.DeleteSelection()
+	ed.CursorPos = org
+	return cut
+}
+
 // DeleteSelection deletes any selected text, without adding to clipboard --
 // returns text deleted as textbuf.Edit (nil if none)
 func (ed *Editor) DeleteSelection() *textbuf.Edit {
@@ -384,8 +404,27 @@ func (ed *Editor) DeleteSelection() *textbuf.Edit {
 }
 
 // Copy copies any selected text to the clipboard, and returns that text,
-// optionally resetting the current selection
+// optionally resetting the current selection.  If there are multiple
+// cursors, the clipboard gets the selected texts of all of them, one per
+// line in the order they are in the text, as for [Editor.Cut].
 func (ed *Editor) Copy(reset bool) *textbuf.Edit {
+	if ed.HasMultiCursors() && ed.Buf != nil {
+		updt := ed.UpdateStart()
+		defer ed.UpdateEndRender(updt)
+		tbe, cb := ed.multiCursorClip(func() *textbuf.Edit {
+			tbe := ed.Selection()
+			if reset {
+				ed.SelectReset()
+			}
+			return tbe
+		})
+		if cb != nil {
+			ViewClipHistAdd(cb)
+			ed.EventMgr().ClipBoard().Write(mimedata.NewTextBytes(cb))
+		}
+		ed.SavePosHistory(ed.CursorPos)
+		return tbe
+	}
 	tbe := ed.Selection()
 	if tbe == nil {
 		return nil
@@ -505,13 +544,17 @@ func (ed *Editor) PasteRect() {
 
 // ReCaseSelection changes the case of the currently-selected text.
 // Returns the new text -- empty if nothing selected.
+// If there are multiple cursors, the selection at each cursor is changed.
 func (ed *Editor) ReCaseSelection(c textbuf.Cases) string {
-	if !ed.HasSelection() {
-		return ""
-	}
-	sel := ed.Selection()
-	nstr := textbuf.ReCaseString(string(sel.ToBytes()), c)
-	ed.Buf.ReplaceText(sel.Reg.Start, sel.Reg.End, sel.Reg.Start, nstr, EditSignal, ReplaceNoMatchCase)
+	nstr := ""
+	ed.MultiCursorDo(func() {
+		if !ed.HasSelection() {
+			return
+		}
+		sel := ed.Selection()
+		nstr = textbuf.ReCaseString(string(sel.ToBytes()), c)
+		ed.Buf.ReplaceText(sel.Reg.Start, sel.Reg.End, sel.Reg.Start, nstr, EditSignal, ReplaceNoMatchCase)
+	})
 	return nstr
 }
 
@@ -551,4 +594,5 @@ func (ed *Editor) ContextMenu(m *gi.Scene) {
 				ed.Clear()
 			})
 	}
+	ed.DiagnosticsContextMenu(m)
 }
@@ -8,6 +8,7 @@ package textbuf
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/iancoleman/strcase"
 )
@@ -55,3 +56,64 @@ func ReCaseString(str string, c Cases) string {
 	}
 	return str
 }
+
+// DetectCase returns the case of the given string, which must have at least
+// one letter, and false if it is not one of the Cases, e.g., because
+// it is a mix of upper and lower case that is not CamelCase.
+func DetectCase(str string) (Cases, bool) {
+	hasUpper, hasLower := false, false
+	nlet := 0
+	for _, r := range str {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		}
+		if unicode.IsLetter(r) {
+			nlet++
+		}
+	}
+	if !hasUpper && !hasLower {
+		return LowerCase, false
+	}
+	switch {
+	case strings.Contains(str, "_"):
+		switch {
+		case !hasLower:
+			return UpperSnakeCase, true
+		case !hasUpper:
+			return SnakeCase, true
+		}
+		return LowerCase, false
+	case strings.Contains(str, "-"):
+		if !hasUpper {
+			return KebabCase, true
+		}
+		return LowerCase, false
+	case !hasLower && nlet > 1:
+		return UpperCase, true
+	case !hasLower: // a single capital letter
+		return CamelCase, true
+	case !hasUpper:
+		return LowerCase, true
+	}
+	if strings.ContainsAny(str, " \t") {
+		return LowerCase, false
+	}
+	if unicode.IsUpper([]rune(str)[0]) {
+		return CamelCase, true
+	}
+	return LowerCamelCase, true
+}
+
+// ReCaseAs changes the case of the given string to that of the model string,
+// as detected by DetectCase, e.g., for a case-preserving replace of the model.
+// The string is returned unchanged if the case of the model is not detected.
+func ReCaseAs(str, model string) string {
+	c, ok := DetectCase(model)
+	if !ok {
+		return str
+	}
+	return ReCaseString(str, c)
+}
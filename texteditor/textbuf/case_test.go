@@ -0,0 +1,30 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import "testing"
+
+func TestReCaseAs(t *testing.T) {
+	tests := []struct {
+		str, model, want string
+	}{
+		{"new name", "old_name", "new_name"},
+		{"new_name", "OLD_NAME", "NEW_NAME"},
+		{"new_name", "OldName", "NewName"},
+		{"new_name", "oldName", "newName"},
+		{"new_name", "old-name", "new-name"},
+		{"bar", "FOO", "BAR"},
+		{"bar", "Foo", "Bar"},
+		{"Bar", "foo", "bar"},
+		{"bar", "F", "Bar"},
+		{"new_name", "oLD_name", "new_name"},
+		{"bar", "123", "bar"},
+	}
+	for _, tt := range tests {
+		if got := ReCaseAs(tt.str, tt.model); got != tt.want {
+			t.Errorf("ReCaseAs(%q, %q) = %q, want %q", tt.str, tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Severities are the severities of diagnostics, from most to least severe
+type Severities int32 //enums:enum -trim-prefix Severity
+
+const (
+	// SeverityError is an error that prevents the code from working
+	SeverityError Severities = iota
+
+	// SeverityWarning is a likely problem, e.g., from a linter
+	SeverityWarning
+
+	// SeverityInfo is information about the code
+	SeverityInfo
+
+	// SeverityHint is a suggestion for improving the code
+	SeverityHint
+)
+
+// FixEdit is one edit of a QuickFix, replacing the text in the region
+// with the new text
+type FixEdit struct {
+
+	// region of text to replace -- empty to insert at its start
+	Reg Region
+
+	// text to replace the region with -- empty to delete it
+	Text string
+}
+
+// QuickFix is a suggested fix for a Diagnostic, as a set of edits
+// that are all applied together
+type QuickFix struct {
+
+	// title of the fix, for a menu
+	Title string
+
+	// edits to apply, which must not overlap
+	Edits []FixEdit
+}
+
+// Diagnostic is a problem with a region of the text, reported by
+// a source such as a compiler, linter, spell checker or language server
+type Diagnostic struct {
+
+	// region of the text the diagnostic applies to
+	Reg Region
+
+	// how severe the problem is
+	Severity Severities
+
+	// message describing the problem
+	Message string
+
+	// name of the source of the diagnostic, e.g., "go vet", "spell" or "lsp"
+	Source string
+
+	// optional code identifying the kind of problem within the source
+	Code string
+
+	// suggested fixes, if any
+	Fixes []QuickFix
+}
+
+// String returns the diagnostic as a one-line string, with the
+// 1-based line:col of its start, as in compiler output
+func (d *Diagnostic) String() string {
+	s := strconv.Itoa(d.Reg.Start.Ln+1) + ":" + strconv.Itoa(d.Reg.Start.Ch+1) + ": " + d.Severity.String() + ": " + d.Message
+	if d.Source != "" {
+		s += " (" + d.Source + ")"
+	}
+	return s
+}
+
+// Diagnostics is the collection of diagnostics for a text buffer, from
+// any number of sources, each of which replaces its own diagnostics
+// independently of the others.  It is safe for concurrent use, as
+// sources typically run in other goroutines.
+type Diagnostics struct {
+
+	// diagnostics for each source, by source name
+	Sources map[string][]Diagnostic
+
+	// mutex protecting Sources
+	Mu sync.Mutex
+}
+
+// Set replaces the diagnostics from the given source, setting their
+// Source to it -- nil removes them all
+func (ds *Diagnostics) Set(source string, diags []Diagnostic) {
+	ds.Mu.Lock()
+	defer ds.Mu.Unlock()
+	if len(diags) == 0 {
+		delete(ds.Sources, source)
+		return
+	}
+	if ds.Sources == nil {
+		ds.Sources = make(map[string][]Diagnostic)
+	}
+	dc := make([]Diagnostic, len(diags))
+	copy(dc, diags)
+	for i := range dc {
+		dc[i].Source = source
+	}
+	ds.Sources[source] = dc
+}
+
+// Reset removes the diagnostics from all sources
+func (ds *Diagnostics) Reset() {
+	ds.Mu.Lock()
+	ds.Sources = nil
+	ds.Mu.Unlock()
+}
+
+// Len returns the total number of diagnostics from all sources
+func (ds *Diagnostics) Len() int {
+	ds.Mu.Lock()
+	defer ds.Mu.Unlock()
+	n := 0
+	for _, dl := range ds.Sources {
+		n += len(dl)
+	}
+	return n
+}
+
+// All returns the diagnostics from all sources sorted by position, and
+// then by severity.  If adj is non-nil, each region is passed through it
+// first, e.g., to adjust it for the edits since the diagnostic was set,
+// and those that come back nil, because their text was deleted, are dropped.
+func (ds *Diagnostics) All(adj func(reg Region) Region) []Diagnostic {
+	ds.Mu.Lock()
+	var all []Diagnostic
+	for _, dl := range ds.Sources {
+		all = append(all, dl...)
+	}
+	ds.Mu.Unlock()
+	if adj != nil {
+		n := 0
+		for _, d := range all {
+			reg := adj(d.Reg)
+			if reg == RegionNil && d.Reg != RegionNil {
+				continue
+			}
+			d.Reg = reg
+			all[n] = d
+			n++
+		}
+		all = all[:n]
+	}
+	SortDiagnostics(all)
+	return all
+}
+
+// SortDiagnostics sorts the diagnostics by start position, then severity,
+// then source
+func SortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		di, dj := &diags[i], &diags[j]
+		if di.Reg.Start != dj.Reg.Start {
+			return di.Reg.Start.IsLess(dj.Reg.Start)
+		}
+		if di.Severity != dj.Severity {
+			return di.Severity < dj.Severity
+		}
+		return di.Source < dj.Source
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////
+//  Parsing tool output
+
+// ToolDiag is a diagnostic parsed from the output of a tool such as
+// go vet, for the file it names, at the 1-based line and column it reports,
+// where the column is in bytes, as reported by compilers, and 0 if none
+type ToolDiag struct {
+
+	// file name as given in the output, typically relative to the
+	// directory the tool was run in
+	File string
+
+	// 1-based line number
+	Line int
+
+	// 1-based byte column, or 0 if not given
+	Col int
+
+	// severity of the diagnostic
+	Severity Severities
+
+	// message, including any continuation lines
+	Message string
+}
+
+// DiagParser is a function that parses the output of a tool into
+// diagnostics, for setting on the buffers of the files they are in
+type DiagParser func(out []byte) []ToolDiag
+
+// DiagParsers are the parsers for the output of tools, by tool name,
+// used by ParseToolOutput -- add to this for other tools
+var DiagParsers = map[string]DiagParser{
+	"go vet":   ParseGoVet,
+	"go build": ParseGoBuild,
+}
+
+// ParseToolOutput parses the given output of the tool with given name,
+// using its parser in DiagParsers, or ParseFileLineCol with warning
+// severity if there is none
+func ParseToolOutput(tool string, out []byte) []ToolDiag {
+	if pf, ok := DiagParsers[tool]; ok {
+		return pf(out)
+	}
+	return ParseFileLineCol(out, SeverityWarning)
+}
+
+// fileLineColRe matches file:line:col: message lines, with the col optional
+var fileLineColRe = regexp.MustCompile(`^(.+?\.\w+):(\d+)(?::(\d+))?:\s*(.*)$`)
+
+// ParseFileLineCol parses the standard file:line:col: message format of
+// compilers and linters, with the col being optional, and all diagnostics
+// of the given severity, unless the message starts with "error:" or
+// "warning:", as in gcc.  Lines starting with a tab or space continue the
+// message of the previous diagnostic, and other lines are skipped.
+func ParseFileLineCol(out []byte, sev Severities) []ToolDiag {
+	return parseFileLineCol(out, sev, "")
+}
+
+// ParseGoVet parses the output of go vet: its own reports are warnings,
+// and those prefixed with "vet: ", from type checking, are errors
+func ParseGoVet(out []byte) []ToolDiag {
+	return parseFileLineCol(out, SeverityWarning, "vet: ")
+}
+
+// parseFileLineCol is ParseFileLineCol where lines starting with the
+// given errPrefix, if any, have it removed and are errors
+func parseFileLineCol(out []byte, sev Severities, errPrefix string) []ToolDiag {
+	var diags []ToolDiag
+	cont := false // whether a continuation line goes with the last diagnostic
+	for _, ln := range bytes.Split(out, []byte("\n")) {
+		s := strings.TrimSuffix(string(ln), "\r")
+		if s == "" {
+			continue
+		}
+		if s[0] == '\t' || s[0] == ' ' {
+			if cont {
+				d := &diags[len(diags)-1]
+				d.Message += "\n" + strings.TrimSpace(s)
+			}
+			continue
+		}
+		isErr := false
+		if errPrefix != "" && strings.HasPrefix(s, errPrefix) {
+			s = s[len(errPrefix):]
+			isErr = true
+		}
+		m := fileLineColRe.FindStringSubmatch(s)
+		cont = m != nil
+		if m == nil {
+			continue
+		}
+		d := ToolDiag{File: m[1], Severity: sev, Message: m[4]}
+		d.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			d.Col, _ = strconv.Atoi(m[3])
+		}
+		switch {
+		case isErr:
+			d.Severity = SeverityError
+		case strings.HasPrefix(d.Message, "error:"):
+			d.Severity = SeverityError
+			d.Message = strings.TrimSpace(d.Message[len("error:"):])
+		case strings.HasPrefix(d.Message, "warning:"):
+			d.Severity = SeverityWarning
+			d.Message = strings.TrimSpace(d.Message[len("warning:"):])
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// ParseGoBuild parses the output of go build, with all diagnostics errors
+func ParseGoBuild(out []byte) []ToolDiag {
+	return ParseFileLineCol(out, SeverityError)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"testing"
+)
+
+func TestParseGoVet(t *testing.T) {
+	out := []byte(`# goki.dev/gi/v2/texteditor
+./buf.go:12:5: fmt.Printf format %d has arg s of wrong type string
+vet: ./find.go:30:2: undefined: foo
+./render.go:7: unreachable code
+	more detail
+# other
+	not a continuation
+`)
+	diags := ParseGoVet(out)
+	want := []ToolDiag{
+		{File: "./buf.go", Line: 12, Col: 5, Severity: SeverityWarning, Message: "fmt.Printf format %d has arg s of wrong type string"},
+		{File: "./find.go", Line: 30, Col: 2, Severity: SeverityError, Message: "undefined: foo"},
+		{File: "./render.go", Line: 7, Col: 0, Severity: SeverityWarning, Message: "unreachable code\nmore detail"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("ParseGoVet got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i := range want {
+		if diags[i] != want[i] {
+			t.Errorf("ParseGoVet diagnostic %d = %+v, want %+v", i, diags[i], want[i])
+		}
+	}
+}
+
+func TestParseFileLineColGcc(t *testing.T) {
+	diags := ParseToolOutput("cc", []byte("main.c:3:10: error: expected ';'\nmain.c:4:1: warning: unused variable\n"))
+	if len(diags) != 2 || diags[0].Severity != SeverityError || diags[0].Message != "expected ';'" || diags[1].Severity != SeverityWarning {
+		t.Errorf("ParseToolOutput got %+v", diags)
+	}
+}
+
+func TestDiagnosticsAll(t *testing.T) {
+	var ds Diagnostics
+	ds.Set("vet", []Diagnostic{
+		{Reg: NewRegion(4, 0, 4, 3), Severity: SeverityWarning, Message: "b"},
+		{Reg: NewRegion(1, 2, 1, 5), Severity: SeverityWarning, Message: "a"},
+	})
+	ds.Set("spell", []Diagnostic{{Reg: NewRegion(1, 2, 1, 4), Severity: SeverityInfo, Message: "c"}})
+	ds.Set("lsp", []Diagnostic{{Reg: NewRegion(9, 0, 9, 1), Message: "gone"}})
+	if n := ds.Len(); n != 4 {
+		t.Fatalf("Len = %d, want 4", n)
+	}
+	all := ds.All(func(reg Region) Region {
+		if reg.Start.Ln == 9 {
+			return RegionNil
+		}
+		reg.Start.Ln++
+		reg.End.Ln++
+		return reg
+	})
+	got := ""
+	for _, d := range all {
+		got += d.Message + d.Source + " "
+	}
+	if got != "avet cspell bvet " || all[0].Reg.Start.Ln != 2 {
+		t.Errorf("All = %q, first at line %d", got, all[0].Reg.Start.Ln)
+	}
+	ds.Set("vet", nil)
+	if n := ds.Len(); n != 2 {
+		t.Errorf("Len after removing source = %d, want 2", n)
+	}
+}
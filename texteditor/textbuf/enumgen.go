@@ -140,3 +140,235 @@ func (i Cases) MarshalText() ([]byte, error) {
 func (i *Cases) UnmarshalText(text []byte) error {
 	return i.SetString(string(text))
 }
+
+var _SeveritiesValues = []Severities{0, 1, 2, 3}
+
+// SeveritiesN is the highest valid value
+// for type Severities, plus one.
+const SeveritiesN Severities = 4
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the enumgen command to generate them again.
+func _SeveritiesNoOp() {
+	var x [1]struct{}
+	_ = x[SeverityError-(0)]
+	_ = x[SeverityWarning-(1)]
+	_ = x[SeverityInfo-(2)]
+	_ = x[SeverityHint-(3)]
+}
+
+var _SeveritiesNameToValueMap = map[string]Severities{
+	`Error`:   0,
+	`error`:   0,
+	`Warning`: 1,
+	`warning`: 1,
+	`Info`:    2,
+	`info`:    2,
+	`Hint`:    3,
+	`hint`:    3,
+}
+
+var _SeveritiesDescMap = map[Severities]string{
+	0: `SeverityError is an error that prevents the code from working`,
+	1: `SeverityWarning is a likely problem, e.g., from a linter`,
+	2: `SeverityInfo is information about the code`,
+	3: `SeverityHint is a suggestion for improving the code`,
+}
+
+var _SeveritiesMap = map[Severities]string{
+	0: `Error`,
+	1: `Warning`,
+	2: `Info`,
+	3: `Hint`,
+}
+
+// String returns the string representation
+// of this Severities value.
+func (i Severities) String() string {
+	if str, ok := _SeveritiesMap[i]; ok {
+		return str
+	}
+	return strconv.FormatInt(int64(i), 10)
+}
+
+// SetString sets the Severities value from its
+// string representation, and returns an
+// error if the string is invalid.
+func (i *Severities) SetString(s string) error {
+	if val, ok := _SeveritiesNameToValueMap[s]; ok {
+		*i = val
+		return nil
+	}
+	if val, ok := _SeveritiesNameToValueMap[strings.ToLower(s)]; ok {
+		*i = val
+		return nil
+	}
+	return errors.New(s + " is not a valid value for type Severities")
+}
+
+// Int64 returns the Severities value as an int64.
+func (i Severities) Int64() int64 {
+	return int64(i)
+}
+
+// SetInt64 sets the Severities value from an int64.
+func (i *Severities) SetInt64(in int64) {
+	*i = Severities(in)
+}
+
+// Desc returns the description of the Severities value.
+func (i Severities) Desc() string {
+	if str, ok := _SeveritiesDescMap[i]; ok {
+		return str
+	}
+	return i.String()
+}
+
+// SeveritiesValues returns all possible values
+// for the type Severities.
+func SeveritiesValues() []Severities {
+	return _SeveritiesValues
+}
+
+// Values returns all possible values
+// for the type Severities.
+func (i Severities) Values() []enums.Enum {
+	res := make([]enums.Enum, len(_SeveritiesValues))
+	for i, d := range _SeveritiesValues {
+		res[i] = d
+	}
+	return res
+}
+
+// IsValid returns whether the value is a
+// valid option for type Severities.
+func (i Severities) IsValid() bool {
+	_, ok := _SeveritiesMap[i]
+	return ok
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface.
+func (i Severities) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements the [encoding.TextUnmarshaler] interface.
+func (i *Severities) UnmarshalText(text []byte) error {
+	return i.SetString(string(text))
+}
+
+var _MergeTakeValues = []MergeTake{0, 1, 2, 3}
+
+// MergeTakeN is the highest valid value
+// for type MergeTake, plus one.
+const MergeTakeN MergeTake = 4
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the enumgen command to generate them again.
+func _MergeTakeNoOp() {
+	var x [1]struct{}
+	_ = x[MergeUnresolved-(0)]
+	_ = x[MergeTakeA-(1)]
+	_ = x[MergeTakeB-(2)]
+	_ = x[MergeTakeBoth-(3)]
+}
+
+var _MergeTakeNameToValueMap = map[string]MergeTake{
+	`MergeUnresolved`: 0,
+	`mergeunresolved`: 0,
+	`MergeTakeA`:      1,
+	`mergetakea`:      1,
+	`MergeTakeB`:      2,
+	`mergetakeb`:      2,
+	`MergeTakeBoth`:   3,
+	`mergetakeboth`:   3,
+}
+
+var _MergeTakeDescMap = map[MergeTake]string{
+	0: `MergeUnresolved is a conflict that has not been resolved, which is written with conflict markers in the merged lines`,
+	1: `MergeTakeA takes the lines from the A (left, ours) version`,
+	2: `MergeTakeB takes the lines from the B (right, theirs) version`,
+	3: `MergeTakeBoth takes the lines from the A version followed by those from the B version`,
+}
+
+var _MergeTakeMap = map[MergeTake]string{
+	0: `MergeUnresolved`,
+	1: `MergeTakeA`,
+	2: `MergeTakeB`,
+	3: `MergeTakeBoth`,
+}
+
+// String returns the string representation
+// of this MergeTake value.
+func (i MergeTake) String() string {
+	if str, ok := _MergeTakeMap[i]; ok {
+		return str
+	}
+	return strconv.FormatInt(int64(i), 10)
+}
+
+// SetString sets the MergeTake value from its
+// string representation, and returns an
+// error if the string is invalid.
+func (i *MergeTake) SetString(s string) error {
+	if val, ok := _MergeTakeNameToValueMap[s]; ok {
+		*i = val
+		return nil
+	}
+	if val, ok := _MergeTakeNameToValueMap[strings.ToLower(s)]; ok {
+		*i = val
+		return nil
+	}
+	return errors.New(s + " is not a valid value for type MergeTake")
+}
+
+// Int64 returns the MergeTake value as an int64.
+func (i MergeTake) Int64() int64 {
+	return int64(i)
+}
+
+// SetInt64 sets the MergeTake value from an int64.
+func (i *MergeTake) SetInt64(in int64) {
+	*i = MergeTake(in)
+}
+
+// Desc returns the description of the MergeTake value.
+func (i MergeTake) Desc() string {
+	if str, ok := _MergeTakeDescMap[i]; ok {
+		return str
+	}
+	return i.String()
+}
+
+// MergeTakeValues returns all possible values
+// for the type MergeTake.
+func MergeTakeValues() []MergeTake {
+	return _MergeTakeValues
+}
+
+// Values returns all possible values
+// for the type MergeTake.
+func (i MergeTake) Values() []enums.Enum {
+	res := make([]enums.Enum, len(_MergeTakeValues))
+	for i, d := range _MergeTakeValues {
+		res[i] = d
+	}
+	return res
+}
+
+// IsValid returns whether the value is a
+// valid option for type MergeTake.
+func (i MergeTake) IsValid() bool {
+	_, ok := _MergeTakeMap[i]
+	return ok
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface.
+func (i MergeTake) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements the [encoding.TextUnmarshaler] interface.
+func (i *MergeTake) UnmarshalText(text []byte) error {
+	return i.SetString(string(text))
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Fold is a range of lines that can be folded (hidden) in a view:
+// the first line, StLn, remains visible as the header of the fold,
+// and the lines after it through EdLn (inclusive) are hidden.
+type Fold struct {
+
+	// starting (header) line of the fold, which remains visible
+	StLn int
+
+	// last line hidden by the fold
+	EdLn int
+}
+
+// Contains returns true if given line is hidden by the fold
+func (fd Fold) Contains(ln int) bool {
+	return ln > fd.StLn && ln <= fd.EdLn
+}
+
+// SortFolds sorts folds by starting line, with outer folds before
+// the inner folds that start on the same line, and removes duplicates
+// and empty folds.
+func SortFolds(fds []Fold) []Fold {
+	fds = slices.DeleteFunc(fds, func(fd Fold) bool { return fd.EdLn <= fd.StLn })
+	slices.SortFunc(fds, func(a, b Fold) int {
+		if a.StLn != b.StLn {
+			return a.StLn - b.StLn
+		}
+		return b.EdLn - a.EdLn
+	})
+	return slices.Compact(fds)
+}
+
+// FoldAt returns the index of the innermost fold in given list
+// (as sorted by SortFolds) that has given line as its header or
+// hides it, or -1 if none.
+func FoldAt(fds []Fold, ln int) int {
+	idx := -1
+	for i, fd := range fds {
+		if fd.StLn > ln {
+			break
+		}
+		if fd.StLn == ln || fd.Contains(ln) {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// FoldsIndent returns the folds for given lines based on indentation:
+// each line that is followed by more deeply indented lines is the header
+// of a fold through the last of those lines.  Blank lines are included
+// in a fold only if followed by more deeply indented lines.
+// Tabs count as tabSz spaces.
+func FoldsIndent(lines [][]rune, tabSz int) []Fold {
+	type open struct {
+		ln, ind int
+	}
+	var fds []Fold
+	var stack []open
+	last := -1 // last non-blank line
+	for ln, l := range lines {
+		ind, blank := lineIndent(l, tabSz)
+		if blank {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].ind >= ind {
+			op := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if last > op.ln {
+				fds = append(fds, Fold{StLn: op.ln, EdLn: last})
+			}
+		}
+		stack = append(stack, open{ln, ind})
+		last = ln
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if last > stack[i].ln {
+			fds = append(fds, Fold{StLn: stack[i].ln, EdLn: last})
+		}
+	}
+	return SortFolds(fds)
+}
+
+// lineIndent returns the indentation of given line in spaces,
+// and whether it is blank.
+func lineIndent(l []rune, tabSz int) (int, bool) {
+	ind := 0
+	for _, r := range l {
+		switch {
+		case r == '\t':
+			ind += tabSz
+		case unicode.IsSpace(r):
+			ind++
+		default:
+			return ind, false
+		}
+	}
+	return ind, true
+}
+
+// FoldsBrace returns the folds for given lines based on matching
+// {}, () and [] brackets that span multiple lines: the line with the
+// opening bracket is the header, and the fold extends to the line before
+// the one with the closing bracket (which remains visible), or through
+// that line if there is other text before the bracket on it.
+// Brackets within quotes are skipped, but comments are not recognized.
+func FoldsBrace(lines [][]rune) []Fold {
+	type open struct {
+		ln int
+		br rune
+	}
+	var fds []Fold
+	var stack []open
+	for ln, l := range lines {
+		var quote rune
+		for ch := 0; ch < len(l); ch++ {
+			r := l[ch]
+			if quote != 0 {
+				switch r {
+				case '\\':
+					ch++
+				case quote:
+					quote = 0
+				}
+				continue
+			}
+			switch r {
+			case '"', '\'', '`':
+				quote = r
+			case '{', '(', '[':
+				stack = append(stack, open{ln, r})
+			case '}', ')', ']':
+				if len(stack) == 0 {
+					continue
+				}
+				op := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if ln == op.ln {
+					continue
+				}
+				edln := ln - 1
+				if _, blank := lineIndent(l[:ch], 1); !blank {
+					edln = ln
+				}
+				fds = append(fds, Fold{StLn: op.ln, EdLn: edln})
+			}
+		}
+	}
+	return SortFolds(fds)
+}
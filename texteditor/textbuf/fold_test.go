@@ -0,0 +1,57 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func foldLines(s string) [][]rune {
+	var lns [][]rune
+	for _, l := range strings.Split(s, "\n") {
+		lns = append(lns, []rune(l))
+	}
+	return lns
+}
+
+func TestFoldsBrace(t *testing.T) {
+	src := `func main() {
+	if x {
+		s := "{"
+	}
+	f(a,
+		b)
+}
+`
+	fds := FoldsBrace(foldLines(src))
+	want := []Fold{{0, 5}, {1, 2}, {4, 5}}
+	if !slices.Equal(fds, want) {
+		t.Errorf("FoldsBrace: got %v want %v", fds, want)
+	}
+	if idx := FoldAt(fds, 2); idx != 1 {
+		t.Errorf("FoldAt 2: got %d want 1", idx)
+	}
+	if idx := FoldAt(fds, 6); idx != -1 {
+		t.Errorf("FoldAt 6: got %d want -1", idx)
+	}
+}
+
+func TestFoldsIndent(t *testing.T) {
+	src := `def f():
+    x = 1
+
+    if x:
+        y = 2
+z = 3
+
+`
+	fds := FoldsIndent(foldLines(src), 4)
+	want := []Fold{{0, 4}, {3, 4}}
+	if !slices.Equal(fds, want) {
+		t.Errorf("FoldsIndent: got %v want %v", fds, want)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// JournalVersion is the current version of the journal file format,
+// which is incremented whenever the format changes incompatibly.
+const JournalVersion = 1
+
+// ErrJournalHashMismatch is returned by RecoverJournal when the journal
+// was started for a different version of the file than the given one,
+// so its edits cannot be replayed onto it.
+var ErrJournalHashMismatch = errors.New("textbuf.Journal: journal does not match saved text")
+
+// JournalHeader is the first line of a journal file, recording the
+// text that the journaled edits apply to.
+type JournalHeader struct {
+
+	// format version -- see JournalVersion
+	Version int
+
+	// hash of the saved text that the edits apply to -- see UndoHash
+	Hash string
+}
+
+// Journal is a write-ahead log of the edits made to a buffer since it
+// was last saved: a JournalHeader line followed by one line of JSON per
+// Edit, each written to the file as soon as the edit is made, so that
+// the edits can be recovered with RecoverJournal after a crash.
+type Journal struct {
+
+	// name of the journal file -- empty if not on
+	Filename string
+
+	// the open journal file
+	file *os.File
+
+	// mutex protecting the file
+	Mu sync.Mutex `json:"-" xml:"-"`
+}
+
+// IsOn returns true if the journal is currently recording edits
+func (jn *Journal) IsOn() bool {
+	jn.Mu.Lock()
+	defer jn.Mu.Unlock()
+	return jn.file != nil
+}
+
+// Start starts a new journal in given file, for saved text with given
+// hash (see UndoHash), replacing any existing contents of the file.
+func (jn *Journal) Start(filename string, hash string) error {
+	jn.Mu.Lock()
+	defer jn.Mu.Unlock()
+	jn.closeImpl()
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(&JournalHeader{Version: JournalVersion, Hash: hash})
+	if err == nil {
+		_, err = fp.Write(append(b, '\n'))
+	}
+	if err == nil {
+		err = fp.Sync()
+	}
+	if err != nil {
+		fp.Close()
+		return err
+	}
+	jn.Filename = filename
+	jn.file = fp
+	return nil
+}
+
+// Append writes given edit to the journal, if it is on.  Each edit is
+// written directly to the file and synced to disk before returning, so
+// that it survives the program or the system crashing -- nothing is
+// buffered in memory.
+func (jn *Journal) Append(tbe *Edit) error {
+	if tbe == nil {
+		return nil
+	}
+	jn.Mu.Lock()
+	defer jn.Mu.Unlock()
+	if jn.file == nil {
+		return nil
+	}
+	b, err := json.Marshal(tbe)
+	if err != nil {
+		return err
+	}
+	if _, err = jn.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return jn.file.Sync()
+}
+
+// Close stops recording edits, leaving the journal file in place
+func (jn *Journal) Close() error {
+	jn.Mu.Lock()
+	defer jn.Mu.Unlock()
+	return jn.closeImpl()
+}
+
+func (jn *Journal) closeImpl() error {
+	if jn.file == nil {
+		return nil
+	}
+	err := jn.file.Close()
+	jn.file = nil
+	return err
+}
+
+// Delete stops recording edits and removes the journal file
+func (jn *Journal) Delete() {
+	jn.Mu.Lock()
+	defer jn.Mu.Unlock()
+	jn.closeImpl()
+	if jn.Filename != "" {
+		os.Remove(jn.Filename)
+	}
+	jn.Filename = ""
+}
+
+// ReadJournal reads the header and edits from given journal file.
+// A last line that cannot be decoded, as left by a crash in the middle
+// of writing it, is ignored.
+func ReadJournal(filename string) (*JournalHeader, []*Edit, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	if !sc.Scan() {
+		return nil, nil, fmt.Errorf("textbuf.ReadJournal: journal file is empty: %v", filename)
+	}
+	hd := &JournalHeader{}
+	if err := json.Unmarshal(sc.Bytes(), hd); err != nil {
+		return nil, nil, err
+	}
+	if hd.Version != JournalVersion {
+		return nil, nil, fmt.Errorf("textbuf.ReadJournal: journal file version %d is not the current version %d", hd.Version, JournalVersion)
+	}
+	var edits []*Edit
+	var lerr error
+	for sc.Scan() {
+		if lerr != nil { // only the last line can be incomplete
+			return nil, nil, lerr
+		}
+		tbe := &Edit{}
+		if err := json.Unmarshal(sc.Bytes(), tbe); err != nil {
+			lerr = fmt.Errorf("textbuf.ReadJournal: invalid edit %d: %w", len(edits), err)
+			continue
+		}
+		edits = append(edits, tbe)
+	}
+	return hd, edits, sc.Err()
+}
+
+// RecoverJournal returns the text that results from replaying the edits
+// in given journal file onto given saved text, which must be the text
+// that the journal was started for -- otherwise ErrJournalHashMismatch
+// is returned.  The recovered text ends with a newline only if the
+// saved text does.
+func RecoverJournal(filename string, saved []byte) ([]byte, error) {
+	hd, edits, err := ReadJournal(filename)
+	if err != nil {
+		return nil, err
+	}
+	if hd.Hash != UndoHash(saved) {
+		return nil, ErrJournalHashMismatch
+	}
+	lns, err := ApplyEdits(BytesToRuneLines(saved), edits)
+	if err != nil {
+		return nil, err
+	}
+	return RuneLinesToBytes(lns, bytes.HasSuffix(saved, []byte("\n"))), nil
+}
+
+// BytesToRuneLines splits given text into lines of runes, in the same
+// way as the Buf does: a final newline does not start a new line, and
+// there is always at least one line.
+func BytesToRuneLines(txt []byte) [][]rune {
+	lns := bytes.Split(txt, []byte("\n"))
+	if n := len(lns); n > 1 && len(lns[n-1]) == 0 {
+		lns = lns[:n-1]
+	}
+	rs := make([][]rune, len(lns))
+	for i, ln := range lns {
+		rs[i] = bytes.Runes(ln)
+	}
+	return rs
+}
+
+// RuneLinesToBytes joins given lines into text, separated by newlines,
+// with a newline at the end of the last line only if eol is true,
+// so that it is the inverse of BytesToRuneLines.
+func RuneLinesToBytes(lns [][]rune, eol bool) []byte {
+	var b bytes.Buffer
+	for i, ln := range lns {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(string(ln))
+	}
+	if eol {
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// ApplyEdits applies given edits in order to given lines of text, with
+// the same effect as the corresponding Buf edits, returning the resulting
+// lines, which may share memory with the given ones.  An error is returned
+// if an edit does not fit the text.
+func ApplyEdits(lns [][]rune, edits []*Edit) ([][]rune, error) {
+	if len(lns) == 0 {
+		lns = [][]rune{{}}
+	}
+	for i, tbe := range edits {
+		st, ed := tbe.Reg.Start, tbe.Reg.End
+		if st.Ln < 0 || st.Ch < 0 || ed.Ln < st.Ln {
+			return nil, fmt.Errorf("textbuf.ApplyEdits: invalid region for edit %d: %v", i, tbe.Reg)
+		}
+		switch {
+		case tbe.Rect && tbe.Delete:
+			if ed.Ln >= len(lns) {
+				return nil, fmt.Errorf("textbuf.ApplyEdits: edit %d is past the end of the text: %v", i, tbe.Reg)
+			}
+			for ln := st.Ln; ln <= ed.Ln; ln++ {
+				ls := lns[ln]
+				if len(ls) > st.Ch {
+					if ed.Ch < len(ls)-1 {
+						lns[ln] = append(ls[:st.Ch:st.Ch], ls[ed.Ch:]...)
+					} else {
+						lns[ln] = ls[:st.Ch]
+					}
+				}
+			}
+		case tbe.Rect:
+			for len(lns) <= ed.Ln {
+				lns = append(lns, nil)
+			}
+			for j := 0; j <= ed.Ln-st.Ln && j < len(tbe.Text); j++ {
+				ln := st.Ln + j
+				lr := lns[ln]
+				for len(lr) < st.Ch {
+					lr = append(lr, ' ')
+				}
+				lns[ln] = slices.Insert(lr, st.Ch, tbe.Text[j]...)
+			}
+		case tbe.Delete:
+			if ed.Ln >= len(lns) || st.Ch > len(lns[st.Ln]) || ed.Ch > len(lns[ed.Ln]) {
+				return nil, fmt.Errorf("textbuf.ApplyEdits: edit %d is past the end of the text: %v", i, tbe.Reg)
+			}
+			nl := append(lns[st.Ln][:st.Ch:st.Ch], lns[ed.Ln][ed.Ch:]...)
+			lns = slices.Delete(lns, st.Ln+1, ed.Ln+1)
+			lns[st.Ln] = nl
+		default:
+			if st.Ln >= len(lns) || st.Ch > len(lns[st.Ln]) || len(tbe.Text) == 0 {
+				return nil, fmt.Errorf("textbuf.ApplyEdits: edit %d is past the end of the text: %v", i, tbe.Reg)
+			}
+			ls := lns[st.Ln]
+			eost := slices.Clone(ls[st.Ch:])
+			nt := len(tbe.Text)
+			ins := make([][]rune, nt)
+			for j, t := range tbe.Text {
+				ins[j] = slices.Clone(t)
+			}
+			ins[0] = append(slices.Clone(ls[:st.Ch]), ins[0]...)
+			ins[nt-1] = append(ins[nt-1], eost...)
+			lns = slices.Replace(lns, st.Ln, st.Ln+1, ins...)
+		}
+	}
+	return lns, nil
+}
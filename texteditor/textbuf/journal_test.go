@@ -0,0 +1,70 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRecover(t *testing.T) {
+	saved := []byte("one\ntwo\nthree\n")
+	fn := filepath.Join(t.TempDir(), ".file.journal")
+	jn := &Journal{}
+	if err := jn.Start(fn, UndoHash(saved)); err != nil {
+		t.Fatal(err)
+	}
+	edits := []*Edit{
+		{Reg: NewRegion(0, 3, 1, 3), Text: [][]rune{[]rune("!"), []rune("new")}},
+		{Reg: NewRegion(2, 0, 3, 0), Delete: true},
+		{Reg: NewRegion(2, 1, 3, 2), Text: [][]rune{[]rune("xy"), []rune("zw")}, Rect: true},
+		{Reg: NewRegion(0, 0, 0, 1), Delete: true},
+	}
+	for _, tbe := range edits {
+		if err := jn.Append(tbe); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jn.Close()
+	// simulate a crash in the middle of writing an edit
+	fp, _ := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY, 0644)
+	fp.WriteString(`{"Reg":{"St`)
+	fp.Close()
+
+	got, err := RecoverJournal(fn, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ne!\nnew\ntxyhree\n zw\n"
+	if string(got) != want {
+		t.Errorf("RecoverJournal = %q, want %q", got, want)
+	}
+	if _, err := RecoverJournal(fn, []byte("other\n")); err != ErrJournalHashMismatch {
+		t.Errorf("RecoverJournal on other text: err = %v, want ErrJournalHashMismatch", err)
+	}
+	jn.Delete()
+	if _, err := os.Stat(fn); !os.IsNotExist(err) {
+		t.Errorf("journal file not deleted")
+	}
+}
+
+func TestJournalRecoverNoEOL(t *testing.T) {
+	for _, saved := range []string{"one\ntwo", "one\ntwo\n", "", "\n", "one\r\ntwo\r\n"} {
+		fn := filepath.Join(t.TempDir(), ".file.journal")
+		jn := &Journal{}
+		if err := jn.Start(fn, UndoHash([]byte(saved))); err != nil {
+			t.Fatal(err)
+		}
+		jn.Close()
+		got, err := RecoverJournal(fn, []byte(saved))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != saved {
+			t.Errorf("RecoverJournal with no edits = %q, want %q", got, saved)
+		}
+	}
+}
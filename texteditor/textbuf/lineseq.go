@@ -0,0 +1,283 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import "slices"
+
+// LineSeqChunkSize is the maximum number of values stored in each
+// chunk of a LineSeq -- values are split into chunks of this size
+// when inserted, and small inserts go into existing chunks up to this size.
+var LineSeqChunkSize = 256
+
+// LineSeq is a sequence of values for each line of a text, e.g., the
+// markup or tags of the lines held in a Rope, where inserting and deleting
+// lines costs O(log n) in the number of lines, instead of the O(n) cost
+// of splicing a slice.  As in the Rope, the values are held in chunks in
+// a balanced tree (a treap), where each node records the number of values
+// below it, so that the value for a given line is also found in O(log n).
+// The zero value is an empty sequence.  It is not safe for concurrent use.
+type LineSeq[T any] struct {
+	root *seqNode[T]
+
+	// random state for node priorities
+	seed uint32
+}
+
+// seqNode is one node in the LineSeq treap, holding a chunk of values
+type seqNode[T any] struct {
+	left, right *seqNode[T]
+
+	// heap priority for balancing
+	pri uint32
+
+	// the chunk of values for this node
+	vals []T
+
+	// total number of values in this subtree
+	size int
+}
+
+func (n *seqNode[T]) update() {
+	n.size = len(n.vals) + seqSize(n.left) + seqSize(n.right)
+}
+
+func seqSize[T any](n *seqNode[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (ls *LineSeq[T]) newNode(vals []T) *seqNode[T] {
+	n := &seqNode[T]{vals: vals, pri: nextPri(&ls.seed)}
+	n.update()
+	return n
+}
+
+// build returns a tree for a copy of given values, split into chunks
+func (ls *LineSeq[T]) build(vals []T) *seqNode[T] {
+	var t *seqNode[T]
+	for len(vals) > 0 {
+		n := min(LineSeqChunkSize, len(vals))
+		t = seqMerge(t, ls.newNode(slices.Clone(vals[:n])))
+		vals = vals[n:]
+	}
+	return t
+}
+
+// seqMerge merges two trees where all of a precedes b
+func seqMerge[T any](a, b *seqNode[T]) *seqNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.pri > b.pri {
+		a.right = seqMerge(a.right, b)
+		a.update()
+		return a
+	}
+	b.left = seqMerge(a, b.left)
+	b.update()
+	return b
+}
+
+// split splits tree into the first k values and the rest,
+// splitting a chunk if needed
+func (ls *LineSeq[T]) split(n *seqNode[T], k int) (*seqNode[T], *seqNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+	lsz := seqSize(n.left)
+	switch {
+	case k <= lsz:
+		a, b := ls.split(n.left, k)
+		n.left = b
+		n.update()
+		return a, n
+	case k >= lsz+len(n.vals):
+		a, b := ls.split(n.right, k-lsz-len(n.vals))
+		n.right = a
+		n.update()
+		return n, b
+	}
+	// split within this chunk
+	ck := k - lsz
+	rn := ls.newNode(slices.Clone(n.vals[ck:]))
+	rn.pri = n.pri // keeps heap order with n.right
+	n.vals = n.vals[:ck:ck]
+	rn.right = n.right
+	rn.update()
+	n.right = nil
+	n.update()
+	return n, rn
+}
+
+// insertInto inserts given values at index i within the chunk that
+// contains it, if the chunk has room for them, returning false if not.
+func insertInto[T any](n *seqNode[T], i int, vals []T) bool {
+	if n == nil {
+		return false
+	}
+	lsz := seqSize(n.left)
+	var ok bool
+	switch {
+	case i < lsz:
+		ok = insertInto(n.left, i, vals)
+	case i > lsz+len(n.vals):
+		ok = insertInto(n.right, i-lsz-len(n.vals), vals)
+	case len(n.vals)+len(vals) <= LineSeqChunkSize:
+		n.vals = slices.Insert(n.vals, i-lsz, vals...)
+		ok = true
+	}
+	if ok {
+		n.update()
+	}
+	return ok
+}
+
+// deleteFrom deletes the values from st up to ed within the chunk that
+// contains them, if they are in one chunk and do not empty it,
+// returning false if not.
+func deleteFrom[T any](n *seqNode[T], st, ed int) bool {
+	if n == nil {
+		return false
+	}
+	lsz := seqSize(n.left)
+	var ok bool
+	switch {
+	case ed <= lsz:
+		ok = deleteFrom(n.left, st, ed)
+	case st >= lsz+len(n.vals):
+		ok = deleteFrom(n.right, st-lsz-len(n.vals), ed-lsz-len(n.vals))
+	case st >= lsz && ed <= lsz+len(n.vals) && ed-st < len(n.vals):
+		nv := slices.Delete(n.vals, st-lsz, ed-lsz)
+		clear(n.vals[len(nv):]) // no stale references
+		n.vals = nv
+		ok = true
+	}
+	if ok {
+		n.update()
+	}
+	return ok
+}
+
+// Len returns the number of values, one for each line
+func (ls *LineSeq[T]) Len() int {
+	return seqSize(ls.root)
+}
+
+// Reset replaces the values with n zero values
+func (ls *LineSeq[T]) Reset(n int) {
+	ls.root = nil
+	for n > 0 {
+		cn := min(LineSeqChunkSize, n)
+		ls.root = seqMerge(ls.root, ls.newNode(make([]T, cn)))
+		n -= cn
+	}
+}
+
+// SetAll replaces the values with a copy of given values
+func (ls *LineSeq[T]) SetAll(vals []T) {
+	ls.root = ls.build(vals)
+}
+
+// chunkAt returns the chunk containing index i, which must be
+// valid, and the index within it
+func (ls *LineSeq[T]) chunkAt(i int) ([]T, int) {
+	n := ls.root
+	for {
+		lsz := seqSize(n.left)
+		switch {
+		case i < lsz:
+			n = n.left
+		case i < lsz+len(n.vals):
+			return n.vals, i - lsz
+		default:
+			i -= lsz + len(n.vals)
+			n = n.right
+		}
+	}
+}
+
+// At returns the value for line i, or the zero value if i is not in range
+func (ls *LineSeq[T]) At(i int) T {
+	if i < 0 || i >= ls.Len() {
+		var zv T
+		return zv
+	}
+	cs, ci := ls.chunkAt(i)
+	return cs[ci]
+}
+
+// Set sets the value for line i, doing nothing if i is not in range
+func (ls *LineSeq[T]) Set(i int, v T) {
+	if i < 0 || i >= ls.Len() {
+		return
+	}
+	cs, ci := ls.chunkAt(i)
+	cs[ci] = v
+}
+
+// Insert inserts given values before line i, so that the first of them
+// is at i, e.g., for lines inserted in the text, as zero values to be set.
+func (ls *LineSeq[T]) Insert(i int, vals ...T) {
+	if len(vals) == 0 {
+		return
+	}
+	i = min(max(i, 0), ls.Len())
+	if insertInto(ls.root, i, vals) {
+		return
+	}
+	a, b := ls.split(ls.root, i)
+	ls.root = seqMerge(seqMerge(a, ls.build(vals)), b)
+}
+
+// Delete deletes the values for lines from st up to (not including) ed
+func (ls *LineSeq[T]) Delete(st, ed int) {
+	st = max(st, 0)
+	ed = min(ed, ls.Len())
+	if st >= ed {
+		return
+	}
+	if deleteFrom(ls.root, st, ed) {
+		return
+	}
+	a, b := ls.split(ls.root, st)
+	_, c := ls.split(b, ed-st)
+	ls.root = seqMerge(a, c)
+}
+
+// Slice returns a copy of the values for lines from st up to
+// (not including) ed, clipped to the valid range
+func (ls *LineSeq[T]) Slice(st, ed int) []T {
+	st = max(st, 0)
+	ed = min(ed, ls.Len())
+	if st >= ed {
+		return nil
+	}
+	return seqCollect(ls.root, st, ed, make([]T, 0, ed-st))
+}
+
+// seqCollect appends the values in [st, ed) of the subtree to out
+func seqCollect[T any](n *seqNode[T], st, ed int, out []T) []T {
+	if n == nil || st >= ed {
+		return out
+	}
+	lsz := seqSize(n.left)
+	if st < lsz {
+		out = seqCollect(n.left, st, min(ed, lsz), out)
+	}
+	cs, ce := max(st-lsz, 0), min(ed-lsz, len(n.vals))
+	if cs < ce {
+		out = append(out, n.vals[cs:ce]...)
+	}
+	rs := lsz + len(n.vals)
+	if ed > rs {
+		out = seqCollect(n.right, max(st-rs, 0), ed-rs, out)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestLineSeq(t *testing.T) {
+	defer func(sz int) { LineSeqChunkSize = sz }(LineSeqChunkSize)
+	LineSeqChunkSize = 8 // exercise splitting and merging chunks
+	rnd := rand.New(rand.NewSource(1))
+	ls := &LineSeq[int]{}
+	ls.Reset(20)
+	ref := make([]int, 20)
+	next := 1
+	for i := 0; i < 3000; i++ {
+		n := ls.Len()
+		switch rnd.Intn(4) {
+		case 0, 1:
+			at := rnd.Intn(n + 1)
+			vals := make([]int, 1+rnd.Intn(3)*rnd.Intn(10))
+			for j := range vals {
+				vals[j] = next
+				next++
+			}
+			ls.Insert(at, vals...)
+			ref = slices.Insert(ref, at, vals...)
+			vals[0] = -1 // the values are copied
+		case 2:
+			st := rnd.Intn(n + 1)
+			ed := st + rnd.Intn(12)
+			ls.Delete(st, ed)
+			ref = slices.Delete(ref, st, min(ed, len(ref)))
+		default:
+			if n > 0 {
+				at := rnd.Intn(n)
+				ls.Set(at, next)
+				ref[at] = next
+				next++
+			}
+		}
+		if ls.Len() != len(ref) {
+			t.Fatalf("step %d: Len %d != %d", i, ls.Len(), len(ref))
+		}
+		if got := ls.Slice(0, ls.Len()); !slices.Equal(got, ref) {
+			t.Fatalf("step %d: values:\n%v\n%v", i, got, ref)
+		}
+	}
+	for i, v := range ref {
+		if ls.At(i) != v {
+			t.Errorf("At(%d) = %d, want %d", i, ls.At(i), v)
+		}
+	}
+	if ls.At(-1) != 0 || ls.At(len(ref)) != 0 {
+		t.Errorf("At out of range is not the zero value")
+	}
+	ls.Set(len(ref), 5) // does nothing
+	if got := ls.Slice(2, 5); !slices.Equal(got, ref[2:5]) {
+		t.Errorf("Slice(2, 5) = %v, want %v", got, ref[2:5])
+	}
+	ls.SetAll([]int{1, 2, 3})
+	if got := ls.Slice(-1, 10); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("values after SetAll = %v", got)
+	}
+}
+
+func BenchmarkLineSeqInsertDelete(b *testing.B) {
+	ls := &LineSeq[[]byte]{}
+	ls.Reset(benchLines)
+	rnd := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ln := rnd.Intn(ls.Len())
+		ls.Insert(ln, nil, nil)
+		ls.Delete(ln, ln+2)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"strings"
+)
+
+// MergeTake specifies how a hunk of a three-way merge is resolved
+type MergeTake int32 //enums:enum
+
+const (
+	// MergeUnresolved is a conflict that has not been resolved,
+	// which is written with conflict markers in the merged lines
+	MergeUnresolved MergeTake = iota
+
+	// MergeTakeA takes the lines from the A (left, ours) version
+	MergeTakeA
+
+	// MergeTakeB takes the lines from the B (right, theirs) version
+	MergeTakeB
+
+	// MergeTakeBoth takes the lines from the A version followed by
+	// those from the B version
+	MergeTakeBoth
+)
+
+// Conflict markers used in the merged lines for unresolved conflicts,
+// as used by git and diff3.
+const (
+	MergeMarkerA    = "<<<<<<<"
+	MergeMarkerBase = "|||||||"
+	MergeMarkerSep  = "======="
+	MergeMarkerB    = ">>>>>>>"
+)
+
+// MergeHunk is a region of lines that was changed in one or both of
+// the A and B versions relative to the common Base version, in a
+// three-way merge.  All ranges are [St, Ed) line indexes.
+type MergeHunk struct {
+
+	// true if A and B both changed these lines, in different ways
+	Conflict bool
+
+	// how the hunk is resolved -- automatically set for hunks that
+	// are not conflicts
+	Take MergeTake
+
+	// range of lines in the Base version
+	BaseSt, BaseEd int
+
+	// range of lines in the A version
+	ASt, AEd int
+
+	// range of lines in the B version
+	BSt, BEd int
+
+	// range of lines in the merged Lines output, updated by Update
+	OutSt, OutEd int
+}
+
+// Merge is the result of a three-way merge of two versions of text
+// (A and B) that were both derived from a common Base version,
+// with one string per line.
+type Merge struct {
+
+	// common base version
+	Base []string
+
+	// A version (left, ours)
+	A []string
+
+	// B version (right, theirs)
+	B []string
+
+	// label for the A version, used in conflict markers
+	LabelA string
+
+	// label for the B version, used in conflict markers
+	LabelB string
+
+	// the hunks of lines changed in A and / or B, in order
+	Hunks []MergeHunk
+
+	// the merged lines, with conflict markers around unresolved conflicts
+	Lines []string
+}
+
+// Merge3 does a three-way merge of the a and b versions of text relative
+// to their common base, with one string per line.  Lines changed in only
+// one of a or b (or changed the same way in both) are merged automatically,
+// while lines changed in different ways in both are conflicts, which
+// are marked in the merged Lines until they are resolved.
+func Merge3(base, a, b []string) *Merge {
+	mg := &Merge{Base: base, A: a, B: b, LabelA: "A", LabelB: "B"}
+	type change struct {
+		i1, i2, j1, j2 int
+		side           int // 0 = a, 1 = b
+	}
+	var chs []change
+	for side, dif := range []Diffs{DiffLines(base, a), DiffLines(base, b)} {
+		for _, op := range dif {
+			if op.Tag != 'e' {
+				chs = append(chs, change{op.I1, op.I2, op.J1, op.J2, side})
+			}
+		}
+	}
+	slices.SortStableFunc(chs, func(x, y change) int { return x.i1 - y.i1 })
+	var off [2]int // offsets of a, b lines relative to base lines before current hunk
+	for ci := 0; ci < len(chs); {
+		// changes that overlap or touch in base lines are in the same hunk
+		bst, bed := chs[ci].i1, chs[ci].i2
+		var in [2]bool
+		var delta [2]int
+		for ; ci < len(chs) && chs[ci].i1 <= bed; ci++ {
+			ch := chs[ci]
+			bed = max(bed, ch.i2)
+			in[ch.side] = true
+			delta[ch.side] += (ch.j2 - ch.j1) - (ch.i2 - ch.i1)
+		}
+		h := MergeHunk{BaseSt: bst, BaseEd: bed}
+		h.ASt, h.AEd = bst+off[0], bed+off[0]+delta[0]
+		h.BSt, h.BEd = bst+off[1], bed+off[1]+delta[1]
+		switch {
+		case !in[1]:
+			h.Take = MergeTakeA
+		case !in[0]:
+			h.Take = MergeTakeB
+		case slices.Equal(a[h.ASt:h.AEd], b[h.BSt:h.BEd]):
+			h.Take = MergeTakeA
+		default:
+			h.Conflict = true
+		}
+		off[0] += delta[0]
+		off[1] += delta[1]
+		mg.Hunks = append(mg.Hunks, h)
+	}
+	mg.Update()
+	return mg
+}
+
+// ParseConflicts parses lines of text containing conflict markers, as
+// written by git and diff3 (optionally including the base lines after a
+// ||||||| marker), into a Merge with one conflict hunk per marked region.
+// The labels after the first markers are used for LabelA and LabelB.
+func ParseConflicts(lines []string) *Merge {
+	mg := &Merge{LabelA: "A", LabelB: "B"}
+	const (
+		common = iota
+		inA
+		inBase
+		inB
+	)
+	state := common
+	var h MergeHunk
+	label := func(ln, mark string) string {
+		return strings.TrimSpace(strings.TrimPrefix(ln, mark))
+	}
+	for _, ln := range lines {
+		switch {
+		case state == common && strings.HasPrefix(ln, MergeMarkerA):
+			if len(mg.Hunks) == 0 {
+				if lb := label(ln, MergeMarkerA); lb != "" {
+					mg.LabelA = lb
+				}
+			}
+			h = MergeHunk{Conflict: true, BaseSt: len(mg.Base), ASt: len(mg.A), BSt: len(mg.B)}
+			state = inA
+		case state == inA && strings.HasPrefix(ln, MergeMarkerBase):
+			state = inBase
+		case (state == inA || state == inBase) && strings.HasPrefix(ln, MergeMarkerSep):
+			state = inB
+		case state == inB && strings.HasPrefix(ln, MergeMarkerB):
+			if len(mg.Hunks) == 0 {
+				if lb := label(ln, MergeMarkerB); lb != "" {
+					mg.LabelB = lb
+				}
+			}
+			h.BaseEd, h.AEd, h.BEd = len(mg.Base), len(mg.A), len(mg.B)
+			mg.Hunks = append(mg.Hunks, h)
+			state = common
+		case state == inA:
+			mg.A = append(mg.A, ln)
+		case state == inBase:
+			mg.Base = append(mg.Base, ln)
+		case state == inB:
+			mg.B = append(mg.B, ln)
+		default:
+			mg.Base = append(mg.Base, ln)
+			mg.A = append(mg.A, ln)
+			mg.B = append(mg.B, ln)
+		}
+	}
+	if state != common { // unterminated conflict
+		h.BaseEd, h.AEd, h.BEd = len(mg.Base), len(mg.A), len(mg.B)
+		mg.Hunks = append(mg.Hunks, h)
+	}
+	mg.Update()
+	return mg
+}
+
+// Update updates the merged Lines, and the OutSt, OutEd ranges of the
+// hunks, from the current resolution of each of the hunks.
+func (mg *Merge) Update() {
+	lines := make([]string, 0, len(mg.Base))
+	bi := 0
+	for i := range mg.Hunks {
+		h := &mg.Hunks[i]
+		lines = append(lines, mg.Base[bi:h.BaseSt]...)
+		h.OutSt = len(lines)
+		a := mg.A[h.ASt:h.AEd]
+		b := mg.B[h.BSt:h.BEd]
+		switch h.Take {
+		case MergeTakeA:
+			lines = append(lines, a...)
+		case MergeTakeB:
+			lines = append(lines, b...)
+		case MergeTakeBoth:
+			lines = append(lines, a...)
+			lines = append(lines, b...)
+		default:
+			lines = append(lines, MergeMarkerA+" "+mg.LabelA)
+			lines = append(lines, a...)
+			lines = append(lines, MergeMarkerSep)
+			lines = append(lines, b...)
+			lines = append(lines, MergeMarkerB+" "+mg.LabelB)
+		}
+		h.OutEd = len(lines)
+		bi = h.BaseEd
+	}
+	mg.Lines = append(lines, mg.Base[bi:]...)
+}
+
+// Resolve resolves the hunk at given index according to given take,
+// and updates the merged Lines.
+func (mg *Merge) Resolve(idx int, take MergeTake) {
+	if idx < 0 || idx >= len(mg.Hunks) {
+		return
+	}
+	mg.Hunks[idx].Take = take
+	mg.Update()
+}
+
+// NumConflicts returns the number of conflict hunks, and the number
+// of those that have not yet been resolved.
+func (mg *Merge) NumConflicts() (n, unresolved int) {
+	for _, h := range mg.Hunks {
+		if !h.Conflict {
+			continue
+		}
+		n++
+		if h.Take == MergeUnresolved {
+			unresolved++
+		}
+	}
+	return
+}
+
+// NextConflict returns the index of the next conflict hunk after the
+// given hunk index (use -1 to start at the beginning), wrapping around
+// at the end, or -1 if there are no conflicts.
+func (mg *Merge) NextConflict(idx int) int {
+	n := len(mg.Hunks)
+	for i := 1; i <= n; i++ {
+		hi := (idx + i + n) % n
+		if mg.Hunks[hi].Conflict {
+			return hi
+		}
+	}
+	return -1
+}
+
+// PrevConflict returns the index of the previous conflict hunk before
+// the given hunk index (use -1 to start at the end), wrapping around
+// at the start, or -1 if there are no conflicts.
+func (mg *Merge) PrevConflict(idx int) int {
+	n := len(mg.Hunks)
+	if idx < 0 {
+		idx = n
+	}
+	for i := 1; i <= n; i++ {
+		hi := ((idx-i)%n + n) % n
+		if mg.Hunks[hi].Conflict {
+			return hi
+		}
+	}
+	return -1
+}
+
+// HunkAtLine returns the index of the hunk that contains given line
+// of the merged Lines, or -1 if none.
+func (mg *Merge) HunkAtLine(ln int) int {
+	for i, h := range mg.Hunks {
+		if ln >= h.OutSt && (ln < h.OutEd || (ln == h.OutSt && h.OutEd == h.OutSt)) {
+			return i
+		}
+	}
+	return -1
+}
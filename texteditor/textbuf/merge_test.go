@@ -0,0 +1,64 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := strings.Split("a b c d e f g", " ")
+	a := strings.Split("a B c d e f g h", " ")
+	b := strings.Split("a b c D e F g", " ")
+	mg := Merge3(base, a, b)
+	if n, _ := mg.NumConflicts(); n != 0 {
+		t.Errorf("expected no conflicts, got %d: %v", n, mg.Hunks)
+	}
+	want := strings.Split("a B c D e F g h", " ")
+	if !slices.Equal(mg.Lines, want) {
+		t.Errorf("merged:\n%v\nwant:\n%v", mg.Lines, want)
+	}
+
+	b = strings.Split("a b2 c d e f", " ")
+	mg = Merge3(base, a, b)
+	if n, un := mg.NumConflicts(); n != 2 || un != 2 {
+		t.Fatalf("expected 2 unresolved conflicts, got %d, %d: %v", n, un, mg.Hunks)
+	}
+	want = []string{"a", "<<<<<<< A", "B", "=======", "b2", ">>>>>>> B", "c", "d", "e", "f",
+		"<<<<<<< A", "g", "h", "=======", ">>>>>>> B"}
+	if !slices.Equal(mg.Lines, want) {
+		t.Errorf("merged:\n%v\nwant:\n%v", mg.Lines, want)
+	}
+	ci := mg.NextConflict(-1)
+	mg.Resolve(ci, MergeTakeBoth)
+	mg.Resolve(mg.NextConflict(ci), MergeTakeB)
+	want = strings.Split("a B b2 c d e f", " ")
+	if !slices.Equal(mg.Lines, want) {
+		t.Errorf("resolved:\n%v\nwant:\n%v", mg.Lines, want)
+	}
+	if _, un := mg.NumConflicts(); un != 0 {
+		t.Errorf("expected all conflicts resolved")
+	}
+	if hi := mg.HunkAtLine(2); hi != ci {
+		t.Errorf("HunkAtLine(2) = %d, want %d", hi, ci)
+	}
+}
+
+func TestParseConflicts(t *testing.T) {
+	src := []string{"a", "<<<<<<< HEAD", "x", "||||||| base", "b", "=======", "y", "z", ">>>>>>> branch", "c"}
+	mg := ParseConflicts(src)
+	if mg.LabelA != "HEAD" || mg.LabelB != "branch" {
+		t.Errorf("labels: %q %q", mg.LabelA, mg.LabelB)
+	}
+	if !slices.Equal(mg.Base, []string{"a", "b", "c"}) || !slices.Equal(mg.A, []string{"a", "x", "c"}) || !slices.Equal(mg.B, []string{"a", "y", "z", "c"}) {
+		t.Errorf("versions: %v %v %v", mg.Base, mg.A, mg.B)
+	}
+	mg.Resolve(0, MergeTakeB)
+	if want := []string{"a", "y", "z", "c"}; !slices.Equal(mg.Lines, want) {
+		t.Errorf("resolved:\n%v\nwant:\n%v", mg.Lines, want)
+	}
+}
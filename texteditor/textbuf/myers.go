@@ -0,0 +1,503 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/goki/go-difflib/difflib"
+)
+
+// DiffLevels are the levels of granularity at which differences
+// within lines are computed by DiffLineRegions.
+type DiffLevels int32
+
+const (
+	// DiffLevelWord computes differences in terms of words,
+	// where a word is a run of letters, digits and underscores,
+	// and every other non-space rune is its own word.
+	DiffLevelWord DiffLevels = iota
+
+	// DiffLevelRune computes differences in terms of individual runes
+	DiffLevelRune
+)
+
+// DiffOpts are options for computing diffs with MyersDiffLines
+// and related functions.
+type DiffOpts struct {
+
+	// ignore all differences in whitespace, including the amount of
+	// indentation and any whitespace at the end of lines
+	IgnoreWhitespace bool
+
+	// maximum edit cost (number of differences) that is searched for
+	// an optimal diff between any pair of regions, beyond which a fast
+	// approximation is used -- 0 uses a default based on the size of the
+	// text, and -1 means no limit, which can be very slow for large,
+	// very different texts
+	MaxCost int
+
+	// minimum number of lines in a block of deleted lines that is
+	// reported as a move by DiffMoves if the same lines are inserted
+	// elsewhere -- 0 uses a default of 2
+	MinMoveLines int
+}
+
+// MyersDiffLines computes the diff between two string arrays (one string per
+// line), using the Myers O(ND) difference algorithm, in linear space, which
+// finds a minimal set of differences, and is deterministic and fast even for
+// very large files.  The result is in the same format as DiffLines, as a
+// sequence of 'r' (replace), 'd' (delete), 'i' (insert) and 'e' (equal)
+// operations that convert a into b.  opts can be nil for defaults.
+func MyersDiffLines(astr, bstr []string, opts *DiffOpts) Diffs {
+	if opts == nil {
+		opts = &DiffOpts{}
+	}
+	ids := make(map[string]int, len(astr))
+	tokens := func(strs []string) []int {
+		toks := make([]int, len(strs))
+		for i, s := range strs {
+			if opts.IgnoreWhitespace {
+				s = stripSpace(s)
+			}
+			id, ok := ids[s]
+			if !ok {
+				id = len(ids)
+				ids[s] = id
+			}
+			toks[i] = id
+		}
+		return toks
+	}
+	at := tokens(astr)
+	bt := tokens(bstr)
+	return myersDiff(at, bt, opts.MaxCost)
+}
+
+// DiffMove records a block of lines that were moved: the lines
+// deleted from the a text at I1 - I2 are inserted in the b text at J1 - J2.
+type DiffMove struct {
+	I1, I2, J1, J2 int
+}
+
+// DiffMoves returns the blocks of lines that are deleted in one place and
+// inserted in another in given diffs between the a and b texts (as
+// returned by MyersDiffLines or DiffLines).  A deleted block (which can
+// be part of a replace) is a move if exactly the same lines (ignoring
+// whitespace if so specified in opts) are inserted as a block elsewhere,
+// and it has at least opts.MinMoveLines non-blank lines.
+// Each inserted block is matched with at most one deleted block.
+func DiffMoves(astr, bstr []string, dif Diffs, opts *DiffOpts) []DiffMove {
+	if opts == nil {
+		opts = &DiffOpts{}
+	}
+	minLns := opts.MinMoveLines
+	if minLns <= 0 {
+		minLns = 2
+	}
+	key := func(strs []string) (string, bool) {
+		var b strings.Builder
+		nb := 0
+		for _, s := range strs {
+			if opts.IgnoreWhitespace {
+				s = stripSpace(s)
+			}
+			if strings.TrimSpace(s) != "" {
+				nb++
+			}
+			b.WriteString(s)
+			b.WriteByte('\n')
+		}
+		return b.String(), nb >= minLns
+	}
+	ins := make(map[string][]int) // key -> index of inserting ops
+	for i, op := range dif {
+		if op.Tag == 'i' || op.Tag == 'r' {
+			if k, ok := key(bstr[op.J1:op.J2]); ok {
+				ins[k] = append(ins[k], i)
+			}
+		}
+	}
+	var mvs []DiffMove
+	for i, op := range dif {
+		if op.Tag != 'd' && op.Tag != 'r' {
+			continue
+		}
+		k, ok := key(astr[op.I1:op.I2])
+		if !ok {
+			continue
+		}
+		for n, ii := range ins[k] {
+			if ii == i { // replaced in place, not moved
+				continue
+			}
+			iop := dif[ii]
+			mvs = append(mvs, DiffMove{I1: op.I1, I2: op.I2, J1: iop.J1, J2: iop.J2})
+			ins[k] = append(ins[k][:n], ins[k][n+1:]...)
+			break
+		}
+	}
+	return mvs
+}
+
+// LineDiff is one difference within a single line of text between a and b,
+// as returned by DiffLineEdits.
+type LineDiff struct {
+
+	// kind of difference: 'r' (replace), 'd' (delete from a)
+	// or 'i' (insert into b)
+	Tag byte
+
+	// region in a on line 0 -- empty for an insert
+	A Region
+
+	// region in b on line 0 -- empty for a delete
+	B Region
+}
+
+// DiffLineEdits returns the differences within a single line of text
+// between a and b, at the given level of granularity, where any adjacent
+// differences are combined into one.  If ignoreWs is true, differences
+// in whitespace are ignored.
+func DiffLineEdits(a, b []rune, level DiffLevels, ignoreWs bool) []LineDiff {
+	var ats, bts []diffToken
+	if level == DiffLevelRune {
+		ats = runeTokens(a, ignoreWs)
+		bts = runeTokens(b, ignoreWs)
+	} else {
+		ats = wordTokens(a, ignoreWs)
+		bts = wordTokens(b, ignoreWs)
+	}
+	ids := make(map[string]int)
+	toInts := func(txt []rune, toks []diffToken) []int {
+		ints := make([]int, len(toks))
+		for i, tk := range toks {
+			s := string(txt[tk.st:tk.ed])
+			id, ok := ids[s]
+			if !ok {
+				id = len(ids)
+				ids[s] = id
+			}
+			ints[i] = id
+		}
+		return ints
+	}
+	dif := myersDiff(toInts(a, ats), toInts(b, bts), -1)
+	var lds []LineDiff
+	for _, op := range dif {
+		if op.Tag == 'e' {
+			continue
+		}
+		ld := LineDiff{Tag: op.Tag}
+		if op.I2 > op.I1 {
+			ld.A = NewRegion(0, ats[op.I1].st, 0, ats[op.I2-1].ed)
+		}
+		if op.J2 > op.J1 {
+			ld.B = NewRegion(0, bts[op.J1].st, 0, bts[op.J2-1].ed)
+		}
+		lds = append(lds, ld)
+	}
+	return lds
+}
+
+// DiffLineRegions returns the regions that differ within a single line of
+// text between a and b, at the given level of granularity, as regions in
+// each of a and b on line 0, where any adjacent differences are combined
+// into one region.  An insertion into b has a region only in b, and
+// a deletion from a only in a.  If ignoreWs is true, differences in
+// whitespace are ignored.  See DiffLineEdits for the kind of each.
+func DiffLineRegions(a, b []rune, level DiffLevels, ignoreWs bool) (aregs, bregs []Region) {
+	for _, ld := range DiffLineEdits(a, b, level, ignoreWs) {
+		if ld.Tag != 'i' {
+			aregs = append(aregs, ld.A)
+		}
+		if ld.Tag != 'd' {
+			bregs = append(bregs, ld.B)
+		}
+	}
+	return
+}
+
+// DiffRegions returns the regions that differ within each of the lines that
+// are replaced in given diffs between the a and b texts (as returned by
+// MyersDiffLines or DiffLines), at the given level of granularity.  The lines
+// in each replace operation are paired in order, and any lines beyond the
+// number in the other text have no regions, as the whole line differs.
+// The regions are in terms of the line numbers of a and b respectively.
+func DiffRegions(astr, bstr []string, dif Diffs, level DiffLevels, ignoreWs bool) (aregs, bregs []Region) {
+	for _, op := range dif {
+		if op.Tag != 'r' {
+			continue
+		}
+		n := min(op.I2-op.I1, op.J2-op.J1)
+		for i := 0; i < n; i++ {
+			ar, br := DiffLineRegions([]rune(astr[op.I1+i]), []rune(bstr[op.J1+i]), level, ignoreWs)
+			for _, r := range ar {
+				r.Start.Ln, r.End.Ln = op.I1+i, op.I1+i
+				aregs = append(aregs, r)
+			}
+			for _, r := range br {
+				r.Start.Ln, r.End.Ln = op.J1+i, op.J1+i
+				bregs = append(bregs, r)
+			}
+		}
+	}
+	return
+}
+
+// stripSpace returns the string with all whitespace removed
+func stripSpace(s string) string {
+	if strings.IndexFunc(s, unicode.IsSpace) < 0 {
+		return s
+	}
+	return strings.Join(strings.Fields(s), "")
+}
+
+// diffToken is a token of text within a line, as a range of runes
+type diffToken struct {
+	st, ed int
+}
+
+// runeTokens returns each rune as a token, skipping whitespace if ignoreWs
+func runeTokens(txt []rune, ignoreWs bool) []diffToken {
+	toks := make([]diffToken, 0, len(txt))
+	for i, r := range txt {
+		if ignoreWs && unicode.IsSpace(r) {
+			continue
+		}
+		toks = append(toks, diffToken{i, i + 1})
+	}
+	return toks
+}
+
+// wordTokens returns the words in the text as tokens: runs of letters,
+// digits and underscores, runs of whitespace (skipped if ignoreWs), and
+// each other rune on its own.
+func wordTokens(txt []rune, ignoreWs bool) []diffToken {
+	isWord := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	var toks []diffToken
+	for i := 0; i < len(txt); {
+		r := txt[i]
+		st := i
+		i++
+		switch {
+		case isWord(r):
+			for i < len(txt) && isWord(txt[i]) {
+				i++
+			}
+		case unicode.IsSpace(r):
+			for i < len(txt) && unicode.IsSpace(txt[i]) {
+				i++
+			}
+			if ignoreWs {
+				continue
+			}
+		}
+		toks = append(toks, diffToken{st, i})
+	}
+	return toks
+}
+
+// myersDiff returns the diffs between the a and b sequences of tokens,
+// using the Myers algorithm, with given maximum cost per region
+// (0 = default, -1 = no limit).
+func myersDiff(a, b []int, maxCost int) Diffs {
+	md := &myers{a: a, b: b, chgA: make([]bool, len(a)), chgB: make([]bool, len(b))}
+	n := len(a) + len(b) + 1
+	md.vf = make([]int, 2*n+1)
+	md.vb = make([]int, 2*n+1)
+	md.off = n
+	md.maxCost = maxCost
+	if maxCost == 0 {
+		md.maxCost = 256
+		for md.maxCost*md.maxCost < n {
+			md.maxCost *= 2
+		}
+	}
+	md.compare(0, len(a), 0, len(b))
+	return md.diffs()
+}
+
+// myers has the state for computing the Myers diff between two
+// sequences of tokens, marking the tokens that are changed in each.
+type myers struct {
+	a, b       []int
+	chgA, chgB []bool
+
+	// forward and backward furthest reaching x values per diagonal k,
+	// stored at index k + off
+	vf, vb []int
+	off    int
+
+	maxCost int
+}
+
+// compare marks the changed tokens between a[aLo:aHi] and b[bLo:bHi],
+// recursively splitting them at the middle of an optimal edit path.
+func (md *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && md.a[aLo] == md.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && md.a[aHi-1] == md.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for i := bLo; i < bHi; i++ {
+			md.chgB[i] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			md.chgA[i] = true
+		}
+	default:
+		x, y := md.split(aLo, aHi, bLo, bHi)
+		if (x == aLo && y == bLo) || (x == aHi && y == bHi) { // can't split: replace all
+			for i := aLo; i < aHi; i++ {
+				md.chgA[i] = true
+			}
+			for i := bLo; i < bHi; i++ {
+				md.chgB[i] = true
+			}
+			return
+		}
+		md.compare(aLo, x, bLo, y)
+		md.compare(x, aHi, y, bHi)
+	}
+}
+
+// split returns the point at which to split the given regions, which
+// must differ at their start and end, on the middle snake of an optimal
+// edit path, found by searching forward from the start and backward from
+// the end at the same time.  If the cost exceeds maxCost, the furthest
+// reaching point found so far is returned instead.  Diagonals that go
+// outside of the regions are excluded from the search, as in the
+// bisect function of diff-match-patch.
+func (md *myers) split(aLo, aHi, bLo, bHi int) (int, int) {
+	a, b := md.a, md.b
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	front := delta%2 != 0 // check for overlap on the forward pass
+	maxd := (n + m + 1) / 2
+	vf, vb, off := md.vf, md.vb, md.off
+	for k := -maxd - 1; k <= maxd+1; k++ {
+		vf[off+k] = -1
+		vb[off+k] = -1
+	}
+	vf[off+1] = 0
+	vb[off+1] = 0
+	valid := func(k int) bool { return k >= -maxd-1 && k <= maxd+1 }
+	var fst, fed, bst, bed int // excluded diagonals at the start and end of each range
+	for d := 0; d < maxd; d++ {
+		for k := -d + fst; k <= d-fed; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[aLo+x] == b[bLo+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			switch {
+			case x > n:
+				fed += 2
+			case y > m:
+				fst += 2
+			case front:
+				if bk := delta - k; valid(bk) && vb[off+bk] != -1 && x >= n-vb[off+bk] {
+					return aLo + x, bLo + y
+				}
+			}
+		}
+		for k := -d + bst; k <= d-bed; k += 2 {
+			var x int
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[aHi-1-x] == b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			switch {
+			case x > n:
+				bed += 2
+			case y > m:
+				bst += 2
+			case !front:
+				if fk := delta - k; valid(fk) && vf[off+fk] != -1 && vf[off+fk] >= n-x {
+					fx := vf[off+fk]
+					return aLo + fx, bLo + fx - fk
+				}
+			}
+		}
+		if md.maxCost > 0 && d >= md.maxCost {
+			return md.bestSplit(d, aLo, aHi, bLo, bHi)
+		}
+	}
+	return aLo, bLo // no overlap found: replace all
+}
+
+// bestSplit returns the furthest reaching point of the forward and
+// backward searches at cost d, for when the cost is too high to find
+// the optimal middle snake.
+func (md *myers) bestSplit(d, aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	best, bx, by := 0, aLo, bLo
+	for k := -d; k <= d; k += 2 {
+		if x := md.vf[md.off+k]; x >= 0 && x <= n && x-k >= 0 && x-k <= m && x+x-k > best {
+			best, bx, by = x+x-k, aLo+x, bLo+x-k
+		}
+		if x := md.vb[md.off+k]; x >= 0 && x <= n && x-k >= 0 && x-k <= m && x+x-k > best {
+			best, bx, by = x+x-k, aHi-x, bHi-(x-k)
+		}
+	}
+	return bx, by
+}
+
+// diffs returns the diffs from the changed tokens
+func (md *myers) diffs() Diffs {
+	var dif Diffs
+	na, nb := len(md.a), len(md.b)
+	i, j := 0, 0
+	for i < na || j < nb {
+		i1, j1 := i, j
+		if i < na && j < nb && !md.chgA[i] && !md.chgB[j] {
+			for i < na && j < nb && !md.chgA[i] && !md.chgB[j] {
+				i++
+				j++
+			}
+			dif = append(dif, difflib.OpCode{Tag: 'e', I1: i1, I2: i, J1: j1, J2: j})
+			continue
+		}
+		for i < na && md.chgA[i] {
+			i++
+		}
+		for j < nb && md.chgB[j] {
+			j++
+		}
+		tag := byte('r')
+		switch {
+		case i == i1:
+			tag = 'i'
+		case j == j1:
+			tag = 'd'
+		}
+		dif = append(dif, difflib.OpCode{Tag: tag, I1: i1, I2: i, J1: j1, J2: j})
+	}
+	return dif
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// lcsLen returns the length of the longest common subsequence of a and b
+func lcsLen(a, b []string) int {
+	prv := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prv[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prv[j+1])
+			}
+		}
+		prv, cur = cur, prv
+	}
+	return prv[len(b)]
+}
+
+// checkDiffs checks that the diffs turn a into b, with a minimal
+// number of changed lines if minimal is true.
+func checkDiffs(t *testing.T, a, b []string, dfs Diffs, minimal bool) {
+	t.Helper()
+	nb := dfs.ToPatch(b).Apply(a)
+	if !slices.Equal(nb, b) && !(len(nb) == 0 && len(b) == 0) {
+		t.Fatalf("patch of\n%v\ngave\n%v\nnot\n%v\ndiffs:\n%v", a, nb, b, dfs)
+	}
+	nchg := 0
+	for _, df := range dfs {
+		if df.Tag == 'e' {
+			if !slices.Equal(a[df.I1:df.I2], b[df.J1:df.J2]) {
+				t.Fatalf("equal op is not equal: %v", df)
+			}
+			continue
+		}
+		nchg += (df.I2 - df.I1) + (df.J2 - df.J1)
+	}
+	if want := len(a) + len(b) - 2*lcsLen(a, b); minimal && nchg != want {
+		t.Errorf("not minimal: %d changes, want %d, for\n%v\n%v\n%v", nchg, want, a, b, dfs)
+	}
+}
+
+func TestMyersDiffLines(t *testing.T) {
+	astr := []string{"foo", "bar", "baz"}
+	bstr := []string{"foo", "bar1", "asdf", "baz"}
+	dfs := MyersDiffLines(astr, bstr, nil)
+	if len(dfs) != 3 || dfs[1].Tag != 'r' {
+		t.Errorf("diffs: %v", dfs)
+	}
+	checkDiffs(t, astr, bstr, dfs, true)
+
+	rnd := rand.New(rand.NewSource(1))
+	rlines := func(n int) []string {
+		lns := make([]string, n)
+		for i := range lns {
+			lns[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return lns
+	}
+	for i := 0; i < 500; i++ {
+		a := rlines(rnd.Intn(30))
+		b := rlines(rnd.Intn(30))
+		checkDiffs(t, a, b, MyersDiffLines(a, b, &DiffOpts{MaxCost: -1}), true)
+		checkDiffs(t, a, b, MyersDiffLines(a, b, &DiffOpts{MaxCost: 2}), false)
+	}
+}
+
+func TestMyersDiffWhitespace(t *testing.T) {
+	astr := []string{"func f() {", "\treturn x + y", "}"}
+	bstr := []string{"func f()  {", "    return x+y ", "}"}
+	if dfs := MyersDiffLines(astr, bstr, &DiffOpts{IgnoreWhitespace: true}); len(dfs) != 1 || dfs[0].Tag != 'e' {
+		t.Errorf("expected all equal ignoring whitespace: %v", dfs)
+	}
+	if dfs := MyersDiffLines(astr, bstr, nil); len(dfs) != 2 {
+		t.Errorf("expected 2 diffs: %v", dfs)
+	}
+}
+
+func TestDiffMoves(t *testing.T) {
+	astr := []string{"a", "b", "c", "d", "e", "f"}
+	bstr := []string{"a", "e", "f", "b", "c", "d"}
+	dfs := MyersDiffLines(astr, bstr, nil)
+	mvs := DiffMoves(astr, bstr, dfs, nil)
+	if len(mvs) != 1 {
+		t.Fatalf("expected 1 move, got %v from %v", mvs, dfs)
+	}
+	mv := mvs[0]
+	if !slices.Equal(astr[mv.I1:mv.I2], bstr[mv.J1:mv.J2]) {
+		t.Errorf("move is not the same lines: %v", mv)
+	}
+}
+
+func TestDiffLineRegions(t *testing.T) {
+	a := []rune("the quick brown fox")
+	b := []rune("the slow brown cat!")
+	ar, br := DiffLineRegions(a, b, DiffLevelWord, false)
+	str := func(txt []rune, regs []Region) []string {
+		var s []string
+		for _, r := range regs {
+			s = append(s, string(txt[r.Start.Ch:r.End.Ch]))
+		}
+		return s
+	}
+	if got := str(a, ar); !slices.Equal(got, []string{"quick", "fox"}) {
+		t.Errorf("a word regions: %v", got)
+	}
+	if got := str(b, br); !slices.Equal(got, []string{"slow", "cat!"}) {
+		t.Errorf("b word regions: %v", got)
+	}
+	ar, br = DiffLineRegions([]rune("colour"), []rune("color"), DiffLevelRune, false)
+	if len(ar) != 1 || ar[0].Start.Ch != 4 || ar[0].End.Ch != 5 || len(br) != 0 {
+		t.Errorf("rune regions: %v %v", ar, br)
+	}
+	ar, br = DiffLineRegions([]rune("a  +b"), []rune("a+ b"), DiffLevelWord, true)
+	if len(ar) != 0 || len(br) != 0 {
+		t.Errorf("expected no regions ignoring whitespace: %v %v", ar, br)
+	}
+
+	astr := []string{"x", "the quick brown fox"}
+	bstr := []string{"y", "the slow brown cat!"}
+	ar, br = DiffRegions(astr, bstr, MyersDiffLines(astr, bstr, nil), DiffLevelWord, false)
+	if len(ar) != 3 || ar[2].Start.Ln != 1 || len(br) != 3 {
+		t.Errorf("diff regions: %v %v", ar, br)
+	}
+}
+
+func TestDiffLineEdits(t *testing.T) {
+	a := []rune("the quick brown fox jumps")
+	b := []rune("the brown cat jumps high")
+	lds := DiffLineEdits(a, b, DiffLevelWord, false)
+	type ldStr struct {
+		tag  byte
+		a, b string
+	}
+	var got []ldStr
+	for _, ld := range lds {
+		got = append(got, ldStr{ld.Tag, string(a[ld.A.Start.Ch:ld.A.End.Ch]), string(b[ld.B.Start.Ch:ld.B.End.Ch])})
+	}
+	want := []ldStr{{'d', "quick ", ""}, {'r', "fox", "cat"}, {'i', "", " high"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("DiffLineEdits = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkMyersDiffLines(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	const n = 100000
+	astr := make([]string, n)
+	for i := range astr {
+		astr[i] = fmt.Sprintf("line %d: %d", i, rnd.Intn(1000))
+	}
+	bstr := slices.Clone(astr)
+	for i := 0; i < 1000; i++ { // scattered edits
+		ln := rnd.Intn(len(bstr))
+		switch rnd.Intn(3) {
+		case 0:
+			bstr[ln] = "changed"
+		case 1:
+			bstr = slices.Delete(bstr, ln, ln+1)
+		default:
+			bstr = slices.Insert(bstr, ln, "inserted")
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MyersDiffLines(astr, bstr, nil)
+	}
+}
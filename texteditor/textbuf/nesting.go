@@ -0,0 +1,246 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+)
+
+// IsBracket returns true if given rune is one of the brackets that
+// are counted for nesting: ( ) [ ] { }, and whether it opens
+func IsBracket(r rune) (is, open bool) {
+	switch r {
+	case '(', '[', '{':
+		return true, true
+	case ')', ']', '}':
+		return true, false
+	}
+	return false, false
+}
+
+// InStrOrComment returns true if given char index is within a string
+// or comment token in given tags
+func InStrOrComment(tags lex.Line, ch int) bool {
+	for _, lx := range tags {
+		if ch >= lx.St && ch < lx.Ed && (lx.Tok.Tok.InCat(token.Comment) || lx.Tok.Tok.InSubCat(token.LitStr)) {
+			return true
+		}
+	}
+	return false
+}
+
+// LineBrackets calls given function, if non-nil, for each bracket in given
+// line, with its index in the line and its nesting depth, starting from
+// given depth at the start of the line, and returns the depth at the end
+// of the line.  An opening bracket has the depth outside of it, as does
+// the matching closing bracket.  Brackets within strings and comments,
+// according to given tags for the line (which can be nil), are skipped.
+func LineBrackets(ln []rune, tags lex.Line, depth int, fun func(ch, depth int)) int {
+	for ch, r := range ln {
+		is, open := IsBracket(r)
+		if !is || InStrOrComment(tags, ch) {
+			continue
+		}
+		if !open {
+			depth = max(depth-1, 0)
+		}
+		if fun != nil {
+			fun(ch, depth)
+		}
+		if open {
+			depth++
+		}
+	}
+	return depth
+}
+
+// LineIndentCols returns the indentation of given line in columns, with
+// tabs expanded to given size, or -1 if the line is blank
+func LineIndentCols(ln []rune, tabSz int) int {
+	tabSz = max(tabSz, 1)
+	cols := 0
+	for _, r := range ln {
+		switch r {
+		case ' ':
+			cols++
+		case '\t':
+			cols += tabSz - cols%tabSz
+		default:
+			return cols
+		}
+	}
+	return -1
+}
+
+// LineFunc returns the runes of given line of a text and its tags, for
+// processing lines that are materialized as they are needed, e.g., from
+// a Rope.
+type LineFunc func(ln int) ([]rune, lex.Line)
+
+// SliceLines returns a LineFunc for given lines and their tags,
+// which can be nil or shorter than the lines.
+func SliceLines(lns [][]rune, tags []lex.Line) LineFunc {
+	return func(ln int) ([]rune, lex.Line) {
+		if ln < len(tags) {
+			return lns[ln], tags[ln]
+		}
+		return lns[ln], nil
+	}
+}
+
+// Nesting records the bracket nesting depth at the start of each line of
+// a text, and the indentation of each line, which are used for coloring
+// brackets by depth and for drawing indent guides.  It is updated for the
+// lines changed by each edit, which only requires rescanning the lines
+// after them for as long as their starting depth changes.
+type Nesting struct {
+
+	// bracket nesting depth at the start of each line
+	Depths LineSeq[int]
+
+	// indentation of each line in columns, with tabs expanded,
+	// or -1 if the line is blank
+	Indents LineSeq[int]
+
+	// whether the nesting has been computed
+	valid bool
+}
+
+// IsValid returns true if the nesting has been computed for given number of lines
+func (ns *Nesting) IsValid(nlines int) bool {
+	return ns.valid && ns.Depths.Len() == nlines
+}
+
+// Reset resets the nesting so that it must be computed again with Init
+func (ns *Nesting) Reset() {
+	ns.Depths.Reset(0)
+	ns.Indents.Reset(0)
+	ns.valid = false
+}
+
+// Init computes the nesting for all of given lines, with given tags for
+// each line to skip strings and comments (see LineBrackets), which can be
+// nil or shorter than the lines, and given tab size for the indentation.
+func (ns *Nesting) Init(lns [][]rune, tags []lex.Line, tabSz int) {
+	n := len(lns)
+	dps := make([]int, n)
+	ins := make([]int, n)
+	line := SliceLines(lns, tags)
+	depth := 0
+	for ln := range lns {
+		lr, tg := line(ln)
+		dps[ln] = depth
+		ins[ln] = LineIndentCols(lr, tabSz)
+		depth = LineBrackets(lr, tg, depth, nil)
+	}
+	ns.Depths.SetAll(dps)
+	ns.Indents.SetAll(ins)
+	ns.valid = true
+}
+
+// LinesInserted inserts n lines before line st, which must then be
+// updated along with the other lines of the edit.  Does nothing if the
+// nesting has not been computed.
+func (ns *Nesting) LinesInserted(st, n int) {
+	if !ns.valid {
+		return
+	}
+	ns.Depths.Insert(st, make([]int, n)...)
+	ns.Indents.Insert(st, make([]int, n)...)
+}
+
+// LinesDeleted deletes the lines from st up to ed (exclusive).
+// Does nothing if the nesting has not been computed.
+func (ns *Nesting) LinesDeleted(st, ed int) {
+	if !ns.valid {
+		return
+	}
+	ns.Depths.Delete(st, ed)
+	ns.Indents.Delete(st, ed)
+}
+
+// Update updates the nesting for given range of lines that have been edited
+// (edLn is *inclusive*), after any LinesInserted or LinesDeleted for the
+// edit, and for the following lines whose starting depth changes as a result,
+// for a text of given number of lines, which are read with given function.
+// Does nothing if the nesting has not been computed for the same number of lines.
+func (ns *Nesting) Update(nlines int, line LineFunc, stLn, edLn, tabSz int) {
+	n := nlines
+	if !ns.IsValid(n) || n == 0 {
+		return
+	}
+	stLn = min(max(stLn, 0), n-1)
+	edLn = min(edLn, n-1)
+	depth := ns.Depths.At(stLn) // the edit does not change the depth at its start
+	for ln := stLn; ln < n; ln++ {
+		if ln > edLn && ns.Depths.At(ln) == depth {
+			break
+		}
+		ns.Depths.Set(ln, depth)
+		lr, tg := line(ln)
+		if ln <= edLn {
+			ns.Indents.Set(ln, LineIndentCols(lr, tabSz))
+		}
+		depth = LineBrackets(lr, tg, depth, nil)
+	}
+}
+
+// GuideIndent returns the indentation of given line for indent guides,
+// which for a blank line is the greater of that of the non-blank lines
+// before and after it, so that the guides continue through it.
+func (ns *Nesting) GuideIndent(ln int) int {
+	n := ns.Indents.Len()
+	if ln < 0 || ln >= n {
+		return 0
+	}
+	if ind := ns.Indents.At(ln); ind >= 0 {
+		return ind
+	}
+	ind := 0
+	for pl := ln - 1; pl >= 0; pl-- {
+		if pi := ns.Indents.At(pl); pi >= 0 {
+			ind = pi
+			break
+		}
+	}
+	for nl := ln + 1; nl < n; nl++ {
+		if ni := ns.Indents.At(nl); ni >= 0 {
+			ind = max(ind, ni)
+			break
+		}
+	}
+	return ind
+}
+
+// ActiveGuide returns the column of the indent guide of the innermost block
+// that contains given line, as determined by indentation, and the range of
+// lines (inclusive) that it spans.  If the next line is indented more, then
+// the block is the one the line starts.  col is -1 if there is no block.
+func (ns *Nesting) ActiveGuide(ln, tabSz int) (col, st, ed int) {
+	n := ns.Indents.Len()
+	if ln < 0 || ln >= n || tabSz <= 0 {
+		return -1, 0, 0
+	}
+	ind := ns.GuideIndent(ln)
+	if ln+1 < n && ns.GuideIndent(ln+1) > ind {
+		col = ind
+		st = ln + 1
+	} else {
+		if ind == 0 {
+			return -1, 0, 0
+		}
+		col = ((ind - 1) / tabSz) * tabSz
+		st = ln
+	}
+	for st > 0 && ns.GuideIndent(st-1) > col {
+		st--
+	}
+	ed = st
+	for ed+1 < n && ns.GuideIndent(ed+1) > col {
+		ed++
+	}
+	return col, st, ed
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"goki.dev/pi/v2/lex"
+	"goki.dev/pi/v2/token"
+)
+
+func nestingLines(s string) [][]rune {
+	var lns [][]rune
+	for _, l := range strings.Split(s, "\n") {
+		lns = append(lns, []rune(l))
+	}
+	return lns
+}
+
+// seqAll returns all of the values in given sequence
+func seqAll(ls *LineSeq[int]) []int {
+	return ls.Slice(0, ls.Len())
+}
+
+func TestNesting(t *testing.T) {
+	lns := nestingLines("func f() {\n\tif x {\n\t\ty(a[0])\n\n\t}\n}\nvar z")
+	ns := &Nesting{}
+	ns.Init(lns, nil, 4)
+	if want := []int{0, 1, 2, 2, 2, 1, 0}; !slices.Equal(seqAll(&ns.Depths), want) {
+		t.Errorf("Depths = %v, want %v", seqAll(&ns.Depths), want)
+	}
+	if want := []int{0, 4, 8, -1, 4, 0, 0}; !slices.Equal(seqAll(&ns.Indents), want) {
+		t.Errorf("Indents = %v, want %v", seqAll(&ns.Indents), want)
+	}
+	if gi := ns.GuideIndent(3); gi != 8 {
+		t.Errorf("GuideIndent(3) = %d, want 8", gi)
+	}
+	if col, st, ed := ns.ActiveGuide(1, 4); col != 4 || st != 2 || ed != 3 {
+		t.Errorf("ActiveGuide(1) = %d, %d, %d, want 4, 2, 3", col, st, ed)
+	}
+	if col, st, ed := ns.ActiveGuide(4, 4); col != 0 || st != 1 || ed != 4 {
+		t.Errorf("ActiveGuide(4) = %d, %d, %d, want 0, 1, 4", col, st, ed)
+	}
+
+	// insert a line opening a block after line 1, and edit line 1
+	lns = slices.Insert(lns, 2, []rune("\t\tfor {"))
+	lns[1] = []rune("\tif x[1] {")
+	ns.LinesInserted(2, 1)
+	ns.Update(len(lns), SliceLines(lns, nil), 1, 2, 4)
+	fresh := &Nesting{}
+	fresh.Init(lns, nil, 4)
+	if !slices.Equal(seqAll(&ns.Depths), seqAll(&fresh.Depths)) || !slices.Equal(seqAll(&ns.Indents), seqAll(&fresh.Indents)) {
+		t.Errorf("after insert: %v %v, want %v %v", seqAll(&ns.Depths), seqAll(&ns.Indents), seqAll(&fresh.Depths), seqAll(&fresh.Indents))
+	}
+	lns = slices.Delete(lns, 2, 3)
+	ns.LinesDeleted(2, 3)
+	ns.Update(len(lns), SliceLines(lns, nil), 1, 1, 4)
+	fresh.Init(lns, nil, 4)
+	if !slices.Equal(seqAll(&ns.Depths), seqAll(&fresh.Depths)) || !slices.Equal(seqAll(&ns.Indents), seqAll(&fresh.Indents)) {
+		t.Errorf("after delete: %v %v, want %v %v", seqAll(&ns.Depths), seqAll(&ns.Indents), seqAll(&fresh.Depths), seqAll(&fresh.Indents))
+	}
+
+	var got []int
+	LineBrackets([]rune("a(b[c]){"), nil, 1, func(ch, depth int) { got = append(got, depth) })
+	if want := []int{1, 2, 2, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("LineBrackets depths = %v, want %v", got, want)
+	}
+}
+
+func TestNestingRetag(t *testing.T) {
+	lns := nestingLines("a := \"{\"\nb(\nc)")
+	ns := &Nesting{}
+	ns.Init(lns, nil, 4) // before the tags are known, the bracket in the string counts
+	if want := []int{0, 1, 2}; !slices.Equal(seqAll(&ns.Depths), want) {
+		t.Errorf("Depths without tags = %v, want %v", seqAll(&ns.Depths), want)
+	}
+	tags := []lex.Line{{lex.NewLex(token.KeyToken{Tok: token.LitStrDouble}, 5, 8)}}
+	ns.Update(len(lns), SliceLines(lns, tags), 0, len(lns)-1, 4)
+	if want := []int{0, 0, 1}; !slices.Equal(seqAll(&ns.Depths), want) {
+		t.Errorf("Depths with tags = %v, want %v", seqAll(&ns.Depths), want)
+	}
+}
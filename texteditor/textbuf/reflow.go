@@ -0,0 +1,82 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParaPrefix returns the prefix of given line that is kept at the start
+// of each line of a paragraph when it is reflowed: its leading whitespace,
+// followed by given line comment string and the whitespace after that,
+// if the line starts with it (comLn can be empty).
+func ParaPrefix(ln []rune, comLn string) []rune {
+	i := 0
+	for i < len(ln) && unicode.IsSpace(ln[i]) {
+		i++
+	}
+	if comLn == "" || !strings.HasPrefix(string(ln[i:]), comLn) {
+		return ln[:i]
+	}
+	i += len([]rune(comLn))
+	for i < len(ln) && unicode.IsSpace(ln[i]) {
+		i++
+	}
+	return ln[:i]
+}
+
+// IsParaBreak returns true if given line separates paragraphs: it is blank,
+// apart from its ParaPrefix
+func IsParaBreak(ln []rune, comLn string) bool {
+	return len(ParaPrefix(ln, comLn)) == len(ln)
+}
+
+// ReflowPara hard wraps the words of given lines of a paragraph into
+// lines that are at most col columns wide, with tabs expanded to given
+// size, except where a single word is wider than that.  Each resulting
+// line starts with the ParaPrefix of the first line, and the ParaPrefix
+// of each of the other lines is removed before its words are reflowed.
+func ReflowPara(lns [][]rune, col, tabSz int, comLn string) [][]rune {
+	if len(lns) == 0 {
+		return nil
+	}
+	pfx := ParaPrefix(lns[0], comLn)
+	pw := 0
+	for _, r := range pfx {
+		if r == '\t' {
+			pw += tabSz - pw%tabSz
+		} else {
+			pw++
+		}
+	}
+	var words []string
+	for _, ln := range lns {
+		words = append(words, strings.Fields(string(ln[len(ParaPrefix(ln, comLn)):]))...)
+	}
+	if len(words) == 0 {
+		return [][]rune{pfx}
+	}
+	var out [][]rune
+	var cur []rune
+	cw := 0
+	for _, w := range words {
+		wl := len([]rune(w))
+		if cur != nil && pw+cw+1+wl > col {
+			out = append(out, cur)
+			cur = nil
+		}
+		if cur == nil {
+			cur = append([]rune{}, pfx...)
+			cw = 0
+		} else {
+			cur = append(cur, ' ')
+			cw++
+		}
+		cur = append(cur, []rune(w)...)
+		cw += wl
+	}
+	return append(out, cur)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReflowPara(t *testing.T) {
+	tests := []struct {
+		in    string
+		col   int
+		comLn string
+		want  string
+	}{
+		{"the quick brown\nfox jumps over the lazy dog", 16, "", "the quick brown\nfox jumps over\nthe lazy dog"},
+		{"\t// the quick brown fox\n\t//   jumps over", 17, "//", "\t// the quick\n\t// brown fox\n\t// jumps over"},
+		{"  a verylongwordthatdoesnotfit b", 10, "", "  a\n  verylongwordthatdoesnotfit\n  b"},
+		{"  ", 10, "", "  "},
+	}
+	for _, tt := range tests {
+		var lns [][]rune
+		for _, l := range strings.Split(tt.in, "\n") {
+			lns = append(lns, []rune(l))
+		}
+		var got []string
+		for _, l := range ReflowPara(lns, tt.col, 4, tt.comLn) {
+			got = append(got, string(l))
+		}
+		if g := strings.Join(got, "\n"); g != tt.want {
+			t.Errorf("ReflowPara(%q, %d) = %q, want %q", tt.in, tt.col, g, tt.want)
+		}
+	}
+	if !IsParaBreak([]rune("  // "), "//") || IsParaBreak([]rune("// x"), "//") {
+		t.Errorf("IsParaBreak wrong")
+	}
+}
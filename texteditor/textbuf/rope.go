@@ -0,0 +1,572 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// RopeChunkSize is the maximum number of runes stored in each
+// chunk of a Rope -- text is split into chunks of this size
+// when inserted, and small inserts go into existing chunks
+// up to this size.
+var RopeChunkSize = 1024
+
+// Rope is a text store for large texts, where inserting and deleting
+// text costs O(log n) in the size of the text, instead of the O(n)
+// cost of splicing the runes of per-line slices.  The text is
+// held as a balanced tree (a treap) of rune chunks, where each node
+// records the number of runes and line breaks below it, so that
+// positions in line, char (rune) coordinates are found in O(log n).
+// Lines are only materialized as [][]rune when requested, e.g., for the
+// lines in view.  The API mirrors the Buf InsertText, DeleteText and
+// Region methods, using the same lex.Pos coordinates and Edit records.
+// It is not safe for concurrent use.
+type Rope struct {
+	root *ropeNode
+
+	// random state for node priorities
+	seed uint32
+}
+
+// ropeNode is one node in the Rope treap, holding a chunk of text
+type ropeNode struct {
+	left, right *ropeNode
+
+	// heap priority for balancing
+	pri uint32
+
+	// the text chunk for this node
+	txt []rune
+
+	// rune offsets of the line breaks in txt
+	brks []int
+
+	// total number of runes in this subtree
+	size int
+
+	// total number of line breaks in this subtree
+	lines int
+}
+
+func (n *ropeNode) update() {
+	n.size = len(n.txt)
+	n.lines = len(n.brks)
+	if n.left != nil {
+		n.size += n.left.size
+		n.lines += n.left.lines
+	}
+	if n.right != nil {
+		n.size += n.right.size
+		n.lines += n.right.lines
+	}
+}
+
+func ropeSize(n *ropeNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func ropeLines(n *ropeNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.lines
+}
+
+// appendBreaks appends the offsets of the line breaks in txt,
+// plus given offset, to brks
+func appendBreaks(brks []int, txt []rune, off int) []int {
+	for i, r := range txt {
+		if r == '\n' {
+			brks = append(brks, off+i)
+		}
+	}
+	return brks
+}
+
+func countNL(txt []rune) int {
+	nl := 0
+	for _, r := range txt {
+		if r == '\n' {
+			nl++
+		}
+	}
+	return nl
+}
+
+// NewRope returns a new Rope with given text
+func NewRope(txt []byte) *Rope {
+	rp := &Rope{}
+	rp.SetText(txt)
+	return rp
+}
+
+// SetText replaces all the text in the rope with given text
+func (rp *Rope) SetText(txt []byte) {
+	rp.root = nil
+	rp.root = rp.build(txt)
+}
+
+// nextPri returns the next pseudo-random treap priority from
+// given random state (xorshift), used by Rope and LineSeq
+func nextPri(seed *uint32) uint32 {
+	if *seed == 0 {
+		*seed = 2463534242
+	}
+	x := *seed
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	*seed = x
+	return x
+}
+
+func (rp *Rope) newNode(txt []rune) *ropeNode {
+	n := &ropeNode{txt: txt, brks: appendBreaks(nil, txt, 0), pri: nextPri(&rp.seed)}
+	n.update()
+	return n
+}
+
+// build returns a tree for given text, split into chunks
+func (rp *Rope) build(txt []byte) *ropeNode {
+	var t *ropeNode
+	for len(txt) > 0 {
+		cs := make([]rune, 0, min(RopeChunkSize, len(txt)))
+		for len(txt) > 0 && len(cs) < RopeChunkSize {
+			r, sz := utf8.DecodeRune(txt)
+			cs = append(cs, r)
+			txt = txt[sz:]
+		}
+		t = ropeMerge(t, rp.newNode(cs))
+	}
+	return t
+}
+
+// ropeMerge merges two trees where all of a precedes b
+func ropeMerge(a, b *ropeNode) *ropeNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.pri > b.pri {
+		a.right = ropeMerge(a.right, b)
+		a.update()
+		return a
+	}
+	b.left = ropeMerge(a, b.left)
+	b.update()
+	return b
+}
+
+// split splits tree into the first k runes and the rest,
+// splitting a chunk if needed
+func (rp *Rope) split(n *ropeNode, k int) (*ropeNode, *ropeNode) {
+	if n == nil {
+		return nil, nil
+	}
+	ls := ropeSize(n.left)
+	switch {
+	case k <= ls:
+		a, b := rp.split(n.left, k)
+		n.left = b
+		n.update()
+		return a, n
+	case k >= ls+len(n.txt):
+		a, b := rp.split(n.right, k-ls-len(n.txt))
+		n.right = a
+		n.update()
+		return n, b
+	}
+	// split within this chunk
+	ck := k - ls
+	rn := rp.newNode(slices.Clone(n.txt[ck:]))
+	rn.pri = n.pri // keeps heap order with n.right
+	n.txt = n.txt[:ck:ck]
+	nb := sort.SearchInts(n.brks, ck)
+	n.brks = n.brks[:nb:nb]
+	rn.right = n.right
+	rn.update()
+	n.right = nil
+	n.update()
+	return n, rn
+}
+
+// appendRight appends given runes to the last chunk in the tree
+// if it has room, returning false if not.
+func appendRight(n *ropeNode, txt []rune) bool {
+	if n == nil {
+		return false
+	}
+	if n.right != nil {
+		if !appendRight(n.right, txt) {
+			return false
+		}
+		n.update()
+		return true
+	}
+	if len(n.txt)+len(txt) > RopeChunkSize {
+		return false
+	}
+	n.brks = appendBreaks(n.brks, txt, len(n.txt))
+	n.txt = append(n.txt, txt...)
+	n.update()
+	return true
+}
+
+// insertAt inserts given runes at index i in the chunk of this node,
+// shifting the offsets of the line breaks after it
+func (n *ropeNode) insertAt(i int, txt []rune) {
+	n.txt = slices.Insert(n.txt, i, txt...)
+	bi := sort.SearchInts(n.brks, i)
+	for j := bi; j < len(n.brks); j++ {
+		n.brks[j] += len(txt)
+	}
+	n.brks = slices.Insert(n.brks, bi, appendBreaks(nil, txt, i)...)
+}
+
+// deleteRange deletes the runes from index st up to ed in the chunk of
+// this node, shifting the offsets of the line breaks after them
+func (n *ropeNode) deleteRange(st, ed int) {
+	n.txt = slices.Delete(n.txt, st, ed)
+	bs := sort.SearchInts(n.brks, st)
+	be := sort.SearchInts(n.brks, ed)
+	for j := be; j < len(n.brks); j++ {
+		n.brks[j] -= ed - st
+	}
+	n.brks = slices.Delete(n.brks, bs, be)
+}
+
+// insertRunes inserts given runes at rune offset k within the chunk
+// that contains it, if the chunk has room for them, returning false if not.
+func insertRunes(n *ropeNode, k int, txt []rune) bool {
+	if n == nil {
+		return false
+	}
+	ls := ropeSize(n.left)
+	var ok bool
+	switch {
+	case k < ls:
+		ok = insertRunes(n.left, k, txt)
+	case k > ls+len(n.txt):
+		ok = insertRunes(n.right, k-ls-len(n.txt), txt)
+	case len(n.txt)+len(txt) <= RopeChunkSize:
+		n.insertAt(k-ls, txt)
+		ok = true
+	}
+	if ok {
+		n.update()
+	}
+	return ok
+}
+
+// deleteRunes deletes the runes from offset st up to ed within the
+// chunk that contains them, if they are in one chunk and do not empty it,
+// returning false if not.
+func deleteRunes(n *ropeNode, st, ed int) bool {
+	if n == nil {
+		return false
+	}
+	ls := ropeSize(n.left)
+	var ok bool
+	switch {
+	case ed <= ls:
+		ok = deleteRunes(n.left, st, ed)
+	case st >= ls+len(n.txt):
+		ok = deleteRunes(n.right, st-ls-len(n.txt), ed-ls-len(n.txt))
+	case st >= ls && ed <= ls+len(n.txt) && ed-st < len(n.txt):
+		n.deleteRange(st-ls, ed-ls)
+		ok = true
+	}
+	if ok {
+		n.update()
+	}
+	return ok
+}
+
+// Len returns the total number of runes in the text
+func (rp *Rope) Len() int {
+	return ropeSize(rp.root)
+}
+
+// NumLines returns the number of lines in the text, which is one more
+// than the number of line breaks (the last line may be empty).
+func (rp *Rope) NumLines() int {
+	return ropeLines(rp.root) + 1
+}
+
+// EndPos returns the position at the end of the text
+func (rp *Rope) EndPos() lex.Pos {
+	ln := ropeLines(rp.root)
+	return lex.Pos{Ln: ln, Ch: rp.LineLen(ln)}
+}
+
+// nlOffset returns the rune offset of the k'th (1-based) line break
+func nlOffset(n *ropeNode, k int) int {
+	off := 0
+	for n != nil {
+		ll := ropeLines(n.left)
+		if k <= ll {
+			n = n.left
+			continue
+		}
+		k -= ll
+		off += ropeSize(n.left)
+		if k <= len(n.brks) {
+			return off + n.brks[k-1]
+		}
+		k -= len(n.brks)
+		off += len(n.txt)
+		n = n.right
+	}
+	return off
+}
+
+// lineStart returns the rune offset of the start of given line,
+// which must be valid
+func (rp *Rope) lineStart(ln int) int {
+	if ln <= 0 {
+		return 0
+	}
+	return nlOffset(rp.root, ln) + 1
+}
+
+// lineEnd returns the rune offset of the end of given line,
+// just before its line break, which must be valid
+func (rp *Rope) lineEnd(ln int) int {
+	if ln >= ropeLines(rp.root) {
+		return rp.Len()
+	}
+	return nlOffset(rp.root, ln+1)
+}
+
+// lineRange returns the rune offsets of the start and end of given line,
+// which must be valid, finding both in one search of the tree when the
+// line is within one chunk
+func (rp *Rope) lineRange(ln int) (int, int) {
+	if ln >= ropeLines(rp.root) {
+		return rp.lineStart(ln), rp.Len()
+	}
+	n, k, off := rp.root, ln+1, 0 // find the line break ending ln
+	for {
+		ll := ropeLines(n.left)
+		if k <= ll {
+			n = n.left
+			continue
+		}
+		k -= ll
+		off += ropeSize(n.left)
+		if k <= len(n.brks) {
+			ed := off + n.brks[k-1]
+			switch {
+			case ln == 0:
+				return 0, ed
+			case k >= 2:
+				return off + n.brks[k-2] + 1, ed
+			}
+			return rp.lineStart(ln), ed
+		}
+		k -= len(n.brks)
+		off += len(n.txt)
+		n = n.right
+	}
+}
+
+// IsValidLine returns true if given line is in range
+func (rp *Rope) IsValidLine(ln int) bool {
+	return ln >= 0 && ln < rp.NumLines()
+}
+
+// LineLen returns the number of runes in given line
+func (rp *Rope) LineLen(ln int) int {
+	if !rp.IsValidLine(ln) {
+		return 0
+	}
+	st, ed := rp.lineRange(ln)
+	return ed - st
+}
+
+// validOffset returns given position made valid, and its rune offset
+func (rp *Rope) validOffset(pos lex.Pos) (lex.Pos, int) {
+	pos.Ln = min(max(pos.Ln, 0), rp.NumLines()-1)
+	st, ed := rp.lineRange(pos.Ln)
+	pos.Ch = min(max(pos.Ch, 0), ed-st)
+	return pos, st + pos.Ch
+}
+
+// ValidPos returns a position that is in a valid range
+func (rp *Rope) ValidPos(pos lex.Pos) lex.Pos {
+	pos, _ = rp.validOffset(pos)
+	return pos
+}
+
+// Offset returns the rune offset in the text for given position,
+// which is first made valid
+func (rp *Rope) Offset(pos lex.Pos) int {
+	_, off := rp.validOffset(pos)
+	return off
+}
+
+// collect appends the runes in [st, ed) of the subtree to out
+func collect(n *ropeNode, st, ed int, out []rune) []rune {
+	if n == nil || st >= ed {
+		return out
+	}
+	ls := ropeSize(n.left)
+	if st < ls {
+		out = collect(n.left, st, min(ed, ls), out)
+	}
+	cs, ce := max(st-ls, 0), min(ed-ls, len(n.txt))
+	if cs < ce {
+		out = append(out, n.txt[cs:ce]...)
+	}
+	rs := ls + len(n.txt)
+	if ed > rs {
+		out = collect(n.right, max(st-rs, 0), ed-rs, out)
+	}
+	return out
+}
+
+// Runes returns the runes between the given rune offsets
+func (rp *Rope) Runes(st, ed int) []rune {
+	st = max(st, 0)
+	ed = min(ed, rp.Len())
+	return collect(rp.root, st, ed, make([]rune, 0, max(ed-st, 0)))
+}
+
+// Line returns the runes of given line, materialized from the tree,
+// or nil if not a valid line.
+func (rp *Rope) Line(ln int) []rune {
+	if !rp.IsValidLine(ln) {
+		return nil
+	}
+	return rp.Runes(rp.lineRange(ln))
+}
+
+// Lines returns the runes of lines from st up to (not including) ed
+func (rp *Rope) Lines(st, ed int) [][]rune {
+	st = max(st, 0)
+	ed = min(ed, rp.NumLines())
+	if st >= ed {
+		return nil
+	}
+	return splitLines(rp.Runes(rp.lineStart(st), rp.lineEnd(ed-1)))
+}
+
+// Bytes returns the full text as bytes
+func (rp *Rope) Bytes() []byte {
+	var b bytes.Buffer
+	b.Grow(rp.Len())
+	var walk func(n *ropeNode)
+	walk = func(n *ropeNode) {
+		if n == nil {
+			return
+		}
+		walk(n.left)
+		for _, r := range n.txt {
+			b.WriteRune(r)
+		}
+		walk(n.right)
+	}
+	walk(rp.root)
+	return b.Bytes()
+}
+
+// Region returns an Edit representation of the text between start
+// and end positions, as in Buf.Region, or nil if not a valid region.
+func (rp *Rope) Region(st, ed lex.Pos) *Edit {
+	tbe, _, _ := rp.region(st, ed)
+	return tbe
+}
+
+// region returns the Region and the rune offsets of its start and end
+func (rp *Rope) region(st, ed lex.Pos) (*Edit, int, int) {
+	st, so := rp.validOffset(st)
+	ed, eo := rp.validOffset(ed)
+	if !st.IsLess(ed) {
+		return nil, so, eo
+	}
+	tbe := &Edit{Reg: NewRegionPos(st, ed)}
+	tbe.Text = splitLines(rp.Runes(so, eo))
+	return tbe, so, eo
+}
+
+// InsertText inserts given text at given position, returning the
+// Edit record for the insertion, as in Buf.InsertText.
+func (rp *Rope) InsertText(st lex.Pos, text []byte) *Edit {
+	if len(text) == 0 {
+		return nil
+	}
+	st, off := rp.validOffset(st)
+	rs := bytes.Runes(text)
+	if !insertRunes(rp.root, off, rs) {
+		a, b := rp.split(rp.root, off)
+		if !appendRight(a, rs) {
+			a = ropeMerge(a, rp.build(text))
+		}
+		rp.root = ropeMerge(a, b)
+	}
+	nl := countNL(rs)
+	ed := st
+	if nl == 0 {
+		ed.Ch += len(rs)
+	} else {
+		ed.Ln += nl
+		ed.Ch = len(rs) - 1 - lastNL(rs)
+	}
+	tbe := &Edit{Reg: NewRegionPos(st, ed)}
+	tbe.Text = splitLines(rs)
+	return tbe
+}
+
+// DeleteText deletes the text between given start and end positions,
+// returning the Edit record for the deletion, as in Buf.DeleteText.
+func (rp *Rope) DeleteText(st, ed lex.Pos) *Edit {
+	tbe, so, eo := rp.region(st, ed)
+	if tbe == nil {
+		return nil
+	}
+	tbe.Delete = true
+	if deleteRunes(rp.root, so, eo) {
+		return tbe
+	}
+	a, b := rp.split(rp.root, so)
+	_, c := rp.split(b, eo-so)
+	rp.root = ropeMerge(a, c)
+	return tbe
+}
+
+// lastNL returns the index of the last line break in given runes, or -1
+func lastNL(rs []rune) int {
+	for i := len(rs) - 1; i >= 0; i-- {
+		if rs[i] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitLines splits runes into lines at line breaks,
+// with each line capped so it can be appended to separately
+func splitLines(rs []rune) [][]rune {
+	var lns [][]rune
+	for {
+		i := slices.Index(rs, '\n')
+		if i < 0 {
+			return append(lns, rs)
+		}
+		lns = append(lns, rs[:i:i])
+		rs = rs[i+1:]
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"goki.dev/pi/v2/lex"
+)
+
+func TestRope(t *testing.T) {
+	defer func(sz int) { RopeChunkSize = sz }(RopeChunkSize)
+	RopeChunkSize = 16 // exercise splitting and editing within chunks
+	rnd := rand.New(rand.NewSource(1))
+	ref := []rune("hello world\nsecond line\n\nfourth é line")
+	rp := NewRope([]byte(string(ref)))
+	offs := func(rs []rune, pos lex.Pos) int {
+		ln := 0
+		for i, r := range rs {
+			if ln == pos.Ln {
+				return i + pos.Ch
+			}
+			if r == '\n' {
+				ln++
+			}
+		}
+		return len(rs)
+	}
+	for i := 0; i < 2000; i++ {
+		nl := rp.NumLines()
+		pos := lex.Pos{Ln: rnd.Intn(nl)}
+		pos.Ch = rnd.Intn(rp.LineLen(pos.Ln) + 1)
+		if rnd.Intn(3) > 0 {
+			ins := []rune(fmt.Sprintf("ins%d", i))
+			if rnd.Intn(4) == 0 {
+				ins = append(ins, '\n')
+				ins = append(ins, []rune("αβγ")...)
+			}
+			tbe := rp.InsertText(pos, []byte(string(ins)))
+			o := offs(ref, pos)
+			ref = append(ref[:o], append(ins, ref[o:]...)...)
+			if !bytes.Equal(tbe.ToBytes(), []byte(string(ins))) {
+				t.Fatalf("insert edit text: %q != %q", tbe.ToBytes(), string(ins))
+			}
+			if rp.Offset(tbe.Reg.End)-rp.Offset(tbe.Reg.Start) != len(ins) {
+				t.Fatalf("insert edit region: %v", tbe.Reg)
+			}
+		} else {
+			ed := rp.ValidPos(lex.Pos{Ln: pos.Ln + rnd.Intn(2), Ch: rnd.Intn(10)})
+			so, eo := offs(ref, pos), offs(ref, ed)
+			tbe := rp.DeleteText(pos, ed)
+			if so >= eo {
+				if tbe != nil {
+					t.Fatalf("delete of empty region: %v %v", pos, ed)
+				}
+				continue
+			}
+			if string(tbe.ToBytes()) != string(ref[so:eo]) {
+				t.Fatalf("delete edit text: %q != %q", tbe.ToBytes(), string(ref[so:eo]))
+			}
+			ref = append(ref[:so], ref[eo:]...)
+		}
+		if string(rp.Bytes()) != string(ref) {
+			t.Fatalf("step %d: text mismatch:\n%q\n%q", i, rp.Bytes(), string(ref))
+		}
+	}
+	lns := strings.Split(string(ref), "\n")
+	if rp.NumLines() != len(lns) {
+		t.Fatalf("NumLines %d != %d", rp.NumLines(), len(lns))
+	}
+	for ln, l := range lns {
+		if string(rp.Line(ln)) != l {
+			t.Errorf("Line %d: %q != %q", ln, string(rp.Line(ln)), l)
+		}
+	}
+	all := rp.Lines(0, rp.NumLines())
+	for ln, l := range lns {
+		if string(all[ln]) != l {
+			t.Errorf("Lines %d: %q != %q", ln, string(all[ln]), l)
+		}
+	}
+}
+
+// benchText returns a large text with given number of lines
+func benchText(nlines int) []byte {
+	var b bytes.Buffer
+	for i := 0; i < nlines; i++ {
+		fmt.Fprintf(&b, "%08d: the quick brown fox jumps over the lazy dog, again and again\n", i)
+	}
+	return b.Bytes()
+}
+
+const benchLines = 500000
+
+func BenchmarkRopeInsertDelete(b *testing.B) {
+	rp := NewRope(benchText(benchLines))
+	rnd := rand.New(rand.NewSource(1))
+	ins := []byte("inserted line one\ninserted line two\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ln := rnd.Intn(rp.NumLines() - 2)
+		tbe := rp.InsertText(lex.Pos{Ln: ln}, ins)
+		rp.DeleteText(tbe.Reg.Start, tbe.Reg.End)
+	}
+}
+
+func BenchmarkRopeLine(b *testing.B) {
+	rp := NewRope(benchText(benchLines))
+	rnd := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rp.Line(rnd.Intn(rp.NumLines()))
+	}
+}
@@ -283,3 +283,24 @@ func SearchByteLinesRegexp(src [][]byte, re *regexp.Regexp) (int, []Match) {
 	}
 	return cnt, matches
 }
+
+// ExpandRegexp returns the replacement for the match of the given regexp
+// that starts at given rune column ch within given line of bytes, with the
+// submatches referred to in the template as $1, ${name} etc expanded,
+// as in regexp.Expand.  Returns false if no match starts there.
+func ExpandRegexp(re *regexp.Regexp, line []byte, ch int, template string) (string, bool) {
+	bst := 0
+	for i := 0; i < ch && bst < len(line); i++ {
+		_, w := utf8.DecodeRune(line[bst:])
+		bst += w
+	}
+	for _, m := range re.FindAllSubmatchIndex(line, -1) {
+		if m[0] == bst {
+			return string(re.Expand(nil, []byte(template), line, m)), true
+		}
+		if m[0] > bst {
+			break
+		}
+	}
+	return "", false
+}
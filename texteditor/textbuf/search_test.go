@@ -0,0 +1,32 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandRegexp(t *testing.T) {
+	re := regexp.MustCompile(`(?P<key>\w+)=(\d+)`)
+	line := []byte("é a=1, bb=22")
+	tests := []struct {
+		ch       int
+		template string
+		want     string
+		ok       bool
+	}{
+		{2, "$2:$1", "1:a", true},
+		{7, "${key}[${2}]", "bb[22]", true},
+		{7, "$$1", "$1", true},
+		{3, "$1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ExpandRegexp(re, line, tt.ch, tt.template)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ExpandRegexp at %d with %q = %q, %v, want %q, %v", tt.ch, tt.template, got, ok, tt.want, tt.ok)
+		}
+	}
+}
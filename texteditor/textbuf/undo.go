@@ -25,7 +25,8 @@ type Undo struct {
 	// if true, saving and using undos is turned off (e.g., inactive buffers)
 	Off bool
 
-	// undo stack of edits
+	// undo stack of edits -- this is the current branch of the undo tree,
+	// from the original text through Pos and on through any edits that can be redone
 	Stack []*Edit
 
 	// undo stack of *undo* edits -- added to whenever an Undo is done -- for emacs-style undo
@@ -37,6 +38,17 @@ type Undo struct {
 	// group counter
 	Group int
 
+	// root of the undo tree, which represents the original state of the
+	// text: all edits descend from it, with a new branch started whenever
+	// a new edit is saved after an undo, instead of discarding the redo edits
+	Root *UndoNode `json:"-" xml:"-"`
+
+	// undo tree nodes for each edit in Stack
+	Nodes []*UndoNode `json:"-" xml:"-"`
+
+	// sequence counter for undo tree nodes, in order of creation
+	Seq int
+
 	// mutex protecting all updates
 	Mu sync.Mutex `json:"-" xml:"-"`
 }
@@ -48,12 +60,27 @@ func (un *Undo) NewGroup() {
 	un.Mu.Unlock()
 }
 
+// SetGroupFrom sets the Group of all of the edits from given stack
+// position up to the current Pos to the current Group, so that they are
+// all undone and redone together, regardless of the time between them
+// (e.g., edits made at multiple cursors).
+func (un *Undo) SetGroupFrom(pos int) {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	for i := max(pos, 0); i < un.Pos; i++ {
+		un.Stack[i].Group = un.Group
+	}
+}
+
 // Reset clears all undo records
 func (un *Undo) Reset() {
 	un.Pos = 0
 	un.Group = 0
 	un.Stack = nil
 	un.UndoStack = nil
+	un.Root = nil
+	un.Nodes = nil
+	un.Seq = 0
 }
 
 // Save saves given edit to undo stack, with current group marker unless timer interval
@@ -64,11 +91,13 @@ func (un *Undo) Save(tbe *Edit) {
 	}
 	un.Mu.Lock()
 	defer un.Mu.Unlock()
+	un.InitTree()
 	if un.Pos < len(un.Stack) {
 		if UndoTrace {
-			fmt.Printf("Undo: resetting to pos: %v len was: %v\n", un.Pos, len(un.Stack))
+			fmt.Printf("Undo: new branch at pos: %v len was: %v\n", un.Pos, len(un.Stack))
 		}
 		un.Stack = un.Stack[:un.Pos]
+		un.Nodes = un.Nodes[:un.Pos]
 	}
 	if len(un.Stack) > 0 {
 		since := tbe.Reg.SinceMSec(&un.Stack[len(un.Stack)-1].Reg)
@@ -84,6 +113,7 @@ func (un *Undo) Save(tbe *Edit) {
 		fmt.Printf("Undo: save to pos: %v: group: %v\n->\t%v\n", un.Pos, un.Group, string(tbe.ToBytes()))
 	}
 	un.Stack = append(un.Stack, tbe)
+	un.AddNode(tbe)
 	un.Pos = len(un.Stack)
 }
 
@@ -144,6 +174,10 @@ func (un *Undo) UndoStackSave() {
 	if len(un.UndoStack) == 0 {
 		return
 	}
+	un.InitTree()
+	for _, tbe := range un.UndoStack {
+		un.AddNode(tbe)
+	}
 	un.Stack = append(un.Stack, un.UndoStack...)
 	un.Pos = len(un.Stack)
 	un.UndoStack = nil
@@ -0,0 +1,195 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// UndoFileVersion is the current version of the undo file format,
+// which is incremented whenever the format changes incompatibly.
+const UndoFileVersion = 2
+
+// ErrUndoHashMismatch is returned by Undo.OpenFile when the undo file
+// was saved for a different version of the text than the current one.
+var ErrUndoHashMismatch = errors.New("textbuf.Undo: undo file does not match current text")
+
+// UndoFile is the on-disk representation of the Undo state,
+// saved along with a hash of the text it applies to, so that it is
+// only restored when the text has not been changed by anything else.
+type UndoFile struct {
+
+	// format version -- see UndoFileVersion
+	Version int
+
+	// hash of the text that the undo stack ends in -- see UndoHash
+	Hash string
+
+	// undo position in stack
+	Pos int
+
+	// group counter
+	Group int
+
+	// all of the edits in the undo tree, in order of creation
+	Edits []*Edit
+
+	// index into Edits of the parent of each edit, -1 for the root of the tree
+	Parents []int
+
+	// indexes into Edits of the current branch of the tree, i.e., the undo Stack
+	Path []int
+
+	// undo stack of *undo* edits, for emacs-style undo
+	UndoStack []*Edit
+}
+
+// UndoHash returns the hash of given text, as used in the UndoFile
+func UndoHash(txt []byte) string {
+	sum := sha256.Sum256(txt)
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveFile saves the undo tree, current branch and group counters to
+// given file, along with the given hash of the current text (see UndoHash).
+func (un *Undo) SaveFile(filename string, hash string) error {
+	var nds []*UndoNode
+	un.Walk(func(nd *UndoNode) bool {
+		nds = append(nds, nd)
+		return true
+	})
+	slices.SortFunc(nds, func(a, b *UndoNode) int { return a.Seq - b.Seq })
+	un.Mu.Lock()
+	uf := &UndoFile{Version: UndoFileVersion, Hash: hash, Pos: un.Pos, Group: un.Group, UndoStack: un.UndoStack}
+	idxs := make(map[*UndoNode]int, len(nds))
+	uf.Edits = make([]*Edit, len(nds))
+	uf.Parents = make([]int, len(nds))
+	for i, nd := range nds {
+		idxs[nd] = i
+		uf.Edits[i] = nd.Edit
+		if pi, ok := idxs[nd.Parent]; ok {
+			uf.Parents[i] = pi
+		} else {
+			uf.Parents[i] = -1
+		}
+	}
+	uf.Path = make([]int, len(un.Nodes))
+	for i, nd := range un.Nodes {
+		uf.Path[i] = idxs[nd]
+	}
+	b, err := json.Marshal(uf)
+	un.Mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0644)
+}
+
+// OpenFile restores the undo tree and group counters from given file,
+// only if the hash saved there matches the given hash of the current text
+// (see UndoHash) -- otherwise ErrUndoHashMismatch is returned and the
+// existing undo state is not changed.
+func (un *Undo) OpenFile(filename string, hash string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	uf := &UndoFile{}
+	err = json.Unmarshal(b, uf)
+	if err != nil {
+		return err
+	}
+	if uf.Version != UndoFileVersion {
+		return fmt.Errorf("textbuf.Undo: undo file version %d is not the current version %d", uf.Version, UndoFileVersion)
+	}
+	if uf.Hash != hash {
+		return ErrUndoHashMismatch
+	}
+	if len(uf.Parents) != len(uf.Edits) {
+		return fmt.Errorf("textbuf.Undo: undo file has %d parents for %d edits", len(uf.Parents), len(uf.Edits))
+	}
+	root := &UndoNode{}
+	nds := make([]*UndoNode, len(uf.Edits))
+	for i, tbe := range uf.Edits {
+		if tbe == nil {
+			return fmt.Errorf("textbuf.Undo: undo file edit %d is missing", i)
+		}
+		par := root
+		if pi := uf.Parents[i]; pi >= 0 {
+			if pi >= i {
+				return fmt.Errorf("textbuf.Undo: undo file edit %d has invalid parent %d", i, pi)
+			}
+			par = nds[pi]
+		}
+		nd := &UndoNode{Edit: tbe, Parent: par, Seq: i}
+		par.Children = append(par.Children, nd)
+		nds[i] = nd
+	}
+	path := make([]*UndoNode, len(uf.Path))
+	for i, ni := range uf.Path {
+		if ni < 0 || ni >= len(nds) || (i > 0 && nds[ni].Parent != path[i-1]) || (i == 0 && nds[ni].Parent != root) {
+			return fmt.Errorf("textbuf.Undo: undo file has an invalid path")
+		}
+		path[i] = nds[ni]
+	}
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.Root = root
+	un.Seq = len(nds)
+	un.setPath(path)
+	un.UndoStack = uf.UndoStack
+	un.Pos = min(max(uf.Pos, 0), len(un.Stack))
+	un.Group = uf.Group
+	return nil
+}
+
+// editJSON is the stable on-disk encoding of an Edit, with the Text
+// as strings instead of rune slices.
+type editJSON struct {
+	Reg    Region
+	Text   []string `json:",omitempty"`
+	Group  int
+	Delete bool `json:",omitempty"`
+	Rect   bool `json:",omitempty"`
+}
+
+// MarshalJSON encodes the edit with the text lines as strings
+func (te *Edit) MarshalJSON() ([]byte, error) {
+	ej := editJSON{Reg: te.Reg, Group: te.Group, Delete: te.Delete, Rect: te.Rect}
+	if len(te.Text) > 0 {
+		ej.Text = make([]string, len(te.Text))
+		for i, r := range te.Text {
+			ej.Text[i] = string(r)
+		}
+	}
+	return json.Marshal(ej)
+}
+
+// UnmarshalJSON decodes the edit as encoded by MarshalJSON
+func (te *Edit) UnmarshalJSON(b []byte) error {
+	ej := editJSON{}
+	err := json.Unmarshal(b, &ej)
+	if err != nil {
+		return err
+	}
+	te.Reg = ej.Reg
+	te.Group = ej.Group
+	te.Delete = ej.Delete
+	te.Rect = ej.Rect
+	te.Text = nil
+	if len(ej.Text) > 0 {
+		te.Text = make([][]rune, len(ej.Text))
+		for i, s := range ej.Text {
+			te.Text[i] = []rune(s)
+		}
+	}
+	return nil
+}
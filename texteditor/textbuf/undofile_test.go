@@ -0,0 +1,104 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// testEdit returns an edit of given text at given start position and time
+func testEdit(stLn, stCh int, text string, del bool, tm time.Time) *Edit {
+	lns := strings.Split(text, "\n")
+	tbe := &Edit{Delete: del, Text: make([][]rune, len(lns))}
+	for i, ln := range lns {
+		tbe.Text[i] = []rune(ln)
+	}
+	ed := lex.Pos{Ln: stLn + len(lns) - 1, Ch: len(tbe.Text[len(lns)-1])}
+	if len(lns) == 1 {
+		ed.Ch += stCh
+	}
+	tbe.Reg = Region{Start: lex.Pos{Ln: stLn, Ch: stCh}, End: ed}
+	tbe.Reg.Time.SetTime(tm)
+	return tbe
+}
+
+func TestEditJSON(t *testing.T) {
+	tm := time.Unix(1700000000, 123456789)
+	rect := testEdit(1, 2, "ab\ncd", true, tm)
+	rect.Rect = true
+	rect.Group = 3
+	edits := []*Edit{
+		testEdit(0, 0, "héllo, 世界\n\tsecond \"line\"\n", false, tm),
+		testEdit(4, 1, "x", true, tm),
+		rect,
+	}
+	for _, tbe := range edits {
+		b, err := json.Marshal(tbe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ne := &Edit{}
+		if err := json.Unmarshal(b, ne); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ne, tbe) {
+			t.Errorf("edit round trip through %s:\n got: %+v\nwant: %+v", b, ne, tbe)
+		}
+	}
+}
+
+func TestUndoFile(t *testing.T) {
+	tm := time.Now()
+	un := &Undo{}
+	un.Save(testEdit(0, 0, "one", false, tm))
+	un.Save(testEdit(0, 3, " two", false, tm.Add(time.Second))) // new group
+	un.Save(testEdit(0, 0, "o", true, tm.Add(2*time.Second)))
+	un.UndoPop()
+	un.SaveUndo(testEdit(0, 0, "o", false, tm.Add(3*time.Second)))
+
+	fn := filepath.Join(t.TempDir(), ".file.txt.undo")
+	hash := UndoHash([]byte("ne two\n"))
+	if err := un.SaveFile(fn, hash); err != nil {
+		t.Fatal(err)
+	}
+	nu := &Undo{}
+	if err := nu.OpenFile(fn, hash); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Pos != un.Pos || nu.Group != un.Group {
+		t.Errorf("Pos, Group = %d, %d, want %d, %d", nu.Pos, nu.Group, un.Pos, un.Group)
+	}
+	if !reflect.DeepEqual(nu.Stack, un.Stack) {
+		t.Errorf("Stack = %v, want %v", nu.Stack, un.Stack)
+	}
+	if !reflect.DeepEqual(nu.UndoStack, un.UndoStack) {
+		t.Errorf("UndoStack = %v, want %v", nu.UndoStack, un.UndoStack)
+	}
+	if tbe := nu.RedoNext(); tbe == nil || string(tbe.ToBytes()) != "o" || !tbe.Delete {
+		t.Errorf("RedoNext after OpenFile = %v", tbe)
+	}
+
+	// a file saved for other text is rejected, without changing the state
+	other := &Undo{}
+	other.Save(testEdit(0, 0, "other", false, tm))
+	err := other.OpenFile(fn, UndoHash([]byte("one two\n")))
+	if !errors.Is(err, ErrUndoHashMismatch) {
+		t.Errorf("OpenFile with other hash: error = %v, want ErrUndoHashMismatch", err)
+	}
+	if other.Pos != 1 || len(other.Stack) != 1 || string(other.Stack[0].ToBytes()) != "other" {
+		t.Errorf("OpenFile with other hash changed the undo state: %v", other.Stack)
+	}
+	if err := other.OpenFile(filepath.Join(t.TempDir(), "none"), hash); err == nil {
+		t.Errorf("OpenFile of missing file did not return an error")
+	}
+}
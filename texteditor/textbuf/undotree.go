@@ -0,0 +1,265 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"time"
+)
+
+// UndoNode is a node in the undo tree, recording one Edit along with
+// the node it was made after (its parent) and all of the edits that
+// have been made after it (its children), each of which starts a
+// different branch of the editing history.
+type UndoNode struct {
+
+	// the edit for this node -- nil for the Root
+	Edit *Edit
+
+	// the node that this edit was made after -- nil for the Root
+	Parent *UndoNode
+
+	// the edits made after this one, in order of creation -- the last one is the most recent
+	Children []*UndoNode
+
+	// sequence number in order of creation across the whole tree
+	Seq int
+}
+
+// Time returns the time stamp of the edit, which is zero for the Root
+func (nd *UndoNode) Time() time.Time {
+	if nd.Edit == nil {
+		return time.Time{}
+	}
+	return nd.Edit.Reg.Time.Time()
+}
+
+// Depth returns the number of edits from the Root to this node,
+// which is the undo Pos of the state after this edit.
+func (nd *UndoNode) Depth() int {
+	d := 0
+	for pn := nd; pn.Parent != nil; pn = pn.Parent {
+		d++
+	}
+	return d
+}
+
+// Path returns the nodes from just below the Root down to this node
+func (nd *UndoNode) Path() []*UndoNode {
+	path := make([]*UndoNode, nd.Depth())
+	i := len(path) - 1
+	for pn := nd; pn.Parent != nil; pn = pn.Parent {
+		path[i] = pn
+		i--
+	}
+	return path
+}
+
+// Tip returns the end of the most recent branch below this node,
+// following the last child at each level.
+func (nd *UndoNode) Tip() *UndoNode {
+	tn := nd
+	for len(tn.Children) > 0 {
+		tn = tn.Children[len(tn.Children)-1]
+	}
+	return tn
+}
+
+// IsGroupEnd returns true if this is the last edit in its undo Group,
+// i.e., none of its children are in the same group.
+// The state of the text after such an edit is a distinct state
+// that can be reached by Undo and Redo.
+func (nd *UndoNode) IsGroupEnd() bool {
+	if nd.Edit == nil {
+		return true
+	}
+	for _, cn := range nd.Children {
+		if cn.Edit.Group == nd.Edit.Group {
+			return false
+		}
+	}
+	return true
+}
+
+// InitTree makes sure the undo tree exists and that the Nodes are in
+// sync with the Stack -- if not, the tree is rebuilt as a single branch
+// from the Stack. Must be called under the mutex.
+func (un *Undo) InitTree() {
+	if un.Root != nil && len(un.Nodes) == len(un.Stack) {
+		return
+	}
+	un.Root = &UndoNode{}
+	un.Nodes = nil
+	un.Seq = 0
+	stk := un.Stack
+	un.Stack = nil
+	for _, tbe := range stk {
+		un.Stack = append(un.Stack, tbe)
+		un.AddNode(tbe)
+	}
+}
+
+// AddNode adds a tree node for given edit, as a child of the last
+// node in Nodes, and appends it to Nodes.  The edit must be appended to
+// the Stack separately. Must be called under the mutex.
+func (un *Undo) AddNode(tbe *Edit) *UndoNode {
+	par := un.Root
+	if len(un.Nodes) > 0 {
+		par = un.Nodes[len(un.Nodes)-1]
+	}
+	nd := &UndoNode{Edit: tbe, Parent: par, Seq: un.Seq}
+	un.Seq++
+	par.Children = append(par.Children, nd)
+	un.Nodes = append(un.Nodes, nd)
+	return nd
+}
+
+// CurNode returns the tree node for the current state of the text,
+// which is the Root if all edits have been undone.
+func (un *Undo) CurNode() *UndoNode {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	return un.curNode()
+}
+
+func (un *Undo) curNode() *UndoNode {
+	if un.Pos == 0 {
+		return un.Root
+	}
+	return un.Nodes[un.Pos-1]
+}
+
+// Branches returns the alternative edits that could be redone from
+// the current state, one for each branch, in order of creation.
+// The current redo branch is the one in the Stack at Pos.
+func (un *Undo) Branches() []*UndoNode {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	return slices.Clone(un.curNode().Children)
+}
+
+// SelectBranch selects the given branch (an index into Branches)
+// as the one that will be followed by subsequent Redo actions.
+// The branch is followed to the end of its most recent sub-branch.
+// Returns false if the index is out of range.
+func (un *Undo) SelectBranch(idx int) bool {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	cn := un.curNode()
+	if idx < 0 || idx >= len(cn.Children) {
+		return false
+	}
+	un.setPath(cn.Children[idx].Tip().Path())
+	return true
+}
+
+// setPath sets the Nodes and Stack to given path through the tree
+func (un *Undo) setPath(path []*UndoNode) {
+	un.Nodes = path
+	un.Stack = make([]*Edit, len(path))
+	for i, nd := range path {
+		un.Stack[i] = nd.Edit
+	}
+}
+
+// Walk calls given function on all nodes in the tree below the Root,
+// depth-first, stopping if the function returns false.
+func (un *Undo) Walk(fun func(nd *UndoNode) bool) {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	un.walk(un.Root, fun)
+}
+
+func (un *Undo) walk(nd *UndoNode, fun func(nd *UndoNode) bool) bool {
+	for _, cn := range nd.Children {
+		if !fun(cn) || !un.walk(cn, fun) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tips returns the end nodes of all of the branches in the tree,
+// in order of creation.
+func (un *Undo) Tips() []*UndoNode {
+	var tips []*UndoNode
+	un.Walk(func(nd *UndoNode) bool {
+		if len(nd.Children) == 0 {
+			tips = append(tips, nd)
+		}
+		return true
+	})
+	slices.SortFunc(tips, func(a, b *UndoNode) int { return a.Seq - b.Seq })
+	return tips
+}
+
+// States returns the nodes for all of the distinct states of the text
+// recorded in the tree (i.e., at the end of each undo Group), in order of
+// creation, starting with the Root for the original text.
+// This is the timeline used for time travel, e.g., with a slider.
+func (un *Undo) States() []*UndoNode {
+	un.Mu.Lock()
+	un.InitTree()
+	sts := []*UndoNode{un.Root}
+	un.Mu.Unlock()
+	un.Walk(func(nd *UndoNode) bool {
+		if nd.IsGroupEnd() {
+			sts = append(sts, nd)
+		}
+		return true
+	})
+	slices.SortFunc(sts[1:], func(a, b *UndoNode) int { return a.Seq - b.Seq })
+	return sts
+}
+
+// StateAtTime returns the most recently created state (see States)
+// with a time stamp at or before the given time, which is the state of
+// the text at that time, unless undo was used in the meantime.
+// Returns the Root if there is no such state.
+func (un *Undo) StateAtTime(t time.Time) *UndoNode {
+	sts := un.States()
+	for i := len(sts) - 1; i > 0; i-- {
+		if !sts[i].Time().After(t) {
+			return sts[i]
+		}
+	}
+	return sts[0]
+}
+
+// CommonPos returns the number of edits that the current state of the
+// text shares with the state after given node, i.e., the undo Pos of
+// their closest common ancestor.  To move to the given node, edits must
+// be undone back to this position, then SetPathTo called, and edits
+// redone to the Depth of the node.
+func (un *Undo) CommonPos(nd *UndoNode) int {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	path := nd.Path()
+	n := min(un.Pos, len(path))
+	for i := 0; i < n; i++ {
+		if un.Nodes[i] != path[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// SetPathTo makes the branch through given node the current one in the
+// Stack, continuing on through its most recent sub-branch, so that
+// Redo will move toward (and beyond) the node.  The current Pos must be at
+// or before the CommonPos with the node.  Returns the Depth of the node,
+// which is the Pos to redo up to, to reach it.
+func (un *Undo) SetPathTo(nd *UndoNode) int {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.InitTree()
+	un.setPath(nd.Tip().Path())
+	return nd.Depth()
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testBranches returns an Undo with a branch made by an edit after an undo:
+// a, then b, which is undone, then c, each in its own group, one second apart,
+// along with the edits and the time of the first one.
+func testBranches() (un *Undo, a, b, c *Edit, t0 time.Time) {
+	t0 = time.Now()
+	a = testEdit(0, 0, "a", false, t0)
+	b = testEdit(0, 1, "b", false, t0.Add(time.Second))
+	c = testEdit(0, 1, "c", false, t0.Add(2*time.Second))
+	un = &Undo{}
+	un.Save(a)
+	un.Save(b)
+	un.UndoPop()
+	un.Save(c)
+	return
+}
+
+func TestUndoTree(t *testing.T) {
+	un, a, b, c, _ := testBranches()
+	if len(un.Root.Children) != 1 || un.Root.Children[0].Edit != a {
+		t.Fatalf("root children: %v", un.Root.Children)
+	}
+	na := un.Root.Children[0]
+	if len(na.Children) != 2 || na.Children[0].Edit != b || na.Children[1].Edit != c {
+		t.Fatalf("branches after a: %v", na.Children)
+	}
+	nb, nc := na.Children[0], na.Children[1]
+	if !reflect.DeepEqual(un.Stack, []*Edit{a, c}) || un.Pos != 2 || un.CurNode() != nc {
+		t.Errorf("Stack, Pos = %v, %d, want [a c], 2", un.Stack, un.Pos)
+	}
+	if tips := un.Tips(); !reflect.DeepEqual(tips, []*UndoNode{nb, nc}) {
+		t.Errorf("Tips = %v, want [b c]", tips)
+	}
+	if nc.Depth() != 2 || !reflect.DeepEqual(nc.Path(), []*UndoNode{na, nc}) || na.Tip() != nc {
+		t.Errorf("Depth, Path, Tip of c: %d, %v, %v", nc.Depth(), nc.Path(), na.Tip())
+	}
+
+	// switch to the other branch
+	if tbe := un.UndoPop(); tbe != c {
+		t.Fatalf("UndoPop = %v, want c", tbe)
+	}
+	if brs := un.Branches(); !reflect.DeepEqual(brs, []*UndoNode{nb, nc}) {
+		t.Errorf("Branches = %v, want [b c]", brs)
+	}
+	if un.SelectBranch(2) || un.SelectBranch(-1) {
+		t.Errorf("SelectBranch out of range returned true")
+	}
+	if !un.SelectBranch(0) || !reflect.DeepEqual(un.Stack, []*Edit{a, b}) || un.Pos != 1 {
+		t.Errorf("after SelectBranch(0): Stack, Pos = %v, %d, want [a b], 1", un.Stack, un.Pos)
+	}
+	if tbe := un.RedoNext(); tbe != b || un.CurNode() != nb {
+		t.Errorf("RedoNext = %v, want b", tbe)
+	}
+
+	// navigate from b to c, as Buf.UndoToNode does
+	cp := un.CommonPos(nc)
+	if cp != 1 {
+		t.Errorf("CommonPos(c) = %d, want 1", cp)
+	}
+	for un.Pos > cp {
+		un.UndoPop()
+	}
+	if tpos := un.SetPathTo(nc); tpos != 2 {
+		t.Errorf("SetPathTo(c) = %d, want 2", tpos)
+	}
+	if tbe := un.RedoNext(); tbe != c || un.CurNode() != nc {
+		t.Errorf("RedoNext after SetPathTo(c) = %v, want c", tbe)
+	}
+	if cp := un.CommonPos(un.Root); cp != 0 {
+		t.Errorf("CommonPos(Root) = %d, want 0", cp)
+	}
+}
+
+func TestUndoStates(t *testing.T) {
+	un, _, _, _, t0 := testBranches()
+	na := un.Root.Children[0]
+	nb, nc := na.Children[0], na.Children[1]
+	sts := un.States()
+	if !reflect.DeepEqual(sts, []*UndoNode{un.Root, na, nb, nc}) {
+		t.Errorf("States = %v, want [root a b c]", sts)
+	}
+	tests := []struct {
+		at   time.Duration
+		want *UndoNode
+	}{
+		{-time.Second, un.Root},
+		{0, na},
+		{1500 * time.Millisecond, nb},
+		{time.Hour, nc},
+	}
+	for _, tt := range tests {
+		if st := un.StateAtTime(t0.Add(tt.at)); st != tt.want {
+			t.Errorf("StateAtTime(%v) = %v, want %v", tt.at, st, tt.want)
+		}
+	}
+
+	// edits in the same group are one state
+	gu := &Undo{}
+	gu.Save(testEdit(0, 0, "x", false, t0))
+	gu.Save(testEdit(0, 1, "y", false, t0.Add(10*time.Millisecond)))
+	if sts := gu.States(); len(sts) != 2 || sts[1] != gu.Root.Children[0].Children[0] {
+		t.Errorf("States of one group = %v, want [root y]", sts)
+	}
+}
+
+func TestUndoFileTree(t *testing.T) {
+	un, a, b, c, _ := testBranches()
+	un.UndoPop()
+	fn := filepath.Join(t.TempDir(), ".file.txt.undo")
+	hash := UndoHash([]byte("a\n"))
+	if err := un.SaveFile(fn, hash); err != nil {
+		t.Fatal(err)
+	}
+	nu := &Undo{}
+	if err := nu.OpenFile(fn, hash); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Pos != 1 || !reflect.DeepEqual(nu.Stack, []*Edit{a, c}) {
+		t.Errorf("Pos, Stack = %d, %v, want 1, [a c]", nu.Pos, nu.Stack)
+	}
+	brs := nu.Branches()
+	if len(brs) != 2 || !reflect.DeepEqual(brs[0].Edit, b) || !reflect.DeepEqual(brs[1].Edit, c) {
+		t.Errorf("Branches = %v, want [b c]", brs)
+	}
+	if nu.Seq != 3 || brs[1].Seq != 2 {
+		t.Errorf("Seq = %d, c Seq = %d, want 3, 2", nu.Seq, brs[1].Seq)
+	}
+}
+
+func TestUndoFileVersion(t *testing.T) {
+	a := testEdit(0, 0, "a", false, time.Now())
+	hash := UndoHash([]byte("a\n"))
+	fn := filepath.Join(t.TempDir(), ".file.txt.undo")
+	un := &Undo{}
+	un.Save(a)
+	for _, vers := range []int{0, 1, UndoFileVersion + 1} {
+		b, err := json.Marshal(map[string]any{"Version": vers, "Hash": hash, "Pos": 1, "Edits": []*Edit{a}, "Parents": []int{-1}, "Path": []int{0}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := un.OpenFile(fn, hash); err == nil {
+			t.Errorf("undo file version %d was opened", vers)
+		}
+		if len(un.Stack) != 1 || un.Stack[0] != a {
+			t.Errorf("undo file version %d changed the undo stack: %v", vers, un.Stack)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the patch for one file in a unified diff, as parsed by
+// ParseUnified, which can be applied to the lines of the file with Apply.
+type FilePatch struct {
+
+	// name of the original file, without any a/ prefix from git --
+	// empty if the file is new
+	OldName string
+
+	// name of the new file, without any b/ prefix from git --
+	// empty if the file is deleted
+	NewName string
+
+	// true if this is from a git diff, with a diff --git line
+	Git bool
+
+	// extended header lines from git, such as the index, mode and
+	// rename lines, not including the diff --git line itself
+	Header []string
+
+	// true if the diff is for a binary file, which has no hunks
+	Binary bool
+
+	// the hunks of changes to the file
+	Hunks []*PatchHunk
+}
+
+// IsNew returns true if the patch creates a new file
+func (fp *FilePatch) IsNew() bool {
+	return fp.OldName == ""
+}
+
+// IsDelete returns true if the patch deletes the file
+func (fp *FilePatch) IsDelete() bool {
+	return fp.NewName == ""
+}
+
+// PatchHunk is one hunk of changes in a unified diff, starting with
+// an @@ -OldSt,OldN +NewSt,NewN @@ line.
+type PatchHunk struct {
+
+	// starting line in the original file (1-based, as in the diff header)
+	OldSt int
+
+	// number of lines in the original file
+	OldN int
+
+	// starting line in the new file (1-based, as in the diff header)
+	NewSt int
+
+	// number of lines in the new file
+	NewN int
+
+	// section heading after the @@ line numbers, typically the function
+	Section string
+
+	// the lines of the hunk, each starting with ' ' (context),
+	// '-' (deleted) or '+' (added)
+	Lines []string
+
+	// true if the original file has no newline at the end of the last line
+	OldNoNewline bool
+
+	// true if the new file has no newline at the end of the last line
+	NewNoNewline bool
+}
+
+// OldLines returns the lines that the hunk expects in the original file
+// (context and deleted lines), without the prefix
+func (ph *PatchHunk) OldLines() []string {
+	return ph.linesWith('-')
+}
+
+// NewLines returns the lines that the hunk produces in the new file
+// (context and added lines), without the prefix
+func (ph *PatchHunk) NewLines() []string {
+	return ph.linesWith('+')
+}
+
+func (ph *PatchHunk) linesWith(op byte) []string {
+	var lns []string
+	for _, ln := range ph.Lines {
+		if len(ln) == 0 { // empty context line with trailing space removed
+			lns = append(lns, "")
+			continue
+		}
+		if ln[0] == ' ' || ln[0] == op {
+			lns = append(lns, ln[1:])
+		}
+	}
+	return lns
+}
+
+// contextLines returns the number of context lines at the start
+// and end of the hunk.
+func (ph *PatchHunk) contextLines() (lead, trail int) {
+	isCtx := func(ln string) bool { return len(ln) == 0 || ln[0] == ' ' }
+	for lead < len(ph.Lines) && isCtx(ph.Lines[lead]) {
+		lead++
+	}
+	for trail < len(ph.Lines)-lead && isCtx(ph.Lines[len(ph.Lines)-1-trail]) {
+		trail++
+	}
+	return
+}
+
+// String returns the hunk in unified diff format
+func (ph *PatchHunk) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@", ph.OldSt, ph.OldN, ph.NewSt, ph.NewN)
+	if ph.Section != "" {
+		b.WriteString(" " + ph.Section)
+	}
+	b.WriteByte('\n')
+	for _, ln := range ph.Lines {
+		b.WriteString(ln)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ParseUnified parses a unified diff, such as a .diff or .patch file or
+// the output of git diff or git format-patch, which can contain the diffs
+// for multiple files.  Any text before, between or after the file diffs
+// (e.g., a commit message) is ignored.
+func ParseUnified(data []byte) ([]*FilePatch, error) {
+	var fps []*FilePatch
+	var fp *FilePatch
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, strings.TrimSuffix(sc.Text(), "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	inHeader := false // in git extended header
+	for li := 0; li < len(lines); li++ {
+		ln := lines[li]
+		switch {
+		case strings.HasPrefix(ln, "diff --git "):
+			fp = &FilePatch{Git: true}
+			fps = append(fps, fp)
+			fp.OldName, fp.NewName = gitDiffNames(ln[len("diff --git "):])
+			inHeader = true
+		case strings.HasPrefix(ln, "--- ") && li+1 < len(lines) && strings.HasPrefix(lines[li+1], "+++ "):
+			if fp == nil || !inHeader {
+				fp = &FilePatch{}
+				fps = append(fps, fp)
+			}
+			fp.OldName = patchFileName(ln[4:], fp.Git, "a/")
+			fp.NewName = patchFileName(lines[li+1][4:], fp.Git, "b/")
+			li++
+			inHeader = false
+		case strings.HasPrefix(ln, "@@ "):
+			if fp == nil {
+				return nil, fmt.Errorf("textbuf.ParseUnified: line %d: hunk without file header", li+1)
+			}
+			inHeader = false
+			ph, err := parseHunkHeader(ln)
+			if err != nil {
+				return nil, fmt.Errorf("textbuf.ParseUnified: line %d: %w", li+1, err)
+			}
+			oldN, newN := 0, 0
+			for li+1 < len(lines) && (oldN < ph.OldN || newN < ph.NewN || strings.HasPrefix(lines[li+1], `\`)) {
+				li++
+				hl := lines[li]
+				switch {
+				case strings.HasPrefix(hl, `\`): // \ No newline at end of file
+					if len(ph.Lines) > 0 && ph.Lines[len(ph.Lines)-1] != "" {
+						switch ph.Lines[len(ph.Lines)-1][0] {
+						case '-':
+							ph.OldNoNewline = true
+						case '+':
+							ph.NewNoNewline = true
+						default:
+							ph.OldNoNewline = true
+							ph.NewNoNewline = true
+						}
+					}
+					continue
+				case hl == "" || hl[0] == ' ':
+					oldN++
+					newN++
+				case hl[0] == '-':
+					oldN++
+				case hl[0] == '+':
+					newN++
+				default:
+					return nil, fmt.Errorf("textbuf.ParseUnified: line %d: invalid hunk line: %q", li+1, hl)
+				}
+				ph.Lines = append(ph.Lines, hl)
+			}
+			if oldN != ph.OldN || newN != ph.NewN {
+				return nil, fmt.Errorf("textbuf.ParseUnified: line %d: hunk is truncated: %s", li+1, ln)
+			}
+			fp.Hunks = append(fp.Hunks, ph)
+		case inHeader:
+			fp.Header = append(fp.Header, ln)
+			switch {
+			case strings.HasPrefix(ln, "new file mode"):
+				fp.OldName = ""
+			case strings.HasPrefix(ln, "deleted file mode"):
+				fp.NewName = ""
+			case strings.HasPrefix(ln, "rename from "):
+				fp.OldName = ln[len("rename from "):]
+			case strings.HasPrefix(ln, "rename to "):
+				fp.NewName = ln[len("rename to "):]
+			case strings.HasPrefix(ln, "Binary files "), ln == "GIT binary patch":
+				fp.Binary = true
+			}
+		}
+	}
+	return fps, nil
+}
+
+// parseHunkHeader parses a @@ -l,s +l,s @@ section hunk header line
+func parseHunkHeader(ln string) (*PatchHunk, error) {
+	flds := strings.SplitN(ln, " ", 5)
+	if len(flds) < 4 || flds[3] != "@@" || !strings.HasPrefix(flds[1], "-") || !strings.HasPrefix(flds[2], "+") {
+		return nil, fmt.Errorf("invalid hunk header: %q", ln)
+	}
+	rng := func(s string) (int, int, error) {
+		st, n, hasN := strings.Cut(s, ",")
+		sti, err := strconv.Atoi(st)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header: %q", ln)
+		}
+		if !hasN {
+			return sti, 1, nil
+		}
+		ni, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header: %q", ln)
+		}
+		return sti, ni, nil
+	}
+	ph := &PatchHunk{}
+	var err error
+	if ph.OldSt, ph.OldN, err = rng(flds[1][1:]); err != nil {
+		return nil, err
+	}
+	if ph.NewSt, ph.NewN, err = rng(flds[2][1:]); err != nil {
+		return nil, err
+	}
+	if len(flds) == 5 {
+		ph.Section = flds[4]
+	}
+	return ph, nil
+}
+
+// gitDiffNames returns the file names from the a/old b/new part of
+// a diff --git line, without the prefixes
+func gitDiffNames(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) { // quoted names are not split reliably
+		s = strings.ReplaceAll(s, `"`, "")
+	}
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	old, nw, _ := strings.Cut(s, " ")
+	return old, nw
+}
+
+// patchFileName returns the file name from a --- or +++ line,
+// without any timestamp, and without the git prefix if git,
+// or empty if it is /dev/null
+func patchFileName(s string, git bool, prefix string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.Trim(s, `"`)
+	if s == "/dev/null" {
+		return ""
+	}
+	if git {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return s
+}
+
+// HunkResult is the result of placing a hunk of a FilePatch in the lines
+// of a file, as returned by FilePatch.Place.
+type HunkResult struct {
+
+	// the hunk
+	Hunk *PatchHunk
+
+	// true if the hunk was placed and can be applied, false if rejected
+	Applied bool
+
+	// range of lines in the original file that are replaced by the New lines
+	// (0-based, with OldEd exclusive)
+	OldSt, OldEd int
+
+	// the lines that replace the OldSt - OldEd lines
+	New []string
+
+	// number of lines that the hunk was offset from where it was expected,
+	// given the offsets of the hunks before it
+	Offset int
+
+	// number of context lines at the start and end of the hunk that
+	// were ignored to place it
+	Fuzz int
+}
+
+// Place finds where each of the hunks of the patch applies in given lines
+// of the file, as in the patch command: each hunk is first looked for where
+// it is expected, given its line numbers and the offset of the previous hunk,
+// and then at increasing offsets from there, after the previous hunk.
+// If not found, up to maxFuzz lines of context at the start and end of the
+// hunk are ignored in turn.  Hunks that are not found are rejected.
+func (fp *FilePatch) Place(lines []string, maxFuzz int) []HunkResult {
+	res := make([]HunkResult, len(fp.Hunks))
+	offset := 0
+	minLn := 0
+	for i, ph := range fp.Hunks {
+		hr := &res[i]
+		hr.Hunk = ph
+		old := ph.OldLines()
+		nw := ph.NewLines()
+		lead, trail := ph.contextLines()
+		for fuzz := 0; fuzz <= maxFuzz; fuzz++ {
+			fl, ft := min(fuzz, lead), min(fuzz, trail)
+			if fuzz > 0 && fl+ft == 0 {
+				break
+			}
+			o := old[fl : len(old)-ft]
+			exp := ph.OldSt - 1 + fl + offset
+			if ph.OldN == 0 { // pure insert is after the given line
+				exp++
+			}
+			pos := findLines(lines, o, exp, minLn)
+			if pos < 0 {
+				continue
+			}
+			hr.Applied = true
+			hr.OldSt, hr.OldEd = pos, pos+len(o)
+			hr.New = nw[fl : len(nw)-ft]
+			hr.Offset = pos - exp
+			hr.Fuzz = max(fl, ft)
+			offset += hr.Offset
+			minLn = hr.OldEd
+			break
+		}
+	}
+	return res
+}
+
+// findLines returns the line at which the given lines occur in the lines
+// of text, looking first at the expected line and then at increasing
+// distances from it, not before minLn, or -1 if not found.
+func findLines(lines, find []string, exp, minLn int) int {
+	match := func(ln int) bool {
+		return ln >= minLn && ln+len(find) <= len(lines) && slices.Equal(lines[ln:ln+len(find)], find)
+	}
+	exp = min(max(exp, minLn), len(lines))
+	for d := 0; exp-d >= minLn || exp+d <= len(lines); d++ {
+		if match(exp - d) {
+			return exp - d
+		}
+		if d > 0 && match(exp+d) {
+			return exp + d
+		}
+	}
+	return -1
+}
+
+// Apply applies the patch to given lines of the file, where eol is true if
+// the file ends with a newline, allowing up to maxFuzz lines of context to
+// be ignored in placing each hunk (2 is typical), and returns the new lines,
+// whether the new file ends with a newline, and the results for each hunk,
+// including whether it was applied or rejected.  An applied hunk that is
+// marked with "\ No newline at end of file" (see PatchHunk.OldNoNewline and
+// NewNoNewline) is at the end of the file, so it determines whether the
+// new file ends with a newline.
+func (fp *FilePatch) Apply(lines []string, eol bool, maxFuzz int) ([]string, bool, []HunkResult) {
+	res := fp.Place(lines, maxFuzz)
+	out := make([]string, 0, len(lines))
+	ln := 0
+	for _, hr := range res {
+		if !hr.Applied {
+			continue
+		}
+		out = append(out, lines[ln:hr.OldSt]...)
+		out = append(out, hr.New...)
+		ln = hr.OldEd
+		if ph := hr.Hunk; ph.OldNoNewline || ph.NewNoNewline {
+			eol = !ph.NewNoNewline
+		}
+	}
+	out = append(out, lines[ln:]...)
+	return out, eol, res
+}
+
+// Rejects returns the hunks that were not applied in given results,
+// as returned by Place or Apply.
+func Rejects(res []HunkResult) []*PatchHunk {
+	var rej []*PatchHunk
+	for _, hr := range res {
+		if !hr.Applied {
+			rej = append(rej, hr.Hunk)
+		}
+	}
+	return rej
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testPatch = `commit message that is ignored
+
+diff --git a/one.txt b/one.txt
+index 1234567..89abcde 100644
+--- a/one.txt
++++ b/one.txt
+@@ -2,3 +2,3 @@ section
+ b
+-c
++C
+ d
+@@ -7,2 +7,3 @@
+ g
++g2
+ h
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++new
++file
+\ No newline at end of file
+diff --git a/old.go b/renamed.go
+similarity index 100%
+rename from old.go
+rename to renamed.go
+`
+
+func TestParseUnified(t *testing.T) {
+	fps, err := ParseUnified([]byte(testPatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fps) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(fps))
+	}
+	one := fps[0]
+	if one.OldName != "one.txt" || one.NewName != "one.txt" || len(one.Hunks) != 2 {
+		t.Errorf("one.txt: %+v", one)
+	}
+	if h := one.Hunks[0]; h.OldSt != 2 || h.OldN != 3 || h.NewSt != 2 || h.NewN != 3 || h.Section != "section" {
+		t.Errorf("hunk header: %+v", h)
+	}
+	nw := fps[1]
+	if !nw.IsNew() || nw.NewName != "new.txt" || !nw.Hunks[0].NewNoNewline {
+		t.Errorf("new.txt: %+v %+v", nw, nw.Hunks[0])
+	}
+	if rn := fps[2]; rn.OldName != "old.go" || rn.NewName != "renamed.go" || len(rn.Hunks) != 0 {
+		t.Errorf("rename: %+v", rn)
+	}
+
+	if _, err := ParseUnified([]byte("--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n")); err == nil {
+		t.Errorf("expected error for truncated hunk")
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	fps, err := ParseUnified([]byte(testPatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := fps[0]
+	lines := strings.Split("a b c d e f g h", " ")
+	out, eol, res := one.Apply(lines, true, 2)
+	if want := strings.Split("a b C d e f g g2 h", " "); !slices.Equal(out, want) {
+		t.Errorf("apply:\n%v\nwant:\n%v", out, want)
+	}
+	if len(Rejects(res)) != 0 || !eol {
+		t.Errorf("unexpected rejects, or newline at end removed")
+	}
+
+	// offset by 2 lines
+	lines = strings.Split("x y a b c d e f g h", " ")
+	out, _, res = one.Apply(lines, true, 0)
+	if want := strings.Split("x y a b C d e f g g2 h", " "); !slices.Equal(out, want) {
+		t.Errorf("apply with offset:\n%v\nwant:\n%v", out, want)
+	}
+	if res[0].Offset != 2 || res[1].Offset != 0 {
+		t.Errorf("offsets: %d %d", res[0].Offset, res[1].Offset)
+	}
+
+	// context changed: needs fuzz
+	lines = strings.Split("a B c d e f g H", " ")
+	out, _, res = one.Apply(lines, true, 0)
+	if rej := Rejects(res); len(rej) != 2 || !slices.Equal(out, lines) {
+		t.Errorf("expected 2 rejects without fuzz: %v", out)
+	}
+	out, _, res = one.Apply(lines, true, 1)
+	if want := strings.Split("a B C d e f g g2 H", " "); !slices.Equal(out, want) {
+		t.Errorf("apply with fuzz:\n%v\nwant:\n%v", out, want)
+	}
+	if res[0].Fuzz != 1 || res[1].Fuzz != 1 {
+		t.Errorf("fuzz: %d %d", res[0].Fuzz, res[1].Fuzz)
+	}
+
+	out, eol, _ = fps[1].Apply(nil, true, 0)
+	if !slices.Equal(out, []string{"new", "file"}) || eol {
+		t.Errorf("new file: %v, newline at end: %v", out, eol)
+	}
+}
+
+func TestApplyPatchNoNewline(t *testing.T) {
+	// adds a newline at the end of the last line
+	addNl := `--- a/f.txt
++++ b/f.txt
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`
+	// changes the last line, which still has no newline
+	chgNoNl := `--- a/f.txt
++++ b/f.txt
+@@ -2,2 +2,2 @@
+ b
+-c
+\ No newline at end of file
++C
+\ No newline at end of file
+`
+	// adds a line after the last line, which had no newline
+	appendNl := `--- a/f.txt
++++ b/f.txt
+@@ -1 +1,2 @@
+-a
+\ No newline at end of file
++a
++b
+`
+	for _, tt := range []struct {
+		patch, in, want string
+	}{
+		{addNl, "a\nb", "a\nb\n"},
+		{chgNoNl, "a\nb\nc", "a\nb\nC"},
+		{appendNl, "a", "a\nb\n"},
+	} {
+		fps, err := ParseUnified([]byte(tt.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := strings.TrimSuffix(tt.in, "\n")
+		out, eol, res := fps[0].Apply(strings.Split(in, "\n"), strings.HasSuffix(tt.in, "\n"), 0)
+		got := strings.Join(out, "\n")
+		if eol {
+			got += "\n"
+		}
+		if got != tt.want || len(Rejects(res)) != 0 {
+			t.Errorf("apply to %q = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
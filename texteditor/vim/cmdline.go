@@ -0,0 +1,285 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+)
+
+// startCmdLine starts the command line, with given initial text,
+// which starts with : / or ?
+func (v *Vim) startCmdLine(txt string) {
+	v.CmdLine = txt
+	v.setMode(CmdLine)
+}
+
+// cmdLineKey handles given key in CmdLine mode
+func (v *Vim) cmdLineKey(ed *texteditor.Editor, k string) {
+	switch k {
+	case "Escape", "Control+C", "Control+[":
+		v.setMode(Normal)
+	case "ReturnEnter":
+		cl := v.CmdLine
+		v.Message = ""
+		v.setMode(Normal)
+		updt := ed.UpdateStart()
+		v.runCmdLine(ed, cl)
+		v.clampCursor(ed)
+		ed.UpdateEndRender(updt)
+	case "DeleteBackspace":
+		rs := []rune(v.CmdLine)
+		if len(rs) <= 1 {
+			v.setMode(Normal)
+			return
+		}
+		v.CmdLine = string(rs[:len(rs)-1])
+	case "Control+U":
+		v.CmdLine = v.CmdLine[:1]
+	default:
+		if utf8.RuneCountInString(k) == 1 {
+			v.CmdLine += k
+		}
+	}
+	v.changed()
+}
+
+// runCmdLine runs given command line, which starts with : / or ?
+func (v *Vim) runCmdLine(ed *texteditor.Editor, cl string) {
+	if cl == "" {
+		return
+	}
+	switch cl[0] {
+	case ':':
+		v.ex(ed, cl[1:])
+	case '/', '?':
+		if pat := cl[1:]; pat != "" {
+			v.search = pat
+		}
+		v.searchBack = cl[0] == '?'
+		v.move(ed, &command{cmd: "n"})
+	}
+	v.changed()
+}
+
+// searchMotion returns the position of the next match of the last search
+// pattern for n and N, or of the word under the cursor for * and #.
+// All of the matches are highlighted.
+func (v *Vim) searchMotion(ed *texteditor.Editor, c *command) (lex.Pos, motionKind, bool) {
+	pos := ed.CursorPos
+	if c.cmd == "*" || c.cmd == "#" {
+		st, en, _, ok := textObject(ed.Buf, pos, "iw")
+		ln := line(ed, pos.Ln)
+		if !ok || st.Ch >= len(ln) || charClass(ln[st.Ch], false) != 1 {
+			v.Message = "no word under the cursor"
+			return pos, exclusive, false
+		}
+		v.search = `\b` + regexp.QuoteMeta(string(ln[st.Ch:en.Ch])) + `\b`
+		v.searchBack = c.cmd == "#"
+		pos = st
+	}
+	if v.search == "" {
+		v.Message = "no previous search pattern"
+		return pos, exclusive, false
+	}
+	re, err := regexp.Compile(v.search)
+	if err != nil {
+		v.Message = err.Error()
+		return pos, exclusive, false
+	}
+	_, ms := ed.Buf.SearchRegexp(re)
+	if len(ms) == 0 {
+		v.Message = "pattern not found: " + v.search
+		ed.ClearHighlights()
+		return pos, exclusive, false
+	}
+	ed.Highlights = make([]textbuf.Region, len(ms))
+	for i, m := range ms {
+		ed.Highlights[i] = m.Reg
+	}
+	ed.SetNeedsRender()
+
+	back := v.searchBack != (c.cmd == "N")
+	mi := -1
+	for i := 0; i < c.n(); i++ {
+		mi = -1
+		if back {
+			for j := len(ms) - 1; j >= 0; j-- {
+				if ms[j].Reg.Start.IsLess(pos) {
+					mi = j
+					break
+				}
+			}
+			if mi < 0 {
+				mi = len(ms) - 1
+				v.Message = "search hit TOP, continuing at BOTTOM"
+			}
+		} else {
+			for j, m := range ms {
+				if pos.IsLess(m.Reg.Start) {
+					mi = j
+					break
+				}
+			}
+			if mi < 0 {
+				mi = 0
+				v.Message = "search hit BOTTOM, continuing at TOP"
+			}
+		}
+		pos = ms[mi].Reg.Start
+	}
+	return pos, exclusive, true
+}
+
+// ex runs given : command line (without the :)
+func (v *Vim) ex(ed *texteditor.Editor, s string) {
+	ec, err := parseEx(s, ed.CursorPos.Ln, ed.Buf.NumLines(), v.markLine)
+	if err != nil {
+		v.Message = "E: " + err.Error()
+		return
+	}
+	if ec.name != "" && ec.name != "write" && ec.name != "nohlsearch" && ec.name != "yank" && ed.IsReadOnly() {
+		v.Message = "the text is read only"
+		return
+	}
+	reg := rune(0)
+	if ec.arg != "" {
+		reg, _ = utf8.DecodeRuneInString(ec.arg)
+	}
+	st, en := lineRange(ed, ec.start, ec.end)
+	upos := undoStart(ed)
+	defer undoEnd(ed, upos)
+	switch ec.name {
+	case "":
+		if ec.hasRange {
+			ed.SavePosHistory(ed.CursorPos)
+			ed.SetCursorShow(lex.Pos{Ln: ec.end, Ch: firstNonBlank(line(ed, ec.end))})
+			ed.SavePosHistory(ed.CursorPos)
+		}
+	case "substitute", "&":
+		arg := ec.arg
+		if ec.name == "&" || arg == "" {
+			arg = v.lastSubst
+		}
+		v.substitute(ed, ec.start, ec.end, arg)
+	case "write", "wq", "xit":
+		v.write(ed, ec)
+	case "quit":
+		v.Message = "E: :quit is not supported -- close the editor instead"
+	case "delete":
+		v.delete(ed, reg, st, en, true)
+		ln := min(ec.start, ed.Buf.NumLines()-1)
+		ed.SetCursorShow(lex.Pos{Ln: ln, Ch: firstNonBlank(line(ed, ln))})
+	case "yank":
+		v.yank(ed, reg, st, en, true, true)
+	case "join":
+		join(ed, ec.start, max(ec.end, min(ec.start+1, ed.Buf.NumLines()-1)), !ec.bang)
+	case "nohlsearch":
+		ed.ClearHighlights()
+	case "undo":
+		ed.Undo()
+	case "redo":
+		ed.Redo()
+	case ">", "<":
+		n := 1 + strings.Count(ec.arg, ec.name)
+		v.shift(ed, ec.start, ec.end, n, ec.name == ">")
+	}
+}
+
+// write saves the text for :w, :wq and :x, to the file given as the
+// argument if any
+func (v *Vim) write(ed *texteditor.Editor, ec exCmd) {
+	tb := ed.Buf
+	var err error
+	switch {
+	case ec.arg != "":
+		err = tb.SaveFile(gi.FileName(ec.arg))
+	case ec.name == "xit" && !tb.IsChanged():
+		return
+	default:
+		err = tb.Save()
+	}
+	if err != nil {
+		v.Message = "E: " + err.Error()
+		return
+	}
+	v.Message = fmt.Sprintf("%q written", string(tb.Filename))
+}
+
+// substitute does a :s substitution with given argument, on the lines
+// from st to last (inclusive).  The flags are g for all matches on a line
+// (else just the first), i to ignore case, and n to just count the matches.
+func (v *Vim) substitute(ed *texteditor.Editor, st, last int, arg string) {
+	pat, repl, flags, err := parseSubst(arg)
+	if err != nil {
+		v.Message = "E: " + err.Error()
+		return
+	}
+	v.lastSubst = arg
+	if strings.Contains(flags, "i") {
+		pat = "(?i)" + pat
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		v.Message = "E: " + err.Error()
+		return
+	}
+	lns := make([][]byte, last-st+1)
+	for i := range lns {
+		lns[i] = ed.Buf.BytesLine(st + i)
+	}
+	_, ms := textbuf.SearchByteLinesRegexp(lns, re)
+	// the matches are in the same order as the submatch indexes of each line
+	type subst struct {
+		m   textbuf.Match
+		idx []int
+	}
+	var todo []subst
+	var si [][]int
+	k, pln, nlines := 0, -1, 0
+	for _, m := range ms {
+		ln := m.Reg.Start.Ln
+		if ln != pln {
+			si = re.FindAllSubmatchIndex(lns[ln], -1)
+			k, pln = 0, ln
+			nlines++
+		} else {
+			k++
+		}
+		if (k > 0 && !strings.Contains(flags, "g")) || k >= len(si) {
+			continue
+		}
+		todo = append(todo, subst{m, si[k]})
+	}
+	if len(todo) == 0 {
+		v.Message = "E: pattern not found: " + pat
+		return
+	}
+	if strings.Contains(flags, "n") {
+		v.Message = fmt.Sprintf("%d matches on %d lines", len(todo), nlines)
+		return
+	}
+	// replace from the end, so the earlier positions stay the same
+	for i := len(todo) - 1; i >= 0; i-- {
+		sb := todo[i]
+		ln := sb.m.Reg.Start.Ln
+		txt := re.Expand(nil, []byte(repl), lns[ln], sb.idx)
+		sp := lex.Pos{Ln: st + ln, Ch: sb.m.Reg.Start.Ch}
+		ep := lex.Pos{Ln: st + ln, Ch: sb.m.Reg.End.Ch}
+		ed.Buf.ReplaceText(sp, ep, sp, string(txt), texteditor.EditSignal, false)
+	}
+	lln := st + todo[len(todo)-1].m.Reg.Start.Ln
+	ed.SetCursorShow(lex.Pos{Ln: lln, Ch: firstNonBlank(line(ed, lln))})
+	if len(todo) > 1 {
+		v.Message = fmt.Sprintf("%d substitutions on %d lines", len(todo), nlines)
+	}
+}
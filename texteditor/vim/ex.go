@@ -0,0 +1,219 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exCmd is a parsed : command line command
+type exCmd struct {
+
+	// first and last lines of the range, 0-based
+	start, end int
+
+	// whether a range was given, else it is the current line
+	hasRange bool
+
+	// full name of the command, e.g., "substitute" for "s",
+	// which is empty for just a range, to go to its last line
+	name string
+
+	// whether the command was followed by !
+	bang bool
+
+	// the rest of the command line after the name
+	arg string
+}
+
+// exNames are the names of the supported : commands, with their shortest
+// abbreviations
+var exNames = []struct{ abbrev, name string }{
+	{"s", "substitute"}, {"w", "write"}, {"wq", "wq"}, {"x", "xit"}, {"q", "quit"},
+	{"d", "delete"}, {"y", "yank"}, {"j", "join"}, {"noh", "nohlsearch"},
+	{"u", "undo"}, {"red", "redo"}, {">", ">"}, {"<", "<"}, {"&", "&"},
+}
+
+// parseEx parses given : command line (without the :), for given current
+// line and number of lines, with given function to get the line of a mark
+// (for '< and '>).
+func parseEx(s string, cur, nlines int, mark func(r rune) (int, bool)) (exCmd, error) {
+	ec := exCmd{start: cur, end: cur}
+	s = strings.TrimLeft(s, " :")
+	// addr parses one line address, returning false if there is none
+	addr := func() (int, bool, error) {
+		ln, has := cur, false
+		switch {
+		case s == "":
+			return ln, false, nil
+		case s[0] == '.':
+			s, has = s[1:], true
+		case s[0] == '$':
+			ln, s, has = nlines-1, s[1:], true
+		case s[0] == '\'' && len(s) > 1:
+			ml, ok := mark(rune(s[1]))
+			if !ok {
+				return ln, false, fmt.Errorf("mark not set: %c", s[1])
+			}
+			ln, s, has = ml, s[2:], true
+		case s[0] >= '0' && s[0] <= '9':
+			n := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+			if n < 0 {
+				n = len(s)
+			}
+			v, _ := strconv.Atoi(s[:n])
+			ln, s, has = v-1, s[n:], true
+		}
+		for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+			sign := 1
+			if s[0] == '-' {
+				sign = -1
+			}
+			s = s[1:]
+			n := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+			if n < 0 {
+				n = len(s)
+			}
+			off := 1
+			if n > 0 {
+				off, _ = strconv.Atoi(s[:n])
+			}
+			ln, s, has = ln+sign*off, s[n:], true
+		}
+		return ln, has, nil
+	}
+	if strings.HasPrefix(s, "%") {
+		ec.start, ec.end, ec.hasRange = 0, nlines-1, true
+		s = s[1:]
+	} else {
+		ln, has, err := addr()
+		if err != nil {
+			return ec, err
+		}
+		if has {
+			ec.start, ec.end, ec.hasRange = ln, ln, true
+		}
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+			ln, has, err := addr()
+			if err != nil {
+				return ec, err
+			}
+			if has {
+				ec.end, ec.hasRange = ln, true
+			}
+		}
+	}
+	if ec.start > ec.end {
+		ec.start, ec.end = ec.end, ec.start
+	}
+	if ec.start < 0 || ec.end >= nlines {
+		return ec, errors.New("invalid range")
+	}
+
+	s = strings.TrimLeft(s, " ")
+	n := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	switch {
+	case n < 0:
+		n = len(s)
+	case n == 0 && len(s) > 0 && strings.ContainsRune("<>&", rune(s[0])):
+		n = 1
+	}
+	nm := s[:n]
+	s = s[n:]
+	if strings.HasPrefix(s, "!") {
+		ec.bang = true
+		s = s[1:]
+	}
+	ec.arg = strings.TrimSpace(s)
+	if nm == "" {
+		return ec, nil
+	}
+	for _, en := range exNames {
+		if strings.HasPrefix(en.name, nm) && len(nm) >= len(en.abbrev) {
+			ec.name = en.name
+			return ec, nil
+		}
+	}
+	return ec, fmt.Errorf("not an editor command: %s", nm)
+}
+
+// parseSubst parses the argument of a :s command, /pattern/replacement/flags,
+// where any punctuation char can be used instead of /, and a \ escapes it.
+// The replacement is converted to a regexp.Expand template.
+func parseSubst(arg string) (pat, repl, flags string, err error) {
+	if arg == "" {
+		return "", "", "", errors.New("no previous substitute")
+	}
+	rs := []rune(arg)
+	dl := rs[0]
+	if unicode.IsLetter(dl) || unicode.IsDigit(dl) || unicode.IsSpace(dl) || dl == '\\' || dl == '"' {
+		return "", "", "", errors.New("invalid delimiter: " + string(dl))
+	}
+	var parts []string
+	var cur []rune
+	for i := 1; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\\' && i+1 < len(rs) && rs[i+1] == dl:
+			cur = append(cur, dl)
+			i++
+		case r == '\\' && i+1 < len(rs):
+			cur = append(cur, r, rs[i+1])
+			i++
+		case r == dl && len(parts) < 2:
+			parts = append(parts, string(cur))
+			cur = nil
+		default:
+			cur = append(cur, r)
+		}
+	}
+	parts = append(parts, string(cur))
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	if parts[0] == "" {
+		return "", "", "", errors.New("empty pattern")
+	}
+	return parts[0], substTemplate(parts[1]), strings.TrimSpace(parts[2]), nil
+}
+
+// substTemplate converts a vim :s replacement to a regexp.Expand template,
+// where & and \0 are the whole match, \1 to \9 are the submatches,
+// \n and \r are a line break, \t is a tab, and \ escapes other chars.
+func substTemplate(repl string) string {
+	var sb strings.Builder
+	rs := []rune(repl)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '$':
+			sb.WriteString("$$")
+		case r == '&':
+			sb.WriteString("${0}")
+		case r == '\\' && i+1 < len(rs):
+			i++
+			switch n := rs[i]; {
+			case n >= '0' && n <= '9':
+				sb.WriteString("${" + string(n) + "}")
+			case n == 'n' || n == 'r':
+				sb.WriteRune('\n')
+			case n == 't':
+				sb.WriteRune('\t')
+			case n == '$':
+				sb.WriteString("$$")
+			default:
+				sb.WriteRune(n)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,641 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"strings"
+	"unicode"
+
+	"goki.dev/gi/v2/texteditor"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/goosi/mimedata"
+	"goki.dev/pi/v2/filecat"
+	"goki.dev/pi/v2/lex"
+)
+
+// changeCmds are the normal mode commands that change the text,
+// which can be repeated with .
+var changeCmds = map[string]bool{
+	"x": true, "X": true, "s": true, "S": true, "D": true, "C": true, "p": true, "P": true,
+	"J": true, "gJ": true, "r": true, "~": true, "i": true, "a": true, "I": true, "A": true,
+	"o": true, "O": true,
+}
+
+// cmdAliases are the normal mode commands that are short for an
+// operator with a motion
+var cmdAliases = map[string]command{
+	"x": {op: "d", cmd: "l"}, "X": {op: "d", cmd: "h"}, "s": {op: "c", cmd: "l"},
+	"S": {op: "c", cmd: "_"}, "D": {op: "d", cmd: "$"}, "C": {op: "c", cmd: "$"},
+	"Y": {op: "y", cmd: "_"},
+}
+
+// exec executes given parsed command
+func (v *Vim) exec(ed *texteditor.Editor, c *command) {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+
+	if v.isVisual() {
+		v.undoPos = undoStart(ed)
+		v.visualCmd(ed, c)
+		if v.Mode != Insert {
+			undoEnd(ed, v.undoPos)
+		}
+		return
+	}
+	if motions[c.cmd] && c.op == "" {
+		v.move(ed, c)
+		return
+	}
+	if (c.op != "" && c.op != "y" || changeCmds[c.cmd]) && ed.IsReadOnly() {
+		v.Message = "the text is read only"
+		return
+	}
+	if !v.repeating {
+		v.undoPos = undoStart(ed)
+	}
+	if c.op != "" {
+		v.operate(ed, c)
+	} else {
+		v.action(ed, c)
+	}
+	if v.Mode == Insert {
+		return // the change ends with insert mode
+	}
+	if !v.repeating {
+		undoEnd(ed, v.undoPos)
+	}
+	v.clampCursor(ed)
+}
+
+// undoStart starts a new undo group for a change, and returns the undo
+// position to pass to undoEnd at the end of the change.
+func undoStart(ed *texteditor.Editor) int {
+	un := &ed.Buf.Undos
+	un.NewGroup()
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	return un.Pos
+}
+
+// undoEnd puts all of the edits since given undo position in one undo group
+func undoEnd(ed *texteditor.Editor, pos int) {
+	ed.Buf.Undos.SetGroupFrom(pos)
+}
+
+// clampCursor keeps the cursor on the last char of the line in normal mode
+func (v *Vim) clampCursor(ed *texteditor.Editor) {
+	if v.Mode != Normal {
+		return
+	}
+	pos := ed.CursorPos
+	if ln := ed.Buf.LineLen(pos.Ln); pos.Ch >= ln && ln > 0 {
+		pos.Ch = ln - 1
+		ed.SetCursorShow(pos)
+	}
+}
+
+// setChange records given command as the last change, for .
+func (v *Vim) setChange(c *command, text string) {
+	if v.repeating {
+		return
+	}
+	v.lastChange = &change{cmd: *c, text: text}
+}
+
+// line returns the runes of given line
+func line(ed *texteditor.Editor, ln int) []rune {
+	return ed.Buf.Line(ln)
+}
+
+// motion returns the position that the motion of given command moves to
+// from the cursor, with its kind, and false if it fails.
+func (v *Vim) motion(ed *texteditor.Editor, c *command) (lex.Pos, motionKind, bool) {
+	pos := ed.CursorPos
+	switch c.cmd {
+	case ";", ",":
+		if v.lastFind.cmd == "" {
+			return pos, exclusive, false
+		}
+		fc := v.lastFind
+		fc.count = c.count
+		if c.cmd == "," {
+			fc.cmd = map[string]string{"f": "F", "F": "f", "t": "T", "T": "t"}[fc.cmd]
+		}
+		from := pos
+		switch fc.cmd { // don't get stuck just before the char
+		case "t":
+			from.Ch++
+		case "T":
+			from.Ch--
+		}
+		if tp, kind, ok := motion(ed.Buf, from, ed.CursorCol, &fc); ok {
+			return tp, kind, ok
+		}
+		return motion(ed.Buf, pos, ed.CursorCol, &fc)
+	case "n", "N", "*", "#":
+		return v.searchMotion(ed, c)
+	case "f", "F", "t", "T":
+		v.lastFind = *c
+	}
+	return motion(ed.Buf, pos, ed.CursorCol, c)
+}
+
+// jumps are the motions that save the position history
+var jumps = map[string]bool{"G": true, "gg": true, "%": true, "{": true, "}": true, "n": true, "N": true, "*": true, "#": true}
+
+// move moves the cursor by the motion of given command
+func (v *Vim) move(ed *texteditor.Editor, c *command) {
+	switch c.cmd {
+	case "j":
+		ed.CursorDown(c.n())
+	case "k":
+		ed.CursorUp(c.n())
+	default:
+		pos, _, ok := v.motion(ed, c)
+		if !ok {
+			return
+		}
+		if jumps[c.cmd] {
+			ed.SavePosHistory(ed.CursorPos)
+		}
+		ed.SetCursorShow(pos)
+		ed.SetCursorCol(ed.CursorPos)
+		if jumps[c.cmd] {
+			ed.SavePosHistory(ed.CursorPos)
+		}
+	}
+	if v.isVisual() {
+		v.showVisual(ed)
+	} else {
+		v.clampCursor(ed)
+	}
+}
+
+// operate applies the operator of given command to the range of its
+// motion or text object
+func (v *Vim) operate(ed *texteditor.Editor, c *command) {
+	pos := ed.CursorPos
+	var st, en lex.Pos
+	lines := false
+	switch {
+	case c.cmd == "_":
+		last := min(pos.Ln+c.n()-1, ed.Buf.NumLines()-1)
+		st, en, _ = motionRange(ed.Buf, pos, lex.Pos{Ln: last}, linewise)
+		lines = true
+	case c.cmd[0] == 'i' || c.cmd[0] == 'a':
+		var ok bool
+		st, en, lines, ok = textObject(ed.Buf, pos, c.cmd)
+		if !ok {
+			return
+		}
+	default:
+		mc := *c
+		if c.op == "c" && (c.cmd == "w" || c.cmd == "W") { // cw is like ce on a word
+			if ln := line(ed, pos.Ln); pos.Ch < len(ln) && !unicode.IsSpace(ln[pos.Ch]) {
+				mc.cmd = strings.Replace(strings.Replace(c.cmd, "w", "e", 1), "W", "E", 1)
+			}
+		}
+		to, kind, ok := v.motion(ed, &mc)
+		if !ok {
+			return
+		}
+		if (mc.cmd == "w" || mc.cmd == "W") && to.Ln > pos.Ln && to.Ch <= firstNonBlank(line(ed, to.Ln)) {
+			// the last word moved over ends at the end of its line
+			to = lex.Pos{Ln: to.Ln - 1, Ch: ed.Buf.LineLen(to.Ln - 1)}
+		}
+		st, en, kind = motionRange(ed.Buf, pos, to, kind)
+		lines = kind == linewise
+	}
+	v.apply(ed, c, st, en, lines)
+	if c.op != "y" && c.op != "c" {
+		v.setChange(c, "")
+	}
+}
+
+// lastLine returns the last line in a range of lines ending at en
+func lastLine(st, en lex.Pos) int {
+	if en.Ch == 0 && en.Ln > st.Ln {
+		return en.Ln - 1
+	}
+	return en.Ln
+}
+
+// apply applies the operator of given command to given range,
+// with the end exclusive, which is whole lines if lines is true.
+func (v *Vim) apply(ed *texteditor.Editor, c *command, st, en lex.Pos, lines bool) {
+	switch c.op {
+	case "y":
+		v.yank(ed, c.reg, st, en, lines, true)
+		if !lines || st.Ln != ed.CursorPos.Ln {
+			ed.SetCursorShow(st)
+		}
+	case "d":
+		v.delete(ed, c.reg, st, en, lines)
+		if lines {
+			ln := min(st.Ln, ed.Buf.NumLines()-1)
+			st = lex.Pos{Ln: ln, Ch: firstNonBlank(line(ed, ln))}
+		}
+		ed.SetCursorShow(st)
+	case "c":
+		if lines { // keep the line, with its indent
+			v.yank(ed, c.reg, st, en, true, false)
+			ln := lastLine(st, en)
+			ed.Buf.DeleteText(st, lex.Pos{Ln: ln, Ch: ed.Buf.LineLen(ln)}, texteditor.EditSignal)
+			ed.SetCursorShow(st)
+			if ed.Buf.Opts.AutoIndent {
+				_, _, cpos := ed.Buf.AutoIndent(st.Ln)
+				ed.SetCursorShow(lex.Pos{Ln: st.Ln, Ch: cpos})
+			}
+		} else {
+			v.delete(ed, c.reg, st, en, false)
+			ed.SetCursorShow(st)
+		}
+		v.startInsert(ed, c)
+	case "<", ">":
+		v.shift(ed, st.Ln, lastLine(st, en), 1, c.op == ">")
+		ed.SetCursorShow(lex.Pos{Ln: st.Ln, Ch: firstNonBlank(line(ed, st.Ln))})
+	case "=":
+		ed.Buf.AutoIndentRegion(st.Ln, lastLine(st, en)+1)
+		ed.SetCursorShow(lex.Pos{Ln: st.Ln, Ch: firstNonBlank(line(ed, st.Ln))})
+	case "g~", "gu", "gU":
+		recase(ed, st, en, c.op)
+		ed.SetCursorShow(st)
+	}
+}
+
+// recase changes the case of given range, for the g~ gu and gU operators
+func recase(ed *texteditor.Editor, st, en lex.Pos, op string) {
+	tbe := ed.Buf.Region(st, en)
+	if tbe == nil {
+		return
+	}
+	txt := string(tbe.ToBytes())
+	switch op {
+	case "gu":
+		txt = strings.ToLower(txt)
+	case "gU":
+		txt = strings.ToUpper(txt)
+	default:
+		txt = strings.Map(func(r rune) rune {
+			if unicode.IsUpper(r) {
+				return unicode.ToLower(r)
+			}
+			return unicode.ToUpper(r)
+		}, txt)
+	}
+	ed.Buf.ReplaceText(st, en, st, txt, texteditor.EditSignal, false)
+}
+
+// shift shifts the lines from st to last (inclusive) by n indent levels,
+// to the right or left, skipping blank lines
+func (v *Vim) shift(ed *texteditor.Editor, st, last, n int, right bool) {
+	tabSz := ed.Buf.Opts.TabSize
+	for ln := st; ln <= last; ln++ {
+		lr := line(ed, ln)
+		if isBlankLine(lr) {
+			continue
+		}
+		ind, _ := lex.LineIndent(lr, tabSz)
+		if right {
+			ind += n
+		} else {
+			ind = max(ind-n, 0)
+		}
+		ed.Buf.IndentLine(ln, ind)
+	}
+}
+
+// regText returns the text of given range for a register
+func regText(ed *texteditor.Editor, st, en lex.Pos, lines bool) string {
+	tbe := ed.Buf.Region(st, en)
+	txt := ""
+	if tbe != nil {
+		txt = string(tbe.ToBytes())
+	}
+	if lines && !strings.HasSuffix(txt, "\n") {
+		txt += "\n"
+	}
+	return txt
+}
+
+// yank puts the text of given range into given register
+func (v *Vim) yank(ed *texteditor.Editor, reg rune, st, en lex.Pos, lines, yank bool) {
+	v.setReg(ed, reg, Register{Text: regText(ed, st, en, lines), Linewise: lines}, yank)
+}
+
+// delete deletes given range, putting it in given register
+func (v *Vim) delete(ed *texteditor.Editor, reg rune, st, en lex.Pos, lines bool) {
+	v.yank(ed, reg, st, en, lines, false)
+	if lines && en.Ln == ed.Buf.NumLines()-1 && en.Ch > 0 && st.Ln > 0 {
+		// deleting the last lines: delete the line break before them
+		st = lex.Pos{Ln: st.Ln - 1, Ch: ed.Buf.LineLen(st.Ln - 1)}
+	}
+	ed.Buf.DeleteText(st, en, texteditor.EditSignal)
+}
+
+// setReg sets given register, where + and * are the system clipboard
+func (v *Vim) setReg(ed *texteditor.Editor, name rune, rg Register, yank bool) {
+	if name == '+' || name == '*' {
+		ed.EventMgr().ClipBoard().Write(mimedata.NewText(rg.Text))
+		name = 0
+	}
+	v.Registers.Set(name, rg, yank)
+}
+
+// getReg returns given register, where + and * are the system clipboard,
+// and nil if it is empty
+func (v *Vim) getReg(ed *texteditor.Editor, name rune) *Register {
+	if name == '+' || name == '*' {
+		data := ed.EventMgr().ClipBoard().Read([]string{filecat.TextPlain})
+		if data == nil {
+			return nil
+		}
+		txt := string(data.TypeData(filecat.TextPlain))
+		return &Register{Text: txt, Linewise: strings.HasSuffix(txt, "\n")}
+	}
+	return v.Registers.Get(name)
+}
+
+// action executes the given non-operator command in normal mode
+func (v *Vim) action(ed *texteditor.Editor, c *command) {
+	if ac, ok := cmdAliases[c.cmd]; ok {
+		ac.count, ac.reg = c.count, c.reg
+		if ed.Buf.LineLen(ed.CursorPos.Ln) == 0 && (c.cmd == "x" || c.cmd == "X" || c.cmd == "D") {
+			return
+		}
+		v.operate(ed, &ac)
+		if ac.op == "d" {
+			v.setChange(c, "")
+		}
+		return
+	}
+	pos := ed.CursorPos
+	ln := line(ed, pos.Ln)
+	switch c.cmd {
+	case "p", "P":
+		v.put(ed, c.reg, c.n(), c.cmd == "P")
+		v.setChange(c, "")
+	case "J", "gJ":
+		join(ed, pos.Ln, min(pos.Ln+max(c.n()-1, 1), ed.Buf.NumLines()-1), c.cmd == "J")
+		v.setChange(c, "")
+	case "r":
+		if pos.Ch+c.n() > len(ln) {
+			return
+		}
+		en := lex.Pos{Ln: pos.Ln, Ch: pos.Ch + c.n()}
+		ed.Buf.ReplaceText(pos, en, pos, strings.Repeat(string(c.arg), c.n()), texteditor.EditSignal, false)
+		ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: en.Ch - 1})
+		v.setChange(c, "")
+	case "~":
+		if len(ln) == 0 {
+			return
+		}
+		en := lex.Pos{Ln: pos.Ln, Ch: min(pos.Ch+c.n(), len(ln))}
+		recase(ed, pos, en, "g~")
+		ed.SetCursorShow(en)
+		v.setChange(c, "")
+	case "i", "a", "I", "A", "o", "O":
+		switch c.cmd {
+		case "a":
+			if len(ln) > 0 {
+				ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: pos.Ch + 1})
+			}
+		case "I":
+			ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: firstNonBlank(ln)})
+		case "A":
+			ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: len(ln)})
+		case "o":
+			ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: len(ln)})
+			ed.InsertNewline()
+		case "O":
+			openAbove(ed)
+		}
+		v.startInsert(ed, c)
+	case "u":
+		for i := 0; i < c.n(); i++ {
+			ed.Undo()
+		}
+	case "Control+R":
+		for i := 0; i < c.n(); i++ {
+			ed.Redo()
+		}
+	case ".":
+		v.repeat(ed, c)
+	case "v", "V", "Control+V":
+		v.startVisual(ed, visualModes[c.cmd])
+	case "gv":
+		if v.lastVis == v.lastVisEn && v.lastVisMode == Normal {
+			return
+		}
+		v.visStart = v.lastVis
+		ed.SetCursorShow(v.lastVisEn)
+		v.startVisualFrom(ed, v.lastVisMode)
+	case ":", "/", "?":
+		v.startCmdLine(c.cmd)
+	case "zz":
+		ed.CursorRecenter()
+	case "zt":
+		ed.ScrollCursorToTop()
+	case "zb":
+		ed.ScrollCursorToBottom()
+	case "Control+F", "Control+D":
+		ed.CursorPageDown(c.n())
+	case "Control+B", "Control+U":
+		ed.CursorPageUp(c.n())
+	}
+}
+
+// openAbove opens a new line above the cursor line, with auto-indent
+func openAbove(ed *texteditor.Editor) {
+	ln := ed.CursorPos.Ln
+	if ln > 0 {
+		ed.SetCursorShow(lex.Pos{Ln: ln - 1, Ch: ed.Buf.LineLen(ln - 1)})
+		ed.InsertNewline()
+		return
+	}
+	ed.Buf.InsertText(lex.Pos{}, []byte("\n"), texteditor.EditSignal)
+	ed.SetCursorShow(lex.Pos{})
+	if ed.Buf.Opts.AutoIndent {
+		_, _, cpos := ed.Buf.AutoIndent(0)
+		ed.SetCursorShow(lex.Pos{Ch: cpos})
+	}
+}
+
+// join joins the lines from st to last (inclusive), for J and gJ,
+// where spaces replaces the leading space of each joined line with
+// one space (J), otherwise the lines are joined as they are (gJ).
+func join(ed *texteditor.Editor, st, last int, spaces bool) {
+	pos := ed.CursorPos
+	for ln := st; ln < last; ln++ {
+		cur := line(ed, st)
+		nxt := line(ed, st+1)
+		ep := lex.Pos{Ln: st, Ch: len(cur)}
+		np := lex.Pos{Ln: st + 1}
+		ins := ""
+		if spaces {
+			for np.Ch < len(nxt) && unicode.IsSpace(nxt[np.Ch]) {
+				np.Ch++
+			}
+			for ep.Ch > 0 && unicode.IsSpace(cur[ep.Ch-1]) {
+				ep.Ch--
+			}
+			if ep.Ch > 0 && np.Ch < len(nxt) && nxt[np.Ch] != ')' {
+				ins = " "
+			}
+		}
+		ed.Buf.ReplaceText(ep, np, ep, ins, texteditor.EditSignal, false)
+		pos = ep
+	}
+	ed.SetCursorShow(pos)
+}
+
+// put puts the text in given register n times, before or after the cursor,
+// on new lines before or after the cursor line for whole lines
+func (v *Vim) put(ed *texteditor.Editor, reg rune, n int, before bool) {
+	rg := v.getReg(ed, reg)
+	if rg == nil || rg.Text == "" {
+		v.Message = "nothing in register"
+		return
+	}
+	v.putReg(ed, rg, n, before)
+}
+
+// putReg puts the text of given register, as for put
+func (v *Vim) putReg(ed *texteditor.Editor, rg *Register, n int, before bool) {
+	pos := ed.CursorPos
+	if !before && !rg.Linewise && ed.Buf.LineLen(pos.Ln) > 0 {
+		pos.Ch++
+	}
+	switch {
+	case rg.Block:
+		putBlock(ed, pos, rg.Text, n)
+		ed.SetCursorShow(pos)
+	case rg.Linewise:
+		txt := strings.Repeat(rg.Text, n)
+		ln := pos.Ln
+		if !before {
+			ln++
+		}
+		if ln >= ed.Buf.NumLines() { // after the last line
+			end := ed.Buf.EndPos()
+			ed.Buf.InsertText(end, []byte("\n"+strings.TrimSuffix(txt, "\n")), texteditor.EditSignal)
+		} else {
+			ed.Buf.InsertText(lex.Pos{Ln: ln}, []byte(txt), texteditor.EditSignal)
+		}
+		ed.SetCursorShow(lex.Pos{Ln: ln, Ch: firstNonBlank(line(ed, ln))})
+	default:
+		tbe := ed.Buf.InsertText(pos, []byte(strings.Repeat(rg.Text, n)), texteditor.EditSignal)
+		if tbe != nil {
+			end := tbe.Reg.End
+			if end.Ch > 0 {
+				end.Ch--
+			}
+			ed.SetCursorShow(end)
+		}
+	}
+	ed.SavePosHistory(ed.CursorPos)
+}
+
+// putBlock inserts the lines of given text as a rectangular block at pos,
+// repeated n times across
+func putBlock(ed *texteditor.Editor, pos lex.Pos, txt string, n int) {
+	lns := strings.Split(strings.TrimSuffix(txt, "\n"), "\n")
+	wd := 0
+	for _, l := range lns {
+		wd = max(wd, len([]rune(l)))
+	}
+	tbe := &textbuf.Edit{Rect: true}
+	for _, l := range lns {
+		rl := []rune(l)
+		rl = append(rl, []rune(strings.Repeat(" ", wd-len(rl)))...)
+		tbe.Text = append(tbe.Text, []rune(strings.Repeat(string(rl), n)))
+	}
+	tbe.Reg = textbuf.NewRegion(pos.Ln, pos.Ch, pos.Ln+len(lns)-1, pos.Ch+wd*n)
+	ed.Buf.InsertTextRect(tbe, texteditor.EditSignal)
+}
+
+// startInsert starts insert mode after given command, or when repeating
+// it with ., inserts the text of the last change
+func (v *Vim) startInsert(ed *texteditor.Editor, c *command) {
+	if v.repeating {
+		txt := v.lastChange.text
+		if txt != "" {
+			ed.InsertAtCursor([]byte(txt))
+		}
+		insertCopies(ed, txt, c)
+		return
+	}
+	v.ins = &change{cmd: *c}
+	v.insStart = ed.CursorPos
+	v.insBroken = false
+	v.setMode(Insert)
+}
+
+// countInserts are the commands where a count repeats the inserted text
+var countInserts = map[string]bool{"i": true, "a": true, "I": true, "A": true, "o": true, "O": true}
+
+// insertCopies inserts the copies of given text typed in insert mode
+// after given command, for its count, on new lines for o and O
+func insertCopies(ed *texteditor.Editor, txt string, c *command) {
+	if txt == "" || c.op != "" || !countInserts[c.cmd] {
+		return
+	}
+	for i := 1; i < c.n(); i++ {
+		if c.cmd == "o" || c.cmd == "O" {
+			ed.InsertNewline()
+		}
+		ed.InsertAtCursor([]byte(txt))
+	}
+}
+
+// endInsert ends insert mode, going back to normal mode.  The text that
+// was typed is kept for repeating with ., unless the cursor was moved.
+func (v *Vim) endInsert(ed *texteditor.Editor) {
+	updt := ed.UpdateStart()
+	defer ed.UpdateEndRender(updt)
+	block := ed.HasMultiCursors()
+	ed.ClearCursors()
+	ed.SelectReset()
+	if v.ins != nil {
+		txt := ""
+		if cur := ed.CursorPos; !v.insBroken && !block && !cur.IsLess(v.insStart) {
+			txt = regText(ed, v.insStart, cur, false)
+			insertCopies(ed, txt, &v.ins.cmd)
+		}
+		if !block && v.ins.cmd.cmd != "" { // not a change from visual mode
+			v.ins.text = txt
+			v.lastChange = v.ins
+		}
+		v.ins = nil
+	}
+	undoEnd(ed, v.undoPos)
+	v.setMode(Normal)
+	if pos := ed.CursorPos; pos.Ch > 0 {
+		ed.SetCursorShow(lex.Pos{Ln: pos.Ln, Ch: pos.Ch - 1})
+	}
+	ed.SetCursorCol(ed.CursorPos)
+}
+
+// repeat repeats the last change, for ., with the count of given command
+// if one is given
+func (v *Vim) repeat(ed *texteditor.Editor, c *command) {
+	if v.lastChange == nil {
+		return
+	}
+	rc := v.lastChange.cmd
+	if c.count > 0 {
+		rc.count = c.count
+	}
+	v.repeating = true
+	defer func() { v.repeating = false }()
+	if rc.op != "" {
+		v.operate(ed, &rc)
+	} else {
+		v.action(ed, &rc)
+	}
+	if c.count > 0 {
+		v.lastChange.cmd.count = c.count
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"unicode"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// Text is the text that motions and text objects operate on,
+// which is satisfied by *texteditor.Buf
+type Text interface {
+
+	// NumLines returns the number of lines
+	NumLines() int
+
+	// Line returns the runes of given line
+	Line(ln int) []rune
+}
+
+// kinds of motion, which determine the range an operator applies to
+type motionKind int
+
+const (
+	// exclusive motions do not include the char at the end position
+	exclusive motionKind = iota
+
+	// inclusive motions include the char at the end position
+	inclusive
+
+	// linewise motions operate on whole lines
+	linewise
+)
+
+// charClass returns the class of given rune for word motions:
+// 0 for space, 1 for word chars and 2 for punctuation.
+// For big words (W, B, E), all non-space chars are class 1.
+func charClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	}
+	return 2
+}
+
+// scanner steps through the chars of a Text, where the position
+// just past the end of each line is the newline at the end of it
+type scanner struct {
+	txt Text
+	pos lex.Pos
+}
+
+// at returns the char at the current position, '\n' at the end of a line
+func (s *scanner) at() rune {
+	ln := s.txt.Line(s.pos.Ln)
+	if s.pos.Ch < len(ln) {
+		return ln[s.pos.Ch]
+	}
+	return '\n'
+}
+
+// emptyLine returns true if the current position is on an empty line
+func (s *scanner) emptyLine() bool {
+	return len(s.txt.Line(s.pos.Ln)) == 0
+}
+
+// next moves to the next char, returning false at the end of the text
+func (s *scanner) next() bool {
+	if s.pos.Ch < len(s.txt.Line(s.pos.Ln)) {
+		s.pos.Ch++
+		return true
+	}
+	if s.pos.Ln+1 < s.txt.NumLines() {
+		s.pos = lex.Pos{Ln: s.pos.Ln + 1}
+		return true
+	}
+	return false
+}
+
+// prev moves to the previous char, returning false at the start of the text
+func (s *scanner) prev() bool {
+	if s.pos.Ch > 0 {
+		s.pos.Ch--
+		return true
+	}
+	if s.pos.Ln > 0 {
+		s.pos.Ln--
+		s.pos.Ch = len(s.txt.Line(s.pos.Ln))
+		return true
+	}
+	return false
+}
+
+// wordForward returns the start of the next word after pos (w, W)
+func wordForward(txt Text, pos lex.Pos, big bool) lex.Pos {
+	s := &scanner{txt, pos}
+	if c := charClass(s.at(), big); c != 0 {
+		for s.next() && charClass(s.at(), big) == c {
+		}
+	}
+	for charClass(s.at(), big) == 0 {
+		if s.emptyLine() && s.pos != pos {
+			break
+		}
+		if !s.next() {
+			break
+		}
+	}
+	return s.pos
+}
+
+// wordEnd returns the end of the word at or after the one at pos (e, E)
+func wordEnd(txt Text, pos lex.Pos, big bool) lex.Pos {
+	s := &scanner{txt, pos}
+	if !s.next() {
+		return pos
+	}
+	for charClass(s.at(), big) == 0 {
+		if !s.next() {
+			return s.pos
+		}
+	}
+	c := charClass(s.at(), big)
+	for {
+		cur := s.pos
+		if !s.next() || charClass(s.at(), big) != c {
+			return cur
+		}
+	}
+}
+
+// wordBackward returns the start of the word at or before the one at pos (b, B)
+func wordBackward(txt Text, pos lex.Pos, big bool) lex.Pos {
+	s := &scanner{txt, pos}
+	if !s.prev() {
+		return pos
+	}
+	for charClass(s.at(), big) == 0 {
+		if s.emptyLine() || !s.prev() {
+			return s.pos
+		}
+	}
+	c := charClass(s.at(), big)
+	for {
+		cur := s.pos
+		if !s.prev() || charClass(s.at(), big) != c {
+			return cur
+		}
+	}
+}
+
+// wordEndBackward returns the end of the word before the one at pos (ge, gE)
+func wordEndBackward(txt Text, pos lex.Pos, big bool) lex.Pos {
+	s := &scanner{txt, pos}
+	c := charClass(s.at(), big)
+	for {
+		if !s.prev() {
+			return s.pos
+		}
+		if charClass(s.at(), big) != c {
+			break
+		}
+	}
+	for charClass(s.at(), big) == 0 {
+		if s.emptyLine() || !s.prev() {
+			return s.pos
+		}
+	}
+	return s.pos
+}
+
+// firstNonBlank returns the index of the first non-blank char in given line
+func firstNonBlank(ln []rune) int {
+	for i, r := range ln {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return max(len(ln)-1, 0)
+}
+
+// isBlankLine returns true if given line is empty or all space
+func isBlankLine(ln []rune) bool {
+	for _, r := range ln {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// lastCh returns the position of the last char in given line, which is 0
+// for an empty line
+func lastCh(ln []rune) int {
+	return max(len(ln)-1, 0)
+}
+
+// findInLine finds the n'th occurrence of r in the line of pos, for the
+// f, F, t and T motions.  Returns false if not found.
+func findInLine(txt Text, pos lex.Pos, cmd string, r rune, n int) (lex.Pos, bool) {
+	ln := txt.Line(pos.Ln)
+	fwd := cmd == "f" || cmd == "t"
+	ch := pos.Ch
+	for n > 0 {
+		if fwd {
+			ch++
+		} else {
+			ch--
+		}
+		if ch < 0 || ch >= len(ln) {
+			return pos, false
+		}
+		if ln[ch] == r {
+			n--
+		}
+	}
+	switch cmd {
+	case "t":
+		ch--
+	case "T":
+		ch++
+	}
+	return lex.Pos{Ln: pos.Ln, Ch: ch}, true
+}
+
+// brackets are the matching open and close brackets
+var brackets = map[rune]rune{'(': ')', '[': ']', '{': '}', '<': '>'}
+
+// matchBracket returns the position of the bracket that matches the one
+// at or after pos on its line (%), and false if there is none.
+func matchBracket(txt Text, pos lex.Pos) (lex.Pos, bool) {
+	ln := txt.Line(pos.Ln)
+	for ch := pos.Ch; ch < len(ln); ch++ {
+		r := ln[ch]
+		if r == '<' || r == '>' {
+			continue // too often an operator
+		}
+		bp := lex.Pos{Ln: pos.Ln, Ch: ch}
+		if cl, ok := brackets[r]; ok {
+			return findClose(txt, bp, r, cl)
+		}
+		for op, cl := range brackets {
+			if r == cl {
+				return findOpen(txt, bp, op, cl)
+			}
+		}
+	}
+	return pos, false
+}
+
+// findClose returns the position of the close bracket that matches the
+// open bracket at pos, skipping nested pairs.
+func findClose(txt Text, pos lex.Pos, op, cl rune) (lex.Pos, bool) {
+	s := &scanner{txt, pos}
+	depth := 0
+	for s.next() {
+		switch s.at() {
+		case op:
+			depth++
+		case cl:
+			if depth == 0 {
+				return s.pos, true
+			}
+			depth--
+		}
+	}
+	return pos, false
+}
+
+// findOpen returns the position of the open bracket that matches the
+// close bracket at pos, skipping nested pairs.
+func findOpen(txt Text, pos lex.Pos, op, cl rune) (lex.Pos, bool) {
+	s := &scanner{txt, pos}
+	depth := 0
+	for s.prev() {
+		switch s.at() {
+		case cl:
+			depth++
+		case op:
+			if depth == 0 {
+				return s.pos, true
+			}
+			depth--
+		}
+	}
+	return pos, false
+}
+
+// paragraph returns the line of the n'th blank line before (back) or after
+// pos, for the { and } motions, or the first or last line if there is none.
+func paragraph(txt Text, pos lex.Pos, back bool, n int) lex.Pos {
+	ln := pos.Ln
+	nl := txt.NumLines()
+	for ; n > 0; n-- {
+		// skip any blank lines we are on, then go to the next blank one
+		for ln >= 0 && ln < nl && isBlankLine(txt.Line(ln)) {
+			ln = step(ln, back)
+		}
+		for ln >= 0 && ln < nl && !isBlankLine(txt.Line(ln)) {
+			ln = step(ln, back)
+		}
+	}
+	switch {
+	case ln < 0:
+		return lex.Pos{}
+	case ln >= nl:
+		return lex.Pos{Ln: nl - 1, Ch: len(txt.Line(nl - 1))}
+	}
+	return lex.Pos{Ln: ln}
+}
+
+// step returns the line before or after ln
+func step(ln int, back bool) int {
+	if back {
+		return ln - 1
+	}
+	return ln + 1
+}
+
+// motion returns the position that the motion of given command moves to
+// from pos, where col is the desired column for vertical motions, along
+// with the kind of motion, and false if the motion fails.
+// The ; , n N * # motions are handled by the Vim, which keeps their state.
+func motion(txt Text, pos lex.Pos, col int, c *command) (lex.Pos, motionKind, bool) {
+	n := c.n()
+	nl := txt.NumLines()
+	ln := txt.Line(pos.Ln)
+	switch c.cmd {
+	case "h":
+		if pos.Ch == 0 {
+			return pos, exclusive, false
+		}
+		return lex.Pos{Ln: pos.Ln, Ch: max(pos.Ch-n, 0)}, exclusive, true
+	case "l":
+		if pos.Ch >= len(ln) {
+			return pos, exclusive, false
+		}
+		return lex.Pos{Ln: pos.Ln, Ch: min(pos.Ch+n, len(ln))}, exclusive, true
+	case "j", "k", "+", "-", "_":
+		tl := pos.Ln
+		switch c.cmd {
+		case "j", "+":
+			tl += n
+		case "k", "-":
+			tl -= n
+		case "_":
+			tl += n - 1
+		}
+		if tl < 0 || tl >= nl {
+			return pos, linewise, false
+		}
+		tln := txt.Line(tl)
+		if c.cmd == "j" || c.cmd == "k" {
+			return lex.Pos{Ln: tl, Ch: min(col, lastCh(tln))}, linewise, true
+		}
+		return lex.Pos{Ln: tl, Ch: firstNonBlank(tln)}, linewise, true
+	case "w", "W", "e", "E", "b", "B", "ge", "gE":
+		big := c.cmd == "W" || c.cmd == "E" || c.cmd == "B" || c.cmd == "gE"
+		kind := exclusive
+		for i := 0; i < n; i++ {
+			switch c.cmd {
+			case "w", "W":
+				pos = wordForward(txt, pos, big)
+			case "e", "E":
+				pos = wordEnd(txt, pos, big)
+				kind = inclusive
+			case "b", "B":
+				pos = wordBackward(txt, pos, big)
+			default:
+				pos = wordEndBackward(txt, pos, big)
+				kind = inclusive
+			}
+		}
+		return pos, kind, true
+	case "0":
+		return lex.Pos{Ln: pos.Ln}, exclusive, true
+	case "^":
+		return lex.Pos{Ln: pos.Ln, Ch: firstNonBlank(ln)}, exclusive, true
+	case "$":
+		tl := min(pos.Ln+n-1, nl-1)
+		return lex.Pos{Ln: tl, Ch: lastCh(txt.Line(tl))}, inclusive, true
+	case "gg", "G":
+		tl := nl - 1
+		if c.count > 0 {
+			tl = min(c.count, nl) - 1
+		} else if c.cmd == "gg" {
+			tl = 0
+		}
+		return lex.Pos{Ln: tl, Ch: firstNonBlank(txt.Line(tl))}, linewise, true
+	case "f", "F", "t", "T":
+		tp, ok := findInLine(txt, pos, c.cmd, c.arg, n)
+		if c.cmd == "f" || c.cmd == "t" {
+			return tp, inclusive, ok
+		}
+		return tp, exclusive, ok
+	case "%":
+		tp, ok := matchBracket(txt, pos)
+		return tp, inclusive, ok
+	case "{", "}":
+		return paragraph(txt, pos, c.cmd == "{", n), exclusive, true
+	}
+	return pos, exclusive, false
+}
+
+// motionRange returns the range of text from pos to the end of a motion
+// of given kind, which an operator applies to, with the end exclusive.
+// For a linewise motion, the range is whole lines, with the end at the
+// start of the line after the last one (or the end of the text).
+// An exclusive motion that ends at the start of a later line stops at
+// the end of the line before, as for dw on the last word of a line, and
+// becomes linewise if it starts at or before the first non-blank char,
+// so the returned kind can differ from the given one.
+func motionRange(txt Text, pos, to lex.Pos, kind motionKind) (st, en lex.Pos, knd motionKind) {
+	st, en = pos, to
+	if en.IsLess(st) {
+		st, en = en, st
+	}
+	if kind == exclusive && en.Ch == 0 && en.Ln > st.Ln {
+		en = lex.Pos{Ln: en.Ln - 1, Ch: len(txt.Line(en.Ln - 1))}
+		if st.Ch > firstNonBlank(txt.Line(st.Ln)) {
+			return st, en, exclusive
+		}
+		kind = linewise
+	}
+	switch kind {
+	case linewise:
+		st.Ch = 0
+		if en.Ln+1 < txt.NumLines() {
+			en = lex.Pos{Ln: en.Ln + 1}
+		} else {
+			en.Ch = len(txt.Line(en.Ln))
+		}
+	case inclusive:
+		en.Ch = min(en.Ch+1, len(txt.Line(en.Ln)))
+	}
+	return st, en, kind
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"unicode/utf8"
+)
+
+// command is a parsed normal or visual mode command, of the general form
+// ["x][count]operator[count]motion, or ["x][count]action
+type command struct {
+
+	// count, 0 if none was given -- the counts before the operator
+	// and the motion are multiplied together
+	count int
+
+	// register name, 0 for the unnamed register
+	reg rune
+
+	// operator, if any: d c y < > = g~ gu gU
+	op string
+
+	// motion, text object (iw, a( etc), or action for the command --
+	// for an operator on whole lines (dd, yy, >> etc), it is "_"
+	cmd string
+
+	// char argument for the f t F T and r commands
+	arg rune
+}
+
+// n returns the count, which is 1 if no count was given
+func (c *command) n() int {
+	if c.count == 0 {
+		return 1
+	}
+	return c.count
+}
+
+// parse results
+type parseStatus int
+
+const (
+	// parseMore means that more keys are needed to complete the command
+	parseMore parseStatus = iota
+
+	// parseDone means that a complete command has been parsed
+	parseDone
+
+	// parseFail means that the keys are not a valid command
+	parseFail
+)
+
+// operators are the operators that take a motion or text object
+var operators = map[string]bool{"d": true, "c": true, "y": true, "<": true, ">": true, "=": true, "g~": true, "gu": true, "gU": true}
+
+// motions are the motion commands
+var motions = map[string]bool{
+	"h": true, "j": true, "k": true, "l": true, "w": true, "W": true, "b": true, "B": true,
+	"e": true, "E": true, "ge": true, "gE": true, "0": true, "^": true, "$": true, "gg": true,
+	"G": true, "f": true, "F": true, "t": true, "T": true, ";": true, ",": true, "%": true,
+	"{": true, "}": true, "+": true, "-": true, "_": true, "n": true, "N": true, "*": true, "#": true,
+}
+
+// actions are the normal mode commands that are not motions or operators
+var actions = map[string]bool{
+	"x": true, "X": true, "s": true, "S": true, "D": true, "C": true, "Y": true, "p": true,
+	"P": true, "J": true, "gJ": true, "r": true, "~": true, "i": true, "a": true, "I": true,
+	"A": true, "o": true, "O": true, "u": true, "Control+R": true, ".": true, "v": true,
+	"V": true, "Control+V": true, "gv": true, ":": true, "/": true, "?": true, "zz": true,
+	"zt": true, "zb": true, "Control+F": true, "Control+B": true, "Control+D": true,
+	"Control+U": true,
+}
+
+// visualActions are the commands in visual modes that act on the selection
+var visualActions = map[string]bool{
+	"d": true, "x": true, "X": true, "D": true, "y": true, "Y": true, "c": true, "s": true,
+	"C": true, "S": true, "r": true, "J": true, "gJ": true, "~": true, "u": true, "U": true,
+	"<": true, ">": true, "=": true, "p": true, "P": true, "I": true, "A": true, "o": true,
+	"O": true, "v": true, "V": true, "Control+V": true, ":": true,
+}
+
+// argCmds are the commands that take a char argument
+var argCmds = map[string]bool{"f": true, "F": true, "t": true, "T": true, "r": true}
+
+// keyAliases are keys that are the same as other keys in normal and visual modes
+var keyAliases = map[string]string{
+	"LeftArrow": "h", "RightArrow": "l", "UpArrow": "k", "DownArrow": "j",
+	"Home": "0", "End": "$", "DeleteBackspace": "h", " ": "l", "ReturnEnter": "+",
+	"DeleteForward": "x", "PageDown": "Control+F", "PageUp": "Control+B",
+}
+
+// parse parses given keys as a command, in visual mode or not
+func parse(keys []string, visual bool) (c command, st parseStatus) {
+	i := 0
+	next := func() (string, bool) {
+		if i >= len(keys) {
+			return "", false
+		}
+		k := keys[i]
+		i++
+		if a, ok := keyAliases[k]; ok {
+			return a, true
+		}
+		return k, true
+	}
+	// count reads a count and the key after it
+	count := func() (int, string, bool) {
+		n := 0
+		for {
+			k, ok := next()
+			if !ok {
+				return n, "", false
+			}
+			if len(k) == 1 && k[0] >= '0' && k[0] <= '9' && (n > 0 || k[0] != '0') {
+				n = n*10 + int(k[0]-'0')
+				continue
+			}
+			return n, k, true
+		}
+	}
+	// token reads a command token, which is two keys for g and z
+	token := func(k string) (string, bool) {
+		if k != "g" && k != "z" {
+			return k, true
+		}
+		k2, ok := next()
+		return k + k2, ok
+	}
+	// charArg reads a single char argument
+	charArg := func() (rune, parseStatus) {
+		k, ok := next()
+		if !ok {
+			return 0, parseMore
+		}
+		if utf8.RuneCountInString(k) != 1 {
+			return 0, parseFail
+		}
+		r, _ := utf8.DecodeRuneInString(k)
+		return r, parseDone
+	}
+
+	n, k, ok := count()
+	if ok && k == `"` {
+		r, st := charArg()
+		if st != parseDone {
+			return c, st
+		}
+		c.reg = r
+		n, k, ok = count()
+	}
+	if !ok {
+		return c, parseMore
+	}
+	c.count = n
+	tok, ok := token(k)
+	if !ok {
+		return c, parseMore
+	}
+	switch {
+	case (tok == "i" || tok == "a") && visual:
+		return textObj(&c, tok, charArg)
+	case visual && visualActions[tok]:
+		c.cmd = tok
+		if tok == "r" {
+			c.arg, st = charArg()
+			return c, st
+		}
+		return c, parseDone
+	case operators[tok]:
+		c.op = tok
+		n2, k2, ok := count()
+		if !ok {
+			return c, parseMore
+		}
+		if n2 > 0 {
+			c.count = max(c.count, 1) * n2
+		}
+		if k2 == "i" || k2 == "a" {
+			return textObj(&c, k2, charArg)
+		}
+		t2, ok := token(k2)
+		if !ok {
+			return c, parseMore
+		}
+		if t2 == tok || t2 == tok[len(tok)-1:] { // dd, gUgU, gUU
+			c.cmd = "_"
+			return c, parseDone
+		}
+		if !motions[t2] {
+			return c, parseFail
+		}
+		c.cmd = t2
+		if argCmds[t2] {
+			c.arg, st = charArg()
+			return c, st
+		}
+		return c, parseDone
+	case motions[tok] || !visual && actions[tok]:
+		c.cmd = tok
+		if argCmds[tok] {
+			c.arg, st = charArg()
+			return c, st
+		}
+		return c, parseDone
+	}
+	return c, parseFail
+}
+
+// textObj finishes parsing a text object starting with i or a
+func textObj(c *command, ia string, charArg func() (rune, parseStatus)) (command, parseStatus) {
+	r, st := charArg()
+	if st != parseDone {
+		return *c, st
+	}
+	switch r {
+	case 'w', 'W', '"', '\'', '`', '(', ')', 'b', '{', '}', 'B', '[', ']', '<', '>', 'p':
+		c.cmd = ia + string(r)
+		return *c, parseDone
+	}
+	return *c, parseFail
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Register is the content of a register, with text that was
+// yanked or deleted
+type Register struct {
+
+	// the text
+	Text string
+
+	// whether the text is whole lines, which are put on new lines
+	Linewise bool
+
+	// whether the text is a rectangular block, from visual block mode
+	Block bool
+}
+
+// Registers are named registers, with the unnamed register as '"'.
+// A yank also goes into register '0', and a delete of one or more lines
+// into register '1', shifting the previous ones up to '9', and a
+// smaller delete into '-'.  An upper-case name appends to the lower-case
+// register, and '_' is the black hole register, which discards the text.
+// The system clipboard registers '+' and '*' are handled by the Vim.
+type Registers map[rune]*Register
+
+// DefaultRegisters are the registers used by a new Vim, which are
+// shared by all of the editors, as in vim itself.
+var DefaultRegisters = Registers{}
+
+// Get returns the register with given name, where 0 is the unnamed
+// register, and nil if it is empty.
+func (rs Registers) Get(name rune) *Register {
+	if name == 0 {
+		name = '"'
+	}
+	return rs[unicode.ToLower(name)]
+}
+
+// Set sets the register with given name (0 for the unnamed register)
+// to given text, from a yank or a delete, which also updates the
+// unnamed register and the numbered and small delete registers.
+func (rs Registers) Set(name rune, rg Register, yank bool) {
+	if name == '_' {
+		return
+	}
+	if unicode.IsUpper(name) {
+		lr := unicode.ToLower(name)
+		if prv := rs[lr]; prv != nil {
+			if prv.Linewise && !strings.HasSuffix(prv.Text, "\n") {
+				prv.Text += "\n"
+			}
+			rg.Text = prv.Text + rg.Text
+			rg.Linewise = rg.Linewise || prv.Linewise
+		}
+		name = lr
+	}
+	r := &rg
+	rs['"'] = r
+	switch {
+	case name != 0 && name != '"':
+		rs[name] = r
+	case yank:
+		rs['0'] = r
+	case rg.Linewise || strings.Contains(rg.Text, "\n"):
+		for i := '9'; i > '1'; i-- {
+			rs[i] = rs[i-1]
+		}
+		rs['1'] = r
+	default:
+		rs['-'] = r
+	}
+}
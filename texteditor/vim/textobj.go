@@ -0,0 +1,161 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"unicode"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// textObject returns the range of the text object with given name at pos,
+// e.g., "iw" for the inner word or "a(" for a parenthesized block with the
+// parens, with the end exclusive, and whether it is linewise.
+// Returns false if there is no such object at pos.
+func textObject(txt Text, pos lex.Pos, obj string) (st, en lex.Pos, lines bool, ok bool) {
+	if len(obj) != 2 {
+		return
+	}
+	inner := obj[0] == 'i'
+	switch r := rune(obj[1]); r {
+	case 'w', 'W':
+		st, en, ok = wordObject(txt, pos, inner, r == 'W')
+	case '"', '\'', '`':
+		st, en, ok = quoteObject(txt, pos, inner, r)
+	case '(', ')', 'b':
+		st, en, ok = bracketObject(txt, pos, inner, '(', ')')
+	case '{', '}', 'B':
+		st, en, ok = bracketObject(txt, pos, inner, '{', '}')
+	case '[', ']':
+		st, en, ok = bracketObject(txt, pos, inner, '[', ']')
+	case '<', '>':
+		st, en, ok = bracketObject(txt, pos, inner, '<', '>')
+	case 'p':
+		st, en, ok = paraObject(txt, pos, inner)
+		lines = true
+	}
+	return
+}
+
+// wordObject returns the word (or run of space) at pos, with the
+// trailing (or else leading) space if not inner (iw, aw, iW, aW)
+func wordObject(txt Text, pos lex.Pos, inner, big bool) (st, en lex.Pos, ok bool) {
+	ln := txt.Line(pos.Ln)
+	if len(ln) == 0 {
+		return pos, pos, false
+	}
+	ch := min(pos.Ch, len(ln)-1)
+	run := func(from int) (int, int) {
+		c := charClass(ln[from], big)
+		s, e := from, from+1
+		for s > 0 && charClass(ln[s-1], big) == c {
+			s--
+		}
+		for e < len(ln) && charClass(ln[e], big) == c {
+			e++
+		}
+		return s, e
+	}
+	s, e := run(ch)
+	if !inner {
+		switch {
+		case charClass(ln[ch], big) == 0 && e < len(ln):
+			_, e = run(e) // space then the following word
+		case e < len(ln) && charClass(ln[e], big) == 0:
+			_, e = run(e) // word then the trailing space
+		case s > 0 && charClass(ln[s-1], big) == 0:
+			s, _ = run(s - 1) // else the leading space
+		}
+	}
+	return lex.Pos{Ln: pos.Ln, Ch: s}, lex.Pos{Ln: pos.Ln, Ch: e}, true
+}
+
+// quoteObject returns the quoted string on the line of pos that contains
+// pos, or else the first one after it, without the quotes if inner
+// (i", a", etc), or with the quotes and any trailing space if not.
+func quoteObject(txt Text, pos lex.Pos, inner bool, q rune) (st, en lex.Pos, ok bool) {
+	ln := txt.Line(pos.Ln)
+	var qs []int
+	for i, r := range ln {
+		if r == q && (i == 0 || ln[i-1] != '\\') {
+			qs = append(qs, i)
+		}
+	}
+	for i := 0; i+1 < len(qs); i += 2 {
+		s, e := qs[i], qs[i+1]
+		if pos.Ch > e {
+			continue
+		}
+		if inner {
+			s++
+		} else {
+			e++
+			for e < len(ln) && unicode.IsSpace(ln[e]) {
+				e++
+			}
+		}
+		return lex.Pos{Ln: pos.Ln, Ch: s}, lex.Pos{Ln: pos.Ln, Ch: e}, true
+	}
+	return pos, pos, false
+}
+
+// bracketObject returns the bracketed block that contains pos, without
+// the brackets if inner (i(, i{ etc), or with them if not.  If the inner
+// text starts after a line break, or ends on a line with only space
+// before the close bracket, those line breaks and spaces are excluded.
+func bracketObject(txt Text, pos lex.Pos, inner bool, op, cl rune) (st, en lex.Pos, ok bool) {
+	s := &scanner{txt, pos}
+	ob := pos
+	ok = s.at() == op
+	if !ok {
+		ob, ok = findOpen(txt, pos, op, cl)
+	}
+	if !ok {
+		return pos, pos, false
+	}
+	cb, ok := findClose(txt, ob, op, cl)
+	if !ok {
+		return pos, pos, false
+	}
+	if !inner {
+		return ob, lex.Pos{Ln: cb.Ln, Ch: cb.Ch + 1}, true
+	}
+	st = lex.Pos{Ln: ob.Ln, Ch: ob.Ch + 1}
+	if st.Ch == len(txt.Line(st.Ln)) && st.Ln < cb.Ln {
+		st = lex.Pos{Ln: st.Ln + 1}
+	}
+	en = cb
+	if en.Ln > st.Ln && isBlankLine(txt.Line(en.Ln)[:en.Ch]) {
+		en.Ch = 0
+	}
+	return st, en, true
+}
+
+// paraObject returns the paragraph (run of non-blank or blank lines)
+// containing pos, with the following blank lines if not inner (ip, ap).
+// The range is whole lines.
+func paraObject(txt Text, pos lex.Pos, inner bool) (st, en lex.Pos, ok bool) {
+	nl := txt.NumLines()
+	blank := isBlankLine(txt.Line(pos.Ln))
+	s, e := pos.Ln, pos.Ln
+	for s > 0 && isBlankLine(txt.Line(s-1)) == blank {
+		s--
+	}
+	for e+1 < nl && isBlankLine(txt.Line(e+1)) == blank {
+		e++
+	}
+	if !inner {
+		for e+1 < nl && isBlankLine(txt.Line(e+1)) != blank {
+			e++
+		}
+	}
+	st = lex.Pos{Ln: s}
+	if e+1 < nl {
+		en = lex.Pos{Ln: e + 1}
+	} else {
+		en = lex.Pos{Ln: e, Ch: len(txt.Line(e))}
+	}
+	return st, en, true
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vim provides vim-style modal editing for a texteditor.Editor,
+// as a texteditor.KeyHandler that is turned on per editor:
+//
+//	ed.SetKeyHandler(vim.New())
+//
+// It has normal, insert, visual, visual line and visual block modes,
+// operators with motions, text objects and counts, registers, repeat
+// with ., and a : command line with :s substitution, along with / and ?
+// search.  Patterns are Go regular expressions, not vim ones.
+package vim
+
+//go:generate goki generate
+
+import (
+	"strings"
+	"unicode"
+
+	"goki.dev/gi/v2/texteditor"
+	"goki.dev/goosi/events"
+	"goki.dev/goosi/events/key"
+	"goki.dev/pi/v2/lex"
+)
+
+// Modes are the editing modes of a Vim
+type Modes int32 //enums:enum
+
+const (
+	// Normal mode is for moving around and operating on the text
+	Normal Modes = iota
+
+	// Insert mode is for typing text, using the standard editor keys
+	Insert
+
+	// Visual mode selects a range of chars
+	Visual
+
+	// VisualLine mode selects a range of whole lines
+	VisualLine
+
+	// VisualBlock mode selects a rectangular block
+	VisualBlock
+
+	// CmdLine mode is for typing a : command line, or a / or ? search
+	CmdLine
+)
+
+// modeStatus is the status shown for each mode
+var modeStatus = map[Modes]string{
+	Normal:      "",
+	Insert:      "-- INSERT --",
+	Visual:      "-- VISUAL --",
+	VisualLine:  "-- VISUAL LINE --",
+	VisualBlock: "-- VISUAL BLOCK --",
+}
+
+// Vim is a texteditor.KeyHandler for vim-style modal editing.
+// Each Editor needs its own Vim, as it keeps the state of the mode,
+// but the Registers are shared by default.
+type Vim struct {
+
+	// current mode
+	Mode Modes
+
+	// registers for yanked and deleted text -- the DefaultRegisters by default
+	Registers Registers
+
+	// text of the command line in CmdLine mode, starting with : / or ?
+	CmdLine string
+
+	// message from the last command, e.g., an error, which is cleared on the next key
+	Message string
+
+	// function called when the Mode, CmdLine, Message or pending keys
+	// change, e.g., to show the Status in a status bar
+	OnChange func(v *Vim)
+
+	// keys of the command being typed
+	pending []string
+
+	// start (anchor) of the visual selection, with the cursor at the other end
+	visStart lex.Pos
+
+	// mode and ends of the last visual selection, for gv and '< '>
+	lastVisMode        Modes
+	lastVis, lastVisEn lex.Pos
+
+	// the last f F t T command, for ; and ,
+	lastFind command
+
+	// the last search pattern, and whether it was backward (?)
+	search     string
+	searchBack bool
+
+	// the last :s argument, for :&
+	lastSubst string
+
+	// the last change, for .
+	lastChange *change
+
+	// the change being made in insert mode
+	ins *change
+
+	// where insert mode started, and whether the cursor was moved
+	// during it, so the inserted text is not known
+	insStart  lex.Pos
+	insBroken bool
+
+	// undo stack position at the start of the current change
+	undoPos int
+
+	// true while repeating the last change with .
+	repeating bool
+}
+
+// change is a change that can be repeated with .
+type change struct {
+
+	// the command that made the change
+	cmd command
+
+	// text typed in insert mode after the command, if any
+	text string
+}
+
+// New returns a new Vim in Normal mode
+func New() *Vim {
+	return &Vim{Registers: DefaultRegisters}
+}
+
+// Status returns the status of the Vim, for a status bar: the command
+// line in CmdLine mode, else any Message, else the mode and the keys of
+// a command being typed.
+func (v *Vim) Status() string {
+	switch {
+	case v.Mode == CmdLine:
+		return v.CmdLine
+	case v.Message != "":
+		return v.Message
+	}
+	st := modeStatus[v.Mode]
+	if len(v.pending) > 0 {
+		st += "  " + strings.Join(v.pending, "")
+	}
+	return st
+}
+
+// changed calls the OnChange function
+func (v *Vim) changed() {
+	if v.OnChange != nil {
+		v.OnChange(v)
+	}
+}
+
+// isVisual returns true if in one of the visual modes
+func (v *Vim) isVisual() bool {
+	return v.Mode == Visual || v.Mode == VisualLine || v.Mode == VisualBlock
+}
+
+// keyName returns the name of the key for given key event: the char for
+// a printable key without Control or Meta, else the key chord
+func keyName(e events.Event) string {
+	r := e.KeyRune()
+	if unicode.IsPrint(r) && !e.HasAnyModifier(key.Control, key.Meta, key.Alt) {
+		return string(r)
+	}
+	return string(e.KeyChord())
+}
+
+// insertKeysBreak are the keys that move the cursor in insert mode,
+// after which the inserted text can not be repeated
+var insertKeysBreak = map[string]bool{
+	"UpArrow": true, "DownArrow": true, "LeftArrow": true, "RightArrow": true,
+	"Home": true, "End": true, "PageUp": true, "PageDown": true,
+}
+
+// HandleKey handles given key event for given editor, as a texteditor.KeyHandler.
+// In Insert mode, all keys except Escape go on to the standard editor keys,
+// as do keys with Control or Meta that are not vim commands in other modes.
+func (v *Vim) HandleKey(ed *texteditor.Editor, e events.Event) {
+	if ed.Buf == nil {
+		return
+	}
+	k := keyName(e)
+	switch {
+	case v.Mode == Insert:
+		switch {
+		case k == "Escape" || k == "Control+[" || k == "Control+C":
+			e.SetHandled()
+			v.endInsert(ed)
+		case insertKeysBreak[k]:
+			v.insBroken = true
+		}
+		return
+	case v.Mode == CmdLine:
+		e.SetHandled()
+		v.cmdLineKey(ed, k)
+		return
+	case e.HasAnyModifier(key.Control, key.Meta) && !actions[k] && !visualActions[k]:
+		return // other shortcuts are handled by the editor
+	}
+	e.SetHandled()
+	v.Message = ""
+	if k == "Escape" {
+		if len(v.pending) == 0 && v.isVisual() {
+			v.exitVisual(ed)
+		}
+		v.pending = nil
+		v.changed()
+		return
+	}
+	v.pending = append(v.pending, k)
+	c, st := parse(v.pending, v.isVisual())
+	if st == parseMore {
+		v.changed()
+		return
+	}
+	v.pending = nil
+	if st == parseDone {
+		v.exec(ed, &c)
+	}
+	v.changed()
+}
+
+// setMode sets the mode
+func (v *Vim) setMode(md Modes) {
+	v.Mode = md
+	v.changed()
+}
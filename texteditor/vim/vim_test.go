@@ -0,0 +1,255 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"strings"
+	"testing"
+
+	"goki.dev/pi/v2/lex"
+)
+
+// lines is a Text for testing
+type lines []string
+
+func (ls lines) NumLines() int      { return len(ls) }
+func (ls lines) Line(ln int) []rune { return []rune(ls[ln]) }
+
+func pos(ln, ch int) lex.Pos { return lex.Pos{Ln: ln, Ch: ch} }
+
+func keys(s string) []string {
+	ks := make([]string, 0, len(s))
+	for _, r := range s {
+		ks = append(ks, string(r))
+	}
+	return ks
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		keys   string
+		visual bool
+		want   command
+		st     parseStatus
+	}{
+		{"w", false, command{cmd: "w"}, parseDone},
+		{"3w", false, command{count: 3, cmd: "w"}, parseDone},
+		{"2d3w", false, command{count: 6, op: "d", cmd: "w"}, parseDone},
+		{"dd", false, command{op: "d", cmd: "_"}, parseDone},
+		{`"a5yy`, false, command{count: 5, reg: 'a', op: "y", cmd: "_"}, parseDone},
+		{"gUU", false, command{op: "gU", cmd: "_"}, parseDone},
+		{"gUgU", false, command{op: "gU", cmd: "_"}, parseDone},
+		{"gUgg", false, command{op: "gU", cmd: "gg"}, parseDone},
+		{"ci(", false, command{op: "c", cmd: "i("}, parseDone},
+		{"dtx", false, command{op: "d", cmd: "t", arg: 'x'}, parseDone},
+		{"rz", false, command{cmd: "r", arg: 'z'}, parseDone},
+		{"0", false, command{cmd: "0"}, parseDone},
+		{"10G", false, command{count: 10, cmd: "G"}, parseDone},
+		{"d", false, command{op: "d"}, parseMore},
+		{"g", false, command{}, parseMore},
+		{"f", false, command{cmd: "f"}, parseMore},
+		{"dq", false, command{op: "d"}, parseFail},
+		{"Q", false, command{}, parseFail},
+		{"iw", true, command{cmd: "iw"}, parseDone},
+		{"d", true, command{cmd: "d"}, parseDone},
+		{"i", false, command{cmd: "i"}, parseDone},
+	}
+	for _, tt := range tests {
+		c, st := parse(keys(tt.keys), tt.visual)
+		if st != tt.st || (st != parseMore && st != parseFail && c != tt.want) {
+			t.Errorf("parse(%q): got %+v, %v, want %+v, %v", tt.keys, c, st, tt.want, tt.st)
+		}
+	}
+	c, st := parse([]string{"d", "DownArrow"}, false)
+	if st != parseDone || c.cmd != "j" {
+		t.Errorf("parse(d DownArrow): got %+v, %v", c, st)
+	}
+}
+
+func TestMotion(t *testing.T) {
+	txt := lines{
+		"func main() {",
+		"\tfoo.bar(x, y)",
+		"",
+		"\treturn",
+		"}",
+	}
+	tests := []struct {
+		cmd  command
+		pos  lex.Pos
+		want lex.Pos
+	}{
+		{command{cmd: "w"}, pos(0, 0), pos(0, 5)},
+		{command{cmd: "w"}, pos(0, 5), pos(0, 9)},
+		{command{cmd: "W"}, pos(0, 5), pos(0, 12)},
+		{command{cmd: "w"}, pos(0, 12), pos(1, 1)},
+		{command{cmd: "w"}, pos(1, 13), pos(2, 0)},
+		{command{cmd: "e"}, pos(0, 0), pos(0, 3)},
+		{command{cmd: "b"}, pos(1, 1), pos(0, 12)},
+		{command{cmd: "B", count: 2}, pos(1, 8), pos(0, 12)},
+		{command{cmd: "ge"}, pos(1, 5), pos(1, 4)},
+		{command{cmd: "$"}, pos(1, 0), pos(1, 13)},
+		{command{cmd: "^"}, pos(1, 6), pos(1, 1)},
+		{command{cmd: "G"}, pos(0, 3), pos(4, 0)},
+		{command{cmd: "gg", count: 4}, pos(0, 3), pos(3, 1)},
+		{command{cmd: "f", arg: ','}, pos(1, 0), pos(1, 10)},
+		{command{cmd: "t", arg: ')'}, pos(1, 0), pos(1, 12)},
+		{command{cmd: "F", arg: 'f'}, pos(1, 10), pos(1, 1)},
+		{command{cmd: "%"}, pos(0, 12), pos(4, 0)},
+		{command{cmd: "%"}, pos(1, 8), pos(1, 13)},
+		{command{cmd: "}"}, pos(0, 3), pos(2, 0)},
+		{command{cmd: "{"}, pos(4, 0), pos(2, 0)},
+		{command{cmd: "j", count: 3}, pos(0, 5), pos(3, 5)},
+	}
+	for _, tt := range tests {
+		got, _, ok := motion(txt, tt.pos, tt.pos.Ch, &tt.cmd)
+		if !ok || got != tt.want {
+			t.Errorf("%+v from %v: got %v, %v, want %v", tt.cmd, tt.pos, got, ok, tt.want)
+		}
+	}
+	if _, _, ok := motion(txt, pos(1, 0), 0, &command{cmd: "f", arg: 'q'}); ok {
+		t.Error("fq should fail")
+	}
+}
+
+func TestMotionRange(t *testing.T) {
+	txt := lines{"one two", "  three", "four"}
+	tests := []struct {
+		pos, to lex.Pos
+		kind    motionKind
+		st, en  lex.Pos
+		knd     motionKind
+	}{
+		{pos(0, 0), pos(0, 4), exclusive, pos(0, 0), pos(0, 4), exclusive},
+		{pos(0, 4), pos(0, 0), exclusive, pos(0, 0), pos(0, 4), exclusive},
+		{pos(0, 0), pos(0, 2), inclusive, pos(0, 0), pos(0, 3), inclusive},
+		{pos(0, 4), pos(1, 0), exclusive, pos(0, 4), pos(0, 7), exclusive},
+		{pos(0, 0), pos(2, 0), exclusive, pos(0, 0), pos(2, 0), linewise},
+		{pos(1, 3), pos(0, 2), linewise, pos(0, 0), pos(2, 0), linewise},
+		{pos(1, 3), pos(2, 1), linewise, pos(1, 0), pos(2, 4), linewise},
+	}
+	for _, tt := range tests {
+		st, en, knd := motionRange(txt, tt.pos, tt.to, tt.kind)
+		if st != tt.st || en != tt.en || knd != tt.knd {
+			t.Errorf("%v to %v: got %v %v %v, want %v %v %v", tt.pos, tt.to, st, en, knd, tt.st, tt.en, tt.knd)
+		}
+	}
+}
+
+func TestTextObject(t *testing.T) {
+	txt := lines{
+		`if f(a, "b c") {`,
+		"\tx := [2]int{1, 2}",
+		"}",
+		"",
+		"next para",
+	}
+	tests := []struct {
+		obj    string
+		pos    lex.Pos
+		st, en lex.Pos
+	}{
+		{"iw", pos(0, 0), pos(0, 0), pos(0, 2)},
+		{"aw", pos(0, 0), pos(0, 0), pos(0, 3)},
+		{"i(", pos(0, 6), pos(0, 5), pos(0, 13)},
+		{"ab", pos(0, 6), pos(0, 4), pos(0, 14)},
+		{`i"`, pos(0, 10), pos(0, 9), pos(0, 12)},
+		{`a"`, pos(0, 10), pos(0, 8), pos(0, 13)},
+		{"i[", pos(1, 7), pos(1, 7), pos(1, 8)},
+		{"i{", pos(1, 14), pos(1, 13), pos(1, 17)},
+		{"i{", pos(1, 2), pos(1, 0), pos(2, 0)},
+		{"a{", pos(1, 2), pos(0, 15), pos(2, 1)},
+	}
+	for _, tt := range tests {
+		st, en, _, ok := textObject(txt, tt.pos, tt.obj)
+		if !ok || st != tt.st || en != tt.en {
+			t.Errorf("%s at %v: got %v %v %v, want %v %v", tt.obj, tt.pos, st, en, ok, tt.st, tt.en)
+		}
+	}
+	st, en, ln, ok := textObject(txt, pos(1, 3), "ip")
+	if !ok || !ln || st != pos(0, 0) || en != pos(3, 0) {
+		t.Errorf("ip: got %v %v %v %v", st, en, ln, ok)
+	}
+	if _, _, _, ok := textObject(txt, pos(4, 0), "i("); ok {
+		t.Error("i( outside of parens should fail")
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	rs := Registers{}
+	rs.Set(0, Register{Text: "yanked"}, true)
+	if rs.Get(0).Text != "yanked" || rs.Get('0').Text != "yanked" {
+		t.Errorf("yank: %+v", rs)
+	}
+	rs.Set(0, Register{Text: "line1\n", Linewise: true}, false)
+	rs.Set(0, Register{Text: "line2\n", Linewise: true}, false)
+	if rs.Get('1').Text != "line2\n" || rs.Get('2').Text != "line1\n" {
+		t.Errorf("numbered: %q %q", rs.Get('1').Text, rs.Get('2').Text)
+	}
+	rs.Set(0, Register{Text: "w"}, false)
+	if rs.Get('-').Text != "w" || rs.Get('0').Text != "yanked" {
+		t.Errorf("small delete: %+v", rs)
+	}
+	rs.Set('a', Register{Text: "a"}, true)
+	rs.Set('A', Register{Text: "b"}, true)
+	if rs.Get('a').Text != "ab" || rs.Get(0).Text != "ab" {
+		t.Errorf("append: %q", rs.Get('a').Text)
+	}
+	rs.Set('_', Register{Text: "gone"}, false)
+	if rs.Get(0).Text != "ab" {
+		t.Errorf("black hole: %q", rs.Get(0).Text)
+	}
+}
+
+func TestParseEx(t *testing.T) {
+	mark := func(r rune) (int, bool) {
+		if r == '<' {
+			return 2, true
+		}
+		return 0, false
+	}
+	tests := []struct {
+		s    string
+		want exCmd
+	}{
+		{"8", exCmd{start: 7, end: 7, hasRange: true}},
+		{"%s/a/b/g", exCmd{start: 0, end: 9, hasRange: true, name: "substitute", arg: "/a/b/g"}},
+		{".,+2d", exCmd{start: 4, end: 6, hasRange: true, name: "delete"}},
+		{"'<,$>", exCmd{start: 2, end: 9, hasRange: true, name: ">"}},
+		{"w! out.txt", exCmd{start: 4, end: 4, name: "write", bang: true, arg: "out.txt"}},
+		{"noh", exCmd{start: 4, end: 4, name: "nohlsearch"}},
+		{"$-1,.j", exCmd{start: 4, end: 8, hasRange: true, name: "join"}},
+	}
+	for _, tt := range tests {
+		ec, err := parseEx(tt.s, 4, 10, mark)
+		if err != nil || ec != tt.want {
+			t.Errorf("parseEx(%q): got %+v, %v, want %+v", tt.s, ec, err, tt.want)
+		}
+	}
+	for _, s := range []string{"20d", "'xd", "bogus"} {
+		if _, err := parseEx(s, 4, 10, mark); err == nil {
+			t.Errorf("parseEx(%q): expected an error", s)
+		}
+	}
+}
+
+func TestParseSubst(t *testing.T) {
+	pat, repl, flags, err := parseSubst(`/(\w+)\/x/[\1] & $1\n/gi`)
+	if err != nil || pat != `(\w+)/x` || repl != "[${1}] ${0} $$1\n" || flags != "gi" {
+		t.Errorf("got %q %q %q %v", pat, repl, flags, err)
+	}
+	pat, repl, flags, err = parseSubst("#a#b")
+	if err != nil || pat != "a" || repl != "b" || flags != "" {
+		t.Errorf("got %q %q %q %v", pat, repl, flags, err)
+	}
+	for _, arg := range []string{"", "xaxbx", "//b/"} {
+		if _, _, _, err := parseSubst(arg); err == nil {
+			t.Errorf("parseSubst(%q): expected an error", arg)
+		}
+	}
+	if got := substTemplate(strings.Repeat(`\t`, 2)); got != "\t\t" {
+		t.Errorf("substTemplate: got %q", got)
+	}
+}
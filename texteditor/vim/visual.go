@@ -0,0 +1,322 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vim
+
+import (
+	"strings"
+
+	"goki.dev/gi/v2/texteditor"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/pi/v2/lex"
+)
+
+// visualModes are the visual modes for the commands that start them
+var visualModes = map[string]Modes{"v": Visual, "V": VisualLine, "Control+V": VisualBlock}
+
+// startVisual starts given visual mode at the cursor, or switches to it
+// from another visual mode, or ends visual mode if it is already in it
+func (v *Vim) startVisual(ed *texteditor.Editor, md Modes) {
+	if v.Mode == md {
+		v.exitVisual(ed)
+		return
+	}
+	if !v.isVisual() {
+		v.visStart = ed.CursorPos
+	}
+	v.startVisualFrom(ed, md)
+}
+
+// startVisualFrom starts given visual mode from the current visStart
+func (v *Vim) startVisualFrom(ed *texteditor.Editor, md Modes) {
+	v.setMode(md)
+	v.showVisual(ed)
+}
+
+// exitVisual ends visual mode, saving the selection for gv and '< '>
+func (v *Vim) exitVisual(ed *texteditor.Editor) {
+	v.lastVisMode = v.Mode
+	v.lastVis, v.lastVisEn = v.visStart, ed.CursorPos
+	ed.ClearCursors()
+	ed.SelectReset()
+	ed.SetNeedsRender()
+	v.setMode(Normal)
+	v.clampCursor(ed)
+}
+
+// markLine returns the line of given mark, for : command ranges,
+// which are only the '< and '> marks of the last visual selection
+func (v *Vim) markLine(r rune) (int, bool) {
+	if v.lastVisMode == Normal {
+		return 0, false
+	}
+	switch r {
+	case '<':
+		return min(v.lastVis.Ln, v.lastVisEn.Ln), true
+	case '>':
+		return max(v.lastVis.Ln, v.lastVisEn.Ln), true
+	}
+	return 0, false
+}
+
+// lineRange returns the range of the whole lines from line st to last
+func lineRange(ed *texteditor.Editor, st, last int) (lex.Pos, lex.Pos) {
+	s, e, _ := motionRange(ed.Buf, lex.Pos{Ln: st}, lex.Pos{Ln: last}, linewise)
+	return s, e
+}
+
+// visualRange returns the range of the visual selection, with the end
+// exclusive, which is whole lines in VisualLine mode, and the upper left
+// and lower right corners of the block in VisualBlock mode.
+func (v *Vim) visualRange(ed *texteditor.Editor) (st, en lex.Pos) {
+	st, en = v.visStart, ed.CursorPos
+	if en.IsLess(st) {
+		st, en = en, st
+	}
+	switch v.Mode {
+	case VisualLine:
+		return lineRange(ed, st.Ln, en.Ln)
+	case VisualBlock:
+		c1 := min(v.visStart.Ch, ed.CursorPos.Ch)
+		c2 := max(v.visStart.Ch, ed.CursorPos.Ch) + 1
+		return lex.Pos{Ln: st.Ln, Ch: c1}, lex.Pos{Ln: en.Ln, Ch: c2}
+	}
+	en.Ch = min(en.Ch+1, ed.Buf.LineLen(en.Ln))
+	return st, en
+}
+
+// showVisual shows the visual selection as the editor selection, with a
+// cursor and selection for each line in VisualBlock mode
+func (v *Vim) showVisual(ed *texteditor.Editor) {
+	st, en := v.visualRange(ed)
+	ed.Cursors = nil
+	if v.Mode == VisualBlock {
+		var cs []texteditor.MultiCursor
+		for ln := st.Ln; ln <= en.Ln; ln++ {
+			ll := ed.Buf.LineLen(ln)
+			s := lex.Pos{Ln: ln, Ch: min(st.Ch, ll)}
+			e := lex.Pos{Ln: ln, Ch: min(en.Ch, ll)}
+			mc := texteditor.MultiCursor{Pos: s, Select: textbuf.NewRegionPos(s, e)}
+			if ln == ed.CursorPos.Ln {
+				ed.SelectReg = mc.Select
+				continue
+			}
+			cs = append(cs, mc)
+		}
+		ed.Cursors = cs
+	} else {
+		ed.SelectReg = textbuf.NewRegionPos(st, en)
+	}
+	ed.SelectStart = ed.SelectReg.Start
+	ed.SetNeedsRender()
+}
+
+// visualCmd executes given command in one of the visual modes
+func (v *Vim) visualCmd(ed *texteditor.Editor, c *command) {
+	if motions[c.cmd] {
+		v.move(ed, c)
+		return
+	}
+	if len(c.cmd) == 2 && (c.cmd[0] == 'i' || c.cmd[0] == 'a') {
+		st, en, lines, ok := textObject(ed.Buf, ed.CursorPos, c.cmd)
+		if !ok {
+			return
+		}
+		v.visStart = st
+		if en.Ch > 0 {
+			en.Ch--
+		} else if en.Ln > st.Ln {
+			en = lex.Pos{Ln: en.Ln - 1, Ch: lastCh(line(ed, en.Ln-1))}
+		}
+		ed.SetCursorShow(en)
+		if lines && v.Mode == Visual {
+			v.setMode(VisualLine)
+		}
+		v.showVisual(ed)
+		return
+	}
+	switch c.cmd {
+	case "o", "O":
+		pos := ed.CursorPos
+		ed.SetCursorShow(v.visStart)
+		v.visStart = pos
+		v.showVisual(ed)
+		return
+	case "v", "V", "Control+V":
+		v.startVisual(ed, visualModes[c.cmd])
+		return
+	case ":":
+		v.exitVisual(ed)
+		v.startCmdLine(":'<,'>")
+		return
+	}
+	if c.cmd != "y" && c.cmd != "Y" && ed.IsReadOnly() {
+		v.Message = "the text is read only"
+		return
+	}
+
+	md := v.Mode
+	st, en := v.visualRange(ed)
+	block := md == VisualBlock
+	lines := md == VisualLine
+	switch c.cmd {
+	case "X", "D", "Y", "C", "S":
+		if block && (c.cmd == "D" || c.cmd == "C") { // to the end of the lines
+			for ln := st.Ln; ln <= en.Ln; ln++ {
+				en.Ch = max(en.Ch, ed.Buf.LineLen(ln))
+			}
+			break
+		}
+		st, en = lineRange(ed, st.Ln, en.Ln)
+		block, lines = false, true
+	}
+	last := en.Ln
+	if lines || !block {
+		last = lastLine(st, en)
+	}
+	v.exitVisual(ed)
+	ed.SetCursorShow(st)
+
+	switch c.cmd {
+	case "d", "x", "X", "D":
+		if block {
+			v.yankBlock(ed, c.reg, st, en, false)
+			ed.Buf.DeleteTextRect(st, en, texteditor.EditSignal)
+			ed.SetCursorShow(st)
+		} else {
+			v.apply(ed, &command{op: "d", reg: c.reg}, st, en, lines)
+		}
+	case "y", "Y":
+		if block {
+			v.yankBlock(ed, c.reg, st, en, true)
+			ed.SetCursorShow(st)
+		} else {
+			v.apply(ed, &command{op: "y", reg: c.reg}, st, en, lines)
+		}
+	case "c", "s", "C", "S":
+		if block {
+			v.yankBlock(ed, c.reg, st, en, false)
+			ed.Buf.DeleteTextRect(st, en, texteditor.EditSignal)
+			v.blockInsert(ed, st.Ln, en.Ln, st.Ch, false)
+		} else {
+			v.apply(ed, &command{op: "c", reg: c.reg}, st, en, lines)
+		}
+	case "I", "A":
+		switch {
+		case block:
+			col := st.Ch
+			if c.cmd == "A" {
+				col = en.Ch
+			}
+			v.blockInsert(ed, st.Ln, en.Ln, col, c.cmd == "A")
+		case c.cmd == "A":
+			ed.SetCursorShow(en)
+			v.startInsert(ed, &command{cmd: "i"})
+		default:
+			v.startInsert(ed, &command{cmd: "i"})
+		}
+	case "r":
+		v.eachRange(ed, st, en, block, func(s, e lex.Pos) {
+			tbe := ed.Buf.Region(s, e)
+			if tbe == nil {
+				return
+			}
+			txt := strings.Map(func(r rune) rune {
+				if r == '\n' {
+					return r
+				}
+				return c.arg
+			}, string(tbe.ToBytes()))
+			ed.Buf.ReplaceText(s, e, s, txt, texteditor.EditSignal, false)
+		})
+		ed.SetCursorShow(st)
+	case "J", "gJ":
+		join(ed, st.Ln, max(last, st.Ln+1), c.cmd == "J")
+	case "~", "u", "U":
+		op := map[string]string{"~": "g~", "u": "gu", "U": "gU"}[c.cmd]
+		v.eachRange(ed, st, en, block, func(s, e lex.Pos) { recase(ed, s, e, op) })
+		ed.SetCursorShow(st)
+	case "<", ">":
+		v.shift(ed, st.Ln, last, c.n(), c.cmd == ">")
+		ed.SetCursorShow(lex.Pos{Ln: st.Ln, Ch: firstNonBlank(line(ed, st.Ln))})
+	case "=":
+		ed.Buf.AutoIndentRegion(st.Ln, last+1)
+	case "p", "P":
+		rg := v.getReg(ed, c.reg)
+		if rg == nil {
+			v.Message = "nothing in register"
+			return
+		}
+		prg := *rg // the delete can replace it
+		dreg := rune(0)
+		if c.cmd == "P" {
+			dreg = '_'
+		}
+		if block {
+			v.yankBlock(ed, dreg, st, en, false)
+			ed.Buf.DeleteTextRect(st, en, texteditor.EditSignal)
+		} else {
+			v.delete(ed, dreg, st, en, lines)
+		}
+		before := true
+		if lines && st.Ln >= ed.Buf.NumLines()-1 && en.Ch > 0 {
+			before = false
+		}
+		ed.SetCursorShow(lex.Pos{Ln: min(st.Ln, ed.Buf.NumLines()-1), Ch: st.Ch})
+		v.putReg(ed, &prg, c.n(), before)
+	}
+}
+
+// eachRange calls given function for given range, or for the range
+// on each line of a block
+func (v *Vim) eachRange(ed *texteditor.Editor, st, en lex.Pos, block bool, fun func(s, e lex.Pos)) {
+	if !block {
+		fun(st, en)
+		return
+	}
+	for ln := st.Ln; ln <= en.Ln; ln++ {
+		ll := ed.Buf.LineLen(ln)
+		s := lex.Pos{Ln: ln, Ch: min(st.Ch, ll)}
+		e := lex.Pos{Ln: ln, Ch: min(en.Ch, ll)}
+		if s.IsLess(e) {
+			fun(s, e)
+		}
+	}
+}
+
+// yankBlock puts the block with given corners into given register
+func (v *Vim) yankBlock(ed *texteditor.Editor, reg rune, st, en lex.Pos, yank bool) {
+	tbe := ed.Buf.RegionRect(st, en)
+	if tbe == nil {
+		return
+	}
+	lns := make([]string, len(tbe.Text))
+	for i, l := range tbe.Text {
+		lns[i] = strings.TrimRight(string(l), " ")
+	}
+	v.setReg(ed, reg, Register{Text: strings.Join(lns, "\n"), Block: true}, yank)
+}
+
+// blockInsert starts insert mode with a cursor at given column on each
+// of the lines from st to last, so the typed text is inserted on each.
+// Lines that are too short are skipped, or padded with spaces if pad.
+func (v *Vim) blockInsert(ed *texteditor.Editor, st, last, col int, pad bool) {
+	var cs []texteditor.MultiCursor
+	for ln := st; ln <= last; ln++ {
+		ll := ed.Buf.LineLen(ln)
+		if ll < col {
+			if !pad {
+				continue
+			}
+			ed.Buf.InsertText(lex.Pos{Ln: ln, Ch: ll}, []byte(strings.Repeat(" ", col-ll)), texteditor.EditSignal)
+		}
+		cs = append(cs, texteditor.MultiCursor{Pos: lex.Pos{Ln: ln, Ch: col}, Select: textbuf.RegionNil})
+	}
+	if len(cs) == 0 {
+		return
+	}
+	ed.SetAllCursors(cs)
+	ed.SetCursorShow(cs[0].Pos)
+	v.startInsert(ed, &command{cmd: "i"})
+}
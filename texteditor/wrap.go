@@ -0,0 +1,215 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"unicode"
+
+	"goki.dev/colors"
+	"goki.dev/gi/v2/gi"
+	"goki.dev/gi/v2/texteditor/textbuf"
+	"goki.dev/girl/styles"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// WrapSize returns the size that lines are wrapped to: the LineLayoutSize,
+// limited to the WrapColumn if the WrapAtColumn preference is on
+func (ed *Editor) WrapSize() mat32.Vec2 {
+	sz := ed.LineLayoutSize
+	pf := &gi.Prefs.Editor
+	if pf.WordWrap && pf.WrapAtColumn && pf.WrapColumn > 0 {
+		sz.X = mat32.Min(sz.X, float32(pf.WrapColumn)*ed.Styles.Font.Face.Metrics.Ch)
+	}
+	return sz
+}
+
+// HangingIndent returns the number of leading whitespace characters of
+// given line, and their width in dots, by which its continuation lines
+// are indented when it is wrapped.  It is 0 if HangingIndent is off, or
+// if the indent would take more than half of the WrapSize.  It is based
+// on the Render of the line, so it does not need the Buf to be locked.
+func (ed *Editor) HangingIndent(ln int) (n int, wd float32) {
+	if !gi.Prefs.Editor.HangingIndent || ln >= len(ed.Renders) || len(ed.Renders[ln].Spans) == 0 {
+		return 0, 0
+	}
+	tabSz := max(ed.Styles.Text.TabSize, 1)
+	cols := 0
+	for _, r := range ed.Renders[ln].Spans[0].Text {
+		if !unicode.IsSpace(r) {
+			break
+		}
+		if r == '\t' {
+			cols += tabSz - cols%tabSz
+		} else {
+			cols++
+		}
+		n++
+	}
+	wd = float32(cols) * ed.Styles.Font.Face.Metrics.Ch
+	if wd > 0.5*ed.WrapSize().X {
+		return 0, 0
+	}
+	return n, wd
+}
+
+// LayoutLineRender sets the Render of given line from its markup, and lays
+// it out wrapped to given size, with the HangingIndent for its continuation
+// lines, for which all of it is wrapped to the size less the indent.
+// Must be called with the Buf MarkupMu locked.
+func (ed *Editor) LayoutLineRender(ln int, fst *styles.FontRender, sz mat32.Vec2) {
+	sty := &ed.Styles
+	tx := &ed.Renders[ln]
+	tx.SetHTMLPre(ed.Buf.Markup.At(ln), fst, &sty.Text, &sty.UnContext, ed.CSS)
+	tx.LayoutStdLR(&sty.Text, sty.FontRender(), &sty.UnContext, sz)
+	if len(tx.Spans) < 2 {
+		return
+	}
+	_, hw := ed.HangingIndent(ln)
+	if hw == 0 {
+		return
+	}
+	tx.SetHTMLPre(ed.Buf.Markup.At(ln), fst, &sty.Text, &sty.UnContext, ed.CSS)
+	tx.LayoutStdLR(&sty.Text, sty.FontRender(), &sty.UnContext, mat32.Vec2{sz.X - hw, sz.Y})
+	for si := 1; si < len(tx.Spans); si++ {
+		sr := &tx.Spans[si]
+		sr.RelPos.X += hw
+		sr.LastPos.X += hw
+	}
+	tx.Size.X += hw
+}
+
+// wrapSpanStart returns the index of the first rune of given wrapped line
+// (span) of given line
+func (ed *Editor) wrapSpanStart(ln, si int) int {
+	st := 0
+	for i := 0; i < si; i++ {
+		st += len(ed.Renders[ln].Spans[i].Render)
+	}
+	return st
+}
+
+// VisualLinePos returns the wrapped line (span index) of given position
+// within its line, and its column within that visual line, which includes
+// the HangingIndent of the continuation lines
+func (ed *Editor) VisualLinePos(pos lex.Pos) (si, col int) {
+	wln := ed.WrappedLines(pos.Ln)
+	if wln <= 1 || ed.IsLineHidden(pos.Ln) {
+		return 0, pos.Ch
+	}
+	si, col, ok := ed.Renders[pos.Ln].RuneSpanPos(pos.Ch)
+	if !ok { // end of line
+		si = wln - 1
+		col = pos.Ch - ed.wrapSpanStart(pos.Ln, si)
+	}
+	if si > 0 {
+		hn, _ := ed.HangingIndent(pos.Ln)
+		col += hn
+	}
+	return si, col
+}
+
+// VisualLineToPos returns the position on given wrapped line (span index)
+// of given line that is closest to given visual column (see VisualLinePos).
+// The end of a wrapped line that continues is the start of the next one,
+// so the cursor stays before its last character, which is a space.
+func (ed *Editor) VisualLineToPos(ln, si, col int) lex.Pos {
+	wln := ed.WrappedLines(ln)
+	if wln <= 1 || ed.IsLineHidden(ln) {
+		return lex.Pos{Ln: ln, Ch: min(col, ed.Buf.LineLen(ln))}
+	}
+	si = min(max(si, 0), wln-1)
+	if si > 0 {
+		hn, _ := ed.HangingIndent(ln)
+		col = max(col-hn, 0)
+	}
+	mx := len(ed.Renders[ln].Spans[si].Render)
+	if si < wln-1 {
+		mx = max(mx-1, 0)
+	}
+	return lex.Pos{Ln: ln, Ch: ed.wrapSpanStart(ln, si) + min(col, mx)}
+}
+
+// VisualLineDown returns the position on the visual line below that of
+// given position that is closest to given visual column: the next wrapped
+// line of the same line, or the first one of the next visible line.
+// It returns false if the position is on the last visual line.
+func (ed *Editor) VisualLineDown(pos lex.Pos, col int) (lex.Pos, bool) {
+	si, _ := ed.VisualLinePos(pos)
+	if si < ed.WrappedLines(pos.Ln)-1 {
+		return ed.VisualLineToPos(pos.Ln, si+1, col), true
+	}
+	if pos.Ln >= ed.NLines-1 {
+		return pos, false
+	}
+	ln := ed.VisibleLine(pos.Ln+1, 1)
+	return ed.VisualLineToPos(ln, 0, col), true
+}
+
+// VisualLineUp returns the position on the visual line above that of
+// given position that is closest to given visual column: the previous
+// wrapped line of the same line, or the last one of the previous visible
+// line.  It returns false if the position is on the first visual line.
+func (ed *Editor) VisualLineUp(pos lex.Pos, col int) (lex.Pos, bool) {
+	si, _ := ed.VisualLinePos(pos)
+	if si > 0 {
+		return ed.VisualLineToPos(pos.Ln, si-1, col), true
+	}
+	if pos.Ln <= 0 {
+		return pos, false
+	}
+	ln := ed.VisibleLine(pos.Ln-1, -1)
+	return ed.VisualLineToPos(ln, ed.WrappedLines(ln)-1, col), true
+}
+
+// RenderRulers renders a vertical line at each of the Rulers columns
+// -- always called within context of outer RenderAllLines
+func (ed *Editor) RenderRulers() {
+	rls := gi.Prefs.Editor.Rulers
+	if len(rls) == 0 {
+		return
+	}
+	rs := &ed.Sc.RenderState
+	pc := &rs.Paint
+	pos := ed.RenderStartPos()
+	ch := ed.Styles.Font.Face.Metrics.Ch
+	sy, ey := float32(ed.ScBBox.Min.Y), float32(ed.ScBBox.Max.Y)
+	pc.StrokeStyle.Width.Dots = 1
+	pc.StrokeStyle.SetColor(colors.Scheme.OutlineVariant)
+	for _, col := range rls {
+		x := mat32.Floor(pos.X+ed.LineNoOff+float32(col)*ch) + 0.5
+		if col <= 0 || x < float32(ed.ScBBox.Min.X)+ed.LineNoOff || x > float32(ed.ScBBox.Max.X) {
+			continue
+		}
+		pc.DrawLine(rs, x, sy, x, ey)
+	}
+	pc.Stroke(rs)
+}
+
+// ReflowPara hard wraps the paragraph at the cursor, or the paragraphs in
+// the selection, to the WrapColumn (see Buf.ReflowParaLines)
+func (ed *Editor) ReflowPara() {
+	if ed.Buf == nil {
+		return
+	}
+	stln, edln := ed.CursorPos.Ln, ed.CursorPos.Ln
+	if ed.HasSelection() {
+		stln, edln = ed.SelectReg.Start.Ln, ed.SelectReg.End.Ln
+		if ed.SelectReg.End.Ch == 0 && edln > stln {
+			edln--
+		}
+	} else {
+		comLn := ed.Buf.Opts.CommentLn
+		for stln > 0 && !textbuf.IsParaBreak(ed.Buf.Line(stln-1), comLn) {
+			stln--
+		}
+		for edln < ed.NLines-1 && !textbuf.IsParaBreak(ed.Buf.Line(edln+1), comLn) {
+			edln++
+		}
+	}
+	ed.Buf.ReflowParaLines(stln, edln, gi.Prefs.Editor.WrapColumn)
+	ed.SelectReset()
+	ed.SetCursorShow(ed.CursorPos)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texteditor
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"goki.dev/gi/v2/gi"
+	"goki.dev/girl/paint"
+	"goki.dev/girl/styles"
+	"goki.dev/mat32/v2"
+	"goki.dev/pi/v2/lex"
+)
+
+// wrapTestEditor returns an Editor with the given lines, each laid out
+// as the given wrapped lines (spans), without needing a window
+func wrapTestEditor(lines ...[]string) *Editor {
+	ed := &Editor{}
+	ed.Renders = make([]paint.Text, len(lines))
+	txt := make([]string, len(lines))
+	for ln, spans := range lines {
+		for _, s := range spans {
+			rs := []rune(s)
+			ed.Renders[ln].Spans = append(ed.Renders[ln].Spans, paint.Span{Text: rs, Render: make([]paint.Rune, len(rs))})
+		}
+		txt[ln] = strings.Join(spans, "")
+	}
+	ed.Buf = NewBuf()
+	ed.Buf.SetText([]byte(strings.Join(txt, "\n")))
+	ed.NLines = len(lines)
+	ed.Styles.Font.Face = &styles.FontFace{Metrics: styles.FontMetrics{Ch: 8}}
+	ed.LineLayoutSize = mat32.Vec2{X: 800, Y: 800}
+	return ed
+}
+
+func TestVisualLinePos(t *testing.T) {
+	defer func(hi bool) { gi.Prefs.Editor.HangingIndent = hi }(gi.Prefs.Editor.HangingIndent)
+	gi.Prefs.Editor.HangingIndent = true
+	ed := wrapTestEditor([]string{"aaa ", "bbb ", "cc"}, []string{"  xy ", "zw"}, []string{"q"})
+
+	for _, tt := range []struct {
+		pos     lex.Pos
+		si, col int
+	}{
+		{lex.Pos{Ln: 0, Ch: 0}, 0, 0},
+		{lex.Pos{Ln: 0, Ch: 5}, 1, 1},
+		{lex.Pos{Ln: 0, Ch: 8}, 2, 0},
+		{lex.Pos{Ln: 0, Ch: 10}, 2, 2}, // end of line
+		{lex.Pos{Ln: 1, Ch: 6}, 1, 3},  // with the hanging indent
+		{lex.Pos{Ln: 2, Ch: 1}, 0, 1},
+	} {
+		si, col := ed.VisualLinePos(tt.pos)
+		if si != tt.si || col != tt.col {
+			t.Errorf("VisualLinePos(%v) = %d, %d, want %d, %d", tt.pos, si, col, tt.si, tt.col)
+		}
+		if p := ed.VisualLineToPos(tt.pos.Ln, si, col); p != tt.pos {
+			t.Errorf("VisualLineToPos(%d, %d, %d) = %v, want %v", tt.pos.Ln, si, col, p, tt.pos)
+		}
+	}
+
+	for _, tt := range []struct {
+		ln, si, col int
+		want        lex.Pos
+	}{
+		{0, 1, 2, lex.Pos{Ln: 0, Ch: 6}},
+		{0, 1, 10, lex.Pos{Ln: 0, Ch: 7}}, // before the space that wraps
+		{0, 2, 10, lex.Pos{Ln: 0, Ch: 10}},
+		{0, 5, 0, lex.Pos{Ln: 0, Ch: 8}},
+		{1, 1, 0, lex.Pos{Ln: 1, Ch: 5}},
+		{2, 0, 5, lex.Pos{Ln: 2, Ch: 1}},
+	} {
+		if p := ed.VisualLineToPos(tt.ln, tt.si, tt.col); p != tt.want {
+			t.Errorf("VisualLineToPos(%d, %d, %d) = %v, want %v", tt.ln, tt.si, tt.col, p, tt.want)
+		}
+	}
+}
+
+func TestVisualLineUpDown(t *testing.T) {
+	defer func(hi bool) { gi.Prefs.Editor.HangingIndent = hi }(gi.Prefs.Editor.HangingIndent)
+	gi.Prefs.Editor.HangingIndent = true
+	ed := wrapTestEditor([]string{"aaa ", "bbb ", "cc"}, []string{"  xy ", "zw"}, []string{"q"})
+
+	down := []lex.Pos{{Ln: 0, Ch: 3}, {Ln: 0, Ch: 7}, {Ln: 0, Ch: 10}, {Ln: 1, Ch: 3}, {Ln: 1, Ch: 6}, {Ln: 2, Ch: 1}}
+	pos := lex.Pos{Ln: 0, Ch: 3}
+	for i, want := range down[1:] {
+		np, ok := ed.VisualLineDown(pos, 3)
+		if !ok || np != want {
+			t.Fatalf("VisualLineDown %d from %v = %v, %v, want %v", i, pos, np, ok, want)
+		}
+		pos = np
+	}
+	if _, ok := ed.VisualLineDown(pos, 3); ok {
+		t.Errorf("VisualLineDown from the last line should not move")
+	}
+	up := []lex.Pos{{Ln: 1, Ch: 6}, {Ln: 1, Ch: 3}, {Ln: 0, Ch: 10}, {Ln: 0, Ch: 7}, {Ln: 0, Ch: 3}}
+	for i, want := range up {
+		np, ok := ed.VisualLineUp(pos, 3)
+		if !ok || np != want {
+			t.Fatalf("VisualLineUp %d from %v = %v, %v, want %v", i, pos, np, ok, want)
+		}
+		pos = np
+	}
+	if _, ok := ed.VisualLineUp(pos, 3); ok {
+		t.Errorf("VisualLineUp from the first line should not move")
+	}
+
+	// home and end go to the start and end of the visual line
+	for _, tt := range []struct {
+		pos, home, end lex.Pos
+	}{
+		{lex.Pos{Ln: 0, Ch: 1}, lex.Pos{Ln: 0, Ch: 0}, lex.Pos{Ln: 0, Ch: 3}},
+		{lex.Pos{Ln: 0, Ch: 6}, lex.Pos{Ln: 0, Ch: 4}, lex.Pos{Ln: 0, Ch: 7}},
+		{lex.Pos{Ln: 0, Ch: 9}, lex.Pos{Ln: 0, Ch: 8}, lex.Pos{Ln: 0, Ch: 10}},
+		{lex.Pos{Ln: 1, Ch: 6}, lex.Pos{Ln: 1, Ch: 5}, lex.Pos{Ln: 1, Ch: 7}},
+		{lex.Pos{Ln: 2, Ch: 0}, lex.Pos{Ln: 2, Ch: 0}, lex.Pos{Ln: 2, Ch: 1}},
+	} {
+		si, _ := ed.VisualLinePos(tt.pos)
+		if p := ed.VisualLineToPos(tt.pos.Ln, si, 0); p != tt.home {
+			t.Errorf("home from %v = %v, want %v", tt.pos, p, tt.home)
+		}
+		if p := ed.VisualLineToPos(tt.pos.Ln, si, math.MaxInt); p != tt.end {
+			t.Errorf("end from %v = %v, want %v", tt.pos, p, tt.end)
+		}
+	}
+}